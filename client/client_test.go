@@ -0,0 +1,139 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rockpaperscissors/internal/api/openapi"
+	"rockpaperscissors/internal/api/routes"
+	"rockpaperscissors/internal/database"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const testAdminToken = "test-admin-token"
+
+func TestGeneratedClientIsCurrent(t *testing.T) {
+	want, err := openapi.GenerateClient(openapi.Spec(1), "client")
+	if err != nil {
+		t.Fatalf("Failed to generate client: %v", err)
+	}
+	got, err := os.ReadFile("client_gen.go")
+	if err != nil {
+		t.Fatalf("Failed to read client_gen.go: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("client_gen.go is out of date; run go generate ./client")
+	}
+}
+
+// setupTestServer serves the whole API over a fresh database
+func setupTestServer(t *testing.T) *httptest.Server {
+	// the HTML templates are loaded relative to the repository root
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(".."); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	t.Setenv("ADMIN_TOKEN", testAdminToken)
+	t.Setenv("AVATAR_DIR", t.TempDir())
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.RunMigrations(db.DB); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.SetupRoutes(router, db)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient(t *testing.T) {
+	server := setupTestServer(t)
+	ctx := context.Background()
+	c := New(server.URL+"/", "")
+
+	t.Run("Success - Sign up, play and read back", func(t *testing.T) {
+		user, err := c.CreateUser(ctx, CreateUserRequest{Username: "alice"})
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if user.AccountToken == "" || user.Username != "alice" {
+			t.Errorf("Expected alice with an account token, got %+v", user)
+		}
+
+		game, err := c.PlayGame(ctx, PlayGameRequest{Username: "alice", PlayerChoice: ChoiceRock})
+		if err != nil {
+			t.Fatalf("Failed to play: %v", err)
+		}
+		if game.PlayerChoice != ChoiceRock {
+			t.Errorf("Expected rock, got %s", game.PlayerChoice)
+		}
+
+		page, err := c.GetUserGames(ctx, "alice", &GetUserGamesParams{Limit: 10, Order: SortOrderAsc})
+		if err != nil {
+			t.Fatalf("Failed to get games: %v", err)
+		}
+		if page.TotalGames != 1 || len(page.Games) != 1 {
+			t.Errorf("Expected 1 game, got %d of %d", len(page.Games), page.TotalGames)
+		}
+	})
+
+	t.Run("Success - Tokens and downloads", func(t *testing.T) {
+		user, err := c.CreateUser(ctx, CreateUserRequest{Username: "bob"})
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+
+		owner := New(server.URL, user.AccountToken)
+		archive, err := owner.ExportAccountData(ctx, "bob")
+		if err != nil {
+			t.Fatalf("Failed to export account data: %v", err)
+		}
+		data, err := io.ReadAll(archive)
+		archive.Close()
+		if err != nil || !bytes.HasPrefix(data, []byte("PK")) {
+			t.Errorf("Expected a ZIP archive, got %d bytes (%v)", len(data), err)
+		}
+
+		admin := New(server.URL, testAdminToken)
+		tx, err := admin.AdjustCoins(ctx, "bob", AdjustCoinsRequest{Amount: 50, Reason: "welcome"}, &AdjustCoinsParams{XAdminActor: "tester"})
+		if err != nil {
+			t.Fatalf("Failed to adjust coins: %v", err)
+		}
+		if tx.Amount != 50 {
+			t.Errorf("Expected a credit of 50, got %d", tx.Amount)
+		}
+	})
+
+	t.Run("Error - Responses become *Error", func(t *testing.T) {
+		_, err := c.GetUser(ctx, "nobody")
+		var apiErr *Error
+		if !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+			t.Fatalf("Expected a 404 *Error, got %v", err)
+		}
+
+		_, err = c.GetAdminActions(ctx, nil)
+		if !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnauthorized {
+			t.Errorf("Expected a 401 *Error without the admin token, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,16 @@
+// Package client is a typed Go client for version 1 of the Rock Paper
+// Scissors HTTP API, served under /api/v1. The client, the request and
+// response types and a method for every operation are generated from the
+// server's OpenAPI document into client_gen.go; run go generate after
+// changing a route or a model. Version 2 is in client/v2.
+//
+//	c := client.New("http://localhost:8080", "")
+//	user, err := c.CreateUser(ctx, client.CreateUserRequest{Username: "alice"})
+//
+// Admin operations need the server's admin token. Operations on a player's
+// own data, such as changing their profile or timezone, exporting it or
+// deleting the account, need the player's account token; pass it to New or
+// set Token. Error responses are returned as *Error.
+package client
+
+//go:generate go run ../cmd/openapi -version 1 -client client_gen.go -package client
@@ -0,0 +1,2641 @@
+// Code generated by cmd/openapi; DO NOT EDIT.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client calls the API of one server
+type Client struct {
+	baseURL string
+
+	// Token is sent as a bearer token when set
+	Token string
+
+	// HTTPClient sends the requests
+	HTTPClient *http.Client
+}
+
+// New creates a client for the server at baseURL
+func New(baseURL, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Error is an error response from the server
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.Status)
+}
+
+// request is one call to the API
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   interface{}          // sent as JSON when set
+	files  map[string]io.Reader // sent as a multipart form when set
+}
+
+// do sends a request and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, r request, out interface{}) error {
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
+
+// download sends a request and returns the body of the response, which the
+// caller must close
+func (c *Client) download(ctx context.Context, r request) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// send sends a request, turning an error response into an *Error
+func (c *Client) send(ctx context.Context, r request) (*http.Response, error) {
+	var body io.Reader
+	contentType := ""
+	switch {
+	case r.files != nil:
+		var buf bytes.Buffer
+		form := multipart.NewWriter(&buf)
+		for name, file := range r.files {
+			part, err := form.CreateFormFile(name, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode request: %v", err)
+			}
+			if _, err := io.Copy(part, file); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %v", name, err)
+			}
+		}
+		if err := form.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encode request: %v", err)
+		}
+		body, contentType = &buf, form.FormDataContentType()
+	case r.body != nil:
+		data, err := json.Marshal(r.body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %v", err)
+		}
+		body, contentType = bytes.NewReader(data), "application/json"
+	}
+
+	target := c.baseURL + r.path
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	for name, values := range r.header {
+		req.Header[name] = values
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %v", c.baseURL, err)
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&failure) != nil || failure.Error == "" {
+			failure.Error = http.StatusText(resp.StatusCode)
+		}
+		return nil, &Error{Status: resp.StatusCode, Message: failure.Error}
+	}
+	return resp, nil
+}
+
+// AccountToken is the AccountToken schema
+type AccountToken struct {
+	Username     string `json:"username"`
+	AccountToken string `json:"account_token"`
+}
+
+// Achievements is the Achievements schema
+type Achievements struct {
+	Username      string            `json:"username"`
+	Achievements  []UserAchievement `json:"achievements"`
+	TotalUnlocked int               `json:"total_unlocked"`
+}
+
+// AdjustCoinsRequest is the AdjustCoinsRequest schema
+type AdjustCoinsRequest struct {
+	Amount int    `json:"amount"`
+	Reason string `json:"reason"`
+}
+
+// AdminAction is the AdminAction schema
+type AdminAction struct {
+	ID        int       `json:"id"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	UserID    *int      `json:"user_id,omitempty"`
+	Username  string    `json:"username"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AdminActions is the AdminActions schema
+type AdminActions struct {
+	Actions []AdminAction `json:"actions"`
+	Total   int           `json:"total"`
+	Limit   int           `json:"limit"`
+	Offset  int           `json:"offset"`
+}
+
+// AdminUserRecord is the AdminUserRecord schema
+type AdminUserRecord struct {
+	User               User              `json:"user"`
+	ModerationReason   string            `json:"moderation_reason,omitempty"`
+	RecentTransactions []CoinTransaction `json:"recent_transactions"`
+	Actions            []AdminAction     `json:"actions"`
+	Games              []Game            `json:"games"`
+	NextCursor         string            `json:"next_cursor"`
+}
+
+// BotStrategy is one of the BotStrategy constants
+type BotStrategy string
+
+const (
+	BotStrategyRandom    BotStrategy = "random"
+	BotStrategyCycle     BotStrategy = "cycle"
+	BotStrategyMirror    BotStrategy = "mirror"
+	BotStrategyCounter   BotStrategy = "counter"
+	BotStrategyFrequency BotStrategy = "frequency"
+)
+
+// Bracket is the Bracket schema
+type Bracket struct {
+	TournamentID int               `json:"tournament_id"`
+	Rounds       []TournamentRound `json:"rounds"`
+}
+
+// Challenge is the Challenge schema
+type Challenge struct {
+	ID             int              `json:"id"`
+	Challenger     string           `json:"challenger"`
+	Opponent       string           `json:"opponent"`
+	BestOf         int              `json:"best_of"`
+	Stake          int              `json:"stake"`
+	Status         ChallengeStatus  `json:"status"`
+	ChallengerWins int              `json:"challenger_wins"`
+	OpponentWins   int              `json:"opponent_wins"`
+	Winner         string           `json:"winner,omitempty"`
+	Rounds         []ChallengeRound `json:"rounds"`
+	CreatedAt      time.Time        `json:"created_at"`
+	ExpiresAt      time.Time        `json:"expires_at"`
+	MoveDeadline   *time.Time       `json:"move_deadline,omitempty"`
+	CompletedAt    *time.Time       `json:"completed_at,omitempty"`
+}
+
+// ChallengeActionRequest is the ChallengeActionRequest schema
+type ChallengeActionRequest struct {
+	Username string `json:"username,omitempty"`
+}
+
+// ChallengeKind is one of the ChallengeKind constants
+type ChallengeKind string
+
+const (
+	ChallengeKindWinWithChoice ChallengeKind = "win_with_choice"
+	ChallengeKindReachStreak   ChallengeKind = "reach_streak"
+	ChallengeKindPlayGames     ChallengeKind = "play_games"
+	ChallengeKindWinGames      ChallengeKind = "win_games"
+)
+
+// ChallengeList is the ChallengeList schema
+type ChallengeList struct {
+	Username        string      `json:"username"`
+	Challenges      []Challenge `json:"challenges"`
+	TotalChallenges int         `json:"total_challenges"`
+}
+
+// ChallengeMoveRequest is the ChallengeMoveRequest schema
+type ChallengeMoveRequest struct {
+	Username     string `json:"username,omitempty"`
+	PlayerChoice Choice `json:"player_choice"`
+}
+
+// ChallengeRound is the ChallengeRound schema
+type ChallengeRound struct {
+	Number           int         `json:"number"`
+	ChallengerChoice Choice      `json:"challenger_choice,omitempty"`
+	OpponentChoice   Choice      `json:"opponent_choice,omitempty"`
+	ChallengerMoved  bool        `json:"challenger_moved"`
+	OpponentMoved    bool        `json:"opponent_moved"`
+	Winner           RoundWinner `json:"winner,omitempty"`
+}
+
+// ChallengeStatus is one of the ChallengeStatus constants
+type ChallengeStatus string
+
+const (
+	ChallengeStatusPending   ChallengeStatus = "pending"
+	ChallengeStatusAccepted  ChallengeStatus = "accepted"
+	ChallengeStatusDeclined  ChallengeStatus = "declined"
+	ChallengeStatusCancelled ChallengeStatus = "cancelled"
+	ChallengeStatusExpired   ChallengeStatus = "expired"
+	ChallengeStatusCompleted ChallengeStatus = "completed"
+	ChallengeStatusForfeited ChallengeStatus = "forfeited"
+)
+
+// Choice is one of the Choice constants
+type Choice string
+
+const (
+	ChoiceRock     Choice = "rock"
+	ChoicePaper    Choice = "paper"
+	ChoiceScissors Choice = "scissors"
+)
+
+// ChoiceCount is the ChoiceCount schema
+type ChoiceCount struct {
+	Choice Choice `json:"choice"`
+	Count  int    `json:"count"`
+}
+
+// ChoiceStats is the ChoiceStats schema
+type ChoiceStats struct {
+	Choice  Choice  `json:"choice"`
+	Played  int     `json:"played"`
+	Share   float64 `json:"share"`
+	Wins    int     `json:"wins"`
+	Losses  int     `json:"losses"`
+	Ties    int     `json:"ties"`
+	WinRate float64 `json:"win_rate"`
+}
+
+// Clan is the Clan schema
+type Clan struct {
+	ID          int          `json:"id"`
+	Name        string       `json:"name"`
+	Tag         string       `json:"tag"`
+	Description string       `json:"description,omitempty"`
+	Open        bool         `json:"open"`
+	Members     []ClanMember `json:"members,omitempty"`
+	MemberCount int          `json:"member_count"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// ClanActionRequest is the ClanActionRequest schema
+type ClanActionRequest struct {
+	Username string `json:"username,omitempty"`
+}
+
+// ClanInvite is the ClanInvite schema
+type ClanInvite struct {
+	Clan      string    `json:"clan"`
+	Tag       string    `json:"tag"`
+	InvitedBy string    `json:"invited_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ClanInviteSent is the ClanInviteSent schema
+type ClanInviteSent struct {
+	Message string `json:"message"`
+	Clan    string `json:"clan"`
+	Member  string `json:"member"`
+}
+
+// ClanInvites is the ClanInvites schema
+type ClanInvites struct {
+	Username string       `json:"username"`
+	Invites  []ClanInvite `json:"invites"`
+}
+
+// ClanLeaderboard is the ClanLeaderboard schema
+type ClanLeaderboard struct {
+	Season      Season         `json:"season"`
+	Leaderboard []ClanStanding `json:"leaderboard"`
+	TotalClans  int            `json:"total_clans"`
+}
+
+// ClanLeft is the ClanLeft schema
+type ClanLeft struct {
+	Message   string `json:"message"`
+	Disbanded bool   `json:"disbanded"`
+}
+
+// ClanMember is the ClanMember schema
+type ClanMember struct {
+	Username   string    `json:"username"`
+	Role       ClanRole  `json:"role"`
+	TotalCoins int       `json:"total_coins"`
+	GamesWon   int       `json:"games_won"`
+	JoinedAt   time.Time `json:"joined_at"`
+}
+
+// ClanMemberRequest is the ClanMemberRequest schema
+type ClanMemberRequest struct {
+	Username string `json:"username,omitempty"`
+	Member   string `json:"member"`
+}
+
+// ClanRole is one of the ClanRole constants
+type ClanRole string
+
+const (
+	ClanRoleOwner   ClanRole = "owner"
+	ClanRoleOfficer ClanRole = "officer"
+	ClanRoleMember  ClanRole = "member"
+)
+
+// ClanStanding is the ClanStanding schema
+type ClanStanding struct {
+	Rank        int     `json:"rank"`
+	Name        string  `json:"name"`
+	Tag         string  `json:"tag"`
+	Members     int     `json:"members"`
+	TotalCoins  int     `json:"total_coins"`
+	Coins       int     `json:"coins"`
+	GamesPlayed int     `json:"games_played"`
+	GamesWon    int     `json:"games_won"`
+	WinRate     float64 `json:"win_rate"`
+}
+
+// ClanWar is the ClanWar schema
+type ClanWar struct {
+	ID            int           `json:"id"`
+	Clan          string        `json:"clan"`
+	Opponent      string        `json:"opponent"`
+	Status        ClanWarStatus `json:"status"`
+	DurationHours int           `json:"duration_hours"`
+	ClanScore     int           `json:"clan_score"`
+	OpponentScore int           `json:"opponent_score"`
+	Winner        string        `json:"winner,omitempty"`
+	DeclaredBy    string        `json:"declared_by"`
+	CreatedAt     time.Time     `json:"created_at"`
+	StartsAt      *time.Time    `json:"starts_at,omitempty"`
+	EndsAt        *time.Time    `json:"ends_at,omitempty"`
+}
+
+// ClanWarStatus is one of the ClanWarStatus constants
+type ClanWarStatus string
+
+const (
+	ClanWarStatusPending   ClanWarStatus = "pending"
+	ClanWarStatusActive    ClanWarStatus = "active"
+	ClanWarStatusCompleted ClanWarStatus = "completed"
+	ClanWarStatusDeclined  ClanWarStatus = "declined"
+)
+
+// ClanWars is the ClanWars schema
+type ClanWars struct {
+	Clan string    `json:"clan"`
+	Wars []ClanWar `json:"wars"`
+}
+
+// CoinTransaction is the CoinTransaction schema
+type CoinTransaction struct {
+	ID             int             `json:"id"`
+	UserID         int             `json:"user_id"`
+	Type           TransactionType `json:"type"`
+	Amount         int             `json:"amount"`
+	BalanceAfter   int             `json:"balance_after"`
+	CounterAccount string          `json:"counter_account"`
+	Reference      string          `json:"reference,omitempty"`
+	Reason         string          `json:"reason,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// CosmeticSlot is one of the CosmeticSlot constants
+type CosmeticSlot string
+
+const (
+	CosmeticSlotAvatar           CosmeticSlot = "avatar"
+	CosmeticSlotHandSkin         CosmeticSlot = "hand_skin"
+	CosmeticSlotVictoryAnimation CosmeticSlot = "victory_animation"
+	CosmeticSlotNameColor        CosmeticSlot = "name_color"
+)
+
+// CreateChallengeRequest is the CreateChallengeRequest schema
+type CreateChallengeRequest struct {
+	Username         string `json:"username,omitempty"`
+	Opponent         string `json:"opponent"`
+	BestOf           int    `json:"best_of,omitempty"`
+	Stake            int    `json:"stake,omitempty"`
+	ExpiresInMinutes int    `json:"expires_in_minutes,omitempty"`
+}
+
+// CreateClanRequest is the CreateClanRequest schema
+type CreateClanRequest struct {
+	Username    string `json:"username,omitempty"`
+	Name        string `json:"name"`
+	Tag         string `json:"tag"`
+	Description string `json:"description,omitempty"`
+	Open        bool   `json:"open,omitempty"`
+}
+
+// CreateTournamentRequest is the CreateTournamentRequest schema
+type CreateTournamentRequest struct {
+	Name                 string            `json:"name"`
+	Format               TournamentFormat  `json:"format"`
+	Seeding              TournamentSeeding `json:"seeding,omitempty"`
+	BestOf               int               `json:"best_of,omitempty"`
+	MaxPlayers           int               `json:"max_players,omitempty"`
+	EntryFee             int               `json:"entry_fee,omitempty"`
+	GuaranteedPrize      int               `json:"guaranteed_prize,omitempty"`
+	PrizeSplit           []int             `json:"prize_split,omitempty"`
+	SwissRounds          int               `json:"swiss_rounds,omitempty"`
+	MoveTimeoutMinutes   int               `json:"move_timeout_minutes,omitempty"`
+	RegistrationClosesAt time.Time         `json:"registration_closes_at"`
+}
+
+// CreateUserRequest is the CreateUserRequest schema
+type CreateUserRequest struct {
+	Username string `json:"username"`
+}
+
+// CreateUserResponse is the CreateUserResponse schema
+type CreateUserResponse struct {
+	ID            int               `json:"id"`
+	Username      string            `json:"username"`
+	ClanTag       string            `json:"clan_tag,omitempty"`
+	TotalCoins    int               `json:"total_coins"`
+	CurrentStreak int               `json:"current_streak"`
+	BestStreak    int               `json:"best_streak"`
+	GamesPlayed   int               `json:"games_played"`
+	GamesWon      int               `json:"games_won"`
+	WinRate       float64           `json:"win_rate"`
+	Cosmetics     EquippedCosmetics `json:"cosmetics"`
+	Profile       Profile           `json:"profile"`
+	AccountToken  string            `json:"account_token"`
+}
+
+// DailyChallenge is the DailyChallenge schema
+type DailyChallenge struct {
+	ID          string        `json:"id"`
+	Day         string        `json:"day"`
+	Kind        ChallengeKind `json:"kind"`
+	Description string        `json:"description"`
+	Choice      Choice        `json:"choice,omitempty"`
+	Target      int           `json:"target"`
+	RewardCoins int           `json:"reward_coins"`
+	Progress    int           `json:"progress"`
+	Completed   bool          `json:"completed"`
+	Claimed     bool          `json:"claimed"`
+}
+
+// DailyChallengeClaim is the DailyChallengeClaim schema
+type DailyChallengeClaim struct {
+	Challenge  DailyChallenge `json:"challenge"`
+	TotalCoins int            `json:"total_coins"`
+}
+
+// DailyChallenges is the DailyChallenges schema
+type DailyChallenges struct {
+	Username   string           `json:"username"`
+	Challenges []DailyChallenge `json:"challenges"`
+}
+
+// DailyRewardClaim is the DailyRewardClaim schema
+type DailyRewardClaim struct {
+	Day        string `json:"day"`
+	StreakDay  int    `json:"streak_day"`
+	Coins      int    `json:"coins"`
+	TotalCoins int    `json:"total_coins"`
+}
+
+// DailyRewardStatus is the DailyRewardStatus schema
+type DailyRewardStatus struct {
+	Day          string     `json:"day"`
+	Timezone     string     `json:"timezone"`
+	ClaimedToday bool       `json:"claimed_today"`
+	StreakDay    int        `json:"streak_day"`
+	NextReward   int        `json:"next_reward"`
+	NextResetAt  time.Time  `json:"next_reset_at"`
+	NextClaimAt  *time.Time `json:"next_claim_at,omitempty"`
+}
+
+// DeclareClanWarRequest is the DeclareClanWarRequest schema
+type DeclareClanWarRequest struct {
+	Username      string `json:"username,omitempty"`
+	Opponent      string `json:"opponent"`
+	DurationHours int    `json:"duration_hours,omitempty"`
+}
+
+// DeletionScheduled is the DeletionScheduled schema
+type DeletionScheduled struct {
+	Username            string    `json:"username"`
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+// EquipRequest is the EquipRequest schema
+type EquipRequest struct {
+	Username string `json:"username,omitempty"`
+	ItemID   string `json:"item_id"`
+}
+
+// Equipped is the Equipped schema
+type Equipped struct {
+	Message string `json:"message"`
+	ItemID  string `json:"item_id"`
+}
+
+// EquippedCosmetics is the EquippedCosmetics schema
+type EquippedCosmetics struct {
+	Avatar           *ShopItem `json:"avatar,omitempty"`
+	HandSkin         *ShopItem `json:"hand_skin,omitempty"`
+	VictoryAnimation *ShopItem `json:"victory_animation,omitempty"`
+	NameColor        *ShopItem `json:"name_color,omitempty"`
+}
+
+// ExportFormat is one of the ExportFormat constants
+type ExportFormat string
+
+const (
+	ExportFormatCSV     ExportFormat = "csv"
+	ExportFormatNdjson  ExportFormat = "ndjson"
+	ExportFormatParquet ExportFormat = "parquet"
+)
+
+// Friend is the Friend schema
+type Friend struct {
+	Username string           `json:"username"`
+	Status   FriendshipStatus `json:"status"`
+	Since    time.Time        `json:"since"`
+}
+
+// FriendList is the FriendList schema
+type FriendList struct {
+	Friends          []Friend `json:"friends"`
+	IncomingRequests []Friend `json:"incoming_requests"`
+	OutgoingRequests []Friend `json:"outgoing_requests"`
+}
+
+// FriendRequest is the FriendRequest schema
+type FriendRequest struct {
+	Username string `json:"username,omitempty"`
+	Friend   string `json:"friend"`
+}
+
+// Friendship is the Friendship schema
+type Friendship struct {
+	Username string           `json:"username"`
+	Friend   string           `json:"friend"`
+	Status   FriendshipStatus `json:"status"`
+}
+
+// FriendshipStatus is one of the FriendshipStatus constants
+type FriendshipStatus string
+
+const (
+	FriendshipStatusPending  FriendshipStatus = "pending"
+	FriendshipStatusAccepted FriendshipStatus = "accepted"
+)
+
+// Game is the Game schema
+type Game struct {
+	ID               int        `json:"id"`
+	UserID           int        `json:"user_id"`
+	PlayerChoice     Choice     `json:"player_choice"`
+	ComputerChoice   Choice     `json:"computer_choice"`
+	Result           GameResult `json:"result"`
+	CoinsEarned      int        `json:"coins_earned"`
+	StreakMultiplier int        `json:"streak_multiplier"`
+	OpponentUserID   *int       `json:"opponent_user_id,omitempty"`
+	PlayedAt         time.Time  `json:"played_at"`
+}
+
+// GameHistory is the GameHistory schema
+type GameHistory struct {
+	Username   string `json:"username"`
+	Games      []Game `json:"games"`
+	TotalGames int    `json:"total_games"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// GameOutcome is the GameOutcome schema
+type GameOutcome struct {
+	PlayerChoice   Choice     `json:"player_choice"`
+	ComputerChoice Choice     `json:"computer_choice"`
+	Result         GameResult `json:"result"`
+}
+
+// GameResult is one of the GameResult constants
+type GameResult string
+
+const (
+	GameResultWin  GameResult = "win"
+	GameResultLose GameResult = "lose"
+	GameResultTie  GameResult = "tie"
+)
+
+// HeadToHead is the HeadToHead schema
+type HeadToHead struct {
+	Player                   string        `json:"player"`
+	Opponent                 string        `json:"opponent"`
+	GamesPlayed              int           `json:"games_played"`
+	Wins                     int           `json:"wins"`
+	Losses                   int           `json:"losses"`
+	Ties                     int           `json:"ties"`
+	WinRate                  float64       `json:"win_rate"`
+	CoinSwing                int           `json:"coin_swing"`
+	LongestWinStreak         int           `json:"longest_win_streak"`
+	OpponentLongestWinStreak int           `json:"opponent_longest_win_streak"`
+	PlayerChoices            []ChoiceCount `json:"player_choices"`
+	OpponentChoices          []ChoiceCount `json:"opponent_choices"`
+	ChallengesPlayed         int           `json:"challenges_played"`
+	RecentGames              []Game        `json:"recent_games"`
+}
+
+// Health is the Health schema
+type Health struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// HourStats is the HourStats schema
+type HourStats struct {
+	Hour        int     `json:"hour"`
+	GamesPlayed int     `json:"games_played"`
+	GamesWon    int     `json:"games_won"`
+	WinRate     float64 `json:"win_rate"`
+}
+
+// Inventory is the Inventory schema
+type Inventory struct {
+	Username   string          `json:"username"`
+	Inventory  []InventoryItem `json:"inventory"`
+	TotalItems int             `json:"total_items"`
+}
+
+// InventoryItem is the InventoryItem schema
+type InventoryItem struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Slot        CosmeticSlot `json:"slot"`
+	Price       int          `json:"price"`
+	Value       string       `json:"value"`
+	Description string       `json:"description,omitempty"`
+	Equipped    bool         `json:"equipped"`
+	PurchasedAt time.Time    `json:"purchased_at"`
+}
+
+// Leaderboard is the Leaderboard schema
+type Leaderboard struct {
+	Leaderboard []LeaderboardEntry `json:"leaderboard"`
+	TotalUsers  int                `json:"total_users"`
+}
+
+// LeaderboardEntry is the LeaderboardEntry schema
+type LeaderboardEntry struct {
+	Rank      int               `json:"rank"`
+	Username  string            `json:"username"`
+	ClanTag   *string           `json:"clan_tag"`
+	Coins     int               `json:"coins"`
+	Record    Record            `json:"record"`
+	Cosmetics EquippedCosmetics `json:"cosmetics"`
+	Profile   Profile           `json:"profile"`
+}
+
+// Message is the Message schema
+type Message struct {
+	Message string `json:"message"`
+}
+
+// ModerationRequest is the ModerationRequest schema
+type ModerationRequest struct {
+	Reason string `json:"reason"`
+}
+
+// OpponentType is one of the OpponentType constants
+type OpponentType string
+
+const (
+	OpponentTypeComputer OpponentType = "computer"
+	OpponentTypePlayer   OpponentType = "player"
+	OpponentTypeAll      OpponentType = "all"
+)
+
+// PlayGameRequest is the PlayGameRequest schema
+type PlayGameRequest struct {
+	Username     string      `json:"username"`
+	PlayerChoice Choice      `json:"player_choice"`
+	Bot          BotStrategy `json:"bot,omitempty"`
+}
+
+// PlayGameResponse is the PlayGameResponse schema
+type PlayGameResponse struct {
+	Game            GameOutcome       `json:"game"`
+	Reward          Reward            `json:"reward"`
+	Streak          StreakUpdate      `json:"streak"`
+	Balance         int               `json:"balance"`
+	Message         string            `json:"message"`
+	NewAchievements []UserAchievement `json:"new_achievements"`
+}
+
+// PlayerAnalytics is the PlayerAnalytics schema
+type PlayerAnalytics struct {
+	Username           string                    `json:"username"`
+	Opponent           OpponentType              `json:"opponent"`
+	Timezone           string                    `json:"timezone"`
+	GamesPlayed        int                       `json:"games_played"`
+	Choices            []ChoiceStats             `json:"choices"`
+	Transitions        map[string]map[string]int `json:"transitions"`
+	ChoiceEntropy      float64                   `json:"choice_entropy"`
+	ConditionalEntropy float64                   `json:"conditional_entropy"`
+	Predictability     float64                   `json:"predictability"`
+	Hours              []HourStats               `json:"hours"`
+	LongestWinStreak   int                       `json:"longest_win_streak"`
+	LongestLossStreak  int                       `json:"longest_loss_streak"`
+}
+
+// Profile is the Profile schema
+type Profile struct {
+	DisplayName string `json:"display_name,omitempty"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+	Country     string `json:"country,omitempty"`
+	Bio         string `json:"bio,omitempty"`
+}
+
+// Purchase is the Purchase schema
+type Purchase struct {
+	Item       InventoryItem `json:"item"`
+	TotalCoins int           `json:"total_coins"`
+}
+
+// PurchaseRequest is the PurchaseRequest schema
+type PurchaseRequest struct {
+	Username string `json:"username,omitempty"`
+	ItemID   string `json:"item_id"`
+}
+
+// Record is the Record schema
+type Record struct {
+	GamesPlayed   int     `json:"games_played"`
+	GamesWon      int     `json:"games_won"`
+	WinRate       float64 `json:"win_rate"`
+	CurrentStreak int     `json:"current_streak"`
+}
+
+// RenameUserRequest is the RenameUserRequest schema
+type RenameUserRequest struct {
+	Username string `json:"username"`
+	Reason   string `json:"reason"`
+}
+
+// Reward is the Reward schema
+type Reward struct {
+	Coins            int `json:"coins"`
+	StreakMultiplier int `json:"streak_multiplier"`
+}
+
+// RoundWinner is one of the RoundWinner constants
+type RoundWinner string
+
+const (
+	RoundWinnerChallenger RoundWinner = "challenger"
+	RoundWinnerOpponent   RoundWinner = "opponent"
+	RoundWinnerTie        RoundWinner = "tie"
+)
+
+// Season is the Season schema
+type Season struct {
+	ID       int          `json:"id"`
+	Name     string       `json:"name"`
+	StartsAt time.Time    `json:"starts_at"`
+	EndsAt   time.Time    `json:"ends_at"`
+	Status   SeasonStatus `json:"status"`
+}
+
+// SeasonLeaderboard is the SeasonLeaderboard schema
+type SeasonLeaderboard struct {
+	Season      Season           `json:"season"`
+	Leaderboard []SeasonStanding `json:"leaderboard"`
+	TotalUsers  int              `json:"total_users"`
+}
+
+// SeasonList is the SeasonList schema
+type SeasonList struct {
+	Seasons      []Season `json:"seasons"`
+	TotalSeasons int      `json:"total_seasons"`
+}
+
+// SeasonResult is the SeasonResult schema
+type SeasonResult struct {
+	ID       int            `json:"id"`
+	Name     string         `json:"name"`
+	StartsAt time.Time      `json:"starts_at"`
+	EndsAt   time.Time      `json:"ends_at"`
+	Status   SeasonStatus   `json:"status"`
+	Standing SeasonStanding `json:"standing"`
+}
+
+// SeasonStanding is the SeasonStanding schema
+type SeasonStanding struct {
+	Rank        int     `json:"rank"`
+	Username    string  `json:"username"`
+	Coins       int     `json:"coins"`
+	GamesPlayed int     `json:"games_played"`
+	GamesWon    int     `json:"games_won"`
+	WinRate     float64 `json:"win_rate"`
+	Rating      int     `json:"rating"`
+	Badge       string  `json:"badge,omitempty"`
+	RewardCoins int     `json:"reward_coins,omitempty"`
+}
+
+// SeasonStatus is one of the SeasonStatus constants
+type SeasonStatus string
+
+const (
+	SeasonStatusActive   SeasonStatus = "active"
+	SeasonStatusArchived SeasonStatus = "archived"
+)
+
+// SetClanRoleRequest is the SetClanRoleRequest schema
+type SetClanRoleRequest struct {
+	Username string   `json:"username,omitempty"`
+	Role     ClanRole `json:"role"`
+}
+
+// SetTimezoneRequest is the SetTimezoneRequest schema
+type SetTimezoneRequest struct {
+	Timezone string `json:"timezone"`
+}
+
+// ShopCatalog is the ShopCatalog schema
+type ShopCatalog struct {
+	Items      []ShopItem `json:"items"`
+	TotalItems int        `json:"total_items"`
+}
+
+// ShopItem is the ShopItem schema
+type ShopItem struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Slot        CosmeticSlot `json:"slot"`
+	Price       int          `json:"price"`
+	Value       string       `json:"value"`
+	Description string       `json:"description,omitempty"`
+}
+
+// SortOrder is one of the SortOrder constants
+type SortOrder string
+
+const (
+	SortOrderDesc SortOrder = "desc"
+	SortOrderAsc  SortOrder = "asc"
+)
+
+// Streak is the Streak schema
+type Streak struct {
+	ID          int          `json:"id"`
+	StartGameID *int         `json:"start_game_id,omitempty"`
+	EndGameID   *int         `json:"end_game_id,omitempty"`
+	Length      int          `json:"length"`
+	CoinsEarned int          `json:"coins_earned"`
+	Status      StreakStatus `json:"status"`
+	StartedAt   time.Time    `json:"started_at"`
+	EndedAt     *time.Time   `json:"ended_at,omitempty"`
+}
+
+// StreakHistory is the StreakHistory schema
+type StreakHistory struct {
+	Username      string   `json:"username"`
+	CurrentStreak int      `json:"current_streak"`
+	BestStreak    int      `json:"best_streak"`
+	Streaks       []Streak `json:"streaks"`
+	Total         int      `json:"total"`
+	Limit         int      `json:"limit"`
+	Offset        int      `json:"offset"`
+}
+
+// StreakLeaderboard is the StreakLeaderboard schema
+type StreakLeaderboard struct {
+	Leaderboard []StreakLeaderboardEntry `json:"leaderboard"`
+	TotalUsers  int                      `json:"total_users"`
+}
+
+// StreakLeaderboardEntry is the StreakLeaderboardEntry schema
+type StreakLeaderboardEntry struct {
+	Rank          int    `json:"rank"`
+	Username      string `json:"username"`
+	BestStreak    int    `json:"best_streak"`
+	CurrentStreak int    `json:"current_streak"`
+}
+
+// StreakStatus is one of the StreakStatus constants
+type StreakStatus string
+
+const (
+	StreakStatusActive StreakStatus = "active"
+	StreakStatusEnded  StreakStatus = "ended"
+)
+
+// StreakUpdate is the StreakUpdate schema
+type StreakUpdate struct {
+	Current int  `json:"current"`
+	NewBest bool `json:"new_best"`
+}
+
+// SuspendUserRequest is the SuspendUserRequest schema
+type SuspendUserRequest struct {
+	Reason string    `json:"reason"`
+	Until  time.Time `json:"until"`
+}
+
+// Timezone is the Timezone schema
+type Timezone struct {
+	Username string `json:"username"`
+	Timezone string `json:"timezone"`
+}
+
+// Tournament is the Tournament schema
+type Tournament struct {
+	ID                   int               `json:"id"`
+	Name                 string            `json:"name"`
+	Format               TournamentFormat  `json:"format"`
+	Status               TournamentStatus  `json:"status"`
+	Seeding              TournamentSeeding `json:"seeding"`
+	BestOf               int               `json:"best_of"`
+	MaxPlayers           int               `json:"max_players"`
+	Players              int               `json:"players"`
+	EntryFee             int               `json:"entry_fee"`
+	GuaranteedPrize      int               `json:"guaranteed_prize"`
+	PrizePool            int               `json:"prize_pool"`
+	PrizeSplit           []int             `json:"prize_split"`
+	SwissRounds          int               `json:"swiss_rounds,omitempty"`
+	MoveTimeoutMinutes   int               `json:"move_timeout_minutes"`
+	CurrentRound         int               `json:"current_round,omitempty"`
+	Winner               string            `json:"winner,omitempty"`
+	RegistrationClosesAt time.Time         `json:"registration_closes_at"`
+	CreatedBy            string            `json:"created_by"`
+	CreatedAt            time.Time         `json:"created_at"`
+	StartedAt            *time.Time        `json:"started_at,omitempty"`
+	CompletedAt          *time.Time        `json:"completed_at,omitempty"`
+}
+
+// TournamentBracket is one of the TournamentBracket constants
+type TournamentBracket string
+
+const (
+	TournamentBracketMain       TournamentBracket = "main"
+	TournamentBracketWinners    TournamentBracket = "winners"
+	TournamentBracketLosers     TournamentBracket = "losers"
+	TournamentBracketGrandFinal TournamentBracket = "grand_final"
+)
+
+// TournamentFormat is one of the TournamentFormat constants
+type TournamentFormat string
+
+const (
+	TournamentFormatSingleElimination TournamentFormat = "single_elimination"
+	TournamentFormatDoubleElimination TournamentFormat = "double_elimination"
+	TournamentFormatRoundRobin        TournamentFormat = "round_robin"
+	TournamentFormatSwiss             TournamentFormat = "swiss"
+)
+
+// TournamentGame is the TournamentGame schema
+type TournamentGame struct {
+	Number        int    `json:"number"`
+	Player1Choice Choice `json:"player1_choice,omitempty"`
+	Player2Choice Choice `json:"player2_choice,omitempty"`
+	Player1Moved  bool   `json:"player1_moved"`
+	Player2Moved  bool   `json:"player2_moved"`
+	Winner        string `json:"winner,omitempty"`
+}
+
+// TournamentList is the TournamentList schema
+type TournamentList struct {
+	Tournaments      []Tournament `json:"tournaments"`
+	TotalTournaments int          `json:"total_tournaments"`
+}
+
+// TournamentMatch is the TournamentMatch schema
+type TournamentMatch struct {
+	ID          int                   `json:"id"`
+	Bracket     TournamentBracket     `json:"bracket"`
+	Round       int                   `json:"round"`
+	Position    int                   `json:"position"`
+	Player1     string                `json:"player1,omitempty"`
+	Player2     string                `json:"player2,omitempty"`
+	Player1Wins int                   `json:"player1_wins"`
+	Player2Wins int                   `json:"player2_wins"`
+	Winner      string                `json:"winner,omitempty"`
+	Status      TournamentMatchStatus `json:"status"`
+	Games       []TournamentGame      `json:"games"`
+	Deadline    *time.Time            `json:"deadline,omitempty"`
+	CompletedAt *time.Time            `json:"completed_at,omitempty"`
+}
+
+// TournamentMatchStatus is one of the TournamentMatchStatus constants
+type TournamentMatchStatus string
+
+const (
+	TournamentMatchStatusWaiting   TournamentMatchStatus = "waiting"
+	TournamentMatchStatusActive    TournamentMatchStatus = "active"
+	TournamentMatchStatusCompleted TournamentMatchStatus = "completed"
+	TournamentMatchStatusForfeit   TournamentMatchStatus = "forfeit"
+	TournamentMatchStatusBye       TournamentMatchStatus = "bye"
+)
+
+// TournamentMoveRequest is the TournamentMoveRequest schema
+type TournamentMoveRequest struct {
+	Username     string `json:"username,omitempty"`
+	PlayerChoice Choice `json:"player_choice"`
+}
+
+// TournamentPlayer is the TournamentPlayer schema
+type TournamentPlayer struct {
+	Username    string `json:"username"`
+	Seed        int    `json:"seed,omitempty"`
+	Points      int    `json:"points"`
+	MatchWins   int    `json:"match_wins"`
+	MatchLosses int    `json:"match_losses"`
+	GameWins    int    `json:"game_wins"`
+	GameLosses  int    `json:"game_losses"`
+	Buchholz    int    `json:"buchholz,omitempty"`
+	Eliminated  bool   `json:"eliminated"`
+	Withdrawn   bool   `json:"withdrawn"`
+	Place       int    `json:"place,omitempty"`
+	Prize       int    `json:"prize,omitempty"`
+}
+
+// TournamentRegistrationRequest is the TournamentRegistrationRequest schema
+type TournamentRegistrationRequest struct {
+	Username string `json:"username,omitempty"`
+}
+
+// TournamentRound is the TournamentRound schema
+type TournamentRound struct {
+	Bracket TournamentBracket `json:"bracket"`
+	Round   int               `json:"round"`
+	Matches []TournamentMatch `json:"matches"`
+}
+
+// TournamentSeeding is one of the TournamentSeeding constants
+type TournamentSeeding string
+
+const (
+	TournamentSeedingCoins  TournamentSeeding = "coins"
+	TournamentSeedingRating TournamentSeeding = "rating"
+)
+
+// TournamentStandings is the TournamentStandings schema
+type TournamentStandings struct {
+	TournamentID int                `json:"tournament_id"`
+	Standings    []TournamentPlayer `json:"standings"`
+}
+
+// TournamentStatus is one of the TournamentStatus constants
+type TournamentStatus string
+
+const (
+	TournamentStatusRegistration TournamentStatus = "registration"
+	TournamentStatusInProgress   TournamentStatus = "in_progress"
+	TournamentStatusCompleted    TournamentStatus = "completed"
+	TournamentStatusCancelled    TournamentStatus = "cancelled"
+)
+
+// TransactionHistory is the TransactionHistory schema
+type TransactionHistory struct {
+	Username     string            `json:"username"`
+	Balance      int               `json:"balance"`
+	Transactions []CoinTransaction `json:"transactions"`
+	Total        int               `json:"total"`
+	Limit        int               `json:"limit"`
+	Offset       int               `json:"offset"`
+}
+
+// TransactionType is one of the TransactionType constants
+type TransactionType string
+
+const (
+	TransactionTypeGameReward        TransactionType = "game_reward"
+	TransactionTypeWager             TransactionType = "wager"
+	TransactionTypePurchase          TransactionType = "purchase"
+	TransactionTypeAdminAdjustment   TransactionType = "admin_adjustment"
+	TransactionTypeDailyBonus        TransactionType = "daily_bonus"
+	TransactionTypeOpeningBalance    TransactionType = "opening_balance"
+	TransactionTypeAchievementReward TransactionType = "achievement_reward"
+	TransactionTypeDailyChallenge    TransactionType = "daily_challenge"
+	TransactionTypeSeasonReward      TransactionType = "season_reward"
+	TransactionTypeTournament        TransactionType = "tournament"
+)
+
+// UnequipRequest is the UnequipRequest schema
+type UnequipRequest struct {
+	Username string       `json:"username,omitempty"`
+	Slot     CosmeticSlot `json:"slot"`
+}
+
+// Unequipped is the Unequipped schema
+type Unequipped struct {
+	Message string       `json:"message"`
+	Slot    CosmeticSlot `json:"slot"`
+}
+
+// UpdateProfileRequest is the UpdateProfileRequest schema
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name,omitempty"`
+	AvatarURL   *string `json:"avatar_url,omitempty"`
+	Country     *string `json:"country,omitempty"`
+	Bio         *string `json:"bio,omitempty"`
+	Timezone    *string `json:"timezone,omitempty"`
+}
+
+// User is the User schema
+type User struct {
+	ID                  int        `json:"id"`
+	Username            string     `json:"username"`
+	TotalCoins          int        `json:"total_coins"`
+	CurrentStreak       int        `json:"current_streak"`
+	BestStreak          int        `json:"best_streak"`
+	GamesPlayed         int        `json:"games_played"`
+	GamesWon            int        `json:"games_won"`
+	Timezone            string     `json:"timezone"`
+	Profile             Profile    `json:"profile"`
+	ClanTag             string     `json:"clan_tag,omitempty"`
+	Status              UserStatus `json:"status"`
+	SuspendedUntil      *time.Time `json:"suspended_until,omitempty"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// UserAchievement is the UserAchievement schema
+type UserAchievement struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	RewardCoins int        `json:"reward_coins"`
+	Unlocked    bool       `json:"unlocked"`
+	UnlockedAt  *time.Time `json:"unlocked_at,omitempty"`
+}
+
+// UserResponse is the UserResponse schema
+type UserResponse struct {
+	ID            int               `json:"id"`
+	Username      string            `json:"username"`
+	ClanTag       string            `json:"clan_tag,omitempty"`
+	TotalCoins    int               `json:"total_coins"`
+	CurrentStreak int               `json:"current_streak"`
+	BestStreak    int               `json:"best_streak"`
+	GamesPlayed   int               `json:"games_played"`
+	GamesWon      int               `json:"games_won"`
+	WinRate       float64           `json:"win_rate"`
+	Cosmetics     EquippedCosmetics `json:"cosmetics"`
+	Profile       Profile           `json:"profile"`
+}
+
+// UserSearch is the UserSearch schema
+type UserSearch struct {
+	Users  []User `json:"users"`
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+// UserSeasons is the UserSeasons schema
+type UserSeasons struct {
+	Username string         `json:"username"`
+	Seasons  []SeasonResult `json:"seasons"`
+}
+
+// UserStats is the UserStats schema
+type UserStats struct {
+	ID                  int        `json:"id"`
+	Username            string     `json:"username"`
+	TotalCoins          int        `json:"total_coins"`
+	CurrentStreak       int        `json:"current_streak"`
+	BestStreak          int        `json:"best_streak"`
+	GamesPlayed         int        `json:"games_played"`
+	GamesWon            int        `json:"games_won"`
+	Timezone            string     `json:"timezone"`
+	Profile             Profile    `json:"profile"`
+	ClanTag             string     `json:"clan_tag,omitempty"`
+	Status              UserStatus `json:"status"`
+	SuspendedUntil      *time.Time `json:"suspended_until,omitempty"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	WinRate             float64    `json:"win_rate"`
+	Rank                int        `json:"rank"`
+}
+
+// UserStatus is one of the UserStatus constants
+type UserStatus string
+
+const (
+	UserStatusActive    UserStatus = "active"
+	UserStatusSuspended UserStatus = "suspended"
+	UserStatusBanned    UserStatus = "banned"
+)
+
+// GetAdminActionsParams are the optional parameters of GetAdminActions. Zero values are left out.
+type GetAdminActionsParams struct {
+	// Page size, at most 100
+	Limit int
+	// Items to skip
+	Offset int
+}
+
+// GetAdminActions sends GET /admin/actions.
+//
+// List the audit log of admin changes, newest first.
+// Token must be the server's ADMIN_TOKEN.
+func (c *Client) GetAdminActions(ctx context.Context, params *GetAdminActionsParams) (*AdminActions, error) {
+	r := request{method: "GET", path: "/admin/actions", query: url.Values{}}
+	if params != nil {
+		if params.Limit != 0 {
+			r.query.Set("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Offset != 0 {
+			r.query.Set("offset", strconv.Itoa(params.Offset))
+		}
+	}
+	var out AdminActions
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExportAllGamesParams are the optional parameters of ExportAllGames. Zero values are left out.
+type ExportAllGamesParams struct {
+	// File format; csv if left out
+	Format ExportFormat
+	// Compress the file with gzip
+	Gzip bool
+}
+
+// ExportAllGames sends GET /admin/games/export.
+//
+// Download every game of every player.
+// Token must be the server's ADMIN_TOKEN.
+func (c *Client) ExportAllGames(ctx context.Context, params *ExportAllGamesParams) (io.ReadCloser, error) {
+	r := request{method: "GET", path: "/admin/games/export", query: url.Values{}}
+	if params != nil {
+		if params.Format != "" {
+			r.query.Set("format", string(params.Format))
+		}
+		if params.Gzip {
+			r.query.Set("gzip", strconv.FormatBool(params.Gzip))
+		}
+	}
+	return c.download(ctx, r)
+}
+
+// CreateTournamentParams are the optional parameters of CreateTournament. Zero values are left out.
+type CreateTournamentParams struct {
+	// Who is acting, for the audit log; admin if left out
+	XAdminActor string
+}
+
+// CreateTournament sends POST /admin/tournaments.
+//
+// Set up a tournament.
+// Token must be the server's ADMIN_TOKEN.
+func (c *Client) CreateTournament(ctx context.Context, body CreateTournamentRequest, params *CreateTournamentParams) (*Tournament, error) {
+	r := request{method: "POST", path: "/admin/tournaments", body: body, header: http.Header{}}
+	if params != nil {
+		if params.XAdminActor != "" {
+			r.header.Set("X-Admin-Actor", params.XAdminActor)
+		}
+	}
+	var out Tournament
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CancelTournament sends POST /admin/tournaments/{id}/cancel.
+//
+// Cancel a tournament and refund its entry fees.
+// Token must be the server's ADMIN_TOKEN.
+func (c *Client) CancelTournament(ctx context.Context, id int) (*Tournament, error) {
+	r := request{method: "POST", path: "/admin/tournaments/" + strconv.Itoa(id) + "/cancel"}
+	var out Tournament
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartTournament sends POST /admin/tournaments/{id}/start.
+//
+// Close registration and start a tournament early.
+// Token must be the server's ADMIN_TOKEN.
+func (c *Client) StartTournament(ctx context.Context, id int) (*Tournament, error) {
+	r := request{method: "POST", path: "/admin/tournaments/" + strconv.Itoa(id) + "/start"}
+	var out Tournament
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SearchUsersParams are the optional parameters of SearchUsers. Zero values are left out.
+type SearchUsersParams struct {
+	// Part of the username
+	Q string
+	// Only users in this state
+	Status UserStatus
+	// Page size, at most 100
+	Limit int
+	// Items to skip
+	Offset int
+}
+
+// SearchUsers sends GET /admin/users.
+//
+// Search users by name.
+// Token must be the server's ADMIN_TOKEN.
+func (c *Client) SearchUsers(ctx context.Context, params *SearchUsersParams) (*UserSearch, error) {
+	r := request{method: "GET", path: "/admin/users", query: url.Values{}}
+	if params != nil {
+		if params.Q != "" {
+			r.query.Set("q", params.Q)
+		}
+		if params.Status != "" {
+			r.query.Set("status", string(params.Status))
+		}
+		if params.Limit != 0 {
+			r.query.Set("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Offset != 0 {
+			r.query.Set("offset", strconv.Itoa(params.Offset))
+		}
+	}
+	var out UserSearch
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteUserParams are the optional parameters of DeleteUser. Zero values are left out.
+type DeleteUserParams struct {
+	// Who is acting, for the audit log; admin if left out
+	XAdminActor string
+	// Why, for the audit log, required
+	Reason string
+}
+
+// DeleteUser sends DELETE /admin/users/{username}.
+//
+// Delete a user and everything that belongs to them.
+// Token must be the server's ADMIN_TOKEN.
+func (c *Client) DeleteUser(ctx context.Context, username string, params *DeleteUserParams) (*Message, error) {
+	r := request{method: "DELETE", path: "/admin/users/" + url.PathEscape(username), query: url.Values{}, header: http.Header{}}
+	if params != nil {
+		if params.XAdminActor != "" {
+			r.header.Set("X-Admin-Actor", params.XAdminActor)
+		}
+		if params.Reason != "" {
+			r.query.Set("reason", params.Reason)
+		}
+	}
+	var out Message
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUserRecordParams are the optional parameters of GetUserRecord. Zero values are left out.
+type GetUserRecordParams struct {
+	// Only games with this result
+	Result GameResult
+	// Only games where the player threw this
+	Choice Choice
+	// Only games against this kind of opponent
+	Opponent OpponentType
+	// Only games played at or after this date (YYYY-MM-DD) or RFC 3339 time
+	From string
+	// Only games played before this RFC 3339 time, or up to the end of this date (YYYY-MM-DD)
+	To string
+	// Newest first (desc) if left out
+	Order SortOrder
+	// Page size, 20 if left out and at most 100
+	Limit int
+	// The next_cursor of the previous page
+	Cursor string
+}
+
+// GetUserRecord sends GET /admin/users/{username}.
+//
+// Get everything an admin sees about a user; the game parameters page through their games.
+// Token must be the server's ADMIN_TOKEN.
+func (c *Client) GetUserRecord(ctx context.Context, username string, params *GetUserRecordParams) (*AdminUserRecord, error) {
+	r := request{method: "GET", path: "/admin/users/" + url.PathEscape(username), query: url.Values{}}
+	if params != nil {
+		if params.Result != "" {
+			r.query.Set("result", string(params.Result))
+		}
+		if params.Choice != "" {
+			r.query.Set("choice", string(params.Choice))
+		}
+		if params.Opponent != "" {
+			r.query.Set("opponent", string(params.Opponent))
+		}
+		if params.From != "" {
+			r.query.Set("from", params.From)
+		}
+		if params.To != "" {
+			r.query.Set("to", params.To)
+		}
+		if params.Order != "" {
+			r.query.Set("order", string(params.Order))
+		}
+		if params.Limit != 0 {
+			r.query.Set("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Cursor != "" {
+			r.query.Set("cursor", params.Cursor)
+		}
+	}
+	var out AdminUserRecord
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// BanUserParams are the optional parameters of BanUser. Zero values are left out.
+type BanUserParams struct {
+	// Who is acting, for the audit log; admin if left out
+	XAdminActor string
+}
+
+// BanUser sends POST /admin/users/{username}/ban.
+//
+// Ban a user.
+// Token must be the server's ADMIN_TOKEN.
+func (c *Client) BanUser(ctx context.Context, username string, body ModerationRequest, params *BanUserParams) (*User, error) {
+	r := request{method: "POST", path: "/admin/users/" + url.PathEscape(username) + "/ban", body: body, header: http.Header{}}
+	if params != nil {
+		if params.XAdminActor != "" {
+			r.header.Set("X-Admin-Actor", params.XAdminActor)
+		}
+	}
+	var out User
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdjustCoinsParams are the optional parameters of AdjustCoins. Zero values are left out.
+type AdjustCoinsParams struct {
+	// Who is acting, for the audit log; admin if left out
+	XAdminActor string
+}
+
+// AdjustCoins sends POST /admin/users/{username}/coins.
+//
+// Credit or debit a user's coins.
+// Token must be the server's ADMIN_TOKEN.
+func (c *Client) AdjustCoins(ctx context.Context, username string, body AdjustCoinsRequest, params *AdjustCoinsParams) (*CoinTransaction, error) {
+	r := request{method: "POST", path: "/admin/users/" + url.PathEscape(username) + "/coins", body: body, header: http.Header{}}
+	if params != nil {
+		if params.XAdminActor != "" {
+			r.header.Set("X-Admin-Actor", params.XAdminActor)
+		}
+	}
+	var out CoinTransaction
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ReinstateUserParams are the optional parameters of ReinstateUser. Zero values are left out.
+type ReinstateUserParams struct {
+	// Who is acting, for the audit log; admin if left out
+	XAdminActor string
+}
+
+// ReinstateUser sends POST /admin/users/{username}/reinstate.
+//
+// Lift a ban or suspension.
+// Token must be the server's ADMIN_TOKEN.
+func (c *Client) ReinstateUser(ctx context.Context, username string, body ModerationRequest, params *ReinstateUserParams) (*User, error) {
+	r := request{method: "POST", path: "/admin/users/" + url.PathEscape(username) + "/reinstate", body: body, header: http.Header{}}
+	if params != nil {
+		if params.XAdminActor != "" {
+			r.header.Set("X-Admin-Actor", params.XAdminActor)
+		}
+	}
+	var out User
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ResetStreakParams are the optional parameters of ResetStreak. Zero values are left out.
+type ResetStreakParams struct {
+	// Who is acting, for the audit log; admin if left out
+	XAdminActor string
+}
+
+// ResetStreak sends POST /admin/users/{username}/reset-streak.
+//
+// Reset a user's win streak.
+// Token must be the server's ADMIN_TOKEN.
+func (c *Client) ResetStreak(ctx context.Context, username string, body ModerationRequest, params *ResetStreakParams) (*User, error) {
+	r := request{method: "POST", path: "/admin/users/" + url.PathEscape(username) + "/reset-streak", body: body, header: http.Header{}}
+	if params != nil {
+		if params.XAdminActor != "" {
+			r.header.Set("X-Admin-Actor", params.XAdminActor)
+		}
+	}
+	var out User
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SuspendUserParams are the optional parameters of SuspendUser. Zero values are left out.
+type SuspendUserParams struct {
+	// Who is acting, for the audit log; admin if left out
+	XAdminActor string
+}
+
+// SuspendUser sends POST /admin/users/{username}/suspend.
+//
+// Suspend a user until a given time.
+// Token must be the server's ADMIN_TOKEN.
+func (c *Client) SuspendUser(ctx context.Context, username string, body SuspendUserRequest, params *SuspendUserParams) (*User, error) {
+	r := request{method: "POST", path: "/admin/users/" + url.PathEscape(username) + "/suspend", body: body, header: http.Header{}}
+	if params != nil {
+		if params.XAdminActor != "" {
+			r.header.Set("X-Admin-Actor", params.XAdminActor)
+		}
+	}
+	var out User
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// IssueAccountTokenParams are the optional parameters of IssueAccountToken. Zero values are left out.
+type IssueAccountTokenParams struct {
+	// Who is acting, for the audit log; admin if left out
+	XAdminActor string
+}
+
+// IssueAccountToken sends POST /admin/users/{username}/token.
+//
+// Issue a user a new account token, replacing the old one.
+// Token must be the server's ADMIN_TOKEN.
+func (c *Client) IssueAccountToken(ctx context.Context, username string, body ModerationRequest, params *IssueAccountTokenParams) (*AccountToken, error) {
+	r := request{method: "POST", path: "/admin/users/" + url.PathEscape(username) + "/token", body: body, header: http.Header{}}
+	if params != nil {
+		if params.XAdminActor != "" {
+			r.header.Set("X-Admin-Actor", params.XAdminActor)
+		}
+	}
+	var out AccountToken
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RenameUserParams are the optional parameters of RenameUser. Zero values are left out.
+type RenameUserParams struct {
+	// Who is acting, for the audit log; admin if left out
+	XAdminActor string
+}
+
+// RenameUser sends PUT /admin/users/{username}/username.
+//
+// Change a user's username.
+// Token must be the server's ADMIN_TOKEN.
+func (c *Client) RenameUser(ctx context.Context, username string, body RenameUserRequest, params *RenameUserParams) (*User, error) {
+	r := request{method: "PUT", path: "/admin/users/" + url.PathEscape(username) + "/username", body: body, header: http.Header{}}
+	if params != nil {
+		if params.XAdminActor != "" {
+			r.header.Set("X-Admin-Actor", params.XAdminActor)
+		}
+	}
+	var out User
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateChallenge sends POST /api/v2/challenges.
+//
+// Challenge a friend; the stake is held until the match is settled.
+// Token must be the account token returned when the user was created.
+func (c *Client) CreateChallenge(ctx context.Context, body CreateChallengeRequest) (*Challenge, error) {
+	r := request{method: "POST", path: "/api/v2/challenges", body: body}
+	var out Challenge
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetChallenge sends GET /api/v2/challenges/{id}.
+//
+// Get a challenge.
+func (c *Client) GetChallenge(ctx context.Context, id int) (*Challenge, error) {
+	r := request{method: "GET", path: "/api/v2/challenges/" + strconv.Itoa(id)}
+	var out Challenge
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AcceptChallenge sends POST /api/v2/challenges/{id}/accept.
+//
+// Accept a challenge, staking the same amount.
+// Token must be the account token returned when the user was created.
+func (c *Client) AcceptChallenge(ctx context.Context, id int, body ChallengeActionRequest) (*Challenge, error) {
+	r := request{method: "POST", path: "/api/v2/challenges/" + strconv.Itoa(id) + "/accept", body: body}
+	var out Challenge
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CancelChallenge sends POST /api/v2/challenges/{id}/cancel.
+//
+// Call off a challenge that has not been answered.
+// Token must be the account token returned when the user was created.
+func (c *Client) CancelChallenge(ctx context.Context, id int, body ChallengeActionRequest) (*Challenge, error) {
+	r := request{method: "POST", path: "/api/v2/challenges/" + strconv.Itoa(id) + "/cancel", body: body}
+	var out Challenge
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeclineChallenge sends POST /api/v2/challenges/{id}/decline.
+//
+// Decline a challenge.
+// Token must be the account token returned when the user was created.
+func (c *Client) DeclineChallenge(ctx context.Context, id int, body ChallengeActionRequest) (*Challenge, error) {
+	r := request{method: "POST", path: "/api/v2/challenges/" + strconv.Itoa(id) + "/decline", body: body}
+	var out Challenge
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SubmitChallengeMove sends POST /api/v2/challenges/{id}/moves.
+//
+// Throw in the current round.
+// Token must be the account token returned when the user was created.
+func (c *Client) SubmitChallengeMove(ctx context.Context, id int, body ChallengeMoveRequest) (*Challenge, error) {
+	r := request{method: "POST", path: "/api/v2/challenges/" + strconv.Itoa(id) + "/moves", body: body}
+	var out Challenge
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetClanWar sends GET /api/v2/clan-wars/{id}.
+//
+// Get a clan war.
+func (c *Client) GetClanWar(ctx context.Context, id int) (*ClanWar, error) {
+	r := request{method: "GET", path: "/api/v2/clan-wars/" + strconv.Itoa(id)}
+	var out ClanWar
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AcceptClanWar sends POST /api/v2/clan-wars/{id}/accept.
+//
+// Accept a war declared on the clan, which starts it.
+// Token must be the account token returned when the user was created.
+func (c *Client) AcceptClanWar(ctx context.Context, id int, body ClanActionRequest) (*ClanWar, error) {
+	r := request{method: "POST", path: "/api/v2/clan-wars/" + strconv.Itoa(id) + "/accept", body: body}
+	var out ClanWar
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeclineClanWar sends POST /api/v2/clan-wars/{id}/decline.
+//
+// Decline a war declared on the clan.
+// Token must be the account token returned when the user was created.
+func (c *Client) DeclineClanWar(ctx context.Context, id int, body ClanActionRequest) (*ClanWar, error) {
+	r := request{method: "POST", path: "/api/v2/clan-wars/" + strconv.Itoa(id) + "/decline", body: body}
+	var out ClanWar
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateClan sends POST /api/v2/clans.
+//
+// Found a clan.
+// Token must be the account token returned when the user was created.
+func (c *Client) CreateClan(ctx context.Context, body CreateClanRequest) (*Clan, error) {
+	r := request{method: "POST", path: "/api/v2/clans", body: body}
+	var out Clan
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetClanLeaderboardParams are the optional parameters of GetClanLeaderboard. Zero values are left out.
+type GetClanLeaderboardParams struct {
+	// Season ID, or current if left out
+	Season string
+}
+
+// GetClanLeaderboard sends GET /api/v2/clans/leaderboard.
+//
+// Rank clans by the coins their members won in a season.
+func (c *Client) GetClanLeaderboard(ctx context.Context, params *GetClanLeaderboardParams) (*ClanLeaderboard, error) {
+	r := request{method: "GET", path: "/api/v2/clans/leaderboard", query: url.Values{}}
+	if params != nil {
+		if params.Season != "" {
+			r.query.Set("season", params.Season)
+		}
+	}
+	var out ClanLeaderboard
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetClan sends GET /api/v2/clans/{tag}.
+//
+// Get a clan and its members.
+func (c *Client) GetClan(ctx context.Context, tag string) (*Clan, error) {
+	r := request{method: "GET", path: "/api/v2/clans/" + url.PathEscape(tag)}
+	var out Clan
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// InviteToClan sends POST /api/v2/clans/{tag}/invites.
+//
+// Invite a player to the clan.
+// Token must be the account token returned when the user was created.
+func (c *Client) InviteToClan(ctx context.Context, tag string, body ClanMemberRequest) (*ClanInviteSent, error) {
+	r := request{method: "POST", path: "/api/v2/clans/" + url.PathEscape(tag) + "/invites", body: body}
+	var out ClanInviteSent
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeclineClanInvite sends POST /api/v2/clans/{tag}/invites/decline.
+//
+// Decline an invite to a clan.
+// Token must be the account token returned when the user was created.
+func (c *Client) DeclineClanInvite(ctx context.Context, tag string, body ClanActionRequest) (*Message, error) {
+	r := request{method: "POST", path: "/api/v2/clans/" + url.PathEscape(tag) + "/invites/decline", body: body}
+	var out Message
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// JoinClan sends POST /api/v2/clans/{tag}/join.
+//
+// Join an open clan, or one the player was invited to.
+// Token must be the account token returned when the user was created.
+func (c *Client) JoinClan(ctx context.Context, tag string, body ClanActionRequest) (*Clan, error) {
+	r := request{method: "POST", path: "/api/v2/clans/" + url.PathEscape(tag) + "/join", body: body}
+	var out Clan
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// KickClanMember sends POST /api/v2/clans/{tag}/kick.
+//
+// Remove a member from the clan.
+// Token must be the account token returned when the user was created.
+func (c *Client) KickClanMember(ctx context.Context, tag string, body ClanMemberRequest) (*Message, error) {
+	r := request{method: "POST", path: "/api/v2/clans/" + url.PathEscape(tag) + "/kick", body: body}
+	var out Message
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// LeaveClan sends POST /api/v2/clans/{tag}/leave.
+//
+// Leave a clan; the last member leaving disbands it.
+// Token must be the account token returned when the user was created.
+func (c *Client) LeaveClan(ctx context.Context, tag string, body ClanActionRequest) (*ClanLeft, error) {
+	r := request{method: "POST", path: "/api/v2/clans/" + url.PathEscape(tag) + "/leave", body: body}
+	var out ClanLeft
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetClanRole sends PUT /api/v2/clans/{tag}/members/{member}/role.
+//
+// Change a member's role; making someone owner hands the clan over.
+// Token must be the account token returned when the user was created.
+func (c *Client) SetClanRole(ctx context.Context, tag string, member string, body SetClanRoleRequest) (*Clan, error) {
+	r := request{method: "PUT", path: "/api/v2/clans/" + url.PathEscape(tag) + "/members/" + url.PathEscape(member) + "/role", body: body}
+	var out Clan
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListClanWars sends GET /api/v2/clans/{tag}/wars.
+//
+// List a clan's wars.
+func (c *Client) ListClanWars(ctx context.Context, tag string) (*ClanWars, error) {
+	r := request{method: "GET", path: "/api/v2/clans/" + url.PathEscape(tag) + "/wars"}
+	var out ClanWars
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeclareClanWar sends POST /api/v2/clans/{tag}/wars.
+//
+// Challenge another clan to a war.
+// Token must be the account token returned when the user was created.
+func (c *Client) DeclareClanWar(ctx context.Context, tag string, body DeclareClanWarRequest) (*ClanWar, error) {
+	r := request{method: "POST", path: "/api/v2/clans/" + url.PathEscape(tag) + "/wars", body: body}
+	var out ClanWar
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SendFriendRequest sends POST /api/v2/friends/requests.
+//
+// Send a friend request, or accept the other player's.
+// Token must be the account token returned when the user was created.
+func (c *Client) SendFriendRequest(ctx context.Context, body FriendRequest) (*Friendship, error) {
+	r := request{method: "POST", path: "/api/v2/friends/requests", body: body}
+	var out Friendship
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AcceptFriendRequest sends POST /api/v2/friends/requests/accept.
+//
+// Accept a friend request.
+// Token must be the account token returned when the user was created.
+func (c *Client) AcceptFriendRequest(ctx context.Context, body FriendRequest) (*Friendship, error) {
+	r := request{method: "POST", path: "/api/v2/friends/requests/accept", body: body}
+	var out Friendship
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeclineFriendRequest sends POST /api/v2/friends/requests/decline.
+//
+// Decline a friend request.
+// Token must be the account token returned when the user was created.
+func (c *Client) DeclineFriendRequest(ctx context.Context, body FriendRequest) (*Message, error) {
+	r := request{method: "POST", path: "/api/v2/friends/requests/decline", body: body}
+	var out Message
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetLeaderboardParams are the optional parameters of GetLeaderboard. Zero values are left out.
+type GetLeaderboardParams struct {
+	// Only players from this ISO 3166-1 alpha-2 country
+	Country string
+}
+
+// GetLeaderboard sends GET /api/v2/leaderboard.
+//
+// Get the players with the most coins.
+func (c *Client) GetLeaderboard(ctx context.Context, params *GetLeaderboardParams) (*Leaderboard, error) {
+	r := request{method: "GET", path: "/api/v2/leaderboard", query: url.Values{}}
+	if params != nil {
+		if params.Country != "" {
+			r.query.Set("country", params.Country)
+		}
+	}
+	var out Leaderboard
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetStreakLeaderboard sends GET /api/v2/leaderboard/streaks.
+//
+// Get the players with the best win streaks ever.
+func (c *Client) GetStreakLeaderboard(ctx context.Context) (*StreakLeaderboard, error) {
+	r := request{method: "GET", path: "/api/v2/leaderboard/streaks"}
+	var out StreakLeaderboard
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PlayGame sends POST /api/v2/play.
+//
+// Play a game against the computer.
+func (c *Client) PlayGame(ctx context.Context, body PlayGameRequest) (*PlayGameResponse, error) {
+	r := request{method: "POST", path: "/api/v2/play", body: body}
+	var out PlayGameResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListSeasons sends GET /api/v2/seasons.
+//
+// List every season that has started, newest first.
+func (c *Client) ListSeasons(ctx context.Context) (*SeasonList, error) {
+	r := request{method: "GET", path: "/api/v2/seasons"}
+	var out SeasonList
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSeasonLeaderboard sends GET /api/v2/seasons/{id}/leaderboard.
+//
+// Get the standings of a season.
+func (c *Client) GetSeasonLeaderboard(ctx context.Context, id string) (*SeasonLeaderboard, error) {
+	r := request{method: "GET", path: "/api/v2/seasons/" + url.PathEscape(id) + "/leaderboard"}
+	var out SeasonLeaderboard
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// EquipItem sends POST /api/v2/shop/equip.
+//
+// Equip an owned item in its slot.
+// Token must be the account token returned when the user was created.
+func (c *Client) EquipItem(ctx context.Context, body EquipRequest) (*Equipped, error) {
+	r := request{method: "POST", path: "/api/v2/shop/equip", body: body}
+	var out Equipped
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetShopItems sends GET /api/v2/shop/items.
+//
+// List the items for sale.
+func (c *Client) GetShopItems(ctx context.Context) (*ShopCatalog, error) {
+	r := request{method: "GET", path: "/api/v2/shop/items"}
+	var out ShopCatalog
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PurchaseItem sends POST /api/v2/shop/purchase.
+//
+// Buy an item.
+// Token must be the account token returned when the user was created.
+func (c *Client) PurchaseItem(ctx context.Context, body PurchaseRequest) (*Purchase, error) {
+	r := request{method: "POST", path: "/api/v2/shop/purchase", body: body}
+	var out Purchase
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UnequipSlot sends POST /api/v2/shop/unequip.
+//
+// Clear a cosmetic slot.
+// Token must be the account token returned when the user was created.
+func (c *Client) UnequipSlot(ctx context.Context, body UnequipRequest) (*Unequipped, error) {
+	r := request{method: "POST", path: "/api/v2/shop/unequip", body: body}
+	var out Unequipped
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUserStats sends GET /api/v2/stats/{username}.
+//
+// Get a user's statistics and leaderboard rank.
+func (c *Client) GetUserStats(ctx context.Context, username string) (*UserStats, error) {
+	r := request{method: "GET", path: "/api/v2/stats/" + url.PathEscape(username)}
+	var out UserStats
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTournamentsParams are the optional parameters of ListTournaments. Zero values are left out.
+type ListTournamentsParams struct {
+	// Only tournaments in this state
+	Status TournamentStatus
+}
+
+// ListTournaments sends GET /api/v2/tournaments.
+//
+// List tournaments.
+func (c *Client) ListTournaments(ctx context.Context, params *ListTournamentsParams) (*TournamentList, error) {
+	r := request{method: "GET", path: "/api/v2/tournaments", query: url.Values{}}
+	if params != nil {
+		if params.Status != "" {
+			r.query.Set("status", string(params.Status))
+		}
+	}
+	var out TournamentList
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTournament sends GET /api/v2/tournaments/{id}.
+//
+// Get a tournament.
+func (c *Client) GetTournament(ctx context.Context, id int) (*Tournament, error) {
+	r := request{method: "GET", path: "/api/v2/tournaments/" + strconv.Itoa(id)}
+	var out Tournament
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTournamentBracket sends GET /api/v2/tournaments/{id}/bracket.
+//
+// Get every round of a tournament and its matches.
+func (c *Client) GetTournamentBracket(ctx context.Context, id int) (*Bracket, error) {
+	r := request{method: "GET", path: "/api/v2/tournaments/" + strconv.Itoa(id) + "/bracket"}
+	var out Bracket
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SubmitTournamentMove sends POST /api/v2/tournaments/{id}/moves.
+//
+// Throw in the player's current match.
+// Token must be the account token returned when the user was created.
+func (c *Client) SubmitTournamentMove(ctx context.Context, id int, body TournamentMoveRequest) (*TournamentMatch, error) {
+	r := request{method: "POST", path: "/api/v2/tournaments/" + strconv.Itoa(id) + "/moves", body: body}
+	var out TournamentMatch
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RegisterForTournament sends POST /api/v2/tournaments/{id}/register.
+//
+// Register for a tournament, paying the entry fee.
+// Token must be the account token returned when the user was created.
+func (c *Client) RegisterForTournament(ctx context.Context, id int, body TournamentRegistrationRequest) (*Tournament, error) {
+	r := request{method: "POST", path: "/api/v2/tournaments/" + strconv.Itoa(id) + "/register", body: body}
+	var out Tournament
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTournamentStandings sends GET /api/v2/tournaments/{id}/standings.
+//
+// Get how the players of a tournament are doing.
+func (c *Client) GetTournamentStandings(ctx context.Context, id int) (*TournamentStandings, error) {
+	r := request{method: "GET", path: "/api/v2/tournaments/" + strconv.Itoa(id) + "/standings"}
+	var out TournamentStandings
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// WithdrawFromTournament sends POST /api/v2/tournaments/{id}/withdraw.
+//
+// Withdraw from a tournament; the entry fee is refunded before it starts.
+// Token must be the account token returned when the user was created.
+func (c *Client) WithdrawFromTournament(ctx context.Context, id int, body TournamentRegistrationRequest) (*Tournament, error) {
+	r := request{method: "POST", path: "/api/v2/tournaments/" + strconv.Itoa(id) + "/withdraw", body: body}
+	var out Tournament
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateUser sends POST /api/v2/users.
+//
+// Create a user. The account token in the response cannot be shown again.
+func (c *Client) CreateUser(ctx context.Context, body CreateUserRequest) (*CreateUserResponse, error) {
+	r := request{method: "POST", path: "/api/v2/users", body: body}
+	var out CreateUserResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUser sends GET /api/v2/users/{username}.
+//
+// Get a user's public profile.
+func (c *Client) GetUser(ctx context.Context, username string) (*UserResponse, error) {
+	r := request{method: "GET", path: "/api/v2/users/" + url.PathEscape(username)}
+	var out UserResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateProfile sends PATCH /api/v2/users/{username}.
+//
+// Change the profile fields present in the body; an empty string clears one.
+// Token must be the account token returned when the user was created.
+func (c *Client) UpdateProfile(ctx context.Context, username string, body UpdateProfileRequest) (*UserResponse, error) {
+	r := request{method: "PATCH", path: "/api/v2/users/" + url.PathEscape(username), body: body}
+	var out UserResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUserAchievements sends GET /api/v2/users/{username}/achievements.
+//
+// List every achievement and whether the user has unlocked it.
+func (c *Client) GetUserAchievements(ctx context.Context, username string) (*Achievements, error) {
+	r := request{method: "GET", path: "/api/v2/users/" + url.PathEscape(username) + "/achievements"}
+	var out Achievements
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAnalyticsParams are the optional parameters of GetAnalytics. Zero values are left out.
+type GetAnalyticsParams struct {
+	// Which games to analyse; computer if left out
+	Opponent OpponentType
+}
+
+// GetAnalytics sends GET /api/v2/users/{username}/analytics.
+//
+// Get how a user plays, computed from their games.
+func (c *Client) GetAnalytics(ctx context.Context, username string, params *GetAnalyticsParams) (*PlayerAnalytics, error) {
+	r := request{method: "GET", path: "/api/v2/users/" + url.PathEscape(username) + "/analytics", query: url.Values{}}
+	if params != nil {
+		if params.Opponent != "" {
+			r.query.Set("opponent", string(params.Opponent))
+		}
+	}
+	var out PlayerAnalytics
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UploadAvatar sends POST /api/v2/users/{username}/avatar.
+//
+// Upload a PNG, JPEG or GIF avatar of at most 1 MB and 1024×1024 pixels.
+// Token must be the account token returned when the user was created.
+func (c *Client) UploadAvatar(ctx context.Context, username string, avatar io.Reader) (*UserResponse, error) {
+	r := request{method: "POST", path: "/api/v2/users/" + url.PathEscape(username) + "/avatar", files: map[string]io.Reader{"avatar": avatar}}
+	var out UserResponse
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUserChallengesParams are the optional parameters of GetUserChallenges. Zero values are left out.
+type GetUserChallengesParams struct {
+	// Only challenges in this state
+	Status ChallengeStatus
+}
+
+// GetUserChallenges sends GET /api/v2/users/{username}/challenges.
+//
+// List a user's challenges.
+func (c *Client) GetUserChallenges(ctx context.Context, username string, params *GetUserChallengesParams) (*ChallengeList, error) {
+	r := request{method: "GET", path: "/api/v2/users/" + url.PathEscape(username) + "/challenges", query: url.Values{}}
+	if params != nil {
+		if params.Status != "" {
+			r.query.Set("status", string(params.Status))
+		}
+	}
+	var out ChallengeList
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetClanInvites sends GET /api/v2/users/{username}/clan-invites.
+//
+// List the clans a user is invited to.
+func (c *Client) GetClanInvites(ctx context.Context, username string) (*ClanInvites, error) {
+	r := request{method: "GET", path: "/api/v2/users/" + url.PathEscape(username) + "/clan-invites"}
+	var out ClanInvites
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDailyChallenges sends GET /api/v2/users/{username}/daily-challenges.
+//
+// Get today's challenges and the user's progress.
+func (c *Client) GetDailyChallenges(ctx context.Context, username string) (*DailyChallenges, error) {
+	r := request{method: "GET", path: "/api/v2/users/" + url.PathEscape(username) + "/daily-challenges"}
+	var out DailyChallenges
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ClaimDailyChallenge sends POST /api/v2/users/{username}/daily-challenges/{id}/claim.
+//
+// Claim the reward of a completed challenge.
+// Token must be the account token returned when the user was created.
+func (c *Client) ClaimDailyChallenge(ctx context.Context, username string, id string) (*DailyChallengeClaim, error) {
+	r := request{method: "POST", path: "/api/v2/users/" + url.PathEscape(username) + "/daily-challenges/" + url.PathEscape(id) + "/claim"}
+	var out DailyChallengeClaim
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDailyReward sends GET /api/v2/users/{username}/daily-reward.
+//
+// Get the state of today's login reward.
+func (c *Client) GetDailyReward(ctx context.Context, username string) (*DailyRewardStatus, error) {
+	r := request{method: "GET", path: "/api/v2/users/" + url.PathEscape(username) + "/daily-reward"}
+	var out DailyRewardStatus
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ClaimDailyReward sends POST /api/v2/users/{username}/daily-reward.
+//
+// Claim today's login reward.
+// Token must be the account token returned when the user was created.
+func (c *Client) ClaimDailyReward(ctx context.Context, username string) (*DailyRewardClaim, error) {
+	r := request{method: "POST", path: "/api/v2/users/" + url.PathEscape(username) + "/daily-reward"}
+	var out DailyRewardClaim
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExportAccountData sends GET /api/v2/users/{username}/data-export.
+//
+// Download everything kept about the user as a ZIP of JSON files.
+// Token must be the account token returned when the user was created.
+func (c *Client) ExportAccountData(ctx context.Context, username string) (io.ReadCloser, error) {
+	r := request{method: "GET", path: "/api/v2/users/" + url.PathEscape(username) + "/data-export"}
+	return c.download(ctx, r)
+}
+
+// CancelAccountDeletion sends DELETE /api/v2/users/{username}/deletion.
+//
+// Keep an account still in its deletion grace period.
+// Token must be the account token returned when the user was created.
+func (c *Client) CancelAccountDeletion(ctx context.Context, username string) (*Message, error) {
+	r := request{method: "DELETE", path: "/api/v2/users/" + url.PathEscape(username) + "/deletion"}
+	var out Message
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RequestAccountDeletion sends POST /api/v2/users/{username}/deletion.
+//
+// Schedule the account for deletion after the grace period.
+// Token must be the account token returned when the user was created.
+func (c *Client) RequestAccountDeletion(ctx context.Context, username string) (*DeletionScheduled, error) {
+	r := request{method: "POST", path: "/api/v2/users/" + url.PathEscape(username) + "/deletion"}
+	var out DeletionScheduled
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetFriends sends GET /api/v2/users/{username}/friends.
+//
+// List a user's friends and pending requests.
+func (c *Client) GetFriends(ctx context.Context, username string) (*FriendList, error) {
+	r := request{method: "GET", path: "/api/v2/users/" + url.PathEscape(username) + "/friends"}
+	var out FriendList
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetFriendsLeaderboard sends GET /api/v2/users/{username}/friends/leaderboard.
+//
+// Rank a user and their friends by coins.
+func (c *Client) GetFriendsLeaderboard(ctx context.Context, username string) (*Leaderboard, error) {
+	r := request{method: "GET", path: "/api/v2/users/" + url.PathEscape(username) + "/friends/leaderboard"}
+	var out Leaderboard
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RemoveFriend sends DELETE /api/v2/users/{username}/friends/{friend}.
+//
+// Remove a friend.
+// Token must be the account token returned when the user was created.
+func (c *Client) RemoveFriend(ctx context.Context, username string, friend string) (*Message, error) {
+	r := request{method: "DELETE", path: "/api/v2/users/" + url.PathEscape(username) + "/friends/" + url.PathEscape(friend)}
+	var out Message
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUserGamesParams are the optional parameters of GetUserGames. Zero values are left out.
+type GetUserGamesParams struct {
+	// Only games with this result
+	Result GameResult
+	// Only games where the player threw this
+	Choice Choice
+	// Only games against this kind of opponent
+	Opponent OpponentType
+	// Only games played at or after this date (YYYY-MM-DD) or RFC 3339 time
+	From string
+	// Only games played before this RFC 3339 time, or up to the end of this date (YYYY-MM-DD)
+	To string
+	// Newest first (desc) if left out
+	Order SortOrder
+	// Page size, 20 if left out and at most 100
+	Limit int
+	// The next_cursor of the previous page
+	Cursor string
+}
+
+// GetUserGames sends GET /api/v2/users/{username}/games.
+//
+// Get a page of a user's games.
+func (c *Client) GetUserGames(ctx context.Context, username string, params *GetUserGamesParams) (*GameHistory, error) {
+	r := request{method: "GET", path: "/api/v2/users/" + url.PathEscape(username) + "/games", query: url.Values{}}
+	if params != nil {
+		if params.Result != "" {
+			r.query.Set("result", string(params.Result))
+		}
+		if params.Choice != "" {
+			r.query.Set("choice", string(params.Choice))
+		}
+		if params.Opponent != "" {
+			r.query.Set("opponent", string(params.Opponent))
+		}
+		if params.From != "" {
+			r.query.Set("from", params.From)
+		}
+		if params.To != "" {
+			r.query.Set("to", params.To)
+		}
+		if params.Order != "" {
+			r.query.Set("order", string(params.Order))
+		}
+		if params.Limit != 0 {
+			r.query.Set("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Cursor != "" {
+			r.query.Set("cursor", params.Cursor)
+		}
+	}
+	var out GameHistory
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExportUserGamesParams are the optional parameters of ExportUserGames. Zero values are left out.
+type ExportUserGamesParams struct {
+	// File format; csv if left out
+	Format ExportFormat
+	// Compress the file with gzip
+	Gzip bool
+}
+
+// ExportUserGames sends GET /api/v2/users/{username}/games/export.
+//
+// Download a user's whole game history.
+func (c *Client) ExportUserGames(ctx context.Context, username string, params *ExportUserGamesParams) (io.ReadCloser, error) {
+	r := request{method: "GET", path: "/api/v2/users/" + url.PathEscape(username) + "/games/export", query: url.Values{}}
+	if params != nil {
+		if params.Format != "" {
+			r.query.Set("format", string(params.Format))
+		}
+		if params.Gzip {
+			r.query.Set("gzip", strconv.FormatBool(params.Gzip))
+		}
+	}
+	return c.download(ctx, r)
+}
+
+// GetInventory sends GET /api/v2/users/{username}/inventory.
+//
+// List the items a user owns.
+func (c *Client) GetInventory(ctx context.Context, username string) (*Inventory, error) {
+	r := request{method: "GET", path: "/api/v2/users/" + url.PathEscape(username) + "/inventory"}
+	var out Inventory
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUserSeasons sends GET /api/v2/users/{username}/seasons.
+//
+// List a user's results in finished seasons.
+func (c *Client) GetUserSeasons(ctx context.Context, username string) (*UserSeasons, error) {
+	r := request{method: "GET", path: "/api/v2/users/" + url.PathEscape(username) + "/seasons"}
+	var out UserSeasons
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUserStreaksParams are the optional parameters of GetUserStreaks. Zero values are left out.
+type GetUserStreaksParams struct {
+	// Page size, at most 100
+	Limit int
+	// Items to skip
+	Offset int
+}
+
+// GetUserStreaks sends GET /api/v2/users/{username}/streaks.
+//
+// List a user's win streaks, newest first.
+func (c *Client) GetUserStreaks(ctx context.Context, username string, params *GetUserStreaksParams) (*StreakHistory, error) {
+	r := request{method: "GET", path: "/api/v2/users/" + url.PathEscape(username) + "/streaks", query: url.Values{}}
+	if params != nil {
+		if params.Limit != 0 {
+			r.query.Set("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Offset != 0 {
+			r.query.Set("offset", strconv.Itoa(params.Offset))
+		}
+	}
+	var out StreakHistory
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetTimezone sends PUT /api/v2/users/{username}/timezone.
+//
+// Set the IANA timezone a user's days start in, at most once every 30 days.
+// Token must be the account token returned when the user was created.
+func (c *Client) SetTimezone(ctx context.Context, username string, body SetTimezoneRequest) (*Timezone, error) {
+	r := request{method: "PUT", path: "/api/v2/users/" + url.PathEscape(username) + "/timezone", body: body}
+	var out Timezone
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUserTransactionsParams are the optional parameters of GetUserTransactions. Zero values are left out.
+type GetUserTransactionsParams struct {
+	// Page size, at most 100
+	Limit int
+	// Items to skip
+	Offset int
+}
+
+// GetUserTransactions sends GET /api/v2/users/{username}/transactions.
+//
+// List a user's coin transactions, newest first.
+func (c *Client) GetUserTransactions(ctx context.Context, username string, params *GetUserTransactionsParams) (*TransactionHistory, error) {
+	r := request{method: "GET", path: "/api/v2/users/" + url.PathEscape(username) + "/transactions", query: url.Values{}}
+	if params != nil {
+		if params.Limit != 0 {
+			r.query.Set("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Offset != 0 {
+			r.query.Set("offset", strconv.Itoa(params.Offset))
+		}
+	}
+	var out TransactionHistory
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetHeadToHead sends GET /api/v2/users/{username}/vs/{opponent}.
+//
+// Get a user's record against another player.
+func (c *Client) GetHeadToHead(ctx context.Context, username string, opponent string) (*HeadToHead, error) {
+	r := request{method: "GET", path: "/api/v2/users/" + url.PathEscape(username) + "/vs/" + url.PathEscape(opponent)}
+	var out HeadToHead
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetHealth sends GET /health.
+//
+// Check that the server is up.
+func (c *Client) GetHealth(ctx context.Context) (*Health, error) {
+	r := request{method: "GET", path: "/health"}
+	var out Health
+	if err := c.do(ctx, r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rockpaperscissors/internal/api/openapi"
+	"rockpaperscissors/internal/api/routes"
+	"rockpaperscissors/internal/database"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestGeneratedClientIsCurrent(t *testing.T) {
+	want, err := openapi.GenerateClient(openapi.Spec(2), "client")
+	if err != nil {
+		t.Fatalf("Failed to generate client: %v", err)
+	}
+	got, err := os.ReadFile("client_gen.go")
+	if err != nil {
+		t.Fatalf("Failed to read client_gen.go: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("client_gen.go is out of date; run go generate ./client/v2")
+	}
+}
+
+// setupTestServer serves the whole API over a fresh database
+func setupTestServer(t *testing.T) *httptest.Server {
+	// the HTML templates are loaded relative to the repository root
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(filepath.Join("..", "..")); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	t.Setenv("AVATAR_DIR", t.TempDir())
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.RunMigrations(db.DB); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.SetupRoutes(router, db)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient(t *testing.T) {
+	server := setupTestServer(t)
+	ctx := context.Background()
+	c := New(server.URL, "")
+
+	t.Run("Success - Version 2 game results and leaderboard", func(t *testing.T) {
+		if _, err := c.CreateUser(ctx, CreateUserRequest{Username: "alice"}); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+
+		game, err := c.PlayGame(ctx, PlayGameRequest{Username: "alice", PlayerChoice: ChoiceRock})
+		if err != nil {
+			t.Fatalf("Failed to play: %v", err)
+		}
+		if game.Game.PlayerChoice != ChoiceRock {
+			t.Errorf("Expected rock, got %+v", game.Game)
+		}
+
+		board, err := c.GetLeaderboard(ctx, nil)
+		if err != nil {
+			t.Fatalf("Failed to get leaderboard: %v", err)
+		}
+		if len(board.Leaderboard) != 1 || board.Leaderboard[0].Record.GamesPlayed != 1 {
+			t.Fatalf("Expected alice with 1 game, got %+v", board.Leaderboard)
+		}
+		if board.Leaderboard[0].ClanTag != nil {
+			t.Errorf("Expected no clan tag, got %q", *board.Leaderboard[0].ClanTag)
+		}
+	})
+}
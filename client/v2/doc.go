@@ -0,0 +1,17 @@
+// Package client is a typed Go client for version 2 of the Rock Paper
+// Scissors HTTP API, served under /api/v2. It differs from version 1 in
+// the shape of game results and leaderboard entries. The client, the
+// request and response types and a method for every operation are
+// generated from the server's OpenAPI document into client_gen.go; run go
+// generate after changing a route or a model.
+//
+//	c := client.New("http://localhost:8080", "")
+//	game, err := c.PlayGame(ctx, client.PlayGameRequest{Username: "alice", PlayerChoice: client.ChoiceRock})
+//
+// Admin operations need the server's admin token. Operations on a player's
+// own data, such as changing their profile or timezone, exporting it or
+// deleting the account, need the player's account token; pass it to New or
+// set Token. Error responses are returned as *Error.
+package client
+
+//go:generate go run ../../cmd/openapi -version 2 -client client_gen.go -package client
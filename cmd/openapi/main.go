@@ -0,0 +1,49 @@
+// Command openapi writes the OpenAPI document of a version of the server's
+// API, as served at /api/vN/openapi.json, or generates the typed Go client
+// of that version from it.
+//
+//	go run ./cmd/openapi -version 2 > openapi.json
+//	go run ./cmd/openapi -version 2 -client client/v2/client_gen.go -package client
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"rockpaperscissors/internal/api/openapi"
+)
+
+func main() {
+	clientPath := flag.String("client", "", "write a Go client to this file instead of printing the document")
+	pkg := flag.String("package", "client", "package name of the generated client")
+	version := flag.Int("version", 1, "version of the API")
+	flag.Parse()
+
+	if err := run(*version, *clientPath, *pkg); err != nil {
+		fmt.Fprintln(os.Stderr, "openapi:", err)
+		os.Exit(1)
+	}
+}
+
+func run(version int, clientPath, pkg string) error {
+	doc := openapi.Spec(version)
+	if doc == nil {
+		return fmt.Errorf("there is no version %d of the API", version)
+	}
+	if clientPath == "" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(doc)
+	}
+
+	source, err := openapi.GenerateClient(doc, pkg)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(clientPath, source, 0644); err != nil {
+		return fmt.Errorf("failed to write client: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"rockpaperscissors/internal/models"
+)
+
+// client calls the game's HTTP API
+type client struct {
+	baseURL string
+	token   string // sent as a bearer token when set
+	http    *http.Client
+}
+
+// newClient creates a client for the server at baseURL
+func newClient(baseURL, token string) *client {
+	return &client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// apiError is an error response from the server
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.Status)
+}
+
+// do sends a request with an optional JSON body and decodes a JSON response
+// into out, which may be nil
+func (c *client) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %v", c.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&failure) != nil || failure.Error == "" {
+			failure.Error = http.StatusText(resp.StatusCode)
+		}
+		return &apiError{Status: resp.StatusCode, Message: failure.Error}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
+
+// CreateUser registers a new player
+func (c *client) CreateUser(username string) (*models.CreateUserResponse, error) {
+	var user models.CreateUserResponse
+	if err := c.do("POST", "/api/v1/users", models.CreateUserRequest{Username: username}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUser returns a player's public profile
+func (c *client) GetUser(username string) (*models.UserResponse, error) {
+	var user models.UserResponse
+	if err := c.do("GET", "/api/v1/users/"+url.PathEscape(username), nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetStats returns a player's statistics
+func (c *client) GetStats(username string) (*models.UserStats, error) {
+	var stats models.UserStats
+	if err := c.do("GET", "/api/v1/stats/"+url.PathEscape(username), nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Play plays one game against the computer
+func (c *client) Play(username string, choice models.Choice) (*models.PlayGameResponse, error) {
+	var game models.PlayGameResponse
+	req := models.PlayGameRequest{Username: username, PlayerChoice: choice}
+	if err := c.do("POST", "/api/v1/play", req, &game); err != nil {
+		return nil, err
+	}
+	return &game, nil
+}
+
+// historyPage is a page of game history
+type historyPage struct {
+	Games      []models.Game `json:"games"`
+	NextCursor string        `json:"next_cursor"`
+}
+
+// History returns a player's most recent games, newest first
+func (c *client) History(username string, limit int) (*historyPage, error) {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(limit))
+	var page historyPage
+	if err := c.do("GET", "/api/v1/users/"+url.PathEscape(username)+"/games?"+query.Encode(), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Leaderboard returns the top players, optionally from one country
+func (c *client) Leaderboard(country string) ([]models.LeaderboardEntry, error) {
+	path := "/api/v1/leaderboard"
+	if country != "" {
+		path += "?country=" + url.QueryEscape(country)
+	}
+	var board struct {
+		Leaderboard []models.LeaderboardEntry `json:"leaderboard"`
+	}
+	if err := c.do("GET", path, nil, &board); err != nil {
+		return nil, err
+	}
+	return board.Leaderboard, nil
+}
+
+// CheckToken verifies the client's account token belongs to username, using
+// an endpoint that requires it
+func (c *client) CheckToken(username string) error {
+	return c.do("GET", "/api/v1/users/"+url.PathEscape(username)+"/data-export", nil, nil)
+}
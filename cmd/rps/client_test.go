@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/mattn/go-sqlite3"
+
+	"rockpaperscissors/internal/api/handlers"
+	"rockpaperscissors/internal/api/middleware"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+)
+
+// setupTestServer runs the API endpoints the client uses against a fresh
+// database
+func setupTestServer(t *testing.T) *httptest.Server {
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.RunMigrations(db.DB); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	userHandler := handlers.NewUserHandler(db)
+	gameHandler := handlers.NewGameHandler(db)
+	accountHandler := handlers.NewAccountHandler(db)
+	router.POST("/api/v1/users", userHandler.CreateUser)
+	router.GET("/api/v1/users/:username", userHandler.GetUser)
+	router.GET("/api/v1/stats/:username", userHandler.GetUserStats)
+	router.POST("/api/v1/play", gameHandler.PlayGame)
+	router.GET("/api/v1/leaderboard", userHandler.GetLeaderboard)
+	router.GET("/api/v1/users/:username/games", gameHandler.GetUserGames)
+	router.GET("/api/v1/users/:username/data-export",
+		middleware.UserAuth(services.NewUserService(db).Authenticate), accountHandler.ExportData)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient(t *testing.T) {
+	server := setupTestServer(t)
+	api := newClient(server.URL+"/", "")
+
+	t.Run("Success - Sign up, play and read back", func(t *testing.T) {
+		user, err := api.CreateUser("alice")
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if user.AccountToken == "" {
+			t.Errorf("Expected an account token")
+		}
+
+		game, err := api.Play("alice", models.Rock)
+		if err != nil {
+			t.Fatalf("Failed to play: %v", err)
+		}
+		if game.PlayerChoice != models.Rock || !game.Result.IsValid() {
+			t.Errorf("Expected a rock game with a result, got %+v", game)
+		}
+
+		stats, err := api.GetStats("alice")
+		if err != nil {
+			t.Fatalf("Failed to get stats: %v", err)
+		}
+		if stats.GamesPlayed != 1 || stats.TotalCoins != game.TotalCoins {
+			t.Errorf("Expected 1 game and %d coins, got %d and %d", game.TotalCoins, stats.GamesPlayed, stats.TotalCoins)
+		}
+
+		page, err := api.History("alice", 5)
+		if err != nil {
+			t.Fatalf("Failed to get history: %v", err)
+		}
+		if len(page.Games) != 1 || page.Games[0].PlayerChoice != models.Rock {
+			t.Errorf("Expected the rock game in the history, got %+v", page.Games)
+		}
+
+		entries, err := api.Leaderboard("")
+		if err != nil {
+			t.Fatalf("Failed to get leaderboard: %v", err)
+		}
+		if len(entries) != 1 || entries[0].Username != "alice" {
+			t.Errorf("Expected alice on the leaderboard, got %+v", entries)
+		}
+
+		if err := newClient(server.URL, user.AccountToken).CheckToken("alice"); err != nil {
+			t.Errorf("Expected the account token to be accepted, got %v", err)
+		}
+	})
+
+	t.Run("Error - Server errors are returned with their status", func(t *testing.T) {
+		_, err := api.GetUser("nobody")
+		var apiErr *apiError
+		if !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+			t.Fatalf("Expected a 404 apiError, got %v", err)
+		}
+		if apiErr.Message == "" {
+			t.Errorf("Expected the server's error message")
+		}
+
+		err = newClient(server.URL, "wrong").CheckToken("alice")
+		if !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnauthorized {
+			t.Errorf("Expected a wrong token to be rejected with 401, got %v", err)
+		}
+	})
+
+	t.Run("Success - Series ends once a side has won enough games", func(t *testing.T) {
+		s := newSeries(3)
+		moves := 0
+		next := func() (models.Choice, error) {
+			moves++
+			if moves > 50 {
+				return "", errors.New("series never ended")
+			}
+			return models.Paper, nil
+		}
+		if err := playSeries(api, "alice", s, next, nil); err != nil {
+			t.Fatalf("Failed to play series: %v", err)
+		}
+		if !s.over() || (s.Wins != 2 && s.Losses != 2) {
+			t.Errorf("Expected one side to reach 2 wins, got %s", s.score())
+		}
+		if len(s.Games) != s.Wins+s.Losses+s.Ties {
+			t.Errorf("Expected every game to be recorded, got %d games for %+v", len(s.Games), s)
+		}
+	})
+}
+
+func TestParseChoice(t *testing.T) {
+	for input, want := range map[string]models.Choice{"r": models.Rock, "Paper": models.Paper, " s\n": models.Scissors} {
+		if got, err := parseChoice(input); err != nil || got != want {
+			t.Errorf("parseChoice(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := parseChoice("lizard"); err == nil {
+		t.Errorf("Expected an error for an unknown move")
+	}
+}
+
+func TestNewSeries_RoundsUpToOdd(t *testing.T) {
+	for bestOf, want := range map[int]int{0: 1, 1: 1, 4: 5, 7: 7} {
+		if got := newSeries(bestOf).BestOf; got != want {
+			t.Errorf("newSeries(%d).BestOf = %d, want %d", bestOf, got, want)
+		}
+	}
+}
+
+func TestConfig_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.json")
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load missing config: %v", err)
+	}
+	if cfg.Server != defaultServer {
+		t.Errorf("Expected the default server, got %q", cfg.Server)
+	}
+
+	cfg.Username = "alice"
+	cfg.AccountToken = "secret"
+	if err := cfg.save(path); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	loaded, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if *loaded != *cfg {
+		t.Errorf("Expected %+v, got %+v", cfg, loaded)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// defaultServer is used until a server is configured
+const defaultServer = "http://localhost:8080"
+
+// config is what the client remembers between runs. The account token is
+// stored as given, so the file is only readable by its owner.
+type config struct {
+	Server       string `json:"server"`
+	Username     string `json:"username,omitempty"`
+	AccountToken string `json:"account_token,omitempty"`
+}
+
+// configPath returns the config file to use: the -config flag, then
+// RPS_CONFIG, then rps/config.json in the user's config directory
+func configPath(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if env := os.Getenv("RPS_CONFIG"); env != "" {
+		return env, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find config directory: %v", err)
+	}
+	return filepath.Join(dir, "rps", "config.json"), nil
+}
+
+// loadConfig reads the config file. A missing file gives the defaults.
+func loadConfig(path string) (*config, error) {
+	cfg := &config{Server: defaultServer}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %v", path, err)
+	}
+	if cfg.Server == "" {
+		cfg.Server = defaultServer
+	}
+	return cfg, nil
+}
+
+// save writes the config file, creating its directory if needed
+func (c *config) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write config: %v", err)
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Chmod(path, 0o600); err != nil {
+		return fmt.Errorf("failed to protect config: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,506 @@
+// Command rps plays Rock Paper Scissors against a running server from the
+// command line. It can sign up or log in a player, play single games or a
+// best-of-N series, show stats, history and the leaderboard, and run a
+// keyboard-driven TUI.
+//
+// The server URL and the player's credentials are kept in a config file, so
+// after signing up once the other commands need no arguments. With -json
+// every command prints the server's JSON instead of text, for scripting.
+//
+//	go run ./cmd/rps signup alice
+//	go run ./cmd/rps play rock
+//	go run ./cmd/rps tui -n 5
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"rockpaperscissors/internal/models"
+)
+
+// app is the state shared by the subcommands
+type app struct {
+	cfg      *config
+	cfgPath  string
+	username string // -user, or the logged in player
+	json     bool
+	out      io.Writer
+	in       *bufio.Reader
+}
+
+// command is a subcommand; run receives the arguments after its name
+type command struct {
+	usage string
+	run   func(a *app, args []string) error
+}
+
+var commands = map[string]command{
+	"signup":      {"signup USERNAME             create a player and log in as them", runSignup},
+	"login":       {"login [-token T] USERNAME   log in as an existing player", runLogin},
+	"logout":      {"logout                      forget the logged in player", runLogout},
+	"config":      {"config [-server URL]        show or change the config", runConfig},
+	"whoami":      {"whoami                      show the logged in player", runWhoami},
+	"play":        {"play [CHOICE]               play one game (rock, paper, scissors or r, p, s)", runPlay},
+	"match":       {"match [-n 3] [CHOICES...]   play a best-of-N series", runMatch},
+	"stats":       {"stats [USERNAME]            show a player's statistics", runStats},
+	"history":     {"history [-limit 10] [USER]  show recent games", runHistory},
+	"leaderboard": {"leaderboard [-country XX]   show the top players", runLeaderboard},
+	"tui":         {"tui [-n N]                  play with single key presses", runTUIcommand},
+}
+
+// commandOrder is the order commands are listed in the usage message
+var commandOrder = []string{"signup", "login", "logout", "config", "whoami", "play", "match", "stats", "history", "leaderboard", "tui"}
+
+func main() {
+	server := flag.String("server", "", "server URL (default from the config file, then "+defaultServer+")")
+	user := flag.String("user", "", "player to act as (default the logged in player)")
+	configFlag := flag.String("config", "", "config file (default $RPS_CONFIG, then rps/config.json in the user config directory)")
+	jsonOutput := flag.Bool("json", false, "print JSON instead of text")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "rps: unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	path, err := configPath(*configFlag)
+	if err != nil {
+		fail(err)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		fail(err)
+	}
+	if *server != "" {
+		cfg.Server = *server
+	}
+
+	a := &app{
+		cfg:      cfg,
+		cfgPath:  path,
+		username: *user,
+		json:     *jsonOutput,
+		out:      os.Stdout,
+		in:       bufio.NewReader(os.Stdin),
+	}
+	if a.username == "" {
+		a.username = cfg.Username
+	}
+	if err := cmd.run(a, flag.Args()[1:]); err != nil {
+		fail(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: rps [flags] COMMAND [args]\n\nCommands:\n")
+	for _, name := range commandOrder {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "rps: %v\n", err)
+	os.Exit(1)
+}
+
+// client returns an API client for the configured server. The account
+// token is only sent for the logged in player.
+func (a *app) client() *client {
+	token := ""
+	if a.username == a.cfg.Username {
+		token = a.cfg.AccountToken
+	}
+	return newClient(a.cfg.Server, token)
+}
+
+// player returns the player to act as, or an error if there is none
+func (a *app) player() (string, error) {
+	if a.username == "" {
+		return "", errors.New("no player given: log in with 'rps signup' or 'rps login', or pass -user")
+	}
+	return a.username, nil
+}
+
+// playerArg returns the optional USERNAME argument, defaulting to the player
+func (a *app) playerArg(fs *flag.FlagSet) (string, error) {
+	if fs.NArg() > 0 {
+		return fs.Arg(0), nil
+	}
+	return a.player()
+}
+
+// print writes v as indented JSON
+func (a *app) print(v interface{}) error {
+	enc := json.NewEncoder(a.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// prompt asks for a move on stderr and reads it from stdin, asking again
+// until the answer is valid
+func (a *app) prompt(label string) (models.Choice, error) {
+	for {
+		fmt.Fprintf(os.Stderr, "%s [r/p/s]: ", label)
+		line, err := a.in.ReadString('\n')
+		if strings.TrimSpace(line) == "" && err != nil {
+			if err == io.EOF {
+				return "", errors.New("no move given")
+			}
+			return "", err
+		}
+		choice, parseErr := parseChoice(line)
+		if parseErr == nil {
+			return choice, nil
+		}
+		fmt.Fprintln(os.Stderr, parseErr)
+		if err != nil {
+			return "", errors.New("no move given")
+		}
+	}
+}
+
+// newFlagSet creates the flag set of a subcommand
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: rps [flags] %s [flags] [args]\n", name)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// login remembers a player and their account token
+func (a *app) login(username, token string) error {
+	a.cfg.Username = username
+	a.cfg.AccountToken = token
+	a.username = username
+	return a.cfg.save(a.cfgPath)
+}
+
+func runSignup(a *app, args []string) error {
+	fs := newFlagSet("signup")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("a username is required")
+	}
+
+	user, err := a.client().CreateUser(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := a.login(user.Username, user.AccountToken); err != nil {
+		return err
+	}
+	if a.json {
+		return a.print(user)
+	}
+	fmt.Fprintf(a.out, "Welcome, %s! You start with %d coins.\n", user.Username, user.TotalCoins)
+	fmt.Fprintf(a.out, "Your account token is saved in %s; it is needed to export or delete your account.\n", a.cfgPath)
+	return nil
+}
+
+func runLogin(a *app, args []string) error {
+	fs := newFlagSet("login")
+	token := fs.String("token", "", "account token, needed to export or delete the account")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("a username is required")
+	}
+
+	api := newClient(a.cfg.Server, *token)
+	user, err := api.GetUser(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *token != "" {
+		if err := api.CheckToken(user.Username); err != nil {
+			return fmt.Errorf("token rejected: %v", err)
+		}
+	}
+	if err := a.login(user.Username, *token); err != nil {
+		return err
+	}
+	if a.json {
+		return a.print(user)
+	}
+	fmt.Fprintf(a.out, "Logged in as %s.\n", user.Username)
+	return nil
+}
+
+func runLogout(a *app, args []string) error {
+	fs := newFlagSet("logout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := a.login("", ""); err != nil {
+		return err
+	}
+	if !a.json {
+		fmt.Fprintln(a.out, "Logged out.")
+	}
+	return nil
+}
+
+func runConfig(a *app, args []string) error {
+	fs := newFlagSet("config")
+	server := fs.String("server", "", "server URL to save")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *server != "" {
+		a.cfg.Server = *server
+		if err := a.cfg.save(a.cfgPath); err != nil {
+			return err
+		}
+	}
+
+	if a.json {
+		return a.print(struct {
+			Path     string `json:"path"`
+			Server   string `json:"server"`
+			Username string `json:"username,omitempty"`
+			HasToken bool   `json:"has_account_token"`
+		}{a.cfgPath, a.cfg.Server, a.cfg.Username, a.cfg.AccountToken != ""})
+	}
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Config file:\t%s\n", a.cfgPath)
+	fmt.Fprintf(w, "Server:\t%s\n", a.cfg.Server)
+	fmt.Fprintf(w, "Player:\t%s\n", a.cfg.Username)
+	fmt.Fprintf(w, "Account token:\t%v\n", a.cfg.AccountToken != "")
+	return w.Flush()
+}
+
+func runWhoami(a *app, args []string) error {
+	fs := newFlagSet("whoami")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	username, err := a.player()
+	if err != nil {
+		return err
+	}
+	user, err := a.client().GetUser(username)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.print(user)
+	}
+	fmt.Fprintf(a.out, "%s — %d coins, streak %d\n", displayName(user.Username, user.ClanTag), user.TotalCoins, user.CurrentStreak)
+	return nil
+}
+
+func runPlay(a *app, args []string) error {
+	fs := newFlagSet("play")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	username, err := a.player()
+	if err != nil {
+		return err
+	}
+
+	var choice models.Choice
+	if fs.NArg() > 0 {
+		choice, err = parseChoice(fs.Arg(0))
+	} else {
+		choice, err = a.prompt("Your move")
+	}
+	if err != nil {
+		return err
+	}
+
+	game, err := a.client().Play(username, choice)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.print(game)
+	}
+	printGame(a.out, game)
+	return nil
+}
+
+// printGame describes one game and what it paid
+func printGame(w io.Writer, game *models.PlayGameResponse) {
+	fmt.Fprintf(w, "You played %s, the computer played %s. %s\n", game.PlayerChoice, game.ComputerChoice, game.Message)
+	for _, achievement := range game.NewAchievements {
+		fmt.Fprintf(w, "Achievement unlocked: %s\n", achievement.Name)
+	}
+}
+
+func runMatch(a *app, args []string) error {
+	fs := newFlagSet("match")
+	bestOf := fs.Int("n", 3, "games in the series; ties are replayed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	username, err := a.player()
+	if err != nil {
+		return err
+	}
+
+	// Moves given as arguments are played first, then the rest are asked for
+	var moves []models.Choice
+	for _, arg := range fs.Args() {
+		choice, err := parseChoice(arg)
+		if err != nil {
+			return err
+		}
+		moves = append(moves, choice)
+	}
+
+	s := newSeries(*bestOf)
+	next := func() (models.Choice, error) {
+		if len(moves) > 0 {
+			choice := moves[0]
+			moves = moves[1:]
+			return choice, nil
+		}
+		return a.prompt(fmt.Sprintf("Game %d", len(s.Games)+1))
+	}
+	onGame := func(game *models.PlayGameResponse) {
+		if !a.json {
+			printGame(a.out, game)
+			fmt.Fprintf(a.out, "Series %s\n", s.score())
+		}
+	}
+	if err := playSeries(a.client(), username, s, next, onGame); err != nil {
+		return err
+	}
+
+	if a.json {
+		return a.print(s)
+	}
+	if s.Result == models.Win {
+		fmt.Fprintf(a.out, "You won the series, earning %d coins.\n", s.Coins)
+	} else {
+		fmt.Fprintf(a.out, "The computer won the series. You earned %d coins.\n", s.Coins)
+	}
+	return nil
+}
+
+func runStats(a *app, args []string) error {
+	fs := newFlagSet("stats")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	username, err := a.playerArg(fs)
+	if err != nil {
+		return err
+	}
+	stats, err := a.client().GetStats(username)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.print(stats)
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Player:\t%s\n", displayName(stats.Username, stats.ClanTag))
+	fmt.Fprintf(w, "Coins:\t%d\n", stats.TotalCoins)
+	fmt.Fprintf(w, "Games:\t%d played, %d won (%.1f%%)\n", stats.GamesPlayed, stats.GamesWon, stats.WinRate*100)
+	fmt.Fprintf(w, "Streak:\t%d (best %d)\n", stats.CurrentStreak, stats.BestStreak)
+	fmt.Fprintf(w, "Rank:\t%d\n", stats.Rank)
+	return w.Flush()
+}
+
+func runHistory(a *app, args []string) error {
+	fs := newFlagSet("history")
+	limit := fs.Int("limit", 10, "games to show")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	username, err := a.playerArg(fs)
+	if err != nil {
+		return err
+	}
+	page, err := a.client().History(username, *limit)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.print(page)
+	}
+
+	if len(page.Games) == 0 {
+		fmt.Fprintln(a.out, "No games played yet.")
+		return nil
+	}
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PLAYED\tYOU\tOPPONENT\tRESULT\tCOINS")
+	for _, g := range page.Games {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n", g.PlayedAt.Local().Format("2006-01-02 15:04"), g.PlayerChoice, g.ComputerChoice, g.Result, g.CoinsEarned)
+	}
+	return w.Flush()
+}
+
+func runLeaderboard(a *app, args []string) error {
+	fs := newFlagSet("leaderboard")
+	country := fs.String("country", "", "only players from this country (ISO 3166-1 alpha-2 code)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	entries, err := a.client().Leaderboard(*country)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.print(entries)
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RANK\tPLAYER\tCOINS\tGAMES\tWIN RATE")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%.1f%%\n", e.Rank, displayName(e.Username, e.ClanTag), e.TotalCoins, e.GamesPlayed, e.WinRate*100)
+	}
+	return w.Flush()
+}
+
+func runTUIcommand(a *app, args []string) error {
+	fs := newFlagSet("tui")
+	bestOf := fs.Int("n", 0, "play best-of-N series instead of single games")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	username, err := a.player()
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return errors.New("the TUI has no JSON output, use play or match instead")
+	}
+	return runTUI(a.client(), username, *bestOf)
+}
+
+// displayName shows a player with their clan tag, if they have one
+func displayName(username, clanTag string) string {
+	if clanTag == "" {
+		return username
+	}
+	return fmt.Sprintf("[%s] %s", clanTag, username)
+}
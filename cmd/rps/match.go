@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"rockpaperscissors/internal/models"
+)
+
+// parseChoice accepts a move by name or by its first letter
+func parseChoice(input string) (models.Choice, error) {
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "r", "rock":
+		return models.Rock, nil
+	case "p", "paper":
+		return models.Paper, nil
+	case "s", "scissors":
+		return models.Scissors, nil
+	}
+	return "", fmt.Errorf("invalid choice %q, must be rock, paper or scissors (or r, p, s)", input)
+}
+
+// series is a best-of-N match against the computer, played as one game
+// after another. Ties are replayed, like in challenges.
+type series struct {
+	BestOf int                       `json:"best_of"`
+	Wins   int                       `json:"wins"`
+	Losses int                       `json:"losses"`
+	Ties   int                       `json:"ties"`
+	Coins  int                       `json:"coins_earned"`
+	Games  []models.PlayGameResponse `json:"games"`
+	Result models.GameResult         `json:"result,omitempty"`
+}
+
+// newSeries starts a best-of-N series; N is rounded up to an odd number
+func newSeries(bestOf int) *series {
+	if bestOf < 1 {
+		bestOf = 1
+	}
+	if bestOf%2 == 0 {
+		bestOf++
+	}
+	return &series{BestOf: bestOf, Games: []models.PlayGameResponse{}}
+}
+
+// needed is how many games a side has to win to take the series
+func (s *series) needed() int {
+	return s.BestOf/2 + 1
+}
+
+// over reports whether one side has won the series
+func (s *series) over() bool {
+	return s.Result != ""
+}
+
+// record adds a played game to the series
+func (s *series) record(game *models.PlayGameResponse) {
+	s.Games = append(s.Games, *game)
+	s.Coins += game.CoinsEarned
+	switch game.Result {
+	case models.Win:
+		s.Wins++
+	case models.Lose:
+		s.Losses++
+	default:
+		s.Ties++
+	}
+	switch {
+	case s.Wins >= s.needed():
+		s.Result = models.Win
+	case s.Losses >= s.needed():
+		s.Result = models.Lose
+	}
+}
+
+// score describes the series so far
+func (s *series) score() string {
+	return fmt.Sprintf("best of %d: you %d - %d computer", s.BestOf, s.Wins, s.Losses)
+}
+
+// playSeries plays games until the series is decided, asking next for
+// each move
+func playSeries(api *client, username string, s *series, next func() (models.Choice, error), onGame func(*models.PlayGameResponse)) error {
+	for !s.over() {
+		choice, err := next()
+		if err != nil {
+			return err
+		}
+		game, err := api.Play(username, choice)
+		if err != nil {
+			return err
+		}
+		s.record(game)
+		if onGame != nil {
+			onGame(game)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// makeRaw switches the terminal to raw mode so single key presses can be
+// read, and returns a function that restores it. It uses stty to stay free
+// of platform-specific ioctls.
+func makeRaw() (func(), error) {
+	if !isTerminal(os.Stdin) {
+		return nil, fmt.Errorf("stdin is not a terminal")
+	}
+	state, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+	return func() { stty(state) }, nil
+}
+
+// stty runs stty against the terminal on stdin
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("stty %s failed: %v", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// isTerminal reports whether f is a character device, such as a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
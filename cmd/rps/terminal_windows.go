@@ -0,0 +1,20 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// makeRaw is not supported on Windows; the TUI falls back to reading a line
+// per key
+func makeRaw() (func(), error) {
+	return nil, fmt.Errorf("raw terminal mode is not supported on Windows")
+}
+
+// isTerminal reports whether f is a character device, such as a console
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
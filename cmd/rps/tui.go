@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"rockpaperscissors/internal/models"
+)
+
+// choiceIcons are shown next to moves in the TUI
+var choiceIcons = map[models.Choice]string{
+	models.Rock:     "🪨",
+	models.Paper:    "📄",
+	models.Scissors: "✂️",
+}
+
+// tui is the keyboard-driven full screen client
+type tui struct {
+	api      *client
+	username string
+	bestOf   int // 0 for free play
+	out      io.Writer
+	keys     *bufio.Reader
+	raw      bool // keys arrive one at a time rather than a line at a time
+
+	user    *models.UserResponse
+	last    *models.PlayGameResponse
+	series  *series
+	view    string // "play", "leaderboard" or "history"
+	message string
+}
+
+// runTUI plays in the terminal until the player quits. Without a terminal
+// that supports raw mode each key has to be followed by Enter.
+func runTUI(api *client, username string, bestOf int) error {
+	t := &tui{
+		api:      api,
+		username: username,
+		bestOf:   bestOf,
+		out:      os.Stdout,
+		keys:     bufio.NewReader(os.Stdin),
+		view:     "play",
+	}
+	if restore, err := makeRaw(); err == nil {
+		defer restore()
+		t.raw = true
+	}
+	if bestOf > 0 {
+		t.series = newSeries(bestOf)
+	}
+	if err := t.refreshUser(); err != nil {
+		return err
+	}
+
+	for {
+		t.render()
+		key, err := t.readKey()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !t.handleKey(key) {
+			t.clear()
+			return nil
+		}
+	}
+}
+
+// readKey returns the next key pressed, lower-cased
+func (t *tui) readKey() (byte, error) {
+	if t.raw {
+		key, err := t.keys.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		return strings.ToLower(string(key))[0], nil
+	}
+	for {
+		line, err := t.keys.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" {
+			return strings.ToLower(line)[0], nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// handleKey acts on a key press and reports whether to keep running
+func (t *tui) handleKey(key byte) bool {
+	t.message = ""
+	switch key {
+	case 'q', 3, 27: // q, Ctrl-C, Esc
+		return false
+	case 'r', 'p', 's':
+		t.view = "play"
+		choice, _ := parseChoice(string(key))
+		t.play(choice)
+	case 'l':
+		t.toggle("leaderboard")
+	case 'h':
+		t.toggle("history")
+	case 'n':
+		if t.bestOf > 0 {
+			t.series = newSeries(t.bestOf)
+			t.last = nil
+			t.message = "New series started"
+		}
+	}
+	return true
+}
+
+// toggle switches to a view, or back to playing if it is already shown
+func (t *tui) toggle(view string) {
+	if t.view == view {
+		t.view = "play"
+	} else {
+		t.view = view
+	}
+}
+
+// play plays one game and updates the screen state
+func (t *tui) play(choice models.Choice) {
+	if t.series != nil && t.series.over() {
+		t.message = "The series is over, press n for a new one"
+		return
+	}
+	game, err := t.api.Play(t.username, choice)
+	if err != nil {
+		t.message = "Error: " + err.Error()
+		return
+	}
+	t.last = game
+	if t.series != nil {
+		t.series.record(game)
+	}
+	if err := t.refreshUser(); err != nil {
+		t.message = "Error: " + err.Error()
+	}
+}
+
+// refreshUser reloads the player's coins and streak
+func (t *tui) refreshUser() error {
+	user, err := t.api.GetUser(t.username)
+	if err != nil {
+		return err
+	}
+	t.user = user
+	return nil
+}
+
+// clear wipes the screen
+func (t *tui) clear() {
+	fmt.Fprint(t.out, "\033[H\033[2J")
+}
+
+// render draws the whole screen
+func (t *tui) render() {
+	var lines []string
+	add := func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	add("🪨📄✂️  Rock Paper Scissors — %s", displayName(t.user.Username, t.user.ClanTag))
+	add("Coins: %d   Streak: %d (best %d)   Won %d of %d (%.1f%%)",
+		t.user.TotalCoins, t.user.CurrentStreak, t.user.BestStreak, t.user.GamesWon, t.user.GamesPlayed, t.user.WinRate*100)
+	add("")
+
+	switch t.view {
+	case "leaderboard":
+		t.renderLeaderboard(add)
+	case "history":
+		t.renderHistory(add)
+	default:
+		t.renderPlay(add)
+	}
+
+	add("")
+	if t.message != "" {
+		add("%s", t.message)
+	}
+	keys := "[r]ock  [p]aper  [s]cissors   [l]eaderboard  [h]istory  "
+	if t.bestOf > 0 {
+		keys += "[n]ew series  "
+	}
+	keys += "[q]uit"
+	if !t.raw {
+		keys += "   (press Enter after each key)"
+	}
+	add("%s", keys)
+
+	t.clear()
+	// raw mode does not turn \n into a carriage return
+	fmt.Fprint(t.out, strings.Join(lines, "\r\n")+"\r\n")
+}
+
+// renderPlay draws the last game and the series score
+func (t *tui) renderPlay(add func(string, ...interface{})) {
+	if t.series != nil {
+		add("Series %s", t.series.score())
+		switch t.series.Result {
+		case models.Win:
+			add("🏆 You won the series! +%d coins", t.series.Coins)
+		case models.Lose:
+			add("The computer took the series.")
+		}
+		add("")
+	}
+	if t.last == nil {
+		add("Make your move.")
+		return
+	}
+	add("You %s %s  vs  %s %s computer", choiceIcons[t.last.PlayerChoice], t.last.PlayerChoice,
+		t.last.ComputerChoice, choiceIcons[t.last.ComputerChoice])
+	add("%s", t.last.Message)
+	for _, achievement := range t.last.NewAchievements {
+		add("🏅 Achievement unlocked: %s", achievement.Name)
+	}
+}
+
+// renderLeaderboard draws the top players
+func (t *tui) renderLeaderboard(add func(string, ...interface{})) {
+	entries, err := t.api.Leaderboard("")
+	if err != nil {
+		add("Error: %v", err)
+		return
+	}
+	add("Leaderboard")
+	for _, e := range entries {
+		add("%3d. %-26s %8d coins  %5.1f%%", e.Rank, displayName(e.Username, e.ClanTag), e.TotalCoins, e.WinRate*100)
+	}
+}
+
+// renderHistory draws the player's recent games
+func (t *tui) renderHistory(add func(string, ...interface{})) {
+	page, err := t.api.History(t.username, 10)
+	if err != nil {
+		add("Error: %v", err)
+		return
+	}
+	add("Recent games")
+	for _, g := range page.Games {
+		add("%s  %-8s vs %-8s  %-4s  +%d", g.PlayedAt.Local().Format("Jan 02 15:04"), g.PlayerChoice, g.ComputerChoice, g.Result, g.CoinsEarned)
+	}
+}
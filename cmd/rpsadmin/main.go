@@ -0,0 +1,637 @@
+// Command rpsadmin maintains the game's database directly, without going
+// through the server: it lists and searches users, adjusts coins, checks
+// and repairs the counters stored on users, prunes old games into an
+// archive, vacuums and analyzes the file, and seeds fake players for local
+// testing. It also takes, checks and restores backups.
+//
+// It opens the same file the server does (data/rockpaperscissors.db under
+// the working directory) unless -db says otherwise, and brings its schema
+// up to date first; listing backups, verifying and restoring work on files
+// and leave it closed. Changes to users are written to the admin audit log.
+//
+//	go run ./cmd/rpsadmin recompute -dry-run
+//	go run ./cmd/rpsadmin prune -older-than 180 -archive games-2024.jsonl.gz
+//	go run ./cmd/rpsadmin -db /tmp/dev.db seed -users 50
+//	go run ./cmd/rpsadmin restore -wal-archive data/wal -at 2025-03-01T12:00:00Z
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+)
+
+// app is the state shared by the subcommands
+type app struct {
+	db     *database.DB // nil for offline commands
+	dbPath string
+	actor  string
+	json   bool
+	out    io.Writer
+}
+
+// command is a subcommand; run receives the arguments after its name
+type command struct {
+	usage   string
+	create  bool // may create the database rather than needing it to exist
+	offline bool // works on files and never opens the database
+	run     func(a *app, args []string) error
+}
+
+var commands = map[string]command{
+	"users":        {usage: "users [-status S] [-limit N] [-offset N] [QUERY]   list users, optionally matching QUERY", run: runUsers},
+	"adjust-coins": {usage: "adjust-coins -reason R USERNAME AMOUNT           credit or debit coins", run: runAdjustCoins},
+	"recompute":    {usage: "recompute [-dry-run]                              repair users' counters from games and the ledger", run: runRecompute},
+	"prune":        {usage: "prune (-before DATE | -older-than DAYS) -archive FILE   archive and delete old games against the computer", run: runPrune},
+	"vacuum":       {usage: "vacuum                                            rebuild the file to reclaim free space", run: runVacuum},
+	"analyze":      {usage: "analyze                                           refresh the query planner's statistics", run: runAnalyze},
+	"seed":         {usage: "seed [-users N] [-games N] [-days N] [-prefix P] [-seed N]   create fake players", create: true, run: runSeed},
+	"backup":       {usage: "backup [-dir DIR] [-prune]                         take an online backup, safe while the server runs", run: runBackup},
+	"backups":      {usage: "backups [-dir DIR]                                list backups", offline: true, run: runBackups},
+	"verify":       {usage: "verify FILE...                                    check database files for corruption", offline: true, run: runVerify},
+	"restore":      {usage: "restore (-backup FILE | -wal-archive DIR [-at TIME])   replace the database; stop the server first", offline: true, run: runRestore},
+}
+
+// commandOrder is the order commands are listed in the usage message
+var commandOrder = []string{"users", "adjust-coins", "recompute", "prune", "vacuum", "analyze", "seed", "backup", "backups", "verify", "restore"}
+
+func main() {
+	dbPath := flag.String("db", database.DefaultPath, "database file")
+	actor := flag.String("actor", defaultActor(), "name recorded in the admin audit log")
+	jsonOutput := flag.Bool("json", false, "print JSON instead of text")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "rpsadmin: unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	a := &app{dbPath: *dbPath, actor: *actor, json: *jsonOutput, out: os.Stdout}
+	if cmd.offline {
+		if err := cmd.run(a, flag.Args()[1:]); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	// only seeding may start a database from nothing; anything else on a
+	// missing file is most likely the wrong path
+	if _, err := os.Stat(*dbPath); err != nil && !cmd.create {
+		fail(fmt.Errorf("cannot open database %s: %v", *dbPath, err))
+	}
+	config, err := database.LoadConfig(*dbPath)
+	if err != nil {
+		fail(err)
+	}
+	db, err := database.OpenWithConfig(config)
+	if err != nil {
+		fail(err)
+	}
+	defer db.Close()
+	if err := database.RunMigrations(db.DB); err != nil {
+		fail(err)
+	}
+
+	a.db = db
+	if err := cmd.run(a, flag.Args()[1:]); err != nil {
+		db.Close()
+		fail(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: rpsadmin [flags] COMMAND [args]\n\nCommands:\n")
+	for _, name := range commandOrder {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "rpsadmin: %v\n", err)
+	os.Exit(1)
+}
+
+// defaultActor names the person running the tool in the audit log
+func defaultActor() string {
+	if user := os.Getenv("USER"); user != "" {
+		return "rpsadmin:" + user
+	}
+	return "rpsadmin"
+}
+
+// print writes v as indented JSON
+func (a *app) print(v interface{}) error {
+	enc := json.NewEncoder(a.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// newFlagSet creates the flag set of a subcommand
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: rpsadmin [flags] %s [flags] [args]\n", name)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func runUsers(a *app, args []string) error {
+	fs := newFlagSet("users")
+	status := fs.String("status", "", "only users with this status: active, suspended or banned")
+	limit := fs.Int("limit", 20, "users to list")
+	offset := fs.Int("offset", 0, "users to skip")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	users, total, err := services.NewAdminService(a.db).SearchUsers(fs.Arg(0), models.UserStatus(*status), *limit, *offset)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.print(map[string]interface{}{"users": users, "total": total})
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSERNAME\tSTATUS\tCOINS\tGAMES\tWON\tSTREAK\tCREATED")
+	for _, u := range users {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%d\t%d\t%s\n", u.ID, u.Username, u.Status, u.TotalCoins, u.GamesPlayed, u.GamesWon,
+			u.CurrentStreak, u.CreatedAt.UTC().Format("2006-01-02"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "%d of %d users\n", len(users), total)
+	return nil
+}
+
+func runAdjustCoins(a *app, args []string) error {
+	fs := newFlagSet("adjust-coins")
+	reason := fs.String("reason", "", "why the coins are adjusted, for the audit log (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 || strings.TrimSpace(*reason) == "" {
+		fs.Usage()
+		return errors.New("a username, an amount and a reason are required")
+	}
+	amount, err := strconv.Atoi(fs.Arg(1))
+	if err != nil {
+		return fmt.Errorf("invalid amount %q: must be a whole number of coins", fs.Arg(1))
+	}
+
+	entry, err := services.NewAdminService(a.db).AdjustCoins(a.actor, fs.Arg(0), amount, *reason)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.print(entry)
+	}
+	fmt.Fprintf(a.out, "Adjusted %s by %+d coins; balance is now %d.\n", fs.Arg(0), entry.Amount, entry.BalanceAfter)
+	return nil
+}
+
+func runRecompute(a *app, args []string) error {
+	fs := newFlagSet("recompute")
+	dryRun := fs.Bool("dry-run", false, "only report drift, change nothing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	maintenance := services.NewMaintenanceService(a.db)
+	var drifts []models.AggregateDrift
+	var err error
+	if *dryRun {
+		drifts, err = maintenance.CheckAggregates()
+	} else {
+		drifts, err = maintenance.RepairAggregates(a.actor)
+	}
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.print(map[string]interface{}{"drift": drifts, "repaired": !*dryRun && len(drifts) > 0})
+	}
+
+	if len(drifts) == 0 {
+		fmt.Fprintln(a.out, "No drift: every user's counters match their games and ledger.")
+		return nil
+	}
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USER\tGAMES PLAYED\tGAMES WON\tCOINS\tSTREAK")
+	for _, d := range drifts {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.Username,
+			driftCell(d.Stored.GamesPlayed, d.Expected.GamesPlayed),
+			driftCell(d.Stored.GamesWon, d.Expected.GamesWon),
+			driftCell(d.Stored.TotalCoins, d.Expected.TotalCoins),
+			driftCell(d.Stored.CurrentStreak, d.Expected.CurrentStreak))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if *dryRun {
+		fmt.Fprintf(a.out, "Users with drift: %d. Run without -dry-run to repair them.\n", len(drifts))
+	} else {
+		fmt.Fprintf(a.out, "Users repaired: %d.\n", len(drifts))
+	}
+	return nil
+}
+
+// driftCell shows a stored counter, and what it should be if that differs
+func driftCell(stored, expected int) string {
+	if stored == expected {
+		return strconv.Itoa(stored)
+	}
+	return fmt.Sprintf("%d -> %d", stored, expected)
+}
+
+func runPrune(a *app, args []string) error {
+	fs := newFlagSet("prune")
+	before := fs.String("before", "", "delete games played before this date (YYYY-MM-DD) or RFC 3339 time")
+	olderThan := fs.Int("older-than", 0, "delete games played more than this many days ago")
+	archivePath := fs.String("archive", "", "file to write the deleted games to as JSON lines, gzipped if it ends in .gz (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *archivePath == "" {
+		fs.Usage()
+		return errors.New("an -archive file is required")
+	}
+	cutoff, err := pruneCutoff(*before, *olderThan, time.Now())
+	if err != nil {
+		return err
+	}
+
+	archive, err := createArchive(*archivePath)
+	if err != nil {
+		return err
+	}
+	result, err := services.NewMaintenanceService(a.db).PruneGames(cutoff, archive)
+	if err != nil {
+		// nothing was deleted, so the archive holds nothing worth keeping
+		os.Remove(*archivePath)
+		return err
+	}
+	if a.json {
+		return a.print(result)
+	}
+	fmt.Fprintf(a.out, "Archived %d games of %d players played before %s to %s.\n",
+		result.Games, result.Users, result.Before.Format(time.RFC3339), *archivePath)
+	fmt.Fprintln(a.out, "Run vacuum to give the space back to the file system.")
+	return nil
+}
+
+// pruneCutoff works out the prune cutoff from exactly one of -before and
+// -older-than
+func pruneCutoff(before string, olderThan int, now time.Time) (time.Time, error) {
+	switch {
+	case before != "" && olderThan != 0:
+		return time.Time{}, errors.New("give either -before or -older-than, not both")
+	case olderThan > 0:
+		return now.AddDate(0, 0, -olderThan), nil
+	case before != "":
+		if at, err := time.Parse("2006-01-02", before); err == nil {
+			return at, nil
+		}
+		at, err := time.Parse(time.RFC3339, before)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid -before %q: must be a date (YYYY-MM-DD) or an RFC 3339 time", before)
+		}
+		return at, nil
+	}
+	return time.Time{}, errors.New("a cutoff is required: give -before DATE or -older-than DAYS")
+}
+
+// archiveFile is an archive being written; closing it flushes any
+// compression and syncs the file to disk
+type archiveFile struct {
+	io.Writer
+	file *os.File
+	gzip *gzip.Writer
+}
+
+// createArchive creates a new archive file, refusing to overwrite one
+func createArchive(path string) (*archiveFile, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %v", err)
+	}
+	archive := &archiveFile{Writer: file, file: file}
+	if strings.HasSuffix(path, ".gz") {
+		archive.gzip = gzip.NewWriter(file)
+		archive.Writer = archive.gzip
+	}
+	return archive, nil
+}
+
+func (f *archiveFile) Close() error {
+	if f.gzip != nil {
+		if err := f.gzip.Close(); err != nil {
+			f.file.Close()
+			return err
+		}
+	}
+	if err := f.file.Sync(); err != nil {
+		f.file.Close()
+		return err
+	}
+	return f.file.Close()
+}
+
+func runVacuum(a *app, args []string) error {
+	fs := newFlagSet("vacuum")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	size, err := services.NewMaintenanceService(a.db).Vacuum()
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.print(size)
+	}
+	fmt.Fprintf(a.out, "Vacuumed: %s -> %s\n", formatBytes(size.Before), formatBytes(size.After))
+	return nil
+}
+
+// formatBytes shows a size in the largest unit that keeps it above one
+func formatBytes(n int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB"}
+	size := float64(n)
+	unit := 0
+	for size >= 1024 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f %s", size, units[unit])
+}
+
+func runAnalyze(a *app, args []string) error {
+	fs := newFlagSet("analyze")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := services.NewMaintenanceService(a.db).Analyze(); err != nil {
+		return err
+	}
+	if a.json {
+		return a.print(map[string]bool{"analyzed": true})
+	}
+	fmt.Fprintln(a.out, "Analyzed.")
+	return nil
+}
+
+func runSeed(a *app, args []string) error {
+	fs := newFlagSet("seed")
+	opts := models.SeedOptions{}
+	fs.IntVar(&opts.Users, "users", 10, "players to create")
+	fs.IntVar(&opts.GamesPerUser, "games", 50, "games each player has played")
+	fs.IntVar(&opts.Days, "days", 30, "days the games are spread over")
+	fs.StringVar(&opts.Prefix, "prefix", "seed", "username prefix; names already taken are skipped")
+	fs.Int64Var(&opts.Seed, "seed", 1, "random seed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	result, err := services.NewMaintenanceService(a.db).Seed(opts)
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.print(result)
+	}
+	fmt.Fprintf(a.out, "Created %d players with %d games: %s\n", len(result.Users), result.Games, strings.Join(result.Users, ", "))
+	return nil
+}
+
+// backupService backs up to dir, or to where the server does if dir is empty
+func backupService(db *database.DB, dir string) (*services.BackupService, error) {
+	config, err := services.LoadBackupConfig()
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		dir = config.Dir
+	}
+	return services.NewBackupService(db, dir, config.Retention), nil
+}
+
+func runBackup(a *app, args []string) error {
+	fs := newFlagSet("backup")
+	dir := fs.String("dir", "", "directory to write the backup to (default BACKUP_DIR or "+services.DefaultBackupDir+")")
+	prune := fs.Bool("prune", false, "then delete the backups the BACKUP_KEEP_* retention policy does not keep")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	backups, err := backupService(a.db, *dir)
+	if err != nil {
+		return err
+	}
+	backup, err := backups.CreateBackup()
+	if err != nil {
+		return err
+	}
+	removed := []models.BackupInfo{}
+	if *prune {
+		if removed, err = backups.PruneBackups(); err != nil {
+			return err
+		}
+	}
+	if a.json {
+		return a.print(map[string]interface{}{"backup": backup, "removed": removed})
+	}
+	fmt.Fprintf(a.out, "Backed up to %s (%s).\n", backup.Path, formatBytes(backup.Size))
+	for _, old := range removed {
+		fmt.Fprintf(a.out, "Removed %s.\n", old.Path)
+	}
+	return nil
+}
+
+func runBackups(a *app, args []string) error {
+	fs := newFlagSet("backups")
+	dir := fs.String("dir", "", "directory the backups are in (default BACKUP_DIR or "+services.DefaultBackupDir+")")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	backups, err := backupService(nil, *dir)
+	if err != nil {
+		return err
+	}
+	list, err := backups.ListBackups()
+	if err != nil {
+		return err
+	}
+	if a.json {
+		return a.print(list)
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tCREATED")
+	for _, backup := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", backup.Name, formatBytes(backup.Size), backup.CreatedAt.Format(time.RFC3339))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "%d backups\n", len(list))
+	return nil
+}
+
+func runVerify(a *app, args []string) error {
+	fs := newFlagSet("verify")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("at least one file is required")
+	}
+
+	type result struct {
+		File  string `json:"file"`
+		OK    bool   `json:"ok"`
+		Error string `json:"error,omitempty"`
+	}
+	var results []result
+	failed := 0
+	for _, file := range fs.Args() {
+		r := result{File: file, OK: true}
+		if err := database.CheckIntegrity(file); err != nil {
+			r.OK, r.Error = false, err.Error()
+			failed++
+		}
+		results = append(results, r)
+	}
+
+	if a.json {
+		if err := a.print(results); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range results {
+			if r.OK {
+				fmt.Fprintf(a.out, "%s: ok\n", r.File)
+			} else {
+				fmt.Fprintf(a.out, "%s: %s\n", r.File, r.Error)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed the integrity check", failed, len(results))
+	}
+	return nil
+}
+
+func runRestore(a *app, args []string) error {
+	fs := newFlagSet("restore")
+	backupPath := fs.String("backup", "", "backup file to restore")
+	archiveDir := fs.String("wal-archive", "", "WAL archive directory to restore from (WAL_ARCHIVE_DIR of the server)")
+	at := fs.String("at", "", "with -wal-archive, the RFC 3339 time to restore to (default the latest)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if (*backupPath == "") == (*archiveDir == "") {
+		fs.Usage()
+		return errors.New("give either -backup or -wal-archive")
+	}
+	if *at != "" && *archiveDir == "" {
+		return errors.New("-at only works with -wal-archive")
+	}
+	target := time.Now()
+	if *at != "" {
+		parsed, err := time.Parse(time.RFC3339, *at)
+		if err != nil {
+			return fmt.Errorf("invalid -at %q: must be an RFC 3339 time", *at)
+		}
+		target = parsed
+	}
+
+	if *backupPath != "" {
+		if err := database.CheckIntegrity(*backupPath); err != nil {
+			return fmt.Errorf("cannot restore backup: %v", err)
+		}
+	}
+
+	// keep the database being replaced, in case the wrong backup was picked;
+	// it is moved rather than copied as it may well be corrupt
+	previous := ""
+	if _, err := os.Stat(a.dbPath); err == nil {
+		previous = a.dbPath + ".pre-restore-" + time.Now().UTC().Format("20060102T150405Z")
+		if _, err := os.Stat(previous); err == nil {
+			return fmt.Errorf("%s already exists", previous)
+		}
+		if err := moveDatabase(a.dbPath, previous); err != nil {
+			return fmt.Errorf("failed to set the current database aside: %v", err)
+		}
+	}
+
+	result := map[string]interface{}{"database": a.dbPath, "previous": previous}
+	var err error
+	if *backupPath != "" {
+		err = database.Restore(*backupPath, a.dbPath)
+		result["restored_from"] = *backupPath
+	} else {
+		var restoredTo time.Time
+		restoredTo, err = database.RestoreWALArchive(*archiveDir, target, a.dbPath)
+		result["restored_from"] = *archiveDir
+		result["restored_to"] = restoredTo
+	}
+	if err != nil {
+		if previous != "" {
+			if moveErr := moveDatabase(previous, a.dbPath); moveErr != nil {
+				return fmt.Errorf("%v; the previous database is still at %s", err, previous)
+			}
+		}
+		return err
+	}
+
+	if a.json {
+		return a.print(result)
+	}
+	if restoredTo, ok := result["restored_to"].(time.Time); ok {
+		fmt.Fprintf(a.out, "Restored %s as of %s from %s.\n", a.dbPath, restoredTo.Format(time.RFC3339), *archiveDir)
+	} else {
+		fmt.Fprintf(a.out, "Restored %s from %s.\n", a.dbPath, *backupPath)
+	}
+	if previous != "" {
+		fmt.Fprintf(a.out, "The database it replaced was saved as %s.\n", previous)
+	}
+	return nil
+}
+
+// moveDatabase renames a database file along with its write-ahead log
+func moveDatabase(from, to string) error {
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if err := os.Rename(from+suffix, to+suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPruneCutoff(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		before    string
+		olderThan int
+		want      time.Time
+	}{
+		{"", 30, time.Date(2025, 5, 16, 12, 0, 0, 0, time.UTC)},
+		{"2025-01-01", 0, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"2025-01-01T06:30:00+02:00", 0, time.Date(2025, 1, 1, 4, 30, 0, 0, time.UTC)},
+	} {
+		got, err := pruneCutoff(tc.before, tc.olderThan, now)
+		if err != nil || !got.Equal(tc.want) {
+			t.Errorf("pruneCutoff(%q, %d) = %v, %v; want %v", tc.before, tc.olderThan, got, err, tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		before    string
+		olderThan int
+	}{
+		{"", 0},
+		{"2025-01-01", 30},
+		{"last week", 0},
+	} {
+		if _, err := pruneCutoff(tc.before, tc.olderThan, now); err == nil {
+			t.Errorf("Expected pruneCutoff(%q, %d) to fail", tc.before, tc.olderThan)
+		}
+	}
+}
+
+func TestCreateArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "games.jsonl.gz")
+
+	archive, err := createArchive(path)
+	if err != nil {
+		t.Fatalf("Failed to create archive: %v", err)
+	}
+	if _, err := io.WriteString(archive, `{"id":1}`+"\n"); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Failed to close archive: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Expected a gzipped archive: %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil || string(data) != `{"id":1}`+"\n" {
+		t.Errorf("Expected the written line back, got %q, %v", data, err)
+	}
+
+	if _, err := createArchive(path); err == nil {
+		t.Error("Expected an existing archive not to be overwritten")
+	}
+}
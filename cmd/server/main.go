@@ -3,9 +3,13 @@ package main
 import (
 	"log"
 	"net/http"
+	"os"
+	"time"
+	_ "time/tzdata" // user timezones must resolve even on images without tzdata
 
 	"rockpaperscissors/internal/api/routes"
 	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,10 +23,117 @@ func main() {
 	defer db.Close()
 
 	// Run database migrations
-	if err := database.RunMigrations(db); err != nil {
+	if err := database.RunMigrations(db.DB); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
+	// Rebuild streak history for games played before streaks were tracked
+	if backfilled, err := services.NewStreakService(db).Backfill(); err != nil {
+		log.Fatalf("Failed to backfill streaks: %v", err)
+	} else if backfilled > 0 {
+		log.Printf("Backfilled streaks for %d users", backfilled)
+	}
+
+	// Store case-insensitive and look-alike keys for usernames registered
+	// before they existed; clashing names are left for an admin to rename
+	if backfilled, clashes, err := services.NewUserService(db).BackfillUsernameKeys(); err != nil {
+		log.Fatalf("Failed to backfill username keys: %v", err)
+	} else {
+		if backfilled > 0 {
+			log.Printf("Backfilled username keys for %d users", backfilled)
+		}
+		if len(clashes) > 0 {
+			log.Printf("Usernames clashing with older accounts, rename them via the admin API: %v", clashes)
+		}
+	}
+
+	// Fail fast on a broken username blocklist
+	if _, err := services.LoadUsernamePolicy(); err != nil {
+		log.Fatalf("Failed to load username policy: %v", err)
+	}
+
+	// Fail fast on a broken shop catalog rather than on the first purchase
+	if _, err := services.LoadShopCatalog(); err != nil {
+		log.Fatalf("Failed to load shop catalog: %v", err)
+	}
+
+	// Periodically check that every balance still matches the coin ledger
+	reconcileInterval := time.Hour
+	if raw := os.Getenv("LEDGER_RECONCILE_INTERVAL"); raw != "" {
+		interval, err := time.ParseDuration(raw)
+		if err != nil || interval <= 0 {
+			log.Fatalf("Invalid LEDGER_RECONCILE_INTERVAL %q", raw)
+		}
+		reconcileInterval = interval
+	}
+	stopJobs := make(chan struct{})
+	defer close(stopJobs)
+	go services.NewLedgerService(db).RunReconciliation(reconcileInterval, stopJobs)
+
+	// Archive any season that ended while the server was down, then keep
+	// rolling seasons over as they end
+	if _, err := services.LoadSeasonCalendar(); err != nil {
+		log.Fatalf("Failed to load season calendar: %v", err)
+	}
+	seasonService := services.NewSeasonService(db)
+	if _, err := seasonService.Rollover(); err != nil {
+		log.Fatalf("Failed to roll over seasons: %v", err)
+	}
+	go seasonService.RunRollover(time.Minute, stopJobs)
+
+	// Refund challenges nobody answered in time
+	go services.NewChallengeService(db).RunExpiry(time.Minute, stopJobs)
+
+	// Start tournaments as registration closes and forfeit matches whose
+	// players ran out of time
+	go services.NewTournamentService(db).RunScheduler(time.Minute, stopJobs)
+
+	// Settle clan wars as they end
+	go services.NewClanService(db).RunWarScheduler(time.Minute, stopJobs)
+
+	// Delete accounts whose deletion grace period is over
+	if _, err := services.DeletionGracePeriod(); err != nil {
+		log.Fatalf("Failed to configure account deletion: %v", err)
+	}
+	go services.NewAccountService(db).RunPurge(time.Hour, stopJobs)
+
+	// Rank players from memory instead of sorting the users table on every
+	// leaderboard request, and reload it now and then for changes made
+	// around the server, such as by rpsadmin
+	leaderboardService := services.NewLeaderboardService(db)
+	if ranked, err := leaderboardService.Warm(); err != nil {
+		log.Fatalf("Failed to load leaderboard: %v", err)
+	} else {
+		log.Printf("Loaded %d players into the leaderboard", ranked)
+	}
+	leaderboardRefreshInterval := 5 * time.Minute
+	if raw := os.Getenv("LEADERBOARD_REFRESH_INTERVAL"); raw != "" {
+		interval, err := time.ParseDuration(raw)
+		if err != nil || interval <= 0 {
+			log.Fatalf("Invalid LEADERBOARD_REFRESH_INTERVAL %q", raw)
+		}
+		leaderboardRefreshInterval = interval
+	}
+	go leaderboardService.RunRefresh(leaderboardRefreshInterval, stopJobs)
+
+	// Back the database up online, and archive its write-ahead log for
+	// point-in-time restores when WAL_ARCHIVE_DIR is set
+	backupConfig, err := services.LoadBackupConfig()
+	if err != nil {
+		log.Fatalf("Failed to configure backups: %v", err)
+	}
+	backupService := services.NewBackupService(db, backupConfig.Dir, backupConfig.Retention)
+	if backupConfig.Interval > 0 {
+		go backupService.RunBackups(backupConfig.Interval, stopJobs)
+	}
+	if backupConfig.WALArchiveDir != "" {
+		archiver, err := database.NewWALArchiver(db.DB, backupConfig.WALArchiveDir)
+		if err != nil {
+			log.Fatalf("Failed to start WAL archiving: %v", err)
+		}
+		go backupService.RunWALArchiving(archiver, backupConfig.WALArchiveInterval, backupConfig.WALArchiveRetention, stopJobs)
+	}
+
 	// Set Gin mode
 	gin.SetMode(gin.ReleaseMode)
 
@@ -32,8 +143,8 @@ func main() {
 	// Add CORS middleware for development
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Admin-Actor")
 		
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
@@ -0,0 +1,120 @@
+// Command simulate pits move strategies against each other for many rounds,
+// scored with the same GameLogicService rules as the server, and reports
+// win rates and coin yields under the streak multiplier with confidence
+// intervals.
+//
+// Every session is seeded from -seed and its position alone, so a run can
+// be reproduced exactly with the same flags, whatever -workers is set to.
+//
+//	go run ./cmd/simulate -players all -opponents random -rounds 1000000
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+)
+
+func main() {
+	cfg := config{}
+	players := flag.String("players", "all", "comma-separated player strategies, or all")
+	opponents := flag.String("opponents", "random", "comma-separated opponent strategies, or all")
+	flag.IntVar(&cfg.rounds, "rounds", 1000000, "rounds to play per matchup")
+	flag.IntVar(&cfg.sessionSize, "session", 1000, "rounds per session; streaks and strategy memory reset between sessions")
+	flag.IntVar(&cfg.workers, "workers", runtime.NumCPU(), "sessions to simulate in parallel")
+	flag.Int64Var(&cfg.seed, "seed", 1, "base random seed")
+	flag.Float64Var(&cfg.confidence, "confidence", 0.95, "confidence level of the intervals")
+	jsonOutput := flag.Bool("json", false, "print results as JSON")
+	list := flag.Bool("list", false, "list the strategies and exit")
+	flag.Parse()
+
+	if *list {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, spec := range sortedStrategies() {
+			fmt.Fprintf(w, "%s\t%s\n", spec.name, spec.description)
+		}
+		w.Flush()
+		return
+	}
+
+	switch {
+	case cfg.rounds <= 0:
+		log.Fatalf("-rounds must be positive")
+	case cfg.sessionSize <= 0:
+		log.Fatalf("-session must be positive")
+	case cfg.workers <= 0:
+		log.Fatalf("-workers must be positive")
+	case cfg.confidence <= 0 || cfg.confidence >= 1:
+		log.Fatalf("-confidence must be between 0 and 1")
+	}
+
+	playerSpecs, err := lookupStrategies(*players)
+	if err != nil {
+		log.Fatalf("Invalid -players: %v", err)
+	}
+	opponentSpecs, err := lookupStrategies(*opponents)
+	if err != nil {
+		log.Fatalf("Invalid -opponents: %v", err)
+	}
+	var matchups []matchup
+	for _, player := range playerSpecs {
+		for _, opponent := range opponentSpecs {
+			matchups = append(matchups, matchup{player: player, opponent: opponent})
+		}
+	}
+
+	started := time.Now()
+	results := simulate(cfg, matchups)
+	elapsed := time.Since(started)
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(map[string]interface{}{
+			"seed":       cfg.seed,
+			"rounds":     cfg.rounds,
+			"session":    cfg.sessionSize,
+			"confidence": cfg.confidence,
+			"results":    results,
+		}); err != nil {
+			log.Fatalf("Failed to write results: %v", err)
+		}
+		return
+	}
+
+	printResults(os.Stdout, cfg, results)
+	total := float64(cfg.rounds) * float64(len(matchups))
+	fmt.Printf("\n%.0f rounds in %s (%.1fM rounds/s) on %d workers, seed %d\n",
+		total, elapsed.Round(time.Millisecond), total/elapsed.Seconds()/1e6, cfg.workers, cfg.seed)
+}
+
+// printResults writes results as an aligned table
+func printResults(out io.Writer, cfg config, results []result) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	level := fmt.Sprintf("%g%% CI", cfg.confidence*100)
+	fmt.Fprintf(w, "PLAYER\tOPPONENT\tWIN %%\t%s\tLOSS %%\tTIE %%\tCOINS/ROUND\t%s\tCOINS/WIN\tBEST STREAK\t\n", level, level)
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%.2f\t%s\t%.2f\t%.2f\t%.3f\t%s\t%.2f\t%d\t\n",
+			r.Player, r.Opponent,
+			r.WinRate*100, formatInterval(r.WinRateCI, 100, 2),
+			r.LossRate*100, r.TieRate*100,
+			r.CoinsPerRound, formatInterval(r.CoinsCI, 1, 3),
+			r.CoinsPerWin, r.BestStreak)
+	}
+	w.Flush()
+}
+
+// formatInterval prints an interval scaled by scale, or a dash if there is
+// none
+func formatInterval(ci *interval, scale float64, decimals int) string {
+	if ci == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.*f-%.*f", decimals, ci.Low*scale, decimals, ci.High*scale)
+}
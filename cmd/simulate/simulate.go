@@ -0,0 +1,222 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+)
+
+// config controls a simulation run
+type config struct {
+	rounds      int     // rounds per matchup
+	sessionSize int     // rounds a player plays before their streak resets
+	workers     int     // sessions simulated at once
+	seed        int64   // base seed every session seed is derived from
+	confidence  float64 // confidence level of the reported intervals
+}
+
+// matchup is one player strategy against one opponent strategy
+type matchup struct {
+	player   strategySpec
+	opponent strategySpec
+}
+
+// sessionResult is the outcome of one session. Sessions are independent of
+// each other, unlike the rounds within them, so intervals are computed over
+// sessions.
+type sessionResult struct {
+	rounds     int
+	wins       int
+	losses     int
+	ties       int
+	coins      int
+	bestStreak int
+}
+
+// interval is a two-sided confidence interval
+type interval struct {
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+// result is the aggregate outcome of a matchup, from the player's side
+type result struct {
+	Player        string    `json:"player"`
+	Opponent      string    `json:"opponent"`
+	Rounds        int       `json:"rounds"`
+	Sessions      int       `json:"sessions"`
+	Wins          int       `json:"wins"`
+	Losses        int       `json:"losses"`
+	Ties          int       `json:"ties"`
+	WinRate       float64   `json:"win_rate"`
+	WinRateCI     *interval `json:"win_rate_ci,omitempty"`
+	LossRate      float64   `json:"loss_rate"`
+	TieRate       float64   `json:"tie_rate"`
+	Coins         int64     `json:"coins"`
+	CoinsPerRound float64   `json:"coins_per_round"`
+	CoinsCI       *interval `json:"coins_per_round_ci,omitempty"`
+	CoinsPerWin   float64   `json:"coins_per_win"`
+	BestStreak    int       `json:"best_streak"`
+}
+
+// splitmix64 scrambles x; consecutive inputs give unrelated outputs, which
+// makes it a good way to derive many seeds from one
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// sessionSeed derives the seed of one side of one session. It depends only
+// on the base seed and the positions, never on which worker runs the
+// session, so results are the same with any number of workers.
+func sessionSeed(base int64, matchup, session, side int) int64 {
+	x := splitmix64(uint64(base))
+	x = splitmix64(x ^ uint64(matchup))
+	x = splitmix64(x ^ uint64(session))
+	x = splitmix64(x ^ uint64(side))
+	return int64(x >> 1)
+}
+
+// playSession plays rounds between fresh instances of both strategies,
+// scoring them the way the server scores a game against the computer
+func playSession(gameLogic *services.GameLogicService, m matchup, rounds int, playerSeed, opponentSeed int64) sessionResult {
+	player := m.player.new(rand.New(rand.NewSource(playerSeed)))
+	opponent := m.opponent.new(rand.New(rand.NewSource(opponentSeed)))
+
+	var res sessionResult
+	streak := 0
+	for i := 0; i < rounds; i++ {
+		playerChoice, opponentChoice := player.Choose(), opponent.Choose()
+		outcome := gameLogic.DetermineWinner(playerChoice, opponentChoice)
+
+		res.coins += gameLogic.CalculateCoinsEarned(outcome, streak)
+		streak = gameLogic.CalculateNewStreak(streak, outcome)
+		if streak > res.bestStreak {
+			res.bestStreak = streak
+		}
+		switch outcome {
+		case models.Win:
+			res.wins++
+		case models.Lose:
+			res.losses++
+		default:
+			res.ties++
+		}
+
+		player.Observe(playerChoice, opponentChoice)
+		opponent.Observe(opponentChoice, playerChoice)
+	}
+	res.rounds = rounds
+	return res
+}
+
+// simulate plays every matchup for cfg.rounds rounds, spreading the sessions
+// over cfg.workers goroutines, and returns one result per matchup in order
+func simulate(cfg config, matchups []matchup) []result {
+	sessions := (cfg.rounds + cfg.sessionSize - 1) / cfg.sessionSize
+
+	type job struct {
+		matchup, session int
+	}
+	outcomes := make([][]sessionResult, len(matchups))
+	for i := range outcomes {
+		outcomes[i] = make([]sessionResult, sessions)
+	}
+
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// scoring never draws random numbers, so one per worker will do
+			gameLogic := services.NewGameLogicService()
+			for j := range jobs {
+				rounds := cfg.sessionSize
+				if last := cfg.rounds - j.session*cfg.sessionSize; last < rounds {
+					rounds = last
+				}
+				outcomes[j.matchup][j.session] = playSession(gameLogic, matchups[j.matchup], rounds,
+					sessionSeed(cfg.seed, j.matchup, j.session, 0), sessionSeed(cfg.seed, j.matchup, j.session, 1))
+			}
+		}()
+	}
+	for m := range matchups {
+		for s := 0; s < sessions; s++ {
+			jobs <- job{matchup: m, session: s}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	z := zScore(cfg.confidence)
+	results := make([]result, len(matchups))
+	for i, m := range matchups {
+		results[i] = summarize(m, outcomes[i], z)
+	}
+	return results
+}
+
+// summarize adds up a matchup's sessions, in session order so that the
+// floating point sums come out the same on every run
+func summarize(m matchup, sessions []sessionResult, z float64) result {
+	res := result{
+		Player:   m.player.name,
+		Opponent: m.opponent.name,
+		Sessions: len(sessions),
+	}
+
+	winRates := make([]float64, len(sessions))
+	yields := make([]float64, len(sessions))
+	for i, s := range sessions {
+		res.Rounds += s.rounds
+		res.Wins += s.wins
+		res.Losses += s.losses
+		res.Ties += s.ties
+		res.Coins += int64(s.coins)
+		if s.bestStreak > res.BestStreak {
+			res.BestStreak = s.bestStreak
+		}
+		winRates[i] = float64(s.wins) / float64(s.rounds)
+		yields[i] = float64(s.coins) / float64(s.rounds)
+	}
+	if res.Rounds == 0 {
+		return res
+	}
+
+	res.WinRate = float64(res.Wins) / float64(res.Rounds)
+	res.LossRate = float64(res.Losses) / float64(res.Rounds)
+	res.TieRate = float64(res.Ties) / float64(res.Rounds)
+	res.CoinsPerRound = float64(res.Coins) / float64(res.Rounds)
+	if res.Wins > 0 {
+		res.CoinsPerWin = float64(res.Coins) / float64(res.Wins)
+	}
+	res.WinRateCI = meanInterval(winRates, res.WinRate, z)
+	res.CoinsCI = meanInterval(yields, res.CoinsPerRound, z)
+	return res
+}
+
+// meanInterval returns the confidence interval around mean given the
+// per-session values, or nil with fewer than two sessions
+func meanInterval(values []float64, mean, z float64) *interval {
+	if len(values) < 2 {
+		return nil
+	}
+	var sumSquares float64
+	for _, v := range values {
+		sumSquares += (v - mean) * (v - mean)
+	}
+	stdErr := math.Sqrt(sumSquares/float64(len(values)-1)) / math.Sqrt(float64(len(values)))
+	return &interval{Low: mean - z*stdErr, High: mean + z*stdErr}
+}
+
+// zScore returns the two-sided normal quantile for a confidence level,
+// 1.96 for 0.95
+func zScore(confidence float64) float64 {
+	return math.Sqrt2 * math.Erfinv(confidence)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"rockpaperscissors/internal/models"
+)
+
+func TestSimulate_Reproducible(t *testing.T) {
+	matchups := []matchup{
+		{player: strategies["markov"], opponent: strategies["human"]},
+		{player: strategies["random"], opponent: strategies["random"]},
+	}
+	cfg := config{rounds: 20500, sessionSize: 1000, workers: 1, seed: 7, confidence: 0.95}
+
+	serial := simulate(cfg, matchups)
+	cfg.workers = 8
+	parallel := simulate(cfg, matchups)
+	if !reflect.DeepEqual(serial, parallel) {
+		t.Errorf("Expected the same results with any number of workers:\n%+v\n%+v", serial, parallel)
+	}
+
+	cfg.seed = 8
+	if reseeded := simulate(cfg, matchups); reflect.DeepEqual(serial, reseeded) {
+		t.Errorf("Expected a different seed to give different results")
+	}
+
+	if serial[0].Rounds != 20500 || serial[0].Sessions != 21 {
+		t.Errorf("Expected 20500 rounds over 21 sessions, got %d over %d", serial[0].Rounds, serial[0].Sessions)
+	}
+	if got := serial[0].Wins + serial[0].Losses + serial[0].Ties; got != serial[0].Rounds {
+		t.Errorf("Expected every round to be a win, loss or tie, got %d of %d", got, serial[0].Rounds)
+	}
+}
+
+func TestSimulate_StreakCoins(t *testing.T) {
+	// rock always beats scissors: 10, 20, 30, 40, then 50 a round, reset
+	// every session
+	cfg := config{rounds: 20, sessionSize: 10, workers: 2, seed: 1, confidence: 0.95}
+	results := simulate(cfg, []matchup{{player: strategies["rock"], opponent: strategies["scissors"]}})
+
+	res := results[0]
+	if res.Wins != 20 || res.WinRate != 1 {
+		t.Errorf("Expected rock to win every round, got %+v", res)
+	}
+	if want := int64(2 * (10 + 20 + 30 + 40 + 6*50)); res.Coins != want {
+		t.Errorf("Expected %d coins, got %d", want, res.Coins)
+	}
+	if res.BestStreak != 10 {
+		t.Errorf("Expected a best streak of 10, got %d", res.BestStreak)
+	}
+	if res.WinRateCI == nil || res.WinRateCI.Low != 1 || res.WinRateCI.High != 1 {
+		t.Errorf("Expected a zero-width interval, got %+v", res.WinRateCI)
+	}
+}
+
+func TestSimulate_RandomOpponent(t *testing.T) {
+	cfg := config{rounds: 300000, sessionSize: 1000, workers: 4, seed: 3, confidence: 0.99}
+	results := simulate(cfg, []matchup{{player: strategies["cycle"], opponent: strategies["random"]}})
+
+	// nothing beats a uniformly random opponent
+	res := results[0]
+	if res.WinRateCI.Low > 1.0/3 || res.WinRateCI.High < 1.0/3 {
+		t.Errorf("Expected the win rate interval to cover 1/3, got %+v", res.WinRateCI)
+	}
+}
+
+func TestStrategies(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	counter := strategies["counter"].new(rng)
+	counter.Observe(models.Paper, models.Rock)
+	if got := counter.Choose(); got != models.Paper {
+		t.Errorf("Expected counter to play paper after rock, got %s", got)
+	}
+
+	winStay := strategies["win-stay"].new(rng)
+	winStay.Observe(models.Rock, models.Scissors)
+	if got := winStay.Choose(); got != models.Rock {
+		t.Errorf("Expected win-stay to keep a winning rock, got %s", got)
+	}
+	winStay.Observe(models.Rock, models.Paper)
+	if got := winStay.Choose(); got != models.Scissors {
+		t.Errorf("Expected win-stay to switch to scissors after losing to paper, got %s", got)
+	}
+
+	markov := strategies["markov"].new(rng)
+	for _, move := range []models.Choice{models.Rock, models.Paper, models.Rock, models.Paper, models.Rock} {
+		markov.Observe(models.Scissors, move)
+	}
+	if got := markov.Choose(); got != models.Scissors {
+		t.Errorf("Expected markov to expect paper after rock and play scissors, got %s", got)
+	}
+
+	human := strategies["human"].new(rng)
+	for i := 0; i < 1000; i++ {
+		choice := human.Choose()
+		human.Observe(choice, models.Rock)
+		second := human.Choose()
+		human.Observe(second, models.Rock)
+		if third := human.Choose(); choice == second && second == third {
+			t.Fatalf("Expected human never to play %s three times in a row", choice)
+		}
+	}
+
+	if _, err := lookupStrategies("rock,bogus"); err == nil {
+		t.Errorf("Expected an unknown strategy to be rejected")
+	}
+	if specs, err := lookupStrategies("all"); err != nil || len(specs) != len(strategies) {
+		t.Errorf("Expected all to list every strategy, got %d (%v)", len(specs), err)
+	}
+}
+
+func TestZScore(t *testing.T) {
+	if z := zScore(0.95); math.Abs(z-1.96) > 0.001 {
+		t.Errorf("Expected 1.96 for 95%%, got %f", z)
+	}
+}
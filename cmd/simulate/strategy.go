@@ -0,0 +1,319 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+)
+
+// choices are the three moves, in the order each beats the one before
+var choices = []models.Choice{models.Rock, models.Paper, models.Scissors}
+
+// choiceIndex returns the position of a move in choices
+func choiceIndex(choice models.Choice) int {
+	switch choice {
+	case models.Paper:
+		return 1
+	case models.Scissors:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// beats returns the move that beats choice
+func beats(choice models.Choice) models.Choice {
+	return choices[(choiceIndex(choice)+1)%3]
+}
+
+// strategy picks moves for one side of a session. A fresh strategy is made
+// for every session, so state does not leak between them.
+type strategy interface {
+	// Choose picks the next move
+	Choose() models.Choice
+	// Observe is told both moves once a round is played
+	Observe(own, opponent models.Choice)
+}
+
+// strategySpec describes a strategy that can be named on the command line
+type strategySpec struct {
+	name        string
+	description string
+	new         func(rng *rand.Rand) strategy
+}
+
+// strategies are every strategy the simulator knows, by name. Any of them
+// can play either side: the player side earns coins under the streak
+// multiplier, the opponent side stands in for the computer.
+var strategies = map[string]strategySpec{
+	"random": {
+		name:        "random",
+		description: "uniformly random, exactly like the server's default computer opponent",
+		new: func(rng *rand.Rand) strategy {
+			return &randomStrategy{gameLogic: services.NewSeededGameLogicService(rng.Int63())}
+		},
+	},
+	"rock": {
+		name:        "rock",
+		description: "always rock",
+		new:         func(rng *rand.Rand) strategy { return constantStrategy(models.Rock) },
+	},
+	"paper": {
+		name:        "paper",
+		description: "always paper",
+		new:         func(rng *rand.Rand) strategy { return constantStrategy(models.Paper) },
+	},
+	"scissors": {
+		name:        "scissors",
+		description: "always scissors",
+		new:         func(rng *rand.Rand) strategy { return constantStrategy(models.Scissors) },
+	},
+	"cycle": {
+		name:        "cycle",
+		description: "rock, paper, scissors, rock, ... starting at a random move",
+		new:         func(rng *rand.Rand) strategy { return &cycleStrategy{next: rng.Intn(3)} },
+	},
+	"mirror": {
+		name:        "mirror",
+		description: "repeats the opponent's last move",
+		new:         func(rng *rand.Rand) strategy { return &mirrorStrategy{rng: rng} },
+	},
+	"counter": {
+		name:        "counter",
+		description: "plays what beats the opponent's last move",
+		new:         func(rng *rand.Rand) strategy { return &counterStrategy{rng: rng} },
+	},
+	"frequency": {
+		name:        "frequency",
+		description: "plays what beats the opponent's most common move so far",
+		new:         func(rng *rand.Rand) strategy { return &frequencyStrategy{rng: rng} },
+	},
+	"markov": {
+		name:        "markov",
+		description: "predicts the opponent's next move from what they played after their last one",
+		new:         func(rng *rand.Rand) strategy { return &markovStrategy{rng: rng} },
+	},
+	"win-stay": {
+		name:        "win-stay",
+		description: "player model: keeps a winning move, after a loss switches to what beat it",
+		new:         func(rng *rand.Rand) strategy { return &winStayStrategy{rng: rng} },
+	},
+	"human": {
+		name:        "human",
+		description: "player model: favours rock and paper and avoids repeating a move three times",
+		new:         func(rng *rand.Rand) strategy { return &humanStrategy{rng: rng} },
+	},
+}
+
+// lookupStrategies resolves a comma-separated list of strategy names; "all"
+// stands for every strategy
+func lookupStrategies(list string) ([]strategySpec, error) {
+	var specs []strategySpec
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if name == "all" {
+			specs = append(specs, sortedStrategies()...)
+			continue
+		}
+		spec, ok := strategies[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown strategy %q, run with -list to see them", name)
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("no strategies given")
+	}
+	return specs, nil
+}
+
+// sortedStrategies returns every strategy ordered by name
+func sortedStrategies() []strategySpec {
+	specs := make([]strategySpec, 0, len(strategies))
+	for _, spec := range strategies {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].name < specs[j].name })
+	return specs
+}
+
+// randomStrategy draws moves from a seeded GameLogicService
+type randomStrategy struct {
+	gameLogic *services.GameLogicService
+}
+
+func (s *randomStrategy) Choose() models.Choice {
+	return s.gameLogic.GenerateComputerChoice()
+}
+
+func (s *randomStrategy) Observe(own, opponent models.Choice) {}
+
+// constantStrategy always plays the same move
+type constantStrategy models.Choice
+
+func (s constantStrategy) Choose() models.Choice {
+	return models.Choice(s)
+}
+
+func (s constantStrategy) Observe(own, opponent models.Choice) {}
+
+// cycleStrategy walks through the moves in order
+type cycleStrategy struct {
+	next int
+}
+
+func (s *cycleStrategy) Choose() models.Choice {
+	choice := choices[s.next]
+	s.next = (s.next + 1) % 3
+	return choice
+}
+
+func (s *cycleStrategy) Observe(own, opponent models.Choice) {}
+
+// mirrorStrategy copies the opponent's last move
+type mirrorStrategy struct {
+	rng  *rand.Rand
+	last models.Choice
+}
+
+func (s *mirrorStrategy) Choose() models.Choice {
+	if s.last == "" {
+		return choices[s.rng.Intn(3)]
+	}
+	return s.last
+}
+
+func (s *mirrorStrategy) Observe(own, opponent models.Choice) {
+	s.last = opponent
+}
+
+// counterStrategy assumes the opponent repeats themselves
+type counterStrategy struct {
+	rng  *rand.Rand
+	last models.Choice
+}
+
+func (s *counterStrategy) Choose() models.Choice {
+	if s.last == "" {
+		return choices[s.rng.Intn(3)]
+	}
+	return beats(s.last)
+}
+
+func (s *counterStrategy) Observe(own, opponent models.Choice) {
+	s.last = opponent
+}
+
+// frequencyStrategy counters the opponent's favourite move, breaking ties
+// at random
+type frequencyStrategy struct {
+	rng    *rand.Rand
+	counts [3]int
+}
+
+func (s *frequencyStrategy) Choose() models.Choice {
+	return beats(choices[mostLikely(s.rng, s.counts)])
+}
+
+func (s *frequencyStrategy) Observe(own, opponent models.Choice) {
+	s.counts[choiceIndex(opponent)]++
+}
+
+// markovStrategy keeps a first-order transition table of the opponent's
+// moves and counters the most likely next one
+type markovStrategy struct {
+	rng         *rand.Rand
+	transitions [3][3]int
+	last        models.Choice
+}
+
+func (s *markovStrategy) Choose() models.Choice {
+	if s.last == "" {
+		return choices[s.rng.Intn(3)]
+	}
+	return beats(choices[mostLikely(s.rng, s.transitions[choiceIndex(s.last)])])
+}
+
+func (s *markovStrategy) Observe(own, opponent models.Choice) {
+	if s.last != "" {
+		s.transitions[choiceIndex(s.last)][choiceIndex(opponent)]++
+	}
+	s.last = opponent
+}
+
+// mostLikely returns the index of the highest count, picking at random
+// between equal ones
+func mostLikely(rng *rand.Rand, counts [3]int) int {
+	best, ties := 0, 0
+	for i, count := range counts {
+		switch {
+		case count > counts[best]:
+			best, ties = i, 1
+		case count == counts[best]:
+			ties++
+			// reservoir sampling keeps each tied index equally likely
+			if rng.Intn(ties) == 0 {
+				best = i
+			}
+		}
+	}
+	return best
+}
+
+// winStayStrategy is the win-stay, lose-shift pattern many people fall
+// into: repeat a move that won, drop one that lost for what beat it, and
+// keep going after a tie
+type winStayStrategy struct {
+	rng  *rand.Rand
+	next models.Choice
+}
+
+func (s *winStayStrategy) Choose() models.Choice {
+	if s.next == "" {
+		return choices[s.rng.Intn(3)]
+	}
+	return s.next
+}
+
+func (s *winStayStrategy) Observe(own, opponent models.Choice) {
+	if beats(own) == opponent {
+		s.next = beats(opponent)
+	} else {
+		s.next = own
+	}
+}
+
+// humanStrategy models a casual player: slightly more rock and paper than
+// scissors, and never the same move three times in a row
+type humanStrategy struct {
+	rng         *rand.Rand
+	last, prior models.Choice
+}
+
+func (s *humanStrategy) Choose() models.Choice {
+	for {
+		var choice models.Choice
+		switch roll := s.rng.Intn(100); {
+		case roll < 35:
+			choice = models.Rock
+		case roll < 70:
+			choice = models.Paper
+		default:
+			choice = models.Scissors
+		}
+		if choice != s.last || choice != s.prior {
+			return choice
+		}
+	}
+}
+
+func (s *humanStrategy) Observe(own, opponent models.Choice) {
+	s.prior, s.last = s.last, own
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"rockpaperscissors/internal/api/middleware"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountHandler handles data exports and self-service account deletion.
+// Its routes sit behind the player's account token.
+type AccountHandler struct {
+	accountService *services.AccountService
+}
+
+// NewAccountHandler creates a new account handler
+func NewAccountHandler(db *database.DB) *AccountHandler {
+	return &AccountHandler{
+		accountService: services.NewAccountService(db),
+	}
+}
+
+// ExportData downloads everything kept about the user as a ZIP of JSON files
+func (h *AccountHandler) ExportData(c *gin.Context) {
+	username := c.Param("username")
+
+	// built in memory so a failure can still be reported as an error
+	var archive bytes.Buffer
+	if err := h.accountService.ExportData(username, &archive); err != nil {
+		respondAccountError(c, err, "Failed to export account data")
+		return
+	}
+
+	// the API's JSON content type is already set, and c.Data keeps it
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", `attachment; filename="`+username+`-data.zip"`)
+	c.Data(http.StatusOK, "application/zip", archive.Bytes())
+}
+
+// RequestDeletion schedules the account for deletion after the grace period
+func (h *AccountHandler) RequestDeletion(c *gin.Context) {
+	user, err := h.accountService.RequestDeletion(c.Param("username"))
+	if err != nil {
+		respondAccountError(c, err, "Failed to schedule account deletion")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"username":              user.Username,
+		"deletion_scheduled_at": user.DeletionScheduledAt,
+	})
+}
+
+// CancelDeletion restores an account still in its deletion grace period
+func (h *AccountHandler) CancelDeletion(c *gin.Context) {
+	user, err := h.accountService.CancelDeletion(c.Param("username"))
+	if err != nil {
+		respondAccountError(c, err, "Failed to cancel account deletion")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Deletion of user '" + user.Username + "' cancelled"})
+}
+
+// respondAccountError maps account service errors to HTTP responses
+func respondAccountError(c *gin.Context, err error, fallback string) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "already scheduled"), strings.Contains(err.Error(), "no deletion is scheduled"):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// actingPlayer sets username to the player whose account token
+// authenticated a request made behind middleware.PlayerAuth. A username
+// already sent in the body has to name the same player.
+func actingPlayer(c *gin.Context, username *string) bool {
+	player := middleware.Player(c)
+	if *username != "" && *username != player {
+		c.JSON(http.StatusForbidden, gin.H{"error": "username does not match the account token"})
+		return false
+	}
+	*username = player
+	return true
+}
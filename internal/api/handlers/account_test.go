@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"rockpaperscissors/internal/api/middleware"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupAccountTestRouter(db *database.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	userHandler := NewUserHandler(db)
+	gameHandler := NewGameHandler(db)
+	accountHandler := NewAccountHandler(db)
+	adminHandler := NewAdminHandler(db)
+
+	api := router.Group("/api")
+	api.POST("/users", userHandler.CreateUser)
+	api.POST("/play", gameHandler.PlayGame)
+
+	account := router.Group("/api/users/:username")
+	account.Use(middleware.UserAuth(services.NewUserService(db).Authenticate))
+	account.GET("/data-export", accountHandler.ExportData)
+	account.POST("/deletion", accountHandler.RequestDeletion)
+	account.DELETE("/deletion", accountHandler.CancelDeletion)
+
+	admin := router.Group("/admin")
+	admin.Use(middleware.AdminAuth(testAdminToken))
+	admin.POST("/users/:username/token", adminHandler.IssueAccountToken)
+
+	return router
+}
+
+func accountRequest(router *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAccountHandler(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	router := setupAccountTestRouter(db)
+
+	tokens := map[string]string{}
+	for _, name := range []string{"dataowner", "stranger"} {
+		w := postJSON(router, "/api/users", models.CreateUserRequest{Username: name})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create user %s: %s", name, w.Body.String())
+		}
+		var response models.CreateUserResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.AccountToken == "" {
+			t.Fatalf("Expected an account token for %s", name)
+		}
+		tokens[name] = response.AccountToken
+	}
+	if w := postJSON(router, "/api/play", models.PlayGameRequest{Username: "dataowner", PlayerChoice: models.Paper}); w.Code != http.StatusOK {
+		t.Fatalf("Failed to play game: %s", w.Body.String())
+	}
+
+	t.Run("Error - Missing or someone else's token", func(t *testing.T) {
+		for _, token := range []string{"", "not-a-token", tokens["stranger"]} {
+			if w := accountRequest(router, "GET", "/api/users/dataowner/data-export", token); w.Code != http.StatusUnauthorized {
+				t.Errorf("Expected status %d for token %q, got %d", http.StatusUnauthorized, token, w.Code)
+			}
+		}
+	})
+
+	t.Run("Success - Export data as a ZIP of JSON files", func(t *testing.T) {
+		w := accountRequest(router, "GET", "/api/users/dataowner/data-export", tokens["dataowner"])
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if w.Header().Get("Content-Type") != "application/zip" {
+			t.Errorf("Expected application/zip, got %s", w.Header().Get("Content-Type"))
+		}
+
+		archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		if err != nil {
+			t.Fatalf("Failed to open export: %v", err)
+		}
+		var names []string
+		for _, file := range archive.File {
+			names = append(names, file.Name)
+			if file.Name != "games.json" {
+				continue
+			}
+			rc, _ := file.Open()
+			var games []models.Game
+			if err := json.NewDecoder(rc).Decode(&games); err != nil || len(games) != 1 {
+				t.Errorf("Expected one game in games.json, got %d, %v", len(games), err)
+			}
+			rc.Close()
+		}
+		sort.Strings(names)
+		if strings.Join(names, ",") != "achievements.json,games.json,profile.json,transactions.json" {
+			t.Errorf("Unexpected files in export: %v", names)
+		}
+	})
+
+	t.Run("Success - Schedule and cancel deletion", func(t *testing.T) {
+		w := accountRequest(router, "POST", "/api/users/dataowner/deletion", tokens["dataowner"])
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+		}
+		if w := accountRequest(router, "POST", "/api/users/dataowner/deletion", tokens["dataowner"]); w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d when already scheduled, got %d", http.StatusConflict, w.Code)
+		}
+		if w := postJSON(router, "/api/play", models.PlayGameRequest{Username: "dataowner", PlayerChoice: models.Rock}); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d when playing during the grace period, got %d", http.StatusForbidden, w.Code)
+		}
+
+		if w := accountRequest(router, "DELETE", "/api/users/dataowner/deletion", tokens["dataowner"]); w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if w := accountRequest(router, "DELETE", "/api/users/dataowner/deletion", tokens["dataowner"]); w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d with nothing scheduled, got %d", http.StatusConflict, w.Code)
+		}
+	})
+
+	t.Run("Success - Admin reissues a lost token", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/admin/users/stranger/token", strings.NewReader(`{"reason":"lost token"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+testAdminToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var response struct {
+			AccountToken string `json:"account_token"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if w := accountRequest(router, "GET", "/api/users/stranger/data-export", tokens["stranger"]); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected the old token to stop working, got %d", w.Code)
+		}
+		if w := accountRequest(router, "GET", "/api/users/stranger/data-export", response.AccountToken); w.Code != http.StatusOK {
+			t.Errorf("Expected the new token to work, got %d", w.Code)
+		}
+	})
+}
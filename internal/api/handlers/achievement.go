@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AchievementHandler handles achievement requests
+type AchievementHandler struct {
+	achievementService *services.AchievementService
+}
+
+// NewAchievementHandler creates a new achievement handler
+func NewAchievementHandler(db *database.DB) *AchievementHandler {
+	return &AchievementHandler{
+		achievementService: services.NewAchievementService(db),
+	}
+}
+
+// GetUserAchievements lists every achievement and whether the user has unlocked it
+func (h *AchievementHandler) GetUserAchievements(c *gin.Context) {
+	username := c.Param("username")
+
+	achievements, err := h.achievementService.GetUserAchievements(username)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get achievements"})
+		return
+	}
+
+	unlocked := 0
+	for _, achievement := range achievements {
+		if achievement.Unlocked {
+			unlocked++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"username":       username,
+		"achievements":   achievements,
+		"total_unlocked": unlocked,
+	})
+}
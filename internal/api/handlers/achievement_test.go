@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAchievementHandler_GetUserAchievements(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api")
+	api.POST("/play", NewGameHandler(db).PlayGame)
+	api.GET("/users/:username/achievements", NewAchievementHandler(db).GetUserAchievements)
+
+	userService := services.NewUserService(db)
+	if _, err := userService.CreateUser("achiever"); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	t.Run("Success - First win unlocks in play response", func(t *testing.T) {
+		var firstWin *models.PlayGameResponse
+		for i := 0; i < 50 && firstWin == nil; i++ {
+			w := postJSON(router, "/api/play", models.PlayGameRequest{Username: "achiever", PlayerChoice: models.Rock})
+			if w.Code != http.StatusOK {
+				t.Fatalf("Failed to play game: status %d", w.Code)
+			}
+			var response models.PlayGameResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if response.Result == models.Win {
+				firstWin = &response
+			}
+		}
+		if firstWin == nil {
+			t.Skip("No win in 50 games")
+		}
+
+		found := false
+		for _, achievement := range firstWin.NewAchievements {
+			if achievement.ID == "first_win" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected first_win in new achievements, got %v", firstWin.NewAchievements)
+		}
+	})
+
+	t.Run("Success - List achievements", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/users/achiever/achievements", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Achievements  []models.UserAchievement `json:"achievements"`
+			TotalUnlocked int                      `json:"total_unlocked"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(response.Achievements) == 0 {
+			t.Error("Expected achievement definitions")
+		}
+		for _, achievement := range response.Achievements {
+			if achievement.Unlocked && achievement.UnlockedAt == nil {
+				t.Errorf("Unlocked achievement %s has no timestamp", achievement.ID)
+			}
+		}
+	})
+
+	t.Run("Error - User not found", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/users/nobody/achievements", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler handles moderation and account management requests. Its
+// routes sit behind middleware.AdminAuth.
+type AdminHandler struct {
+	adminService *services.AdminService
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(db *database.DB) *AdminHandler {
+	return &AdminHandler{
+		adminService: services.NewAdminService(db),
+	}
+}
+
+// adminActor names who made a change in the audit log, from the
+// X-Admin-Actor header
+func adminActor(c *gin.Context) string {
+	if actor := strings.TrimSpace(c.GetHeader("X-Admin-Actor")); actor != "" {
+		return actor
+	}
+	return "admin"
+}
+
+// adminErrorStatus maps admin service errors to HTTP status codes
+func adminErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "already exists"), strings.Contains(err.Error(), "too similar"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "insufficient coins"):
+		return http.StatusPaymentRequired
+	case strings.Contains(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondAdminError writes an admin service error, hiding internal details
+func respondAdminError(c *gin.Context, err error, message string) {
+	status := adminErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// SearchUsers lists users whose username contains ?q=, optionally only those
+// with ?status=active, suspended or banned
+func (h *AdminHandler) SearchUsers(c *gin.Context) {
+	limit, offset, ok := parsePagination(c, 20)
+	if !ok {
+		return
+	}
+
+	users, total, err := h.adminService.SearchUsers(c.Query("q"), models.UserStatus(c.Query("status")), limit, offset)
+	if err != nil {
+		respondAdminError(c, err, "Failed to search users")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":  users,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GetUserRecord returns a user's full record; the game history query
+// parameters page through their games
+func (h *AdminHandler) GetUserRecord(c *gin.Context) {
+	filter, ok := parseGameHistoryFilter(c)
+	if !ok {
+		return
+	}
+
+	record, err := h.adminService.GetUserRecord(c.Param("username"), filter)
+	if err != nil {
+		respondAdminError(c, err, "Failed to get user record")
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// GetActions lists the admin audit log, newest first
+func (h *AdminHandler) GetActions(c *gin.Context) {
+	limit, offset, ok := parsePagination(c, 50)
+	if !ok {
+		return
+	}
+
+	actions, total, err := h.adminService.GetActions(limit, offset)
+	if err != nil {
+		respondAdminError(c, err, "Failed to get admin actions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"actions": actions,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// AdjustCoins credits or debits a user's coins
+func (h *AdminHandler) AdjustCoins(c *gin.Context) {
+	var req models.AdjustCoinsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.adminService.AdjustCoins(adminActor(c), c.Param("username"), req.Amount, req.Reason)
+	if err != nil {
+		respondAdminError(c, err, "Failed to adjust coins")
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// ResetStreak sets a user's current streak back to zero
+func (h *AdminHandler) ResetStreak(c *gin.Context) {
+	var req models.ModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.adminService.ResetStreak(adminActor(c), c.Param("username"), req.Reason)
+	if err != nil {
+		respondAdminError(c, err, "Failed to reset streak")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// BanUser bans a user until they are reinstated
+func (h *AdminHandler) BanUser(c *gin.Context) {
+	var req models.ModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.adminService.BanUser(adminActor(c), c.Param("username"), req.Reason)
+	if err != nil {
+		respondAdminError(c, err, "Failed to ban user")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// SuspendUser bans a user until a given time
+func (h *AdminHandler) SuspendUser(c *gin.Context) {
+	var req models.SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.adminService.SuspendUser(adminActor(c), c.Param("username"), req.Until, req.Reason)
+	if err != nil {
+		respondAdminError(c, err, "Failed to suspend user")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ReinstateUser lifts a ban or suspension
+func (h *AdminHandler) ReinstateUser(c *gin.Context) {
+	var req models.ModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.adminService.ReinstateUser(adminActor(c), c.Param("username"), req.Reason)
+	if err != nil {
+		respondAdminError(c, err, "Failed to reinstate user")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// RenameUser changes a user's username
+func (h *AdminHandler) RenameUser(c *gin.Context) {
+	var req models.RenameUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.adminService.RenameUser(adminActor(c), c.Param("username"), req.Username, req.Reason)
+	if err != nil {
+		respondAdminError(c, err, "Failed to rename user")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteUser deletes an account and everything that belongs to it; the
+// reason is given as ?reason=
+func (h *AdminHandler) DeleteUser(c *gin.Context) {
+	reason := strings.TrimSpace(c.Query("reason"))
+	if reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+		return
+	}
+
+	username := c.Param("username")
+	if err := h.adminService.DeleteUser(adminActor(c), username, reason); err != nil {
+		respondAdminError(c, err, "Failed to delete user")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User '" + username + "' deleted"})
+}
+
+// IssueAccountToken replaces a user's account token and returns the new one
+func (h *AdminHandler) IssueAccountToken(c *gin.Context) {
+	var req models.ModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	username := c.Param("username")
+	token, err := h.adminService.IssueAccountToken(adminActor(c), username, req.Reason)
+	if err != nil {
+		respondAdminError(c, err, "Failed to issue account token")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"username": username, "account_token": token})
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupAdminTestRouter creates a test router with admin, game and
+// leaderboard handlers. Admin authentication is covered by the export tests.
+func setupAdminTestRouter(db *database.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	adminHandler := NewAdminHandler(db)
+	gameHandler := NewGameHandler(db)
+	userHandler := NewUserHandler(db)
+
+	api := router.Group("/api")
+	api.POST("/play", gameHandler.PlayGame)
+	api.GET("/leaderboard", userHandler.GetLeaderboard)
+
+	admin := router.Group("/admin")
+	admin.GET("/users", adminHandler.SearchUsers)
+	admin.GET("/users/:username", adminHandler.GetUserRecord)
+	admin.POST("/users/:username/coins", adminHandler.AdjustCoins)
+	admin.POST("/users/:username/reset-streak", adminHandler.ResetStreak)
+	admin.POST("/users/:username/ban", adminHandler.BanUser)
+	admin.POST("/users/:username/suspend", adminHandler.SuspendUser)
+	admin.POST("/users/:username/reinstate", adminHandler.ReinstateUser)
+	admin.PUT("/users/:username/username", adminHandler.RenameUser)
+	admin.DELETE("/users/:username", adminHandler.DeleteUser)
+	admin.GET("/actions", adminHandler.GetActions)
+
+	return router
+}
+
+// adminRequest sends a JSON request as the named admin
+func adminRequest(router *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Admin-Actor", "moderator")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminHandler(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	router := setupAdminTestRouter(db)
+
+	userService := services.NewUserService(db)
+	for _, name := range []string{"troll_1", "troll_2", "goodplayer"} {
+		if _, err := userService.CreateUser(name); err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+	}
+	if w := postJSON(router, "/api/play", models.PlayGameRequest{Username: "troll_1", PlayerChoice: models.Rock}); w.Code != http.StatusOK {
+		t.Fatalf("Failed to play game: status %d", w.Code)
+	}
+
+	onLeaderboard := func(username string) bool {
+		w := adminRequest(router, "GET", "/api/leaderboard", nil)
+		var response struct {
+			Leaderboard []models.LeaderboardEntry `json:"leaderboard"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse leaderboard: %v", err)
+		}
+		for _, entry := range response.Leaderboard {
+			if entry.Username == username {
+				return true
+			}
+		}
+		return false
+	}
+
+	t.Run("Success - Search users", func(t *testing.T) {
+		w := adminRequest(router, "GET", "/admin/users?q=troll_", nil)
+		var response struct {
+			Users []models.User `json:"users"`
+			Total int           `json:"total"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		// the underscore is matched literally rather than as a wildcard
+		if response.Total != 2 || len(response.Users) != 2 || response.Users[0].Username != "troll_1" {
+			t.Errorf("Expected both trolls, got %+v", response)
+		}
+	})
+
+	t.Run("Success - Adjust coins", func(t *testing.T) {
+		w := adminRequest(router, "POST", "/admin/users/goodplayer/coins", models.AdjustCoinsRequest{Amount: 250, Reason: "Compensation for outage"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var entry models.CoinTransaction
+		if err := json.Unmarshal(w.Body.Bytes(), &entry); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if entry.Type != models.TxAdminAdjustment || entry.BalanceAfter != 250 {
+			t.Errorf("Expected an admin adjustment to 250 coins, got %+v", entry)
+		}
+	})
+
+	t.Run("Error - Debit below zero", func(t *testing.T) {
+		w := adminRequest(router, "POST", "/admin/users/troll_2/coins", models.AdjustCoinsRequest{Amount: -10, Reason: "Clawback"})
+		if w.Code != http.StatusPaymentRequired {
+			t.Errorf("Expected status %d, got %d", http.StatusPaymentRequired, w.Code)
+		}
+	})
+
+	t.Run("Success - Ban blocks play and hides from the leaderboard", func(t *testing.T) {
+		if !onLeaderboard("troll_1") {
+			t.Fatal("Expected troll_1 on the leaderboard before the ban")
+		}
+		w := adminRequest(router, "POST", "/admin/users/troll_1/ban", models.ModerationRequest{Reason: "Harassment"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		if w := postJSON(router, "/api/play", models.PlayGameRequest{Username: "troll_1", PlayerChoice: models.Rock}); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d for a banned player, got %d", http.StatusForbidden, w.Code)
+		}
+		if onLeaderboard("troll_1") {
+			t.Error("Expected troll_1 to be hidden from the leaderboard")
+		}
+	})
+
+	t.Run("Success - Reinstate", func(t *testing.T) {
+		w := adminRequest(router, "POST", "/admin/users/troll_1/reinstate", models.ModerationRequest{Reason: "Appeal accepted"})
+		var user models.User
+		if err := json.Unmarshal(w.Body.Bytes(), &user); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if user.Status != models.UserActive {
+			t.Errorf("Expected the user to be active, got %s", user.Status)
+		}
+		if w := postJSON(router, "/api/play", models.PlayGameRequest{Username: "troll_1", PlayerChoice: models.Rock}); w.Code != http.StatusOK {
+			t.Errorf("Expected a reinstated player to play, got %d", w.Code)
+		}
+	})
+
+	t.Run("Success - Suspend", func(t *testing.T) {
+		until := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		w := adminRequest(router, "POST", "/admin/users/troll_2/suspend", models.SuspendUserRequest{Reason: "Spam", Until: until})
+		var user models.User
+		if err := json.Unmarshal(w.Body.Bytes(), &user); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if user.Status != models.UserSuspended || user.SuspendedUntil == nil || !user.SuspendedUntil.Equal(until) {
+			t.Errorf("Expected a suspension until %s, got %+v", until, user)
+		}
+		if w := postJSON(router, "/api/play", models.PlayGameRequest{Username: "troll_2", PlayerChoice: models.Rock}); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d for a suspended player, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("Error - Suspension in the past", func(t *testing.T) {
+		w := adminRequest(router, "POST", "/admin/users/troll_2/suspend", models.SuspendUserRequest{Reason: "Spam", Until: time.Now().Add(-time.Hour)})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Success - Reset streak", func(t *testing.T) {
+		if _, err := db.Exec(`UPDATE users SET current_streak = 5 WHERE username = 'goodplayer'`); err != nil {
+			t.Fatalf("Failed to set streak: %v", err)
+		}
+		w := adminRequest(router, "POST", "/admin/users/goodplayer/reset-streak", models.ModerationRequest{Reason: "Exploit"})
+		var user models.User
+		if err := json.Unmarshal(w.Body.Bytes(), &user); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if user.CurrentStreak != 0 {
+			t.Errorf("Expected the streak to be reset, got %d", user.CurrentStreak)
+		}
+	})
+
+	t.Run("Success - Rename", func(t *testing.T) {
+		w := adminRequest(router, "PUT", "/admin/users/troll_2/username", models.RenameUserRequest{Username: "player_2", Reason: "Offensive name"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if _, err := userService.GetUser("player_2"); err != nil {
+			t.Errorf("Expected the user under the new name: %v", err)
+		}
+	})
+
+	t.Run("Error - Rename to a taken name", func(t *testing.T) {
+		w := adminRequest(router, "PUT", "/admin/users/player_2/username", models.RenameUserRequest{Username: "goodplayer", Reason: "Offensive name"})
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+		}
+	})
+
+	t.Run("Success - User record", func(t *testing.T) {
+		w := adminRequest(router, "GET", "/admin/users/player_2", nil)
+		var record models.AdminUserRecord
+		if err := json.Unmarshal(w.Body.Bytes(), &record); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if record.ModerationReason != "Spam" || len(record.Actions) != 2 {
+			t.Errorf("Expected the suspension and rename on record, got %+v", record)
+		}
+		if record.Actions[0].Action != "rename" || record.Actions[0].Actor != "moderator" {
+			t.Errorf("Expected the rename by moderator first, got %+v", record.Actions[0])
+		}
+	})
+
+	t.Run("Success - Delete user", func(t *testing.T) {
+		w := adminRequest(router, "DELETE", "/admin/users/troll_1?reason=Repeat+offender", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if w := adminRequest(router, "GET", "/admin/users/troll_1", nil); w.Code != http.StatusNotFound {
+			t.Errorf("Expected the deleted user to be gone, got %d", w.Code)
+		}
+	})
+
+	t.Run("Error - Delete without a reason", func(t *testing.T) {
+		if w := adminRequest(router, "DELETE", "/admin/users/goodplayer", nil); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Success - Audit log", func(t *testing.T) {
+		w := adminRequest(router, "GET", "/admin/actions", nil)
+		var response struct {
+			Actions []models.AdminAction `json:"actions"`
+			Total   int                  `json:"total"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		// coins, ban, reinstate, suspend, reset, rename and delete
+		if response.Total != 7 || response.Actions[0].Action != "delete" {
+			t.Errorf("Expected 7 actions ending with the delete, got %+v", response.Actions)
+		}
+	})
+}
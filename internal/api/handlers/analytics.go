@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnalyticsHandler handles player analytics requests
+type AnalyticsHandler struct {
+	analyticsService *services.AnalyticsService
+}
+
+// NewAnalyticsHandler creates a new analytics handler
+func NewAnalyticsHandler(db *database.DB) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: services.NewAnalyticsService(db),
+	}
+}
+
+// GetAnalytics returns a player's choice analytics; ?opponent= selects
+// computer (default), player or all games
+func (h *AnalyticsHandler) GetAnalytics(c *gin.Context) {
+	opponent := models.OpponentType(c.DefaultQuery("opponent", string(models.OpponentComputer)))
+
+	analytics, err := h.analyticsService.GetAnalytics(c.Param("username"), opponent)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "invalid opponent type") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get analytics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
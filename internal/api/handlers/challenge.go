@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChallengeHandler handles direct challenges between friends
+type ChallengeHandler struct {
+	challengeService *services.ChallengeService
+}
+
+// NewChallengeHandler creates a new challenge handler
+func NewChallengeHandler(db *database.DB) *ChallengeHandler {
+	return &ChallengeHandler{
+		challengeService: services.NewChallengeService(db),
+	}
+}
+
+// challengeErrorStatus maps challenge service errors to HTTP status codes
+func challengeErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "insufficient coins"):
+		return http.StatusPaymentRequired
+	case strings.Contains(err.Error(), "only the"),
+		strings.Contains(err.Error(), "can only challenge friends"):
+		return http.StatusForbidden
+	case strings.Contains(err.Error(), "already moved"),
+		strings.Contains(err.Error(), "is not open"),
+		strings.Contains(err.Error(), "is not in play"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "invalid"),
+		strings.Contains(err.Error(), "cannot challenge yourself"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondChallengeError writes a challenge service error, hiding internal details
+func respondChallengeError(c *gin.Context, err error, message string) {
+	status := challengeErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// challengeID parses the challenge ID path parameter
+func challengeID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Challenge ID must be a positive integer"})
+		return 0, false
+	}
+	return id, true
+}
+
+// CreateChallenge invites a friend to a match
+func (h *ChallengeHandler) CreateChallenge(c *gin.Context) {
+	var req models.CreateChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	challenge, err := h.challengeService.CreateChallenge(req)
+	if err != nil {
+		respondChallengeError(c, err, "Failed to create challenge")
+		return
+	}
+
+	c.JSON(http.StatusCreated, challenge)
+}
+
+// GetChallenge returns a challenge and its rounds
+func (h *ChallengeHandler) GetChallenge(c *gin.Context) {
+	id, ok := challengeID(c)
+	if !ok {
+		return
+	}
+
+	challenge, err := h.challengeService.GetChallenge(id)
+	if err != nil {
+		respondChallengeError(c, err, "Failed to get challenge")
+		return
+	}
+
+	c.JSON(http.StatusOK, challenge)
+}
+
+// GetUserChallenges lists a user's challenges, optionally filtered by ?status=
+func (h *ChallengeHandler) GetUserChallenges(c *gin.Context) {
+	challenges, err := h.challengeService.GetUserChallenges(c.Param("username"), models.ChallengeStatus(c.Query("status")))
+	if err != nil {
+		respondChallengeError(c, err, "Failed to get challenges")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"username":         c.Param("username"),
+		"challenges":       challenges,
+		"total_challenges": len(challenges),
+	})
+}
+
+// respond runs an accept, decline or cancel action on a challenge for the
+// authenticated player
+func (h *ChallengeHandler) respond(c *gin.Context, action func(int, string) (*models.Challenge, error), message string) {
+	id, ok := challengeID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ChallengeActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	challenge, err := action(id, req.Username)
+	if err != nil {
+		respondChallengeError(c, err, message)
+		return
+	}
+
+	c.JSON(http.StatusOK, challenge)
+}
+
+// AcceptChallenge accepts a pending challenge
+func (h *ChallengeHandler) AcceptChallenge(c *gin.Context) {
+	h.respond(c, h.challengeService.AcceptChallenge, "Failed to accept challenge")
+}
+
+// DeclineChallenge declines a pending challenge
+func (h *ChallengeHandler) DeclineChallenge(c *gin.Context) {
+	h.respond(c, h.challengeService.DeclineChallenge, "Failed to decline challenge")
+}
+
+// CancelChallenge withdraws a pending challenge
+func (h *ChallengeHandler) CancelChallenge(c *gin.Context) {
+	h.respond(c, h.challengeService.CancelChallenge, "Failed to cancel challenge")
+}
+
+// SubmitMove plays a player's choice for the current round
+func (h *ChallengeHandler) SubmitMove(c *gin.Context) {
+	id, ok := challengeID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ChallengeMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	challenge, err := h.challengeService.SubmitMove(id, req)
+	if err != nil {
+		respondChallengeError(c, err, "Failed to submit move")
+		return
+	}
+
+	c.JSON(http.StatusOK, challenge)
+}
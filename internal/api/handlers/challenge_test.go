@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupChallengeTestRouter creates a test router with challenge handlers
+func setupChallengeTestRouter(db *database.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	challengeHandler := NewChallengeHandler(db)
+
+	api := router.Group("/api")
+	api.GET("/challenges/:id", challengeHandler.GetChallenge)
+	api.GET("/users/:username/challenges", challengeHandler.GetUserChallenges)
+
+	player := router.Group("/api", testPlayerAuth)
+	player.POST("/challenges", challengeHandler.CreateChallenge)
+	player.POST("/challenges/:id/accept", challengeHandler.AcceptChallenge)
+	player.POST("/challenges/:id/decline", challengeHandler.DeclineChallenge)
+	player.POST("/challenges/:id/cancel", challengeHandler.CancelChallenge)
+	player.POST("/challenges/:id/moves", challengeHandler.SubmitMove)
+
+	return router
+}
+
+// decodeChallenge parses a challenge response
+func decodeChallenge(t *testing.T, w *httptest.ResponseRecorder) models.Challenge {
+	var challenge models.Challenge
+	if err := json.Unmarshal(w.Body.Bytes(), &challenge); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	return challenge
+}
+
+func TestChallengeHandler(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	router := setupChallengeTestRouter(db)
+
+	userService := services.NewUserService(db)
+	friendService := services.NewFriendService(db)
+	ledger := services.NewLedgerService(db)
+	for _, name := range []string{"alice", "bob", "stranger"} {
+		user, err := userService.CreateUser(name)
+		if err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		if _, err := ledger.Record(user.ID, models.TxAdminAdjustment, 100, "", "test balance"); err != nil {
+			t.Fatalf("Failed to credit test user: %v", err)
+		}
+	}
+	if _, err := friendService.SendRequest("alice", "bob"); err != nil {
+		t.Fatalf("Failed to send friend request: %v", err)
+	}
+	if err := friendService.AcceptRequest("bob", "alice"); err != nil {
+		t.Fatalf("Failed to accept friend request: %v", err)
+	}
+
+	coins := func(username string) int {
+		user, err := userService.GetUser(username)
+		if err != nil {
+			t.Fatalf("Failed to get user: %v", err)
+		}
+		return user.TotalCoins
+	}
+
+	t.Run("Success - Best of three with stakes", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/challenges", "alice", models.CreateChallengeRequest{Opponent: "bob", BestOf: 3, Stake: 40})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		challenge := decodeChallenge(t, w)
+		if challenge.Status != models.ChallengePending {
+			t.Errorf("Expected status %s, got %s", models.ChallengePending, challenge.Status)
+		}
+		if coins("alice") != 60 {
+			t.Errorf("Expected alice's stake in escrow, balance %d", coins("alice"))
+		}
+
+		base := fmt.Sprintf("/api/challenges/%d", challenge.ID)
+		w = playerPostJSON(router, base+"/accept", "bob", models.ChallengeActionRequest{})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if coins("bob") != 60 {
+			t.Errorf("Expected bob's stake in escrow, balance %d", coins("bob"))
+		}
+
+		// alice throws rock first; her choice must stay hidden
+		w = playerPostJSON(router, base+"/moves", "alice", models.ChallengeMoveRequest{PlayerChoice: models.Rock})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		challenge = decodeChallenge(t, w)
+		if len(challenge.Rounds) != 1 || !challenge.Rounds[0].ChallengerMoved || challenge.Rounds[0].ChallengerChoice != "" {
+			t.Fatalf("Expected a hidden move in round 1, got %+v", challenge.Rounds)
+		}
+
+		w = playerPostJSON(router, base+"/moves", "alice", models.ChallengeMoveRequest{PlayerChoice: models.Paper})
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d for a second move, got %d", http.StatusConflict, w.Code)
+		}
+
+		// rounds: win, tie (replayed), win
+		moves := []struct{ alice, bob models.Choice }{
+			{"", models.Scissors},
+			{models.Paper, models.Paper},
+			{models.Scissors, models.Paper},
+		}
+		for _, move := range moves {
+			if move.alice != "" {
+				playerPostJSON(router, base+"/moves", "alice", models.ChallengeMoveRequest{PlayerChoice: move.alice})
+			}
+			w = playerPostJSON(router, base+"/moves", "bob", models.ChallengeMoveRequest{PlayerChoice: move.bob})
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+		}
+
+		challenge = decodeChallenge(t, w)
+		if challenge.Status != models.ChallengeCompleted || challenge.Winner != "alice" {
+			t.Fatalf("Expected alice to win the challenge, got %s/%s", challenge.Status, challenge.Winner)
+		}
+		if challenge.ChallengerWins != 2 || challenge.OpponentWins != 0 || len(challenge.Rounds) != 3 {
+			t.Errorf("Expected 2-0 over 3 rounds, got %d-%d over %d", challenge.ChallengerWins, challenge.OpponentWins, len(challenge.Rounds))
+		}
+		if challenge.Rounds[0].ChallengerChoice != models.Rock || challenge.Rounds[1].Winner != models.RoundTie {
+			t.Errorf("Expected resolved rounds to reveal choices, got %+v", challenge.Rounds)
+		}
+		if coins("alice") != 140 || coins("bob") != 60 {
+			t.Errorf("Expected balances 140/60, got %d/%d", coins("alice"), coins("bob"))
+		}
+	})
+
+	t.Run("Success - Decline refunds the challenger", func(t *testing.T) {
+		before := coins("alice")
+		w := playerPostJSON(router, "/api/challenges", "alice", models.CreateChallengeRequest{Opponent: "bob", Stake: 25})
+		challenge := decodeChallenge(t, w)
+
+		w = playerPostJSON(router, fmt.Sprintf("/api/challenges/%d/decline", challenge.ID), "bob", models.ChallengeActionRequest{})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if decodeChallenge(t, w).Status != models.ChallengeDeclined {
+			t.Error("Expected the challenge to be declined")
+		}
+		if coins("alice") != before {
+			t.Errorf("Expected balance %d after refund, got %d", before, coins("alice"))
+		}
+
+		w = playerPostJSON(router, fmt.Sprintf("/api/challenges/%d/accept", challenge.ID), "bob", models.ChallengeActionRequest{})
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+		}
+	})
+
+	t.Run("Success - List challenges by status", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/users/bob/challenges?status=completed", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Challenges []models.Challenge `json:"challenges"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(response.Challenges) != 1 {
+			t.Errorf("Expected 1 completed challenge, got %d", len(response.Challenges))
+		}
+	})
+
+	t.Run("Error - Challenge a non-friend", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/challenges", "alice", models.CreateChallengeRequest{Opponent: "stranger"})
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("Error - Even best of", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/challenges", "alice", models.CreateChallengeRequest{Opponent: "bob", BestOf: 2})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Error - Stake above balance", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/challenges", "bob", models.CreateChallengeRequest{Opponent: "alice", Stake: 1000})
+		if w.Code != http.StatusPaymentRequired {
+			t.Errorf("Expected status %d, got %d", http.StatusPaymentRequired, w.Code)
+		}
+	})
+
+	t.Run("Error - Only the opponent can accept", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/challenges", "alice", models.CreateChallengeRequest{Opponent: "bob"})
+		challenge := decodeChallenge(t, w)
+
+		w = playerPostJSON(router, fmt.Sprintf("/api/challenges/%d/accept", challenge.ID), "alice", models.ChallengeActionRequest{})
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("Error - Acting as another player", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/challenges", "alice", models.CreateChallengeRequest{Opponent: "bob"})
+		challenge := decodeChallenge(t, w)
+
+		path := fmt.Sprintf("/api/challenges/%d/accept", challenge.ID)
+		if w := playerPostJSON(router, path, "alice", models.ChallengeActionRequest{Username: "bob"}); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d for another player's username, got %d", http.StatusForbidden, w.Code)
+		}
+		if w := postJSON(router, path, models.ChallengeActionRequest{Username: "bob"}); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d without a token, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+
+	t.Run("Error - Challenge not found", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/challenges/9999", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClanHandler handles clans, their members, the clan leaderboard and clan
+// wars
+type ClanHandler struct {
+	clanService *services.ClanService
+}
+
+// NewClanHandler creates a new clan handler
+func NewClanHandler(db *database.DB) *ClanHandler {
+	return &ClanHandler{
+		clanService: services.NewClanService(db),
+	}
+}
+
+// clanErrorStatus maps clan service errors to HTTP status codes
+func clanErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "is banned"),
+		strings.Contains(err.Error(), "is suspended"),
+		strings.Contains(err.Error(), "scheduled for deletion"),
+		strings.Contains(err.Error(), "is not a member"),
+		strings.Contains(err.Error(), "only officers"),
+		strings.Contains(err.Error(), "only the owner"),
+		strings.Contains(err.Error(), "invite only"):
+		return http.StatusForbidden
+	case strings.Contains(err.Error(), "already"),
+		strings.Contains(err.Error(), "must hand"),
+		strings.Contains(err.Error(), "is not pending"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "invalid"),
+		strings.Contains(err.Error(), "cannot"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondClanError writes a clan service error, hiding internal details
+func respondClanError(c *gin.Context, err error, message string) {
+	status := clanErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// clanWarID parses the clan war ID path parameter
+func clanWarID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Clan war ID must be a positive integer"})
+		return 0, false
+	}
+	return id, true
+}
+
+// CreateClan founds a clan with the requesting player as owner
+func (h *ClanHandler) CreateClan(c *gin.Context) {
+	var req models.CreateClanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	clan, err := h.clanService.CreateClan(req)
+	if err != nil {
+		respondClanError(c, err, "Failed to create clan")
+		return
+	}
+
+	c.JSON(http.StatusCreated, clan)
+}
+
+// GetClan returns a clan and its members
+func (h *ClanHandler) GetClan(c *gin.Context) {
+	clan, err := h.clanService.GetClan(c.Param("tag"))
+	if err != nil {
+		respondClanError(c, err, "Failed to get clan")
+		return
+	}
+
+	c.JSON(http.StatusOK, clan)
+}
+
+// GetLeaderboard ranks clans by what their members won in a season, given
+// by ?season= as an ID or "current" (the default)
+func (h *ClanHandler) GetLeaderboard(c *gin.Context) {
+	seasonID := 0
+	if param := c.Query("season"); param != "" && param != "current" {
+		id, err := strconv.Atoi(param)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Season must be a positive integer or 'current'"})
+			return
+		}
+		seasonID = id
+	}
+
+	season, leaderboard, err := h.clanService.GetLeaderboard(seasonID, 10) // Top 10 clans
+	if err != nil {
+		respondClanError(c, err, "Failed to get clan leaderboard")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"season":      season,
+		"leaderboard": leaderboard,
+		"total_clans": len(leaderboard),
+	})
+}
+
+// Invite lets an officer invite a player into the clan
+func (h *ClanHandler) Invite(c *gin.Context) {
+	var req models.ClanMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	if err := h.clanService.Invite(c.Param("tag"), req); err != nil {
+		respondClanError(c, err, "Failed to invite player")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Invite sent",
+		"clan":    strings.ToUpper(c.Param("tag")),
+		"member":  req.Member,
+	})
+}
+
+// DeclineInvite turns down a clan's invitation
+func (h *ClanHandler) DeclineInvite(c *gin.Context) {
+	var req models.ClanActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	if err := h.clanService.DeclineInvite(c.Param("tag"), req.Username); err != nil {
+		respondClanError(c, err, "Failed to decline invite")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invite declined"})
+}
+
+// GetInvites lists the clans that have invited a player
+func (h *ClanHandler) GetInvites(c *gin.Context) {
+	username := c.Param("username")
+
+	invites, err := h.clanService.GetInvites(username)
+	if err != nil {
+		respondClanError(c, err, "Failed to get clan invites")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"username": username,
+		"invites":  invites,
+	})
+}
+
+// Join adds the player to an open clan or one that invited them
+func (h *ClanHandler) Join(c *gin.Context) {
+	var req models.ClanActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	clan, err := h.clanService.Join(c.Param("tag"), req.Username)
+	if err != nil {
+		respondClanError(c, err, "Failed to join clan")
+		return
+	}
+
+	c.JSON(http.StatusOK, clan)
+}
+
+// Leave takes the player out of the clan, disbanding it if they were its
+// last member
+func (h *ClanHandler) Leave(c *gin.Context) {
+	var req models.ClanActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	disbanded, err := h.clanService.Leave(c.Param("tag"), req.Username)
+	if err != nil {
+		respondClanError(c, err, "Failed to leave clan")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Left clan",
+		"disbanded": disbanded,
+	})
+}
+
+// Kick removes a member from the clan
+func (h *ClanHandler) Kick(c *gin.Context) {
+	var req models.ClanMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	if err := h.clanService.Kick(c.Param("tag"), req); err != nil {
+		respondClanError(c, err, "Failed to remove member")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+// SetRole lets the owner change a member's role
+func (h *ClanHandler) SetRole(c *gin.Context) {
+	var req models.SetClanRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	clan, err := h.clanService.SetRole(c.Param("tag"), c.Param("member"), req)
+	if err != nil {
+		respondClanError(c, err, "Failed to set role")
+		return
+	}
+
+	c.JSON(http.StatusOK, clan)
+}
+
+// DeclareWar lets an officer challenge another clan
+func (h *ClanHandler) DeclareWar(c *gin.Context) {
+	var req models.DeclareClanWarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	war, err := h.clanService.DeclareWar(c.Param("tag"), req)
+	if err != nil {
+		respondClanError(c, err, "Failed to declare clan war")
+		return
+	}
+
+	c.JSON(http.StatusCreated, war)
+}
+
+// ListWars lists a clan's wars, newest first
+func (h *ClanHandler) ListWars(c *gin.Context) {
+	wars, err := h.clanService.ListWars(c.Param("tag"))
+	if err != nil {
+		respondClanError(c, err, "Failed to get clan wars")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"clan": strings.ToUpper(c.Param("tag")),
+		"wars": wars,
+	})
+}
+
+// GetWar returns a clan war and its score
+func (h *ClanHandler) GetWar(c *gin.Context) {
+	id, ok := clanWarID(c)
+	if !ok {
+		return
+	}
+
+	war, err := h.clanService.GetWar(id)
+	if err != nil {
+		respondClanError(c, err, "Failed to get clan war")
+		return
+	}
+
+	c.JSON(http.StatusOK, war)
+}
+
+// answerWar runs an accept or decline for the authenticated officer
+func (h *ClanHandler) answerWar(c *gin.Context, action func(int, string) (*models.ClanWar, error), message string) {
+	id, ok := clanWarID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ClanActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	war, err := action(id, req.Username)
+	if err != nil {
+		respondClanError(c, err, message)
+		return
+	}
+
+	c.JSON(http.StatusOK, war)
+}
+
+// AcceptWar starts a war the other clan declared
+func (h *ClanHandler) AcceptWar(c *gin.Context) {
+	h.answerWar(c, h.clanService.AcceptWar, "Failed to accept clan war")
+}
+
+// DeclineWar calls off a war before it starts
+func (h *ClanHandler) DeclineWar(c *gin.Context) {
+	h.answerWar(c, h.clanService.DeclineWar, "Failed to decline clan war")
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupClanTestRouter(db *database.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	userHandler := NewUserHandler(db)
+	clanHandler := NewClanHandler(db)
+
+	api := router.Group("/api")
+	api.POST("/users", userHandler.CreateUser)
+	api.GET("/users/:username", userHandler.GetUser)
+	api.GET("/clans/leaderboard", clanHandler.GetLeaderboard)
+	api.GET("/clans/:tag", clanHandler.GetClan)
+	api.GET("/clans/:tag/wars", clanHandler.ListWars)
+	api.GET("/clan-wars/:id", clanHandler.GetWar)
+	api.GET("/users/:username/clan-invites", clanHandler.GetInvites)
+
+	player := router.Group("/api", testPlayerAuth)
+	player.POST("/clans", clanHandler.CreateClan)
+	player.POST("/clans/:tag/invites", clanHandler.Invite)
+	player.POST("/clans/:tag/invites/decline", clanHandler.DeclineInvite)
+	player.POST("/clans/:tag/join", clanHandler.Join)
+	player.POST("/clans/:tag/leave", clanHandler.Leave)
+	player.POST("/clans/:tag/kick", clanHandler.Kick)
+	player.PUT("/clans/:tag/members/:member/role", clanHandler.SetRole)
+	player.POST("/clans/:tag/wars", clanHandler.DeclareWar)
+	player.POST("/clan-wars/:id/accept", clanHandler.AcceptWar)
+	player.POST("/clan-wars/:id/decline", clanHandler.DeclineWar)
+
+	return router
+}
+
+func TestClanHandler(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	router := setupClanTestRouter(db)
+
+	for _, name := range []string{"chief", "second", "joiner", "rival"} {
+		if w := postJSON(router, "/api/users", models.CreateUserRequest{Username: name}); w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create user %s: %s", name, w.Body.String())
+		}
+	}
+
+	t.Run("Success - Create a clan", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/clans", "chief", models.CreateClanRequest{Name: "Paper Tigers", Tag: "ppr"})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var clan models.Clan
+		json.Unmarshal(w.Body.Bytes(), &clan)
+		if clan.Tag != "PPR" || clan.MemberCount != 1 {
+			t.Errorf("Expected PPR with one member, got %+v", clan)
+		}
+	})
+
+	t.Run("Error - Tag taken", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/clans", "rival", models.CreateClanRequest{Name: "Copycats", Tag: "PPR"})
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+		}
+	})
+
+	t.Run("Error - Invalid tag", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/clans", "rival", models.CreateClanRequest{Name: "Rivals", Tag: "TOOLONG"})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Error - Joining an invite only clan", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/clans/ppr/join", "joiner", models.ClanActionRequest{})
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("Success - Invite, join and promote", func(t *testing.T) {
+		for _, member := range []string{"second", "joiner"} {
+			w := playerPostJSON(router, "/api/clans/PPR/invites", "chief", models.ClanMemberRequest{Member: member})
+			if w.Code != http.StatusCreated {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+			}
+		}
+
+		req := httptest.NewRequest("GET", "/api/users/joiner/clan-invites", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var invites struct {
+			Invites []models.ClanInvite `json:"invites"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &invites)
+		if len(invites.Invites) != 1 || invites.Invites[0].Tag != "PPR" {
+			t.Errorf("Expected an invite to PPR, got %+v", invites.Invites)
+		}
+
+		for _, member := range []string{"second", "joiner"} {
+			if w := playerPostJSON(router, "/api/clans/PPR/join", member, models.ClanActionRequest{}); w.Code != http.StatusOK {
+				t.Fatalf("Failed to join as %s: %s", member, w.Body.String())
+			}
+		}
+
+		body, _ := json.Marshal(models.SetClanRoleRequest{Role: models.RoleOfficer})
+		req = httptest.NewRequest("PUT", "/api/clans/PPR/members/second/role", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer chief")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		req = httptest.NewRequest("GET", "/api/users/second", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var user models.UserResponse
+		json.Unmarshal(w.Body.Bytes(), &user)
+		if user.ClanTag != "PPR" {
+			t.Errorf("Expected the PPR tag on the user, got %q", user.ClanTag)
+		}
+	})
+
+	t.Run("Error - Member cannot kick", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/clans/PPR/kick", "joiner", models.ClanMemberRequest{Member: "second"})
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("Error - Owner cannot leave", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/clans/PPR/leave", "chief", models.ClanActionRequest{})
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+		}
+	})
+
+	t.Run("Success - Declare and accept a war", func(t *testing.T) {
+		if w := playerPostJSON(router, "/api/clans", "rival", models.CreateClanRequest{Name: "Scissor Sisters", Tag: "SCS"}); w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create rival clan: %s", w.Body.String())
+		}
+
+		w := playerPostJSON(router, "/api/clans/PPR/wars", "second", models.DeclareClanWarRequest{Opponent: "scs"})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var war models.ClanWar
+		json.Unmarshal(w.Body.Bytes(), &war)
+		if war.Status != models.ClanWarPending || war.DurationHours != 24 {
+			t.Errorf("Expected a pending 24 hour war, got %+v", war)
+		}
+
+		path := fmt.Sprintf("/api/clan-wars/%d/accept", war.ID)
+		if w := playerPostJSON(router, path, "chief", models.ClanActionRequest{}); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d accepting for the wrong clan, got %d", http.StatusForbidden, w.Code)
+		}
+		w = playerPostJSON(router, path, "rival", models.ClanActionRequest{})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		json.Unmarshal(w.Body.Bytes(), &war)
+		if war.Status != models.ClanWarActive || war.EndsAt == nil {
+			t.Errorf("Expected an active war, got %+v", war)
+		}
+		if w := playerPostJSON(router, fmt.Sprintf("/api/clan-wars/%d/decline", war.ID), "rival", models.ClanActionRequest{}); w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d declining a started war, got %d", http.StatusConflict, w.Code)
+		}
+	})
+
+	t.Run("Success - Clan leaderboard", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/clans/leaderboard", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var response struct {
+			Leaderboard []models.ClanStanding `json:"leaderboard"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if len(response.Leaderboard) != 2 {
+			t.Errorf("Expected 2 clans, got %+v", response.Leaderboard)
+		}
+	})
+
+	t.Run("Error - Unknown clan", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/clans/NOPE", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
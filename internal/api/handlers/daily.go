@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DailyHandler handles daily reward and daily challenge requests
+type DailyHandler struct {
+	dailyService *services.DailyService
+}
+
+// NewDailyHandler creates a new daily handler
+func NewDailyHandler(db *database.DB) *DailyHandler {
+	return &DailyHandler{
+		dailyService: services.NewDailyService(db),
+	}
+}
+
+// dailyErrorStatus maps daily service errors to HTTP status codes
+func dailyErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "already claimed"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "not completed"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetDailyReward reports the state of a user's daily login reward
+func (h *DailyHandler) GetDailyReward(c *gin.Context) {
+	status, err := h.dailyService.GetRewardStatus(c.Param("username"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get daily reward"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// ClaimDailyReward claims today's login reward
+func (h *DailyHandler) ClaimDailyReward(c *gin.Context) {
+	claim, err := h.dailyService.ClaimReward(c.Param("username"))
+	if err != nil {
+		status := dailyErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			c.JSON(status, gin.H{"error": "Failed to claim daily reward"})
+			return
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, claim)
+}
+
+// GetDailyChallenges lists today's challenges with the user's progress
+func (h *DailyHandler) GetDailyChallenges(c *gin.Context) {
+	username := c.Param("username")
+
+	challenges, err := h.dailyService.GetChallenges(username)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get daily challenges"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"username":   username,
+		"challenges": challenges,
+	})
+}
+
+// ClaimDailyChallenge claims the reward of a completed challenge
+func (h *DailyHandler) ClaimDailyChallenge(c *gin.Context) {
+	challenge, balance, err := h.dailyService.ClaimChallenge(c.Param("username"), c.Param("id"))
+	if err != nil {
+		status := dailyErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			c.JSON(status, gin.H{"error": "Failed to claim challenge"})
+			return
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"challenge":   challenge,
+		"total_coins": balance,
+	})
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDailyHandler(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	dailyHandler := NewDailyHandler(db)
+	api := router.Group("/api")
+	api.PUT("/users/:username/timezone", NewUserHandler(db).SetTimezone)
+	api.GET("/users/:username/daily-reward", dailyHandler.GetDailyReward)
+	api.POST("/users/:username/daily-reward", dailyHandler.ClaimDailyReward)
+	api.GET("/users/:username/daily-challenges", dailyHandler.GetDailyChallenges)
+	api.POST("/users/:username/daily-challenges/:id/claim", dailyHandler.ClaimDailyChallenge)
+
+	if _, err := services.NewUserService(db).CreateUser("dailyplayer"); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	t.Run("Error - Invalid timezone", func(t *testing.T) {
+		body, _ := json.Marshal(models.SetTimezoneRequest{Timezone: "Mars/Olympus_Mons"})
+		req := httptest.NewRequest("PUT", "/api/users/dailyplayer/timezone", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Success - Set timezone", func(t *testing.T) {
+		body, _ := json.Marshal(models.SetTimezoneRequest{Timezone: "Europe/Berlin"})
+		req := httptest.NewRequest("PUT", "/api/users/dailyplayer/timezone", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("Error - Timezone changed too recently", func(t *testing.T) {
+		for _, timezone := range []string{"Europe/Berlin", "Pacific/Kiritimati"} {
+			body, _ := json.Marshal(models.SetTimezoneRequest{Timezone: timezone})
+			req := httptest.NewRequest("PUT", "/api/users/dailyplayer/timezone", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// setting the current timezone again is not a change
+			want := http.StatusOK
+			if timezone != "Europe/Berlin" {
+				want = http.StatusTooManyRequests
+			}
+			if w.Code != want {
+				t.Errorf("Expected status %d for %s, got %d", want, timezone, w.Code)
+			}
+		}
+	})
+
+	t.Run("Success - Claim daily reward once", func(t *testing.T) {
+		w := postJSON(router, "/api/users/dailyplayer/daily-reward", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var claim models.DailyRewardClaim
+		if err := json.Unmarshal(w.Body.Bytes(), &claim); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if claim.Coins <= 0 || claim.TotalCoins != claim.Coins {
+			t.Errorf("Unexpected claim: %+v", claim)
+		}
+
+		w = postJSON(router, "/api/users/dailyplayer/daily-reward", nil)
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d for second claim, got %d", http.StatusConflict, w.Code)
+		}
+
+		req := httptest.NewRequest("GET", "/api/users/dailyplayer/daily-reward", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var status models.DailyRewardStatus
+		json.Unmarshal(w.Body.Bytes(), &status)
+		if !status.ClaimedToday || status.Timezone != "Europe/Berlin" {
+			t.Errorf("Unexpected status: %+v", status)
+		}
+	})
+
+	t.Run("Success - List daily challenges", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/users/dailyplayer/daily-challenges", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Challenges []models.DailyChallenge `json:"challenges"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if len(response.Challenges) == 0 {
+			t.Fatal("Expected daily challenges")
+		}
+
+		w = postJSON(router, "/api/users/dailyplayer/daily-challenges/"+response.Challenges[0].ID+"/claim", nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for incomplete challenge, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Error - User not found", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/users/nobody/daily-challenges", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"rockpaperscissors/internal/api/middleware"
+	"rockpaperscissors/internal/api/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DocsHandler serves the OpenAPI documents of the API
+type DocsHandler struct{}
+
+// NewDocsHandler creates a new docs handler
+func NewDocsHandler() *DocsHandler {
+	return &DocsHandler{}
+}
+
+// GetOpenAPIDocument returns the OpenAPI document of the request's API
+// version
+func (h *DocsHandler) GetOpenAPIDocument(c *gin.Context) {
+	c.JSON(http.StatusOK, openapi.Spec(middleware.APIVersion(c)))
+}
+
+// GetDocs renders the documents in Swagger UI, newest version first
+func (h *DocsHandler) GetDocs(c *gin.Context) {
+	versions := make([]int, len(openapi.Versions))
+	for i, version := range openapi.Versions {
+		versions[len(versions)-1-i] = version
+	}
+	c.HTML(http.StatusOK, "docs.html", gin.H{
+		"title":    "Rock Paper Scissors API",
+		"versions": versions,
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler handles game history export requests
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(db *database.DB) *ExportHandler {
+	return &ExportHandler{
+		exportService: services.NewExportService(db),
+	}
+}
+
+// ExportUserGames streams a player's whole game history as a file download;
+// ?format= is csv (default), ndjson or parquet and ?gzip=true compresses it
+func (h *ExportHandler) ExportUserGames(c *gin.Context) {
+	username := c.Param("username")
+	h.stream(c, username+"-games", func(format models.ExportFormat, w io.Writer) (int, error) {
+		return h.exportService.ExportUserGames(username, format, w)
+	})
+}
+
+// ExportAllGames streams every game of every player, for admins
+func (h *ExportHandler) ExportAllGames(c *gin.Context) {
+	h.stream(c, "games", h.exportService.ExportAllGames)
+}
+
+// stream sets up the download headers and optional gzip compression around
+// an export. Errors found before the first byte is sent get a JSON response;
+// later ones can only cut the download short.
+func (h *ExportHandler) stream(c *gin.Context, name string, export func(models.ExportFormat, io.Writer) (int, error)) {
+	format := models.ExportFormat(c.DefaultQuery("format", string(models.ExportCSV)))
+	if !format.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv, ndjson or parquet"})
+		return
+	}
+	compress := c.Query("gzip") == "true"
+
+	filename := name + "." + string(format)
+	contentType := format.ContentType()
+	if compress {
+		filename += ".gz"
+		contentType = "application/gzip"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	var w io.Writer = c.Writer
+	var zw *gzip.Writer
+	if compress {
+		zw = gzip.NewWriter(c.Writer)
+		w = zw
+	}
+
+	_, err := export(format, w)
+	if err != nil && !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Disposition")
+		c.Header("Content-Type", "application/json")
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export games"})
+		return
+	}
+	if err != nil {
+		log.Printf("Game export %s was cut short: %v", filename, err)
+		return
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			log.Printf("Failed to finish compressing %s: %v", filename, err)
+		}
+	}
+}
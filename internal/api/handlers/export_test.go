@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"rockpaperscissors/internal/api/middleware"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/parquet-go/parquet-go"
+)
+
+const testAdminToken = "test-admin-token"
+
+// setupExportTestRouter creates a test router with export handlers
+func setupExportTestRouter(db *database.DB, adminToken string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	exportHandler := NewExportHandler(db)
+
+	api := router.Group("/api")
+	api.Use(middleware.JSONMiddleware())
+	api.GET("/users/:username/games/export", exportHandler.ExportUserGames)
+
+	admin := router.Group("/admin")
+	admin.Use(middleware.AdminAuth(adminToken))
+	admin.GET("/games/export", exportHandler.ExportAllGames)
+
+	return router
+}
+
+func TestExportHandler(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	router := setupExportTestRouter(db, testAdminToken)
+
+	userService := services.NewUserService(db)
+	analyst, err := userService.CreateUser("exporter")
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	rival, err := userService.CreateUser("exportrival")
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	for _, result := range []string{"win", "lose", "tie"} {
+		_, err := db.Exec(`INSERT INTO games (user_id, player_choice, computer_choice, result, coins_earned, played_at) VALUES (?, 'rock', 'scissors', ?, 10, '2025-03-01 10:00:00')`,
+			analyst.ID, result)
+		if err != nil {
+			t.Fatalf("Failed to insert game: %v", err)
+		}
+	}
+	_, err = db.Exec(`INSERT INTO games (user_id, player_choice, computer_choice, result, opponent_user_id, played_at) VALUES (?, 'paper', 'rock', 'win', ?, '2025-03-02 10:00:00')`,
+		analyst.ID, rival.ID)
+	if err != nil {
+		t.Fatalf("Failed to insert game: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO games (user_id, player_choice, computer_choice, result, opponent_user_id, played_at) VALUES (?, 'rock', 'paper', 'lose', ?, '2025-03-02 10:00:00')`,
+		rival.ID, analyst.ID)
+	if err != nil {
+		t.Fatalf("Failed to insert game: %v", err)
+	}
+
+	get := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Success - CSV export", func(t *testing.T) {
+		w := get("/api/users/exporter/games/export", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if w.Header().Get("Content-Type") != "text/csv" {
+			t.Errorf("Expected text/csv, got %s", w.Header().Get("Content-Type"))
+		}
+		if w.Header().Get("Content-Disposition") != `attachment; filename="exporter-games.csv"` {
+			t.Errorf("Unexpected Content-Disposition %s", w.Header().Get("Content-Disposition"))
+		}
+
+		records, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse csv: %v", err)
+		}
+		if !reflect.DeepEqual(records[0], models.GameExportColumns) {
+			t.Errorf("Expected header %v, got %v", models.GameExportColumns, records[0])
+		}
+		if len(records) != 5 {
+			t.Fatalf("Expected a header and 4 games, got %d rows", len(records))
+		}
+		if records[1][8] != "computer" || records[1][9] != "" || records[1][10] != "2025-03-01T10:00:00Z" {
+			t.Errorf("Unexpected computer game row %v", records[1])
+		}
+		if records[4][8] != "player" || records[4][9] == "" {
+			t.Errorf("Unexpected player game row %v", records[4])
+		}
+	})
+
+	t.Run("Success - NDJSON export uses the same columns", func(t *testing.T) {
+		w := get("/api/users/exporter/games/export?format=ndjson", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		lines := 0
+		scanner := bufio.NewScanner(w.Body)
+		for scanner.Scan() {
+			var row map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+				t.Fatalf("Failed to parse line %q: %v", scanner.Text(), err)
+			}
+			if len(row) != len(models.GameExportColumns) {
+				t.Errorf("Expected %d keys, got %v", len(models.GameExportColumns), row)
+			}
+			for _, column := range models.GameExportColumns {
+				if _, ok := row[column]; !ok {
+					t.Errorf("Expected key %s in %v", column, row)
+				}
+			}
+			lines++
+		}
+		if lines != 4 {
+			t.Errorf("Expected 4 lines, got %d", lines)
+		}
+	})
+
+	t.Run("Success - Parquet export uses the same columns", func(t *testing.T) {
+		w := get("/api/users/exporter/games/export?format=parquet", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		body := w.Body.Bytes()
+		file, err := parquet.OpenFile(bytes.NewReader(body), int64(len(body)))
+		if err != nil {
+			t.Fatalf("Failed to open parquet file: %v", err)
+		}
+		var columns []string
+		for _, field := range file.Schema().Fields() {
+			columns = append(columns, field.Name())
+		}
+		if !reflect.DeepEqual(columns, models.GameExportColumns) {
+			t.Errorf("Expected columns %v, got %v", models.GameExportColumns, columns)
+		}
+
+		rows, err := parquet.Read[models.GameExportRow](bytes.NewReader(body), int64(len(body)))
+		if err != nil {
+			t.Fatalf("Failed to read parquet rows: %v", err)
+		}
+		if len(rows) != 4 || rows[0].Result != "win" || rows[3].OpponentUserID == nil || *rows[3].OpponentUserID != int64(rival.ID) {
+			t.Errorf("Unexpected parquet rows %+v", rows)
+		}
+	})
+
+	t.Run("Success - Gzip compressed export", func(t *testing.T) {
+		w := get("/api/users/exporter/games/export?format=ndjson&gzip=true", nil)
+		if w.Header().Get("Content-Type") != "application/gzip" {
+			t.Errorf("Expected application/gzip, got %s", w.Header().Get("Content-Type"))
+		}
+		reader, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("Failed to open gzip stream: %v", err)
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("Failed to decompress: %v", err)
+		}
+		if lines := bytes.Count(data, []byte("\n")); lines != 4 {
+			t.Errorf("Expected 4 lines, got %d", lines)
+		}
+	})
+
+	t.Run("Success - Admin exports every game", func(t *testing.T) {
+		w := get("/admin/games/export", map[string]string{"Authorization": "Bearer " + testAdminToken})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		records, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse csv: %v", err)
+		}
+		if len(records) != 6 {
+			t.Errorf("Expected a header and 5 games, got %d rows", len(records))
+		}
+	})
+
+	t.Run("Error - Admin export without the token", func(t *testing.T) {
+		if w := get("/admin/games/export", nil); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+		if w := get("/admin/games/export", map[string]string{"Authorization": "Bearer wrong"}); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+
+	t.Run("Error - Admin API disabled without a token", func(t *testing.T) {
+		disabled := setupExportTestRouter(db, "")
+		req := httptest.NewRequest("GET", "/admin/games/export", nil)
+		req.Header.Set("Authorization", "Bearer ")
+		w := httptest.NewRecorder()
+		disabled.ServeHTTP(w, req)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+		}
+	})
+
+	t.Run("Error - User not found", func(t *testing.T) {
+		w := get("/api/users/nobody/games/export", nil)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+		if w.Header().Get("Content-Disposition") != "" {
+			t.Error("Expected no attachment for an error response")
+		}
+	})
+
+	t.Run("Error - Invalid format", func(t *testing.T) {
+		if w := get("/api/users/exporter/games/export?format=xlsx", nil); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
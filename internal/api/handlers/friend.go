@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FriendHandler handles friend requests and friend lists
+type FriendHandler struct {
+	friendService *services.FriendService
+}
+
+// NewFriendHandler creates a new friend handler
+func NewFriendHandler(db *database.DB) *FriendHandler {
+	return &FriendHandler{
+		friendService: services.NewFriendService(db),
+	}
+}
+
+// friendErrorStatus maps friend service errors to HTTP status codes
+func friendErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "already"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "cannot befriend yourself"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondFriendError writes a friend service error, hiding internal details
+func respondFriendError(c *gin.Context, err error, message string) {
+	status := friendErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// SendRequest sends a friend request, or accepts one already waiting from
+// the other user
+func (h *FriendHandler) SendRequest(c *gin.Context) {
+	var req models.FriendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	status, err := h.friendService.SendRequest(req.Username, req.Friend)
+	if err != nil {
+		respondFriendError(c, err, "Failed to send friend request")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"username": req.Username,
+		"friend":   req.Friend,
+		"status":   status,
+	})
+}
+
+// AcceptRequest accepts a pending friend request
+func (h *FriendHandler) AcceptRequest(c *gin.Context) {
+	var req models.FriendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	if err := h.friendService.AcceptRequest(req.Username, req.Friend); err != nil {
+		respondFriendError(c, err, "Failed to accept friend request")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"username": req.Username,
+		"friend":   req.Friend,
+		"status":   models.FriendshipAccepted,
+	})
+}
+
+// DeclineRequest declines a pending friend request
+func (h *FriendHandler) DeclineRequest(c *gin.Context) {
+	var req models.FriendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	if err := h.friendService.DeclineRequest(req.Username, req.Friend); err != nil {
+		respondFriendError(c, err, "Failed to decline friend request")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Friend request declined"})
+}
+
+// RemoveFriend removes a friend or withdraws a pending request
+func (h *FriendHandler) RemoveFriend(c *gin.Context) {
+	if err := h.friendService.RemoveFriend(c.Param("username"), c.Param("friend")); err != nil {
+		respondFriendError(c, err, "Failed to remove friend")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Friend removed"})
+}
+
+// GetFriends lists a user's friends and pending requests
+func (h *FriendHandler) GetFriends(c *gin.Context) {
+	list, err := h.friendService.GetFriends(c.Param("username"))
+	if err != nil {
+		respondFriendError(c, err, "Failed to get friends")
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetFriendsLeaderboard ranks a user among their friends
+func (h *FriendHandler) GetFriendsLeaderboard(c *gin.Context) {
+	leaderboard, err := h.friendService.GetFriendsLeaderboard(c.Param("username"), 10)
+	if err != nil {
+		respondFriendError(c, err, "Failed to get friends leaderboard")
+		return
+	}
+
+	respondLeaderboard(c, leaderboard)
+}
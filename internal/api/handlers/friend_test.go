@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupFriendTestRouter creates a test router with friend handlers
+func setupFriendTestRouter(db *database.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	friendHandler := NewFriendHandler(db)
+
+	api := router.Group("/api")
+	api.GET("/users/:username/friends", friendHandler.GetFriends)
+	api.DELETE("/users/:username/friends/:friend", friendHandler.RemoveFriend)
+	api.GET("/users/:username/friends/leaderboard", friendHandler.GetFriendsLeaderboard)
+
+	player := router.Group("/api", testPlayerAuth)
+	player.POST("/friends/requests", friendHandler.SendRequest)
+	player.POST("/friends/requests/accept", friendHandler.AcceptRequest)
+	player.POST("/friends/requests/decline", friendHandler.DeclineRequest)
+
+	return router
+}
+
+// getFriendList fetches and decodes a user's friend list
+func getFriendList(t *testing.T, router *gin.Engine, username string) models.FriendList {
+	req := httptest.NewRequest("GET", "/api/users/"+username+"/friends", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var list models.FriendList
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	return list
+}
+
+func TestFriendHandler(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	router := setupFriendTestRouter(db)
+
+	userService := services.NewUserService(db)
+	ledger := services.NewLedgerService(db)
+	for i, name := range []string{"alice", "bob", "carol", "dave"} {
+		user, err := userService.CreateUser(name)
+		if err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		if _, err := ledger.Record(user.ID, models.TxAdminAdjustment, (i+1)*100, "", "test balance"); err != nil {
+			t.Fatalf("Failed to credit test user: %v", err)
+		}
+	}
+
+	t.Run("Success - Send and accept request", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/friends/requests", "alice", models.FriendRequest{Friend: "bob"})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+
+		list := getFriendList(t, router, "bob")
+		if len(list.Incoming) != 1 || list.Incoming[0].Username != "alice" {
+			t.Fatalf("Expected an incoming request from alice, got %+v", list.Incoming)
+		}
+
+		w = playerPostJSON(router, "/api/friends/requests/accept", "bob", models.FriendRequest{Friend: "alice"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		list = getFriendList(t, router, "alice")
+		if len(list.Friends) != 1 || list.Friends[0].Username != "bob" {
+			t.Errorf("Expected bob as a friend, got %+v", list.Friends)
+		}
+	})
+
+	t.Run("Success - Crossed requests become friends", func(t *testing.T) {
+		playerPostJSON(router, "/api/friends/requests", "alice", models.FriendRequest{Friend: "carol"})
+		w := playerPostJSON(router, "/api/friends/requests", "carol", models.FriendRequest{Friend: "alice"})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+
+		var response struct {
+			Status models.FriendshipStatus `json:"status"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if response.Status != models.FriendshipAccepted {
+			t.Errorf("Expected status %s, got %s", models.FriendshipAccepted, response.Status)
+		}
+	})
+
+	t.Run("Success - Decline request", func(t *testing.T) {
+		playerPostJSON(router, "/api/friends/requests", "dave", models.FriendRequest{Friend: "bob"})
+		w := playerPostJSON(router, "/api/friends/requests/decline", "bob", models.FriendRequest{Friend: "dave"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		list := getFriendList(t, router, "dave")
+		if len(list.Outgoing) != 0 || len(list.Friends) != 0 {
+			t.Errorf("Expected no friends or requests, got %+v", list)
+		}
+	})
+
+	t.Run("Success - Friends leaderboard", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/users/alice/friends/leaderboard", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Leaderboard []models.LeaderboardEntry `json:"leaderboard"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		// dave has the most coins but is not alice's friend
+		var names []string
+		for _, entry := range response.Leaderboard {
+			names = append(names, entry.Username)
+		}
+		if len(names) != 3 || names[0] != "carol" || names[1] != "bob" || names[2] != "alice" {
+			t.Errorf("Expected [carol bob alice], got %v", names)
+		}
+	})
+
+	t.Run("Success - Remove friend", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/api/users/bob/friends/alice", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		list := getFriendList(t, router, "alice")
+		if len(list.Friends) != 1 || list.Friends[0].Username != "carol" {
+			t.Errorf("Expected only carol as a friend, got %+v", list.Friends)
+		}
+	})
+
+	t.Run("Error - Duplicate request", func(t *testing.T) {
+		playerPostJSON(router, "/api/friends/requests", "dave", models.FriendRequest{Friend: "carol"})
+		w := playerPostJSON(router, "/api/friends/requests", "dave", models.FriendRequest{Friend: "carol"})
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+		}
+	})
+
+	t.Run("Error - Befriend yourself", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/friends/requests", "alice", models.FriendRequest{Friend: "alice"})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Error - Accept missing request", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/friends/requests/accept", "alice", models.FriendRequest{Friend: "dave"})
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
@@ -1,11 +1,14 @@
 package handlers
 
 import (
-	"database/sql"
 	"net/http"
 	"strings"
+	"time"
 
+	"rockpaperscissors/internal/api/middleware"
+	"rockpaperscissors/internal/database"
 	"rockpaperscissors/internal/models"
+	v2 "rockpaperscissors/internal/models/v2"
 	"rockpaperscissors/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -17,24 +20,82 @@ type GameHandler struct {
 }
 
 // NewGameHandler creates a new game handler
-func NewGameHandler(db *sql.DB) *GameHandler {
+func NewGameHandler(db *database.DB) *GameHandler {
 	return &GameHandler{
 		gameService: services.NewGameService(db),
 	}
 }
 
-// GetUserGames retrieves game history for a user
+// parseHistoryTime reads an RFC 3339 time or a YYYY-MM-DD date (midnight UTC).
+// A date passed as the exclusive upper bound covers that whole day.
+func parseHistoryTime(raw string, upperBound bool) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, raw); err == nil {
+		return at, nil
+	}
+	day, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if upperBound {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
+// parseGameHistoryFilter reads the game history query parameters: result,
+// choice, opponent type (computer, player or all), from/to dates, order (desc
+// by default), limit, and cursor to continue from a previous page's
+// next_cursor
+func parseGameHistoryFilter(c *gin.Context) (models.GameHistoryFilter, bool) {
+	limit, ok := parseLimit(c, 20)
+	if !ok {
+		return models.GameHistoryFilter{}, false
+	}
+	filter := models.GameHistoryFilter{
+		Result:       models.GameResult(c.Query("result")),
+		PlayerChoice: models.Choice(c.Query("choice")),
+		Opponent:     models.OpponentType(c.Query("opponent")),
+		Order:        models.SortOrder(c.Query("order")),
+		Cursor:       c.Query("cursor"),
+		Limit:        limit,
+	}
+	for param, bound := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		at, err := parseHistoryTime(raw, param == "to")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": param + " must be a date (YYYY-MM-DD) or an RFC 3339 time"})
+			return models.GameHistoryFilter{}, false
+		}
+		*bound = at
+	}
+	return filter, true
+}
+
+// GetUserGames retrieves a page of a user's game history, filtered and paged
+// by the query parameters parseGameHistoryFilter reads
 func (h *GameHandler) GetUserGames(c *gin.Context) {
 	username := c.Param("username")
 
+	filter, ok := parseGameHistoryFilter(c)
+	if !ok {
+		return
+	}
+
 	// Get game history from game service
-	games, err := h.gameService.GetUserGameHistory(username, 20) // Last 20 games
+	games, nextCursor, err := h.gameService.GetUserGameHistory(username, filter)
 	if err != nil {
 		// Check if it's a "user not found" error
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if strings.Contains(err.Error(), "invalid") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		// Other database errors
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get game history"})
 		return
@@ -44,6 +105,7 @@ func (h *GameHandler) GetUserGames(c *gin.Context) {
 		"username":    username,
 		"games":       games,
 		"total_games": len(games),
+		"next_cursor": nextCursor,
 	})
 }
 
@@ -61,13 +123,30 @@ func (h *GameHandler) PlayGame(c *gin.Context) {
 		return
 	}
 
+	if req.Bot == "" {
+		req.Bot = models.BotRandom
+	}
+	if !req.Bot.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bot, must be 'random', 'cycle', 'mirror', 'counter' or 'frequency'"})
+		return
+	}
+
 	// Step 3: Play the game using the game service
-	response, err := h.gameService.PlayGame(req.Username, req.PlayerChoice)
+	response, err := h.gameService.PlayGameAgainst(req.Username, req.PlayerChoice, req.Bot)
 	if err != nil {
+		if strings.Contains(err.Error(), "is banned") || strings.Contains(err.Error(), "is suspended") ||
+			strings.Contains(err.Error(), "scheduled for deletion") {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Step 4: Return the Game Result
+	if middleware.APIVersion(c) >= 2 {
+		c.JSON(http.StatusOK, v2.NewPlayGameResponse(response))
+		return
+	}
 	c.JSON(http.StatusOK, response)
 }
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"bytes"
-	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -18,24 +17,19 @@ import (
 )
 
 // setupGameTestDB creates a temporary test database for game tests
-func setupGameTestDB(t *testing.T) *sql.DB {
+func setupGameTestDB(t *testing.T) *database.DB {
 	// Create temporary directory for test database
 	tempDir := t.TempDir()
 	dbPath := filepath.Join(tempDir, "game_test.db")
 
-	// Open test database
-	db, err := sql.Open("sqlite3", dbPath)
+	// Open test database; foreign keys are enforced on every connection
+	db, err := database.Open(dbPath)
 	if err != nil {
 		t.Fatalf("Failed to open test database: %v", err)
 	}
 
-	// Enable foreign keys
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		t.Fatalf("Failed to enable foreign keys: %v", err)
-	}
-
 	// Run migrations
-	if err := database.RunMigrations(db); err != nil {
+	if err := database.RunMigrations(db.DB); err != nil {
 		t.Fatalf("Failed to run migrations: %v", err)
 	}
 
@@ -43,7 +37,7 @@ func setupGameTestDB(t *testing.T) *sql.DB {
 }
 
 // setupGameTestRouter creates a test router with game handlers
-func setupGameTestRouter(db *sql.DB) *gin.Engine {
+func setupGameTestRouter(db *database.DB) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 
@@ -205,6 +199,53 @@ func TestGameHandler_PlayGame(t *testing.T) {
 		}
 	})
 
+	t.Run("Error - Invalid bot", func(t *testing.T) {
+		invalidJson := `{"username": "gamer123", "player_choice": "rock", "bot": "cheater"}`
+
+		req := httptest.NewRequest("POST", "/api/play", bytes.NewBufferString(invalidJson))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for invalid bot, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Success - The counter bot answers your last move", func(t *testing.T) {
+		// it goes off script half the time, so it gets a few games to
+		// beat a repeated rock with paper
+		countered := false
+		for i := 0; i < 20; i++ {
+			var response models.PlayGameResponse
+			reqBody := models.PlayGameRequest{
+				Username:     "gamer123",
+				PlayerChoice: models.Rock,
+				Bot:          models.BotCounter,
+			}
+			jsonBody, _ := json.Marshal(reqBody)
+
+			req := httptest.NewRequest("POST", "/api/play", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if i > 0 && response.ComputerChoice == models.Paper && response.Result == models.Lose {
+				countered = true
+			}
+		}
+
+		if !countered {
+			t.Error("Expected the counter bot to beat a repeated rock with paper")
+		}
+	})
+
 	t.Run("Error - Empty username", func(t *testing.T) {
 		reqBody := models.PlayGameRequest{
 			Username:     "",
@@ -480,6 +521,151 @@ func TestGameHandler_Integration(t *testing.T) {
 	})
 }
 
+// TestGameHandler_GetUserGames_Pagination pages through game history with cursors and filters
+func TestGameHandler_GetUserGames_Pagination(t *testing.T) {
+	db := setupGameTestDB(t)
+	defer db.Close()
+
+	router := setupGameTestRouter(db)
+
+	userService := services.NewUserService(db)
+	user, err := userService.CreateUser("pager")
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	rival, err := userService.CreateUser("pagerrival")
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	// five computer games, two of them in the same second, and one game
+	// against another player
+	games := []struct {
+		choice   models.Choice
+		result   models.GameResult
+		playedAt string
+	}{
+		{models.Rock, models.Win, "2025-03-01 10:00:00"},
+		{models.Paper, models.Lose, "2025-03-02 10:00:00"},
+		{models.Rock, models.Tie, "2025-03-02 10:00:00"},
+		{models.Scissors, models.Win, "2025-03-03 10:00:00"},
+		{models.Rock, models.Lose, "2025-03-04 10:00:00"},
+	}
+	for _, game := range games {
+		_, err := db.Exec(`INSERT INTO games (user_id, player_choice, computer_choice, result, played_at) VALUES (?, ?, 'rock', ?, ?)`,
+			user.ID, string(game.choice), string(game.result), game.playedAt)
+		if err != nil {
+			t.Fatalf("Failed to insert game: %v", err)
+		}
+	}
+	_, err = db.Exec(`INSERT INTO games (user_id, player_choice, computer_choice, result, opponent_user_id, played_at) VALUES (?, 'paper', 'rock', 'win', ?, '2025-03-05 10:00:00')`,
+		user.ID, rival.ID)
+	if err != nil {
+		t.Fatalf("Failed to insert game: %v", err)
+	}
+
+	type historyResponse struct {
+		Games      []models.Game `json:"games"`
+		TotalGames int           `json:"total_games"`
+		NextCursor string        `json:"next_cursor"`
+	}
+	getHistory := func(t *testing.T, query string) (int, historyResponse) {
+		req := httptest.NewRequest("GET", "/api/users/pager/games"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response historyResponse
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+		}
+		return w.Code, response
+	}
+
+	t.Run("Success - Walk every page with a cursor", func(t *testing.T) {
+		var ids []int
+		query := "?opponent=computer&limit=2"
+		for page := 0; page < 5; page++ {
+			code, response := getHistory(t, query)
+			if code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, code)
+			}
+			for _, game := range response.Games {
+				ids = append(ids, game.ID)
+			}
+			if response.NextCursor == "" {
+				break
+			}
+			query = "?opponent=computer&limit=2&cursor=" + response.NextCursor
+		}
+
+		if len(ids) != 5 {
+			t.Fatalf("Expected 5 games across all pages, got %v", ids)
+		}
+		// newest first, with games played in the same second ordered by id
+		if ids[0] != 5 || ids[1] != 4 || ids[2] != 3 || ids[3] != 2 || ids[4] != 1 {
+			t.Errorf("Expected games 5, 4, 3, 2, 1, got %v", ids)
+		}
+	})
+
+	t.Run("Success - Ascending order", func(t *testing.T) {
+		_, response := getHistory(t, "?order=asc&limit=1")
+		if len(response.Games) != 1 || response.Games[0].ID != 1 || response.NextCursor == "" {
+			t.Errorf("Expected the oldest game first with a next cursor, got %+v", response)
+		}
+	})
+
+	t.Run("Success - Filters", func(t *testing.T) {
+		_, response := getHistory(t, "?result=win")
+		if response.TotalGames != 3 || response.NextCursor != "" {
+			t.Errorf("Expected 3 wins on one page, got %+v", response)
+		}
+
+		_, response = getHistory(t, "?result=win&opponent=computer")
+		if response.TotalGames != 2 {
+			t.Errorf("Expected 2 wins against the computer, got %d", response.TotalGames)
+		}
+
+		_, response = getHistory(t, "?choice=rock")
+		if response.TotalGames != 3 {
+			t.Errorf("Expected 3 rock games, got %d", response.TotalGames)
+		}
+
+		_, response = getHistory(t, "?opponent=player")
+		if response.TotalGames != 1 || response.Games[0].OpponentUserID == nil {
+			t.Errorf("Expected 1 game against a player, got %+v", response.Games)
+		}
+
+		_, response = getHistory(t, "?from=2025-03-02&to=2025-03-03")
+		if response.TotalGames != 3 {
+			t.Errorf("Expected 3 games from March 2nd through 3rd, got %d", response.TotalGames)
+		}
+
+		_, response = getHistory(t, "?from=2025-03-04T00:00:00Z")
+		if response.TotalGames != 2 {
+			t.Errorf("Expected 2 games since March 4th, got %d", response.TotalGames)
+		}
+	})
+
+	t.Run("Error - Invalid parameters", func(t *testing.T) {
+		for _, query := range []string{
+			"?result=draw",
+			"?choice=lizard",
+			"?opponent=robots",
+			"?order=sideways",
+			"?from=yesterday",
+			"?from=2025-03-04&to=2025-03-01",
+			"?cursor=not-a-cursor",
+			"?limit=0",
+		} {
+			if code, _ := getHistory(t, query); code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, query, code)
+			}
+		}
+	})
+}
+
 // TestGameLogic_Consistency tests that game outcomes are deterministic given the same inputs
 func TestGameLogic_Consistency(t *testing.T) {
 	db := setupGameTestDB(t)
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeadToHeadHandler handles head-to-head records between players
+type HeadToHeadHandler struct {
+	headToHeadService *services.HeadToHeadService
+}
+
+// NewHeadToHeadHandler creates a new head-to-head handler
+func NewHeadToHeadHandler(db *database.DB) *HeadToHeadHandler {
+	return &HeadToHeadHandler{
+		headToHeadService: services.NewHeadToHeadService(db),
+	}
+}
+
+// GetHeadToHead returns the record of a player against another player
+func (h *HeadToHeadHandler) GetHeadToHead(c *gin.Context) {
+	record, err := h.headToHeadService.GetHeadToHead(c.Param("username"), c.Param("opponent"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "invalid head-to-head") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get head-to-head record"})
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
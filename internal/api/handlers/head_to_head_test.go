@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupHeadToHeadTestRouter creates a test router with head-to-head and game handlers
+func setupHeadToHeadTestRouter(db *database.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	headToHeadHandler := NewHeadToHeadHandler(db)
+	gameHandler := NewGameHandler(db)
+	userHandler := NewUserHandler(db)
+
+	api := router.Group("/api")
+	api.GET("/users/:username/vs/:opponent", headToHeadHandler.GetHeadToHead)
+	api.GET("/users/:username/games", gameHandler.GetUserGames)
+	api.GET("/users/:username", userHandler.GetUser)
+
+	return router
+}
+
+// getHeadToHead fetches and decodes a head-to-head record
+func getHeadToHead(t *testing.T, router *gin.Engine, a, b string) models.HeadToHead {
+	req := httptest.NewRequest("GET", "/api/users/"+a+"/vs/"+b, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var record models.HeadToHead
+	if err := json.Unmarshal(w.Body.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	return record
+}
+
+func TestHeadToHeadHandler(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	router := setupHeadToHeadTestRouter(db)
+
+	userService := services.NewUserService(db)
+	friendService := services.NewFriendService(db)
+	challengeService := services.NewChallengeService(db)
+	ledger := services.NewLedgerService(db)
+	for _, name := range []string{"alice", "bob"} {
+		user, err := userService.CreateUser(name)
+		if err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		if _, err := ledger.Record(user.ID, models.TxAdminAdjustment, 100, "", "test balance"); err != nil {
+			t.Fatalf("Failed to credit test user: %v", err)
+		}
+	}
+	if _, err := friendService.SendRequest("alice", "bob"); err != nil {
+		t.Fatalf("Failed to send friend request: %v", err)
+	}
+	if _, err := friendService.SendRequest("bob", "alice"); err != nil {
+		t.Fatalf("Failed to accept friend request: %v", err)
+	}
+
+	// alice wins a best of five 3-1 with a tie along the way:
+	// win, win, tie, loss, win
+	challenge, err := challengeService.CreateChallenge(models.CreateChallengeRequest{Username: "alice", Opponent: "bob", BestOf: 5, Stake: 30})
+	if err != nil {
+		t.Fatalf("Failed to create challenge: %v", err)
+	}
+	if _, err := challengeService.AcceptChallenge(challenge.ID, "bob"); err != nil {
+		t.Fatalf("Failed to accept challenge: %v", err)
+	}
+	rounds := []struct{ alice, bob models.Choice }{
+		{models.Rock, models.Scissors},
+		{models.Rock, models.Scissors},
+		{models.Paper, models.Paper},
+		{models.Rock, models.Paper},
+		{models.Scissors, models.Paper},
+	}
+	for _, round := range rounds {
+		if _, err := challengeService.SubmitMove(challenge.ID, models.ChallengeMoveRequest{Username: "alice", PlayerChoice: round.alice}); err != nil {
+			t.Fatalf("Failed to submit move: %v", err)
+		}
+		if _, err := challengeService.SubmitMove(challenge.ID, models.ChallengeMoveRequest{Username: "bob", PlayerChoice: round.bob}); err != nil {
+			t.Fatalf("Failed to submit move: %v", err)
+		}
+	}
+
+	t.Run("Success - Record from both sides", func(t *testing.T) {
+		record := getHeadToHead(t, router, "alice", "bob")
+		if record.GamesPlayed != 5 || record.Wins != 3 || record.Losses != 1 || record.Ties != 1 {
+			t.Errorf("Expected 5 games 3-1-1, got %d games %d-%d-%d", record.GamesPlayed, record.Wins, record.Losses, record.Ties)
+		}
+		if record.CoinSwing != 30 || record.ChallengesPlayed != 1 {
+			t.Errorf("Expected a coin swing of 30 over 1 challenge, got %d over %d", record.CoinSwing, record.ChallengesPlayed)
+		}
+		if record.LongestWinStreak != 2 || record.OpponentLongestStreak != 1 {
+			t.Errorf("Expected streaks 2 and 1, got %d and %d", record.LongestWinStreak, record.OpponentLongestStreak)
+		}
+		if len(record.PlayerChoices) == 0 || record.PlayerChoices[0].Choice != models.Rock || record.PlayerChoices[0].Count != 3 {
+			t.Errorf("Expected rock thrown 3 times as alice's favourite, got %+v", record.PlayerChoices)
+		}
+		if len(record.OpponentChoices) == 0 || record.OpponentChoices[0].Choice != models.Paper {
+			t.Errorf("Expected paper as bob's favourite, got %+v", record.OpponentChoices)
+		}
+		if len(record.RecentGames) != 5 || record.RecentGames[0].Result != models.Win {
+			t.Errorf("Expected 5 recent games ending in a win, got %+v", record.RecentGames)
+		}
+
+		mirror := getHeadToHead(t, router, "bob", "alice")
+		if mirror.Wins != 1 || mirror.Losses != 3 || mirror.CoinSwing != -30 || mirror.OpponentLongestStreak != 2 {
+			t.Errorf("Expected the mirrored record, got %+v", mirror)
+		}
+	})
+
+	t.Run("Success - Games against players leave stats alone", func(t *testing.T) {
+		user, err := userService.GetUser("alice")
+		if err != nil {
+			t.Fatalf("Failed to get user: %v", err)
+		}
+		if user.GamesPlayed != 0 || user.GamesWon != 0 {
+			t.Errorf("Expected no computer games in stats, got %d played %d won", user.GamesPlayed, user.GamesWon)
+		}
+
+		req := httptest.NewRequest("GET", "/api/users/alice/games", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Games []models.Game `json:"games"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(response.Games) != 5 || response.Games[0].OpponentUserID == nil {
+			t.Errorf("Expected 5 games against bob in history, got %+v", response.Games)
+		}
+	})
+
+	t.Run("Success - No games yet", func(t *testing.T) {
+		if _, err := userService.CreateUser("carol"); err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		record := getHeadToHead(t, router, "alice", "carol")
+		if record.GamesPlayed != 0 || record.WinRate != 0 || len(record.RecentGames) != 0 {
+			t.Errorf("Expected an empty record, got %+v", record)
+		}
+	})
+
+	t.Run("Error - Same player", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/users/alice/vs/alice", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Error - Opponent not found", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/users/alice/vs/nobody", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
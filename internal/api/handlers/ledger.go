@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPageSize caps the number of items a single paginated request can return
+const maxPageSize = 100
+
+// LedgerHandler handles coin ledger requests
+type LedgerHandler struct {
+	ledgerService *services.LedgerService
+	userService   *services.UserService
+}
+
+// NewLedgerHandler creates a new ledger handler
+func NewLedgerHandler(db *database.DB) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerService: services.NewLedgerService(db),
+		userService:   services.NewUserService(db),
+	}
+}
+
+// parseLimit reads the limit query parameter, falling back to defaultLimit
+// and clamping it to maxPageSize
+func parseLimit(c *gin.Context, defaultLimit int) (int, bool) {
+	limit := defaultLimit
+	if raw := c.Query("limit"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return 0, false
+		}
+		limit = value
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	return limit, true
+}
+
+// parsePagination reads limit and offset query parameters, falling back to
+// defaultLimit and clamping the limit to maxPageSize
+func parsePagination(c *gin.Context, defaultLimit int) (int, int, bool) {
+	limit, ok := parseLimit(c, defaultLimit)
+	if !ok {
+		return 0, 0, false
+	}
+	offset := 0
+
+	if raw := c.Query("offset"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+			return 0, 0, false
+		}
+		offset = value
+	}
+
+	return limit, offset, true
+}
+
+// GetUserTransactions lists a user's coin transactions, newest first
+func (h *LedgerHandler) GetUserTransactions(c *gin.Context) {
+	username := c.Param("username")
+
+	limit, offset, ok := parsePagination(c, 20)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.GetUser(username)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+
+	transactions, total, err := h.ledgerService.GetUserTransactions(user.ID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"username":     username,
+		"balance":      user.TotalCoins,
+		"transactions": transactions,
+		"total":        total,
+		"limit":        limit,
+		"offset":       offset,
+	})
+}
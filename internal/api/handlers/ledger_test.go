@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupLedgerTestRouter creates a test router with ledger and game handlers
+func setupLedgerTestRouter(db *database.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	gameHandler := NewGameHandler(db)
+	ledgerHandler := NewLedgerHandler(db)
+
+	api := router.Group("/api")
+	api.POST("/play", gameHandler.PlayGame)
+	api.GET("/users/:username/transactions", ledgerHandler.GetUserTransactions)
+
+	return router
+}
+
+func TestLedgerHandler_GetUserTransactions(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	router := setupLedgerTestRouter(db)
+
+	userService := services.NewUserService(db)
+	user, err := userService.CreateUser("ledgeruser")
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	// Play until some coins have been earned
+	for i := 0; i < 30; i++ {
+		jsonBody, _ := json.Marshal(models.PlayGameRequest{Username: "ledgeruser", PlayerChoice: models.Rock})
+		req := httptest.NewRequest("POST", "/api/play", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Failed to play game: status %d", w.Code)
+		}
+	}
+
+	t.Run("Success - Ledger sums to balance", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/users/ledgeruser/transactions?limit=100", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Balance      int                      `json:"balance"`
+			Transactions []models.CoinTransaction `json:"transactions"`
+			Total        int                      `json:"total"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		sum := 0
+		for _, tx := range response.Transactions {
+			if tx.Type != models.TxGameReward && tx.Type != models.TxAchievement {
+				t.Errorf("Expected a game or achievement reward entry, got %s", tx.Type)
+			}
+			sum += tx.Amount
+		}
+		if sum != response.Balance {
+			t.Errorf("Ledger sum %d does not match balance %d", sum, response.Balance)
+		}
+		if response.Total != len(response.Transactions) {
+			t.Errorf("Expected total %d, got %d", len(response.Transactions), response.Total)
+		}
+	})
+
+	t.Run("Success - Pagination", func(t *testing.T) {
+		ledger := services.NewLedgerService(db)
+		for i := 0; i < 3; i++ {
+			if _, err := ledger.Record(user.ID, models.TxAdminAdjustment, 5, "", "pagination test"); err != nil {
+				t.Fatalf("Failed to record adjustment: %v", err)
+			}
+		}
+
+		req := httptest.NewRequest("GET", "/api/users/ledgeruser/transactions?limit=2&offset=1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Transactions []models.CoinTransaction `json:"transactions"`
+			Total        int                      `json:"total"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(response.Transactions) != 2 {
+			t.Errorf("Expected 2 transactions, got %d", len(response.Transactions))
+		}
+		if response.Total < 3 {
+			t.Errorf("Expected at least 3 transactions in total, got %d", response.Total)
+		}
+	})
+
+	t.Run("Error - Invalid limit", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/users/ledgeruser/transactions?limit=abc", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Error - User not found", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/users/nobody/transactions", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestLedgerService_Integrity(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	userService := services.NewUserService(db)
+	ledger := services.NewLedgerService(db)
+
+	user, err := userService.CreateUser("auditeduser")
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	t.Run("Debit below zero is rejected", func(t *testing.T) {
+		if _, err := ledger.Record(user.ID, models.TxPurchase, -10, "", ""); err == nil {
+			t.Error("Expected insufficient coins error")
+		}
+	})
+
+	t.Run("Entries cannot be edited", func(t *testing.T) {
+		entry, err := ledger.Record(user.ID, models.TxDailyBonus, 25, "", "")
+		if err != nil {
+			t.Fatalf("Failed to record bonus: %v", err)
+		}
+		if _, err := db.Exec("UPDATE coin_transactions SET amount = 1000 WHERE id = ?", entry.ID); err == nil {
+			t.Error("Expected ledger update to be rejected")
+		}
+	})
+
+	t.Run("Reconcile flags drift", func(t *testing.T) {
+		drifts, err := ledger.Reconcile()
+		if err != nil {
+			t.Fatalf("Failed to reconcile: %v", err)
+		}
+		if len(drifts) != 0 {
+			t.Errorf("Expected no drift, got %v", drifts)
+		}
+
+		// Simulate a direct write that bypasses the ledger
+		if _, err := db.Exec("UPDATE users SET total_coins = total_coins + 7 WHERE id = ?", user.ID); err != nil {
+			t.Fatalf("Failed to tamper with balance: %v", err)
+		}
+
+		drifts, err = ledger.Reconcile()
+		if err != nil {
+			t.Fatalf("Failed to reconcile: %v", err)
+		}
+		if len(drifts) != 1 || drifts[0].Drift != 7 {
+			t.Errorf("Expected a single drift of 7, got %v", drifts)
+		}
+	})
+
+	t.Run("Every transaction sums to zero", func(t *testing.T) {
+		unbalanced, err := ledger.UnbalancedTransactions()
+		if err != nil {
+			t.Fatalf("Failed to check balance: %v", err)
+		}
+		if len(unbalanced) != 0 {
+			t.Errorf("Expected every transaction to balance, got %v", unbalanced)
+		}
+
+		var systemTotal int
+		if err := db.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM system_ledger_entries WHERE account = 'system:bonuses'`).Scan(&systemTotal); err != nil {
+			t.Fatalf("Failed to sum bonuses account: %v", err)
+		}
+		if systemTotal != -25 {
+			t.Errorf("Expected the bonuses account to have paid out 25, got %d", -systemTotal)
+		}
+
+		// Simulate a user entry written without its system side
+		if _, err := db.Exec(`INSERT INTO coin_transactions (user_id, type, amount, balance_after, counter_account) VALUES (?, 'admin_adjustment', 5, 0, 'system:admin')`, user.ID); err != nil {
+			t.Fatalf("Failed to insert one-sided entry: %v", err)
+		}
+		unbalanced, err = ledger.UnbalancedTransactions()
+		if err != nil {
+			t.Fatalf("Failed to check balance: %v", err)
+		}
+		if len(unbalanced) != 1 || unbalanced[0].Imbalance != 5 {
+			t.Errorf("Expected a single transaction off by 5, got %v", unbalanced)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+)
+
+// benchmarkPlayers is how many accounts the benchmark spreads its games over
+const benchmarkPlayers = 64
+
+// BenchmarkPlayGame measures /api/play throughput against a database file,
+// once opened the way the server used to (driver defaults, foreign keys set
+// on one pooled connection) and once with database.Open's connection setup.
+// Requests that fail, mostly on "database is locked", are reported as
+// errors/op rather than failing the benchmark; plays/s only counts the
+// games that were actually settled.
+//
+//	go test ./internal/api/handlers -run '^$' -bench PlayGame -cpu 1,4
+func BenchmarkPlayGame(b *testing.B) {
+	setups := []struct {
+		name string
+		open func(path string) (*database.DB, error)
+	}{
+		{"driver-defaults", func(path string) (*database.DB, error) {
+			db, err := sql.Open("sqlite3", path)
+			if err != nil {
+				return nil, err
+			}
+			_, err = db.Exec("PRAGMA foreign_keys = ON")
+			return database.Wrap(db), err
+		}},
+		{"configured", database.Open},
+	}
+
+	for _, setup := range setups {
+		for _, clients := range []int{1, 16} {
+			b.Run(fmt.Sprintf("%s/clients=%d", setup.name, clients), func(b *testing.B) {
+				db, err := setup.open(filepath.Join(b.TempDir(), "bench.db"))
+				if err != nil {
+					b.Fatalf("Failed to open database: %v", err)
+				}
+				defer db.Close()
+				if err := database.RunMigrations(db.DB); err != nil {
+					b.Fatalf("Failed to run migrations: %v", err)
+				}
+				userService := services.NewUserService(db)
+				bodies := make([][]byte, benchmarkPlayers)
+				for i := range bodies {
+					username := fmt.Sprintf("bench%02d", i)
+					if _, err := userService.CreateUser(username); err != nil {
+						b.Fatalf("Failed to create user: %v", err)
+					}
+					bodies[i], _ = json.Marshal(models.PlayGameRequest{Username: username, PlayerChoice: models.Rock})
+				}
+				router := setupGameTestRouter(db)
+
+				var next, failed int64
+				b.SetParallelism(clients)
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						body := bodies[atomic.AddInt64(&next, 1)%benchmarkPlayers]
+						req := httptest.NewRequest("POST", "/api/play", bytes.NewReader(body))
+						req.Header.Set("Content-Type", "application/json")
+						w := httptest.NewRecorder()
+						router.ServeHTTP(w, req)
+						if w.Code != http.StatusOK {
+							atomic.AddInt64(&failed, 1)
+						}
+					}
+				})
+				b.StopTimer()
+				b.ReportMetric(float64(failed)/float64(b.N), "errors/op")
+				b.ReportMetric(float64(int64(b.N)-failed)/b.Elapsed().Seconds(), "plays/s")
+			})
+		}
+	}
+}
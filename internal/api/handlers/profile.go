@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// avatarUploadOverhead leaves room for the multipart headers around the image
+const avatarUploadOverhead = 64 << 10
+
+// ProfileHandler handles the profile fields players edit themselves
+type ProfileHandler struct {
+	profileService *services.ProfileService
+	userService    *services.UserService
+}
+
+// NewProfileHandler creates a new profile handler
+func NewProfileHandler(db *database.DB) *ProfileHandler {
+	return &ProfileHandler{
+		profileService: services.NewProfileService(db),
+		userService:    services.NewUserService(db),
+	}
+}
+
+// UpdateProfile changes the profile fields present in the request body
+func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.profileService.UpdateProfile(c.Param("username"), req)
+	if err != nil {
+		respondProfileError(c, err, "Failed to update profile")
+		return
+	}
+	h.respondUser(c, user)
+}
+
+// UploadAvatar stores the image in the "avatar" form field as the user's avatar
+func (h *ProfileHandler) UploadAvatar(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.MaxAvatarBytes+avatarUploadOverhead)
+
+	header, err := c.FormFile("avatar")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Avatar must be at most 1 MB"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "An image in the 'avatar' form field is required"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read avatar"})
+		return
+	}
+	defer file.Close()
+
+	user, err := h.profileService.SetAvatar(c.Param("username"), file)
+	if err != nil {
+		respondProfileError(c, err, "Failed to upload avatar")
+		return
+	}
+	h.respondUser(c, user)
+}
+
+// respondUser writes the public view of an updated user
+func (h *ProfileHandler) respondUser(c *gin.Context, user *models.User) {
+	cosmetics, err := h.userService.GetEquippedCosmetics(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+	c.JSON(http.StatusOK, userResponse(user, cosmetics))
+}
+
+// respondProfileError maps profile service errors to HTTP responses
+func respondProfileError(c *gin.Context, err error, fallback string) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "too recently"):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "invalid"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupProfileTestRouter(db *database.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	userHandler := NewUserHandler(db)
+	profileHandler := NewProfileHandler(db)
+
+	api := router.Group("/api")
+	api.POST("/users", userHandler.CreateUser)
+	api.GET("/users/:username", userHandler.GetUser)
+	api.PATCH("/users/:username", profileHandler.UpdateProfile)
+	api.POST("/users/:username/avatar", profileHandler.UploadAvatar)
+	api.GET("/leaderboard", userHandler.GetLeaderboard)
+
+	return router
+}
+
+func patchProfile(router *gin.Engine, username string, body interface{}) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(body)
+	req := httptest.NewRequest("PATCH", "/api/users/"+username, bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func uploadAvatar(router *gin.Engine, username string, data []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("avatar", "avatar.png")
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest("POST", "/api/users/"+username+"/avatar", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestProfileHandler(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	avatarDir := t.TempDir()
+	t.Setenv("AVATAR_DIR", avatarDir)
+	router := setupProfileTestRouter(db)
+
+	for _, name := range []string{"globetrotter", "homebody"} {
+		if w := postJSON(router, "/api/users", models.CreateUserRequest{Username: name}); w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create user %s: %s", name, w.Body.String())
+		}
+	}
+
+	t.Run("Success - Update profile fields", func(t *testing.T) {
+		w := patchProfile(router, "globetrotter", map[string]string{
+			"display_name": "  Globe Trotter ",
+			"avatar_url":   "https://example.com/me.png",
+			"country":      "gb",
+			"bio":          "Rock first,\r\nask later.",
+			"timezone":     "Europe/London",
+		})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var response models.UserResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		want := models.Profile{
+			DisplayName: "Globe Trotter",
+			AvatarURL:   "https://example.com/me.png",
+			Country:     "GB",
+			Bio:         "Rock first,\nask later.",
+		}
+		if response.Profile != want {
+			t.Errorf("Expected profile %+v, got %+v", want, response.Profile)
+		}
+	})
+
+	t.Run("Success - Omitted fields are unchanged and empty ones cleared", func(t *testing.T) {
+		w := patchProfile(router, "globetrotter", map[string]string{"bio": ""})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var response models.UserResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Profile.Bio != "" || response.Profile.DisplayName != "Globe Trotter" {
+			t.Errorf("Expected only the bio to be cleared, got %+v", response.Profile)
+		}
+	})
+
+	t.Run("Error - Invalid profile fields", func(t *testing.T) {
+		for _, body := range []map[string]string{
+			{"display_name": strings.Repeat("x", 33)},
+			{"display_name": "Admin"},
+			{"display_name": "evil\u202egnp.exe"},
+			{"avatar_url": "javascript:alert(1)"},
+			{"country": "Narnia"},
+			{"country": "EU"},
+			{"bio": "what the fuck"},
+			{"timezone": "Mars/Olympus"},
+			{},
+		} {
+			if w := patchProfile(router, "globetrotter", body); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %v, got %d", http.StatusBadRequest, body, w.Code)
+			}
+		}
+	})
+
+	t.Run("Error - User not found", func(t *testing.T) {
+		if w := patchProfile(router, "nobody", map[string]string{"bio": "hi"}); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("Success - Upload avatar replaces the previous one", func(t *testing.T) {
+		var img bytes.Buffer
+		png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 64, 64)))
+
+		var urls []string
+		for i := 0; i < 2; i++ {
+			w := uploadAvatar(router, "homebody", img.Bytes())
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			var response models.UserResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if !strings.HasPrefix(response.Profile.AvatarURL, services.AvatarURLPrefix) {
+				t.Fatalf("Expected a local avatar URL, got %q", response.Profile.AvatarURL)
+			}
+			urls = append(urls, response.Profile.AvatarURL)
+		}
+
+		files, _ := os.ReadDir(avatarDir)
+		if len(files) != 1 || files[0].Name() != filepath.Base(urls[1]) {
+			t.Errorf("Expected only the latest avatar to be kept, got %v", files)
+		}
+	})
+
+	t.Run("Error - Upload something that is not an image", func(t *testing.T) {
+		if w := uploadAvatar(router, "homebody", []byte("<svg onload=alert(1)>")); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Success - Leaderboard shows profiles and filters by country", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/leaderboard?country=gb", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Leaderboard []models.LeaderboardEntry `json:"leaderboard"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if len(response.Leaderboard) != 1 || response.Leaderboard[0].Username != "globetrotter" {
+			t.Fatalf("Expected only globetrotter in GB, got %+v", response.Leaderboard)
+		}
+		if response.Leaderboard[0].Profile.DisplayName != "Globe Trotter" {
+			t.Errorf("Expected the display name on the leaderboard, got %+v", response.Leaderboard[0].Profile)
+		}
+	})
+
+	t.Run("Error - Leaderboard with an invalid country", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/leaderboard?country=xyz", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SeasonHandler handles season requests
+type SeasonHandler struct {
+	seasonService *services.SeasonService
+	userService   *services.UserService
+}
+
+// NewSeasonHandler creates a new season handler
+func NewSeasonHandler(db *database.DB) *SeasonHandler {
+	return &SeasonHandler{
+		seasonService: services.NewSeasonService(db),
+		userService:   services.NewUserService(db),
+	}
+}
+
+// ListSeasons lists every season that has started, newest first
+func (h *SeasonHandler) ListSeasons(c *gin.Context) {
+	seasons, err := h.seasonService.ListSeasons()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get seasons"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"seasons":       seasons,
+		"total_seasons": len(seasons),
+	})
+}
+
+// GetSeasonLeaderboard returns the standings of a season; the ID may be
+// "current" for the season in progress
+func (h *SeasonHandler) GetSeasonLeaderboard(c *gin.Context) {
+	var seasonID int
+	if c.Param("id") == "current" {
+		season, err := h.seasonService.CurrentSeason()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current season"})
+			return
+		}
+		seasonID = season.ID
+	} else {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Season ID must be a positive integer or 'current'"})
+			return
+		}
+		seasonID = id
+	}
+
+	season, err := h.seasonService.GetSeason(seasonID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get season"})
+		return
+	}
+
+	leaderboard, err := h.seasonService.GetLeaderboard(season.ID, 10) // Top 10 users
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get season leaderboard"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"season":      season,
+		"leaderboard": leaderboard,
+		"total_users": len(leaderboard),
+	})
+}
+
+// GetUserSeasons lists a user's archived results across finished seasons
+func (h *SeasonHandler) GetUserSeasons(c *gin.Context) {
+	username := c.Param("username")
+
+	user, err := h.userService.GetUser(username)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+
+	results, err := h.seasonService.GetUserResults(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get season results"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"username": username,
+		"seasons":  results,
+	})
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSeasonHandler(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	seasonHandler := NewSeasonHandler(db)
+	api := router.Group("/api")
+	api.POST("/play", NewGameHandler(db).PlayGame)
+	api.GET("/seasons", seasonHandler.ListSeasons)
+	api.GET("/seasons/:id/leaderboard", seasonHandler.GetSeasonLeaderboard)
+	api.GET("/users/:username/seasons", seasonHandler.GetUserSeasons)
+
+	if _, err := services.NewUserService(db).CreateUser("seasonplayer"); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		w := postJSON(router, "/api/play", models.PlayGameRequest{Username: "seasonplayer", PlayerChoice: models.Paper})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Failed to play game: status %d", w.Code)
+		}
+	}
+
+	t.Run("Success - Current season leaderboard", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/seasons/current/leaderboard", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Season      models.Season           `json:"season"`
+			Leaderboard []models.SeasonStanding `json:"leaderboard"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if response.Season.Status != models.SeasonActive {
+			t.Errorf("Expected active season, got %s", response.Season.Status)
+		}
+		if len(response.Leaderboard) != 1 || response.Leaderboard[0].GamesPlayed != 3 {
+			t.Errorf("Unexpected season standings: %+v", response.Leaderboard)
+		}
+	})
+
+	t.Run("Success - List seasons", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/seasons", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("Success - User season history", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/users/seasonplayer/seasons", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("Error - Invalid season ID", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/seasons/abc/leaderboard", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Error - Season not found", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/seasons/9999/leaderboard", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShopHandler handles cosmetic shop requests
+type ShopHandler struct {
+	shopService *services.ShopService
+}
+
+// NewShopHandler creates a new shop handler
+func NewShopHandler(db *database.DB) *ShopHandler {
+	return &ShopHandler{
+		shopService: services.NewShopService(db),
+	}
+}
+
+// shopErrorStatus maps shop service errors to HTTP status codes
+func shopErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "already owns"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "insufficient coins"):
+		return http.StatusPaymentRequired
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetItems lists the shop catalog
+func (h *ShopHandler) GetItems(c *gin.Context) {
+	items, err := h.shopService.GetItems()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load shop catalog"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":       items,
+		"total_items": len(items),
+	})
+}
+
+// Purchase buys an item for a user
+func (h *ShopHandler) Purchase(c *gin.Context) {
+	var req models.PurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	item, balance, err := h.shopService.Purchase(req.Username, req.ItemID)
+	if err != nil {
+		status := shopErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			c.JSON(status, gin.H{"error": "Failed to purchase item"})
+			return
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"item":        item,
+		"total_coins": balance,
+	})
+}
+
+// Equip equips an owned item
+func (h *ShopHandler) Equip(c *gin.Context) {
+	var req models.EquipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	if err := h.shopService.Equip(req.Username, req.ItemID); err != nil {
+		status := shopErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			c.JSON(status, gin.H{"error": "Failed to equip item"})
+			return
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item equipped", "item_id": req.ItemID})
+}
+
+// Unequip clears one of a user's cosmetic slots
+func (h *ShopHandler) Unequip(c *gin.Context) {
+	var req models.UnequipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+	if !req.Slot.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid slot, must be 'avatar', 'hand_skin', 'victory_animation' or 'name_color'"})
+		return
+	}
+
+	if err := h.shopService.Unequip(req.Username, req.Slot); err != nil {
+		status := shopErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			c.JSON(status, gin.H{"error": "Failed to unequip slot"})
+			return
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Slot cleared", "slot": req.Slot})
+}
+
+// GetInventory lists the items a user owns
+func (h *ShopHandler) GetInventory(c *gin.Context) {
+	username := c.Param("username")
+
+	inventory, err := h.shopService.GetInventory(username)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get inventory"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"username":    username,
+		"inventory":   inventory,
+		"total_items": len(inventory),
+	})
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rockpaperscissors/internal/api/middleware"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupShopTestRouter creates a test router with shop and user handlers
+func setupShopTestRouter(db *database.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	shopHandler := NewShopHandler(db)
+	userHandler := NewUserHandler(db)
+
+	api := router.Group("/api")
+	api.GET("/shop/items", shopHandler.GetItems)
+	api.GET("/users/:username/inventory", shopHandler.GetInventory)
+	api.GET("/users/:username", userHandler.GetUser)
+	api.GET("/leaderboard", userHandler.GetLeaderboard)
+
+	player := router.Group("/api", testPlayerAuth)
+	player.POST("/shop/purchase", shopHandler.Purchase)
+	player.POST("/shop/equip", shopHandler.Equip)
+	player.POST("/shop/unequip", shopHandler.Unequip)
+
+	return router
+}
+
+// postJSON sends body as JSON to path and returns the recorded response
+func postJSON(router *gin.Engine, path string, body interface{}) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", path, bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// testPlayerAuth stands in for the account token check: the bearer token
+// is taken as the username of the player making the request
+var testPlayerAuth = middleware.PlayerAuth(func(token string) (string, error) {
+	return token, nil
+})
+
+// playerPostJSON sends body as JSON to path on behalf of player
+func playerPostJSON(router *gin.Engine, path, player string, body interface{}) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", path, bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+player)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestShopHandler(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	router := setupShopTestRouter(db)
+
+	userService := services.NewUserService(db)
+	user, err := userService.CreateUser("shopper")
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	t.Run("Success - List catalog", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/shop/items", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Items []models.ShopItem `json:"items"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(response.Items) == 0 {
+			t.Error("Expected catalog items")
+		}
+	})
+
+	t.Run("Error - Insufficient coins", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/shop/purchase", "shopper", models.PurchaseRequest{ItemID: "avatar-robot"})
+		if w.Code != http.StatusPaymentRequired {
+			t.Errorf("Expected status %d, got %d", http.StatusPaymentRequired, w.Code)
+		}
+	})
+
+	t.Run("Error - Missing token or someone else's username", func(t *testing.T) {
+		if w := postJSON(router, "/api/shop/purchase", models.PurchaseRequest{ItemID: "avatar-robot"}); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d without a token, got %d", http.StatusUnauthorized, w.Code)
+		}
+		w := playerPostJSON(router, "/api/shop/purchase", "shopper", models.PurchaseRequest{Username: "someone", ItemID: "avatar-robot"})
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d for another player's username, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("Error - Unknown item", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/shop/purchase", "shopper", models.PurchaseRequest{ItemID: "does-not-exist"})
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("Success - Purchase and equip", func(t *testing.T) {
+		ledger := services.NewLedgerService(db)
+		if _, err := ledger.Record(user.ID, models.TxAdminAdjustment, 200, "", "test setup"); err != nil {
+			t.Fatalf("Failed to credit coins: %v", err)
+		}
+
+		w := playerPostJSON(router, "/api/shop/purchase", "shopper", models.PurchaseRequest{ItemID: "avatar-robot"})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var purchase struct {
+			TotalCoins int `json:"total_coins"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &purchase)
+		if purchase.TotalCoins != 100 {
+			t.Errorf("Expected 100 coins left, got %d", purchase.TotalCoins)
+		}
+
+		w = playerPostJSON(router, "/api/shop/purchase", "shopper", models.PurchaseRequest{ItemID: "avatar-robot"})
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d for duplicate purchase, got %d", http.StatusConflict, w.Code)
+		}
+
+		w = playerPostJSON(router, "/api/shop/equip", "shopper", models.EquipRequest{ItemID: "avatar-robot"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		req := httptest.NewRequest("GET", "/api/users/shopper", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response models.UserResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if response.Cosmetics.Avatar == nil || response.Cosmetics.Avatar.ID != "avatar-robot" {
+			t.Errorf("Expected avatar-robot to be equipped, got %+v", response.Cosmetics)
+		}
+
+		req = httptest.NewRequest("GET", "/api/leaderboard", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var leaderboard struct {
+			Leaderboard []models.LeaderboardEntry `json:"leaderboard"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &leaderboard)
+		if len(leaderboard.Leaderboard) != 1 || leaderboard.Leaderboard[0].Cosmetics.Avatar == nil {
+			t.Errorf("Expected equipped avatar on leaderboard, got %+v", leaderboard.Leaderboard)
+		}
+	})
+
+	t.Run("Error - Equip item not owned", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/shop/equip", "shopper", models.EquipRequest{ItemID: "avatar-dragon"})
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("Success - Unequip and list inventory", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/shop/unequip", "shopper", models.UnequipRequest{Slot: models.SlotAvatar})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		req := httptest.NewRequest("GET", "/api/users/shopper/inventory", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Inventory []models.InventoryItem `json:"inventory"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(response.Inventory) != 1 || response.Inventory[0].Equipped {
+			t.Errorf("Expected one unequipped item, got %+v", response.Inventory)
+		}
+	})
+}
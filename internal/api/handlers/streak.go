@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StreakHandler handles win streak history and the streak leaderboard
+type StreakHandler struct {
+	streakService *services.StreakService
+	userService   *services.UserService
+}
+
+// NewStreakHandler creates a new streak handler
+func NewStreakHandler(db *database.DB) *StreakHandler {
+	return &StreakHandler{
+		streakService: services.NewStreakService(db),
+		userService:   services.NewUserService(db),
+	}
+}
+
+// GetUserStreaks lists a user's win streaks, newest first
+func (h *StreakHandler) GetUserStreaks(c *gin.Context) {
+	username := c.Param("username")
+
+	limit, offset, ok := parsePagination(c, 20)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.GetUser(username)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+
+	streaks, total, err := h.streakService.GetUserStreaks(user.ID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get streaks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"username":       user.Username,
+		"current_streak": user.CurrentStreak,
+		"best_streak":    user.BestStreak,
+		"streaks":        streaks,
+		"total":          total,
+		"limit":          limit,
+		"offset":         offset,
+	})
+}
+
+// GetStreakLeaderboard ranks players by their best streak ever
+func (h *StreakHandler) GetStreakLeaderboard(c *gin.Context) {
+	leaderboard, err := h.streakService.GetStreakLeaderboard(10)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get streak leaderboard"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"leaderboard": leaderboard,
+		"total_users": len(leaderboard),
+	})
+}
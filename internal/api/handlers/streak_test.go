@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupStreakTestRouter creates a test router with streak and game handlers
+func setupStreakTestRouter(db *database.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	streakHandler := NewStreakHandler(db)
+	gameHandler := NewGameHandler(db)
+
+	api := router.Group("/api")
+	api.POST("/play", gameHandler.PlayGame)
+	api.GET("/users/:username/streaks", streakHandler.GetUserStreaks)
+	api.GET("/leaderboard/streaks", streakHandler.GetStreakLeaderboard)
+
+	return router
+}
+
+func TestStreakHandler(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	router := setupStreakTestRouter(db)
+
+	userService := services.NewUserService(db)
+	if _, err := userService.CreateUser("streakplayer"); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	// Play until at least one streak has been recorded
+	for i := 0; i < 50; i++ {
+		w := postJSON(router, "/api/play", models.PlayGameRequest{Username: "streakplayer", PlayerChoice: models.Rock})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Failed to play game: status %d", w.Code)
+		}
+	}
+
+	t.Run("Success - Streak history matches the user", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/users/streakplayer/streaks?limit=100", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			CurrentStreak int             `json:"current_streak"`
+			BestStreak    int             `json:"best_streak"`
+			Streaks       []models.Streak `json:"streaks"`
+			Total         int             `json:"total"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if response.Total == 0 {
+			t.Fatal("Expected at least one streak after 50 games")
+		}
+
+		longest, active := 0, 0
+		for _, streak := range response.Streaks {
+			if streak.Length > longest {
+				longest = streak.Length
+			}
+			if streak.Status == models.StreakActive {
+				active++
+				if streak.Length != response.CurrentStreak {
+					t.Errorf("Expected the active streak to match current streak %d, got %d", response.CurrentStreak, streak.Length)
+				}
+			}
+		}
+		if longest != response.BestStreak {
+			t.Errorf("Expected best streak %d to be the longest streak %d", response.BestStreak, longest)
+		}
+		if active > 1 {
+			t.Errorf("Expected at most one active streak, got %d", active)
+		}
+	})
+
+	t.Run("Success - Streak leaderboard", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/leaderboard/streaks", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Leaderboard []models.StreakLeaderboardEntry `json:"leaderboard"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(response.Leaderboard) != 1 || response.Leaderboard[0].Username != "streakplayer" {
+			t.Errorf("Expected streakplayer on the streak leaderboard, got %+v", response.Leaderboard)
+		}
+	})
+
+	t.Run("Error - User not found", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/users/nobody/streaks", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TournamentHandler handles tournament requests. Creating, starting and
+// cancelling tournaments are admin routes.
+type TournamentHandler struct {
+	tournamentService *services.TournamentService
+}
+
+// NewTournamentHandler creates a new tournament handler
+func NewTournamentHandler(db *database.DB) *TournamentHandler {
+	return &TournamentHandler{
+		tournamentService: services.NewTournamentService(db),
+	}
+}
+
+// tournamentErrorStatus maps tournament service errors to HTTP status codes
+func tournamentErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "insufficient coins"):
+		return http.StatusPaymentRequired
+	case strings.Contains(err.Error(), "is banned"),
+		strings.Contains(err.Error(), "is suspended"),
+		strings.Contains(err.Error(), "scheduled for deletion"):
+		return http.StatusForbidden
+	case strings.Contains(err.Error(), "is closed"),
+		strings.Contains(err.Error(), "is full"),
+		strings.Contains(err.Error(), "already registered"),
+		strings.Contains(err.Error(), "not registered"),
+		strings.Contains(err.Error(), "already out"),
+		strings.Contains(err.Error(), "already moved"),
+		strings.Contains(err.Error(), "no match to play"),
+		strings.Contains(err.Error(), "is not open"),
+		strings.Contains(err.Error(), "is not in play"),
+		strings.Contains(err.Error(), "at least 2 players"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondTournamentError writes a tournament service error, hiding internal details
+func respondTournamentError(c *gin.Context, err error, message string) {
+	status := tournamentErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// tournamentID parses the tournament ID path parameter
+func tournamentID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tournament ID must be a positive integer"})
+		return 0, false
+	}
+	return id, true
+}
+
+// ListTournaments lists tournaments, optionally filtered by ?status=
+func (h *TournamentHandler) ListTournaments(c *gin.Context) {
+	tournaments, err := h.tournamentService.ListTournaments(models.TournamentStatus(c.Query("status")))
+	if err != nil {
+		respondTournamentError(c, err, "Failed to get tournaments")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tournaments":       tournaments,
+		"total_tournaments": len(tournaments),
+	})
+}
+
+// GetTournament returns a tournament
+func (h *TournamentHandler) GetTournament(c *gin.Context) {
+	id, ok := tournamentID(c)
+	if !ok {
+		return
+	}
+
+	tournament, err := h.tournamentService.GetTournament(id)
+	if err != nil {
+		respondTournamentError(c, err, "Failed to get tournament")
+		return
+	}
+
+	c.JSON(http.StatusOK, tournament)
+}
+
+// GetBracket returns every match of a tournament, round by round
+func (h *TournamentHandler) GetBracket(c *gin.Context) {
+	id, ok := tournamentID(c)
+	if !ok {
+		return
+	}
+
+	rounds, err := h.tournamentService.GetBracket(id)
+	if err != nil {
+		respondTournamentError(c, err, "Failed to get bracket")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tournament_id": id,
+		"rounds":        rounds,
+	})
+}
+
+// GetStandings returns a tournament's players from first to last
+func (h *TournamentHandler) GetStandings(c *gin.Context) {
+	id, ok := tournamentID(c)
+	if !ok {
+		return
+	}
+
+	standings, err := h.tournamentService.GetStandings(id)
+	if err != nil {
+		respondTournamentError(c, err, "Failed to get standings")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tournament_id": id,
+		"standings":     standings,
+	})
+}
+
+// register runs a registration or withdrawal for the authenticated player
+func (h *TournamentHandler) register(c *gin.Context, action func(int, string) (*models.Tournament, error), message string) {
+	id, ok := tournamentID(c)
+	if !ok {
+		return
+	}
+
+	var req models.TournamentRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	tournament, err := action(id, req.Username)
+	if err != nil {
+		respondTournamentError(c, err, message)
+		return
+	}
+
+	c.JSON(http.StatusOK, tournament)
+}
+
+// Register enters a player into a tournament
+func (h *TournamentHandler) Register(c *gin.Context) {
+	h.register(c, h.tournamentService.Register, "Failed to register for tournament")
+}
+
+// Withdraw takes a player out of a tournament
+func (h *TournamentHandler) Withdraw(c *gin.Context) {
+	h.register(c, h.tournamentService.Withdraw, "Failed to withdraw from tournament")
+}
+
+// SubmitMove plays a player's choice in their current match
+func (h *TournamentHandler) SubmitMove(c *gin.Context) {
+	id, ok := tournamentID(c)
+	if !ok {
+		return
+	}
+
+	var req models.TournamentMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !actingPlayer(c, &req.Username) {
+		return
+	}
+
+	match, err := h.tournamentService.SubmitMove(id, req)
+	if err != nil {
+		respondTournamentError(c, err, "Failed to submit move")
+		return
+	}
+
+	c.JSON(http.StatusOK, match)
+}
+
+// CreateTournament sets up a tournament and opens registration
+func (h *TournamentHandler) CreateTournament(c *gin.Context) {
+	var req models.CreateTournamentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tournament, err := h.tournamentService.CreateTournament(adminActor(c), req)
+	if err != nil {
+		respondTournamentError(c, err, "Failed to create tournament")
+		return
+	}
+
+	c.JSON(http.StatusCreated, tournament)
+}
+
+// StartTournament closes registration early and starts a tournament
+func (h *TournamentHandler) StartTournament(c *gin.Context) {
+	id, ok := tournamentID(c)
+	if !ok {
+		return
+	}
+
+	tournament, err := h.tournamentService.Start(id)
+	if err != nil {
+		respondTournamentError(c, err, "Failed to start tournament")
+		return
+	}
+
+	c.JSON(http.StatusOK, tournament)
+}
+
+// CancelTournament calls a tournament off and refunds the entry fees
+func (h *TournamentHandler) CancelTournament(c *gin.Context) {
+	id, ok := tournamentID(c)
+	if !ok {
+		return
+	}
+
+	tournament, err := h.tournamentService.Cancel(id)
+	if err != nil {
+		respondTournamentError(c, err, "Failed to cancel tournament")
+		return
+	}
+
+	c.JSON(http.StatusOK, tournament)
+}
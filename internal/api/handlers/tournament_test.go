@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rockpaperscissors/internal/api/middleware"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupTournamentTestRouter(db *database.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	userHandler := NewUserHandler(db)
+	tournamentHandler := NewTournamentHandler(db)
+
+	api := router.Group("/api")
+	api.POST("/users", userHandler.CreateUser)
+	api.GET("/tournaments", tournamentHandler.ListTournaments)
+	api.GET("/tournaments/:id", tournamentHandler.GetTournament)
+	api.GET("/tournaments/:id/bracket", tournamentHandler.GetBracket)
+	api.GET("/tournaments/:id/standings", tournamentHandler.GetStandings)
+
+	player := router.Group("/api", testPlayerAuth)
+	player.POST("/tournaments/:id/register", tournamentHandler.Register)
+	player.POST("/tournaments/:id/withdraw", tournamentHandler.Withdraw)
+	player.POST("/tournaments/:id/moves", tournamentHandler.SubmitMove)
+
+	admin := router.Group("/admin")
+	admin.Use(middleware.AdminAuth(testAdminToken))
+	admin.POST("/tournaments", tournamentHandler.CreateTournament)
+	admin.POST("/tournaments/:id/start", tournamentHandler.StartTournament)
+	admin.POST("/tournaments/:id/cancel", tournamentHandler.CancelTournament)
+
+	return router
+}
+
+// adminPostJSON sends a JSON POST with the test admin token
+func adminPostJSON(router *gin.Engine, path string, body interface{}) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", path, bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestTournamentHandler(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	router := setupTournamentTestRouter(db)
+
+	for _, name := range []string{"finalist1", "finalist2", "spectator"} {
+		if w := postJSON(router, "/api/users", models.CreateUserRequest{Username: name}); w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create user %s: %s", name, w.Body.String())
+		}
+	}
+
+	create := models.CreateTournamentRequest{
+		Name:                 "Friday final",
+		Format:               models.FormatSingleElimination,
+		RegistrationClosesAt: time.Now().Add(time.Hour),
+	}
+
+	t.Run("Error - Creating needs the admin token", func(t *testing.T) {
+		if w := postJSON(router, "/admin/tournaments", create); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+
+	t.Run("Error - Invalid format", func(t *testing.T) {
+		invalid := create
+		invalid.Format = "knockout"
+		if w := adminPostJSON(router, "/admin/tournaments", invalid); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Error - Unknown tournament", func(t *testing.T) {
+		w := playerPostJSON(router, "/api/tournaments/999/register", "finalist1", models.TournamentRegistrationRequest{})
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	w := adminPostJSON(router, "/admin/tournaments", create)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var tournament models.Tournament
+	json.Unmarshal(w.Body.Bytes(), &tournament)
+	base := fmt.Sprintf("/api/tournaments/%d", tournament.ID)
+	adminBase := fmt.Sprintf("/admin/tournaments/%d", tournament.ID)
+
+	t.Run("Success - Register, start and play the final", func(t *testing.T) {
+		for _, name := range []string{"finalist1", "finalist2"} {
+			if w := playerPostJSON(router, base+"/register", name, models.TournamentRegistrationRequest{}); w.Code != http.StatusOK {
+				t.Fatalf("Failed to register %s: %s", name, w.Body.String())
+			}
+		}
+		if w := playerPostJSON(router, base+"/register", "finalist1", models.TournamentRegistrationRequest{}); w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d registering twice, got %d", http.StatusConflict, w.Code)
+		}
+		if w := adminPostJSON(router, adminBase+"/start", nil); w.Code != http.StatusOK {
+			t.Fatalf("Failed to start tournament: %s", w.Body.String())
+		}
+
+		if w := playerPostJSON(router, base+"/moves", "spectator", models.TournamentMoveRequest{PlayerChoice: models.Rock}); w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d for a player without a match, got %d", http.StatusConflict, w.Code)
+		}
+		playerPostJSON(router, base+"/moves", "finalist1", models.TournamentMoveRequest{PlayerChoice: models.Paper})
+		w := playerPostJSON(router, base+"/moves", "finalist2", models.TournamentMoveRequest{PlayerChoice: models.Rock})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var match models.TournamentMatch
+		json.Unmarshal(w.Body.Bytes(), &match)
+		if match.Status != models.MatchCompleted || match.Winner != "finalist1" {
+			t.Errorf("Expected finalist1 to win the match, got %+v", match)
+		}
+	})
+
+	t.Run("Success - Bracket and standings", func(t *testing.T) {
+		req := httptest.NewRequest("GET", base+"/bracket", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var bracket struct {
+			Rounds []models.TournamentRound `json:"rounds"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &bracket)
+		if len(bracket.Rounds) != 1 || len(bracket.Rounds[0].Matches[0].Games) != 1 {
+			t.Errorf("Expected one round with one game, got %+v", bracket.Rounds)
+		}
+
+		req = httptest.NewRequest("GET", base+"/standings", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var standings struct {
+			Standings []models.TournamentPlayer `json:"standings"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &standings)
+		if len(standings.Standings) != 2 || standings.Standings[0].Username != "finalist1" || standings.Standings[0].Place != 1 {
+			t.Errorf("Expected finalist1 first, got %+v", standings.Standings)
+		}
+	})
+
+	t.Run("Error - Cancelling a finished tournament", func(t *testing.T) {
+		if w := adminPostJSON(router, adminBase+"/cancel", nil); w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+		}
+	})
+}
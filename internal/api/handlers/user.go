@@ -1,11 +1,13 @@
 package handlers
 
 import (
-	"database/sql"
 	"net/http"
 	"strings"
 
+	"rockpaperscissors/internal/api/middleware"
+	"rockpaperscissors/internal/database"
 	"rockpaperscissors/internal/models"
+	v2 "rockpaperscissors/internal/models/v2"
 	"rockpaperscissors/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -17,7 +19,7 @@ type UserHandler struct {
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(db *sql.DB) *UserHandler {
+func NewUserHandler(db *database.DB) *UserHandler {
 	return &UserHandler{
 		userService: services.NewUserService(db),
 	}
@@ -34,8 +36,13 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 
 	user, err := h.userService.CreateUser(req.Username)
 	if err != nil {
-		// Check if it's a duplicate user error
-		if strings.Contains(err.Error(), "already exists") {
+		// Names that break the username policy
+		if strings.Contains(err.Error(), "invalid username") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// Check if it's a duplicate or look-alike user error
+		if strings.Contains(err.Error(), "already exists") || strings.Contains(err.Error(), "too similar") {
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 			return
 		}
@@ -44,20 +51,9 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
-	// Calculate win rate (0 for new users)
-	winRate := 0.0
-	if user.GamesPlayed > 0 {
-		winRate = float64(user.GamesWon) / float64(user.GamesPlayed)
-	}
-
-	c.JSON(http.StatusCreated, models.UserResponse{
-		ID:            user.ID,
-		Username:      user.Username,
-		TotalCoins:    user.TotalCoins,
-		CurrentStreak: user.CurrentStreak,
-		GamesPlayed:   user.GamesPlayed,
-		GamesWon:      user.GamesWon,
-		WinRate:       winRate,
+	c.JSON(http.StatusCreated, models.CreateUserResponse{
+		UserResponse: userResponse(user, models.EquippedCosmetics{}),
+		AccountToken: user.AccountToken,
 	})
 }
 
@@ -77,21 +73,36 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		return
 	}
 
-	// Calculate win rate
+	cosmetics, err := h.userService.GetEquippedCosmetics(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, userResponse(user, cosmetics))
+}
+
+// userResponse builds the public view of a user
+func userResponse(user *models.User, cosmetics models.EquippedCosmetics) models.UserResponse {
+	// Calculate win rate (0 for new users)
 	winRate := 0.0
 	if user.GamesPlayed > 0 {
 		winRate = float64(user.GamesWon) / float64(user.GamesPlayed)
 	}
 
-	c.JSON(http.StatusOK, models.UserResponse{
+	return models.UserResponse{
 		ID:            user.ID,
 		Username:      user.Username,
+		ClanTag:       user.ClanTag,
 		TotalCoins:    user.TotalCoins,
 		CurrentStreak: user.CurrentStreak,
+		BestStreak:    user.BestStreak,
 		GamesPlayed:   user.GamesPlayed,
 		GamesWon:      user.GamesWon,
 		WinRate:       winRate,
-	})
+		Cosmetics:     cosmetics,
+		Profile:       user.Profile,
+	}
 }
 
 // GetUserStats retrieves user statistics
@@ -116,10 +127,12 @@ func (h *UserHandler) GetUserStats(c *gin.Context) {
 		winRate = float64(user.GamesWon) / float64(user.GamesPlayed)
 	}
 
-	// Calculate rank - count users with more coins + 1
-	// For now, we'll add a simple method to calculate rank
-	// TODO: Move this logic to UserService for better architecture
-	rank := 1 // Default rank, should be calculated from database
+	// Place on the leaderboard; 0 for users who are not on it
+	rank, err := h.userService.GetRank(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get rank"})
+		return
+	}
 
 	c.JSON(http.StatusOK, models.UserStats{
 		User:    *user,
@@ -128,17 +141,70 @@ func (h *UserHandler) GetUserStats(c *gin.Context) {
 	})
 }
 
-// GetLeaderboard retrieves the leaderboard
+// GetLeaderboard retrieves the leaderboard, optionally for one country
 func (h *UserHandler) GetLeaderboard(c *gin.Context) {
 	// Get leaderboard from user service
-	leaderboard, err := h.userService.GetLeaderboard(10) // Top 10 users
+	var leaderboard []models.LeaderboardEntry
+	var err error
+	if country := c.Query("country"); country != "" {
+		leaderboard, err = h.userService.GetCountryLeaderboard(country, 10)
+	} else {
+		leaderboard, err = h.userService.GetLeaderboard(10) // Top 10 users
+	}
 	if err != nil {
+		if strings.Contains(err.Error(), "invalid country") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get leaderboard"})
 		return
 	}
 
+	respondLeaderboard(c, leaderboard)
+}
+
+// respondLeaderboard writes a leaderboard in the entries of the request's
+// API version
+func respondLeaderboard(c *gin.Context, leaderboard []models.LeaderboardEntry) {
+	if middleware.APIVersion(c) >= 2 {
+		c.JSON(http.StatusOK, gin.H{
+			"leaderboard": v2.NewLeaderboard(leaderboard),
+			"total_users": len(leaderboard),
+		})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"leaderboard": leaderboard,
 		"total_users": len(leaderboard),
 	})
 }
+
+// SetTimezone changes the timezone used for a user's daily boundaries
+func (h *UserHandler) SetTimezone(c *gin.Context) {
+	username := c.Param("username")
+
+	var req models.SetTimezoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.SetTimezone(username, req.Timezone); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "invalid timezone") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "too recently") {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update timezone"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"username": username, "timezone": req.Timezone})
+}
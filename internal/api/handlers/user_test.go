@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"bytes"
-	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -11,30 +10,26 @@ import (
 
 	"rockpaperscissors/internal/database"
 	"rockpaperscissors/internal/models"
+	"rockpaperscissors/internal/services"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 // setupTestDB creates a temporary test database
-func setupTestDB(t *testing.T) *sql.DB {
+func setupTestDB(t *testing.T) *database.DB {
 	// Create temporary directory for test database
 	tempDir := t.TempDir()
 	dbPath := filepath.Join(tempDir, "test.db")
 
-	// Open test database
-	db, err := sql.Open("sqlite3", dbPath)
+	// Open test database; foreign keys are enforced on every connection
+	db, err := database.Open(dbPath)
 	if err != nil {
 		t.Fatalf("Failed to open test database: %v", err)
 	}
 
-	// Enable foreign keys
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		t.Fatalf("Failed to enable foreign keys: %v", err)
-	}
-
 	// Run migrations
-	if err := database.RunMigrations(db); err != nil {
+	if err := database.RunMigrations(db.DB); err != nil {
 		t.Fatalf("Failed to run migrations: %v", err)
 	}
 
@@ -42,7 +37,7 @@ func setupTestDB(t *testing.T) *sql.DB {
 }
 
 // setupTestRouter creates a test router with handlers
-func setupTestRouter(db *sql.DB) *gin.Engine {
+func setupTestRouter(db *database.DB) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 
@@ -147,6 +142,38 @@ func TestUserHandler_CreateUser(t *testing.T) {
 			t.Errorf("Expected status %d for empty username, got %d", http.StatusBadRequest, w.Code)
 		}
 	})
+	t.Run("Error - Username breaks policy", func(t *testing.T) {
+		for _, username := range []string{"bad name", "Admin", "xXshitXx"} {
+			jsonBody, _ := json.Marshal(models.CreateUserRequest{Username: username})
+			req := httptest.NewRequest("POST", "/api/users", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d for %q, got %d", http.StatusBadRequest, username, w.Code)
+			}
+		}
+	})
+
+	t.Run("Error - Username differs only in case or look-alikes", func(t *testing.T) {
+		if _, err := services.NewUserService(db).CreateUser("coach"); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		for _, username := range []string{"TestUser", "соасһ"} { // all Cyrillic
+			jsonBody, _ := json.Marshal(models.CreateUserRequest{Username: username})
+			req := httptest.NewRequest("POST", "/api/users", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusConflict {
+				t.Errorf("Expected status %d for %q, got %d", http.StatusConflict, username, w.Code)
+			}
+		}
+	})
 }
 
 func TestUserHandler_GetUser(t *testing.T) {
@@ -285,9 +312,12 @@ func TestUserHandler_GetLeaderboard(t *testing.T) {
 		user1, _ := userHandler.userService.CreateUser("leader1")
 		user2, _ := userHandler.userService.CreateUser("leader2")
 
-		// Update their stats to have different coin amounts
-		userHandler.userService.UpdateUserStats(user1.ID, 100, 2, 5, 4)
-		userHandler.userService.UpdateUserStats(user2.ID, 50, 1, 3, 2)
+		// Update their stats and credit different coin amounts through the ledger
+		userHandler.userService.UpdateUserStats(user1.ID, 2, 5, 4)
+		userHandler.userService.UpdateUserStats(user2.ID, 1, 3, 2)
+		ledger := services.NewLedgerService(db)
+		ledger.Record(user1.ID, models.TxAdminAdjustment, 100, "", "test setup")
+		ledger.Record(user2.ID, models.TxAdminAdjustment, 50, "", "test setup")
 
 		req := httptest.NewRequest("GET", "/api/leaderboard", nil)
 		w := httptest.NewRecorder()
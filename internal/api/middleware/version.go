@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VersionHeader tells clients which version of the API a response follows
+const VersionHeader = "API-Version"
+
+// apiVersionKey holds the API version of a request in the gin context
+const apiVersionKey = "apiVersion"
+
+// versionMediaType asks for one version of the API in an Accept header,
+// such as application/vnd.rockpaperscissors.v2+json
+var versionMediaType = regexp.MustCompile(`^application/vnd\.rockpaperscissors\.v([0-9]+)\+json$`)
+
+// APIVersion returns the API version a request is served at: 1 outside
+// the API groups
+func APIVersion(c *gin.Context) int {
+	if version := c.GetInt(apiVersionKey); version > 0 {
+		return version
+	}
+	return 1
+}
+
+// requestedVersion returns the API version named in the Accept header, or 0
+func requestedVersion(c *gin.Context) int {
+	for _, mediaRange := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(mediaRange, ";")
+		match := versionMediaType.FindStringSubmatch(strings.TrimSpace(strings.ToLower(mediaType)))
+		if match == nil {
+			continue
+		}
+		if version, err := strconv.Atoi(match[1]); err == nil && version > 0 {
+			return version
+		}
+	}
+	return 0
+}
+
+// PinAPIVersion serves a group of routes at one API version, such as
+// /api/v2. Asking for another version in the Accept header is an error.
+func PinAPIVersion(version int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if requested := requestedVersion(c); requested != 0 && requested != version {
+			c.JSON(http.StatusNotAcceptable, gin.H{
+				"error": fmt.Sprintf("API version %d was requested, but this path serves version %d", requested, version),
+			})
+			c.Abort()
+			return
+		}
+		c.Set(apiVersionKey, version)
+		c.Header(VersionHeader, strconv.Itoa(version))
+		c.Next()
+	}
+}
+
+// Deprecation describes when a path was deprecated, when it goes away and
+// the path to use instead
+type Deprecation struct {
+	Since     time.Time
+	Sunset    time.Time
+	Successor func(path string) string
+}
+
+// NegotiateAPIVersion serves the unversioned API paths at the version named
+// in the Accept header, if it is one of supported. Requests that do not
+// name one get the fallback version, with Deprecation and Sunset headers
+// and a link to the path of that version.
+func NegotiateAPIVersion(fallback int, supported []int, deprecation Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Vary", "Accept")
+		version := requestedVersion(c)
+		switch {
+		case version == 0:
+			version = fallback
+			c.Header("Deprecation", fmt.Sprintf("@%d", deprecation.Since.Unix()))
+			c.Header("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+			c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, deprecation.Successor(c.Request.URL.Path)))
+		case !containsVersion(supported, version):
+			c.JSON(http.StatusNotAcceptable, gin.H{
+				"error": fmt.Sprintf("API version %d is not supported", version),
+			})
+			c.Abort()
+			return
+		}
+		c.Set(apiVersionKey, version)
+		c.Header(VersionHeader, strconv.Itoa(version))
+		c.Next()
+	}
+}
+
+func containsVersion(versions []int, version int) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
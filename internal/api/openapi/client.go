@@ -0,0 +1,404 @@
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// GeneratedHeader starts every file GenerateClient writes
+const GeneratedHeader = "// Code generated by cmd/openapi; DO NOT EDIT."
+
+// clientRuntime declares Client, New, Error and how requests are sent. It
+// starts every generated client.
+//
+//go:embed client_runtime.go.tmpl
+var clientRuntime string
+
+// runtimeImports are the packages clientRuntime uses
+var runtimeImports = []string{"bytes", "context", "encoding/json", "fmt", "io", "mime/multipart", "net/http", "net/url", "strings", "time"}
+
+// GenerateClient writes the Go source of a typed client for a document:
+// the Client type, a type for every component schema and a method on
+// Client for every operation outside the Web tag. The package it goes in
+// needs nothing else.
+func GenerateClient(doc *Document, pkg string) ([]byte, error) {
+	g := &generator{doc: doc, skip: make(map[string]bool), imports: make(map[string]bool)}
+	for _, imp := range runtimeImports {
+		g.use(imp)
+	}
+	g.buf.WriteString("\n" + clientRuntime)
+
+	// Error responses are returned as the package's Error
+	for _, item := range doc.Paths {
+		for _, op := range item {
+			if failure, ok := op.Responses["default"]; ok {
+				if schema := failure.Content["application/json"].Schema; schema != nil && schema.Ref != "" {
+					g.skip[refName(schema.Ref)] = true
+				}
+			}
+		}
+	}
+
+	var names []string
+	for name := range doc.Components.Schemas {
+		if !g.skip[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g.component(name, doc.Components.Schemas[name])
+	}
+
+	type entry struct {
+		path, method string
+		op           *Operation
+	}
+	var entries []entry
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			if !contains(op.Tags, webTag) {
+				entries = append(entries, entry{path, method, op})
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].path != entries[j].path {
+			return entries[i].path < entries[j].path
+		}
+		return entries[i].method < entries[j].method
+	})
+	for _, e := range entries {
+		g.method(strings.ToUpper(e.method), e.path, e.op)
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%s\n\npackage %s\n\nimport (\n", GeneratedHeader, pkg)
+	var imports []string
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	for _, imp := range imports {
+		fmt.Fprintf(&out, "\t%q\n", imp)
+	}
+	out.WriteString(")\n")
+	out.Write(g.buf.Bytes())
+
+	source, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated client does not parse: %v", err)
+	}
+	return source, nil
+}
+
+// generator accumulates the declarations of a client
+type generator struct {
+	doc     *Document
+	skip    map[string]bool
+	imports map[string]bool
+	buf     bytes.Buffer
+	err     error
+}
+
+// use records that the generated code refers to a package
+func (g *generator) use(imp string) {
+	g.imports[imp] = true
+}
+
+func (g *generator) fail(format string, args ...interface{}) string {
+	if g.err == nil {
+		g.err = fmt.Errorf(format, args...)
+	}
+	return "interface{}"
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// component declares the type of a component schema
+func (g *generator) component(name string, schema *Schema) {
+	if len(schema.Enum) > 0 {
+		g.printf("\n// %s is one of the %s constants\ntype %s string\n\nconst (\n", name, name, name)
+		for _, value := range schema.Enum {
+			g.printf("\t%s %s = %q\n", name+goName(value), name, value)
+		}
+		g.printf(")\n")
+		return
+	}
+	if schema.Type != "object" {
+		g.fail("component %s is not an object", name)
+		return
+	}
+	g.printf("\n// %s is the %s schema\ntype %s %s\n", name, name, name, g.structType(schema))
+}
+
+// structType returns a struct type literal for an object schema
+func (g *generator) structType(schema *Schema) string {
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	seen := make(map[string]bool)
+	for _, p := range schema.Properties {
+		name := goName(p.Name)
+		if seen[name] {
+			return g.fail("properties of %v both become the field %s", schema.Properties, name)
+		}
+		seen[name] = true
+
+		required := contains(schema.Required, p.Name)
+		tag := p.Name
+		if !required {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&b, "\t%s %s `json:%q`\n", name, g.fieldType(p.Schema, required), tag)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// fieldType returns the Go type of a property. Null and, for structs and
+// times, a missing value become a nil pointer.
+func (g *generator) fieldType(schema *Schema, required bool) string {
+	typ, kind := g.goType(schema)
+	nullable := schema.Nullable
+	switch {
+	case kind == kindReference || kind == kindAny:
+		return typ
+	case nullable, !required && kind == kindStruct:
+		return "*" + typ
+	}
+	return typ
+}
+
+// Kinds of Go types, which decide when a field is a pointer
+const (
+	kindScalar    = iota // a string, number, boolean or enum
+	kindStruct           // a struct, or a time
+	kindReference        // a slice or a map, which can already be nil
+	kindAny
+)
+
+// goType returns the Go type a schema decodes into
+func (g *generator) goType(schema *Schema) (string, int) {
+	switch {
+	case schema.Ref != "":
+		name := refName(schema.Ref)
+		if len(g.doc.Resolve(schema).Enum) > 0 {
+			return name, kindScalar
+		}
+		return name, kindStruct
+	case len(schema.AllOf) == 1:
+		return g.goType(schema.AllOf[0])
+	}
+
+	switch schema.Type {
+	case "string":
+		if schema.Format == "date-time" {
+			g.use("time")
+			return "time.Time", kindStruct
+		}
+		return "string", kindScalar
+	case "integer":
+		return "int", kindScalar
+	case "number":
+		return "float64", kindScalar
+	case "boolean":
+		return "bool", kindScalar
+	case "array":
+		return "[]" + g.fieldType(schema.Items, true), kindReference
+	case "object":
+		if schema.AdditionalProperties != nil {
+			return "map[string]" + g.fieldType(schema.AdditionalProperties, true), kindReference
+		}
+		if len(schema.Properties) > 0 {
+			return g.structType(schema), kindStruct
+		}
+		return "map[string]interface{}", kindReference
+	case "":
+		return "interface{}", kindAny
+	}
+	return g.fail("cannot generate a type for %s", schema.Type), kindAny
+}
+
+// method declares the client method of an operation
+func (g *generator) method(method, path string, op *Operation) {
+	name := goName(op.OperationID)
+
+	var args []string
+	var query, header bool
+	pathExpr := fmt.Sprintf("%q", path)
+	for _, p := range op.Parameters {
+		if p.In != "path" {
+			query = query || p.In == "query"
+			header = header || p.In == "header"
+			continue
+		}
+		if !token.IsIdentifier(p.Name) || token.IsKeyword(p.Name) {
+			g.fail("path parameter %s of %s cannot be an argument", p.Name, op.OperationID)
+			return
+		}
+		typ, _ := g.goType(p.Schema)
+		value := "url.PathEscape(" + p.Name + ")"
+		if typ == "int" {
+			value = "strconv.Itoa(" + p.Name + ")"
+			g.use("strconv")
+		} else {
+			g.use("net/url")
+		}
+		args = append(args, p.Name+" "+typ)
+		pathExpr = strings.Replace(pathExpr, "{"+p.Name+"}", `" + `+value+` + "`, 1)
+	}
+	pathExpr = strings.TrimSuffix(pathExpr, ` + ""`)
+
+	var body string
+	if op.RequestBody != nil {
+		if media, ok := op.RequestBody.Content["application/json"]; ok {
+			typ, _ := g.goType(media.Schema)
+			args = append(args, "body "+typ)
+			body = "body: body,"
+		} else if media, ok := op.RequestBody.Content["multipart/form-data"]; ok {
+			var files []string
+			g.use("io")
+			for _, p := range media.Schema.Properties {
+				args = append(args, p.Name+" io.Reader")
+				files = append(files, fmt.Sprintf("%q: %s", p.Name, p.Name))
+			}
+			body = "files: map[string]io.Reader{" + strings.Join(files, ", ") + "},"
+		} else {
+			g.fail("cannot send the request body of %s", op.OperationID)
+			return
+		}
+	}
+	if query {
+		g.use("net/url")
+		body += " query: url.Values{},"
+	}
+	if header {
+		g.use("net/http")
+		body += " header: http.Header{},"
+	}
+	if query || header {
+		g.params(name+"Params", op)
+		args = append(args, "params *"+name+"Params")
+	}
+
+	var result, returnType string
+	for _, status := range []string{"200", "201", "202"} {
+		response, ok := op.Responses[status]
+		if !ok {
+			continue
+		}
+		if media, ok := response.Content["application/json"]; ok {
+			result, _ = g.goType(media.Schema)
+			returnType = "*" + result
+		} else {
+			returnType = "io.ReadCloser"
+			g.use("io")
+		}
+		break
+	}
+	if returnType == "" {
+		g.fail("%s has no successful response", op.OperationID)
+		return
+	}
+
+	summary := strings.TrimSuffix(op.Summary, ".") + "."
+	g.printf("\n// %s sends %s %s.\n//\n// %s\n", name, method, path, summary)
+	if len(op.Security) > 0 {
+		for scheme := range op.Security[0] {
+			description := g.doc.Components.SecuritySchemes[scheme].Description
+			g.printf("// Token must be %s.\n", strings.ToLower(description[:1])+description[1:])
+		}
+	}
+	g.printf("func (c *Client) %s(%s) (%s, error) {\n", name, strings.Join(append([]string{"ctx context.Context"}, args...), ", "), returnType)
+	g.printf("r := request{method: %q, path: %s, %s}\n", method, pathExpr, body)
+	if query || header {
+		g.printf("if params != nil {\n")
+		for _, p := range op.Parameters {
+			if p.In != "path" {
+				g.setParam(p)
+			}
+		}
+		g.printf("}\n")
+	}
+	if result == "" {
+		g.printf("return c.download(ctx, r)\n}\n")
+		return
+	}
+	g.printf("var out %s\nif err := c.do(ctx, r, &out); err != nil {\nreturn nil, err\n}\nreturn &out, nil\n}\n", result)
+}
+
+// params declares the struct of an operation's query and header parameters
+func (g *generator) params(name string, op *Operation) {
+	g.printf("\n// %s are the optional parameters of %s. Zero values are left out.\ntype %s struct {\n", name, goName(op.OperationID), name)
+	for _, p := range op.Parameters {
+		if p.In == "path" {
+			continue
+		}
+		typ, _ := g.goType(p.Schema)
+		description := strings.TrimSuffix(p.Description, ".")
+		if p.Required {
+			description += ", required"
+		}
+		g.printf("// %s\n%s %s\n", description, goName(p.Name), typ)
+	}
+	g.printf("}\n")
+}
+
+// setParam adds a query or header parameter to the request when it is set
+func (g *generator) setParam(p Parameter) {
+	field := "params." + goName(p.Name)
+	typ, kind := g.goType(p.Schema)
+
+	condition, value := field+` != ""`, field
+	switch {
+	case typ == "int":
+		g.use("strconv")
+		condition, value = field+" != 0", "strconv.Itoa("+field+")"
+	case typ == "bool":
+		g.use("strconv")
+		condition, value = field, "strconv.FormatBool("+field+")"
+	case kind == kindScalar && typ != "string":
+		value = "string(" + field + ")"
+	case typ != "string":
+		g.fail("cannot send parameter %s of type %s", p.Name, typ)
+		return
+	}
+
+	switch p.In {
+	case "query":
+		g.printf("if %s {\nr.query.Set(%q, %s)\n}\n", condition, p.Name, value)
+	case "header":
+		g.printf("if %s {\nr.header.Set(%q, %s)\n}\n", condition, p.Name, value)
+	default:
+		g.fail("cannot send a parameter in %s", p.In)
+	}
+}
+
+// initialisms are written in upper case in Go names
+var initialisms = map[string]bool{"api": true, "csv": true, "http": true, "id": true, "ip": true, "json": true, "url": true}
+
+// goName turns a snake_case, kebab-case or camelCase name into an exported
+// Go name
+func goName(name string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '-' || r == ' ' || r == '.'
+	}) {
+		if initialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,168 @@
+// Package openapi describes the HTTP API as an OpenAPI 3 document. The
+// document is built from the route table in routes.go, with schemas
+// reflected from the request and response types in internal/models, and
+// GenerateClient turns it into a typed Go client.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Version is the OpenAPI version the document follows
+const Version = "3.0.3"
+
+// Document is an OpenAPI document. Only the parts this API uses are modelled.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Tag groups operations
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations on one path, keyed by lower case method
+type PathItem map[string]*Operation
+
+// Operation is one method on one path
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a path, query or header parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the body an operation accepts
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is one documented response of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a body in one content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the named schemas and security schemes
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how a request is authenticated
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Schema is a JSON schema, in the OpenAPI 3.0 dialect
+type Schema struct {
+	Ref                  string     `json:"$ref,omitempty"`
+	AllOf                []*Schema  `json:"allOf,omitempty"`
+	Type                 string     `json:"type,omitempty"`
+	Format               string     `json:"format,omitempty"`
+	Description          string     `json:"description,omitempty"`
+	Nullable             bool       `json:"nullable,omitempty"`
+	Enum                 []string   `json:"enum,omitempty"`
+	Items                *Schema    `json:"items,omitempty"`
+	Properties           Properties `json:"properties,omitempty"`
+	Required             []string   `json:"required,omitempty"`
+	AdditionalProperties *Schema    `json:"additionalProperties,omitempty"`
+	MinLength            *int       `json:"minLength,omitempty"`
+	MaxLength            *int       `json:"maxLength,omitempty"`
+	Minimum              *int       `json:"minimum,omitempty"`
+	Maximum              *int       `json:"maximum,omitempty"`
+}
+
+// Property is a named property of an object schema
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+// Properties are the properties of an object schema, in the order the
+// fields are declared
+type Properties []Property
+
+// MarshalJSON writes the properties as a JSON object, keeping their order
+func (p Properties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, property := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(property.Name)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := json.Marshal(property.Schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(schema)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Get returns the schema of the named property, or nil
+func (p Properties) Get(name string) *Schema {
+	for _, property := range p {
+		if property.Name == name {
+			return property.Schema
+		}
+	}
+	return nil
+}
+
+// Resolve follows a reference to a component schema. Other schemas are
+// returned as they are.
+func (d *Document) Resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = d.Components.Schemas[refName(schema.Ref)]
+	}
+	return schema
+}
+
+// refPrefix starts a reference to a component schema
+const refPrefix = "#/components/schemas/"
+
+func refName(ref string) string {
+	return ref[len(refPrefix):]
+}
@@ -0,0 +1,127 @@
+package openapi
+
+import (
+	"strings"
+	"testing"
+
+	"rockpaperscissors/internal/models"
+)
+
+func TestSpec(t *testing.T) {
+	doc := Spec(1)
+
+	t.Run("Success - Paths and parameters", func(t *testing.T) {
+		op := doc.Operation("GET", "/api/v1/tournaments/:id/bracket")
+		if op == nil {
+			t.Fatalf("Expected GET /api/v1/tournaments/:id/bracket to be documented")
+		}
+		if len(op.Parameters) != 1 || op.Parameters[0].Name != "id" || op.Parameters[0].Schema.Type != "integer" {
+			t.Errorf("Expected an integer id path parameter, got %+v", op.Parameters)
+		}
+		if _, ok := doc.Paths["/api/v1/tournaments/{id}/bracket"]; !ok {
+			t.Errorf("Expected the path in OpenAPI form")
+		}
+	})
+
+	t.Run("Success - Request fields follow the binding tags", func(t *testing.T) {
+		schema := doc.Components.Schemas["CreateUserRequest"]
+		if schema == nil || len(schema.Required) != 1 || schema.Required[0] != "username" {
+			t.Fatalf("Expected username to be required, got %+v", schema)
+		}
+		username := schema.Properties.Get("username")
+		if *username.MinLength != 3 || *username.MaxLength != 20 {
+			t.Errorf("Expected a length of 3 to 20, got %d to %d", *username.MinLength, *username.MaxLength)
+		}
+	})
+
+	t.Run("Success - Enums list their values", func(t *testing.T) {
+		schema := doc.Components.Schemas["Choice"]
+		if schema == nil || strings.Join(schema.Enum, ",") != "rock,paper,scissors" {
+			t.Errorf("Expected the three choices, got %+v", schema)
+		}
+	})
+
+	t.Run("Success - Only the API needs a token", func(t *testing.T) {
+		if op := doc.Operation("POST", "/admin/users/:username/ban"); len(op.Security) == 0 {
+			t.Errorf("Expected admin routes to need the admin token")
+		}
+		if op := doc.Operation("GET", "/api/v1/users/:username"); len(op.Security) != 0 {
+			t.Errorf("Expected public routes to need no token")
+		}
+	})
+}
+
+func TestSpecVersions(t *testing.T) {
+	t.Run("Success - Version 2 has its own game results", func(t *testing.T) {
+		v1, v2 := Spec(1), Spec(2)
+		if v2.Info.Version != "2.0.0" || v2.Operation("POST", "/api/v2/play") == nil {
+			t.Fatalf("Expected POST /api/v2/play in version 2.0.0, got %s", v2.Info.Version)
+		}
+		if v1.Components.Schemas["PlayGameResponse"].Properties.Get("game") != nil {
+			t.Errorf("Expected version 1 game results to stay flat")
+		}
+		if v2.Components.Schemas["PlayGameResponse"].Properties.Get("game") == nil {
+			t.Errorf("Expected version 2 game results to nest the game")
+		}
+	})
+
+	t.Run("Error - Unknown version", func(t *testing.T) {
+		if Spec(3) != nil {
+			t.Errorf("Expected no document for version 3")
+		}
+	})
+}
+
+func TestBuildErrors(t *testing.T) {
+	post := func(path, id string, body interface{}, replies ...reply) operation {
+		return operation{method: "POST", path: path, id: id, tag: "Users", body: body, replies: replies}
+	}
+
+	tests := []struct {
+		name string
+		ops  []operation
+		want string
+	}{
+		{
+			name: "Duplicate operation ID",
+			ops:  []operation{post("/a", "same", nil, ok(message)), post("/b", "same", nil, ok(message))},
+			want: "used twice",
+		},
+		{
+			name: "Duplicate route",
+			ops:  []operation{post("/a", "one", nil, ok(message)), post("/a", "two", nil, ok(message))},
+			want: "documented twice",
+		},
+		{
+			name: "Unknown path parameter",
+			ops:  []operation{{method: "GET", path: "/a", id: "a", params: []param{pathParam("id", 0, "")}}},
+			want: "no path parameter",
+		},
+		{
+			name: "Two types with one name",
+			ops:  []operation{post("/a", "a", nil, ok(newObject("User")), ok(models.User{}))},
+			want: "two types are named User",
+		},
+		{
+			name: "A type used both ways",
+			ops:  []operation{post("/a", "a", models.FriendRequest{}, ok(models.FriendRequest{}))},
+			want: "both as a request and as a response",
+		},
+		{
+			name: "Enum without values",
+			ops:  []operation{post("/a", "a", nil, ok(struct{ Kind unlistedKind }{}))},
+			want: "no enum values",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("Error - "+tt.name, func(t *testing.T) {
+			_, err := build(tt.ops, 1)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+type unlistedKind string
@@ -0,0 +1,800 @@
+package openapi
+
+import (
+	"time"
+
+	"rockpaperscissors/internal/models"
+	v2 "rockpaperscissors/internal/models/v2"
+)
+
+// tags are the groups operations are listed under, in order
+var tags = []Tag{
+	{Name: "Users", Description: "Accounts, profiles and statistics"},
+	{Name: "Games", Description: "Playing against the computer, game history and exports"},
+	{Name: "Leaderboards"},
+	{Name: "Shop", Description: "Coins, cosmetic items and achievements"},
+	{Name: "Daily", Description: "The daily login reward and daily challenges"},
+	{Name: "Seasons"},
+	{Name: "Friends"},
+	{Name: "Challenges", Description: "Best-of-N matches between friends"},
+	{Name: "Tournaments"},
+	{Name: "Clans", Description: "Clans and clan wars"},
+	{Name: "Account", Description: "Data export and account deletion, with the account token"},
+	{Name: "Admin", Description: "Moderation and organization, with the admin token"},
+	{Name: webTag, Description: "Pages and files for browsers"},
+}
+
+// Objects handlers build with gin.H
+var (
+	message = newObject("Message",
+		field{"message", ""},
+	)
+	leaderboardPage = newObject("Leaderboard",
+		field{"leaderboard", []models.LeaderboardEntry{}},
+		field{"total_users", 0},
+	)
+	leaderboardPageV2 = newObject("Leaderboard",
+		field{"leaderboard", []v2.LeaderboardEntry{}},
+		field{"total_users", 0},
+	)
+	friendshipReply = newObject("Friendship",
+		field{"username", ""},
+		field{"friend", ""},
+		field{"status", models.FriendshipStatus("")},
+	)
+)
+
+// Query parameters shared by several operations
+var (
+	limitParam  = query("limit", 0, "Page size, at most 100")
+	offsetParam = query("offset", 0, "Items to skip")
+	formatParam = query("format", models.ExportFormat(""), "File format; csv if left out")
+	gzipParam   = query("gzip", false, "Compress the file with gzip")
+
+	gameHistoryParams = []param{
+		query("result", models.GameResult(""), "Only games with this result"),
+		query("choice", models.Choice(""), "Only games where the player threw this"),
+		query("opponent", models.OpponentType(""), "Only games against this kind of opponent"),
+		query("from", "", "Only games played at or after this date (YYYY-MM-DD) or RFC 3339 time"),
+		query("to", "", "Only games played before this RFC 3339 time, or up to the end of this date (YYYY-MM-DD)"),
+		query("order", models.SortOrder(""), "Newest first (desc) if left out"),
+		query("limit", 0, "Page size, 20 if left out and at most 100"),
+		query("cursor", "", "The next_cursor of the previous page"),
+	}
+
+	exportContentTypes = []string{"text/csv", "application/x-ndjson", "application/vnd.apache.parquet", "application/gzip"}
+)
+
+var (
+	challengeID  = pathParam("id", 0, "Challenge ID")
+	tournamentID = pathParam("id", 0, "Tournament ID")
+	clanWarID    = pathParam("id", 0, "Clan war ID")
+)
+
+// operations documents every route routes.SetupRoutes registers, in the
+// same order
+var operations = []operation{
+	{
+		method: "GET", path: "/health", id: "getHealth", tag: "Users",
+		summary: "Check that the server is up",
+		replies: []reply{ok(newObject("Health",
+			field{"status", ""},
+			field{"message", ""},
+		))},
+	},
+
+	// The document of this version
+	{
+		method: "GET", path: "/api/openapi.json", id: "getAPIDocument", tag: webTag,
+		summary: "Get the document of this version",
+		replies: []reply{ok(&Schema{Type: "object"})},
+	},
+
+	// User management
+	{
+		method: "POST", path: "/api/users", id: "createUser", tag: "Users",
+		summary: "Create a user. The account token in the response cannot be shown again.",
+		body:    models.CreateUserRequest{},
+		replies: []reply{created(models.CreateUserResponse{})},
+	},
+	{
+		method: "GET", path: "/api/users/:username", id: "getUser", tag: "Users",
+		summary: "Get a user's public profile",
+		replies: []reply{ok(models.UserResponse{})},
+	},
+	{
+		method: "PATCH", path: "/api/users/:username", id: "updateProfile", tag: "Users",
+		summary: "Change the profile fields present in the body; an empty string clears one",
+		auth:    accountAuth,
+		body:    models.UpdateProfileRequest{},
+		replies: []reply{ok(models.UserResponse{})},
+	},
+	{
+		method: "POST", path: "/api/users/:username/avatar", id: "uploadAvatar", tag: "Users",
+		summary: "Upload a PNG, JPEG or GIF avatar of at most 1 MB and 1024×1024 pixels",
+		auth:    accountAuth,
+		body:    form{files: []string{"avatar"}},
+		replies: []reply{ok(models.UserResponse{})},
+	},
+	{
+		method: "GET", path: "/api/stats/:username", id: "getUserStats", tag: "Users",
+		summary: "Get a user's statistics and leaderboard rank",
+		replies: []reply{ok(models.UserStats{})},
+	},
+	{
+		method: "GET", path: "/api/users/:username/analytics", id: "getAnalytics", tag: "Users",
+		summary: "Get how a user plays, computed from their games",
+		params:  []param{query("opponent", models.OpponentType(""), "Which games to analyse; computer if left out")},
+		replies: []reply{ok(models.PlayerAnalytics{})},
+	},
+
+	// Game endpoints
+	{
+		method: "POST", path: "/api/play", id: "playGame", tag: "Games",
+		summary: "Play a game against the computer",
+		body:    models.PlayGameRequest{},
+		replies: []reply{ok(models.PlayGameResponse{})},
+		v2:      []reply{ok(v2.PlayGameResponse{})},
+	},
+	{
+		method: "GET", path: "/api/leaderboard", id: "getLeaderboard", tag: "Leaderboards",
+		summary: "Get the players with the most coins",
+		params:  []param{query("country", "", "Only players from this ISO 3166-1 alpha-2 country")},
+		replies: []reply{ok(leaderboardPage)},
+		v2:      []reply{ok(leaderboardPageV2)},
+	},
+	{
+		method: "GET", path: "/api/leaderboard/streaks", id: "getStreakLeaderboard", tag: "Leaderboards",
+		summary: "Get the players with the best win streaks ever",
+		replies: []reply{ok(newObject("StreakLeaderboard",
+			field{"leaderboard", []models.StreakLeaderboardEntry{}},
+			field{"total_users", 0},
+		))},
+	},
+
+	// Game history
+	{
+		method: "GET", path: "/api/users/:username/games", id: "getUserGames", tag: "Games",
+		summary: "Get a page of a user's games",
+		params:  gameHistoryParams,
+		replies: []reply{ok(newObject("GameHistory",
+			field{"username", ""},
+			field{"games", []models.Game{}},
+			field{"total_games", 0},
+			field{"next_cursor", ""},
+		))},
+	},
+	{
+		method: "GET", path: "/api/users/:username/games/export", id: "exportUserGames", tag: "Games",
+		summary: "Download a user's whole game history",
+		params:  []param{formatParam, gzipParam},
+		replies: []reply{download(exportContentTypes...)},
+	},
+	{
+		method: "GET", path: "/api/users/:username/streaks", id: "getUserStreaks", tag: "Games",
+		summary: "List a user's win streaks, newest first",
+		params:  []param{limitParam, offsetParam},
+		replies: []reply{ok(newObject("StreakHistory",
+			field{"username", ""},
+			field{"current_streak", 0},
+			field{"best_streak", 0},
+			field{"streaks", []models.Streak{}},
+			field{"total", 0},
+			field{"limit", 0},
+			field{"offset", 0},
+		))},
+	},
+
+	// Coin ledger
+	{
+		method: "GET", path: "/api/users/:username/transactions", id: "getUserTransactions", tag: "Shop",
+		summary: "List a user's coin transactions, newest first",
+		params:  []param{limitParam, offsetParam},
+		replies: []reply{ok(newObject("TransactionHistory",
+			field{"username", ""},
+			field{"balance", 0},
+			field{"transactions", []models.CoinTransaction{}},
+			field{"total", 0},
+			field{"limit", 0},
+			field{"offset", 0},
+		))},
+	},
+
+	// Cosmetic shop
+	{
+		method: "GET", path: "/api/shop/items", id: "getShopItems", tag: "Shop",
+		summary: "List the items for sale",
+		replies: []reply{ok(newObject("ShopCatalog",
+			field{"items", []models.ShopItem{}},
+			field{"total_items", 0},
+		))},
+	},
+	{
+		method: "POST", path: "/api/shop/purchase", id: "purchaseItem", tag: "Shop",
+		summary: "Buy an item",
+		auth:    accountAuth,
+		body:    models.PurchaseRequest{},
+		replies: []reply{created(newObject("Purchase",
+			field{"item", models.InventoryItem{}},
+			field{"total_coins", 0},
+		))},
+	},
+	{
+		method: "POST", path: "/api/shop/equip", id: "equipItem", tag: "Shop",
+		summary: "Equip an owned item in its slot",
+		auth:    accountAuth,
+		body:    models.EquipRequest{},
+		replies: []reply{ok(newObject("Equipped",
+			field{"message", ""},
+			field{"item_id", ""},
+		))},
+	},
+	{
+		method: "POST", path: "/api/shop/unequip", id: "unequipSlot", tag: "Shop",
+		summary: "Clear a cosmetic slot",
+		auth:    accountAuth,
+		body:    models.UnequipRequest{},
+		replies: []reply{ok(newObject("Unequipped",
+			field{"message", ""},
+			field{"slot", models.CosmeticSlot("")},
+		))},
+	},
+	{
+		method: "GET", path: "/api/users/:username/inventory", id: "getInventory", tag: "Shop",
+		summary: "List the items a user owns",
+		replies: []reply{ok(newObject("Inventory",
+			field{"username", ""},
+			field{"inventory", []models.InventoryItem{}},
+			field{"total_items", 0},
+		))},
+	},
+
+	// Achievements
+	{
+		method: "GET", path: "/api/users/:username/achievements", id: "getUserAchievements", tag: "Shop",
+		summary: "List every achievement and whether the user has unlocked it",
+		replies: []reply{ok(newObject("Achievements",
+			field{"username", ""},
+			field{"achievements", []models.UserAchievement{}},
+			field{"total_unlocked", 0},
+		))},
+	},
+
+	// Daily login reward and daily challenges
+	{
+		method: "PUT", path: "/api/users/:username/timezone", id: "setTimezone", tag: "Daily",
+		summary: "Set the IANA timezone a user's days start in, at most once every 30 days",
+		auth:    accountAuth,
+		body:    models.SetTimezoneRequest{},
+		replies: []reply{ok(newObject("Timezone",
+			field{"username", ""},
+			field{"timezone", ""},
+		))},
+	},
+	{
+		method: "GET", path: "/api/users/:username/daily-reward", id: "getDailyReward", tag: "Daily",
+		summary: "Get the state of today's login reward",
+		replies: []reply{ok(models.DailyRewardStatus{})},
+	},
+	{
+		method: "POST", path: "/api/users/:username/daily-reward", id: "claimDailyReward", tag: "Daily",
+		summary: "Claim today's login reward",
+		auth:    accountAuth,
+		replies: []reply{ok(models.DailyRewardClaim{})},
+	},
+	{
+		method: "GET", path: "/api/users/:username/daily-challenges", id: "getDailyChallenges", tag: "Daily",
+		summary: "Get today's challenges and the user's progress",
+		replies: []reply{ok(newObject("DailyChallenges",
+			field{"username", ""},
+			field{"challenges", []models.DailyChallenge{}},
+		))},
+	},
+	{
+		method: "POST", path: "/api/users/:username/daily-challenges/:id/claim", id: "claimDailyChallenge", tag: "Daily",
+		summary: "Claim the reward of a completed challenge",
+		auth:    accountAuth,
+		params:  []param{pathParam("id", "", "Challenge ID")},
+		replies: []reply{ok(newObject("DailyChallengeClaim",
+			field{"challenge", models.DailyChallenge{}},
+			field{"total_coins", 0},
+		))},
+	},
+
+	// Seasons
+	{
+		method: "GET", path: "/api/seasons", id: "listSeasons", tag: "Seasons",
+		summary: "List every season that has started, newest first",
+		replies: []reply{ok(newObject("SeasonList",
+			field{"seasons", []models.Season{}},
+			field{"total_seasons", 0},
+		))},
+	},
+	{
+		method: "GET", path: "/api/seasons/:id/leaderboard", id: "getSeasonLeaderboard", tag: "Seasons",
+		summary: "Get the standings of a season",
+		params:  []param{pathParam("id", "", "Season ID, or current")},
+		replies: []reply{ok(newObject("SeasonLeaderboard",
+			field{"season", models.Season{}},
+			field{"leaderboard", []models.SeasonStanding{}},
+			field{"total_users", 0},
+		))},
+	},
+	{
+		method: "GET", path: "/api/users/:username/seasons", id: "getUserSeasons", tag: "Seasons",
+		summary: "List a user's results in finished seasons",
+		replies: []reply{ok(newObject("UserSeasons",
+			field{"username", ""},
+			field{"seasons", []models.SeasonResult{}},
+		))},
+	},
+
+	// Friends
+	{
+		method: "POST", path: "/api/friends/requests", id: "sendFriendRequest", tag: "Friends",
+		summary: "Send a friend request, or accept the other player's",
+		auth:    accountAuth,
+		body:    models.FriendRequest{},
+		replies: []reply{created(friendshipReply)},
+	},
+	{
+		method: "POST", path: "/api/friends/requests/accept", id: "acceptFriendRequest", tag: "Friends",
+		summary: "Accept a friend request",
+		auth:    accountAuth,
+		body:    models.FriendRequest{},
+		replies: []reply{ok(friendshipReply)},
+	},
+	{
+		method: "POST", path: "/api/friends/requests/decline", id: "declineFriendRequest", tag: "Friends",
+		summary: "Decline a friend request",
+		auth:    accountAuth,
+		body:    models.FriendRequest{},
+		replies: []reply{ok(message)},
+	},
+	{
+		method: "GET", path: "/api/users/:username/friends", id: "getFriends", tag: "Friends",
+		summary: "List a user's friends and pending requests",
+		replies: []reply{ok(models.FriendList{})},
+	},
+	{
+		method: "DELETE", path: "/api/users/:username/friends/:friend", id: "removeFriend", tag: "Friends",
+		summary: "Remove a friend",
+		auth:    accountAuth,
+		replies: []reply{ok(message)},
+	},
+	{
+		method: "GET", path: "/api/users/:username/friends/leaderboard", id: "getFriendsLeaderboard", tag: "Leaderboards",
+		summary: "Rank a user and their friends by coins",
+		replies: []reply{ok(leaderboardPage)},
+		v2:      []reply{ok(leaderboardPageV2)},
+	},
+
+	// Direct challenges
+	{
+		method: "POST", path: "/api/challenges", id: "createChallenge", tag: "Challenges",
+		summary: "Challenge a friend; the stake is held until the match is settled",
+		auth:    accountAuth,
+		body:    models.CreateChallengeRequest{},
+		replies: []reply{created(models.Challenge{})},
+	},
+	{
+		method: "GET", path: "/api/challenges/:id", id: "getChallenge", tag: "Challenges",
+		summary: "Get a challenge",
+		params:  []param{challengeID},
+		replies: []reply{ok(models.Challenge{})},
+	},
+	{
+		method: "POST", path: "/api/challenges/:id/accept", id: "acceptChallenge", tag: "Challenges",
+		summary: "Accept a challenge, staking the same amount",
+		auth:    accountAuth,
+		params:  []param{challengeID},
+		body:    models.ChallengeActionRequest{},
+		replies: []reply{ok(models.Challenge{})},
+	},
+	{
+		method: "POST", path: "/api/challenges/:id/decline", id: "declineChallenge", tag: "Challenges",
+		summary: "Decline a challenge",
+		auth:    accountAuth,
+		params:  []param{challengeID},
+		body:    models.ChallengeActionRequest{},
+		replies: []reply{ok(models.Challenge{})},
+	},
+	{
+		method: "POST", path: "/api/challenges/:id/cancel", id: "cancelChallenge", tag: "Challenges",
+		summary: "Call off a challenge that has not been answered",
+		auth:    accountAuth,
+		params:  []param{challengeID},
+		body:    models.ChallengeActionRequest{},
+		replies: []reply{ok(models.Challenge{})},
+	},
+	{
+		method: "POST", path: "/api/challenges/:id/moves", id: "submitChallengeMove", tag: "Challenges",
+		summary: "Throw in the current round",
+		auth:    accountAuth,
+		params:  []param{challengeID},
+		body:    models.ChallengeMoveRequest{},
+		replies: []reply{ok(models.Challenge{})},
+	},
+	{
+		method: "GET", path: "/api/users/:username/challenges", id: "getUserChallenges", tag: "Challenges",
+		summary: "List a user's challenges",
+		params:  []param{query("status", models.ChallengeStatus(""), "Only challenges in this state")},
+		replies: []reply{ok(newObject("ChallengeList",
+			field{"username", ""},
+			field{"challenges", []models.Challenge{}},
+			field{"total_challenges", 0},
+		))},
+	},
+
+	// Head-to-head records
+	{
+		method: "GET", path: "/api/users/:username/vs/:opponent", id: "getHeadToHead", tag: "Challenges",
+		summary: "Get a user's record against another player",
+		replies: []reply{ok(models.HeadToHead{})},
+	},
+
+	// Tournaments
+	{
+		method: "GET", path: "/api/tournaments", id: "listTournaments", tag: "Tournaments",
+		summary: "List tournaments",
+		params:  []param{query("status", models.TournamentStatus(""), "Only tournaments in this state")},
+		replies: []reply{ok(newObject("TournamentList",
+			field{"tournaments", []models.Tournament{}},
+			field{"total_tournaments", 0},
+		))},
+	},
+	{
+		method: "GET", path: "/api/tournaments/:id", id: "getTournament", tag: "Tournaments",
+		summary: "Get a tournament",
+		params:  []param{tournamentID},
+		replies: []reply{ok(models.Tournament{})},
+	},
+	{
+		method: "GET", path: "/api/tournaments/:id/bracket", id: "getTournamentBracket", tag: "Tournaments",
+		summary: "Get every round of a tournament and its matches",
+		params:  []param{tournamentID},
+		replies: []reply{ok(newObject("Bracket",
+			field{"tournament_id", 0},
+			field{"rounds", []models.TournamentRound{}},
+		))},
+	},
+	{
+		method: "GET", path: "/api/tournaments/:id/standings", id: "getTournamentStandings", tag: "Tournaments",
+		summary: "Get how the players of a tournament are doing",
+		params:  []param{tournamentID},
+		replies: []reply{ok(newObject("TournamentStandings",
+			field{"tournament_id", 0},
+			field{"standings", []models.TournamentPlayer{}},
+		))},
+	},
+	{
+		method: "POST", path: "/api/tournaments/:id/register", id: "registerForTournament", tag: "Tournaments",
+		summary: "Register for a tournament, paying the entry fee",
+		auth:    accountAuth,
+		params:  []param{tournamentID},
+		body:    models.TournamentRegistrationRequest{},
+		replies: []reply{ok(models.Tournament{})},
+	},
+	{
+		method: "POST", path: "/api/tournaments/:id/withdraw", id: "withdrawFromTournament", tag: "Tournaments",
+		summary: "Withdraw from a tournament; the entry fee is refunded before it starts",
+		auth:    accountAuth,
+		params:  []param{tournamentID},
+		body:    models.TournamentRegistrationRequest{},
+		replies: []reply{ok(models.Tournament{})},
+	},
+	{
+		method: "POST", path: "/api/tournaments/:id/moves", id: "submitTournamentMove", tag: "Tournaments",
+		summary: "Throw in the player's current match",
+		auth:    accountAuth,
+		params:  []param{tournamentID},
+		body:    models.TournamentMoveRequest{},
+		replies: []reply{ok(models.TournamentMatch{})},
+	},
+
+	// Clans and clan wars
+	{
+		method: "POST", path: "/api/clans", id: "createClan", tag: "Clans",
+		summary: "Found a clan",
+		auth:    accountAuth,
+		body:    models.CreateClanRequest{},
+		replies: []reply{created(models.Clan{})},
+	},
+	{
+		method: "GET", path: "/api/clans/leaderboard", id: "getClanLeaderboard", tag: "Leaderboards",
+		summary: "Rank clans by the coins their members won in a season",
+		params:  []param{query("season", "", "Season ID, or current if left out")},
+		replies: []reply{ok(newObject("ClanLeaderboard",
+			field{"season", models.Season{}},
+			field{"leaderboard", []models.ClanStanding{}},
+			field{"total_clans", 0},
+		))},
+	},
+	{
+		method: "GET", path: "/api/clans/:tag", id: "getClan", tag: "Clans",
+		summary: "Get a clan and its members",
+		replies: []reply{ok(models.Clan{})},
+	},
+	{
+		method: "POST", path: "/api/clans/:tag/invites", id: "inviteToClan", tag: "Clans",
+		summary: "Invite a player to the clan",
+		auth:    accountAuth,
+		body:    models.ClanMemberRequest{},
+		replies: []reply{created(newObject("ClanInviteSent",
+			field{"message", ""},
+			field{"clan", ""},
+			field{"member", ""},
+		))},
+	},
+	{
+		method: "POST", path: "/api/clans/:tag/invites/decline", id: "declineClanInvite", tag: "Clans",
+		summary: "Decline an invite to a clan",
+		auth:    accountAuth,
+		body:    models.ClanActionRequest{},
+		replies: []reply{ok(message)},
+	},
+	{
+		method: "POST", path: "/api/clans/:tag/join", id: "joinClan", tag: "Clans",
+		summary: "Join an open clan, or one the player was invited to",
+		auth:    accountAuth,
+		body:    models.ClanActionRequest{},
+		replies: []reply{ok(models.Clan{})},
+	},
+	{
+		method: "POST", path: "/api/clans/:tag/leave", id: "leaveClan", tag: "Clans",
+		summary: "Leave a clan; the last member leaving disbands it",
+		auth:    accountAuth,
+		body:    models.ClanActionRequest{},
+		replies: []reply{ok(newObject("ClanLeft",
+			field{"message", ""},
+			field{"disbanded", false},
+		))},
+	},
+	{
+		method: "POST", path: "/api/clans/:tag/kick", id: "kickClanMember", tag: "Clans",
+		summary: "Remove a member from the clan",
+		auth:    accountAuth,
+		body:    models.ClanMemberRequest{},
+		replies: []reply{ok(message)},
+	},
+	{
+		method: "PUT", path: "/api/clans/:tag/members/:member/role", id: "setClanRole", tag: "Clans",
+		summary: "Change a member's role; making someone owner hands the clan over",
+		auth:    accountAuth,
+		body:    models.SetClanRoleRequest{},
+		replies: []reply{ok(models.Clan{})},
+	},
+	{
+		method: "GET", path: "/api/clans/:tag/wars", id: "listClanWars", tag: "Clans",
+		summary: "List a clan's wars",
+		replies: []reply{ok(newObject("ClanWars",
+			field{"clan", ""},
+			field{"wars", []models.ClanWar{}},
+		))},
+	},
+	{
+		method: "POST", path: "/api/clans/:tag/wars", id: "declareClanWar", tag: "Clans",
+		summary: "Challenge another clan to a war",
+		auth:    accountAuth,
+		body:    models.DeclareClanWarRequest{},
+		replies: []reply{created(models.ClanWar{})},
+	},
+	{
+		method: "GET", path: "/api/clan-wars/:id", id: "getClanWar", tag: "Clans",
+		summary: "Get a clan war",
+		params:  []param{clanWarID},
+		replies: []reply{ok(models.ClanWar{})},
+	},
+	{
+		method: "POST", path: "/api/clan-wars/:id/accept", id: "acceptClanWar", tag: "Clans",
+		summary: "Accept a war declared on the clan, which starts it",
+		auth:    accountAuth,
+		params:  []param{clanWarID},
+		body:    models.ClanActionRequest{},
+		replies: []reply{ok(models.ClanWar{})},
+	},
+	{
+		method: "POST", path: "/api/clan-wars/:id/decline", id: "declineClanWar", tag: "Clans",
+		summary: "Decline a war declared on the clan",
+		auth:    accountAuth,
+		params:  []param{clanWarID},
+		body:    models.ClanActionRequest{},
+		replies: []reply{ok(models.ClanWar{})},
+	},
+	{
+		method: "GET", path: "/api/users/:username/clan-invites", id: "getClanInvites", tag: "Clans",
+		summary: "List the clans a user is invited to",
+		replies: []reply{ok(newObject("ClanInvites",
+			field{"username", ""},
+			field{"invites", []models.ClanInvite{}},
+		))},
+	},
+
+	// Data export and account deletion
+	{
+		method: "GET", path: "/api/users/:username/data-export", id: "exportAccountData", tag: "Account",
+		summary: "Download everything kept about the user as a ZIP of JSON files",
+		auth:    accountAuth,
+		replies: []reply{download("application/zip")},
+	},
+	{
+		method: "POST", path: "/api/users/:username/deletion", id: "requestAccountDeletion", tag: "Account",
+		summary: "Schedule the account for deletion after the grace period",
+		auth:    accountAuth,
+		replies: []reply{accepted(newObject("DeletionScheduled",
+			field{"username", ""},
+			field{"deletion_scheduled_at", time.Time{}},
+		))},
+	},
+	{
+		method: "DELETE", path: "/api/users/:username/deletion", id: "cancelAccountDeletion", tag: "Account",
+		summary: "Keep an account still in its deletion grace period",
+		auth:    accountAuth,
+		replies: []reply{ok(message)},
+	},
+
+	// Admin
+	{
+		method: "GET", path: "/admin/games/export", id: "exportAllGames", tag: "Admin",
+		summary: "Download every game of every player",
+		auth:    adminAuth,
+		params:  []param{formatParam, gzipParam},
+		replies: []reply{download(exportContentTypes...)},
+	},
+	{
+		method: "GET", path: "/admin/users", id: "searchUsers", tag: "Admin",
+		summary: "Search users by name",
+		auth:    adminAuth,
+		params: []param{
+			query("q", "", "Part of the username"),
+			query("status", models.UserStatus(""), "Only users in this state"),
+			limitParam,
+			offsetParam,
+		},
+		replies: []reply{ok(newObject("UserSearch",
+			field{"users", []models.User{}},
+			field{"total", 0},
+			field{"limit", 0},
+			field{"offset", 0},
+		))},
+	},
+	{
+		method: "GET", path: "/admin/users/:username", id: "getUserRecord", tag: "Admin",
+		summary: "Get everything an admin sees about a user; the game parameters page through their games",
+		auth:    adminAuth,
+		params:  gameHistoryParams,
+		replies: []reply{ok(models.AdminUserRecord{})},
+	},
+	{
+		method: "POST", path: "/admin/users/:username/coins", id: "adjustCoins", tag: "Admin",
+		summary: "Credit or debit a user's coins",
+		auth:    adminAuth,
+		params:  []param{adminActor},
+		body:    models.AdjustCoinsRequest{},
+		replies: []reply{ok(models.CoinTransaction{})},
+	},
+	{
+		method: "POST", path: "/admin/users/:username/reset-streak", id: "resetStreak", tag: "Admin",
+		summary: "Reset a user's win streak",
+		auth:    adminAuth,
+		params:  []param{adminActor},
+		body:    models.ModerationRequest{},
+		replies: []reply{ok(models.User{})},
+	},
+	{
+		method: "POST", path: "/admin/users/:username/ban", id: "banUser", tag: "Admin",
+		summary: "Ban a user",
+		auth:    adminAuth,
+		params:  []param{adminActor},
+		body:    models.ModerationRequest{},
+		replies: []reply{ok(models.User{})},
+	},
+	{
+		method: "POST", path: "/admin/users/:username/suspend", id: "suspendUser", tag: "Admin",
+		summary: "Suspend a user until a given time",
+		auth:    adminAuth,
+		params:  []param{adminActor},
+		body:    models.SuspendUserRequest{},
+		replies: []reply{ok(models.User{})},
+	},
+	{
+		method: "POST", path: "/admin/users/:username/reinstate", id: "reinstateUser", tag: "Admin",
+		summary: "Lift a ban or suspension",
+		auth:    adminAuth,
+		params:  []param{adminActor},
+		body:    models.ModerationRequest{},
+		replies: []reply{ok(models.User{})},
+	},
+	{
+		method: "PUT", path: "/admin/users/:username/username", id: "renameUser", tag: "Admin",
+		summary: "Change a user's username",
+		auth:    adminAuth,
+		params:  []param{adminActor},
+		body:    models.RenameUserRequest{},
+		replies: []reply{ok(models.User{})},
+	},
+	{
+		method: "DELETE", path: "/admin/users/:username", id: "deleteUser", tag: "Admin",
+		summary: "Delete a user and everything that belongs to them",
+		auth:    adminAuth,
+		params: []param{
+			adminActor,
+			{name: "reason", in: "query", description: "Why, for the audit log", required: true, value: ""},
+		},
+		replies: []reply{ok(message)},
+	},
+	{
+		method: "POST", path: "/admin/users/:username/token", id: "issueAccountToken", tag: "Admin",
+		summary: "Issue a user a new account token, replacing the old one",
+		auth:    adminAuth,
+		params:  []param{adminActor},
+		body:    models.ModerationRequest{},
+		replies: []reply{ok(newObject("AccountToken",
+			field{"username", ""},
+			field{"account_token", ""},
+		))},
+	},
+	{
+		method: "GET", path: "/admin/actions", id: "getAdminActions", tag: "Admin",
+		summary: "List the audit log of admin changes, newest first",
+		auth:    adminAuth,
+		params:  []param{limitParam, offsetParam},
+		replies: []reply{ok(newObject("AdminActions",
+			field{"actions", []models.AdminAction{}},
+			field{"total", 0},
+			field{"limit", 0},
+			field{"offset", 0},
+		))},
+	},
+	{
+		method: "POST", path: "/admin/tournaments", id: "createTournament", tag: "Admin",
+		summary: "Set up a tournament",
+		auth:    adminAuth,
+		params:  []param{adminActor},
+		body:    models.CreateTournamentRequest{},
+		replies: []reply{created(models.Tournament{})},
+	},
+	{
+		method: "POST", path: "/admin/tournaments/:id/start", id: "startTournament", tag: "Admin",
+		summary: "Close registration and start a tournament early",
+		auth:    adminAuth,
+		params:  []param{tournamentID},
+		replies: []reply{ok(models.Tournament{})},
+	},
+	{
+		method: "POST", path: "/admin/tournaments/:id/cancel", id: "cancelTournament", tag: "Admin",
+		summary: "Cancel a tournament and refund its entry fees",
+		auth:    adminAuth,
+		params:  []param{tournamentID},
+		replies: []reply{ok(models.Tournament{})},
+	},
+
+	// Documentation
+	{
+		method: "GET", path: "/openapi.json", id: "getOpenAPIDocument", tag: webTag,
+		summary: "Get the document of version 1",
+		replies: []reply{ok(&Schema{Type: "object"})},
+	},
+	{
+		method: "GET", path: "/docs", id: "getDocs", tag: webTag,
+		summary: "Browse this document in Swagger UI",
+		replies: []reply{{status: 200, body: &Schema{Type: "string"}, contentTypes: []string{"text/html"}}},
+	},
+
+	// Web frontend
+	{
+		method: "GET", path: "/static/*filepath", id: "getStaticFile", tag: webTag,
+		summary: "Get a file of the web frontend",
+		replies: []reply{download("*/*")},
+	},
+	{
+		method: "GET", path: "/avatars/*filepath", id: "getAvatar", tag: webTag,
+		summary: "Get an uploaded avatar",
+		replies: []reply{download("image/png", "image/jpeg", "image/gif")},
+	},
+	{
+		method: "GET", path: "/", id: "getIndex", tag: webTag,
+		summary: "The web frontend",
+		replies: []reply{{status: 200, body: &Schema{Type: "string"}, contentTypes: []string{"text/html"}}},
+	},
+}
@@ -0,0 +1,306 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"rockpaperscissors/internal/models"
+)
+
+// fieldRule decides which fields of a struct are required
+type fieldRule int
+
+const (
+	// responseFields are required unless they are omitted when empty
+	responseFields fieldRule = iota
+	// requestFields are required when their binding tag says so, as that is
+	// what the handlers check
+	requestFields
+)
+
+// enums lists the values of every named string type the API uses. A type
+// missing from here is an error, so a new enum cannot slip into the
+// document as a plain string.
+var enums = enumValues(
+	[]models.Choice{models.Rock, models.Paper, models.Scissors},
+	models.BotStrategies,
+	[]models.GameResult{models.Win, models.Lose, models.Tie},
+	[]models.OpponentType{models.OpponentComputer, models.OpponentPlayer, models.OpponentAll},
+	[]models.SortOrder{models.SortDesc, models.SortAsc},
+	[]models.UserStatus{models.UserActive, models.UserSuspended, models.UserBanned},
+	[]models.CosmeticSlot{models.SlotAvatar, models.SlotHandSkin, models.SlotVictoryAnimation, models.SlotNameColor},
+	[]models.TransactionType{
+		models.TxGameReward, models.TxWager, models.TxPurchase, models.TxAdminAdjustment, models.TxDailyBonus,
+		models.TxOpeningBalance, models.TxAchievement, models.TxDailyChallenge, models.TxSeasonReward, models.TxTournament,
+	},
+	[]models.ChallengeStatus{
+		models.ChallengePending, models.ChallengeAccepted, models.ChallengeDeclined,
+		models.ChallengeCancelled, models.ChallengeExpired, models.ChallengeCompleted, models.ChallengeForfeited,
+	},
+	[]models.RoundWinner{models.RoundChallenger, models.RoundOpponent, models.RoundTie},
+	[]models.ChallengeKind{models.ChallengeWinWithChoice, models.ChallengeReachStreak, models.ChallengePlayGames, models.ChallengeWinGames},
+	[]models.SeasonStatus{models.SeasonActive, models.SeasonArchived},
+	[]models.FriendshipStatus{models.FriendshipPending, models.FriendshipAccepted},
+	[]models.StreakStatus{models.StreakActive, models.StreakEnded},
+	[]models.ClanRole{models.RoleOwner, models.RoleOfficer, models.RoleMember},
+	[]models.ClanWarStatus{models.ClanWarPending, models.ClanWarActive, models.ClanWarCompleted, models.ClanWarDeclined},
+	[]models.TournamentFormat{models.FormatSingleElimination, models.FormatDoubleElimination, models.FormatRoundRobin, models.FormatSwiss},
+	[]models.TournamentSeeding{models.SeedByCoins, models.SeedByRating},
+	[]models.TournamentStatus{models.TournamentRegistration, models.TournamentInProgress, models.TournamentCompleted, models.TournamentCancelled},
+	[]models.TournamentMatchStatus{models.MatchWaiting, models.MatchActive, models.MatchCompleted, models.MatchForfeit, models.MatchBye},
+	[]models.TournamentBracket{models.BracketMain, models.BracketWinners, models.BracketLosers, models.BracketGrandFinal},
+	[]models.ExportFormat{models.ExportCSV, models.ExportNDJSON, models.ExportParquet},
+)
+
+// enumValues indexes slices of enum values by their type
+func enumValues(lists ...interface{}) map[reflect.Type][]string {
+	values := make(map[reflect.Type][]string)
+	for _, list := range lists {
+		v := reflect.ValueOf(list)
+		for i := 0; i < v.Len(); i++ {
+			values[v.Type().Elem()] = append(values[v.Type().Elem()], v.Index(i).String())
+		}
+	}
+	return values
+}
+
+// object describes a JSON object a handler builds with gin.H. It becomes a
+// component schema of its own, and every field is always present.
+type object struct {
+	name   string
+	fields []field
+}
+
+// field is a key of an object, with a value of the type it holds
+type field struct {
+	name  string
+	value interface{}
+}
+
+func newObject(name string, fields ...field) object {
+	return object{name: name, fields: fields}
+}
+
+// components reflects Go types into the component schemas of a document
+type components struct {
+	schemas map[string]*Schema
+	types   map[string]reflect.Type
+	rules   map[string]fieldRule
+	err     error
+}
+
+func newComponents() *components {
+	return &components{
+		schemas: make(map[string]*Schema),
+		types:   make(map[string]reflect.Type),
+		rules:   make(map[string]fieldRule),
+	}
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	objectType = reflect.TypeOf(object{})
+)
+
+// fail records the first error found
+func (c *components) fail(format string, args ...interface{}) *Schema {
+	if c.err == nil {
+		c.err = fmt.Errorf(format, args...)
+	}
+	return &Schema{}
+}
+
+// value returns the schema of a value's type. An object or a *Schema
+// describes itself.
+func (c *components) value(v interface{}, rule fieldRule) *Schema {
+	switch v := v.(type) {
+	case *Schema:
+		return v
+	case object:
+		return c.object(v)
+	}
+	return c.schema(reflect.TypeOf(v), rule)
+}
+
+// object registers a gin.H object as a component
+func (c *components) object(o object) *Schema {
+	if !c.claim(o.name, objectType, responseFields) {
+		return ref(o.name)
+	}
+	schema := &Schema{Type: "object"}
+	c.schemas[o.name] = schema
+	for _, f := range o.fields {
+		schema.Properties = append(schema.Properties, Property{Name: f.name, Schema: c.value(f.value, responseFields)})
+		schema.Required = append(schema.Required, f.name)
+	}
+	return ref(o.name)
+}
+
+// claim reserves a component name for a type. It reports false if the type
+// already has it, and records an error if another type does.
+func (c *components) claim(name string, t reflect.Type, rule fieldRule) bool {
+	existing, ok := c.types[name]
+	switch {
+	case !ok:
+		c.types[name] = t
+		c.rules[name] = rule
+		return true
+	case existing != t:
+		c.fail("two types are named %s: %s and %s", name, existing, t)
+	case c.rules[name] != rule:
+		c.fail("%s is used both as a request and as a response", name)
+	}
+	return false
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: refPrefix + name}
+}
+
+// schema returns the schema of a Go type as encoding/json writes it
+func (c *components) schema(t reflect.Type, rule fieldRule) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Pointer:
+		return nullable(c.schema(t.Elem(), rule))
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if t.PkgPath() == "" {
+			return &Schema{Type: "string"}
+		}
+		values, ok := enums[t]
+		if !ok {
+			return c.fail("no enum values are listed for %s", t)
+		}
+		if c.claim(t.Name(), t, responseFields) {
+			c.schemas[t.Name()] = &Schema{Type: "string", Enum: values}
+		}
+		return ref(t.Name())
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		// a nil slice is written as null
+		return &Schema{Type: "array", Items: c.schema(t.Elem(), rule), Nullable: t.Kind() == reflect.Slice}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return c.fail("map keys of %s are not strings", t)
+		}
+		return &Schema{Type: "object", AdditionalProperties: c.schema(t.Elem(), rule), Nullable: true}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			schema := &Schema{Type: "object"}
+			c.fields(schema, t, rule)
+			return schema
+		}
+		if c.claim(t.Name(), t, rule) {
+			schema := &Schema{Type: "object"}
+			c.schemas[t.Name()] = schema
+			c.fields(schema, t, rule)
+		}
+		return ref(t.Name())
+	}
+	return c.fail("cannot describe %s", t)
+}
+
+// nullable lets a schema also be null. A reference cannot carry anything
+// else, so it is wrapped.
+func nullable(schema *Schema) *Schema {
+	if schema.Ref != "" {
+		return &Schema{AllOf: []*Schema{schema}, Nullable: true}
+	}
+	copied := *schema
+	copied.Nullable = true
+	return &copied
+}
+
+// fields adds the JSON fields of a struct to an object schema. Embedded
+// structs without a name of their own are flattened, as encoding/json does.
+func (c *components) fields(schema *Schema, t reflect.Type, rule fieldRule) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			c.fields(schema, f.Type, rule)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fieldSchema := c.schema(f.Type, rule)
+		binding := strings.Split(f.Tag.Get("binding"), ",")
+		required := !strings.Contains(","+options+",", ",omitempty,")
+		if rule == requestFields {
+			required = contains(binding, "required")
+		}
+		if constrained := constrain(fieldSchema, binding); constrained != nil {
+			fieldSchema = constrained
+		}
+
+		schema.Properties = append(schema.Properties, Property{Name: name, Schema: fieldSchema})
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// constrain returns a copy of a string or integer schema limited by the
+// min and max of a binding tag, or nil if there are none
+func constrain(schema *Schema, binding []string) *Schema {
+	if schema.Type != "string" && schema.Type != "integer" {
+		return nil
+	}
+	copied := *schema
+	changed := false
+	for _, rule := range binding {
+		key, raw, ok := strings.Cut(rule, "=")
+		if !ok || (key != "min" && key != "max") {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			continue
+		}
+		changed = true
+		switch {
+		case key == "min" && schema.Type == "string":
+			copied.MinLength = &n
+		case key == "max" && schema.Type == "string":
+			copied.MaxLength = &n
+		case key == "min":
+			copied.Minimum = &n
+		default:
+			copied.Maximum = &n
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return &copied
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
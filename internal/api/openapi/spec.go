@@ -0,0 +1,279 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Security schemes
+const (
+	adminAuth   = "adminToken"
+	accountAuth = "accountToken"
+)
+
+// webTag marks the pages served to browsers, which the generated client
+// leaves out
+const webTag = "Web"
+
+// Versions are the versions of the API, each with its own document
+var Versions = []int{1, 2}
+
+// apiPrefix starts the paths of the API, which each version serves under
+// its own prefix, such as /api/v2
+const apiPrefix = "/api/"
+
+// operation documents one route
+type operation struct {
+	method  string // as registered with gin
+	path    string // as registered with gin, with :name and *name parameters
+	id      string
+	tag     string
+	summary string
+	auth    string
+	params  []param
+	body    interface{} // a request type, or a form
+	replies []reply
+	v2      []reply // replies from version 2 on, where they differ
+}
+
+// param is a query or header parameter, or a path parameter that is not a
+// plain string. value is a value of its type.
+type param struct {
+	name        string
+	in          string
+	description string
+	required    bool
+	value       interface{}
+}
+
+func query(name string, value interface{}, description string) param {
+	return param{name: name, in: "query", description: description, value: value}
+}
+
+func pathParam(name string, value interface{}, description string) param {
+	return param{name: name, in: "path", description: description, required: true, value: value}
+}
+
+// adminActor is the header admin requests name the admin acting in
+var adminActor = param{
+	name:        "X-Admin-Actor",
+	in:          "header",
+	description: "Who is acting, for the audit log; admin if left out",
+	value:       "",
+}
+
+// form is a multipart form of uploaded files
+type form struct {
+	files []string
+}
+
+// reply is a successful response
+type reply struct {
+	status       int
+	body         interface{}
+	contentTypes []string
+}
+
+func ok(body interface{}) reply {
+	return reply{status: http.StatusOK, body: body, contentTypes: []string{"application/json"}}
+}
+
+func created(body interface{}) reply {
+	return reply{status: http.StatusCreated, body: body, contentTypes: []string{"application/json"}}
+}
+
+func accepted(body interface{}) reply {
+	return reply{status: http.StatusAccepted, body: body, contentTypes: []string{"application/json"}}
+}
+
+// download is a file, in one of contentTypes
+func download(contentTypes ...string) reply {
+	return reply{status: http.StatusOK, body: binary, contentTypes: contentTypes}
+}
+
+// binary is the schema of a file
+var binary = &Schema{Type: "string", Format: "binary"}
+
+// Error is the body of every error response. The error middleware adds a
+// message to the errors it writes.
+type Error struct {
+	Error   string `json:"error"`
+	Message string `json:"message,omitempty"`
+}
+
+var (
+	specs    map[int]*Document
+	specErr  error
+	specOnce sync.Once
+)
+
+// Spec returns the OpenAPI document of a version of the API, or nil if
+// there is no such version. It panics if the route table describes a type
+// it cannot, which the tests catch.
+func Spec(version int) *Document {
+	specOnce.Do(func() {
+		specs = make(map[int]*Document)
+		for _, v := range Versions {
+			if specs[v], specErr = build(operations, v); specErr != nil {
+				return
+			}
+		}
+	})
+	if specErr != nil {
+		panic(specErr)
+	}
+	return specs[version]
+}
+
+// description introduces every document
+const description = "Play rock paper scissors against the computer and other players, and everything around it.\n\n" +
+	"Each version of the API is served under its own prefix, such as /api/v2, and has its own document. " +
+	"The unversioned /api paths are a deprecated alias of /api/v1, answered with Deprecation and Sunset headers, " +
+	"unless the Accept header asks for a version: application/vnd.rockpaperscissors.v2+json. " +
+	"Every API response says which version it follows in the API-Version header."
+
+// build turns the route table into the document of a version
+func build(ops []operation, version int) (*Document, error) {
+	c := newComponents()
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       "Rock Paper Scissors API",
+			Description: description,
+			Version:     fmt.Sprintf("%d.0.0", version),
+		},
+		Tags:  tags,
+		Paths: make(map[string]PathItem),
+		Components: Components{
+			Schemas: c.schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				adminAuth:   {Type: "http", Scheme: "bearer", Description: "The server's ADMIN_TOKEN"},
+				accountAuth: {Type: "http", Scheme: "bearer", Description: "The account token returned when the user was created"},
+			},
+		},
+	}
+	errorSchema := c.value(Error{}, responseFields)
+
+	ids := make(map[string]bool)
+	for _, o := range ops {
+		if ids[o.id] {
+			return nil, fmt.Errorf("operation ID %s is used twice", o.id)
+		}
+		ids[o.id] = true
+
+		ginPath := o.path
+		if strings.HasPrefix(ginPath, apiPrefix) {
+			ginPath = fmt.Sprintf("/api/v%d/", version) + strings.TrimPrefix(ginPath, apiPrefix)
+		}
+		path, names := openAPIPath(ginPath)
+		op := &Operation{
+			OperationID: o.id,
+			Summary:     o.summary,
+			Tags:        []string{o.tag},
+			Responses:   make(map[string]Response),
+		}
+		if o.auth != "" {
+			op.Security = []map[string][]string{{o.auth: {}}}
+		}
+
+		for _, name := range names {
+			p := param{name: name, in: "path", required: true, value: ""}
+			for _, declared := range o.params {
+				if declared.in == "path" && declared.name == name {
+					p = declared
+				}
+			}
+			op.Parameters = append(op.Parameters, c.parameter(p))
+		}
+		for _, p := range o.params {
+			if p.in == "path" {
+				if !contains(names, p.name) {
+					return nil, fmt.Errorf("%s %s has no path parameter %s", o.method, o.path, p.name)
+				}
+				continue
+			}
+			op.Parameters = append(op.Parameters, c.parameter(p))
+		}
+
+		switch body := o.body.(type) {
+		case nil:
+		case form:
+			schema := &Schema{Type: "object", Required: body.files}
+			for _, name := range body.files {
+				schema.Properties = append(schema.Properties, Property{Name: name, Schema: binary})
+			}
+			op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{"multipart/form-data": {Schema: schema}}}
+		default:
+			schema := c.value(body, requestFields)
+			op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{"application/json": {Schema: schema}}}
+		}
+
+		replies := o.replies
+		if version >= 2 && o.v2 != nil {
+			replies = o.v2
+		}
+		for _, r := range replies {
+			response := Response{Description: http.StatusText(r.status), Content: make(map[string]MediaType)}
+			schema := c.value(r.body, responseFields)
+			for _, contentType := range r.contentTypes {
+				response.Content[contentType] = MediaType{Schema: schema}
+			}
+			op.Responses[strconv.Itoa(r.status)] = response
+		}
+		if o.tag != webTag {
+			op.Responses["default"] = Response{
+				Description: "Error",
+				Content:     map[string]MediaType{"application/json": {Schema: errorSchema}},
+			}
+		}
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+		method := strings.ToLower(o.method)
+		if item[method] != nil {
+			return nil, fmt.Errorf("%s %s is documented twice", o.method, o.path)
+		}
+		item[method] = op
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return doc, nil
+}
+
+// parameter describes a parameter
+func (c *components) parameter(p param) Parameter {
+	return Parameter{
+		Name:        p.name,
+		In:          p.in,
+		Description: p.description,
+		Required:    p.required,
+		Schema:      c.value(p.value, requestFields),
+	}
+}
+
+// openAPIPath turns a gin path into an OpenAPI one and lists its parameters
+func openAPIPath(path string) (string, []string) {
+	var names []string
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			names = append(names, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), names
+}
+
+// Operation returns the operation documented for a method and a path as
+// registered with gin, such as /api/users/:username, or nil
+func (d *Document) Operation(method, ginPath string) *Operation {
+	path, _ := openAPIPath(ginPath)
+	return d.Paths[path][strings.ToLower(method)]
+}
@@ -1,53 +1,276 @@
 package routes
 
 import (
-	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"rockpaperscissors/internal/api/handlers"
 	"rockpaperscissors/internal/api/middleware"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
+// apiVersions are the versions of the API served under /api/vN
+var apiVersions = []int{1, 2}
+
+// unversioned is the deprecation of the original /api paths, which serve
+// version 1 unless the Accept header asks for another
+var unversioned = middleware.Deprecation{
+	Since:  time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+	Sunset: time.Date(2027, 4, 19, 0, 0, 0, 0, time.UTC),
+	Successor: func(path string) string {
+		return "/api/v1" + strings.TrimPrefix(path, "/api")
+	},
+}
+
+// apiHandlers are shared by every version of the API
+type apiHandlers struct {
+	game        *handlers.GameHandler
+	user        *handlers.UserHandler
+	ledger      *handlers.LedgerHandler
+	shop        *handlers.ShopHandler
+	achievement *handlers.AchievementHandler
+	daily       *handlers.DailyHandler
+	season      *handlers.SeasonHandler
+	friend      *handlers.FriendHandler
+	challenge   *handlers.ChallengeHandler
+	headToHead  *handlers.HeadToHeadHandler
+	analytics   *handlers.AnalyticsHandler
+	streak      *handlers.StreakHandler
+	export      *handlers.ExportHandler
+	admin       *handlers.AdminHandler
+	profile     *handlers.ProfileHandler
+	account     *handlers.AccountHandler
+	tournament  *handlers.TournamentHandler
+	clan        *handlers.ClanHandler
+	docs        *handlers.DocsHandler
+
+	authenticate func(username, token string) error
+	identify     func(token string) (string, error)
+	adminToken   string
+}
+
 // SetupRoutes configures all the API routes
-func SetupRoutes(router *gin.Engine, db *sql.DB) {
+func SetupRoutes(router *gin.Engine, db *database.DB) {
 	// Initialize handlers
-	gameHandler := handlers.NewGameHandler(db)
-	userHandler := handlers.NewUserHandler(db)
+	h := apiHandlers{
+		game:         handlers.NewGameHandler(db),
+		user:         handlers.NewUserHandler(db),
+		ledger:       handlers.NewLedgerHandler(db),
+		shop:         handlers.NewShopHandler(db),
+		achievement:  handlers.NewAchievementHandler(db),
+		daily:        handlers.NewDailyHandler(db),
+		season:       handlers.NewSeasonHandler(db),
+		friend:       handlers.NewFriendHandler(db),
+		challenge:    handlers.NewChallengeHandler(db),
+		headToHead:   handlers.NewHeadToHeadHandler(db),
+		analytics:    handlers.NewAnalyticsHandler(db),
+		streak:       handlers.NewStreakHandler(db),
+		export:       handlers.NewExportHandler(db),
+		admin:        handlers.NewAdminHandler(db),
+		profile:      handlers.NewProfileHandler(db),
+		account:      handlers.NewAccountHandler(db),
+		tournament:   handlers.NewTournamentHandler(db),
+		clan:         handlers.NewClanHandler(db),
+		docs:         handlers.NewDocsHandler(),
+		authenticate: services.NewUserService(db).Authenticate,
+		identify:     services.NewUserService(db).Identify,
+		adminToken:   os.Getenv("ADMIN_TOKEN"),
+	}
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok", "message": "Rock Paper Scissors API is running"})
 	})
 
-	// API routes group
-	api := router.Group("/api")
+	// Each version of the API has its own prefix. The unversioned /api
+	// paths are kept as a deprecated alias of v1, and can also pick a
+	// version through the Accept header.
+	for _, version := range apiVersions {
+		registerAPI(router.Group(fmt.Sprintf("/api/v%d", version), middleware.PinAPIVersion(version)), h)
+	}
+	registerAPI(router.Group("/api", middleware.NegotiateAPIVersion(1, apiVersions, unversioned)), h)
+
+	// Admin routes require the ADMIN_TOKEN as a bearer token. They are
+	// served once, outside the versioned API.
+	admin := router.Group("/admin")
 	{
-		// Apply common middleware to API routes
-		api.Use(middleware.JSONMiddleware())
-		api.Use(middleware.ErrorHandler())
+		admin.Use(middleware.AdminAuth(h.adminToken))
+		admin.Use(middleware.ErrorHandler())
 
-		// User management
-		api.POST("/users", userHandler.CreateUser)
-		api.GET("/users/:username", userHandler.GetUser)
-		api.GET("/stats/:username", userHandler.GetUserStats)
+		admin.GET("/games/export", h.export.ExportAllGames)
 
-		// Game endpoints
-		api.POST("/play", gameHandler.PlayGame)
-		api.GET("/leaderboard", userHandler.GetLeaderboard)
-		
-		// Game history (optional)
-		api.GET("/users/:username/games", gameHandler.GetUserGames)
+		// Moderation and account management
+		admin.GET("/users", h.admin.SearchUsers)
+		admin.GET("/users/:username", h.admin.GetUserRecord)
+		admin.POST("/users/:username/coins", h.admin.AdjustCoins)
+		admin.POST("/users/:username/reset-streak", h.admin.ResetStreak)
+		admin.POST("/users/:username/ban", h.admin.BanUser)
+		admin.POST("/users/:username/suspend", h.admin.SuspendUser)
+		admin.POST("/users/:username/reinstate", h.admin.ReinstateUser)
+		admin.PUT("/users/:username/username", h.admin.RenameUser)
+		admin.DELETE("/users/:username", h.admin.DeleteUser)
+		admin.POST("/users/:username/token", h.admin.IssueAccountToken)
+		admin.GET("/actions", h.admin.GetActions)
+
+		// Tournament organization
+		admin.POST("/tournaments", h.tournament.CreateTournament)
+		admin.POST("/tournaments/:id/start", h.tournament.StartTournament)
+		admin.POST("/tournaments/:id/cancel", h.tournament.CancelTournament)
 	}
 
+	// API documentation
+	router.GET("/openapi.json", h.docs.GetOpenAPIDocument)
+	router.GET("/docs", h.docs.GetDocs)
+
 	// Serve static files for web frontend (if needed)
 	router.Static("/static", "./web/static")
+
+	// Uploaded avatars
+	router.Static(strings.TrimSuffix(services.AvatarURLPrefix, "/"), services.AvatarDir())
 	router.LoadHTMLGlob("web/templates/*")
-	
+
 	// Web frontend route (optional)
 	router.GET("/", func(c *gin.Context) {
 		c.HTML(200, "index.html", gin.H{
 			"title": "Rock Paper Scissors",
 		})
 	})
-} 
\ No newline at end of file
+}
+
+// registerAPI adds the API routes to a group. The handlers tell the
+// versions apart where their responses differ.
+func registerAPI(group *gin.RouterGroup, h apiHandlers) {
+	api := group.Group("")
+	{
+		// Apply common middleware to API routes
+		api.Use(middleware.JSONMiddleware())
+		api.Use(middleware.ErrorHandler())
+
+		// The document of this version
+		api.GET("/openapi.json", h.docs.GetOpenAPIDocument)
+
+		// User management
+		api.POST("/users", h.user.CreateUser)
+		api.GET("/users/:username", h.user.GetUser)
+		api.GET("/stats/:username", h.user.GetUserStats)
+		api.GET("/users/:username/analytics", h.analytics.GetAnalytics)
+
+		// Game endpoints
+		api.POST("/play", h.game.PlayGame)
+		api.GET("/leaderboard", h.user.GetLeaderboard)
+		api.GET("/leaderboard/streaks", h.streak.GetStreakLeaderboard)
+
+		// Game history (optional)
+		api.GET("/users/:username/games", h.game.GetUserGames)
+		api.GET("/users/:username/games/export", h.export.ExportUserGames)
+		api.GET("/users/:username/streaks", h.streak.GetUserStreaks)
+
+		// Coin ledger
+		api.GET("/users/:username/transactions", h.ledger.GetUserTransactions)
+
+		// Cosmetic shop
+		api.GET("/shop/items", h.shop.GetItems)
+		api.GET("/users/:username/inventory", h.shop.GetInventory)
+
+		// Achievements
+		api.GET("/users/:username/achievements", h.achievement.GetUserAchievements)
+
+		// Daily login reward and daily challenges
+		api.GET("/users/:username/daily-reward", h.daily.GetDailyReward)
+		api.GET("/users/:username/daily-challenges", h.daily.GetDailyChallenges)
+
+		// Seasons
+		api.GET("/seasons", h.season.ListSeasons)
+		api.GET("/seasons/:id/leaderboard", h.season.GetSeasonLeaderboard)
+		api.GET("/users/:username/seasons", h.season.GetUserSeasons)
+
+		// Friends
+		api.GET("/users/:username/friends", h.friend.GetFriends)
+		api.GET("/users/:username/friends/leaderboard", h.friend.GetFriendsLeaderboard)
+
+		// Direct challenges
+		api.GET("/challenges/:id", h.challenge.GetChallenge)
+		api.GET("/users/:username/challenges", h.challenge.GetUserChallenges)
+
+		// Head-to-head records
+		api.GET("/users/:username/vs/:opponent", h.headToHead.GetHeadToHead)
+
+		// Tournaments
+		api.GET("/tournaments", h.tournament.ListTournaments)
+		api.GET("/tournaments/:id", h.tournament.GetTournament)
+		api.GET("/tournaments/:id/bracket", h.tournament.GetBracket)
+		api.GET("/tournaments/:id/standings", h.tournament.GetStandings)
+
+		// Clans and clan wars
+		api.GET("/clans/leaderboard", h.clan.GetLeaderboard)
+		api.GET("/clans/:tag", h.clan.GetClan)
+		api.GET("/clans/:tag/wars", h.clan.ListWars)
+		api.GET("/clan-wars/:id", h.clan.GetWar)
+		api.GET("/users/:username/clan-invites", h.clan.GetInvites)
+	}
+
+	// Routes that act on a player named in the path require that player's
+	// account token
+	account := group.Group("/users/:username")
+	{
+		account.Use(middleware.JSONMiddleware())
+		account.Use(middleware.ErrorHandler())
+		account.Use(middleware.UserAuth(h.authenticate))
+
+		account.PATCH("", h.profile.UpdateProfile)
+		account.POST("/avatar", h.profile.UploadAvatar)
+		account.PUT("/timezone", h.user.SetTimezone)
+		account.GET("/data-export", h.account.ExportData)
+		account.POST("/deletion", h.account.RequestDeletion)
+		account.DELETE("/deletion", h.account.CancelDeletion)
+		account.POST("/daily-reward", h.daily.ClaimDailyReward)
+		account.POST("/daily-challenges/:id/claim", h.daily.ClaimDailyChallenge)
+		account.DELETE("/friends/:friend", h.friend.RemoveFriend)
+	}
+
+	// Routes that act for a player not named in the path take the player
+	// from the account token; a username in the body has to match it
+	player := group.Group("")
+	{
+		player.Use(middleware.JSONMiddleware())
+		player.Use(middleware.ErrorHandler())
+		player.Use(middleware.PlayerAuth(h.identify))
+
+		// Friend requests and direct challenges
+		player.POST("/friends/requests", h.friend.SendRequest)
+		player.POST("/friends/requests/accept", h.friend.AcceptRequest)
+		player.POST("/friends/requests/decline", h.friend.DeclineRequest)
+		player.POST("/challenges", h.challenge.CreateChallenge)
+		player.POST("/challenges/:id/accept", h.challenge.AcceptChallenge)
+		player.POST("/challenges/:id/decline", h.challenge.DeclineChallenge)
+		player.POST("/challenges/:id/cancel", h.challenge.CancelChallenge)
+		player.POST("/challenges/:id/moves", h.challenge.SubmitMove)
+
+		// Cosmetic shop
+		player.POST("/shop/purchase", h.shop.Purchase)
+		player.POST("/shop/equip", h.shop.Equip)
+		player.POST("/shop/unequip", h.shop.Unequip)
+
+		// Tournaments
+		player.POST("/tournaments/:id/register", h.tournament.Register)
+		player.POST("/tournaments/:id/withdraw", h.tournament.Withdraw)
+		player.POST("/tournaments/:id/moves", h.tournament.SubmitMove)
+
+		// Clans and clan wars
+		player.POST("/clans", h.clan.CreateClan)
+		player.POST("/clans/:tag/invites", h.clan.Invite)
+		player.POST("/clans/:tag/invites/decline", h.clan.DeclineInvite)
+		player.POST("/clans/:tag/join", h.clan.Join)
+		player.POST("/clans/:tag/leave", h.clan.Leave)
+		player.POST("/clans/:tag/kick", h.clan.Kick)
+		player.PUT("/clans/:tag/members/:member/role", h.clan.SetRole)
+		player.POST("/clans/:tag/wars", h.clan.DeclareWar)
+		player.POST("/clan-wars/:id/accept", h.clan.AcceptWar)
+		player.POST("/clan-wars/:id/decline", h.clan.DeclineWar)
+	}
+}
@@ -0,0 +1,665 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"rockpaperscissors/internal/api/middleware"
+	"rockpaperscissors/internal/api/openapi"
+	"rockpaperscissors/internal/database"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const testAdminToken = "test-admin-token"
+
+// apiTest drives the router the server runs and checks every request and
+// response against the OpenAPI document
+type apiTest struct {
+	t       *testing.T
+	router  *gin.Engine
+	doc     *openapi.Document // the document of the last request's version
+	route   string            // the documented route of the last request
+	covered map[string]bool   // "METHOD route" of operations that succeeded
+}
+
+// documented returns the document and the documented route of a route the
+// router matched: /api/vN routes are in the document of version N, and
+// the unversioned /api alias in that of the version it served, as named by
+// the API-Version header
+func documented(route, served string) (*openapi.Document, string) {
+	rest, ok := strings.CutPrefix(route, "/api/")
+	if !ok {
+		return openapi.Spec(1), route
+	}
+	if prefix, _, _ := strings.Cut(rest, "/"); strings.HasPrefix(prefix, "v") {
+		if version, err := strconv.Atoi(prefix[1:]); err == nil {
+			return openapi.Spec(version), route
+		}
+	}
+	version, err := strconv.Atoi(served)
+	if err != nil {
+		version = 1
+	}
+	return openapi.Spec(version), fmt.Sprintf("/api/v%d/%s", version, rest)
+}
+
+// setupAPITest builds the router from SetupRoutes over a fresh database
+func setupAPITest(t *testing.T) *apiTest {
+	// the HTML templates are loaded relative to the repository root
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(filepath.Join(wd, "..", "..", "..")); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	t.Setenv("ADMIN_TOKEN", testAdminToken)
+	t.Setenv("AVATAR_DIR", t.TempDir())
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := database.RunMigrations(db.DB); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	a := &apiTest{t: t, covered: make(map[string]bool)}
+	gin.SetMode(gin.TestMode)
+	a.router = gin.New()
+	a.router.Use(func(c *gin.Context) { a.route = c.FullPath() })
+	SetupRoutes(a.router, db)
+	return a
+}
+
+// multipartFile is a request body sent as a form with one file
+type multipartFile struct {
+	field string
+	data  []byte
+}
+
+// call sends a request, fails the test unless it gets the wanted status,
+// and checks both bodies against the document. It returns the decoded JSON
+// response, or nil for other content.
+func (a *apiTest) call(method, target, token string, body interface{}, want int) interface{} {
+	a.t.Helper()
+
+	var reader *bytes.Reader
+	contentType := ""
+	switch body := body.(type) {
+	case nil:
+		reader = bytes.NewReader(nil)
+	case multipartFile:
+		var buf bytes.Buffer
+		form := multipart.NewWriter(&buf)
+		part, _ := form.CreateFormFile(body.field, body.field+".png")
+		part.Write(body.data)
+		form.Close()
+		reader, contentType = bytes.NewReader(buf.Bytes()), form.FormDataContentType()
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			a.t.Fatalf("Failed to encode request: %v", err)
+		}
+		reader, contentType = bytes.NewReader(data), "application/json"
+	}
+
+	req := httptest.NewRequest(method, target, reader)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if token == testAdminToken {
+		req.Header.Set("X-Admin-Actor", "tester")
+	}
+	a.route = ""
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+
+	if w.Code != want {
+		a.t.Fatalf("%s %s: expected status %d, got %d: %s", method, target, want, w.Code, w.Body.String())
+	}
+	a.doc, a.route = documented(a.route, w.Header().Get(middleware.VersionHeader))
+	op := a.doc.Operation(method, a.route)
+	if op == nil {
+		a.t.Fatalf("%s %s matched %q, which is not documented", method, target, a.route)
+	}
+
+	switch body.(type) {
+	case nil:
+		if op.RequestBody != nil {
+			a.t.Errorf("%s %s: documented with a request body, sent none", method, a.route)
+		}
+	case multipartFile:
+		if op.RequestBody == nil || op.RequestBody.Content["multipart/form-data"].Schema == nil {
+			a.t.Errorf("%s %s: not documented as taking a form", method, a.route)
+		}
+	default:
+		if op.RequestBody == nil || op.RequestBody.Content["application/json"].Schema == nil {
+			a.t.Errorf("%s %s: not documented as taking JSON", method, a.route)
+			break
+		}
+		data, _ := json.Marshal(body)
+		if err := a.validate(op.RequestBody.Content["application/json"].Schema, decode(data), "request"); err != nil {
+			a.t.Errorf("%s %s: %v", method, a.route, err)
+		}
+	}
+
+	response, ok := op.Responses[strconv.Itoa(w.Code)]
+	if !ok {
+		if response, ok = op.Responses["default"]; !ok || w.Code < 400 {
+			a.t.Fatalf("%s %s: status %d is not documented", method, a.route, w.Code)
+		}
+	}
+	mediaType, _, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil {
+		a.t.Fatalf("%s %s: bad Content-Type %q", method, a.route, w.Header().Get("Content-Type"))
+	}
+	media, ok := response.Content[mediaType]
+	if !ok {
+		if media, ok = response.Content["*/*"]; !ok {
+			a.t.Fatalf("%s %s: content type %s is not documented for status %d", method, a.route, mediaType, w.Code)
+		}
+	}
+	if w.Code < 300 {
+		a.covered[method+" "+a.route] = true
+	}
+	if mediaType != "application/json" {
+		return nil
+	}
+	value := decode(w.Body.Bytes())
+	if err := a.validate(media.Schema, value, "response"); err != nil {
+		a.t.Errorf("%s %s: %v\n%s", method, a.route, err, w.Body.String())
+	}
+	return value
+}
+
+func decode(data []byte) interface{} {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+	return value
+}
+
+// validate checks a decoded JSON value against a schema: its type, that
+// required properties are there and that there are no others
+func (a *apiTest) validate(schema *openapi.Schema, value interface{}, at string) error {
+	if schema.Ref != "" {
+		return a.validate(a.doc.Resolve(schema), value, at)
+	}
+	if value == nil {
+		if schema.Nullable {
+			return nil
+		}
+		return fmt.Errorf("%s is null", at)
+	}
+	for _, part := range schema.AllOf {
+		if err := a.validate(part, value, at); err != nil {
+			return err
+		}
+	}
+
+	switch schema.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s is %v, not a string", at, value)
+		}
+		if len(schema.Enum) > 0 && !contains(schema.Enum, s) {
+			return fmt.Errorf("%s is %q, not one of %v", at, s, schema.Enum)
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+				return fmt.Errorf("%s is %q, not a date-time", at, s)
+			}
+		}
+		if schema.MinLength != nil && len([]rune(s)) < *schema.MinLength {
+			return fmt.Errorf("%s is shorter than %d", at, *schema.MinLength)
+		}
+		if schema.MaxLength != nil && len([]rune(s)) > *schema.MaxLength {
+			return fmt.Errorf("%s is longer than %d", at, *schema.MaxLength)
+		}
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s is %v, not a number", at, value)
+		}
+		if schema.Type == "integer" {
+			i, err := n.Int64()
+			if err != nil {
+				return fmt.Errorf("%s is %s, not an integer", at, n)
+			}
+			if schema.Minimum != nil && i < int64(*schema.Minimum) {
+				return fmt.Errorf("%s is below %d", at, *schema.Minimum)
+			}
+			if schema.Maximum != nil && i > int64(*schema.Maximum) {
+				return fmt.Errorf("%s is above %d", at, *schema.Maximum)
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s is %v, not a boolean", at, value)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s is %v, not an array", at, value)
+		}
+		for i, item := range items {
+			if err := a.validate(schema.Items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s is %v, not an object", at, value)
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s has no %s", at, name)
+			}
+		}
+		for name, property := range object {
+			propertySchema := schema.Properties.Get(name)
+			switch {
+			case propertySchema != nil:
+			case schema.AdditionalProperties != nil:
+				propertySchema = schema.AdditionalProperties
+			case len(schema.Properties) == 0:
+				continue
+			default:
+				return fmt.Errorf("%s has %s, which is not documented", at, name)
+			}
+			if err := a.validate(propertySchema, property, at+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// get digs a value out of a decoded response by object keys and array
+// indexes, and formats it for a path or a comparison
+func get(value interface{}, path ...interface{}) string {
+	for _, step := range path {
+		switch step := step.(type) {
+		case string:
+			object, _ := value.(map[string]interface{})
+			value = object[step]
+		case int:
+			array, _ := value.([]interface{})
+			if step >= len(array) {
+				return ""
+			}
+			value = array[step]
+		}
+	}
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+func testPNG(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 1, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// ginRoute turns a path of the document back into the route gin matches
+func ginRoute(path string) string {
+	route := strings.NewReplacer("{", ":", "}", "").Replace(path)
+	if strings.HasSuffix(path, "{filepath}") {
+		route = strings.Replace(route, ":filepath", "*filepath", 1)
+	}
+	return route
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	a := setupAPITest(t)
+
+	registered := make(map[string]bool)
+	for _, route := range a.router.Routes() {
+		if route.Method == http.MethodHead {
+			// gin's Static serves HEAD alongside GET
+			continue
+		}
+		doc, path := documented(route.Path, "")
+		op := doc.Operation(route.Method, path)
+		if op == nil {
+			t.Errorf("%s %s is not documented", route.Method, route.Path)
+			continue
+		}
+		registered[route.Method+" "+path] = true
+	}
+
+	for _, version := range openapi.Versions {
+		for path, item := range openapi.Spec(version).Paths {
+			for method := range item {
+				if !registered[strings.ToUpper(method)+" "+ginRoute(path)] {
+					t.Errorf("%s %s is documented but not registered", strings.ToUpper(method), path)
+				}
+			}
+		}
+	}
+}
+
+func TestAPIMatchesDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		api     string
+		version int
+	}{
+		{name: "Version 1", api: "/api/v1", version: 1},
+		{name: "Version 2", api: "/api/v2", version: 2},
+		{name: "Unversioned alias of version 1", api: "/api", version: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run("Success - "+tt.name, func(t *testing.T) {
+			a := setupAPITest(t)
+			exerciseAPI(t, a, tt.api)
+
+			for path, item := range openapi.Spec(tt.version).Paths {
+				for method := range item {
+					if !a.covered[strings.ToUpper(method)+" "+ginRoute(path)] {
+						t.Errorf("%s %s was never answered successfully", strings.ToUpper(method), path)
+					}
+				}
+			}
+		})
+	}
+}
+
+// exerciseAPI answers every documented operation of the API under the
+// prefix api at least once
+func exerciseAPI(t *testing.T, a *apiTest, api string) {
+	admin := testAdminToken
+
+	a.call("GET", "/health", "", nil, http.StatusOK)
+
+	// Users
+	tokens := make(map[string]string)
+	for _, name := range []string{"alice", "bob", "carol", "dave", "erin", "frank", "grace"} {
+		user := a.call("POST", api+"/users", "", map[string]string{"username": name}, http.StatusCreated)
+		tokens[name] = get(user, "account_token")
+	}
+	a.call("GET", api+"/users/alice", "", nil, http.StatusOK)
+	a.call("PATCH", api+"/users/alice", "", map[string]string{"display_name": "Mallory"}, http.StatusUnauthorized)
+	a.call("PATCH", api+"/users/alice", tokens["bob"], map[string]string{"display_name": "Mallory"}, http.StatusUnauthorized)
+	a.call("PATCH", api+"/users/alice", tokens["alice"], map[string]string{"display_name": "Alice", "country": "US", "bio": "Rock first"}, http.StatusOK)
+	user := a.call("POST", api+"/users/alice/avatar", tokens["alice"], multipartFile{field: "avatar", data: testPNG(t)}, http.StatusOK)
+	a.call("GET", get(user, "profile", "avatar_url"), "", nil, http.StatusOK)
+
+	// Games, until one of today's challenges is done
+	a.call("PUT", api+"/users/alice/timezone", tokens["alice"], map[string]string{"timezone": "UTC"}, http.StatusOK)
+	choices := []string{"rock", "paper", "scissors"}
+	completed := ""
+	for i := 0; i < 300 && completed == ""; i++ {
+		a.call("POST", api+"/play", "", map[string]string{"username": "alice", "player_choice": choices[i%3]}, http.StatusOK)
+		if i%5 != 4 {
+			continue
+		}
+		challenges := a.call("GET", api+"/users/alice/daily-challenges", "", nil, http.StatusOK)
+		for j := 0; get(challenges, "challenges", j) != ""; j++ {
+			if get(challenges, "challenges", j, "completed") == "true" {
+				completed = get(challenges, "challenges", j, "id")
+				break
+			}
+		}
+	}
+	if completed == "" {
+		t.Fatalf("No daily challenge was completed")
+	}
+	a.call("POST", api+"/users/alice/daily-challenges/"+completed+"/claim", tokens["alice"], nil, http.StatusOK)
+	a.call("GET", api+"/users/alice/daily-reward", "", nil, http.StatusOK)
+	a.call("POST", api+"/users/alice/daily-reward", tokens["alice"], nil, http.StatusOK)
+	for _, choice := range choices {
+		a.call("POST", api+"/play", "", map[string]string{"username": "bob", "player_choice": choice, "bot": "mirror"}, http.StatusOK)
+	}
+
+	a.call("GET", api+"/stats/alice", "", nil, http.StatusOK)
+	a.call("GET", api+"/users/alice/analytics?opponent=computer", "", nil, http.StatusOK)
+	a.call("GET", api+"/leaderboard", "", nil, http.StatusOK)
+	a.call("GET", api+"/leaderboard?country=US", "", nil, http.StatusOK)
+	a.call("GET", api+"/leaderboard/streaks", "", nil, http.StatusOK)
+	page := a.call("GET", api+"/users/alice/games?limit=5&order=desc&result=win", "", nil, http.StatusOK)
+	a.call("GET", api+"/users/alice/games?limit=5&cursor="+get(page, "next_cursor"), "", nil, http.StatusOK)
+	a.call("GET", api+"/users/alice/games/export?format=csv", "", nil, http.StatusOK)
+	a.call("GET", api+"/users/alice/streaks?limit=5", "", nil, http.StatusOK)
+	a.call("GET", api+"/users/alice/transactions?limit=5", "", nil, http.StatusOK)
+
+	// Shop
+	a.call("POST", "/admin/users/alice/coins", admin, map[string]interface{}{"amount": 1000, "reason": "shopping money"}, http.StatusOK)
+	a.call("GET", api+"/shop/items", "", nil, http.StatusOK)
+	a.call("POST", api+"/shop/purchase", tokens["alice"], map[string]string{"username": "alice", "item_id": "avatar-robot"}, http.StatusCreated)
+	a.call("POST", api+"/shop/equip", tokens["alice"], map[string]string{"username": "alice", "item_id": "avatar-robot"}, http.StatusOK)
+	a.call("GET", api+"/users/alice", "", nil, http.StatusOK)
+	a.call("POST", api+"/shop/unequip", tokens["alice"], map[string]string{"username": "alice", "slot": "avatar"}, http.StatusOK)
+	a.call("GET", api+"/users/alice/inventory", "", nil, http.StatusOK)
+	a.call("GET", api+"/users/alice/achievements", "", nil, http.StatusOK)
+
+	// Seasons
+	seasons := a.call("GET", api+"/seasons", "", nil, http.StatusOK)
+	a.call("GET", api+"/seasons/current/leaderboard", "", nil, http.StatusOK)
+	a.call("GET", api+"/seasons/"+get(seasons, "seasons", 0, "id")+"/leaderboard", "", nil, http.StatusOK)
+	a.call("GET", api+"/users/alice/seasons", "", nil, http.StatusOK)
+
+	// Friends
+	a.call("POST", api+"/friends/requests", tokens["alice"], map[string]string{"username": "alice", "friend": "bob"}, http.StatusCreated)
+	a.call("GET", api+"/users/bob/friends", "", nil, http.StatusOK)
+	a.call("POST", api+"/friends/requests/accept", tokens["bob"], map[string]string{"username": "bob", "friend": "alice"}, http.StatusOK)
+	a.call("POST", api+"/friends/requests", tokens["alice"], map[string]string{"username": "alice", "friend": "carol"}, http.StatusCreated)
+	a.call("POST", api+"/friends/requests/decline", tokens["carol"], map[string]string{"username": "carol", "friend": "alice"}, http.StatusOK)
+	a.call("GET", api+"/users/alice/friends", "", nil, http.StatusOK)
+	a.call("GET", api+"/users/alice/friends/leaderboard", "", nil, http.StatusOK)
+
+	// Challenges
+	challenge := a.call("POST", api+"/challenges", tokens["alice"], map[string]interface{}{"username": "alice", "opponent": "bob", "best_of": 1, "stake": 0}, http.StatusCreated)
+	id := get(challenge, "id")
+	a.call("GET", api+"/challenges/"+id, "", nil, http.StatusOK)
+	a.call("POST", api+"/challenges/"+id+"/accept", tokens["bob"], map[string]string{"username": "bob"}, http.StatusOK)
+	a.call("POST", api+"/challenges/"+id+"/moves", tokens["alice"], map[string]string{"username": "alice", "player_choice": "rock"}, http.StatusOK)
+	a.call("POST", api+"/challenges/"+id+"/moves", tokens["bob"], map[string]string{"username": "bob", "player_choice": "scissors"}, http.StatusOK)
+	challenge = a.call("POST", api+"/challenges", tokens["alice"], map[string]string{"username": "alice", "opponent": "bob"}, http.StatusCreated)
+	a.call("POST", api+"/challenges/"+get(challenge, "id")+"/decline", tokens["bob"], map[string]string{"username": "bob"}, http.StatusOK)
+	challenge = a.call("POST", api+"/challenges", tokens["bob"], map[string]string{"username": "bob", "opponent": "alice"}, http.StatusCreated)
+	a.call("POST", api+"/challenges/"+get(challenge, "id")+"/cancel", tokens["bob"], map[string]string{"username": "bob"}, http.StatusOK)
+	a.call("GET", api+"/users/alice/challenges", "", nil, http.StatusOK)
+	a.call("GET", api+"/users/alice/challenges?status=completed", "", nil, http.StatusOK)
+	a.call("GET", api+"/users/alice/vs/bob", "", nil, http.StatusOK)
+
+	// Tournaments
+	opens := map[string]interface{}{
+		"name":                   "Spring Cup",
+		"format":                 "single_elimination",
+		"registration_closes_at": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	}
+	tournament := a.call("POST", "/admin/tournaments", admin, opens, http.StatusCreated)
+	id = get(tournament, "id")
+	for _, name := range []string{"alice", "bob", "carol"} {
+		a.call("POST", api+"/tournaments/"+id+"/register", tokens[name], map[string]string{"username": name}, http.StatusOK)
+	}
+	a.call("POST", api+"/tournaments/"+id+"/withdraw", tokens["carol"], map[string]string{"username": "carol"}, http.StatusOK)
+	a.call("GET", api+"/tournaments?status=registration", "", nil, http.StatusOK)
+	a.call("POST", "/admin/tournaments/"+id+"/start", admin, nil, http.StatusOK)
+	a.call("GET", api+"/tournaments/"+id, "", nil, http.StatusOK)
+	a.call("POST", api+"/tournaments/"+id+"/moves", tokens["alice"], map[string]string{"username": "alice", "player_choice": "paper"}, http.StatusOK)
+	a.call("GET", api+"/tournaments/"+id+"/bracket", "", nil, http.StatusOK)
+	a.call("GET", api+"/tournaments/"+id+"/standings", "", nil, http.StatusOK)
+	tournament = a.call("POST", "/admin/tournaments", admin, opens, http.StatusCreated)
+	a.call("POST", "/admin/tournaments/"+get(tournament, "id")+"/cancel", admin, nil, http.StatusOK)
+	a.call("GET", api+"/tournaments", "", nil, http.StatusOK)
+
+	// Clans
+	a.call("POST", api+"/clans", tokens["alice"], map[string]interface{}{"username": "alice", "name": "Alice's Army", "tag": "ALC"}, http.StatusCreated)
+	a.call("POST", api+"/clans/ALC/invites", tokens["alice"], map[string]string{"username": "alice", "member": "bob"}, http.StatusCreated)
+	a.call("POST", api+"/clans/ALC/join", tokens["bob"], map[string]string{"username": "bob"}, http.StatusOK)
+	a.call("PUT", api+"/clans/ALC/members/bob/role", tokens["alice"], map[string]string{"username": "alice", "role": "officer"}, http.StatusOK)
+	a.call("POST", api+"/clans/ALC/invites", tokens["bob"], map[string]string{"username": "bob", "member": "carol"}, http.StatusCreated)
+	a.call("GET", api+"/users/carol/clan-invites", "", nil, http.StatusOK)
+	a.call("POST", api+"/clans/ALC/invites/decline", tokens["carol"], map[string]string{"username": "carol"}, http.StatusOK)
+	a.call("POST", api+"/clans/ALC/invites", tokens["alice"], map[string]string{"username": "alice", "member": "dave"}, http.StatusCreated)
+	a.call("POST", api+"/clans/ALC/join", tokens["dave"], map[string]string{"username": "dave"}, http.StatusOK)
+	a.call("POST", api+"/clans/ALC/kick", tokens["alice"], map[string]string{"username": "alice", "member": "dave"}, http.StatusOK)
+	a.call("GET", api+"/clans/ALC", "", nil, http.StatusOK)
+
+	a.call("POST", api+"/clans", tokens["erin"], map[string]interface{}{"username": "erin", "name": "Erin's Engines", "tag": "ERN", "open": true}, http.StatusCreated)
+	a.call("POST", api+"/clans", tokens["grace"], map[string]interface{}{"username": "grace", "name": "Grace's Guard", "tag": "GRC"}, http.StatusCreated)
+	a.call("POST", api+"/clans/ERN/join", tokens["carol"], map[string]string{"username": "carol"}, http.StatusOK)
+	a.call("POST", api+"/clans/ERN/leave", tokens["carol"], map[string]string{"username": "carol"}, http.StatusOK)
+	war := a.call("POST", api+"/clans/ALC/wars", tokens["alice"], map[string]interface{}{"username": "alice", "opponent": "ERN", "duration_hours": 24}, http.StatusCreated)
+	a.call("POST", api+"/clan-wars/"+get(war, "id")+"/accept", tokens["erin"], map[string]string{"username": "erin"}, http.StatusOK)
+	a.call("GET", api+"/clan-wars/"+get(war, "id"), "", nil, http.StatusOK)
+	war = a.call("POST", api+"/clans/ALC/wars", tokens["bob"], map[string]string{"username": "bob", "opponent": "GRC"}, http.StatusCreated)
+	a.call("POST", api+"/clan-wars/"+get(war, "id")+"/decline", tokens["grace"], map[string]string{"username": "grace"}, http.StatusOK)
+	a.call("GET", api+"/clans/ALC/wars", "", nil, http.StatusOK)
+	a.call("GET", api+"/clans/leaderboard", "", nil, http.StatusOK)
+	a.call("GET", api+"/clans/leaderboard?season=current", "", nil, http.StatusOK)
+
+	// Account
+	a.call("GET", api+"/users/alice/data-export", tokens["alice"], nil, http.StatusOK)
+	a.call("POST", api+"/users/grace/deletion", tokens["grace"], nil, http.StatusAccepted)
+	a.call("DELETE", api+"/users/grace/deletion", tokens["grace"], nil, http.StatusOK)
+
+	// Admin
+	reason := map[string]string{"reason": "testing"}
+	a.call("GET", "/admin/games/export?format=ndjson&gzip=true", admin, nil, http.StatusOK)
+	a.call("GET", "/admin/users?q=a&limit=5", admin, nil, http.StatusOK)
+	a.call("GET", "/admin/users/alice?limit=5", admin, nil, http.StatusOK)
+	a.call("POST", "/admin/users/alice/reset-streak", admin, reason, http.StatusOK)
+	a.call("POST", "/admin/users/frank/ban", admin, reason, http.StatusOK)
+	a.call("POST", "/admin/users/frank/reinstate", admin, reason, http.StatusOK)
+	a.call("POST", "/admin/users/frank/suspend", admin, map[string]string{"reason": "testing", "until": time.Now().Add(time.Hour).UTC().Format(time.RFC3339)}, http.StatusOK)
+	a.call("PUT", "/admin/users/frank/username", admin, map[string]string{"username": "franklin", "reason": "testing"}, http.StatusOK)
+	a.call("DELETE", "/admin/users/franklin?reason=testing", admin, nil, http.StatusOK)
+	tokens["alice"] = get(a.call("POST", "/admin/users/alice/token", admin, reason, http.StatusOK), "account_token")
+	a.call("GET", "/admin/actions?limit=5", admin, nil, http.StatusOK)
+
+	// Errors are documented too
+	a.call("GET", api+"/users/nobody", "", nil, http.StatusNotFound)
+	a.call("GET", "/admin/actions", "wrong-token", nil, http.StatusUnauthorized)
+	a.call("POST", api+"/shop/equip", "", map[string]string{"item_id": "avatar-robot"}, http.StatusUnauthorized)
+	a.call("POST", api+"/shop/equip", tokens["bob"], map[string]string{"username": "alice", "item_id": "avatar-robot"}, http.StatusForbidden)
+	a.call("POST", api+"/users/alice/daily-reward", tokens["bob"], nil, http.StatusUnauthorized)
+
+	a.call("DELETE", api+"/users/alice/friends/bob", tokens["alice"], nil, http.StatusOK)
+
+	// Web
+	a.call("GET", api+"/openapi.json", "", nil, http.StatusOK)
+	a.call("GET", "/openapi.json", "", nil, http.StatusOK)
+	a.call("GET", "/docs", "", nil, http.StatusOK)
+	a.call("GET", "/static/favicon.ico", "", nil, http.StatusOK)
+	a.call("GET", "/", "", nil, http.StatusOK)
+}
+
+func TestAPIVersioning(t *testing.T) {
+	a := setupAPITest(t)
+	a.call("POST", "/api/v1/users", "", map[string]string{"username": "alice"}, http.StatusCreated)
+	play, _ := json.Marshal(map[string]string{"username": "alice", "player_choice": "rock"})
+
+	send := func(target, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", target, bytes.NewReader(play))
+		req.Header.Set("Content-Type", "application/json")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		a.router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Success - The unversioned path is a deprecated alias of v1", func(t *testing.T) {
+		w := send("/api/play", "")
+		if w.Code != http.StatusOK || w.Header().Get(middleware.VersionHeader) != "1" {
+			t.Fatalf("Expected version 1, got %d with API-Version %q", w.Code, w.Header().Get(middleware.VersionHeader))
+		}
+		if !strings.HasPrefix(w.Header().Get("Deprecation"), "@") || w.Header().Get("Sunset") == "" {
+			t.Errorf("Expected Deprecation and Sunset headers, got %v", w.Header())
+		}
+		if link := w.Header().Get("Link"); link != `</api/v1/play>; rel="successor-version"` {
+			t.Errorf("Expected a link to /api/v1/play, got %q", link)
+		}
+		if get(decode(w.Body.Bytes()), "player_choice") != "rock" {
+			t.Errorf("Expected a version 1 game result, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Success - The Accept header picks a version", func(t *testing.T) {
+		w := send("/api/play", "application/vnd.rockpaperscissors.v2+json")
+		if w.Code != http.StatusOK || w.Header().Get(middleware.VersionHeader) != "2" {
+			t.Fatalf("Expected version 2, got %d with API-Version %q", w.Code, w.Header().Get(middleware.VersionHeader))
+		}
+		if w.Header().Get("Deprecation") != "" {
+			t.Errorf("Expected no Deprecation header when a version is asked for")
+		}
+		if get(decode(w.Body.Bytes()), "game", "player_choice") != "rock" {
+			t.Errorf("Expected a version 2 game result, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Success - Versioned paths are not deprecated", func(t *testing.T) {
+		w := send("/api/v2/play", "application/json")
+		if w.Code != http.StatusOK || w.Header().Get("Deprecation") != "" {
+			t.Errorf("Expected version 2 without a Deprecation header, got %d: %v", w.Code, w.Header())
+		}
+	})
+
+	t.Run("Error - Unsupported versions are not acceptable", func(t *testing.T) {
+		if w := send("/api/play", "application/vnd.rockpaperscissors.v3+json"); w.Code != http.StatusNotAcceptable {
+			t.Errorf("Expected 406 for version 3, got %d", w.Code)
+		}
+		if w := send("/api/v2/play", "application/vnd.rockpaperscissors.v1+json"); w.Code != http.StatusNotAcceptable {
+			t.Errorf("Expected 406 for version 1 under /api/v2, got %d", w.Code)
+		}
+	})
+	t.Run("Error - Admin routes are not versioned", func(t *testing.T) {
+		for _, target := range []string{"/api/v1/admin/actions", "/api/v2/admin/actions", "/api/admin/actions"} {
+			req := httptest.NewRequest("GET", target, nil)
+			req.Header.Set("Authorization", "Bearer "+testAdminToken)
+			w := httptest.NewRecorder()
+			a.router.ServeHTTP(w, req)
+			if w.Code != http.StatusNotFound {
+				t.Errorf("Expected 404 for %s, got %d", target, w.Code)
+			}
+		}
+	})
+}
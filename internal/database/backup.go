@@ -0,0 +1,270 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	// backupStepPages is how many pages an online backup copies at a time;
+	// the source is only locked while a step runs, so the server keeps
+	// writing in between
+	backupStepPages = 1024
+	backupStepPause = 10 * time.Millisecond
+
+	// backupMaxRestarts is how often a backup may start over because the
+	// server wrote to the database mid-copy before it copies the rest in
+	// one step
+	backupMaxRestarts = 3
+
+	// integrityProblems is how many problems an integrity check reports
+	integrityProblems = 5
+)
+
+// Backup copies the database behind db to dest with the SQLite online backup
+// API, so it is safe while the server keeps using db. The copy is checked for
+// integrity and only then moved into place; an existing dest is replaced.
+func Backup(db *sql.DB, dest string) error {
+	tmp := dest + ".tmp"
+	removeDatabaseFiles(tmp)
+	defer removeDatabaseFiles(tmp)
+
+	if err := copyDatabase(db, tmp); err != nil {
+		return fmt.Errorf("failed to back up database: %v", err)
+	}
+	// a copy of a database in WAL mode is in WAL mode too; a backup should be
+	// a single self-contained file
+	if err := setJournalMode(tmp, "DELETE"); err != nil {
+		return fmt.Errorf("failed to finish backup: %v", err)
+	}
+	if err := CheckIntegrity(tmp); err != nil {
+		return fmt.Errorf("backup failed its integrity check: %v", err)
+	}
+	if err := syncFile(tmp); err != nil {
+		return fmt.Errorf("failed to sync backup: %v", err)
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		return fmt.Errorf("failed to move backup into place: %v", err)
+	}
+	return nil
+}
+
+// copyDatabase copies the main database of src into a new file at dest
+func copyDatabase(src *sql.DB, dest string) error {
+	destDB, err := sql.Open("sqlite3", dest)
+	if err != nil {
+		return err
+	}
+	defer destDB.Close()
+
+	ctx := context.Background()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+	destConn, err := destDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+
+	return destConn.Raw(func(destDriverConn interface{}) error {
+		return srcConn.Raw(func(srcDriverConn interface{}) error {
+			destSQLite, ok := destDriverConn.(*sqlite3.SQLiteConn)
+			srcSQLite, ok2 := srcDriverConn.(*sqlite3.SQLiteConn)
+			if !ok || !ok2 {
+				return fmt.Errorf("not a SQLite connection")
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+			remaining, restarts := -1, 0
+			for {
+				pages := backupStepPages
+				if restarts >= backupMaxRestarts {
+					pages = -1
+				}
+				done, err := backup.Step(pages)
+				if err != nil {
+					backup.Close()
+					return err
+				}
+				if done {
+					return backup.Finish()
+				}
+				// a write to the source by another connection makes SQLite
+				// start the copy over
+				if remaining >= 0 && backup.Remaining() > remaining {
+					restarts++
+				}
+				remaining = backup.Remaining()
+				time.Sleep(backupStepPause)
+			}
+		})
+	})
+}
+
+// CheckIntegrity runs SQLite's integrity check on the database file at path
+func CheckIntegrity(path string) error {
+	// opening a missing file would create an empty database that passes
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(fmt.Sprintf("PRAGMA integrity_check(%d)", integrityProblems))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var problem string
+		if err := rows.Scan(&problem); err != nil {
+			return err
+		}
+		if problem != "ok" {
+			problems = append(problems, problem)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s is corrupt: %s", path, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Restore replaces the database file at dest with the backup at src, after
+// checking the backup's integrity. Nothing may have dest open: the server
+// has to be stopped first.
+func Restore(src, dest string) error {
+	if err := CheckIntegrity(src); err != nil {
+		return fmt.Errorf("cannot restore backup: %v", err)
+	}
+
+	tmp := dest + ".restore.tmp"
+	defer os.Remove(tmp)
+	if err := copyFile(src, tmp); err != nil {
+		return fmt.Errorf("failed to copy backup: %v", err)
+	}
+	return replaceDatabase(tmp, dest)
+}
+
+// replaceDatabase moves the database file at src over dest. The write-ahead
+// log of dest goes first: SQLite would otherwise replay it over src.
+func replaceDatabase(src, dest string) error {
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Remove(dest + suffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %v", dest+suffix, err)
+		}
+	}
+	if err := os.Rename(src, dest); err != nil {
+		return fmt.Errorf("failed to move restored database into place: %v", err)
+	}
+	return syncDir(filepath.Dir(dest))
+}
+
+// setJournalMode switches the database file at path to a journal mode, which
+// also checkpoints and removes its write-ahead log when leaving WAL mode
+func setJournalMode(path, mode string) error {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var got string
+	if err := db.QueryRow("PRAGMA journal_mode = " + mode).Scan(&got); err != nil {
+		return err
+	}
+	if !strings.EqualFold(got, mode) {
+		return fmt.Errorf("journal mode is %s, not %s", got, mode)
+	}
+	return nil
+}
+
+// removeDatabaseFiles removes a database file along with its journals
+func removeDatabaseFiles(path string) {
+	for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+		os.Remove(path + suffix)
+	}
+}
+
+// copyFile copies src to a new file at dest and syncs it to disk
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// writeFileSync writes data to path through a temporary file, so path either
+// holds all of data or does not exist
+func writeFileSync(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := syncFile(tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func syncFile(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir makes a rename in dir durable; not every platform can sync a
+// directory, so that is not an error
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	f.Sync()
+	return nil
+}
@@ -0,0 +1,222 @@
+package database
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupBackupTestDB opens a migrated database file in a temporary directory
+func setupBackupTestDB(t *testing.T) (*sql.DB, string) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := RunMigrations(db.DB); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	return db.DB, path
+}
+
+func insertBackupTestUser(t *testing.T, db *sql.DB, username string) {
+	if _, err := db.Exec(`INSERT INTO users (username) VALUES (?)`, username); err != nil {
+		t.Fatalf("Failed to insert user: %v", err)
+	}
+}
+
+// countUsers opens the database file at path and counts its users
+func countUsers(t *testing.T, path string) int {
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer db.Close()
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		t.Fatalf("Failed to count users: %v", err)
+	}
+	return count
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	db, path := setupBackupTestDB(t)
+	insertBackupTestUser(t, db, "alice")
+	insertBackupTestUser(t, db, "bob")
+
+	backup := filepath.Join(t.TempDir(), "backup.db")
+	if err := Backup(db, backup); err != nil {
+		t.Fatalf("Failed to back up: %v", err)
+	}
+	if _, err := os.Stat(backup + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("Expected the temporary copy to be gone")
+	}
+	if err := CheckIntegrity(backup); err != nil {
+		t.Errorf("Expected the backup to pass its integrity check: %v", err)
+	}
+
+	insertBackupTestUser(t, db, "carol")
+	db.Close()
+
+	if err := Restore(backup, path); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if got := countUsers(t, path); got != 2 {
+		t.Errorf("Expected the 2 users in the backup, got %d", got)
+	}
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := CheckIntegrity(filepath.Join(dir, "missing.db")); err == nil {
+		t.Errorf("Expected a missing file to fail")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing.db")); !os.IsNotExist(err) {
+		t.Errorf("Expected checking a missing file not to create it")
+	}
+
+	garbage := filepath.Join(dir, "garbage.db")
+	if err := os.WriteFile(garbage, []byte(strings.Repeat("not a database", 512)), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := CheckIntegrity(garbage); err == nil {
+		t.Errorf("Expected a file that is not a database to fail")
+	}
+
+	// a valid backup must not be replaced by a broken one
+	_, path := setupBackupTestDB(t)
+	if err := Restore(garbage, path); err == nil {
+		t.Errorf("Expected restoring a broken backup to fail")
+	}
+	if err := CheckIntegrity(path); err != nil {
+		t.Errorf("Expected the database to be left alone: %v", err)
+	}
+}
+
+func TestWALArchiver_RestoreToPointInTime(t *testing.T) {
+	db, path := setupBackupTestDB(t)
+	archiveDir := filepath.Join(t.TempDir(), "wal")
+
+	archiver, err := NewWALArchiver(db, archiveDir)
+	if err != nil {
+		t.Fatalf("Failed to create archiver: %v", err)
+	}
+	defer archiver.Close()
+
+	clock := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	archiver.now = func() time.Time { return clock }
+	sync := func() {
+		t.Helper()
+		if err := archiver.Sync(); err != nil {
+			t.Fatalf("Failed to sync archive: %v", err)
+		}
+	}
+
+	// one user before the generation starts, then one per minute
+	insertBackupTestUser(t, db, "user0")
+	sync()
+	first := archiver.Generation()
+	for i := 1; i <= 3; i++ {
+		clock = clock.Add(time.Minute)
+		insertBackupTestUser(t, db, "user"+string(rune('0'+i)))
+		sync()
+	}
+	if archiver.Generation() != first {
+		t.Errorf("Expected one generation, got %s and %s", first, archiver.Generation())
+	}
+
+	// a log past its size limit starts a new generation
+	archiver.MaxWALSize = 1
+	clock = clock.Add(time.Minute)
+	insertBackupTestUser(t, db, "user4")
+	sync()
+	second := archiver.Generation()
+	if second == first {
+		t.Errorf("Expected a new generation once the log was too big")
+	}
+	archiver.MaxWALSize = DefaultMaxWALSize
+	clock = clock.Add(time.Minute)
+	insertBackupTestUser(t, db, "user5")
+	sync()
+
+	// nothing is archived while nothing is committed
+	segments, _ := listSegments(filepath.Join(archiveDir, second))
+	clock = clock.Add(time.Minute)
+	sync()
+	if idle, _ := listSegments(filepath.Join(archiveDir, second)); len(idle) != len(segments) {
+		t.Errorf("Expected an idle sync to archive nothing, got %d segments after %d", len(idle), len(segments))
+	}
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		at    time.Time
+		users int
+	}{
+		{start, 1},
+		{start.Add(90 * time.Second), 2},
+		{start.Add(3 * time.Minute), 4},
+		{start.Add(4 * time.Minute), 5},
+		{start.Add(time.Hour), 6},
+	}
+	for _, tt := range tests {
+		dest := filepath.Join(t.TempDir(), "restored.db")
+		restoredTo, err := RestoreWALArchive(archiveDir, tt.at, dest)
+		if err != nil {
+			t.Fatalf("Failed to restore to %v: %v", tt.at, err)
+		}
+		if restoredTo.After(tt.at) {
+			t.Errorf("Expected a restore to %v not to go past it, got %v", tt.at, restoredTo)
+		}
+		if got := countUsers(t, dest); got != tt.users {
+			t.Errorf("Expected %d users at %v, got %d", tt.users, tt.at, got)
+		}
+	}
+
+	if _, err := RestoreWALArchive(archiveDir, start.Add(-time.Hour), filepath.Join(t.TempDir(), "x.db")); err == nil {
+		t.Errorf("Expected a restore to before the archive to fail")
+	}
+
+	// the first generation is still needed to restore to three minutes ago
+	if removed, err := archiver.Prune(3 * time.Minute); err != nil || len(removed) != 0 {
+		t.Errorf("Expected nothing to be pruned, got %v, %v", removed, err)
+	}
+	clock = clock.Add(time.Hour)
+	removed, err := archiver.Prune(time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to prune archive: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != first {
+		t.Errorf("Expected the first generation to be pruned, got %v", removed)
+	}
+
+	// the live database is untouched by all of this
+	db.Close()
+	if got := countUsers(t, path); got != 6 {
+		t.Errorf("Expected 6 users in the live database, got %d", got)
+	}
+}
+
+func TestParseWALHeader_RejectsCorruption(t *testing.T) {
+	db, path := setupBackupTestDB(t)
+	if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
+		t.Fatalf("Failed to switch to WAL mode: %v", err)
+	}
+	insertBackupTestUser(t, db, "alice")
+
+	raw, err := os.ReadFile(path + "-wal")
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	if _, err := parseWALHeader(raw); err != nil {
+		t.Fatalf("Expected SQLite's header to parse: %v", err)
+	}
+	raw[20] ^= 0xff
+	if _, err := parseWALHeader(raw); err == nil {
+		t.Errorf("Expected a damaged header to be rejected")
+	}
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"database/sql"
+	"io"
+	"sync"
+)
+
+// DB is an open database along with what the server keeps in memory about
+// it, such as the leaderboard. Whatever is kept goes when the database is
+// closed, rather than outliving it.
+type DB struct {
+	*sql.DB
+
+	mu   sync.Mutex
+	kept map[interface{}]interface{}
+}
+
+// Wrap returns db with nothing kept for it yet. Closing the result closes db.
+func Wrap(db *sql.DB) *DB {
+	return &DB{DB: db, kept: make(map[interface{}]interface{})}
+}
+
+// Keep returns what is kept for the database under key, creating it with
+// create the first time, so every service built on the database shares it
+func (db *DB) Keep(key interface{}, create func() interface{}) interface{} {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	value, ok := db.kept[key]
+	if !ok {
+		value = create()
+		db.kept[key] = value
+	}
+	return value
+}
+
+// Close drops everything kept for the database, closing whatever is an
+// io.Closer, and then closes the database itself
+func (db *DB) Close() error {
+	db.mu.Lock()
+	kept := db.kept
+	db.kept = make(map[interface{}]interface{})
+	db.mu.Unlock()
+
+	for _, value := range kept {
+		if closer, ok := value.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+	return db.DB.Close()
+}
@@ -3,42 +3,201 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-// InitDB initializes the SQLite database connection
-func InitDB() (*sql.DB, error) {
+// DefaultPath is the database file the server uses, relative to its
+// working directory
+var DefaultPath = filepath.Join("data", "rockpaperscissors.db")
+
+// Config says how connections to the database are set up. Its pragmas are
+// passed in the DSN, so the driver applies them to every connection the pool
+// opens rather than only to whichever one happened to run them.
+type Config struct {
+	Path        string
+	JournalMode string        // WAL lets readers carry on while a game is settled
+	Synchronous string        // NORMAL only risks the latest commits on power loss in WAL mode
+	BusyTimeout time.Duration // how long a statement waits on another connection's lock
+	// TxLock is how transactions begin. Settlement transactions read and then
+	// write, so with "deferred" two of them can deadlock on the lock upgrade
+	// and one fails at once; "immediate" queues them on the busy timeout.
+	TxLock string
+
+	MaxOpenConns    int // 0 is unlimited
+	MaxIdleConns    int
+	ConnMaxIdleTime time.Duration // 0 keeps idle connections forever
+}
+
+// DefaultConfig is the connection setup used for whatever the environment
+// does not set
+func DefaultConfig(path string) Config {
+	return Config{
+		Path:            path,
+		JournalMode:     "WAL",
+		Synchronous:     "NORMAL",
+		BusyTimeout:     5 * time.Second,
+		TxLock:          "immediate",
+		MaxOpenConns:    8,
+		MaxIdleConns:    8,
+		ConnMaxIdleTime: 5 * time.Minute,
+	}
+}
+
+var (
+	journalModes = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+	syncModes    = []string{"OFF", "NORMAL", "FULL", "EXTRA"}
+	txLocks      = []string{"deferred", "immediate", "exclusive"}
+)
+
+// LoadConfig reads the connection setup for the database at path from
+// DB_JOURNAL_MODE, DB_SYNCHRONOUS, DB_BUSY_TIMEOUT, DB_TXLOCK,
+// DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS and DB_CONN_MAX_IDLE_TIME
+func LoadConfig(path string) (Config, error) {
+	config := DefaultConfig(path)
+
+	modes := []struct {
+		name    string
+		value   *string
+		allowed []string
+	}{
+		{"DB_JOURNAL_MODE", &config.JournalMode, journalModes},
+		{"DB_SYNCHRONOUS", &config.Synchronous, syncModes},
+		{"DB_TXLOCK", &config.TxLock, txLocks},
+	}
+	for _, m := range modes {
+		raw := os.Getenv(m.name)
+		if raw == "" {
+			continue
+		}
+		value, ok := oneOf(raw, m.allowed)
+		if !ok {
+			return config, fmt.Errorf("invalid %s %q: must be one of %s", m.name, raw, strings.Join(m.allowed, ", "))
+		}
+		*m.value = value
+	}
+
+	durations := []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"DB_BUSY_TIMEOUT", &config.BusyTimeout},
+		{"DB_CONN_MAX_IDLE_TIME", &config.ConnMaxIdleTime},
+	}
+	for _, d := range durations {
+		raw := os.Getenv(d.name)
+		if raw == "" {
+			continue
+		}
+		value, err := time.ParseDuration(raw)
+		if err != nil || value < 0 {
+			return config, fmt.Errorf("invalid %s %q", d.name, raw)
+		}
+		*d.value = value
+	}
+
+	counts := []struct {
+		name  string
+		value *int
+	}{
+		{"DB_MAX_OPEN_CONNS", &config.MaxOpenConns},
+		{"DB_MAX_IDLE_CONNS", &config.MaxIdleConns},
+	}
+	for _, c := range counts {
+		raw := os.Getenv(c.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			return config, fmt.Errorf("invalid %s %q", c.name, raw)
+		}
+		*c.value = value
+	}
+	return config, nil
+}
+
+// oneOf returns the allowed value raw names, ignoring case
+func oneOf(raw string, allowed []string) (string, bool) {
+	for _, value := range allowed {
+		if strings.EqualFold(raw, value) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// DSN returns the data source name that opens the database with this setup.
+// Foreign keys are always enforced.
+func (c Config) DSN() string {
+	params := url.Values{}
+	params.Set("_foreign_keys", "1")
+	if c.JournalMode != "" {
+		params.Set("_journal_mode", c.JournalMode)
+	}
+	if c.Synchronous != "" {
+		params.Set("_synchronous", c.Synchronous)
+	}
+	if c.BusyTimeout > 0 {
+		params.Set("_busy_timeout", strconv.FormatInt(c.BusyTimeout.Milliseconds(), 10))
+	}
+	if c.TxLock != "" {
+		params.Set("_txlock", c.TxLock)
+	}
+	return c.Path + "?" + params.Encode()
+}
+
+// InitDB initializes the SQLite database connection, set up from the
+// environment
+func InitDB() (*DB, error) {
 	// Ensure data directory exists
-	dataDir := "data"
-	if err := os.MkdirAll(dataDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(DefaultPath), 0755); err != nil {
 		return nil, fmt.Errorf("failed to create data directory: %v", err)
 	}
 
-	// Database file path
-	dbPath := filepath.Join(dataDir, "rockpaperscissors.db")
+	config, err := LoadConfig(DefaultPath)
+	if err != nil {
+		return nil, err
+	}
+	return OpenWithConfig(config)
+}
+
+// Open connects to the SQLite database at dbPath with the default setup,
+// creating the file if it does not exist yet
+func Open(dbPath string) (*DB, error) {
+	return OpenWithConfig(DefaultConfig(dbPath))
+}
+
+// OpenWithConfig connects to the SQLite database at config.Path, creating
+// the file if it does not exist yet
+func OpenWithConfig(config Config) (*DB, error) {
+	// the driver takes everything after the first ? as parameters
+	if strings.Contains(config.Path, "?") {
+		return nil, fmt.Errorf("invalid database path %q", config.Path)
+	}
 
 	// Open database connection
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sql.Open("sqlite3", config.DSN())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %v", err)
 	}
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(config.MaxIdleConns)
+	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
 
-	// Test connection
+	// Test connection; this is also where a broken pragma shows up
 	if err := db.Ping(); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %v", err)
 	}
 
-	// Enable foreign key constraints
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to enable foreign keys: %v", err)
-	}
-
-	return db, nil
+	return Wrap(db), nil
 }
 
 // RunMigrations executes database migrations
@@ -70,22 +229,537 @@ func RunMigrations(db *sql.DB) error {
 		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 	);`
 
+	// Create coin ledger table; every balance change is an immutable entry and
+	// users.total_coins must always equal the sum of a user's entries
+	coinTransactionsTable := `
+	CREATE TABLE IF NOT EXISTS coin_transactions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		type TEXT NOT NULL, -- 'game_reward', 'wager', 'purchase', 'admin_adjustment', 'daily_bonus', 'opening_balance'
+		amount INTEGER NOT NULL,
+		balance_after INTEGER NOT NULL,
+		counter_account TEXT NOT NULL,
+		reference TEXT NOT NULL DEFAULT '',
+		reason TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`
+
+	// Ledger entries may be removed along with their user but never edited
+	coinTransactionsImmutable := `
+	CREATE TRIGGER IF NOT EXISTS trg_coin_transactions_immutable
+	BEFORE UPDATE ON coin_transactions
+	BEGIN
+		SELECT RAISE(ABORT, 'coin transactions are immutable');
+	END;`
+
+	// Create the system side of the ledger: every coin_transactions row has
+	// an entry here moving the same coins the other way, from or to the
+	// counter account, so each transaction sums to zero
+	systemLedgerEntriesTable := `
+	CREATE TABLE IF NOT EXISTS system_ledger_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		transaction_id INTEGER NOT NULL,
+		account TEXT NOT NULL, -- 'system:rewards', 'system:shop', ...
+		amount INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (transaction_id) REFERENCES coin_transactions(id) ON DELETE CASCADE
+	);`
+
+	systemLedgerEntriesImmutable := `
+	CREATE TRIGGER IF NOT EXISTS trg_system_ledger_entries_immutable
+	BEFORE UPDATE ON system_ledger_entries
+	BEGIN
+		SELECT RAISE(ABORT, 'ledger entries are immutable');
+	END;`
+
+	// Create inventory table for purchased cosmetics; item_id refers to the
+	// shop catalog data file and slot is copied from it when bought
+	inventoryTable := `
+	CREATE TABLE IF NOT EXISTS inventory (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		item_id TEXT NOT NULL,
+		slot TEXT NOT NULL, -- 'avatar', 'hand_skin', 'victory_animation', 'name_color'
+		equipped INTEGER NOT NULL DEFAULT 0,
+		purchased_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, item_id),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`
+
+	// Create achievement unlocks table; achievement_id refers to the rule
+	// definitions in the achievement service
+	userAchievementsTable := `
+	CREATE TABLE IF NOT EXISTS user_achievements (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		achievement_id TEXT NOT NULL,
+		game_id INTEGER,
+		unlocked_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, achievement_id),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+		FOREIGN KEY (game_id) REFERENCES games(id) ON DELETE SET NULL
+	);`
+
+	// Create daily login reward claims; day is the user's local calendar date
+	dailyRewardsTable := `
+	CREATE TABLE IF NOT EXISTS daily_rewards (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		day TEXT NOT NULL, -- 'YYYY-MM-DD' in the user's timezone
+		streak_day INTEGER NOT NULL,
+		coins INTEGER NOT NULL,
+		claimed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, day),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`
+
+	// Create daily challenge reward claims; challenge_id embeds the day
+	dailyChallengeClaimsTable := `
+	CREATE TABLE IF NOT EXISTS daily_challenge_claims (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		challenge_id TEXT NOT NULL,
+		coins INTEGER NOT NULL,
+		claimed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, challenge_id),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`
+
+	// Create seasons table; id is the season number from the season calendar
+	seasonsTable := `
+	CREATE TABLE IF NOT EXISTS seasons (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		starts_at DATETIME NOT NULL,
+		ends_at DATETIME NOT NULL,
+		status TEXT NOT NULL DEFAULT 'active', -- 'active', 'archived'
+		archived_at DATETIME
+	);`
+
+	// Create per-season tallies, updated as games are settled
+	seasonStatsTable := `
+	CREATE TABLE IF NOT EXISTS season_stats (
+		season_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		coins INTEGER NOT NULL DEFAULT 0,
+		games_played INTEGER NOT NULL DEFAULT 0,
+		games_won INTEGER NOT NULL DEFAULT 0,
+		rating INTEGER NOT NULL DEFAULT 1000,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (season_id, user_id),
+		FOREIGN KEY (season_id) REFERENCES seasons(id),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`
+
+	// Create final standings, written once when a season is archived
+	seasonResultsTable := `
+	CREATE TABLE IF NOT EXISTS season_results (
+		season_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		rank INTEGER NOT NULL,
+		coins INTEGER NOT NULL,
+		games_played INTEGER NOT NULL,
+		games_won INTEGER NOT NULL,
+		rating INTEGER NOT NULL,
+		badge TEXT NOT NULL DEFAULT '',
+		reward_coins INTEGER NOT NULL DEFAULT 0,
+		archived_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (season_id, user_id),
+		FOREIGN KEY (season_id) REFERENCES seasons(id),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`
+
+	// Create friendships; one row per pair, requester_id is whoever asked
+	friendshipsTable := `
+	CREATE TABLE IF NOT EXISTS friendships (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		requester_id INTEGER NOT NULL,
+		addressee_id INTEGER NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending', -- 'pending', 'accepted'
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		accepted_at DATETIME,
+		UNIQUE (requester_id, addressee_id),
+		CHECK (requester_id != addressee_id),
+		FOREIGN KEY (requester_id) REFERENCES users(id) ON DELETE CASCADE,
+		FOREIGN KEY (addressee_id) REFERENCES users(id) ON DELETE CASCADE
+	);`
+
+	// Create direct challenges between two players
+	challengesTable := `
+	CREATE TABLE IF NOT EXISTS challenges (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		challenger_id INTEGER NOT NULL,
+		opponent_id INTEGER NOT NULL,
+		best_of INTEGER NOT NULL DEFAULT 1,
+		stake INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL DEFAULT 'pending', -- 'pending', 'accepted', 'declined', 'cancelled', 'expired', 'completed', 'forfeited'
+		challenger_wins INTEGER NOT NULL DEFAULT 0,
+		opponent_wins INTEGER NOT NULL DEFAULT 0,
+		winner_id INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		expires_at DATETIME NOT NULL,
+		responded_at DATETIME,
+		completed_at DATETIME,
+		FOREIGN KEY (challenger_id) REFERENCES users(id) ON DELETE CASCADE,
+		FOREIGN KEY (opponent_id) REFERENCES users(id) ON DELETE CASCADE,
+		FOREIGN KEY (winner_id) REFERENCES users(id) ON DELETE SET NULL
+	);`
+
+	// Create challenge rounds; a round resolves once both choices are in
+	challengeRoundsTable := `
+	CREATE TABLE IF NOT EXISTS challenge_rounds (
+		challenge_id INTEGER NOT NULL,
+		round INTEGER NOT NULL,
+		challenger_choice TEXT,
+		opponent_choice TEXT,
+		winner TEXT, -- 'challenger', 'opponent', 'tie'
+		resolved_at DATETIME,
+		PRIMARY KEY (challenge_id, round),
+		FOREIGN KEY (challenge_id) REFERENCES challenges(id) ON DELETE CASCADE
+	);`
+
+	// Create win streaks; a streak starts with a win, ties keep it going and
+	// a loss ends it. end_game_id is the last win of the streak.
+	streaksTable := `
+	CREATE TABLE IF NOT EXISTS streaks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		start_game_id INTEGER,
+		end_game_id INTEGER,
+		length INTEGER NOT NULL DEFAULT 0,
+		coins_earned INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL DEFAULT 'active', -- 'active', 'ended'
+		started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		ended_at DATETIME,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+		FOREIGN KEY (start_game_id) REFERENCES games(id) ON DELETE SET NULL,
+		FOREIGN KEY (end_game_id) REFERENCES games(id) ON DELETE SET NULL
+	);`
+
+	// Create the audit log of admin changes; username is kept so entries
+	// still make sense after the account is deleted
+	adminActionsTable := `
+	CREATE TABLE IF NOT EXISTS admin_actions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		actor TEXT NOT NULL,
+		action TEXT NOT NULL,
+		user_id INTEGER,
+		username TEXT NOT NULL,
+		details TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+	);`
+
+	// Create tournaments; prize_split is a JSON array of percentages for 1st,
+	// 2nd and so on, and created_by is the admin who set it up
+	tournamentsTable := `
+	CREATE TABLE IF NOT EXISTS tournaments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		format TEXT NOT NULL, -- 'single_elimination', 'double_elimination', 'round_robin', 'swiss'
+		status TEXT NOT NULL DEFAULT 'registration', -- 'registration', 'in_progress', 'completed', 'cancelled'
+		seeding TEXT NOT NULL DEFAULT 'coins', -- 'coins', 'rating'
+		best_of INTEGER NOT NULL DEFAULT 1,
+		max_players INTEGER NOT NULL,
+		entry_fee INTEGER NOT NULL DEFAULT 0,
+		guaranteed_prize INTEGER NOT NULL DEFAULT 0,
+		prize_pool INTEGER NOT NULL DEFAULT 0,
+		prize_split TEXT NOT NULL,
+		swiss_rounds INTEGER NOT NULL DEFAULT 0,
+		move_timeout_minutes INTEGER NOT NULL,
+		current_round INTEGER NOT NULL DEFAULT 0,
+		winner_id INTEGER,
+		registration_closes_at DATETIME NOT NULL,
+		created_by TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		started_at DATETIME,
+		completed_at DATETIME,
+		FOREIGN KEY (winner_id) REFERENCES users(id) ON DELETE SET NULL
+	);`
+
+	// Create tournament registrations with each player's running record.
+	// elimination_rank orders knocked-out players: later is higher.
+	tournamentPlayersTable := `
+	CREATE TABLE IF NOT EXISTS tournament_players (
+		tournament_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		seed INTEGER NOT NULL DEFAULT 0,
+		points INTEGER NOT NULL DEFAULT 0,
+		match_wins INTEGER NOT NULL DEFAULT 0,
+		match_losses INTEGER NOT NULL DEFAULT 0,
+		game_wins INTEGER NOT NULL DEFAULT 0,
+		game_losses INTEGER NOT NULL DEFAULT 0,
+		had_bye INTEGER NOT NULL DEFAULT 0,
+		eliminated INTEGER NOT NULL DEFAULT 0,
+		elimination_rank INTEGER NOT NULL DEFAULT 0,
+		withdrawn INTEGER NOT NULL DEFAULT 0,
+		place INTEGER NOT NULL DEFAULT 0,
+		prize INTEGER NOT NULL DEFAULT 0,
+		registered_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (tournament_id, user_id),
+		FOREIGN KEY (tournament_id) REFERENCES tournaments(id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`
+
+	// Create tournament matches. A slot is ready once its player is known,
+	// which may be nobody; the winner and loser move on to the linked slots.
+	tournamentMatchesTable := `
+	CREATE TABLE IF NOT EXISTS tournament_matches (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		tournament_id INTEGER NOT NULL,
+		bracket TEXT NOT NULL, -- 'main', 'winners', 'losers', 'grand_final'
+		round INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		player1_id INTEGER,
+		player2_id INTEGER,
+		player1_ready INTEGER NOT NULL DEFAULT 0,
+		player2_ready INTEGER NOT NULL DEFAULT 0,
+		player1_wins INTEGER NOT NULL DEFAULT 0,
+		player2_wins INTEGER NOT NULL DEFAULT 0,
+		winner_id INTEGER,
+		status TEXT NOT NULL DEFAULT 'waiting', -- 'waiting', 'active', 'completed', 'forfeit', 'bye'
+		winner_next_match_id INTEGER,
+		winner_next_slot INTEGER,
+		loser_next_match_id INTEGER,
+		loser_next_slot INTEGER,
+		eliminates INTEGER NOT NULL DEFAULT 0,
+		deadline DATETIME,
+		completed_at DATETIME,
+		FOREIGN KEY (tournament_id) REFERENCES tournaments(id) ON DELETE CASCADE,
+		FOREIGN KEY (player1_id) REFERENCES users(id) ON DELETE SET NULL,
+		FOREIGN KEY (player2_id) REFERENCES users(id) ON DELETE SET NULL,
+		FOREIGN KEY (winner_id) REFERENCES users(id) ON DELETE SET NULL
+	);`
+
+	// Create the games of tournament matches; a game resolves once both
+	// choices are in
+	tournamentGamesTable := `
+	CREATE TABLE IF NOT EXISTS tournament_games (
+		match_id INTEGER NOT NULL,
+		game INTEGER NOT NULL,
+		player1_choice TEXT,
+		player2_choice TEXT,
+		winner TEXT, -- 'player1', 'player2', 'tie'
+		resolved_at DATETIME,
+		PRIMARY KEY (match_id, game),
+		FOREIGN KEY (match_id) REFERENCES tournament_matches(id) ON DELETE CASCADE
+	);`
+
+	// Create clans; tags are stored upper case
+	clansTable := `
+	CREATE TABLE IF NOT EXISTS clans (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		tag TEXT NOT NULL UNIQUE,
+		description TEXT NOT NULL DEFAULT '',
+		open INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	// Create clan memberships; a player belongs to at most one clan
+	clanMembersTable := `
+	CREATE TABLE IF NOT EXISTS clan_members (
+		user_id INTEGER PRIMARY KEY,
+		clan_id INTEGER NOT NULL,
+		role TEXT NOT NULL DEFAULT 'member', -- 'owner', 'officer', 'member'
+		joined_at DATETIME NOT NULL,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+		FOREIGN KEY (clan_id) REFERENCES clans(id) ON DELETE CASCADE
+	);`
+
+	// Create pending clan invitations
+	clanInvitesTable := `
+	CREATE TABLE IF NOT EXISTS clan_invites (
+		clan_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		invited_by INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (clan_id, user_id),
+		FOREIGN KEY (clan_id) REFERENCES clans(id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+		FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL
+	);`
+
+	// Create clan wars; scores are counted from the games table while a war
+	// runs and stored once it is over
+	clanWarsTable := `
+	CREATE TABLE IF NOT EXISTS clan_wars (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		clan_id INTEGER NOT NULL,
+		opponent_clan_id INTEGER NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending', -- 'pending', 'active', 'completed', 'declined'
+		duration_hours INTEGER NOT NULL,
+		clan_score INTEGER NOT NULL DEFAULT 0,
+		opponent_score INTEGER NOT NULL DEFAULT 0,
+		winner_clan_id INTEGER,
+		declared_by TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		starts_at DATETIME,
+		ends_at DATETIME,
+		CHECK (clan_id != opponent_clan_id),
+		FOREIGN KEY (clan_id) REFERENCES clans(id) ON DELETE CASCADE,
+		FOREIGN KEY (opponent_clan_id) REFERENCES clans(id) ON DELETE CASCADE,
+		FOREIGN KEY (winner_clan_id) REFERENCES clans(id) ON DELETE SET NULL
+	);`
+
+	// Create per-user totals of games pruned into an archive, so the counters
+	// on users can still be rebuilt from what is left in games. current_streak
+	// is the streak as of archived_through, the cutoff of the latest prune.
+	gameArchiveTotalsTable := `
+	CREATE TABLE IF NOT EXISTS game_archive_totals (
+		user_id INTEGER PRIMARY KEY,
+		games_played INTEGER NOT NULL DEFAULT 0,
+		games_won INTEGER NOT NULL DEFAULT 0,
+		current_streak INTEGER NOT NULL DEFAULT 0,
+		archived_through DATETIME NOT NULL,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`
+
+	// Columns added to existing tables after they were first created
+	columnMigrations := []struct {
+		table      string
+		column     string
+		definition string
+	}{
+		{"users", "timezone", "TEXT NOT NULL DEFAULT 'UTC'"},
+		{"users", "best_streak", "INTEGER NOT NULL DEFAULT 0"},
+		{"users", "status", "TEXT NOT NULL DEFAULT 'active'"}, // 'active', 'suspended', 'banned'
+		{"users", "suspended_until", "DATETIME"},
+		{"users", "moderation_reason", "TEXT NOT NULL DEFAULT ''"},
+		// case-insensitive and look-alike forms of the username, both unique;
+		// filled in by the user service, NULL until then
+		{"users", "username_normalized", "TEXT"},
+		{"users", "username_skeleton", "TEXT"},
+		// profile fields a player edits themselves
+		{"users", "display_name", "TEXT NOT NULL DEFAULT ''"},
+		{"users", "avatar_url", "TEXT NOT NULL DEFAULT ''"},
+		{"users", "country", "TEXT NOT NULL DEFAULT ''"},
+		{"users", "bio", "TEXT NOT NULL DEFAULT ''"},
+		// SHA-256 of the bearer token a player proves account ownership with
+		{"users", "account_token_hash", "TEXT"},
+		// set while a self-service deletion waits out its grace period
+		{"users", "deletion_scheduled_at", "DATETIME"},
+		// set when two users play each other; NULL means a game against the
+		// computer, so these rows go with the opponent rather than turn into one
+		{"games", "opponent_user_id", "INTEGER REFERENCES users(id) ON DELETE CASCADE"},
+		// the computer strategy a game against the computer was played
+		// against; games from before there was a choice were all random
+		{"games", "bot", "TEXT NOT NULL DEFAULT 'random'"},
+		// the local day of a challenge claim, the 'YYYY-MM-DD' prefix of its
+		// challenge_id
+		{"daily_challenge_claims", "day", "TEXT"},
+		// when the player last moved to another timezone, NULL if never
+		{"users", "timezone_changed_at", "DATETIME"},
+		// when the current round of an accepted challenge must be played by
+		{"challenges", "move_deadline", "DATETIME"},
+	}
+
 	// Create indexes for better performance
 	indexesSQL := []string{
 		"CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);",
-		"CREATE INDEX IF NOT EXISTS idx_games_user_id ON games(user_id);",
+		// idx_games_user_played_at replaces idx_games_user_id and serves keyset
+		// pagination of game history
+		"DROP INDEX IF EXISTS idx_games_user_id;",
+		"CREATE INDEX IF NOT EXISTS idx_games_user_played_at ON games(user_id, played_at, id);",
 		"CREATE INDEX IF NOT EXISTS idx_games_played_at ON games(played_at);",
 		"CREATE INDEX IF NOT EXISTS idx_users_total_coins ON users(total_coins);",
+		"CREATE INDEX IF NOT EXISTS idx_coin_transactions_user_id ON coin_transactions(user_id, id);",
+		"CREATE INDEX IF NOT EXISTS idx_system_ledger_entries_transaction_id ON system_ledger_entries(transaction_id);",
+		"CREATE INDEX IF NOT EXISTS idx_system_ledger_entries_account ON system_ledger_entries(account);",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_inventory_equipped_slot ON inventory(user_id, slot) WHERE equipped = 1;",
+		"CREATE INDEX IF NOT EXISTS idx_season_stats_ranking ON season_stats(season_id, coins DESC, games_won DESC);",
+		"CREATE INDEX IF NOT EXISTS idx_season_results_user_id ON season_results(user_id);",
+		"CREATE INDEX IF NOT EXISTS idx_friendships_addressee_id ON friendships(addressee_id, status);",
+		"CREATE INDEX IF NOT EXISTS idx_challenges_challenger_id ON challenges(challenger_id, status);",
+		"CREATE INDEX IF NOT EXISTS idx_challenges_opponent_id ON challenges(opponent_id, status);",
+		"CREATE INDEX IF NOT EXISTS idx_challenges_expires_at ON challenges(status, expires_at);",
+		"CREATE INDEX IF NOT EXISTS idx_challenges_move_deadline ON challenges(status, move_deadline);",
+		"CREATE INDEX IF NOT EXISTS idx_games_user_opponent ON games(user_id, opponent_user_id, played_at);",
+		"CREATE INDEX IF NOT EXISTS idx_daily_challenge_claims_day ON daily_challenge_claims(user_id, day);",
+		"CREATE INDEX IF NOT EXISTS idx_games_user_bot ON games(user_id, bot, id) WHERE opponent_user_id IS NULL;",
+		"CREATE INDEX IF NOT EXISTS idx_streaks_user_id ON streaks(user_id, id);",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_streaks_active ON streaks(user_id) WHERE status = 'active';",
+		"CREATE INDEX IF NOT EXISTS idx_users_best_streak ON users(best_streak);",
+		"CREATE INDEX IF NOT EXISTS idx_admin_actions_user_id ON admin_actions(user_id, id);",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_normalized ON users(username_normalized);",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_skeleton ON users(username_skeleton);",
+		"CREATE INDEX IF NOT EXISTS idx_users_country ON users(country, total_coins);",
+		"CREATE INDEX IF NOT EXISTS idx_clan_members_clan_id ON clan_members(clan_id);",
+		"CREATE INDEX IF NOT EXISTS idx_clan_invites_user_id ON clan_invites(user_id);",
+		"CREATE INDEX IF NOT EXISTS idx_clan_wars_clans ON clan_wars(clan_id, opponent_clan_id, status);",
+		"CREATE INDEX IF NOT EXISTS idx_tournaments_status ON tournaments(status, registration_closes_at);",
+		"CREATE INDEX IF NOT EXISTS idx_tournament_players_user_id ON tournament_players(user_id);",
+		"CREATE INDEX IF NOT EXISTS idx_tournament_matches_tournament ON tournament_matches(tournament_id, bracket, round, position);",
+		"CREATE INDEX IF NOT EXISTS idx_tournament_matches_deadline ON tournament_matches(status, deadline);",
+		"CREATE INDEX IF NOT EXISTS idx_users_deletion_scheduled_at ON users(deletion_scheduled_at) WHERE deletion_scheduled_at IS NOT NULL;",
+		"CREATE INDEX IF NOT EXISTS idx_users_account_token_hash ON users(account_token_hash) WHERE account_token_hash IS NOT NULL;",
+	}
+
+	// Data migrations run after the schema is in place and must be idempotent
+	dataMigrations := []string{
+		// Balances earned before the ledger existed become a single opening entry
+		`INSERT INTO coin_transactions (user_id, type, amount, balance_after, counter_account, reason)
+		SELECT u.id, 'opening_balance', u.total_coins, u.total_coins, 'system:opening', 'Balance carried over from before the ledger'
+		FROM users u
+		WHERE u.total_coins != 0
+		AND NOT EXISTS (SELECT 1 FROM coin_transactions t WHERE t.user_id = u.id);`,
+		// Challenge claims from before the day was stored
+		`UPDATE daily_challenge_claims SET day = substr(challenge_id, 1, 10) WHERE day IS NULL;`,
+		// Challenges accepted before rounds had a deadline get a day from
+		// when they were accepted
+		`UPDATE challenges SET move_deadline = datetime(COALESCE(responded_at, created_at), '+1 day')
+		WHERE status = 'accepted' AND move_deadline IS NULL;`,
+		// System side of ledger entries posted before it was written
+		`INSERT INTO system_ledger_entries (transaction_id, account, amount, created_at)
+		SELECT t.id, t.counter_account, -t.amount, t.created_at
+		FROM coin_transactions t
+		WHERE NOT EXISTS (SELECT 1 FROM system_ledger_entries s WHERE s.transaction_id = t.id);`,
 	}
 
 	// Execute migrations
-	migrations := []string{usersTable, gamesTable}
+	migrations := []string{
+		usersTable,
+		gamesTable,
+		coinTransactionsTable,
+		coinTransactionsImmutable,
+		systemLedgerEntriesTable,
+		systemLedgerEntriesImmutable,
+		inventoryTable,
+		userAchievementsTable,
+		dailyRewardsTable,
+		dailyChallengeClaimsTable,
+		seasonsTable,
+		seasonStatsTable,
+		seasonResultsTable,
+		friendshipsTable,
+		challengesTable,
+		challengeRoundsTable,
+		streaksTable,
+		adminActionsTable,
+		tournamentsTable,
+		tournamentPlayersTable,
+		tournamentMatchesTable,
+		tournamentGamesTable,
+		clansTable,
+		clanMembersTable,
+		clanInvitesTable,
+		clanWarsTable,
+		gameArchiveTotalsTable,
+	}
 	for _, migration := range migrations {
 		if _, err := db.Exec(migration); err != nil {
 			return fmt.Errorf("failed to execute migration: %v", err)
 		}
 	}
 
+	// Add new columns
+	for _, col := range columnMigrations {
+		if err := addColumnIfMissing(db, col.table, col.column, col.definition); err != nil {
+			return err
+		}
+	}
+
 	// Create indexes
 	for _, indexSQL := range indexesSQL {
 		if _, err := db.Exec(indexSQL); err != nil {
@@ -93,5 +767,43 @@ func RunMigrations(db *sql.DB) error {
 		}
 	}
 
+	// Backfill data
+	for _, dataSQL := range dataMigrations {
+		if _, err := db.Exec(dataSQL); err != nil {
+			return fmt.Errorf("failed to run data migration: %v", err)
+		}
+	}
+
 	return nil
-} 
\ No newline at end of file
+}
+
+// addColumnIfMissing adds a column to an existing table. SQLite has no
+// ADD COLUMN IF NOT EXISTS, so the table schema is checked first.
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to read schema of %s: %v", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return fmt.Errorf("failed to scan schema of %s: %v", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read schema of %s: %v", table, err)
+	}
+	rows.Close()
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %v", table, column, err)
+	}
+	return nil
+}
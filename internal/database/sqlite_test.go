@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("DB_SYNCHRONOUS", "full")
+	t.Setenv("DB_BUSY_TIMEOUT", "250ms")
+	t.Setenv("DB_MAX_OPEN_CONNS", "2")
+
+	config, err := LoadConfig("test.db")
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if config.Synchronous != "FULL" || config.BusyTimeout != 250*time.Millisecond || config.MaxOpenConns != 2 {
+		t.Errorf("Expected the environment to be applied, got %+v", config)
+	}
+	if config.JournalMode != "WAL" || config.TxLock != "immediate" || config.MaxIdleConns != 8 {
+		t.Errorf("Expected defaults for whatever is unset, got %+v", config)
+	}
+	dsn := config.DSN()
+	for _, param := range []string{"_foreign_keys=1", "_journal_mode=WAL", "_synchronous=FULL", "_busy_timeout=250", "_txlock=immediate"} {
+		if !strings.Contains(dsn, param) {
+			t.Errorf("Expected %s in the DSN, got %s", param, dsn)
+		}
+	}
+
+	t.Setenv("DB_JOURNAL_MODE", "wall")
+	if _, err := LoadConfig("test.db"); err == nil {
+		t.Errorf("Expected an unknown journal mode to be rejected")
+	}
+	t.Setenv("DB_JOURNAL_MODE", "")
+	t.Setenv("DB_MAX_IDLE_CONNS", "-1")
+	if _, err := LoadConfig("test.db"); err == nil {
+		t.Errorf("Expected a negative pool size to be rejected")
+	}
+}
+
+func TestOpenWithConfig_SetsUpEveryConnection(t *testing.T) {
+	config := DefaultConfig(filepath.Join(t.TempDir(), "test.db"))
+	config.BusyTimeout = 1500 * time.Millisecond
+	config.MaxOpenConns = 3
+	db, err := OpenWithConfig(config)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// hold every connection of the pool at once so each is a different one
+	ctx := context.Background()
+	for i := 0; i < config.MaxOpenConns; i++ {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get connection: %v", err)
+		}
+		defer conn.Close()
+
+		var journalMode string
+		var foreignKeys, busyTimeout, synchronous int
+		if err := conn.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&journalMode); err != nil {
+			t.Fatalf("Failed to read journal mode: %v", err)
+		}
+		if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+			t.Fatalf("Failed to read foreign keys: %v", err)
+		}
+		if err := conn.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&busyTimeout); err != nil {
+			t.Fatalf("Failed to read busy timeout: %v", err)
+		}
+		if err := conn.QueryRowContext(ctx, "PRAGMA synchronous").Scan(&synchronous); err != nil {
+			t.Fatalf("Failed to read synchronous: %v", err)
+		}
+		if journalMode != "wal" || foreignKeys != 1 || busyTimeout != 1500 || synchronous != 1 {
+			t.Errorf("Connection %d: expected wal, foreign keys, 1500ms and NORMAL, got %s, %d, %dms and %d",
+				i, journalMode, foreignKeys, busyTimeout, synchronous)
+		}
+	}
+	if stats := db.Stats(); stats.OpenConnections != config.MaxOpenConns {
+		t.Errorf("Expected %d open connections, got %d", config.MaxOpenConns, stats.OpenConnections)
+	}
+
+	if _, err := OpenWithConfig(DefaultConfig(filepath.Join(t.TempDir(), "what?.db"))); err == nil {
+		t.Errorf("Expected a path with a ? to be rejected")
+	}
+}
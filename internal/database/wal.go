@@ -0,0 +1,723 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WAL file layout, see https://www.sqlite.org/fileformat.html#the_write_ahead_log
+const (
+	walHeaderSize      = 32
+	walFrameHeaderSize = 24
+	walMagicLE         = 0x377f0682 // checksums over little-endian words
+	walMagicBE         = 0x377f0683 // checksums over big-endian words
+)
+
+// Archive layout: one directory per generation, named after the time it
+// started, holding a snapshot of the database and the log written since
+const (
+	walSnapshotName   = "snapshot.db"
+	walGenerationFile = "generation.json"
+	walSegmentDir     = "wal"
+	walTimeFormat     = "20060102T150405.000000000Z"
+)
+
+const (
+	// walSeqTable is written to before the archiver pins a read transaction;
+	// a reader only holds the log in place while the log has frames that
+	// are not in the database file yet
+	walSeqTable = "_wal_archive_seq"
+
+	// DefaultMaxWALSize and DefaultMaxGenerationAge bound how long a
+	// generation runs: the log cannot be checkpointed back to its start
+	// while it is being archived, and a restore replays the whole
+	// generation
+	DefaultMaxWALSize       = 64 << 20
+	DefaultMaxGenerationAge = 24 * time.Hour
+
+	walCheckpointAttempts = 5
+	walCheckpointPause    = 100 * time.Millisecond
+)
+
+// walHeader is the header at the start of a write-ahead log; every frame
+// repeats its salts, and its checksum seeds the frames' running checksum
+type walHeader struct {
+	raw       []byte
+	bigEndian bool
+	pageSize  int
+	salt1     uint32
+	salt2     uint32
+	checksum  [2]uint32
+}
+
+func parseWALHeader(raw []byte) (*walHeader, error) {
+	if len(raw) < walHeaderSize {
+		return nil, errors.New("write-ahead log header is truncated")
+	}
+	h := &walHeader{raw: append([]byte(nil), raw[:walHeaderSize]...)}
+	switch binary.BigEndian.Uint32(raw[0:]) {
+	case walMagicLE:
+	case walMagicBE:
+		h.bigEndian = true
+	default:
+		return nil, errors.New("not a write-ahead log")
+	}
+	h.pageSize = int(binary.BigEndian.Uint32(raw[8:]))
+	h.salt1 = binary.BigEndian.Uint32(raw[16:])
+	h.salt2 = binary.BigEndian.Uint32(raw[20:])
+	h.checksum = [2]uint32{binary.BigEndian.Uint32(raw[24:]), binary.BigEndian.Uint32(raw[28:])}
+	if h.pageSize < 512 || h.pageSize > 65536 || h.pageSize&(h.pageSize-1) != 0 {
+		return nil, fmt.Errorf("write-ahead log has an invalid page size %d", h.pageSize)
+	}
+	if walChecksum(h.bigEndian, [2]uint32{}, raw[:24]) != h.checksum {
+		return nil, errors.New("write-ahead log header checksum does not match")
+	}
+	return h, nil
+}
+
+// walChecksum continues SQLite's log checksum over b, whose length is a
+// multiple of 8
+func walChecksum(bigEndian bool, sum [2]uint32, b []byte) [2]uint32 {
+	order := binary.ByteOrder(binary.LittleEndian)
+	if bigEndian {
+		order = binary.BigEndian
+	}
+	s0, s1 := sum[0], sum[1]
+	for i := 0; i+8 <= len(b); i += 8 {
+		s0 += order.Uint32(b[i:]) + s1
+		s1 += order.Uint32(b[i+4:]) + s0
+	}
+	return [2]uint32{s0, s1}
+}
+
+// WALArchiver continuously copies the committed part of a database's
+// write-ahead log into a directory, so the database can be restored to any
+// point in time the archive covers.
+//
+// The archive is split into generations. Each starts with a snapshot of the
+// database taken with the backup API and then collects the log as it grows,
+// in numbered segments. The archiver holds a read transaction open between
+// syncs so SQLite cannot start the log over with frames it has not copied;
+// if the log is restarted anyway, or grows past MaxWALSize, or the
+// generation is older than MaxGenerationAge, a new generation starts.
+type WALArchiver struct {
+	MaxWALSize       int64
+	MaxGenerationAge time.Duration
+
+	db   *sql.DB // connections of the archiver's own, to the same file
+	path string
+	dir  string
+	now  func() time.Time
+
+	pinned *sql.Tx
+
+	generation string // directory of the current generation
+	started    time.Time
+	header     *walHeader
+	offset     int64     // the log is archived up to here
+	checksum   [2]uint32 // running checksum of the frame before offset
+	segments   int
+}
+
+// walGeneration is a generation of the archive that has a snapshot
+type walGeneration struct {
+	Name      string    `json:"-"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// walSegment is a piece of the log, archived at a point in time
+type walSegment struct {
+	Path       string
+	Seq        int
+	ArchivedAt time.Time
+}
+
+// NewWALArchiver switches the database behind db to WAL mode and prepares to
+// archive its log into dir. Nothing is archived until the first Sync.
+func NewWALArchiver(db *sql.DB, dir string) (*WALArchiver, error) {
+	var mode string
+	if err := db.QueryRow("PRAGMA journal_mode = WAL").Scan(&mode); err != nil {
+		return nil, fmt.Errorf("failed to switch to WAL mode: %v", err)
+	}
+	if !strings.EqualFold(mode, "wal") {
+		return nil, fmt.Errorf("failed to switch to WAL mode: journal mode is %s", mode)
+	}
+	path, err := databaseFile(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create WAL archive directory: %v", err)
+	}
+
+	// the pin is a read transaction and must not take the write lock
+	config := DefaultConfig(path)
+	config.TxLock = "deferred"
+	own, err := OpenWithConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	createSeq := `CREATE TABLE IF NOT EXISTS ` + walSeqTable + ` (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		seq INTEGER NOT NULL
+	)`
+	if _, err := own.Exec(createSeq); err != nil {
+		own.Close()
+		return nil, fmt.Errorf("failed to create %s: %v", walSeqTable, err)
+	}
+
+	return &WALArchiver{
+		MaxWALSize:       DefaultMaxWALSize,
+		MaxGenerationAge: DefaultMaxGenerationAge,
+		db:               own.DB,
+		path:             path,
+		dir:              dir,
+		now:              time.Now,
+	}, nil
+}
+
+// databaseFile returns the path of the file behind db
+func databaseFile(db *sql.DB) (string, error) {
+	rows, err := db.Query("PRAGMA database_list")
+	if err != nil {
+		return "", fmt.Errorf("failed to find database file: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var seq int
+		var name, file string
+		if err := rows.Scan(&seq, &name, &file); err != nil {
+			return "", fmt.Errorf("failed to find database file: %v", err)
+		}
+		if name == "main" {
+			if file == "" {
+				return "", errors.New("an in-memory database cannot be archived")
+			}
+			return file, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to find database file: %v", err)
+	}
+	return "", errors.New("failed to find database file")
+}
+
+// Generation returns the name of the generation being archived, or "" before
+// the first Sync
+func (a *WALArchiver) Generation() string {
+	if a.generation == "" {
+		return ""
+	}
+	return filepath.Base(a.generation)
+}
+
+// Sync archives whatever has been committed since the last Sync, starting a
+// new generation when the current one cannot or should not go on
+func (a *WALArchiver) Sync() error {
+	if a.generation == "" {
+		return a.startGeneration()
+	}
+	offset := a.offset
+	ok, err := a.copyFrames()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return a.startGeneration()
+	}
+	if a.rotationDue() {
+		// the log is only worth starting over from a fresh snapshot if it can
+		// be truncated; otherwise the generation carries on and a restart of
+		// the log in the meantime is caught by the next copy
+		a.unpin()
+		if a.checkpoint() {
+			return a.startGeneration()
+		}
+	}
+	// while nothing is committed the pin can stay where it is
+	if a.pinned != nil && a.offset == offset {
+		return nil
+	}
+	return a.repin()
+}
+
+// Close stops archiving; the archive stays usable up to the last Sync
+func (a *WALArchiver) Close() error {
+	a.unpin()
+	return a.db.Close()
+}
+
+// checkpoint copies the whole log into the database and truncates it. It
+// fails while another connection is checkpointing, which the server's own
+// connections do after most commits while the log is long, so it retries.
+func (a *WALArchiver) checkpoint() bool {
+	for attempt := 0; attempt < walCheckpointAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(walCheckpointPause)
+		}
+		var busy, logFrames, checkpointed int
+		err := a.db.QueryRow("PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &logFrames, &checkpointed)
+		if err == nil && busy == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *WALArchiver) rotationDue() bool {
+	if a.MaxGenerationAge > 0 && a.now().Sub(a.started) >= a.MaxGenerationAge {
+		return true
+	}
+	info, err := os.Stat(a.path + "-wal")
+	return err == nil && a.MaxWALSize > 0 && info.Size() >= a.MaxWALSize
+}
+
+// repin moves the pin up to the latest commit and archives up to there, so
+// that anything archived later was committed after the pin was taken
+func (a *WALArchiver) repin() error {
+	if err := a.pin(); err != nil {
+		return err
+	}
+	// a restart of the log is left for the next Sync to deal with
+	_, err := a.copyFrames()
+	return err
+}
+
+// pin moves the archiver's read transaction up to the latest commit. The new
+// transaction is taken before the old one is let go, so there is never a
+// moment in which SQLite may restart the log. Without an old one, the
+// archiver commits a write of its own first; otherwise the frames committed
+// since the old one was taken, which it kept from being checkpointed, do the
+// same job.
+func (a *WALArchiver) pin() error {
+	if a.pinned == nil {
+		upsert := `INSERT INTO ` + walSeqTable + ` (id, seq) VALUES (1, 1)
+			ON CONFLICT (id) DO UPDATE SET seq = seq + 1`
+		if _, err := a.db.Exec(upsert); err != nil {
+			return fmt.Errorf("failed to write %s: %v", walSeqTable, err)
+		}
+	}
+	tx, err := a.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to pin write-ahead log: %v", err)
+	}
+	var seq int
+	if err := tx.QueryRow(`SELECT seq FROM ` + walSeqTable).Scan(&seq); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to pin write-ahead log: %v", err)
+	}
+	a.unpin()
+	a.pinned = tx
+	return nil
+}
+
+func (a *WALArchiver) unpin() {
+	if a.pinned != nil {
+		a.pinned.Rollback()
+		a.pinned = nil
+	}
+}
+
+// startGeneration checkpoints the log, snapshots the database and archives
+// the log from its start
+func (a *WALArchiver) startGeneration() error {
+	a.unpin()
+	a.generation = ""
+
+	// shrink the log back to nothing so the generation does not start with
+	// a copy of it; a busy database may prevent that, which only costs space
+	a.checkpoint()
+	if err := a.pin(); err != nil {
+		return err
+	}
+	header, err := a.readHeader()
+	if err != nil {
+		return err
+	}
+
+	// the snapshot is taken after the header is read, so a restart of the
+	// log in between is caught by the first copy
+	name := a.now().UTC().Format(walTimeFormat)
+	dir := filepath.Join(a.dir, name)
+	if err := os.MkdirAll(filepath.Join(dir, walSegmentDir), 0o755); err != nil {
+		return fmt.Errorf("failed to create WAL archive generation: %v", err)
+	}
+	if err := copyDatabase(a.db, filepath.Join(dir, walSnapshotName)); err != nil {
+		os.RemoveAll(dir)
+		return fmt.Errorf("failed to snapshot database: %v", err)
+	}
+	if err := syncFile(filepath.Join(dir, walSnapshotName)); err != nil {
+		os.RemoveAll(dir)
+		return fmt.Errorf("failed to snapshot database: %v", err)
+	}
+
+	a.generation = dir
+	a.started = a.now()
+	a.header = header
+	a.offset = 0
+	a.checksum = header.checksum
+	a.segments = 0
+
+	ok, err := a.copyFrames()
+	if err != nil || !ok {
+		os.RemoveAll(dir)
+		a.generation = ""
+		if err == nil {
+			err = errors.New("write-ahead log was restarted while the generation started")
+		}
+		return err
+	}
+
+	// a generation only counts once it is complete up to here
+	data, err := json.Marshal(walGeneration{StartedAt: a.started.UTC()})
+	if err != nil {
+		return err
+	}
+	if err := writeFileSync(filepath.Join(dir, walGenerationFile), data); err != nil {
+		return fmt.Errorf("failed to write %s: %v", walGenerationFile, err)
+	}
+	return syncDir(a.dir)
+}
+
+func (a *WALArchiver) readHeader() (*walHeader, error) {
+	f, err := os.Open(a.path + "-wal")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read write-ahead log: %v", err)
+	}
+	defer f.Close()
+
+	raw := make([]byte, walHeaderSize)
+	if _, err := io.ReadFull(f, raw); err != nil {
+		return nil, fmt.Errorf("failed to read write-ahead log: %v", err)
+	}
+	return parseWALHeader(raw)
+}
+
+// copyFrames archives the frames committed since the last copy as a new
+// segment. It reports false if the log is no longer the one the generation
+// started with.
+func (a *WALArchiver) copyFrames() (bool, error) {
+	f, err := os.Open(a.path + "-wal")
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read write-ahead log: %v", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to read write-ahead log: %v", err)
+	}
+	raw := make([]byte, walHeaderSize)
+	if _, err := io.ReadFull(f, raw); err != nil || !bytes.Equal(raw, a.header.raw) || info.Size() < a.offset {
+		return false, nil
+	}
+
+	// walk the frames after offset while they belong to this log and their
+	// checksums hold; only whole transactions are archived
+	frameSize := int64(walFrameHeaderSize + a.header.pageSize)
+	pos := a.offset
+	if pos < walHeaderSize {
+		pos = walHeaderSize
+	}
+	end, checksum := a.offset, a.checksum
+	sum := a.checksum
+	frame := make([]byte, frameSize)
+	r := bufio.NewReaderSize(io.NewSectionReader(f, pos, info.Size()-pos), 1<<16)
+	for ; pos+frameSize <= info.Size(); pos += frameSize {
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return false, fmt.Errorf("failed to read write-ahead log: %v", err)
+		}
+		if binary.BigEndian.Uint32(frame[8:]) != a.header.salt1 || binary.BigEndian.Uint32(frame[12:]) != a.header.salt2 {
+			break
+		}
+		sum = walChecksum(a.header.bigEndian, sum, frame[:8])
+		sum = walChecksum(a.header.bigEndian, sum, frame[walFrameHeaderSize:])
+		if sum != [2]uint32{binary.BigEndian.Uint32(frame[16:]), binary.BigEndian.Uint32(frame[20:])} {
+			break
+		}
+		if binary.BigEndian.Uint32(frame[4:]) != 0 {
+			end, checksum = pos+frameSize, sum
+		}
+	}
+	if end == a.offset {
+		return true, nil
+	}
+
+	name := fmt.Sprintf("%08d-%s.wal", a.segments, a.now().UTC().Format(walTimeFormat))
+	path := filepath.Join(a.generation, walSegmentDir, name)
+	if err := writeSegment(path, io.NewSectionReader(f, a.offset, end-a.offset)); err != nil {
+		return false, fmt.Errorf("failed to archive write-ahead log: %v", err)
+	}
+	a.offset, a.checksum = end, checksum
+	a.segments++
+	return true, nil
+}
+
+// writeSegment writes a segment through a temporary file, so a segment that
+// exists is complete
+func writeSegment(path string, r io.Reader) error {
+	tmp := path + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// Prune deletes the generations no longer needed to restore to any point in
+// the last retention: those that started within it, the one running at its
+// start and the current one are kept. It returns the generations deleted.
+func (a *WALArchiver) Prune(retention time.Duration) ([]string, error) {
+	entries, err := os.ReadDir(a.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list WAL archive: %v", err)
+	}
+	generations, err := listGenerations(a.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := map[string]bool{a.Generation(): true}
+	cutoff := a.now().Add(-retention)
+	for i := len(generations) - 1; i >= 0; i-- {
+		keep[generations[i].Name] = true
+		if !generations[i].StartedAt.After(cutoff) {
+			break
+		}
+	}
+
+	var removed []string
+	for _, entry := range entries {
+		// anything else with a generation's name is one that never finished
+		if !entry.IsDir() || keep[entry.Name()] {
+			continue
+		}
+		if _, err := time.Parse(walTimeFormat, entry.Name()); err != nil {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(a.dir, entry.Name())); err != nil {
+			return removed, fmt.Errorf("failed to remove WAL archive generation %s: %v", entry.Name(), err)
+		}
+		removed = append(removed, entry.Name())
+	}
+	return removed, nil
+}
+
+// listGenerations returns the complete generations in dir, oldest first
+func listGenerations(dir string) ([]walGeneration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list WAL archive: %v", err)
+	}
+	var generations []walGeneration
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name(), walGenerationFile))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read WAL archive generation %s: %v", entry.Name(), err)
+		}
+		generation := walGeneration{Name: entry.Name()}
+		if err := json.Unmarshal(data, &generation); err != nil {
+			return nil, fmt.Errorf("failed to read WAL archive generation %s: %v", entry.Name(), err)
+		}
+		generations = append(generations, generation)
+	}
+	sort.Slice(generations, func(i, j int) bool { return generations[i].StartedAt.Before(generations[j].StartedAt) })
+	return generations, nil
+}
+
+// listSegments returns the segments of a generation in order
+func listSegments(dir string) ([]walSegment, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, walSegmentDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list WAL segments: %v", err)
+	}
+	var segments []walSegment
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".wal") {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimSuffix(name, ".wal"), "-", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		seq, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		at, err := time.Parse(walTimeFormat, parts[1])
+		if err != nil {
+			continue
+		}
+		segments = append(segments, walSegment{Path: filepath.Join(dir, walSegmentDir, name), Seq: seq, ArchivedAt: at})
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].Seq < segments[j].Seq })
+	for i, segment := range segments {
+		if segment.Seq != i {
+			return nil, fmt.Errorf("WAL segment %d is missing", i)
+		}
+	}
+	return segments, nil
+}
+
+// RestoreWALArchive rebuilds the database as it was at the given time from
+// the archive in dir and puts it in place of the file at dest. The result
+// holds everything archived up to that time, which is the point it returns.
+// Nothing may have dest open: the server has to be stopped first.
+func RestoreWALArchive(dir string, at time.Time, dest string) (time.Time, error) {
+	generations, err := listGenerations(dir)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var generation *walGeneration
+	for i := range generations {
+		if !generations[i].StartedAt.After(at) {
+			generation = &generations[i]
+		}
+	}
+	if generation == nil {
+		return time.Time{}, fmt.Errorf("the WAL archive in %s does not go back to %s", dir, at.UTC().Format(time.RFC3339))
+	}
+
+	genDir := filepath.Join(dir, generation.Name)
+	segments, err := listSegments(genDir)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for i, segment := range segments {
+		if segment.ArchivedAt.After(at) {
+			segments = segments[:i]
+			break
+		}
+	}
+
+	tmp := dest + ".restore.tmp"
+	removeDatabaseFiles(tmp)
+	defer removeDatabaseFiles(tmp)
+	if err := copyFile(filepath.Join(genDir, walSnapshotName), tmp); err != nil {
+		return time.Time{}, fmt.Errorf("failed to copy snapshot: %v", err)
+	}
+	restoredTo := generation.StartedAt
+	if len(segments) > 0 {
+		if err := replayWAL(tmp, segments); err != nil {
+			return time.Time{}, err
+		}
+		restoredTo = segments[len(segments)-1].ArchivedAt
+	}
+
+	if err := setJournalMode(tmp, "DELETE"); err != nil {
+		return time.Time{}, fmt.Errorf("failed to finish restore: %v", err)
+	}
+	if err := CheckIntegrity(tmp); err != nil {
+		return time.Time{}, fmt.Errorf("restored database failed its integrity check: %v", err)
+	}
+	if err := syncFile(tmp); err != nil {
+		return time.Time{}, err
+	}
+	if err := replaceDatabase(tmp, dest); err != nil {
+		return time.Time{}, err
+	}
+	return restoredTo, nil
+}
+
+// replayWAL puts the segments back together as the log of the database at
+// path and checkpoints them into it
+func replayWAL(path string, segments []walSegment) error {
+	// SQLite only reads a log next to a database in WAL mode
+	if err := setJournalMode(path, "WAL"); err != nil {
+		return fmt.Errorf("failed to prepare snapshot: %v", err)
+	}
+
+	out, err := os.OpenFile(path+"-wal", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	var size int64
+	for _, segment := range segments {
+		in, err := os.Open(segment.Path)
+		if err != nil {
+			out.Close()
+			return err
+		}
+		n, err := io.Copy(out, in)
+		in.Close()
+		if err != nil {
+			out.Close()
+			return err
+		}
+		size += n
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	raw := make([]byte, walHeaderSize)
+	f, err := os.Open(path + "-wal")
+	if err != nil {
+		return err
+	}
+	_, err = io.ReadFull(f, raw)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("first WAL segment is truncated: %v", err)
+	}
+	header, err := parseWALHeader(raw)
+	if err != nil {
+		return err
+	}
+	frames := (size - walHeaderSize) / int64(walFrameHeaderSize+header.pageSize)
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	var busy, logFrames, checkpointed int64
+	if err := db.QueryRow("PRAGMA wal_checkpoint(FULL)").Scan(&busy, &logFrames, &checkpointed); err != nil {
+		return fmt.Errorf("failed to replay write-ahead log: %v", err)
+	}
+	// SQLite silently ignores whatever part of a log it finds invalid
+	if busy != 0 || logFrames != frames || checkpointed != frames {
+		return fmt.Errorf("failed to replay write-ahead log: %d of %d frames applied", checkpointed, frames)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// AchievementKind is the condition an achievement rule checks
+type AchievementKind string
+
+const (
+	// KindGamesWon unlocks once the player has won Threshold games
+	KindGamesWon AchievementKind = "games_won"
+	// KindStreak unlocks once the player's win streak reaches Threshold
+	KindStreak AchievementKind = "streak"
+	// KindGamesPlayed unlocks once the player has played Threshold games
+	KindGamesPlayed AchievementKind = "games_played"
+	// KindAllChoicesWon unlocks once the player has won with every choice
+	KindAllChoicesWon AchievementKind = "all_choices_won"
+	// KindComeback unlocks on a win straight after Threshold losses in a row
+	KindComeback AchievementKind = "comeback"
+	// KindBeatAllBots unlocks once the player has beaten every bot strategy
+	KindBeatAllBots AchievementKind = "beat_all_bots"
+)
+
+// Achievement describes a badge a player can unlock
+type Achievement struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	RewardCoins int             `json:"reward_coins"`
+	Kind        AchievementKind `json:"-"`
+	Threshold   int             `json:"-"`
+}
+
+// UserAchievement is an achievement along with a player's unlock state
+type UserAchievement struct {
+	Achievement
+	Unlocked   bool       `json:"unlocked"`
+	UnlockedAt *time.Time `json:"unlocked_at,omitempty"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// UserStatus is the moderation state of an account
+type UserStatus string
+
+const (
+	UserActive    UserStatus = "active"
+	UserSuspended UserStatus = "suspended"
+	UserBanned    UserStatus = "banned"
+)
+
+// AdminAction is an entry in the audit log of admin changes. UserID is
+// cleared when the account is deleted; Username keeps the name it had.
+type AdminAction struct {
+	ID        int       `json:"id"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	UserID    *int      `json:"user_id,omitempty"`
+	Username  string    `json:"username"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AdminUserRecord is everything an admin sees about one account
+type AdminUserRecord struct {
+	User             User              `json:"user"`
+	ModerationReason string            `json:"moderation_reason,omitempty"`
+	Transactions     []CoinTransaction `json:"recent_transactions"`
+	Actions          []AdminAction     `json:"actions"`
+	Games            []Game            `json:"games"`
+	NextCursor       string            `json:"next_cursor"`
+}
+
+// AdjustCoinsRequest represents an admin credit or debit of a user's coins
+type AdjustCoinsRequest struct {
+	Amount int    `json:"amount" binding:"required"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ModerationRequest represents an admin action that only needs a reason
+type ModerationRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// SuspendUserRequest represents a temporary ban until a given time
+type SuspendUserRequest struct {
+	Reason string    `json:"reason" binding:"required"`
+	Until  time.Time `json:"until" binding:"required"`
+}
+
+// RenameUserRequest represents an admin change of username
+type RenameUserRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=20"`
+	Reason   string `json:"reason" binding:"required"`
+}
@@ -0,0 +1,51 @@
+package models
+
+// OpponentType selects games by who the player faced
+type OpponentType string
+
+const (
+	OpponentComputer OpponentType = "computer"
+	OpponentPlayer   OpponentType = "player"
+	OpponentAll      OpponentType = "all"
+)
+
+// IsValid checks if the opponent type is one of the known types
+func (o OpponentType) IsValid() bool {
+	return o == OpponentComputer || o == OpponentPlayer || o == OpponentAll
+}
+
+// ChoiceStats is how a player fared with one choice
+type ChoiceStats struct {
+	Choice  Choice  `json:"choice"`
+	Played  int     `json:"played"`
+	Share   float64 `json:"share"`
+	Wins    int     `json:"wins"`
+	Losses  int     `json:"losses"`
+	Ties    int     `json:"ties"`
+	WinRate float64 `json:"win_rate"`
+}
+
+// HourStats is how a player fared in one hour of the day
+type HourStats struct {
+	Hour        int     `json:"hour"`
+	GamesPlayed int     `json:"games_played"`
+	GamesWon    int     `json:"games_won"`
+	WinRate     float64 `json:"win_rate"`
+}
+
+// PlayerAnalytics describes how a player plays, computed from their games
+type PlayerAnalytics struct {
+	Username    string        `json:"username"`
+	Opponent    OpponentType  `json:"opponent"`
+	Timezone    string        `json:"timezone"`
+	GamesPlayed int           `json:"games_played"`
+	Choices     []ChoiceStats `json:"choices"`
+	// Transitions counts the choice thrown after each result of the previous game
+	Transitions        map[GameResult]map[Choice]int `json:"transitions"`
+	ChoiceEntropy      float64                       `json:"choice_entropy"`
+	ConditionalEntropy float64                       `json:"conditional_entropy"`
+	Predictability     float64                       `json:"predictability"`
+	Hours              []HourStats                   `json:"hours"`
+	LongestWinStreak   int                           `json:"longest_win_streak"`
+	LongestLossStreak  int                           `json:"longest_loss_streak"`
+}
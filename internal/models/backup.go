@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// BackupInfo describes a backup file
+type BackupInfo struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size_bytes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BackupRetention says which backups to keep; any backup one of the rules
+// keeps survives a prune
+type BackupRetention struct {
+	KeepLast   int // the most recent backups
+	KeepDaily  int // the newest backup of each of this many days
+	KeepWeekly int // the newest backup of each of this many ISO weeks
+}
@@ -0,0 +1,30 @@
+package models
+
+// BotStrategy is how the computer opponent picks its moves
+type BotStrategy string
+
+const (
+	// BotRandom throws each move a third of the time
+	BotRandom BotStrategy = "random"
+	// BotCycle plays rock, paper, scissors in turn
+	BotCycle BotStrategy = "cycle"
+	// BotMirror repeats the player's last move
+	BotMirror BotStrategy = "mirror"
+	// BotCounter plays what beats the player's last move
+	BotCounter BotStrategy = "counter"
+	// BotFrequency plays what beats the player's most common move
+	BotFrequency BotStrategy = "frequency"
+)
+
+// BotStrategies lists every computer opponent a player can pick
+var BotStrategies = []BotStrategy{BotRandom, BotCycle, BotMirror, BotCounter, BotFrequency}
+
+// IsValid checks if the strategy is one of BotStrategies
+func (b BotStrategy) IsValid() bool {
+	for _, bot := range BotStrategies {
+		if b == bot {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// ChallengeStatus is the lifecycle state of a direct challenge
+type ChallengeStatus string
+
+const (
+	ChallengePending   ChallengeStatus = "pending"
+	ChallengeAccepted  ChallengeStatus = "accepted"
+	ChallengeDeclined  ChallengeStatus = "declined"
+	ChallengeCancelled ChallengeStatus = "cancelled"
+	ChallengeExpired   ChallengeStatus = "expired"
+	ChallengeCompleted ChallengeStatus = "completed"
+	ChallengeForfeited ChallengeStatus = "forfeited"
+)
+
+// RoundWinner records who took a challenge round
+type RoundWinner string
+
+const (
+	RoundChallenger RoundWinner = "challenger"
+	RoundOpponent   RoundWinner = "opponent"
+	RoundTie        RoundWinner = "tie"
+)
+
+// Challenge is a best-of-N match one player invites another to
+type Challenge struct {
+	ID             int              `json:"id"`
+	Challenger     string           `json:"challenger"`
+	Opponent       string           `json:"opponent"`
+	BestOf         int              `json:"best_of"`
+	Stake          int              `json:"stake"`
+	Status         ChallengeStatus  `json:"status"`
+	ChallengerWins int              `json:"challenger_wins"`
+	OpponentWins   int              `json:"opponent_wins"`
+	Winner         string           `json:"winner,omitempty"`
+	Rounds         []ChallengeRound `json:"rounds"`
+	CreatedAt      time.Time        `json:"created_at"`
+	ExpiresAt      time.Time        `json:"expires_at"`
+	MoveDeadline   *time.Time       `json:"move_deadline,omitempty"`
+	CompletedAt    *time.Time       `json:"completed_at,omitempty"`
+}
+
+// ChallengeRound is one throw in a challenge. Choices stay hidden until both
+// players have moved.
+type ChallengeRound struct {
+	Number           int         `json:"number"`
+	ChallengerChoice Choice      `json:"challenger_choice,omitempty"`
+	OpponentChoice   Choice      `json:"opponent_choice,omitempty"`
+	ChallengerMoved  bool        `json:"challenger_moved"`
+	OpponentMoved    bool        `json:"opponent_moved"`
+	Winner           RoundWinner `json:"winner,omitempty"`
+}
+
+// CreateChallengeRequest represents the request to challenge a friend
+type CreateChallengeRequest struct {
+	Username         string `json:"username"` // optional, must match the account token
+	Opponent         string `json:"opponent" binding:"required"`
+	BestOf           int    `json:"best_of"`
+	Stake            int    `json:"stake" binding:"min=0"`
+	ExpiresInMinutes int    `json:"expires_in_minutes" binding:"min=0"`
+}
+
+// ChallengeActionRequest represents accepting, declining or cancelling a challenge
+type ChallengeActionRequest struct {
+	Username string `json:"username"` // optional, must match the account token
+}
+
+// ChallengeMoveRequest represents a player's throw in the current round
+type ChallengeMoveRequest struct {
+	Username     string `json:"username"` // optional, must match the account token
+	PlayerChoice Choice `json:"player_choice" binding:"required"`
+}
@@ -0,0 +1,126 @@
+package models
+
+import "time"
+
+// ClanRole is a member's rank within their clan
+type ClanRole string
+
+const (
+	// RoleOwner runs the clan; every clan has exactly one
+	RoleOwner ClanRole = "owner"
+	// RoleOfficer can invite and remove members and declare wars
+	RoleOfficer ClanRole = "officer"
+	RoleMember  ClanRole = "member"
+)
+
+// IsValid checks if the role is owner, officer or member
+func (r ClanRole) IsValid() bool {
+	return r == RoleOwner || r == RoleOfficer || r == RoleMember
+}
+
+// Clan is a group of players competing together under a short tag
+type Clan struct {
+	ID          int          `json:"id"`
+	Name        string       `json:"name"`
+	Tag         string       `json:"tag"`
+	Description string       `json:"description,omitempty"`
+	Open        bool         `json:"open"` // anyone can join without an invite
+	Members     []ClanMember `json:"members,omitempty"`
+	MemberCount int          `json:"member_count"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// ClanMember is a player in a clan
+type ClanMember struct {
+	Username   string    `json:"username"`
+	Role       ClanRole  `json:"role"`
+	TotalCoins int       `json:"total_coins"`
+	GamesWon   int       `json:"games_won"`
+	JoinedAt   time.Time `json:"joined_at"`
+}
+
+// ClanInvite is an invitation for a player to join a clan
+type ClanInvite struct {
+	Clan      string    `json:"clan"`
+	Tag       string    `json:"tag"`
+	InvitedBy string    `json:"invited_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ClanStanding is a clan's position in a season, from the games its members
+// played while in the clan
+type ClanStanding struct {
+	Rank        int     `json:"rank"`
+	Name        string  `json:"name"`
+	Tag         string  `json:"tag"`
+	Members     int     `json:"members"`
+	TotalCoins  int     `json:"total_coins"` // members' current balances
+	Coins       int     `json:"coins"`       // coins won in the season
+	GamesPlayed int     `json:"games_played"`
+	GamesWon    int     `json:"games_won"`
+	WinRate     float64 `json:"win_rate"`
+}
+
+// ClanWarStatus is the lifecycle state of a clan war
+type ClanWarStatus string
+
+const (
+	ClanWarPending   ClanWarStatus = "pending"
+	ClanWarActive    ClanWarStatus = "active"
+	ClanWarCompleted ClanWarStatus = "completed"
+	ClanWarDeclined  ClanWarStatus = "declined"
+)
+
+// ClanWar pits two clans against each other for a set time. Every game a
+// member wins against a member of the other clan scores a point.
+type ClanWar struct {
+	ID            int           `json:"id"`
+	Clan          string        `json:"clan"` // tag of the clan that declared the war
+	Opponent      string        `json:"opponent"`
+	Status        ClanWarStatus `json:"status"`
+	DurationHours int           `json:"duration_hours"`
+	ClanScore     int           `json:"clan_score"`
+	OpponentScore int           `json:"opponent_score"`
+	Winner        string        `json:"winner,omitempty"`
+	DeclaredBy    string        `json:"declared_by"`
+	CreatedAt     time.Time     `json:"created_at"`
+	StartsAt      *time.Time    `json:"starts_at,omitempty"`
+	EndsAt        *time.Time    `json:"ends_at,omitempty"`
+}
+
+// CreateClanRequest represents a player founding a clan
+type CreateClanRequest struct {
+	Username    string `json:"username"` // optional, must match the account token
+	Name        string `json:"name" binding:"required,max=40"`
+	Tag         string `json:"tag" binding:"required"`
+	Description string `json:"description" binding:"max=200"`
+	Open        bool   `json:"open"`
+}
+
+// ClanActionRequest represents a player joining, leaving or answering for a
+// clan
+type ClanActionRequest struct {
+	Username string `json:"username"` // optional, must match the account token
+}
+
+// ClanMemberRequest represents a clan officer acting on another player:
+// inviting them or removing them
+type ClanMemberRequest struct {
+	Username string `json:"username"` // optional, must match the account token
+	Member   string `json:"member" binding:"required"`
+}
+
+// SetClanRoleRequest represents the owner changing a member's role.
+// Making someone owner hands the clan over and makes the old owner an
+// officer.
+type SetClanRoleRequest struct {
+	Username string   `json:"username"` // optional, must match the account token
+	Role     ClanRole `json:"role" binding:"required"`
+}
+
+// DeclareClanWarRequest represents an officer challenging another clan
+type DeclareClanWarRequest struct {
+	Username      string `json:"username"`                    // optional, must match the account token
+	Opponent      string `json:"opponent" binding:"required"` // the other clan's tag
+	DurationHours int    `json:"duration_hours"`
+}
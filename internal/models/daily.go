@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// DailyRewardStatus describes a user's daily login reward for their current day
+type DailyRewardStatus struct {
+	Day          string     `json:"day"`
+	Timezone     string     `json:"timezone"`
+	ClaimedToday bool       `json:"claimed_today"`
+	StreakDay    int        `json:"streak_day"` // consecutive days claimed, including today if claimed
+	NextReward   int        `json:"next_reward"`
+	NextResetAt  time.Time  `json:"next_reset_at"`
+	NextClaimAt  *time.Time `json:"next_claim_at,omitempty"` // set while the next reward cannot be claimed yet
+}
+
+// DailyRewardClaim is the result of claiming the daily login reward
+type DailyRewardClaim struct {
+	Day        string `json:"day"`
+	StreakDay  int    `json:"streak_day"`
+	Coins      int    `json:"coins"`
+	TotalCoins int    `json:"total_coins"`
+}
+
+// ChallengeKind is the goal of a daily challenge
+type ChallengeKind string
+
+const (
+	ChallengeWinWithChoice ChallengeKind = "win_with_choice"
+	ChallengeReachStreak   ChallengeKind = "reach_streak"
+	ChallengePlayGames     ChallengeKind = "play_games"
+	ChallengeWinGames      ChallengeKind = "win_games"
+)
+
+// DailyChallenge is one of the challenges generated for a day, along with a
+// user's progress towards it
+type DailyChallenge struct {
+	ID          string        `json:"id"`
+	Day         string        `json:"day"`
+	Kind        ChallengeKind `json:"kind"`
+	Description string        `json:"description"`
+	Choice      Choice        `json:"choice,omitempty"`
+	Target      int           `json:"target"`
+	RewardCoins int           `json:"reward_coins"`
+	Progress    int           `json:"progress"`
+	Completed   bool          `json:"completed"`
+	Claimed     bool          `json:"claimed"`
+}
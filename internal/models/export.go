@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// ExportFormat is a file format games can be exported in
+type ExportFormat string
+
+const (
+	ExportCSV     ExportFormat = "csv"
+	ExportNDJSON  ExportFormat = "ndjson"
+	ExportParquet ExportFormat = "parquet"
+)
+
+// IsValid checks if the export format is supported
+func (f ExportFormat) IsValid() bool {
+	return f == ExportCSV || f == ExportNDJSON || f == ExportParquet
+}
+
+// ContentType returns the MIME type of the format
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportCSV:
+		return "text/csv"
+	case ExportNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/vnd.apache.parquet"
+	}
+}
+
+// GameExportColumns are the columns of a game export, in order. Every format
+// uses these names: the CSV header, the NDJSON keys and the Parquet schema.
+var GameExportColumns = []string{
+	"game_id", "user_id", "username", "player_choice", "opponent_choice", "result",
+	"coins_earned", "streak_multiplier", "opponent_type", "opponent_user_id", "played_at",
+}
+
+// GameExportRow is one exported game. OpponentChoice is the computer's or the
+// other player's choice, and OpponentUserID is only set for games against
+// another player.
+type GameExportRow struct {
+	GameID           int64     `json:"game_id" parquet:"game_id"`
+	UserID           int64     `json:"user_id" parquet:"user_id"`
+	Username         string    `json:"username" parquet:"username"`
+	PlayerChoice     string    `json:"player_choice" parquet:"player_choice"`
+	OpponentChoice   string    `json:"opponent_choice" parquet:"opponent_choice"`
+	Result           string    `json:"result" parquet:"result"`
+	CoinsEarned      int64     `json:"coins_earned" parquet:"coins_earned"`
+	StreakMultiplier int64     `json:"streak_multiplier" parquet:"streak_multiplier"`
+	OpponentType     string    `json:"opponent_type" parquet:"opponent_type"`
+	OpponentUserID   *int64    `json:"opponent_user_id" parquet:"opponent_user_id,optional"`
+	PlayedAt         time.Time `json:"played_at" parquet:"played_at,timestamp(millisecond)"`
+}
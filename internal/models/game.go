@@ -20,7 +20,8 @@ const (
 	Tie  GameResult = "tie"
 )
 
-// Game represents a single game round
+// Game represents a single game round. Games against another user carry the
+// opponent's ID, and ComputerChoice then holds the opponent's choice.
 type Game struct {
 	ID               int        `json:"id" db:"id"`
 	UserID           int        `json:"user_id" db:"user_id"`
@@ -29,36 +30,94 @@ type Game struct {
 	Result           GameResult `json:"result" db:"result"`
 	CoinsEarned      int        `json:"coins_earned" db:"coins_earned"`
 	StreakMultiplier int        `json:"streak_multiplier" db:"streak_multiplier"`
+	OpponentUserID   *int       `json:"opponent_user_id,omitempty" db:"opponent_user_id"`
 	PlayedAt         time.Time  `json:"played_at" db:"played_at"`
 }
 
+// SortOrder is the direction a list is sorted in
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+// IsValid checks if the sort order is asc or desc
+func (o SortOrder) IsValid() bool {
+	return o == SortAsc || o == SortDesc
+}
+
+// GameHistoryFilter selects and pages through a user's games. Zero values
+// leave a filter off; From is inclusive and To exclusive. Cursor is the
+// next_cursor of the previous page.
+type GameHistoryFilter struct {
+	Result       GameResult
+	PlayerChoice Choice
+	Opponent     OpponentType
+	From         time.Time
+	To           time.Time
+	Order        SortOrder
+	Cursor       string
+	Limit        int
+}
+
 // PlayGameRequest represents the request to play a game
 type PlayGameRequest struct {
-	Username     string `json:"username" binding:"required"`
-	PlayerChoice Choice `json:"player_choice" binding:"required"`
+	Username     string      `json:"username" binding:"required"`
+	PlayerChoice Choice      `json:"player_choice" binding:"required"`
+	Bot          BotStrategy `json:"bot,omitempty"` // random when empty
 }
 
 // PlayGameResponse represents the response after playing a game
 type PlayGameResponse struct {
-	PlayerChoice     Choice     `json:"player_choice"`
-	ComputerChoice   Choice     `json:"computer_choice"`
-	Result           GameResult `json:"result"`
-	CoinsEarned      int        `json:"coins_earned"`
-	StreakMultiplier int        `json:"streak_multiplier"`
-	NewStreak        int        `json:"new_streak"`
-	TotalCoins       int        `json:"total_coins"`
-	Message          string     `json:"message"`
+	PlayerChoice     Choice            `json:"player_choice"`
+	ComputerChoice   Choice            `json:"computer_choice"`
+	Result           GameResult        `json:"result"`
+	CoinsEarned      int               `json:"coins_earned"`
+	StreakMultiplier int               `json:"streak_multiplier"`
+	NewStreak        int               `json:"new_streak"`
+	NewBestStreak    bool              `json:"new_best_streak,omitempty"`
+	TotalCoins       int               `json:"total_coins"`
+	Message          string            `json:"message"`
+	NewAchievements  []UserAchievement `json:"new_achievements,omitempty"`
 }
 
 // LeaderboardEntry represents a player's position on the leaderboard
 type LeaderboardEntry struct {
-	Rank         int     `json:"rank"`
-	Username     string  `json:"username"`
-	TotalCoins   int     `json:"total_coins"`
-	GamesPlayed  int     `json:"games_played"`
-	GamesWon     int     `json:"games_won"`
-	WinRate      float64 `json:"win_rate"`
-	CurrentStreak int    `json:"current_streak"`
+	Rank          int               `json:"rank"`
+	Username      string            `json:"username"`
+	ClanTag       string            `json:"clan_tag,omitempty"`
+	TotalCoins    int               `json:"total_coins"`
+	GamesPlayed   int               `json:"games_played"`
+	GamesWon      int               `json:"games_won"`
+	WinRate       float64           `json:"win_rate"`
+	CurrentStreak int               `json:"current_streak"`
+	Cosmetics     EquippedCosmetics `json:"cosmetics"`
+	Profile       Profile           `json:"profile"`
+}
+
+// LeaderboardScore is what a user is ranked by on the leaderboard: coins,
+// then games won, then the older account first
+type LeaderboardScore struct {
+	UserID   int
+	Coins    int
+	GamesWon int
+}
+
+// RanksAbove reports whether s comes before other on the leaderboard
+func (s LeaderboardScore) RanksAbove(other LeaderboardScore) bool {
+	if s.Coins != other.Coins {
+		return s.Coins > other.Coins
+	}
+	if s.GamesWon != other.GamesWon {
+		return s.GamesWon > other.GamesWon
+	}
+	return s.UserID < other.UserID
+}
+
+// IsValid checks if the result is win, lose or tie
+func (r GameResult) IsValid() bool {
+	return r == Win || r == Lose || r == Tie
 }
 
 // IsValidChoice checks if the choice is valid
@@ -78,4 +137,4 @@ func (c Choice) Beats(other Choice) bool {
 	default:
 		return false
 	}
-} 
\ No newline at end of file
+}
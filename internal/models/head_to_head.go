@@ -0,0 +1,26 @@
+package models
+
+// ChoiceCount is how often a player threw a choice
+type ChoiceCount struct {
+	Choice Choice `json:"choice"`
+	Count  int    `json:"count"`
+}
+
+// HeadToHead is the record of every game one player has played against
+// another, from the first player's point of view
+type HeadToHead struct {
+	Player                string        `json:"player"`
+	Opponent              string        `json:"opponent"`
+	GamesPlayed           int           `json:"games_played"`
+	Wins                  int           `json:"wins"`
+	Losses                int           `json:"losses"`
+	Ties                  int           `json:"ties"`
+	WinRate               float64       `json:"win_rate"`
+	CoinSwing             int           `json:"coin_swing"`
+	LongestWinStreak      int           `json:"longest_win_streak"`
+	OpponentLongestStreak int           `json:"opponent_longest_win_streak"`
+	PlayerChoices         []ChoiceCount `json:"player_choices"`
+	OpponentChoices       []ChoiceCount `json:"opponent_choices"`
+	ChallengesPlayed      int           `json:"challenges_played"`
+	RecentGames           []Game        `json:"recent_games"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// UserAggregates are the counters kept on a user row so they need not be
+// summed from the games table on every read
+type UserAggregates struct {
+	GamesPlayed   int `json:"games_played"`
+	GamesWon      int `json:"games_won"`
+	TotalCoins    int `json:"total_coins"`
+	CurrentStreak int `json:"current_streak"`
+}
+
+// AggregateDrift describes a user whose stored counters no longer match what
+// their games and coin ledger add up to
+type AggregateDrift struct {
+	UserID   int            `json:"user_id"`
+	Username string         `json:"username"`
+	Stored   UserAggregates `json:"stored"`
+	Expected UserAggregates `json:"expected"`
+}
+
+// PruneResult describes games moved out of the database into an archive
+type PruneResult struct {
+	Before time.Time `json:"before"`
+	Games  int       `json:"games"`
+	Users  int       `json:"users"`
+}
+
+// DatabaseSize is the size of the database file in bytes, before and after
+// it was compacted
+type DatabaseSize struct {
+	Before int64 `json:"before_bytes"`
+	After  int64 `json:"after_bytes"`
+}
+
+// SeedOptions controls how much fake data Seed creates
+type SeedOptions struct {
+	Users        int    // players to create
+	GamesPerUser int    // games against the computer each player has played
+	Days         int    // games are spread over this many days up to now
+	Prefix       string // usernames are the prefix followed by a number
+	Seed         int64  // random seed, so a run can be repeated
+}
+
+// SeedResult lists the players Seed created
+type SeedResult struct {
+	Users []string `json:"users"`
+	Games int      `json:"games"`
+}
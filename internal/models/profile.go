@@ -0,0 +1,20 @@
+package models
+
+// Profile is the public part of an account that a player fills in themselves
+type Profile struct {
+	DisplayName string `json:"display_name,omitempty"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+	Country     string `json:"country,omitempty"` // ISO 3166-1 alpha-2, e.g. "GB"
+	Bio         string `json:"bio,omitempty"`
+}
+
+// UpdateProfileRequest represents a partial profile update. Fields left out
+// are unchanged and an empty string clears a field; the timezone cannot be
+// cleared.
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name"`
+	AvatarURL   *string `json:"avatar_url"`
+	Country     *string `json:"country"`
+	Bio         *string `json:"bio"`
+	Timezone    *string `json:"timezone"`
+}
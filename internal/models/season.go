@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// SeasonStatus is the lifecycle state of a season
+type SeasonStatus string
+
+const (
+	SeasonActive   SeasonStatus = "active"
+	SeasonArchived SeasonStatus = "archived"
+)
+
+// Season is one period of the season calendar
+type Season struct {
+	ID       int          `json:"id" db:"id"`
+	Name     string       `json:"name" db:"name"`
+	StartsAt time.Time    `json:"starts_at" db:"starts_at"`
+	EndsAt   time.Time    `json:"ends_at" db:"ends_at"`
+	Status   SeasonStatus `json:"status" db:"status"`
+}
+
+// SeasonStanding is a player's position in a season, live for the current
+// season and archived for finished ones
+type SeasonStanding struct {
+	Rank        int     `json:"rank"`
+	Username    string  `json:"username"`
+	Coins       int     `json:"coins"`
+	GamesPlayed int     `json:"games_played"`
+	GamesWon    int     `json:"games_won"`
+	WinRate     float64 `json:"win_rate"`
+	Rating      int     `json:"rating"`
+	Badge       string  `json:"badge,omitempty"`
+	RewardCoins int     `json:"reward_coins,omitempty"`
+}
+
+// SeasonResult is a user's archived standing in a finished season
+type SeasonResult struct {
+	Season
+	Standing SeasonStanding `json:"standing"`
+}
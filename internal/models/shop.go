@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// CosmeticSlot is the place on a player's profile a cosmetic item occupies
+type CosmeticSlot string
+
+const (
+	SlotAvatar           CosmeticSlot = "avatar"
+	SlotHandSkin         CosmeticSlot = "hand_skin"
+	SlotVictoryAnimation CosmeticSlot = "victory_animation"
+	SlotNameColor        CosmeticSlot = "name_color"
+)
+
+// IsValid checks if the slot is one of the known cosmetic slots
+func (s CosmeticSlot) IsValid() bool {
+	return s == SlotAvatar || s == SlotHandSkin || s == SlotVictoryAnimation || s == SlotNameColor
+}
+
+// ShopItem is a cosmetic item from the shop catalog
+type ShopItem struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Slot        CosmeticSlot `json:"slot"`
+	Price       int          `json:"price"`
+	Value       string       `json:"value"` // emoji, skin name, animation name or color, depending on slot
+	Description string       `json:"description,omitempty"`
+}
+
+// InventoryItem is a shop item owned by a user
+type InventoryItem struct {
+	ShopItem
+	Equipped    bool      `json:"equipped"`
+	PurchasedAt time.Time `json:"purchased_at"`
+}
+
+// EquippedCosmetics holds the item equipped in each slot, if any
+type EquippedCosmetics struct {
+	Avatar           *ShopItem `json:"avatar,omitempty"`
+	HandSkin         *ShopItem `json:"hand_skin,omitempty"`
+	VictoryAnimation *ShopItem `json:"victory_animation,omitempty"`
+	NameColor        *ShopItem `json:"name_color,omitempty"`
+}
+
+// Set places item in the matching slot
+func (e *EquippedCosmetics) Set(item ShopItem) {
+	switch item.Slot {
+	case SlotAvatar:
+		e.Avatar = &item
+	case SlotHandSkin:
+		e.HandSkin = &item
+	case SlotVictoryAnimation:
+		e.VictoryAnimation = &item
+	case SlotNameColor:
+		e.NameColor = &item
+	}
+}
+
+// PurchaseRequest represents the request to buy a shop item
+type PurchaseRequest struct {
+	Username string `json:"username"` // optional, must match the account token
+	ItemID   string `json:"item_id" binding:"required"`
+}
+
+// EquipRequest represents the request to equip an owned item
+type EquipRequest struct {
+	Username string `json:"username"` // optional, must match the account token
+	ItemID   string `json:"item_id" binding:"required"`
+}
+
+// UnequipRequest represents the request to clear a cosmetic slot
+type UnequipRequest struct {
+	Username string       `json:"username"` // optional, must match the account token
+	Slot     CosmeticSlot `json:"slot" binding:"required"`
+}
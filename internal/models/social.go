@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// FriendshipStatus is the state of a friendship between two users
+type FriendshipStatus string
+
+const (
+	FriendshipPending  FriendshipStatus = "pending"
+	FriendshipAccepted FriendshipStatus = "accepted"
+)
+
+// Friend is another user in a player's friend list or pending requests
+type Friend struct {
+	Username string           `json:"username"`
+	Status   FriendshipStatus `json:"status"`
+	Since    time.Time        `json:"since"`
+}
+
+// FriendList is everything in a player's social graph
+type FriendList struct {
+	Friends  []Friend `json:"friends"`
+	Incoming []Friend `json:"incoming_requests"`
+	Outgoing []Friend `json:"outgoing_requests"`
+}
+
+// FriendRequest represents a request to act on a friendship; Username is the
+// acting user and Friend the other side
+type FriendRequest struct {
+	Username string `json:"username"` // optional, must match the account token
+	Friend   string `json:"friend" binding:"required"`
+}
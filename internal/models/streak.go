@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// StreakStatus is whether a win streak is still going
+type StreakStatus string
+
+const (
+	StreakActive StreakStatus = "active"
+	StreakEnded  StreakStatus = "ended"
+)
+
+// Streak is a run of wins against the computer. Ties keep a streak going
+// without adding to it and a loss ends it.
+type Streak struct {
+	ID          int          `json:"id"`
+	StartGameID *int         `json:"start_game_id,omitempty"`
+	EndGameID   *int         `json:"end_game_id,omitempty"`
+	Length      int          `json:"length"`
+	CoinsEarned int          `json:"coins_earned"`
+	Status      StreakStatus `json:"status"`
+	StartedAt   time.Time    `json:"started_at"`
+	EndedAt     *time.Time   `json:"ended_at,omitempty"`
+}
+
+// StreakLeaderboardEntry is a player's position on the best streak leaderboard
+type StreakLeaderboardEntry struct {
+	Rank          int    `json:"rank"`
+	Username      string `json:"username"`
+	BestStreak    int    `json:"best_streak"`
+	CurrentStreak int    `json:"current_streak"`
+}
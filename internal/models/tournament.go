@@ -0,0 +1,177 @@
+package models
+
+import "time"
+
+// TournamentFormat is how a tournament pairs its players
+type TournamentFormat string
+
+const (
+	FormatSingleElimination TournamentFormat = "single_elimination"
+	FormatDoubleElimination TournamentFormat = "double_elimination"
+	FormatRoundRobin        TournamentFormat = "round_robin"
+	FormatSwiss             TournamentFormat = "swiss"
+)
+
+// IsValid checks if the format is one of the supported formats
+func (f TournamentFormat) IsValid() bool {
+	switch f {
+	case FormatSingleElimination, FormatDoubleElimination, FormatRoundRobin, FormatSwiss:
+		return true
+	}
+	return false
+}
+
+// IsElimination reports whether players are knocked out of the tournament,
+// as opposed to everyone playing a fixed number of rounds for points
+func (f TournamentFormat) IsElimination() bool {
+	return f == FormatSingleElimination || f == FormatDoubleElimination
+}
+
+// TournamentSeeding is what players are seeded by when the tournament starts
+type TournamentSeeding string
+
+const (
+	SeedByCoins  TournamentSeeding = "coins"
+	SeedByRating TournamentSeeding = "rating"
+)
+
+// IsValid checks if the seeding is coins or rating
+func (s TournamentSeeding) IsValid() bool {
+	return s == SeedByCoins || s == SeedByRating
+}
+
+// TournamentStatus is the lifecycle state of a tournament
+type TournamentStatus string
+
+const (
+	TournamentRegistration TournamentStatus = "registration"
+	TournamentInProgress   TournamentStatus = "in_progress"
+	TournamentCompleted    TournamentStatus = "completed"
+	TournamentCancelled    TournamentStatus = "cancelled"
+)
+
+// TournamentMatchStatus is the state of a single tournament match
+type TournamentMatchStatus string
+
+const (
+	// MatchWaiting is a bracket match whose players are not known yet
+	MatchWaiting   TournamentMatchStatus = "waiting"
+	MatchActive    TournamentMatchStatus = "active"
+	MatchCompleted TournamentMatchStatus = "completed"
+	// MatchForfeit is a match decided because a player ran out of time
+	MatchForfeit TournamentMatchStatus = "forfeit"
+	// MatchBye is a match with at most one player, who goes through
+	MatchBye TournamentMatchStatus = "bye"
+)
+
+// TournamentBracket is the part of a tournament a match belongs to
+type TournamentBracket string
+
+const (
+	BracketMain       TournamentBracket = "main" // round robin and Swiss
+	BracketWinners    TournamentBracket = "winners"
+	BracketLosers     TournamentBracket = "losers"
+	BracketGrandFinal TournamentBracket = "grand_final"
+)
+
+// Tournament is a competition between registered players for a prize pool
+type Tournament struct {
+	ID                   int               `json:"id"`
+	Name                 string            `json:"name"`
+	Format               TournamentFormat  `json:"format"`
+	Status               TournamentStatus  `json:"status"`
+	Seeding              TournamentSeeding `json:"seeding"`
+	BestOf               int               `json:"best_of"`
+	MaxPlayers           int               `json:"max_players"`
+	Players              int               `json:"players"`
+	EntryFee             int               `json:"entry_fee"`
+	GuaranteedPrize      int               `json:"guaranteed_prize"`
+	PrizePool            int               `json:"prize_pool"`
+	PrizeSplit           []int             `json:"prize_split"` // percent of the pool for 1st, 2nd, ...
+	SwissRounds          int               `json:"swiss_rounds,omitempty"`
+	MoveTimeoutMinutes   int               `json:"move_timeout_minutes"`
+	CurrentRound         int               `json:"current_round,omitempty"` // round robin and Swiss only
+	Winner               string            `json:"winner,omitempty"`
+	RegistrationClosesAt time.Time         `json:"registration_closes_at"`
+	CreatedBy            string            `json:"created_by"`
+	CreatedAt            time.Time         `json:"created_at"`
+	StartedAt            *time.Time        `json:"started_at,omitempty"`
+	CompletedAt          *time.Time        `json:"completed_at,omitempty"`
+}
+
+// TournamentPlayer is a registered player and how they are doing
+type TournamentPlayer struct {
+	Username    string `json:"username"`
+	Seed        int    `json:"seed,omitempty"`
+	Points      int    `json:"points"`
+	MatchWins   int    `json:"match_wins"`
+	MatchLosses int    `json:"match_losses"`
+	GameWins    int    `json:"game_wins"`
+	GameLosses  int    `json:"game_losses"`
+	Buchholz    int    `json:"buchholz,omitempty"` // Swiss tiebreak: points of everyone played
+	Eliminated  bool   `json:"eliminated"`
+	Withdrawn   bool   `json:"withdrawn"`
+	Place       int    `json:"place,omitempty"`
+	Prize       int    `json:"prize,omitempty"`
+}
+
+// TournamentMatch is a best-of-N match between two tournament players
+type TournamentMatch struct {
+	ID          int                   `json:"id"`
+	Bracket     TournamentBracket     `json:"bracket"`
+	Round       int                   `json:"round"`
+	Position    int                   `json:"position"`
+	Player1     string                `json:"player1,omitempty"`
+	Player2     string                `json:"player2,omitempty"`
+	Player1Wins int                   `json:"player1_wins"`
+	Player2Wins int                   `json:"player2_wins"`
+	Winner      string                `json:"winner,omitempty"`
+	Status      TournamentMatchStatus `json:"status"`
+	Games       []TournamentGame      `json:"games"`
+	Deadline    *time.Time            `json:"deadline,omitempty"`
+	CompletedAt *time.Time            `json:"completed_at,omitempty"`
+}
+
+// TournamentGame is one throw in a tournament match. Choices stay hidden
+// until both players have moved.
+type TournamentGame struct {
+	Number        int    `json:"number"`
+	Player1Choice Choice `json:"player1_choice,omitempty"`
+	Player2Choice Choice `json:"player2_choice,omitempty"`
+	Player1Moved  bool   `json:"player1_moved"`
+	Player2Moved  bool   `json:"player2_moved"`
+	Winner        string `json:"winner,omitempty"` // "player1", "player2" or "tie"
+}
+
+// TournamentRound groups the matches of one round of one bracket
+type TournamentRound struct {
+	Bracket TournamentBracket `json:"bracket"`
+	Round   int               `json:"round"`
+	Matches []TournamentMatch `json:"matches"`
+}
+
+// CreateTournamentRequest represents an organizer setting up a tournament
+type CreateTournamentRequest struct {
+	Name                 string            `json:"name" binding:"required,max=60"`
+	Format               TournamentFormat  `json:"format" binding:"required"`
+	Seeding              TournamentSeeding `json:"seeding"`
+	BestOf               int               `json:"best_of"`
+	MaxPlayers           int               `json:"max_players"`
+	EntryFee             int               `json:"entry_fee" binding:"min=0"`
+	GuaranteedPrize      int               `json:"guaranteed_prize" binding:"min=0"`
+	PrizeSplit           []int             `json:"prize_split"`
+	SwissRounds          int               `json:"swiss_rounds" binding:"min=0"`
+	MoveTimeoutMinutes   int               `json:"move_timeout_minutes" binding:"min=0"`
+	RegistrationClosesAt time.Time         `json:"registration_closes_at" binding:"required"`
+}
+
+// TournamentRegistrationRequest represents a player joining or leaving a tournament
+type TournamentRegistrationRequest struct {
+	Username string `json:"username"` // optional, must match the account token
+}
+
+// TournamentMoveRequest represents a player's throw in their current match
+type TournamentMoveRequest struct {
+	Username     string `json:"username"` // optional, must match the account token
+	PlayerChoice Choice `json:"player_choice" binding:"required"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// TransactionType identifies why a user's coin balance changed
+type TransactionType string
+
+const (
+	TxGameReward      TransactionType = "game_reward"
+	TxWager           TransactionType = "wager"
+	TxPurchase        TransactionType = "purchase"
+	TxAdminAdjustment TransactionType = "admin_adjustment"
+	TxDailyBonus      TransactionType = "daily_bonus"
+	TxOpeningBalance  TransactionType = "opening_balance"
+	TxAchievement     TransactionType = "achievement_reward"
+	TxDailyChallenge  TransactionType = "daily_challenge"
+	TxSeasonReward    TransactionType = "season_reward"
+	TxTournament      TransactionType = "tournament" // entry fees, refunds and prizes
+)
+
+// CounterAccount returns the system account the coins of an entry came from
+// or went to. The ledger posts the opposite amount to it alongside every
+// user entry, so each transaction sums to zero.
+func (t TransactionType) CounterAccount() string {
+	switch t {
+	case TxGameReward:
+		return "system:rewards"
+	case TxWager:
+		return "system:wagers"
+	case TxPurchase:
+		return "system:shop"
+	case TxAdminAdjustment:
+		return "system:admin"
+	case TxDailyBonus:
+		return "system:bonuses"
+	case TxOpeningBalance:
+		return "system:opening"
+	case TxAchievement:
+		return "system:achievements"
+	case TxDailyChallenge:
+		return "system:challenges"
+	case TxSeasonReward:
+		return "system:seasons"
+	case TxTournament:
+		return "system:tournaments"
+	default:
+		return "system:unknown"
+	}
+}
+
+// CoinTransaction is an immutable entry in the coin ledger
+type CoinTransaction struct {
+	ID             int             `json:"id" db:"id"`
+	UserID         int             `json:"user_id" db:"user_id"`
+	Type           TransactionType `json:"type" db:"type"`
+	Amount         int             `json:"amount" db:"amount"`
+	BalanceAfter   int             `json:"balance_after" db:"balance_after"`
+	CounterAccount string          `json:"counter_account" db:"counter_account"`
+	Reference      string          `json:"reference,omitempty" db:"reference"`
+	Reason         string          `json:"reason,omitempty" db:"reason"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+}
+
+// LedgerDrift describes a user whose stored balance no longer matches the ledger
+type LedgerDrift struct {
+	UserID        int    `json:"user_id"`
+	Username      string `json:"username"`
+	StoredBalance int    `json:"stored_balance"`
+	LedgerBalance int    `json:"ledger_balance"`
+	Drift         int    `json:"drift"`
+}
+
+// UnbalancedTransaction describes a ledger transaction whose user and system
+// sides do not sum to zero
+type UnbalancedTransaction struct {
+	TransactionID int             `json:"transaction_id"`
+	UserID        int             `json:"user_id"`
+	Type          TransactionType `json:"type"`
+	Amount        int             `json:"amount"`
+	SystemAmount  int             `json:"system_amount"`
+	Imbalance     int             `json:"imbalance"`
+}
@@ -2,16 +2,27 @@ package models
 
 import "time"
 
-// User represents a player in the rock-paper-scissors game
+// User represents a player in the rock-paper-scissors game.
+// DeletionScheduledAt is set while a deletion the player asked for waits out
+// its grace period. AccountToken is only set on a user just created, the one
+// time the token is known in full.
 type User struct {
-	ID           int       `json:"id" db:"id"`
-	Username     string    `json:"username" db:"username"`
-	TotalCoins   int       `json:"total_coins" db:"total_coins"`
-	CurrentStreak int      `json:"current_streak" db:"current_streak"`
-	GamesPlayed  int       `json:"games_played" db:"games_played"`
-	GamesWon     int       `json:"games_won" db:"games_won"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	ID                  int        `json:"id" db:"id"`
+	Username            string     `json:"username" db:"username"`
+	TotalCoins          int        `json:"total_coins" db:"total_coins"`
+	CurrentStreak       int        `json:"current_streak" db:"current_streak"`
+	BestStreak          int        `json:"best_streak" db:"best_streak"`
+	GamesPlayed         int        `json:"games_played" db:"games_played"`
+	GamesWon            int        `json:"games_won" db:"games_won"`
+	Timezone            string     `json:"timezone" db:"timezone"`
+	Profile             Profile    `json:"profile"`
+	ClanTag             string     `json:"clan_tag,omitempty"` // tag of the clan the user is in
+	Status              UserStatus `json:"status" db:"status"`
+	SuspendedUntil      *time.Time `json:"suspended_until,omitempty" db:"suspended_until"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty" db:"deletion_scheduled_at"`
+	AccountToken        string     `json:"-"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // UserStats represents calculated user statistics
@@ -28,11 +39,28 @@ type CreateUserRequest struct {
 
 // UserResponse represents the public user information
 type UserResponse struct {
-	ID           int     `json:"id"`
-	Username     string  `json:"username"`
-	TotalCoins   int     `json:"total_coins"`
-	CurrentStreak int     `json:"current_streak"`
-	GamesPlayed  int     `json:"games_played"`
-	GamesWon     int     `json:"games_won"`
-	WinRate      float64 `json:"win_rate"`
-} 
\ No newline at end of file
+	ID            int               `json:"id"`
+	Username      string            `json:"username"`
+	ClanTag       string            `json:"clan_tag,omitempty"`
+	TotalCoins    int               `json:"total_coins"`
+	CurrentStreak int               `json:"current_streak"`
+	BestStreak    int               `json:"best_streak"`
+	GamesPlayed   int               `json:"games_played"`
+	GamesWon      int               `json:"games_won"`
+	WinRate       float64           `json:"win_rate"`
+	Cosmetics     EquippedCosmetics `json:"cosmetics"`
+	Profile       Profile           `json:"profile"`
+}
+
+// CreateUserResponse is returned once, when an account is created. The
+// account token is needed to export or delete the account and cannot be
+// shown again.
+type CreateUserResponse struct {
+	UserResponse
+	AccountToken string `json:"account_token"`
+}
+
+// SetTimezoneRequest represents the request to change a user's timezone
+type SetTimezoneRequest struct {
+	Timezone string `json:"timezone" binding:"required"`
+}
@@ -0,0 +1,108 @@
+// Package v2 holds the response types of version 2 of the HTTP API where
+// they differ from version 1, which uses the types in internal/models
+// directly, and converts to them. Types that did not change are shared.
+package v2
+
+import "rockpaperscissors/internal/models"
+
+// PlayGameResponse is the result of a game. Version 2 groups the fields of
+// the version 1 response and always lists the new achievements.
+type PlayGameResponse struct {
+	Game            GameOutcome              `json:"game"`
+	Reward          Reward                   `json:"reward"`
+	Streak          StreakUpdate             `json:"streak"`
+	Balance         int                      `json:"balance"`
+	Message         string                   `json:"message"`
+	NewAchievements []models.UserAchievement `json:"new_achievements"`
+}
+
+// GameOutcome is what was thrown and who won
+type GameOutcome struct {
+	PlayerChoice   models.Choice     `json:"player_choice"`
+	ComputerChoice models.Choice     `json:"computer_choice"`
+	Result         models.GameResult `json:"result"`
+}
+
+// Reward is what a game paid
+type Reward struct {
+	Coins            int `json:"coins"`
+	StreakMultiplier int `json:"streak_multiplier"`
+}
+
+// StreakUpdate is the player's win streak after a game
+type StreakUpdate struct {
+	Current int  `json:"current"`
+	NewBest bool `json:"new_best"`
+}
+
+// NewPlayGameResponse converts a version 1 game result
+func NewPlayGameResponse(r *models.PlayGameResponse) PlayGameResponse {
+	achievements := r.NewAchievements
+	if achievements == nil {
+		achievements = []models.UserAchievement{}
+	}
+	return PlayGameResponse{
+		Game: GameOutcome{
+			PlayerChoice:   r.PlayerChoice,
+			ComputerChoice: r.ComputerChoice,
+			Result:         r.Result,
+		},
+		Reward: Reward{
+			Coins:            r.CoinsEarned,
+			StreakMultiplier: r.StreakMultiplier,
+		},
+		Streak: StreakUpdate{
+			Current: r.NewStreak,
+			NewBest: r.NewBestStreak,
+		},
+		Balance:         r.TotalCoins,
+		Message:         r.Message,
+		NewAchievements: achievements,
+	}
+}
+
+// LeaderboardEntry is a player's position on a leaderboard. Version 2
+// renames total_coins to coins, groups the game counts into record and
+// always has a clan_tag, null outside a clan.
+type LeaderboardEntry struct {
+	Rank      int                      `json:"rank"`
+	Username  string                   `json:"username"`
+	ClanTag   *string                  `json:"clan_tag"`
+	Coins     int                      `json:"coins"`
+	Record    Record                   `json:"record"`
+	Cosmetics models.EquippedCosmetics `json:"cosmetics"`
+	Profile   models.Profile           `json:"profile"`
+}
+
+// Record is how a player has done in their games
+type Record struct {
+	GamesPlayed   int     `json:"games_played"`
+	GamesWon      int     `json:"games_won"`
+	WinRate       float64 `json:"win_rate"`
+	CurrentStreak int     `json:"current_streak"`
+}
+
+// NewLeaderboard converts version 1 leaderboard entries
+func NewLeaderboard(entries []models.LeaderboardEntry) []LeaderboardEntry {
+	leaderboard := make([]LeaderboardEntry, len(entries))
+	for i, e := range entries {
+		leaderboard[i] = LeaderboardEntry{
+			Rank:     e.Rank,
+			Username: e.Username,
+			Coins:    e.TotalCoins,
+			Record: Record{
+				GamesPlayed:   e.GamesPlayed,
+				GamesWon:      e.GamesWon,
+				WinRate:       e.WinRate,
+				CurrentStreak: e.CurrentStreak,
+			},
+			Cosmetics: e.Cosmetics,
+			Profile:   e.Profile,
+		}
+		if e.ClanTag != "" {
+			tag := e.ClanTag
+			leaderboard[i].ClanTag = &tag
+		}
+	}
+	return leaderboard
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"time"
+)
+
+const (
+	// defaultDeletionGracePeriod is how long a player has to change their
+	// mind after asking for their account to be deleted
+	defaultDeletionGracePeriod = 30 * 24 * time.Hour
+
+	// accountExportPageSize is how many rows the data export reads at a time
+	accountExportPageSize = 500
+)
+
+// DeletionGracePeriod returns ACCOUNT_DELETION_GRACE_PERIOD, a Go duration
+// such as "720h", or 30 days when it is not set
+func DeletionGracePeriod() (time.Duration, error) {
+	raw := os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD")
+	if raw == "" {
+		return defaultDeletionGracePeriod, nil
+	}
+	grace, err := time.ParseDuration(raw)
+	if err != nil || grace < 0 {
+		return 0, fmt.Errorf("invalid ACCOUNT_DELETION_GRACE_PERIOD %q", raw)
+	}
+	return grace, nil
+}
+
+// AccountService lets players take their data with them and delete their
+// account
+type AccountService struct {
+	db           *sql.DB
+	userService  *UserService
+	gameService  *GameService
+	ledger       *LedgerService
+	achievements *AchievementService
+	admin        *AdminService
+	gracePeriod  time.Duration
+	now          func() time.Time
+}
+
+// NewAccountService creates a new account service
+func NewAccountService(db *database.DB) *AccountService {
+	grace, err := DeletionGracePeriod()
+	if err != nil {
+		grace = defaultDeletionGracePeriod // main refuses to start with a bad value
+	}
+	return &AccountService{
+		db:           db.DB,
+		userService:  NewUserService(db),
+		gameService:  NewGameService(db),
+		ledger:       NewLedgerService(db),
+		achievements: NewAchievementService(db),
+		admin:        NewAdminService(db),
+		gracePeriod:  grace,
+		now:          time.Now,
+	}
+}
+
+// ExportData writes a ZIP of JSON files holding everything kept about a
+// user: profile.json, games.json, transactions.json and achievements.json
+func (a *AccountService) ExportData(username string, w io.Writer) error {
+	user, err := a.userService.GetUser(username)
+	if err != nil {
+		return err
+	}
+
+	games := []models.Game{}
+	filter := models.GameHistoryFilter{Order: models.SortAsc, Limit: accountExportPageSize}
+	for {
+		page, next, err := a.gameService.GetUserGameHistory(username, filter)
+		if err != nil {
+			return err
+		}
+		games = append(games, page...)
+		if next == "" {
+			break
+		}
+		filter.Cursor = next
+	}
+
+	transactions := []models.CoinTransaction{}
+	for offset := 0; ; offset += accountExportPageSize {
+		page, _, err := a.ledger.GetUserTransactions(user.ID, accountExportPageSize, offset)
+		if err != nil {
+			return err
+		}
+		transactions = append(transactions, page...)
+		if len(page) < accountExportPageSize {
+			break
+		}
+	}
+
+	achievements, err := a.achievements.GetUserAchievements(username)
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+	for _, file := range []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", user},
+		{"games.json", games},
+		{"transactions.json", transactions},
+		{"achievements.json", achievements},
+	} {
+		entry, err := archive.Create(file.name)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to export: %v", file.name, err)
+		}
+		encoder := json.NewEncoder(entry)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return fmt.Errorf("failed to write %s: %v", file.name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to finish export: %v", err)
+	}
+	return nil
+}
+
+// RequestDeletion schedules a user's account for deletion once the grace
+// period is over. Until then the account is hidden from leaderboards, cannot
+// play, and can be restored with CancelDeletion.
+func (a *AccountService) RequestDeletion(username string) (*models.User, error) {
+	err := runInTx(a.db, func(tx *sql.Tx) error {
+		user, err := a.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+		if user.DeletionScheduledAt != nil {
+			return fmt.Errorf("deletion of user '%s' is already scheduled", username)
+		}
+		deleteAt := a.now().Add(a.gracePeriod).UTC().Format(sqliteTimeFormat)
+		if _, err := tx.Exec(`UPDATE users SET deletion_scheduled_at = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, deleteAt, user.ID); err != nil {
+			return fmt.Errorf("failed to schedule deletion: %v", err)
+		}
+		a.userService.leaderboard.touch(tx, user.ID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return a.userService.GetUser(username)
+}
+
+// CancelDeletion restores an account whose deletion is still in its grace
+// period
+func (a *AccountService) CancelDeletion(username string) (*models.User, error) {
+	err := runInTx(a.db, func(tx *sql.Tx) error {
+		user, err := a.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+		if user.DeletionScheduledAt == nil {
+			return fmt.Errorf("no deletion is scheduled for user '%s'", username)
+		}
+		if _, err := tx.Exec(`UPDATE users SET deletion_scheduled_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, user.ID); err != nil {
+			return fmt.Errorf("failed to cancel deletion: %v", err)
+		}
+		a.userService.leaderboard.touch(tx, user.ID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return a.userService.GetUser(username)
+}
+
+// PurgeDueDeletions deletes every account whose grace period is over, along
+// with its games and everything else, and returns how many were deleted. A
+// failure is logged and retried on the next run rather than holding up the
+// other accounts.
+func (a *AccountService) PurgeDueDeletions() (int, error) {
+	now := a.now()
+	rows, err := a.db.Query(`SELECT username FROM users WHERE deletion_scheduled_at <= ? ORDER BY deletion_scheduled_at`,
+		now.UTC().Format(sqliteTimeFormat))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query due deletions: %v", err)
+	}
+	var usernames []string
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan due deletion: %v", err)
+		}
+		usernames = append(usernames, username)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating due deletions: %v", err)
+	}
+
+	// the player may have cancelled since the query
+	stillDue := func(user *models.User) error {
+		if user.DeletionScheduledAt == nil || user.DeletionScheduledAt.After(now) {
+			return fmt.Errorf("deletion of user '%s' is no longer due", user.Username)
+		}
+		return nil
+	}
+
+	purged := 0
+	for _, username := range usernames {
+		if err := a.admin.purgeUser("system", "purge", username, "deleted at the player's request", stillDue); err != nil {
+			log.Printf("Failed to purge user %s: %v", username, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// RunPurge purges due deletions every interval until stop is closed
+func (a *AccountService) RunPurge(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			purged, err := a.PurgeDueDeletions()
+			if err != nil {
+				log.Printf("Account purge failed: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("Purged %d accounts after their deletion grace period", purged)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
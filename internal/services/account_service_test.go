@@ -0,0 +1,87 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"rockpaperscissors/internal/models"
+)
+
+func TestAccountService_Deletion(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	userService := NewUserService(db)
+	games := NewGameService(db)
+	accounts := NewAccountService(db)
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	accounts.now = func() time.Time { return now }
+
+	for _, name := range []string{"leaving", "wavering", "staying"} {
+		if _, err := userService.CreateUser(name); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if _, err := games.PlayGame(name, models.Rock); err != nil {
+			t.Fatalf("Failed to play game: %v", err)
+		}
+	}
+	leaving, _ := userService.GetUser("leaving")
+
+	for _, name := range []string{"leaving", "wavering"} {
+		user, err := accounts.RequestDeletion(name)
+		if err != nil {
+			t.Fatalf("Failed to request deletion: %v", err)
+		}
+		if user.DeletionScheduledAt == nil || !user.DeletionScheduledAt.Equal(now.Add(defaultDeletionGracePeriod)) {
+			t.Errorf("Expected deletion after the grace period, got %v", user.DeletionScheduledAt)
+		}
+	}
+	if _, err := accounts.RequestDeletion("leaving"); err == nil || !strings.Contains(err.Error(), "already scheduled") {
+		t.Errorf("Expected already scheduled error, got %v", err)
+	}
+
+	// hidden and unable to play during the grace period
+	if _, err := games.PlayGame("leaving", models.Rock); err == nil || !strings.Contains(err.Error(), "scheduled for deletion") {
+		t.Errorf("Expected scheduled for deletion error, got %v", err)
+	}
+	leaderboard, err := userService.GetLeaderboard(10)
+	if err != nil {
+		t.Fatalf("Failed to get leaderboard: %v", err)
+	}
+	if len(leaderboard) != 1 || leaderboard[0].Username != "staying" {
+		t.Errorf("Expected only staying on the leaderboard, got %+v", leaderboard)
+	}
+
+	if _, err := accounts.CancelDeletion("wavering"); err != nil {
+		t.Fatalf("Failed to cancel deletion: %v", err)
+	}
+	if _, err := accounts.CancelDeletion("staying"); err == nil || !strings.Contains(err.Error(), "no deletion is scheduled") {
+		t.Errorf("Expected no deletion is scheduled error, got %v", err)
+	}
+
+	// nothing is due until the grace period is over
+	if purged, err := accounts.PurgeDueDeletions(); err != nil || purged != 0 {
+		t.Fatalf("Expected nothing to purge yet, got %d, %v", purged, err)
+	}
+	now = now.Add(defaultDeletionGracePeriod)
+	if purged, err := accounts.PurgeDueDeletions(); err != nil || purged != 1 {
+		t.Fatalf("Expected one account purged, got %d, %v", purged, err)
+	}
+
+	if _, err := userService.GetUser("leaving"); err == nil {
+		t.Error("Expected the user to be gone")
+	}
+	var leftGames int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM games WHERE user_id = ?`, leaving.ID).Scan(&leftGames); err != nil {
+		t.Fatalf("Failed to count games: %v", err)
+	}
+	if leftGames != 0 {
+		t.Errorf("Expected the user's games to be purged, got %d", leftGames)
+	}
+	for _, name := range []string{"wavering", "staying"} {
+		if _, err := userService.GetUser(name); err != nil {
+			t.Errorf("Expected %s to be kept: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"time"
+)
+
+// achievementRules lists every achievement and the condition that unlocks it.
+// New achievements only need an entry here; IDs are persisted so they must
+// never be renamed.
+var achievementRules = []models.Achievement{
+	{ID: "first_win", Name: "First Blood", Description: "Win your first game", RewardCoins: 10, Kind: models.KindGamesWon, Threshold: 1},
+	{ID: "streak_5", Name: "On Fire", Description: "Reach a win streak of 5", RewardCoins: 50, Kind: models.KindStreak, Threshold: 5},
+	{ID: "streak_10", Name: "Unstoppable", Description: "Reach a win streak of 10", RewardCoins: 150, Kind: models.KindStreak, Threshold: 10},
+	{ID: "streak_25", Name: "Legendary", Description: "Reach a win streak of 25", RewardCoins: 500, Kind: models.KindStreak, Threshold: 25},
+	{ID: "games_100", Name: "Centurion", Description: "Play 100 games", RewardCoins: 100, Kind: models.KindGamesPlayed, Threshold: 100},
+	{ID: "all_choices", Name: "Jack of All Trades", Description: "Win with rock, paper and scissors", RewardCoins: 30, Kind: models.KindAllChoicesWon},
+	{ID: "comeback_5", Name: "Comeback Kid", Description: "Win right after losing 5 games in a row", RewardCoins: 50, Kind: models.KindComeback, Threshold: 5},
+	{ID: "beat_all_bots", Name: "Bot Buster", Description: "Beat every computer strategy", RewardCoins: 75, Kind: models.KindBeatAllBots},
+}
+
+// settledGame is the state a game leaves behind once it has been settled,
+// handed to everything that reacts to a finished game
+type settledGame struct {
+	GameID       int
+	User         models.User // user state after the game
+	Bot          models.BotStrategy
+	PlayerChoice models.Choice
+	Result       models.GameResult
+}
+
+// AchievementService evaluates achievement rules and records unlocks
+type AchievementService struct {
+	db          *sql.DB
+	stmts       *stmtCache
+	userService *UserService
+	ledger      *LedgerService
+}
+
+// NewAchievementService creates a new achievement service
+func NewAchievementService(db *database.DB) *AchievementService {
+	return &AchievementService{
+		db:          db.DB,
+		stmts:       newStmtCache(db.DB),
+		userService: NewUserService(db),
+		ledger:      NewLedgerService(db),
+	}
+}
+
+// Evaluate checks every locked achievement against a settled game, records
+// the ones that unlocked and pays their rewards through the ledger. It runs
+// inside the settlement transaction.
+func (a *AchievementService) Evaluate(tx *sql.Tx, game settledGame) ([]models.UserAchievement, error) {
+	exec := a.stmts.on(tx)
+	unlocked, err := a.unlockedIDs(exec, game.User.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var newlyUnlocked []models.UserAchievement
+	for _, rule := range achievementRules {
+		if unlocked[rule.ID] {
+			continue
+		}
+
+		met, err := a.ruleMet(exec, rule, game)
+		if err != nil {
+			return nil, err
+		}
+		if !met {
+			continue
+		}
+
+		insertQuery := `INSERT INTO user_achievements (user_id, achievement_id, game_id, unlocked_at)
+		                VALUES (?, ?, ?, CURRENT_TIMESTAMP)`
+		if _, err := exec.Exec(insertQuery, game.User.ID, rule.ID, game.GameID); err != nil {
+			return nil, fmt.Errorf("failed to record achievement: %v", err)
+		}
+
+		if _, err := a.ledger.Post(tx, game.User.ID, models.TxAchievement, rule.RewardCoins, "achievement:"+rule.ID, rule.Name); err != nil {
+			return nil, fmt.Errorf("failed to pay achievement reward: %v", err)
+		}
+
+		now := time.Now().UTC()
+		newlyUnlocked = append(newlyUnlocked, models.UserAchievement{
+			Achievement: rule,
+			Unlocked:    true,
+			UnlockedAt:  &now,
+		})
+	}
+
+	return newlyUnlocked, nil
+}
+
+// ruleMet checks a single rule against the settled game
+func (a *AchievementService) ruleMet(exec dbExecutor, rule models.Achievement, game settledGame) (bool, error) {
+	switch rule.Kind {
+	case models.KindGamesWon:
+		return game.User.GamesWon >= rule.Threshold, nil
+
+	case models.KindStreak:
+		return game.User.CurrentStreak >= rule.Threshold, nil
+
+	case models.KindGamesPlayed:
+		return game.User.GamesPlayed >= rule.Threshold, nil
+
+	case models.KindAllChoicesWon:
+		if game.Result != models.Win {
+			return false, nil
+		}
+		var distinct int
+		query := `SELECT COUNT(DISTINCT player_choice) FROM games WHERE user_id = ? AND opponent_user_id IS NULL AND result = 'win'`
+		if err := exec.QueryRow(query, game.User.ID).Scan(&distinct); err != nil {
+			return false, fmt.Errorf("failed to count winning choices: %v", err)
+		}
+		return distinct >= 3, nil
+
+	case models.KindComeback:
+		if game.Result != models.Win {
+			return false, nil
+		}
+		// ties neither break nor extend a losing run
+		query := `SELECT result FROM games
+		          WHERE user_id = ? AND opponent_user_id IS NULL AND id < ? AND result != 'tie'
+		          ORDER BY id DESC
+		          LIMIT ?`
+		rows, err := exec.Query(query, game.User.ID, game.GameID, rule.Threshold)
+		if err != nil {
+			return false, fmt.Errorf("failed to query recent results: %v", err)
+		}
+		defer rows.Close()
+
+		losses := 0
+		for rows.Next() {
+			var result string
+			if err := rows.Scan(&result); err != nil {
+				return false, fmt.Errorf("failed to scan recent result: %v", err)
+			}
+			if models.GameResult(result) != models.Lose {
+				return false, nil
+			}
+			losses++
+		}
+		if err := rows.Err(); err != nil {
+			return false, fmt.Errorf("error iterating recent results: %v", err)
+		}
+		return losses >= rule.Threshold, nil
+
+	case models.KindBeatAllBots:
+		if game.Result != models.Win {
+			return false, nil
+		}
+		var beaten int
+		query := `SELECT COUNT(DISTINCT bot) FROM games WHERE user_id = ? AND opponent_user_id IS NULL AND result = 'win'`
+		if err := exec.QueryRow(query, game.User.ID).Scan(&beaten); err != nil {
+			return false, fmt.Errorf("failed to count beaten bots: %v", err)
+		}
+		return beaten >= len(models.BotStrategies), nil
+
+	default:
+		return false, nil
+	}
+}
+
+// unlockedIDs returns the set of achievement IDs a user has unlocked
+func (a *AchievementService) unlockedIDs(exec dbExecutor, userID int) (map[string]bool, error) {
+	rows, err := exec.Query(`SELECT achievement_id FROM user_achievements WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query achievements: %v", err)
+	}
+	defer rows.Close()
+
+	unlocked := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan achievement row: %v", err)
+		}
+		unlocked[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating achievement rows: %v", err)
+	}
+
+	return unlocked, nil
+}
+
+// GetUserAchievements lists every achievement with the user's unlock state
+func (a *AchievementService) GetUserAchievements(username string) ([]models.UserAchievement, error) {
+	user, err := a.userService.GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := a.db.Query(`SELECT achievement_id, unlocked_at FROM user_achievements WHERE user_id = ?`, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query achievements: %v", err)
+	}
+	defer rows.Close()
+
+	unlockedAt := make(map[string]time.Time)
+	for rows.Next() {
+		var id string
+		var at time.Time
+		if err := rows.Scan(&id, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan achievement row: %v", err)
+		}
+		unlockedAt[id] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating achievement rows: %v", err)
+	}
+
+	achievements := make([]models.UserAchievement, 0, len(achievementRules))
+	for _, rule := range achievementRules {
+		entry := models.UserAchievement{Achievement: rule}
+		if at, ok := unlockedAt[rule.ID]; ok {
+			at := at
+			entry.Unlocked = true
+			entry.UnlockedAt = &at
+		}
+		achievements = append(achievements, entry)
+	}
+
+	return achievements, nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// setupServiceTestDB creates a temporary migrated database for service tests
+func setupServiceTestDB(t *testing.T) *database.DB {
+	db, err := database.Open(filepath.Join(t.TempDir(), "services_test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := database.RunMigrations(db.DB); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	return db
+}
+
+// evaluateAfter records a game against the random bot and evaluates
+// achievements against it
+func evaluateAfter(t *testing.T, db *database.DB, user models.User, choice models.Choice, result models.GameResult) []models.UserAchievement {
+	return evaluateAgainst(t, db, user, models.BotRandom, choice, result)
+}
+
+// evaluateAgainst records a game against bot and evaluates achievements
+// against it
+func evaluateAgainst(t *testing.T, db *database.DB, user models.User, bot models.BotStrategy, choice models.Choice, result models.GameResult) []models.UserAchievement {
+	games := NewGameService(db)
+	achievements := NewAchievementService(db)
+
+	gameID, err := games.saveGameRecord(db, user.ID, bot, choice, models.Rock, result, 0, 1)
+	if err != nil {
+		t.Fatalf("Failed to save game: %v", err)
+	}
+
+	var unlocked []models.UserAchievement
+	err = runInTx(db.DB, func(tx *sql.Tx) error {
+		var err error
+		unlocked, err = achievements.Evaluate(tx, settledGame{GameID: gameID, User: user, Bot: bot, PlayerChoice: choice, Result: result})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to evaluate achievements: %v", err)
+	}
+	return unlocked
+}
+
+// hasAchievement reports whether id is among unlocked
+func hasAchievement(unlocked []models.UserAchievement, id string) bool {
+	for _, achievement := range unlocked {
+		if achievement.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAchievementService_Evaluate(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	userService := NewUserService(db)
+
+	t.Run("Comeback after five losses", func(t *testing.T) {
+		user, _ := userService.CreateUser("comeback")
+
+		for i := 0; i < 5; i++ {
+			evaluateAfter(t, db, *user, models.Rock, models.Lose)
+		}
+		// a tie in between does not break the losing run
+		evaluateAfter(t, db, *user, models.Rock, models.Tie)
+
+		user.GamesWon = 1
+		unlocked := evaluateAfter(t, db, *user, models.Paper, models.Win)
+		if !hasAchievement(unlocked, "comeback_5") {
+			t.Errorf("Expected comeback_5 to unlock, got %v", unlocked)
+		}
+		if !hasAchievement(unlocked, "first_win") {
+			t.Errorf("Expected first_win to unlock, got %v", unlocked)
+		}
+
+		// Rewards are paid through the ledger
+		refreshed, _ := userService.GetUser("comeback")
+		if refreshed.TotalCoins != 60 {
+			t.Errorf("Expected 60 reward coins, got %d", refreshed.TotalCoins)
+		}
+	})
+
+	t.Run("Win with every choice", func(t *testing.T) {
+		user, _ := userService.CreateUser("allrounder")
+		user.GamesWon = 1
+
+		evaluateAfter(t, db, *user, models.Rock, models.Win)
+		unlocked := evaluateAfter(t, db, *user, models.Paper, models.Win)
+		if hasAchievement(unlocked, "all_choices") {
+			t.Error("all_choices should not unlock after two choices")
+		}
+		unlocked = evaluateAfter(t, db, *user, models.Scissors, models.Win)
+		if !hasAchievement(unlocked, "all_choices") {
+			t.Errorf("Expected all_choices to unlock, got %v", unlocked)
+		}
+	})
+
+	t.Run("Beat every bot", func(t *testing.T) {
+		user, _ := userService.CreateUser("botbuster")
+		user.GamesWon = 1
+
+		last := len(models.BotStrategies) - 1
+		for _, bot := range models.BotStrategies[:last] {
+			if unlocked := evaluateAgainst(t, db, *user, bot, models.Paper, models.Win); hasAchievement(unlocked, "beat_all_bots") {
+				t.Fatalf("beat_all_bots should not unlock before beating %s", models.BotStrategies[last])
+			}
+		}
+		// losing to the last bot does not count
+		evaluateAgainst(t, db, *user, models.BotStrategies[last], models.Paper, models.Lose)
+		unlocked := evaluateAgainst(t, db, *user, models.BotStrategies[last], models.Paper, models.Win)
+		if !hasAchievement(unlocked, "beat_all_bots") {
+			t.Errorf("Expected beat_all_bots to unlock, got %v", unlocked)
+		}
+	})
+
+	t.Run("Achievements unlock only once", func(t *testing.T) {
+		user, _ := userService.CreateUser("onlyonce")
+		user.GamesWon = 1
+		user.CurrentStreak = 5
+
+		unlocked := evaluateAfter(t, db, *user, models.Rock, models.Win)
+		if !hasAchievement(unlocked, "streak_5") {
+			t.Errorf("Expected streak_5 to unlock, got %v", unlocked)
+		}
+		unlocked = evaluateAfter(t, db, *user, models.Rock, models.Win)
+		if len(unlocked) != 0 {
+			t.Errorf("Expected no new achievements, got %v", unlocked)
+		}
+	})
+}
@@ -0,0 +1,378 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"strings"
+	"time"
+)
+
+const (
+	// adminRecentTransactions is how many ledger entries a user record shows
+	adminRecentTransactions = 20
+	// adminRecordActions is how many audit entries a user record shows
+	adminRecordActions = 100
+)
+
+// AdminService carries out moderation and account management. Every change
+// is written to the admin_actions audit log in the same transaction.
+type AdminService struct {
+	db          *sql.DB
+	userService *UserService
+	gameService *GameService
+	ledger      *LedgerService
+	streaks     *StreakService
+	challenges  *ChallengeService
+	tournaments *TournamentService
+	clans       *ClanService
+	profiles    *ProfileService
+	now         func() time.Time
+}
+
+// NewAdminService creates a new admin service
+func NewAdminService(db *database.DB) *AdminService {
+	return &AdminService{
+		db:          db.DB,
+		userService: NewUserService(db),
+		gameService: NewGameService(db),
+		ledger:      NewLedgerService(db),
+		streaks:     NewStreakService(db),
+		challenges:  NewChallengeService(db),
+		tournaments: NewTournamentService(db),
+		clans:       NewClanService(db),
+		profiles:    NewProfileService(db),
+		now:         time.Now,
+	}
+}
+
+// audit appends an entry to the admin audit log
+func (a *AdminService) audit(exec dbExecutor, actor, action string, user *models.User, details string) error {
+	insertQuery := `INSERT INTO admin_actions (actor, action, user_id, username, details, created_at)
+	                VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
+	if _, err := exec.Exec(insertQuery, actor, action, user.ID, user.Username, details); err != nil {
+		return fmt.Errorf("failed to write audit log: %v", err)
+	}
+	return nil
+}
+
+// SearchUsers lists users whose username contains query, optionally only
+// those with a given stored status, along with the total number of matches
+func (a *AdminService) SearchUsers(query string, status models.UserStatus, limit, offset int) ([]models.User, int, error) {
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	where := `username LIKE ? ESCAPE '\'`
+	args := []interface{}{"%" + escaper.Replace(query) + "%"}
+	if status != "" {
+		if status != models.UserActive && status != models.UserSuspended && status != models.UserBanned {
+			return nil, 0, fmt.Errorf("invalid status '%s': must be active, suspended or banned", status)
+		}
+		where += ` AND status = ?`
+		args = append(args, string(status))
+	}
+
+	var total int
+	if err := a.db.QueryRow(`SELECT COUNT(*) FROM users WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %v", err)
+	}
+
+	listQuery := `SELECT ` + userColumns + ` FROM users WHERE ` + where + ` ORDER BY username LIMIT ? OFFSET ?`
+	rows, err := a.db.Query(listQuery, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search users: %v", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user row: %v", err)
+		}
+		users = append(users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating user rows: %v", err)
+	}
+
+	return users, total, nil
+}
+
+// GetUserRecord returns a user's full record: their account, recent ledger
+// entries, the admin actions taken on them and a page of their games
+func (a *AdminService) GetUserRecord(username string, filter models.GameHistoryFilter) (*models.AdminUserRecord, error) {
+	user, err := a.userService.GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.AdminUserRecord{User: *user}
+	if err := a.db.QueryRow(`SELECT moderation_reason FROM users WHERE id = ?`, user.ID).Scan(&record.ModerationReason); err != nil {
+		return nil, fmt.Errorf("failed to get moderation reason: %v", err)
+	}
+	if record.Transactions, _, err = a.ledger.GetUserTransactions(user.ID, adminRecentTransactions, 0); err != nil {
+		return nil, err
+	}
+	if record.Actions, _, err = a.listActions("user_id = ?", []interface{}{user.ID}, adminRecordActions, 0); err != nil {
+		return nil, err
+	}
+	if record.Games, record.NextCursor, err = a.gameService.GetUserGameHistory(username, filter); err != nil {
+		return nil, err
+	}
+	if record.Games == nil {
+		record.Games = []models.Game{}
+	}
+
+	return record, nil
+}
+
+// GetActions lists the audit log newest first, along with the total
+func (a *AdminService) GetActions(limit, offset int) ([]models.AdminAction, int, error) {
+	return a.listActions("1 = 1", nil, limit, offset)
+}
+
+// listActions lists the audit entries matching where, newest first
+func (a *AdminService) listActions(where string, args []interface{}, limit, offset int) ([]models.AdminAction, int, error) {
+	var total int
+	if err := a.db.QueryRow(`SELECT COUNT(*) FROM admin_actions WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count admin actions: %v", err)
+	}
+
+	query := `SELECT id, actor, action, user_id, username, details, created_at
+	          FROM admin_actions
+	          WHERE ` + where + `
+	          ORDER BY id DESC
+	          LIMIT ? OFFSET ?`
+	rows, err := a.db.Query(query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query admin actions: %v", err)
+	}
+	defer rows.Close()
+
+	actions := []models.AdminAction{}
+	for rows.Next() {
+		var action models.AdminAction
+		var userID sql.NullInt64
+		if err := rows.Scan(&action.ID, &action.Actor, &action.Action, &userID, &action.Username, &action.Details, &action.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan admin action: %v", err)
+		}
+		if userID.Valid {
+			id := int(userID.Int64)
+			action.UserID = &id
+		}
+		actions = append(actions, action)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating admin actions: %v", err)
+	}
+
+	return actions, total, nil
+}
+
+// AdjustCoins credits or debits a user's coins through the ledger. Debits
+// cannot take the balance below zero.
+func (a *AdminService) AdjustCoins(actor, username string, amount int, reason string) (*models.CoinTransaction, error) {
+	if amount == 0 {
+		return nil, fmt.Errorf("invalid adjustment: amount must not be zero")
+	}
+
+	var entry *models.CoinTransaction
+	err := runInTx(a.db, func(tx *sql.Tx) error {
+		user, err := a.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+		if entry, err = a.ledger.Post(tx, user.ID, models.TxAdminAdjustment, amount, "admin:"+actor, reason); err != nil {
+			return err
+		}
+		return a.audit(tx, actor, "adjust_coins", user, fmt.Sprintf("%+d coins: %s", amount, reason))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// ResetStreak sets a user's current streak back to zero and ends their
+// active streak. Their best streak is kept.
+func (a *AdminService) ResetStreak(actor, username, reason string) (*models.User, error) {
+	err := runInTx(a.db, func(tx *sql.Tx) error {
+		user, err := a.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE users SET current_streak = 0, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, user.ID); err != nil {
+			return fmt.Errorf("failed to reset streak: %v", err)
+		}
+		if err := a.streaks.endActive(tx, user.ID); err != nil {
+			return err
+		}
+		return a.audit(tx, actor, "reset_streak", user, fmt.Sprintf("streak of %d reset: %s", user.CurrentStreak, reason))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return a.userService.GetUser(username)
+}
+
+// BanUser bans a user indefinitely
+func (a *AdminService) BanUser(actor, username, reason string) (*models.User, error) {
+	return a.setStatus(actor, username, "ban", models.UserBanned, nil, reason)
+}
+
+// SuspendUser bans a user until the given time
+func (a *AdminService) SuspendUser(actor, username string, until time.Time, reason string) (*models.User, error) {
+	if !until.After(a.now()) {
+		return nil, fmt.Errorf("invalid suspension: until must be in the future")
+	}
+	return a.setStatus(actor, username, "suspend", models.UserSuspended, &until, reason)
+}
+
+// ReinstateUser lifts a ban or suspension
+func (a *AdminService) ReinstateUser(actor, username, reason string) (*models.User, error) {
+	return a.setStatus(actor, username, "reinstate", models.UserActive, nil, reason)
+}
+
+// setStatus changes a user's moderation status and records why
+func (a *AdminService) setStatus(actor, username, action string, status models.UserStatus, until *time.Time, reason string) (*models.User, error) {
+	err := runInTx(a.db, func(tx *sql.Tx) error {
+		user, err := a.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+
+		var suspendedUntil interface{}
+		details := reason
+		if until != nil {
+			suspendedUntil = until.UTC().Format(sqliteTimeFormat)
+			details = fmt.Sprintf("until %s: %s", until.UTC().Format(time.RFC3339), reason)
+		}
+		moderationReason := reason
+		if status == models.UserActive {
+			moderationReason = ""
+		}
+		updateQuery := `UPDATE users SET status = ?, suspended_until = ?, moderation_reason = ?, updated_at = CURRENT_TIMESTAMP
+		                WHERE id = ?`
+		if _, err := tx.Exec(updateQuery, string(status), suspendedUntil, moderationReason, user.ID); err != nil {
+			return fmt.Errorf("failed to update user status: %v", err)
+		}
+		a.userService.leaderboard.touch(tx, user.ID)
+		return a.audit(tx, actor, action, user, details)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return a.userService.GetUser(username)
+}
+
+// RenameUser changes a user's username, for names that break the rules. The
+// new name must pass the username policy.
+func (a *AdminService) RenameUser(actor, username, newUsername, reason string) (*models.User, error) {
+	policy, err := LoadUsernamePolicy()
+	if err != nil {
+		return nil, err
+	}
+	if newUsername, err = policy.Check(newUsername); err != nil {
+		return nil, err
+	}
+
+	err = runInTx(a.db, func(tx *sql.Tx) error {
+		user, err := a.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+
+		if err := a.userService.checkUsernameAvailable(tx, newUsername, user.ID); err != nil {
+			return err
+		}
+
+		updateQuery := `UPDATE users SET username = ?, username_normalized = ?, username_skeleton = ?, updated_at = CURRENT_TIMESTAMP
+		                WHERE id = ?`
+		if _, err := tx.Exec(updateQuery, newUsername, normalizeUsername(newUsername), usernameSkeleton(newUsername), user.ID); err != nil {
+			return fmt.Errorf("failed to rename user: %v", err)
+		}
+		renamed := *user
+		renamed.Username = newUsername
+		return a.audit(tx, actor, "rename", &renamed, fmt.Sprintf("renamed from '%s': %s", username, reason))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return a.userService.GetUser(newUsername)
+}
+
+// DeleteUser deletes an account along with everything that belongs to it,
+// through the ON DELETE CASCADE foreign keys. Open challenges are called off
+// first so the other players get their stakes back, tournament matches
+// are forfeited so their opponents move on, and a clan they own passes to
+// another member.
+func (a *AdminService) DeleteUser(actor, username, reason string) error {
+	return a.purgeUser(actor, "delete", username, reason, nil)
+}
+
+// purgeUser deletes a user and everything of theirs, after calling off their
+// open challenges, forfeiting their tournament matches and taking them out
+// of their clan. check, if set, runs on the user inside the transaction and
+// can still stop the deletion.
+func (a *AdminService) purgeUser(actor, action, username, reason string, check func(*models.User) error) error {
+	var avatarURL string
+	err := runInTx(a.db, func(tx *sql.Tx) error {
+		user, err := a.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(user); err != nil {
+				return err
+			}
+		}
+		if err := a.challenges.callOffChallenges(tx, user.ID); err != nil {
+			return err
+		}
+		if err := a.tournaments.forfeitTournaments(tx, user.ID); err != nil {
+			return err
+		}
+		if err := a.clans.leaveClan(tx, user.ID); err != nil {
+			return err
+		}
+		if err := a.audit(tx, actor, action, user, fmt.Sprintf("%d coins, %d games: %s", user.TotalCoins, user.GamesPlayed, reason)); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM users WHERE id = ?`, user.ID); err != nil {
+			return fmt.Errorf("failed to delete user: %v", err)
+		}
+		a.userService.leaderboard.touch(tx, user.ID)
+		avatarURL = user.Profile.AvatarURL
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	a.profiles.removeAvatarFile(avatarURL)
+	return nil
+}
+
+// IssueAccountToken replaces a user's account token, for players who lost
+// theirs or whose account predates tokens, and returns the new token
+func (a *AdminService) IssueAccountToken(actor, username, reason string) (string, error) {
+	var token string
+	err := runInTx(a.db, func(tx *sql.Tx) error {
+		user, err := a.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+		if token, err = a.userService.issueAccountToken(tx, user.ID); err != nil {
+			return err
+		}
+		return a.audit(tx, actor, "issue_token", user, reason)
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
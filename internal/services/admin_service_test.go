@@ -0,0 +1,87 @@
+package services
+
+import (
+	"testing"
+
+	"rockpaperscissors/internal/models"
+)
+
+func TestAdminService_DeleteUser(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	userService := NewUserService(db)
+	friends := NewFriendService(db)
+	ledger := NewLedgerService(db)
+	challenges := NewChallengeService(db)
+	admin := NewAdminService(db)
+
+	for _, name := range []string{"cheater", "victim", "bystander"} {
+		user, err := userService.CreateUser(name)
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if _, err := ledger.Record(user.ID, models.TxAdminAdjustment, 100, "", "test balance"); err != nil {
+			t.Fatalf("Failed to credit user: %v", err)
+		}
+	}
+	for _, pair := range [][2]string{{"cheater", "victim"}, {"victim", "cheater"}, {"cheater", "bystander"}, {"bystander", "cheater"}} {
+		if _, err := friends.SendRequest(pair[0], pair[1]); err != nil {
+			t.Fatalf("Failed to befriend: %v", err)
+		}
+	}
+
+	// victim has a stake in an accepted match, bystander in a pending one
+	accepted, err := challenges.CreateChallenge(models.CreateChallengeRequest{Username: "cheater", Opponent: "victim", Stake: 40})
+	if err != nil {
+		t.Fatalf("Failed to create challenge: %v", err)
+	}
+	if _, err := challenges.AcceptChallenge(accepted.ID, "victim"); err != nil {
+		t.Fatalf("Failed to accept challenge: %v", err)
+	}
+	if _, err := challenges.CreateChallenge(models.CreateChallengeRequest{Username: "bystander", Opponent: "cheater", Stake: 25}); err != nil {
+		t.Fatalf("Failed to create challenge: %v", err)
+	}
+	if _, err := NewGameService(db).PlayGame("cheater", models.Rock); err != nil {
+		t.Fatalf("Failed to play game: %v", err)
+	}
+
+	cheater, err := userService.GetUser("cheater")
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if err := admin.DeleteUser("moderator", "cheater", "match fixing"); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	if _, err := userService.GetUser("cheater"); err == nil {
+		t.Error("Expected the user to be gone")
+	}
+	for table, column := range map[string]string{"games": "user_id", "coin_transactions": "user_id", "friendships": "requester_id", "challenges": "challenger_id", "streaks": "user_id"} {
+		var left int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE `+column+` = ?`, cheater.ID).Scan(&left); err != nil {
+			t.Fatalf("Failed to count %s: %v", table, err)
+		}
+		if left != 0 {
+			t.Errorf("Expected no %s left for the deleted user, got %d", table, left)
+		}
+	}
+
+	for _, name := range []string{"victim", "bystander"} {
+		user, err := userService.GetUser(name)
+		if err != nil {
+			t.Fatalf("Failed to get user: %v", err)
+		}
+		if user.TotalCoins != 100 {
+			t.Errorf("Expected %s's stake to be refunded to 100 coins, got %d", name, user.TotalCoins)
+		}
+	}
+
+	actions, _, err := admin.GetActions(10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get admin actions: %v", err)
+	}
+	if len(actions) != 1 || actions[0].Action != "delete" || actions[0].Username != "cheater" || actions[0].UserID != nil {
+		t.Errorf("Expected a delete entry that outlives the user, got %+v", actions)
+	}
+}
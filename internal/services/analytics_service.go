@@ -0,0 +1,285 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"time"
+)
+
+// allChoices lists the choices in display order
+var allChoices = []models.Choice{models.Rock, models.Paper, models.Scissors}
+
+// AnalyticsService computes how players play from their game history. Every
+// aggregation runs in SQL over the (user_id, opponent_user_id, played_at)
+// index, so only summaries are ever loaded into memory.
+type AnalyticsService struct {
+	db          *sql.DB
+	userService *UserService
+}
+
+// NewAnalyticsService creates a new analytics service
+func NewAnalyticsService(db *database.DB) *AnalyticsService {
+	return &AnalyticsService{
+		db:          db.DB,
+		userService: NewUserService(db),
+	}
+}
+
+// opponentFilter returns the SQL condition selecting games of an opponent type
+func opponentFilter(opponent models.OpponentType) (string, error) {
+	switch opponent {
+	case models.OpponentComputer:
+		return "opponent_user_id IS NULL", nil
+	case models.OpponentPlayer:
+		return "opponent_user_id IS NOT NULL", nil
+	case models.OpponentAll:
+		return "1 = 1", nil
+	default:
+		return "", fmt.Errorf("invalid opponent type '%s': must be computer, player or all", opponent)
+	}
+}
+
+// winRate returns the share of games won, or 0 without games
+func winRate(won, played int) float64 {
+	if played == 0 {
+		return 0
+	}
+	return float64(won) / float64(played)
+}
+
+// longestRun returns the longest run of result among the games matching
+// filter. Ties neither extend nor break a run, matching how streaks work.
+func longestRun(exec dbExecutor, filter string, args []interface{}, result models.GameResult) (int, error) {
+	// consecutive games with the same result share the same difference of
+	// row numbers, which groups each run together
+	query := `SELECT COALESCE(MAX(length), 0) FROM (
+	              SELECT COUNT(*) AS length FROM (
+	                  SELECT result,
+	                         ROW_NUMBER() OVER (ORDER BY played_at, id) -
+	                         ROW_NUMBER() OVER (PARTITION BY result ORDER BY played_at, id) AS run
+	                  FROM games
+	                  WHERE ` + filter + ` AND result != 'tie'
+	              )
+	              WHERE result = ?
+	              GROUP BY run
+	          )`
+	var longest int
+	if err := exec.QueryRow(query, append(args, string(result))...).Scan(&longest); err != nil {
+		return 0, fmt.Errorf("failed to compute longest streak: %v", err)
+	}
+	return longest, nil
+}
+
+// entropy returns the Shannon entropy in bits of a distribution of counts
+func entropy(counts []int) float64 {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	bits := 0.0
+	for _, count := range counts {
+		if count > 0 {
+			p := float64(count) / float64(total)
+			bits -= p * math.Log2(p)
+		}
+	}
+	return bits
+}
+
+// GetAnalytics returns the choice analytics of a player's games against an
+// opponent type
+func (a *AnalyticsService) GetAnalytics(username string, opponent models.OpponentType) (*models.PlayerAnalytics, error) {
+	filter, err := opponentFilter(opponent)
+	if err != nil {
+		return nil, err
+	}
+	user, err := a.userService.GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics := &models.PlayerAnalytics{
+		Username: user.Username,
+		Opponent: opponent,
+		Timezone: user.Timezone,
+	}
+	where := "user_id = ? AND " + filter
+	args := []interface{}{user.ID}
+
+	if err := a.choiceStats(where, args, analytics); err != nil {
+		return nil, err
+	}
+	if err := a.transitions(where, args, analytics); err != nil {
+		return nil, err
+	}
+	if err := a.hourStats(where, args, user.Timezone, analytics); err != nil {
+		return nil, err
+	}
+	if analytics.LongestWinStreak, err = longestRun(a.db, where, args, models.Win); err != nil {
+		return nil, err
+	}
+	if analytics.LongestLossStreak, err = longestRun(a.db, where, args, models.Lose); err != nil {
+		return nil, err
+	}
+
+	return analytics, nil
+}
+
+// choiceStats fills in the choice distribution, win rate per choice and the
+// entropy of the distribution
+func (a *AnalyticsService) choiceStats(where string, args []interface{}, analytics *models.PlayerAnalytics) error {
+	query := `SELECT player_choice, COUNT(*),
+	                 SUM(CASE WHEN result = 'win' THEN 1 ELSE 0 END),
+	                 SUM(CASE WHEN result = 'lose' THEN 1 ELSE 0 END),
+	                 SUM(CASE WHEN result = 'tie' THEN 1 ELSE 0 END)
+	          FROM games
+	          WHERE ` + where + `
+	          GROUP BY player_choice`
+	rows, err := a.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query choice stats: %v", err)
+	}
+	defer rows.Close()
+
+	byChoice := make(map[models.Choice]models.ChoiceStats)
+	for rows.Next() {
+		var stats models.ChoiceStats
+		var choice string
+		if err := rows.Scan(&choice, &stats.Played, &stats.Wins, &stats.Losses, &stats.Ties); err != nil {
+			return fmt.Errorf("failed to scan choice stats: %v", err)
+		}
+		stats.Choice = models.Choice(choice)
+		byChoice[stats.Choice] = stats
+		analytics.GamesPlayed += stats.Played
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating choice stats: %v", err)
+	}
+
+	counts := make([]int, 0, len(allChoices))
+	analytics.Choices = make([]models.ChoiceStats, 0, len(allChoices))
+	for _, choice := range allChoices {
+		stats := byChoice[choice]
+		stats.Choice = choice
+		stats.Share = winRate(stats.Played, analytics.GamesPlayed)
+		stats.WinRate = winRate(stats.Wins, stats.Played)
+		analytics.Choices = append(analytics.Choices, stats)
+		counts = append(counts, stats.Played)
+	}
+	analytics.ChoiceEntropy = entropy(counts)
+
+	return nil
+}
+
+// transitions fills in what the player throws after each result, and how
+// predictable that makes them: the entropy of the next choice once the last
+// result is known, scaled so 0 is uniformly random and 1 fully predictable
+func (a *AnalyticsService) transitions(where string, args []interface{}, analytics *models.PlayerAnalytics) error {
+	query := `SELECT previous_result, player_choice, COUNT(*) FROM (
+	              SELECT LAG(result) OVER (ORDER BY played_at, id) AS previous_result, player_choice
+	              FROM games
+	              WHERE ` + where + `
+	          )
+	          WHERE previous_result IS NOT NULL
+	          GROUP BY previous_result, player_choice`
+	rows, err := a.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query choice transitions: %v", err)
+	}
+	defer rows.Close()
+
+	analytics.Transitions = make(map[models.GameResult]map[models.Choice]int)
+	for _, result := range []models.GameResult{models.Win, models.Lose, models.Tie} {
+		analytics.Transitions[result] = map[models.Choice]int{models.Rock: 0, models.Paper: 0, models.Scissors: 0}
+	}
+	for rows.Next() {
+		var previous, choice string
+		var count int
+		if err := rows.Scan(&previous, &choice, &count); err != nil {
+			return fmt.Errorf("failed to scan choice transition: %v", err)
+		}
+		if next, ok := analytics.Transitions[models.GameResult(previous)]; ok {
+			next[models.Choice(choice)] = count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating choice transitions: %v", err)
+	}
+
+	// H(choice | previous result) is the entropy after each result, weighted
+	// by how often that result happened
+	total := 0
+	weighted := 0.0
+	for _, next := range analytics.Transitions {
+		counts := make([]int, 0, len(allChoices))
+		after := 0
+		for _, choice := range allChoices {
+			counts = append(counts, next[choice])
+			after += next[choice]
+		}
+		total += after
+		weighted += float64(after) * entropy(counts)
+	}
+	if total > 0 {
+		analytics.ConditionalEntropy = weighted / float64(total)
+		analytics.Predictability = 1 - analytics.ConditionalEntropy/math.Log2(float64(len(allChoices)))
+	}
+
+	return nil
+}
+
+// hourStats fills in results by hour of day in the user's timezone. Games are
+// bucketed by UTC hour in SQL and each bucket is moved to local time, which
+// keeps daylight saving changes exact.
+func (a *AnalyticsService) hourStats(where string, args []interface{}, timezone string, analytics *models.PlayerAnalytics) error {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	query := `SELECT strftime('%Y-%m-%d %H:00:00', played_at) AS bucket, COUNT(*),
+	                 SUM(CASE WHEN result = 'win' THEN 1 ELSE 0 END)
+	          FROM games
+	          WHERE ` + where + `
+	          GROUP BY bucket`
+	rows, err := a.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query hourly stats: %v", err)
+	}
+	defer rows.Close()
+
+	analytics.Hours = make([]models.HourStats, 24)
+	for hour := range analytics.Hours {
+		analytics.Hours[hour].Hour = hour
+	}
+	for rows.Next() {
+		var bucket string
+		var played, won int
+		if err := rows.Scan(&bucket, &played, &won); err != nil {
+			return fmt.Errorf("failed to scan hourly stats: %v", err)
+		}
+		at, err := time.Parse(sqliteTimeFormat, bucket)
+		if err != nil {
+			return fmt.Errorf("failed to parse hour bucket %q: %v", bucket, err)
+		}
+		hour := at.In(loc).Hour()
+		analytics.Hours[hour].GamesPlayed += played
+		analytics.Hours[hour].GamesWon += won
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating hourly stats: %v", err)
+	}
+
+	for hour := range analytics.Hours {
+		analytics.Hours[hour].WinRate = winRate(analytics.Hours[hour].GamesWon, analytics.Hours[hour].GamesPlayed)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"rockpaperscissors/internal/models"
+)
+
+func TestEntropy(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts []int
+		want   float64
+	}{
+		{"No games", []int{0, 0, 0}, 0},
+		{"Always the same", []int{5, 0, 0}, 0},
+		{"Two choices evenly", []int{4, 4, 0}, 1},
+		{"Uniform", []int{3, 3, 3}, math.Log2(3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entropy(tt.counts); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("entropy(%v) = %f, want %f", tt.counts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyticsService_GetAnalytics(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	userService := NewUserService(db)
+	analytics := NewAnalyticsService(db)
+
+	user, err := userService.CreateUser("analyst")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	rival, err := userService.CreateUser("rival")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if err := userService.SetTimezone("analyst", "Asia/Tokyo"); err != nil {
+		t.Fatalf("Failed to set timezone: %v", err)
+	}
+
+	games := []struct {
+		choice   models.Choice
+		result   models.GameResult
+		playedAt string
+	}{
+		{models.Rock, models.Win, "2025-03-01 10:00:00"},
+		{models.Rock, models.Win, "2025-03-01 10:01:00"},
+		{models.Paper, models.Lose, "2025-03-01 10:02:00"},
+		{models.Scissors, models.Tie, "2025-03-01 10:03:00"},
+		{models.Rock, models.Win, "2025-03-01 10:04:00"},
+		{models.Rock, models.Lose, "2025-03-01 10:05:00"},
+		{models.Rock, models.Lose, "2025-03-01 23:30:00"},
+	}
+	for _, game := range games {
+		_, err := db.Exec(`INSERT INTO games (user_id, player_choice, computer_choice, result, played_at) VALUES (?, ?, 'rock', ?, ?)`,
+			user.ID, string(game.choice), string(game.result), game.playedAt)
+		if err != nil {
+			t.Fatalf("Failed to insert game: %v", err)
+		}
+	}
+	_, err = db.Exec(`INSERT INTO games (user_id, player_choice, computer_choice, result, opponent_user_id, played_at) VALUES (?, 'paper', 'rock', 'win', ?, '2025-03-02 10:00:00')`,
+		user.ID, rival.ID)
+	if err != nil {
+		t.Fatalf("Failed to insert game: %v", err)
+	}
+
+	t.Run("Computer games", func(t *testing.T) {
+		got, err := analytics.GetAnalytics("analyst", models.OpponentComputer)
+		if err != nil {
+			t.Fatalf("Failed to get analytics: %v", err)
+		}
+
+		if got.GamesPlayed != 7 {
+			t.Errorf("Expected 7 games, got %d", got.GamesPlayed)
+		}
+		rock := got.Choices[0]
+		if rock.Choice != models.Rock || rock.Played != 5 || rock.Wins != 3 || rock.Losses != 2 {
+			t.Errorf("Expected rock 5 played 3-2, got %+v", rock)
+		}
+		if math.Abs(rock.WinRate-0.6) > 1e-9 {
+			t.Errorf("Expected rock win rate 0.6, got %f", rock.WinRate)
+		}
+
+		if got.Transitions[models.Win][models.Rock] != 2 || got.Transitions[models.Win][models.Paper] != 1 {
+			t.Errorf("Expected rock twice and paper once after a win, got %v", got.Transitions[models.Win])
+		}
+		if got.Transitions[models.Lose][models.Scissors] != 1 || got.Transitions[models.Tie][models.Rock] != 1 {
+			t.Errorf("Unexpected transitions %v", got.Transitions)
+		}
+		if got.Predictability <= 0 || got.Predictability >= 1 {
+			t.Errorf("Expected predictability between 0 and 1, got %f", got.Predictability)
+		}
+
+		if got.LongestWinStreak != 2 || got.LongestLossStreak != 2 {
+			t.Errorf("Expected longest streaks 2 and 2, got %d and %d", got.LongestWinStreak, got.LongestLossStreak)
+		}
+
+		// 10:00 UTC is 19:00 in Tokyo, 23:30 UTC is 08:30 the next day
+		if got.Hours[19].GamesPlayed != 6 || got.Hours[19].GamesWon != 3 || got.Hours[8].GamesPlayed != 1 {
+			t.Errorf("Expected games at 19:00 and 08:00 local time, got %+v", got.Hours)
+		}
+	})
+
+	t.Run("All games", func(t *testing.T) {
+		got, err := analytics.GetAnalytics("analyst", models.OpponentAll)
+		if err != nil {
+			t.Fatalf("Failed to get analytics: %v", err)
+		}
+		if got.GamesPlayed != 8 {
+			t.Errorf("Expected 8 games, got %d", got.GamesPlayed)
+		}
+	})
+
+	t.Run("Invalid opponent type", func(t *testing.T) {
+		if _, err := analytics.GetAnalytics("analyst", "robots"); err == nil {
+			t.Error("Expected an invalid opponent type error")
+		}
+	})
+}
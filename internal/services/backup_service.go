@@ -0,0 +1,289 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+)
+
+const (
+	backupPrefix     = "rockpaperscissors-"
+	backupSuffix     = ".db"
+	backupTimeFormat = "20060102T150405Z"
+)
+
+// BackupConfig says where and how often the server backs up its database
+type BackupConfig struct {
+	Dir       string
+	Interval  time.Duration // 0 turns scheduled backups off
+	Retention models.BackupRetention
+
+	WALArchiveDir       string // "" turns WAL archiving off
+	WALArchiveInterval  time.Duration
+	WALArchiveRetention time.Duration
+}
+
+// DefaultBackupDir is where backups go when BACKUP_DIR is not set
+var DefaultBackupDir = filepath.Join("data", "backups")
+
+// defaultBackupConfig is used for whatever the environment does not set
+var defaultBackupConfig = BackupConfig{
+	Dir:                 DefaultBackupDir,
+	Interval:            6 * time.Hour,
+	Retention:           models.BackupRetention{KeepLast: 4, KeepDaily: 7, KeepWeekly: 4},
+	WALArchiveInterval:  10 * time.Second,
+	WALArchiveRetention: 72 * time.Hour,
+}
+
+// LoadBackupConfig reads the backup schedule from BACKUP_DIR,
+// BACKUP_INTERVAL, BACKUP_KEEP_LAST, BACKUP_KEEP_DAILY and BACKUP_KEEP_WEEKLY,
+// and WAL archiving from WAL_ARCHIVE_DIR, WAL_ARCHIVE_INTERVAL and
+// WAL_ARCHIVE_RETENTION
+func LoadBackupConfig() (BackupConfig, error) {
+	config := defaultBackupConfig
+	if dir := os.Getenv("BACKUP_DIR"); dir != "" {
+		config.Dir = dir
+	}
+	config.WALArchiveDir = os.Getenv("WAL_ARCHIVE_DIR")
+
+	durations := []struct {
+		name      string
+		value     *time.Duration
+		allowZero bool
+	}{
+		{"BACKUP_INTERVAL", &config.Interval, true},
+		{"WAL_ARCHIVE_INTERVAL", &config.WALArchiveInterval, false},
+		{"WAL_ARCHIVE_RETENTION", &config.WALArchiveRetention, false},
+	}
+	for _, d := range durations {
+		raw := os.Getenv(d.name)
+		if raw == "" {
+			continue
+		}
+		value, err := time.ParseDuration(raw)
+		if err != nil || value < 0 || (value == 0 && !d.allowZero) {
+			return config, fmt.Errorf("invalid %s %q", d.name, raw)
+		}
+		*d.value = value
+	}
+
+	counts := []struct {
+		name  string
+		value *int
+	}{
+		{"BACKUP_KEEP_LAST", &config.Retention.KeepLast},
+		{"BACKUP_KEEP_DAILY", &config.Retention.KeepDaily},
+		{"BACKUP_KEEP_WEEKLY", &config.Retention.KeepWeekly},
+	}
+	for _, c := range counts {
+		raw := os.Getenv(c.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			return config, fmt.Errorf("invalid %s %q", c.name, raw)
+		}
+		*c.value = value
+	}
+	return config, nil
+}
+
+// BackupService takes online backups of the database into a directory and
+// prunes them by a retention policy
+type BackupService struct {
+	db        *sql.DB
+	dir       string
+	retention models.BackupRetention
+	now       func() time.Time
+}
+
+// NewBackupService creates a new backup service
+func NewBackupService(db *database.DB, dir string, retention models.BackupRetention) *BackupService {
+	return &BackupService{
+		db:        db.DB,
+		dir:       dir,
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+// CreateBackup copies the database into a new, integrity-checked backup
+// file. The server can keep running while it does.
+func (b *BackupService) CreateBackup() (*models.BackupInfo, error) {
+	if err := os.MkdirAll(b.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %v", err)
+	}
+
+	created := b.now().UTC().Truncate(time.Second)
+	name := backupPrefix + created.Format(backupTimeFormat) + backupSuffix
+	path := filepath.Join(b.dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("backup %s already exists", name)
+	}
+	if err := database.Backup(b.db, path); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %v", err)
+	}
+	return &models.BackupInfo{Name: name, Path: path, Size: info.Size(), CreatedAt: created}, nil
+}
+
+// ListBackups returns the backups in the directory, newest first
+func (b *BackupService) ListBackups() ([]models.BackupInfo, error) {
+	entries, err := os.ReadDir(b.dir)
+	if os.IsNotExist(err) {
+		return []models.BackupInfo{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %v", err)
+	}
+
+	backups := []models.BackupInfo{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+			continue
+		}
+		created, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix))
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list backups: %v", err)
+		}
+		backups = append(backups, models.BackupInfo{
+			Name:      name,
+			Path:      filepath.Join(b.dir, name),
+			Size:      info.Size(),
+			CreatedAt: created,
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+// PruneBackups deletes the backups the retention policy does not keep and
+// returns them. The newest backup is always kept.
+func (b *BackupService) PruneBackups() ([]models.BackupInfo, error) {
+	backups, err := b.ListBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	keep := backupsToKeep(backups, b.retention)
+	removed := []models.BackupInfo{}
+	for i, backup := range backups {
+		if i == 0 || keep[backup.Name] {
+			continue
+		}
+		if err := os.Remove(backup.Path); err != nil {
+			return removed, fmt.Errorf("failed to remove backup %s: %v", backup.Name, err)
+		}
+		removed = append(removed, backup)
+	}
+	return removed, nil
+}
+
+// backupsToKeep applies a retention policy to backups sorted newest first
+func backupsToKeep(backups []models.BackupInfo, retention models.BackupRetention) map[string]bool {
+	keep := make(map[string]bool)
+	for i := 0; i < retention.KeepLast && i < len(backups); i++ {
+		keep[backups[i].Name] = true
+	}
+
+	// the first backup seen of a period is its newest
+	keepNewestPer := func(periods int, period func(time.Time) string) {
+		seen := make(map[string]bool)
+		for _, backup := range backups {
+			key := period(backup.CreatedAt.UTC())
+			if seen[key] {
+				continue
+			}
+			if len(seen) == periods {
+				return
+			}
+			seen[key] = true
+			keep[backup.Name] = true
+		}
+	}
+	keepNewestPer(retention.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") })
+	keepNewestPer(retention.KeepWeekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+	return keep
+}
+
+// RunBackups backs up the database and prunes old backups every interval
+// until stop is closed
+func (b *BackupService) RunBackups(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			backup, err := b.CreateBackup()
+			if err != nil {
+				log.Printf("Database backup failed: %v", err)
+				continue
+			}
+			log.Printf("Backed up database to %s (%d bytes)", backup.Path, backup.Size)
+
+			removed, err := b.PruneBackups()
+			if err != nil {
+				log.Printf("Backup pruning failed: %v", err)
+			}
+			for _, old := range removed {
+				log.Printf("Removed old backup %s", old.Path)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// RunWALArchiving archives the database's write-ahead log every interval
+// until stop is closed, then closes the archiver. Generations of the archive
+// older than retention are removed whenever a new one starts.
+func (b *BackupService) RunWALArchiving(archiver *database.WALArchiver, interval, retention time.Duration, stop <-chan struct{}) {
+	defer archiver.Close()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		generation := archiver.Generation()
+		if err := archiver.Sync(); err != nil {
+			log.Printf("WAL archiving failed: %v", err)
+		} else if archiver.Generation() != generation {
+			log.Printf("Started WAL archive generation %s", archiver.Generation())
+			removed, err := archiver.Prune(retention)
+			if err != nil {
+				log.Printf("WAL archive pruning failed: %v", err)
+			}
+			for _, name := range removed {
+				log.Printf("Removed WAL archive generation %s", name)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
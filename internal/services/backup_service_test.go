@@ -0,0 +1,126 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+)
+
+func TestBackupService_CreateListPrune(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	if _, err := NewUserService(db).CreateUser("alice"); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	backups := NewBackupService(db, filepath.Join(t.TempDir(), "backups"), models.BackupRetention{KeepLast: 2})
+	if listed, err := backups.ListBackups(); err != nil || len(listed) != 0 {
+		t.Fatalf("Expected no backups before the first, got %v, %v", listed, err)
+	}
+
+	clock := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	backups.now = func() time.Time { return clock }
+	for i := 0; i < 4; i++ {
+		backup, err := backups.CreateBackup()
+		if err != nil {
+			t.Fatalf("Failed to create backup: %v", err)
+		}
+		if backup.Size == 0 || !backup.CreatedAt.Equal(clock) {
+			t.Errorf("Expected a backup taken at %v, got %+v", clock, backup)
+		}
+		if err := database.CheckIntegrity(backup.Path); err != nil {
+			t.Errorf("Expected the backup to pass its integrity check: %v", err)
+		}
+		clock = clock.Add(time.Hour)
+	}
+	if _, err := backups.CreateBackup(); err != nil {
+		t.Fatalf("Failed to create backup: %v", err)
+	}
+	if _, err := backups.CreateBackup(); err == nil {
+		t.Errorf("Expected a second backup in the same second to be refused")
+	}
+
+	removed, err := backups.PruneBackups()
+	if err != nil {
+		t.Fatalf("Failed to prune backups: %v", err)
+	}
+	if len(removed) != 3 {
+		t.Errorf("Expected 3 backups removed, got %d", len(removed))
+	}
+	listed, err := backups.ListBackups()
+	if err != nil {
+		t.Fatalf("Failed to list backups: %v", err)
+	}
+	if len(listed) != 2 || !listed[0].CreatedAt.Equal(clock) {
+		t.Errorf("Expected the 2 newest backups, newest first, got %+v", listed)
+	}
+}
+
+func TestBackupsToKeep(t *testing.T) {
+	// a backup every 12 hours for 4 weeks, newest first
+	newest := time.Date(2024, 3, 31, 18, 0, 0, 0, time.UTC) // a Sunday
+	var backups []models.BackupInfo
+	for i := 0; i < 56; i++ {
+		at := newest.Add(time.Duration(-12*i) * time.Hour)
+		backups = append(backups, models.BackupInfo{Name: at.Format(backupTimeFormat), CreatedAt: at})
+	}
+
+	tests := []struct {
+		name      string
+		retention models.BackupRetention
+		want      []string
+	}{
+		{"Keep last", models.BackupRetention{KeepLast: 3},
+			[]string{"20240331T180000Z", "20240331T060000Z", "20240330T180000Z"}},
+		{"Keep daily", models.BackupRetention{KeepDaily: 2},
+			[]string{"20240331T180000Z", "20240330T180000Z"}},
+		{"Keep weekly", models.BackupRetention{KeepWeekly: 2},
+			[]string{"20240331T180000Z", "20240324T180000Z"}},
+		{"Rules add up", models.BackupRetention{KeepLast: 2, KeepDaily: 2, KeepWeekly: 2},
+			[]string{"20240331T180000Z", "20240331T060000Z", "20240330T180000Z", "20240324T180000Z"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keep := backupsToKeep(backups, tt.retention)
+			if len(keep) != len(tt.want) {
+				t.Errorf("Expected %d backups kept, got %v", len(tt.want), keep)
+			}
+			for _, name := range tt.want {
+				if !keep[name] {
+					t.Errorf("Expected %s to be kept, got %v", name, keep)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadBackupConfig(t *testing.T) {
+	t.Setenv("BACKUP_INTERVAL", "0")
+	t.Setenv("BACKUP_KEEP_DAILY", "14")
+	t.Setenv("WAL_ARCHIVE_DIR", "/var/backups/wal")
+
+	config, err := LoadBackupConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if config.Interval != 0 || config.Retention.KeepDaily != 14 || config.Retention.KeepLast != 4 {
+		t.Errorf("Expected scheduled backups off and 14 daily backups kept, got %+v", config)
+	}
+	if config.WALArchiveDir != "/var/backups/wal" || config.WALArchiveInterval != 10*time.Second {
+		t.Errorf("Expected WAL archiving every 10s, got %+v", config)
+	}
+
+	t.Setenv("WAL_ARCHIVE_INTERVAL", "0")
+	if _, err := LoadBackupConfig(); err == nil {
+		t.Errorf("Expected a zero WAL archive interval to be rejected")
+	}
+	t.Setenv("WAL_ARCHIVE_INTERVAL", "")
+	t.Setenv("BACKUP_KEEP_LAST", "-1")
+	if _, err := LoadBackupConfig(); err == nil {
+		t.Errorf("Expected a negative count to be rejected")
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"rockpaperscissors/internal/models"
+)
+
+// botMemory is what a bot remembers of the games a player has played
+// against it
+type botMemory struct {
+	LastPlayer models.Choice // the player's last move, empty before the first game
+	LastBot    models.Choice // the bot's own last move
+	Counts     map[models.Choice]int
+}
+
+// winningChoice returns the move that beats choice
+func winningChoice(choice models.Choice) models.Choice {
+	switch choice {
+	case models.Rock:
+		return models.Paper
+	case models.Paper:
+		return models.Scissors
+	default:
+		return models.Rock
+	}
+}
+
+// botOffScriptChance is how often a bot throws a random move instead of
+// following its strategy. Without it every bot but random is beaten every
+// game, and its rewards, once a player has worked it out.
+const botOffScriptChance = 0.5
+
+// ChooseBotMove picks the computer's move for a bot. Every bot plays at
+// random until it has seen the player, and off script now and then after.
+func (g *GameLogicService) ChooseBotMove(bot models.BotStrategy, memory botMemory) models.Choice {
+	if memory.LastPlayer == "" || g.rng.Float64() < botOffScriptChance {
+		return g.GenerateComputerChoice()
+	}
+	return g.scriptedBotMove(bot, memory)
+}
+
+// scriptedBotMove is the move bot's strategy calls for given memory
+func (g *GameLogicService) scriptedBotMove(bot models.BotStrategy, memory botMemory) models.Choice {
+	switch bot {
+	case models.BotCycle:
+		return winningChoice(memory.LastBot)
+	case models.BotMirror:
+		return memory.LastPlayer
+	case models.BotCounter:
+		return winningChoice(memory.LastPlayer)
+	case models.BotFrequency:
+		// ties go to the move seen most recently, then in rock, paper,
+		// scissors order
+		favourite := memory.LastPlayer
+		for _, choice := range []models.Choice{models.Rock, models.Paper, models.Scissors} {
+			if memory.Counts[choice] > memory.Counts[favourite] {
+				favourite = choice
+			}
+		}
+		return winningChoice(favourite)
+	default:
+		return g.GenerateComputerChoice()
+	}
+}
+
+// botMemory loads what a bot remembers of a player from their games
+// against it
+func (g *GameService) botMemory(exec dbExecutor, userID int, bot models.BotStrategy) (botMemory, error) {
+	memory := botMemory{Counts: make(map[models.Choice]int)}
+	if bot == models.BotRandom {
+		return memory, nil
+	}
+
+	lastQuery := `SELECT player_choice, computer_choice FROM games
+	              WHERE user_id = ? AND opponent_user_id IS NULL AND bot = ?
+	              ORDER BY id DESC
+	              LIMIT 1`
+	var lastPlayer, lastBot string
+	err := exec.QueryRow(lastQuery, userID, string(bot)).Scan(&lastPlayer, &lastBot)
+	if err == sql.ErrNoRows {
+		return memory, nil
+	}
+	if err != nil {
+		return memory, fmt.Errorf("failed to query last game against %s: %v", bot, err)
+	}
+	memory.LastPlayer, memory.LastBot = models.Choice(lastPlayer), models.Choice(lastBot)
+
+	if bot != models.BotFrequency {
+		return memory, nil
+	}
+	countQuery := `SELECT player_choice, COUNT(*) FROM games
+	               WHERE user_id = ? AND opponent_user_id IS NULL AND bot = ?
+	               GROUP BY player_choice`
+	rows, err := exec.Query(countQuery, userID, string(bot))
+	if err != nil {
+		return memory, fmt.Errorf("failed to count moves against %s: %v", bot, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var choice string
+		var count int
+		if err := rows.Scan(&choice, &count); err != nil {
+			return memory, fmt.Errorf("failed to scan move count: %v", err)
+		}
+		memory.Counts[models.Choice(choice)] = count
+	}
+	if err := rows.Err(); err != nil {
+		return memory, fmt.Errorf("error iterating move counts: %v", err)
+	}
+	return memory, nil
+}
@@ -0,0 +1,598 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"time"
+)
+
+// maxChallengeBestOf caps the length of a challenge match
+const maxChallengeBestOf = 9
+
+// defaultChallengeExpiry is how long a friend has to respond to a challenge
+const defaultChallengeExpiry = 24 * time.Hour
+
+// maxChallengeExpiry caps how long a challenge may stay open
+const maxChallengeExpiry = 7 * 24 * time.Hour
+
+// challengeMoveTimeout is how long both players have to play each round of
+// an accepted challenge before the idle one forfeits
+const challengeMoveTimeout = 24 * time.Hour
+
+// ChallengeService handles direct challenges between friends
+type ChallengeService struct {
+	db            *sql.DB
+	gameLogic     *GameLogicService
+	gameService   *GameService
+	userService   *UserService
+	friendService *FriendService
+	ledger        *LedgerService
+	now           func() time.Time
+}
+
+// NewChallengeService creates a new challenge service
+func NewChallengeService(db *database.DB) *ChallengeService {
+	return &ChallengeService{
+		db:            db.DB,
+		gameLogic:     NewGameLogicService(),
+		gameService:   NewGameService(db),
+		userService:   NewUserService(db),
+		friendService: NewFriendService(db),
+		ledger:        NewLedgerService(db),
+		now:           time.Now,
+	}
+}
+
+// challengeRow is a challenge along with the IDs of both players
+type challengeRow struct {
+	models.Challenge
+	challengerID int
+	opponentID   int
+}
+
+// winsNeeded is how many rounds a player must take to win the match
+func winsNeeded(bestOf int) int {
+	return bestOf/2 + 1
+}
+
+// CreateChallenge invites a friend to a best-of-N match. The challenger's
+// stake is held in escrow until the match is settled or called off.
+func (c *ChallengeService) CreateChallenge(req models.CreateChallengeRequest) (*models.Challenge, error) {
+	bestOf := req.BestOf
+	if bestOf == 0 {
+		bestOf = 1
+	}
+	if bestOf < 1 || bestOf > maxChallengeBestOf || bestOf%2 == 0 {
+		return nil, fmt.Errorf("invalid best_of %d: must be an odd number between 1 and %d", req.BestOf, maxChallengeBestOf)
+	}
+	if req.Stake < 0 {
+		return nil, fmt.Errorf("invalid stake %d", req.Stake)
+	}
+	expiry := defaultChallengeExpiry
+	if req.ExpiresInMinutes > 0 {
+		expiry = time.Duration(req.ExpiresInMinutes) * time.Minute
+	}
+	if expiry > maxChallengeExpiry {
+		return nil, fmt.Errorf("invalid expiry: challenges may stay open for at most %s", maxChallengeExpiry)
+	}
+	if req.Username == req.Opponent {
+		return nil, fmt.Errorf("cannot challenge yourself")
+	}
+
+	var challengeID int
+	err := runInTx(c.db, func(tx *sql.Tx) error {
+		challenger, err := c.userService.getUser(tx, req.Username)
+		if err != nil {
+			return err
+		}
+		opponent, err := c.userService.getUser(tx, req.Opponent)
+		if err != nil {
+			return err
+		}
+
+		friends, err := c.friendService.areFriends(tx, challenger.ID, opponent.ID)
+		if err != nil {
+			return err
+		}
+		if !friends {
+			return fmt.Errorf("can only challenge friends: '%s' is not a friend", req.Opponent)
+		}
+
+		expiresAt := c.now().UTC().Add(expiry).Format(sqliteTimeFormat)
+		insertQuery := `INSERT INTO challenges (challenger_id, opponent_id, best_of, stake, status, created_at, expires_at)
+		                VALUES (?, ?, ?, ?, 'pending', CURRENT_TIMESTAMP, ?)`
+		result, err := tx.Exec(insertQuery, challenger.ID, opponent.ID, bestOf, req.Stake, expiresAt)
+		if err != nil {
+			return fmt.Errorf("failed to create challenge: %v", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get challenge ID: %v", err)
+		}
+		challengeID = int(id)
+
+		_, err = c.ledger.Post(tx, challenger.ID, models.TxWager, -req.Stake, challengeReference(challengeID), fmt.Sprintf("Stake for challenge #%d", challengeID))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetChallenge(challengeID)
+}
+
+// challengeReference is the ledger reference of every entry for a challenge
+func challengeReference(challengeID int) string {
+	return fmt.Sprintf("challenge:%d", challengeID)
+}
+
+// getChallenge loads a challenge and its rounds
+func (c *ChallengeService) getChallenge(exec dbExecutor, challengeID int) (*challengeRow, error) {
+	var ch challengeRow
+	var status string
+	var winner sql.NullString
+	var moveDeadline, completedAt sql.NullTime
+
+	query := `SELECT c.id, c.challenger_id, cu.username, c.opponent_id, ou.username, c.best_of, c.stake,
+	                 c.status, c.challenger_wins, c.opponent_wins, wu.username, c.created_at, c.expires_at,
+	                 c.move_deadline, c.completed_at
+	          FROM challenges c
+	          JOIN users cu ON cu.id = c.challenger_id
+	          JOIN users ou ON ou.id = c.opponent_id
+	          LEFT JOIN users wu ON wu.id = c.winner_id
+	          WHERE c.id = ?`
+	err := exec.QueryRow(query, challengeID).Scan(
+		&ch.ID, &ch.challengerID, &ch.Challenger, &ch.opponentID, &ch.Opponent, &ch.BestOf, &ch.Stake,
+		&status, &ch.ChallengerWins, &ch.OpponentWins, &winner, &ch.CreatedAt, &ch.ExpiresAt,
+		&moveDeadline, &completedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("challenge %d not found", challengeID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get challenge: %v", err)
+	}
+	ch.Status = models.ChallengeStatus(status)
+	ch.Winner = winner.String
+	if moveDeadline.Valid && ch.Status == models.ChallengeAccepted {
+		ch.MoveDeadline = &moveDeadline.Time
+	}
+	if completedAt.Valid {
+		ch.CompletedAt = &completedAt.Time
+	}
+
+	rows, err := exec.Query(`SELECT round, challenger_choice, opponent_choice, winner
+	                         FROM challenge_rounds WHERE challenge_id = ? ORDER BY round`, challengeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query challenge rounds: %v", err)
+	}
+	defer rows.Close()
+
+	ch.Rounds = []models.ChallengeRound{}
+	for rows.Next() {
+		var round models.ChallengeRound
+		var challengerChoice, opponentChoice, roundWinner sql.NullString
+		if err := rows.Scan(&round.Number, &challengerChoice, &opponentChoice, &roundWinner); err != nil {
+			return nil, fmt.Errorf("failed to scan challenge round: %v", err)
+		}
+		round.ChallengerMoved = challengerChoice.Valid
+		round.OpponentMoved = opponentChoice.Valid
+		round.Winner = models.RoundWinner(roundWinner.String)
+		// choices stay secret until the round is resolved
+		if roundWinner.Valid {
+			round.ChallengerChoice = models.Choice(challengerChoice.String)
+			round.OpponentChoice = models.Choice(opponentChoice.String)
+		}
+		ch.Rounds = append(ch.Rounds, round)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating challenge rounds: %v", err)
+	}
+
+	return &ch, nil
+}
+
+// GetChallenge returns a challenge with its rounds
+func (c *ChallengeService) GetChallenge(challengeID int) (*models.Challenge, error) {
+	if _, err := c.ExpireChallenges(); err != nil {
+		return nil, err
+	}
+	ch, err := c.getChallenge(c.db, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	return &ch.Challenge, nil
+}
+
+// GetUserChallenges lists the challenges a user sent or received, newest
+// first, optionally filtered by status
+func (c *ChallengeService) GetUserChallenges(username string, status models.ChallengeStatus) ([]models.Challenge, error) {
+	user, err := c.userService.GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.ExpireChallenges(); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id FROM challenges
+	          WHERE (challenger_id = ? OR opponent_id = ?) AND (? = '' OR status = ?)
+	          ORDER BY id DESC`
+	ids, err := queryIDs(c.db, query, user.ID, user.ID, string(status), string(status))
+	if err != nil {
+		return nil, err
+	}
+
+	challenges := make([]models.Challenge, 0, len(ids))
+	for _, id := range ids {
+		ch, err := c.getChallenge(c.db, id)
+		if err != nil {
+			return nil, err
+		}
+		challenges = append(challenges, ch.Challenge)
+	}
+
+	return challenges, nil
+}
+
+// respond applies an action to a pending challenge on behalf of username
+func (c *ChallengeService) respond(challengeID int, username string, fn func(tx *sql.Tx, ch *challengeRow, user *models.User) error) (*models.Challenge, error) {
+	if _, err := c.ExpireChallenges(); err != nil {
+		return nil, err
+	}
+
+	err := runInTx(c.db, func(tx *sql.Tx) error {
+		user, err := c.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+		ch, err := c.getChallenge(tx, challengeID)
+		if err != nil {
+			return err
+		}
+		if ch.Status != models.ChallengePending {
+			return fmt.Errorf("challenge %d is not open: it is %s", challengeID, ch.Status)
+		}
+		return fn(tx, ch, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetChallenge(challengeID)
+}
+
+// setStatus moves a challenge to a new status
+func (c *ChallengeService) setStatus(exec dbExecutor, challengeID int, status models.ChallengeStatus) error {
+	updateQuery := `UPDATE challenges SET status = ?, responded_at = CURRENT_TIMESTAMP WHERE id = ?`
+	if _, err := exec.Exec(updateQuery, string(status), challengeID); err != nil {
+		return fmt.Errorf("failed to update challenge: %v", err)
+	}
+	return nil
+}
+
+// refundStake returns the challenger's escrowed stake
+func (c *ChallengeService) refundStake(tx *sql.Tx, ch *challengeRow, reason string) error {
+	_, err := c.ledger.Post(tx, ch.challengerID, models.TxWager, ch.Stake, challengeReference(ch.ID), reason)
+	return err
+}
+
+// resetMoveDeadline gives both players a fresh challengeMoveTimeout to play
+// the next round
+func (c *ChallengeService) resetMoveDeadline(tx *sql.Tx, challengeID int) error {
+	deadline := c.now().UTC().Add(challengeMoveTimeout).Format(sqliteTimeFormat)
+	if _, err := tx.Exec(`UPDATE challenges SET move_deadline = ? WHERE id = ?`, deadline, challengeID); err != nil {
+		return fmt.Errorf("failed to set challenge move deadline: %v", err)
+	}
+	return nil
+}
+
+// AcceptChallenge accepts a challenge; the opponent's stake goes into escrow
+// alongside the challenger's and the clock starts on the first round
+func (c *ChallengeService) AcceptChallenge(challengeID int, username string) (*models.Challenge, error) {
+	return c.respond(challengeID, username, func(tx *sql.Tx, ch *challengeRow, user *models.User) error {
+		if user.ID != ch.opponentID {
+			return fmt.Errorf("only the challenged player can accept challenge %d", ch.ID)
+		}
+		if _, err := c.ledger.Post(tx, user.ID, models.TxWager, -ch.Stake, challengeReference(ch.ID), fmt.Sprintf("Stake for challenge #%d", ch.ID)); err != nil {
+			return err
+		}
+		if err := c.setStatus(tx, ch.ID, models.ChallengeAccepted); err != nil {
+			return err
+		}
+		return c.resetMoveDeadline(tx, ch.ID)
+	})
+}
+
+// DeclineChallenge declines a challenge and refunds the challenger
+func (c *ChallengeService) DeclineChallenge(challengeID int, username string) (*models.Challenge, error) {
+	return c.respond(challengeID, username, func(tx *sql.Tx, ch *challengeRow, user *models.User) error {
+		if user.ID != ch.opponentID {
+			return fmt.Errorf("only the challenged player can decline challenge %d", ch.ID)
+		}
+		if err := c.refundStake(tx, ch, fmt.Sprintf("Challenge #%d declined", ch.ID)); err != nil {
+			return err
+		}
+		return c.setStatus(tx, ch.ID, models.ChallengeDeclined)
+	})
+}
+
+// CancelChallenge withdraws a challenge that has not been answered yet
+func (c *ChallengeService) CancelChallenge(challengeID int, username string) (*models.Challenge, error) {
+	return c.respond(challengeID, username, func(tx *sql.Tx, ch *challengeRow, user *models.User) error {
+		if user.ID != ch.challengerID {
+			return fmt.Errorf("only the challenger can cancel challenge %d", ch.ID)
+		}
+		if err := c.refundStake(tx, ch, fmt.Sprintf("Challenge #%d cancelled", ch.ID)); err != nil {
+			return err
+		}
+		return c.setStatus(tx, ch.ID, models.ChallengeCancelled)
+	})
+}
+
+// SubmitMove records a player's choice for the current round. Once both
+// players have moved the round is resolved, and the match is settled when
+// either player has won enough rounds. Tied rounds are replayed.
+func (c *ChallengeService) SubmitMove(challengeID int, req models.ChallengeMoveRequest) (*models.Challenge, error) {
+	if !req.PlayerChoice.IsValid() {
+		return nil, fmt.Errorf("invalid choice: %s", req.PlayerChoice)
+	}
+	if _, err := c.ExpireChallenges(); err != nil {
+		return nil, err
+	}
+
+	err := runInTx(c.db, func(tx *sql.Tx) error {
+		user, err := c.userService.getUser(tx, req.Username)
+		if err != nil {
+			return err
+		}
+		ch, err := c.getChallenge(tx, challengeID)
+		if err != nil {
+			return err
+		}
+		if ch.Status != models.ChallengeAccepted {
+			return fmt.Errorf("challenge %d is not in play: it is %s", challengeID, ch.Status)
+		}
+
+		column := ""
+		switch user.ID {
+		case ch.challengerID:
+			column = "challenger_choice"
+		case ch.opponentID:
+			column = "opponent_choice"
+		default:
+			return fmt.Errorf("only the challenged players can move in challenge %d", ch.ID)
+		}
+
+		// the current round is the last one, unless it has been resolved
+		round := len(ch.Rounds) + 1
+		if len(ch.Rounds) > 0 && ch.Rounds[len(ch.Rounds)-1].Winner == "" {
+			current := ch.Rounds[len(ch.Rounds)-1]
+			round = current.Number
+			if (user.ID == ch.challengerID && current.ChallengerMoved) || (user.ID == ch.opponentID && current.OpponentMoved) {
+				return fmt.Errorf("already moved in round %d of challenge %d", round, ch.ID)
+			}
+		} else {
+			insertQuery := `INSERT INTO challenge_rounds (challenge_id, round) VALUES (?, ?)`
+			if _, err := tx.Exec(insertQuery, ch.ID, round); err != nil {
+				return fmt.Errorf("failed to start challenge round: %v", err)
+			}
+		}
+
+		updateQuery := `UPDATE challenge_rounds SET ` + column + ` = ? WHERE challenge_id = ? AND round = ?`
+		if _, err := tx.Exec(updateQuery, string(req.PlayerChoice), ch.ID, round); err != nil {
+			return fmt.Errorf("failed to record move: %v", err)
+		}
+
+		return c.resolveRound(tx, ch, round)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetChallenge(challengeID)
+}
+
+// resolveRound settles a round once both players have moved, and the match
+// once a player has won enough rounds
+func (c *ChallengeService) resolveRound(tx *sql.Tx, ch *challengeRow, round int) error {
+	var challengerChoice, opponentChoice sql.NullString
+	query := `SELECT challenger_choice, opponent_choice FROM challenge_rounds WHERE challenge_id = ? AND round = ?`
+	if err := tx.QueryRow(query, ch.ID, round).Scan(&challengerChoice, &opponentChoice); err != nil {
+		return fmt.Errorf("failed to get challenge round: %v", err)
+	}
+	if !challengerChoice.Valid || !opponentChoice.Valid {
+		return nil
+	}
+
+	result := c.gameLogic.DetermineWinner(models.Choice(challengerChoice.String), models.Choice(opponentChoice.String))
+	if err := c.gameService.saveMatchGames(tx, ch.challengerID, ch.opponentID, models.Choice(challengerChoice.String), models.Choice(opponentChoice.String), result); err != nil {
+		return err
+	}
+
+	winner := models.RoundTie
+	switch result {
+	case models.Win:
+		winner = models.RoundChallenger
+		ch.ChallengerWins++
+	case models.Lose:
+		winner = models.RoundOpponent
+		ch.OpponentWins++
+	}
+
+	updateRound := `UPDATE challenge_rounds SET winner = ?, resolved_at = CURRENT_TIMESTAMP WHERE challenge_id = ? AND round = ?`
+	if _, err := tx.Exec(updateRound, string(winner), ch.ID, round); err != nil {
+		return fmt.Errorf("failed to resolve challenge round: %v", err)
+	}
+	updateScore := `UPDATE challenges SET challenger_wins = ?, opponent_wins = ? WHERE id = ?`
+	if _, err := tx.Exec(updateScore, ch.ChallengerWins, ch.OpponentWins, ch.ID); err != nil {
+		return fmt.Errorf("failed to update challenge score: %v", err)
+	}
+
+	var winnerID int
+	switch {
+	case ch.ChallengerWins >= winsNeeded(ch.BestOf):
+		winnerID = ch.challengerID
+	case ch.OpponentWins >= winsNeeded(ch.BestOf):
+		winnerID = ch.opponentID
+	default:
+		return c.resetMoveDeadline(tx, ch.ID)
+	}
+
+	return c.completeChallenge(tx, ch, models.ChallengeCompleted, winnerID, fmt.Sprintf("Won challenge #%d", ch.ID))
+}
+
+// completeChallenge settles a challenge with status and pays the whole pot
+// to the winner
+func (c *ChallengeService) completeChallenge(tx *sql.Tx, ch *challengeRow, status models.ChallengeStatus, winnerID int, reason string) error {
+	completeQuery := `UPDATE challenges
+	                  SET status = ?, winner_id = ?, move_deadline = NULL, completed_at = CURRENT_TIMESTAMP
+	                  WHERE id = ?`
+	if _, err := tx.Exec(completeQuery, string(status), winnerID, ch.ID); err != nil {
+		return fmt.Errorf("failed to complete challenge: %v", err)
+	}
+	_, err := c.ledger.Post(tx, winnerID, models.TxWager, 2*ch.Stake, challengeReference(ch.ID), reason)
+	return err
+}
+
+// callOffChallenges cancels every open challenge of a user whose account is
+// being deleted, refunding whatever the other player has in escrow. The
+// deleted user's own stakes go with their account.
+func (c *ChallengeService) callOffChallenges(tx *sql.Tx, userID int) error {
+	query := `SELECT id FROM challenges
+	          WHERE status IN ('pending', 'accepted') AND (challenger_id = ? OR opponent_id = ?)`
+	ids, err := queryIDs(tx, query, userID, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		ch, err := c.getChallenge(tx, id)
+		if err != nil {
+			return err
+		}
+		reason := fmt.Sprintf("Challenge #%d called off: player account deleted", ch.ID)
+		if ch.challengerID != userID {
+			if err := c.refundStake(tx, ch, reason); err != nil {
+				return err
+			}
+		}
+		if ch.opponentID != userID && ch.Status == models.ChallengeAccepted {
+			if _, err := c.ledger.Post(tx, ch.opponentID, models.TxWager, ch.Stake, challengeReference(ch.ID), reason); err != nil {
+				return err
+			}
+		}
+		if err := c.setStatus(tx, ch.ID, models.ChallengeCancelled); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpireChallenges expires every pending challenge past its deadline and
+// refunds the challengers. Accepted challenges whose current round is past
+// its move deadline are settled too: a player who moved wins by forfeit
+// against one who did not, and if neither moved both stakes are refunded.
+// It returns how many challenges were expired or forfeited.
+func (c *ChallengeService) ExpireChallenges() (int, error) {
+	expired := 0
+
+	err := runInTx(c.db, func(tx *sql.Tx) error {
+		now := c.now().UTC().Format(sqliteTimeFormat)
+		ids, err := queryIDs(tx, `SELECT id FROM challenges WHERE status = 'pending' AND expires_at <= ?`, now)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			ch, err := c.getChallenge(tx, id)
+			if err != nil {
+				return err
+			}
+			if err := c.refundStake(tx, ch, fmt.Sprintf("Challenge #%d expired", ch.ID)); err != nil {
+				return err
+			}
+			if err := c.setStatus(tx, ch.ID, models.ChallengeExpired); err != nil {
+				return err
+			}
+			expired++
+		}
+
+		overdue := `SELECT id FROM challenges WHERE status = 'accepted' AND move_deadline <= ? ORDER BY move_deadline, id`
+		ids, err = queryIDs(tx, overdue, now)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			ch, err := c.getChallenge(tx, id)
+			if err != nil {
+				return err
+			}
+			if err := c.forfeitChallenge(tx, ch); err != nil {
+				return err
+			}
+			expired++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return expired, nil
+}
+
+// forfeitChallenge settles an accepted challenge whose current round ran out
+// of time. The player who moved in it takes the pot; if neither did, both
+// stakes are refunded and the challenge expires.
+func (c *ChallengeService) forfeitChallenge(tx *sql.Tx, ch *challengeRow) error {
+	if len(ch.Rounds) > 0 {
+		if current := ch.Rounds[len(ch.Rounds)-1]; current.Winner == "" {
+			switch {
+			case current.ChallengerMoved && !current.OpponentMoved:
+				return c.completeChallenge(tx, ch, models.ChallengeForfeited, ch.challengerID, fmt.Sprintf("Won challenge #%d by forfeit", ch.ID))
+			case current.OpponentMoved && !current.ChallengerMoved:
+				return c.completeChallenge(tx, ch, models.ChallengeForfeited, ch.opponentID, fmt.Sprintf("Won challenge #%d by forfeit", ch.ID))
+			}
+		}
+	}
+
+	reason := fmt.Sprintf("Challenge #%d expired: no moves before the deadline", ch.ID)
+	if err := c.refundStake(tx, ch, reason); err != nil {
+		return err
+	}
+	if _, err := c.ledger.Post(tx, ch.opponentID, models.TxWager, ch.Stake, challengeReference(ch.ID), reason); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE challenges SET status = ?, move_deadline = NULL WHERE id = ?`,
+		string(models.ChallengeExpired), ch.ID); err != nil {
+		return fmt.Errorf("failed to expire challenge: %v", err)
+	}
+	return nil
+}
+
+// RunExpiry expires overdue challenges every interval until stop is closed
+func (c *ChallengeService) RunExpiry(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			expired, err := c.ExpireChallenges()
+			if err != nil {
+				log.Printf("Challenge expiry failed: %v", err)
+				continue
+			}
+			if expired > 0 {
+				log.Printf("Expired or forfeited %d challenges", expired)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"rockpaperscissors/internal/models"
+)
+
+func TestChallengeService_Expiry(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	userService := NewUserService(db)
+	friends := NewFriendService(db)
+	ledger := NewLedgerService(db)
+	challenges := NewChallengeService(db)
+
+	now := time.Now()
+	challenges.now = func() time.Time { return now }
+
+	for _, name := range []string{"alice", "bob"} {
+		user, err := userService.CreateUser(name)
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if _, err := ledger.Record(user.ID, models.TxAdminAdjustment, 50, "", "test balance"); err != nil {
+			t.Fatalf("Failed to credit user: %v", err)
+		}
+	}
+	if _, err := friends.SendRequest("alice", "bob"); err != nil {
+		t.Fatalf("Failed to send friend request: %v", err)
+	}
+	if _, err := friends.SendRequest("bob", "alice"); err != nil {
+		t.Fatalf("Failed to accept friend request: %v", err)
+	}
+
+	challenge, err := challenges.CreateChallenge(models.CreateChallengeRequest{Username: "alice", Opponent: "bob", Stake: 30, ExpiresInMinutes: 10})
+	if err != nil {
+		t.Fatalf("Failed to create challenge: %v", err)
+	}
+
+	expired, err := challenges.ExpireChallenges()
+	if err != nil {
+		t.Fatalf("Failed to expire challenges: %v", err)
+	}
+	if expired != 0 {
+		t.Errorf("Expected nothing to expire yet, got %d", expired)
+	}
+
+	now = now.Add(11 * time.Minute)
+	if _, err := challenges.AcceptChallenge(challenge.ID, "bob"); err == nil {
+		t.Fatal("Expected accepting an overdue challenge to fail")
+	}
+
+	got, err := challenges.GetChallenge(challenge.ID)
+	if err != nil {
+		t.Fatalf("Failed to get challenge: %v", err)
+	}
+	if got.Status != models.ChallengeExpired {
+		t.Errorf("Expected status %s, got %s", models.ChallengeExpired, got.Status)
+	}
+
+	alice, err := userService.GetUser("alice")
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if alice.TotalCoins != 50 {
+		t.Errorf("Expected the stake to be refunded, balance %d", alice.TotalCoins)
+	}
+}
+
+func TestChallengeService_MoveDeadline(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	userService := NewUserService(db)
+	friends := NewFriendService(db)
+	ledger := NewLedgerService(db)
+	challenges := NewChallengeService(db)
+
+	now := time.Now()
+	challenges.now = func() time.Time { return now }
+
+	for _, name := range []string{"alice", "bob"} {
+		user, err := userService.CreateUser(name)
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if _, err := ledger.Record(user.ID, models.TxAdminAdjustment, 50, "", "test balance"); err != nil {
+			t.Fatalf("Failed to credit user: %v", err)
+		}
+	}
+	if _, err := friends.SendRequest("alice", "bob"); err != nil {
+		t.Fatalf("Failed to send friend request: %v", err)
+	}
+	if _, err := friends.SendRequest("bob", "alice"); err != nil {
+		t.Fatalf("Failed to accept friend request: %v", err)
+	}
+
+	// startChallenge has alice challenge bob to a best of three for 20 coins
+	startChallenge := func(t *testing.T) *models.Challenge {
+		challenge, err := challenges.CreateChallenge(models.CreateChallengeRequest{Username: "alice", Opponent: "bob", BestOf: 3, Stake: 20})
+		if err != nil {
+			t.Fatalf("Failed to create challenge: %v", err)
+		}
+		challenge, err = challenges.AcceptChallenge(challenge.ID, "bob")
+		if err != nil {
+			t.Fatalf("Failed to accept challenge: %v", err)
+		}
+		if challenge.MoveDeadline == nil {
+			t.Fatal("Expected an accepted challenge to have a move deadline")
+		}
+		return challenge
+	}
+
+	balances := func(t *testing.T) (int, int) {
+		alice, err := userService.GetUser("alice")
+		if err != nil {
+			t.Fatalf("Failed to get user: %v", err)
+		}
+		bob, err := userService.GetUser("bob")
+		if err != nil {
+			t.Fatalf("Failed to get user: %v", err)
+		}
+		return alice.TotalCoins, bob.TotalCoins
+	}
+
+	t.Run("Idle player forfeits", func(t *testing.T) {
+		challenge := startChallenge(t)
+
+		// a resolved round restarts the clock
+		now = now.Add(challengeMoveTimeout - time.Hour)
+		if _, err := challenges.SubmitMove(challenge.ID, models.ChallengeMoveRequest{Username: "alice", PlayerChoice: models.Rock}); err != nil {
+			t.Fatalf("Failed to move: %v", err)
+		}
+		if _, err := challenges.SubmitMove(challenge.ID, models.ChallengeMoveRequest{Username: "bob", PlayerChoice: models.Scissors}); err != nil {
+			t.Fatalf("Failed to move: %v", err)
+		}
+		now = now.Add(challengeMoveTimeout - time.Hour)
+		if expired, err := challenges.ExpireChallenges(); err != nil || expired != 0 {
+			t.Fatalf("Expected nothing to expire yet, got %d, %v", expired, err)
+		}
+
+		// bob moves in the second round, alice never does
+		if _, err := challenges.SubmitMove(challenge.ID, models.ChallengeMoveRequest{Username: "bob", PlayerChoice: models.Paper}); err != nil {
+			t.Fatalf("Failed to move: %v", err)
+		}
+		now = now.Add(2 * time.Hour)
+		if _, err := challenges.SubmitMove(challenge.ID, models.ChallengeMoveRequest{Username: "alice", PlayerChoice: models.Scissors}); err == nil {
+			t.Fatal("Expected a move after the deadline to fail")
+		}
+
+		got, err := challenges.GetChallenge(challenge.ID)
+		if err != nil {
+			t.Fatalf("Failed to get challenge: %v", err)
+		}
+		if got.Status != models.ChallengeForfeited || got.Winner != "bob" {
+			t.Errorf("Expected bob to win by forfeit, got %s won by %q", got.Status, got.Winner)
+		}
+		if alice, bob := balances(t); alice != 30 || bob != 70 {
+			t.Errorf("Expected balances 30 and 70, got %d and %d", alice, bob)
+		}
+	})
+
+	t.Run("Both idle are refunded", func(t *testing.T) {
+		challenge := startChallenge(t)
+
+		now = now.Add(challengeMoveTimeout)
+		expired, err := challenges.ExpireChallenges()
+		if err != nil {
+			t.Fatalf("Failed to expire challenges: %v", err)
+		}
+		if expired != 1 {
+			t.Errorf("Expected 1 challenge to expire, got %d", expired)
+		}
+
+		got, err := challenges.GetChallenge(challenge.ID)
+		if err != nil {
+			t.Fatalf("Failed to get challenge: %v", err)
+		}
+		if got.Status != models.ChallengeExpired || got.Winner != "" {
+			t.Errorf("Expected the challenge to expire without a winner, got %s won by %q", got.Status, got.Winner)
+		}
+		if alice, bob := balances(t); alice != 30 || bob != 70 {
+			t.Errorf("Expected both stakes refunded, got %d and %d", alice, bob)
+		}
+	})
+}
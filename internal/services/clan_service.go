@@ -0,0 +1,606 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	minClanNameLength        = 3
+	maxClanNameLength        = 40
+	maxClanDescriptionLength = 200
+)
+
+// clanTagPattern is what a tag looks like once upper-cased
+var clanTagPattern = regexp.MustCompile(`^[A-Z0-9]{2,5}$`)
+
+// ClanService manages clans, their members and invitations, the clan
+// leaderboard and clan wars
+type ClanService struct {
+	db          *sql.DB
+	userService *UserService
+	seasons     *SeasonService
+	now         func() time.Time
+}
+
+// NewClanService creates a new clan service
+func NewClanService(db *database.DB) *ClanService {
+	return &ClanService{
+		db:          db.DB,
+		userService: NewUserService(db),
+		seasons:     NewSeasonService(db),
+		now:         time.Now,
+	}
+}
+
+// normalizeClanTag upper-cases a tag and checks it is 2 to 5 letters or digits
+func normalizeClanTag(tag string) (string, error) {
+	tag = strings.ToUpper(strings.TrimSpace(tag))
+	if !clanTagPattern.MatchString(tag) {
+		return "", fmt.Errorf("invalid clan tag: must be 2 to 5 letters or digits")
+	}
+	return tag, nil
+}
+
+// getClan loads a clan by tag, without its members
+func (s *ClanService) getClan(exec dbExecutor, tag string) (*models.Clan, error) {
+	tag = strings.ToUpper(strings.TrimSpace(tag))
+
+	var clan models.Clan
+	query := `SELECT id, name, tag, description, open, created_at,
+	                 (SELECT COUNT(*) FROM clan_members WHERE clan_id = clans.id)
+	          FROM clans WHERE tag = ?`
+	err := exec.QueryRow(query, tag).Scan(&clan.ID, &clan.Name, &clan.Tag, &clan.Description, &clan.Open, &clan.CreatedAt, &clan.MemberCount)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("clan '%s' not found", tag)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clan: %v", err)
+	}
+	return &clan, nil
+}
+
+// membership returns the clan a user is in and their role there, or a clan
+// ID of 0 if they are in none
+func (s *ClanService) membership(exec dbExecutor, userID int) (int, models.ClanRole, error) {
+	var clanID int
+	var role string
+	err := exec.QueryRow(`SELECT clan_id, role FROM clan_members WHERE user_id = ?`, userID).Scan(&clanID, &role)
+	if err == sql.ErrNoRows {
+		return 0, "", nil
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to get clan membership: %v", err)
+	}
+	return clanID, models.ClanRole(role), nil
+}
+
+// memberRole returns a user's role in clan, failing if they are not in it
+func (s *ClanService) memberRole(exec dbExecutor, user *models.User, clan *models.Clan) (models.ClanRole, error) {
+	clanID, role, err := s.membership(exec, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if clanID != clan.ID {
+		return "", fmt.Errorf("user '%s' is not a member of clan %s", user.Username, clan.Tag)
+	}
+	return role, nil
+}
+
+// requireOfficer loads the acting user and checks they are an officer or
+// the owner of clan
+func (s *ClanService) requireOfficer(exec dbExecutor, username string, clan *models.Clan) (*models.User, models.ClanRole, error) {
+	user, err := s.userService.getUser(exec, username)
+	if err != nil {
+		return nil, "", err
+	}
+	role, err := s.memberRole(exec, user, clan)
+	if err != nil {
+		return nil, "", err
+	}
+	if role == models.RoleMember {
+		return nil, "", fmt.Errorf("only officers of clan %s can do this", clan.Tag)
+	}
+	return user, role, nil
+}
+
+// checkNotInClan fails if the user already belongs to a clan
+func (s *ClanService) checkNotInClan(exec dbExecutor, user *models.User) error {
+	clanID, _, err := s.membership(exec, user.ID)
+	if err != nil {
+		return err
+	}
+	if clanID != 0 {
+		return fmt.Errorf("user '%s' is already in a clan", user.Username)
+	}
+	return nil
+}
+
+// addMember puts a user into a clan and clears their other invitations
+func (s *ClanService) addMember(exec dbExecutor, clanID, userID int, role models.ClanRole) error {
+	insertQuery := `INSERT INTO clan_members (user_id, clan_id, role, joined_at) VALUES (?, ?, ?, ?)`
+	if _, err := exec.Exec(insertQuery, userID, clanID, string(role), s.now().UTC().Format(sqliteTimeFormat)); err != nil {
+		return fmt.Errorf("failed to add clan member: %v", err)
+	}
+	if _, err := exec.Exec(`DELETE FROM clan_invites WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to clear clan invites: %v", err)
+	}
+	return nil
+}
+
+// CreateClan founds a clan with the requesting player as its owner. Names
+// and tags are unique ignoring case and go through the username blocklist.
+func (s *ClanService) CreateClan(req models.CreateClanRequest) (*models.Clan, error) {
+	policy, err := LoadUsernamePolicy()
+	if err != nil {
+		return nil, err
+	}
+	name, err := cleanProfileText("clan name", req.Name, maxClanNameLength, false)
+	if err != nil {
+		return nil, err
+	}
+	if utf8.RuneCountInString(name) < minClanNameLength {
+		return nil, fmt.Errorf("invalid clan name: must be at least %d characters", minClanNameLength)
+	}
+	if policy.BlocksText(name) {
+		return nil, fmt.Errorf("invalid clan name: '%s' is reserved or not allowed", name)
+	}
+	tag, err := normalizeClanTag(req.Tag)
+	if err != nil {
+		return nil, err
+	}
+	if policy.BlocksText(tag) {
+		return nil, fmt.Errorf("invalid clan tag: '%s' is reserved or not allowed", tag)
+	}
+	description, err := cleanProfileText("description", req.Description, maxClanDescriptionLength, false)
+	if err != nil {
+		return nil, err
+	}
+	if policy.BlocksText(description) {
+		return nil, fmt.Errorf("invalid description: contains words that are not allowed")
+	}
+
+	err = runInTx(s.db, func(tx *sql.Tx) error {
+		user, err := s.userService.getUser(tx, req.Username)
+		if err != nil {
+			return err
+		}
+		if err := checkCanPlay(user); err != nil {
+			return err
+		}
+		if err := s.checkNotInClan(tx, user); err != nil {
+			return err
+		}
+
+		var taken int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM clans WHERE name = ?`, name).Scan(&taken); err != nil {
+			return fmt.Errorf("failed to check clan name: %v", err)
+		}
+		if taken > 0 {
+			return fmt.Errorf("clan name '%s' is already taken", name)
+		}
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM clans WHERE tag = ?`, tag).Scan(&taken); err != nil {
+			return fmt.Errorf("failed to check clan tag: %v", err)
+		}
+		if taken > 0 {
+			return fmt.Errorf("clan tag '%s' is already taken", tag)
+		}
+
+		insertQuery := `INSERT INTO clans (name, tag, description, open, created_at) VALUES (?, ?, ?, ?, ?)`
+		res, err := tx.Exec(insertQuery, name, tag, description, req.Open, s.now().UTC().Format(sqliteTimeFormat))
+		if err != nil {
+			return fmt.Errorf("failed to create clan: %v", err)
+		}
+		clanID, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get clan ID: %v", err)
+		}
+		return s.addMember(tx, int(clanID), user.ID, models.RoleOwner)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetClan(tag)
+}
+
+// GetClan returns a clan with its members, owner first, then officers, then
+// members in the order they joined
+func (s *ClanService) GetClan(tag string) (*models.Clan, error) {
+	clan, err := s.getClan(s.db, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT u.username, cm.role, u.total_coins, u.games_won, cm.joined_at
+	          FROM clan_members cm
+	          JOIN users u ON u.id = cm.user_id
+	          WHERE cm.clan_id = ?
+	          ORDER BY CASE cm.role WHEN 'owner' THEN 0 WHEN 'officer' THEN 1 ELSE 2 END, cm.joined_at, u.username`
+	rows, err := s.db.Query(query, clan.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query clan members: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var member models.ClanMember
+		var role string
+		if err := rows.Scan(&member.Username, &role, &member.TotalCoins, &member.GamesWon, &member.JoinedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan clan member: %v", err)
+		}
+		member.Role = models.ClanRole(role)
+		clan.Members = append(clan.Members, member)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating clan members: %v", err)
+	}
+
+	return clan, nil
+}
+
+// Invite lets an officer invite a player who is not in a clan yet
+func (s *ClanService) Invite(tag string, req models.ClanMemberRequest) error {
+	return runInTx(s.db, func(tx *sql.Tx) error {
+		clan, err := s.getClan(tx, tag)
+		if err != nil {
+			return err
+		}
+		officer, _, err := s.requireOfficer(tx, req.Username, clan)
+		if err != nil {
+			return err
+		}
+		invitee, err := s.userService.getUser(tx, req.Member)
+		if err != nil {
+			return err
+		}
+		if err := checkCanPlay(invitee); err != nil {
+			return err
+		}
+		if err := s.checkNotInClan(tx, invitee); err != nil {
+			return err
+		}
+
+		insertQuery := `INSERT OR IGNORE INTO clan_invites (clan_id, user_id, invited_by, created_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)`
+		res, err := tx.Exec(insertQuery, clan.ID, invitee.ID, officer.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create clan invite: %v", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("user '%s' is already invited to clan %s", invitee.Username, clan.Tag)
+		}
+		return nil
+	})
+}
+
+// GetInvites lists the clans that have invited a player, newest first
+func (s *ClanService) GetInvites(username string) ([]models.ClanInvite, error) {
+	user, err := s.userService.GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT c.name, c.tag, COALESCE(u.username, ''), i.created_at
+	          FROM clan_invites i
+	          JOIN clans c ON c.id = i.clan_id
+	          LEFT JOIN users u ON u.id = i.invited_by
+	          WHERE i.user_id = ?
+	          ORDER BY i.created_at DESC, c.tag`
+	rows, err := s.db.Query(query, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query clan invites: %v", err)
+	}
+	defer rows.Close()
+
+	invites := []models.ClanInvite{}
+	for rows.Next() {
+		var invite models.ClanInvite
+		if err := rows.Scan(&invite.Clan, &invite.Tag, &invite.InvitedBy, &invite.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan clan invite: %v", err)
+		}
+		invites = append(invites, invite)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating clan invites: %v", err)
+	}
+
+	return invites, nil
+}
+
+// DeclineInvite turns down a clan's invitation
+func (s *ClanService) DeclineInvite(tag, username string) error {
+	return runInTx(s.db, func(tx *sql.Tx) error {
+		clan, err := s.getClan(tx, tag)
+		if err != nil {
+			return err
+		}
+		user, err := s.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+		res, err := tx.Exec(`DELETE FROM clan_invites WHERE clan_id = ? AND user_id = ?`, clan.ID, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to decline clan invite: %v", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("invite from clan %s not found", clan.Tag)
+		}
+		return nil
+	})
+}
+
+// Join adds a player to an open clan, or to a closed one that invited them
+func (s *ClanService) Join(tag, username string) (*models.Clan, error) {
+	err := runInTx(s.db, func(tx *sql.Tx) error {
+		clan, err := s.getClan(tx, tag)
+		if err != nil {
+			return err
+		}
+		user, err := s.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+		if err := checkCanPlay(user); err != nil {
+			return err
+		}
+		if err := s.checkNotInClan(tx, user); err != nil {
+			return err
+		}
+
+		if !clan.Open {
+			var invited int
+			if err := tx.QueryRow(`SELECT COUNT(*) FROM clan_invites WHERE clan_id = ? AND user_id = ?`, clan.ID, user.ID).Scan(&invited); err != nil {
+				return fmt.Errorf("failed to check clan invite: %v", err)
+			}
+			if invited == 0 {
+				return fmt.Errorf("clan %s is invite only", clan.Tag)
+			}
+		}
+		return s.addMember(tx, clan.ID, user.ID, models.RoleMember)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetClan(tag)
+}
+
+// Leave takes a player out of their clan. The owner has to hand the clan
+// over first, unless they are its last member, in which case the clan is
+// disbanded. It reports whether the clan was disbanded.
+func (s *ClanService) Leave(tag, username string) (bool, error) {
+	disbanded := false
+	err := runInTx(s.db, func(tx *sql.Tx) error {
+		clan, err := s.getClan(tx, tag)
+		if err != nil {
+			return err
+		}
+		user, err := s.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+		role, err := s.memberRole(tx, user, clan)
+		if err != nil {
+			return err
+		}
+
+		if role == models.RoleOwner {
+			if clan.MemberCount > 1 {
+				return fmt.Errorf("the owner must hand clan %s to another member before leaving", clan.Tag)
+			}
+			disbanded = true
+			return s.disband(tx, clan.ID)
+		}
+		if _, err := tx.Exec(`DELETE FROM clan_members WHERE user_id = ?`, user.ID); err != nil {
+			return fmt.Errorf("failed to leave clan: %v", err)
+		}
+		return nil
+	})
+	return disbanded, err
+}
+
+// disband deletes a clan along with its memberships, invitations and wars
+func (s *ClanService) disband(exec dbExecutor, clanID int) error {
+	// foreign keys are not enforced on every connection, so clear the
+	// dependent rows here rather than relying on the cascade
+	if _, err := exec.Exec(`DELETE FROM clan_wars WHERE clan_id = ? OR opponent_clan_id = ?`, clanID, clanID); err != nil {
+		return fmt.Errorf("failed to disband clan: %v", err)
+	}
+	for _, table := range []string{"clan_invites", "clan_members"} {
+		if _, err := exec.Exec(`DELETE FROM `+table+` WHERE clan_id = ?`, clanID); err != nil {
+			return fmt.Errorf("failed to disband clan: %v", err)
+		}
+	}
+	if _, err := exec.Exec(`DELETE FROM clans WHERE id = ?`, clanID); err != nil {
+		return fmt.Errorf("failed to disband clan: %v", err)
+	}
+	return nil
+}
+
+// Kick removes a member from a clan. Officers can remove members; the owner
+// can remove anyone.
+func (s *ClanService) Kick(tag string, req models.ClanMemberRequest) error {
+	return runInTx(s.db, func(tx *sql.Tx) error {
+		clan, err := s.getClan(tx, tag)
+		if err != nil {
+			return err
+		}
+		officer, officerRole, err := s.requireOfficer(tx, req.Username, clan)
+		if err != nil {
+			return err
+		}
+		member, err := s.userService.getUser(tx, req.Member)
+		if err != nil {
+			return err
+		}
+		if member.ID == officer.ID {
+			return fmt.Errorf("cannot remove yourself from a clan, leave it instead")
+		}
+		memberRole, err := s.memberRole(tx, member, clan)
+		if err != nil {
+			return err
+		}
+		if officerRole != models.RoleOwner && memberRole != models.RoleMember {
+			return fmt.Errorf("only the owner of clan %s can remove officers", clan.Tag)
+		}
+
+		if _, err := tx.Exec(`DELETE FROM clan_members WHERE user_id = ?`, member.ID); err != nil {
+			return fmt.Errorf("failed to remove clan member: %v", err)
+		}
+		return nil
+	})
+}
+
+// SetRole lets the owner promote or demote a member. Making someone else
+// owner hands the clan over and makes the old owner an officer.
+func (s *ClanService) SetRole(tag, memberName string, req models.SetClanRoleRequest) (*models.Clan, error) {
+	if !req.Role.IsValid() {
+		return nil, fmt.Errorf("invalid role: must be owner, officer or member")
+	}
+
+	err := runInTx(s.db, func(tx *sql.Tx) error {
+		clan, err := s.getClan(tx, tag)
+		if err != nil {
+			return err
+		}
+		owner, role, err := s.requireOfficer(tx, req.Username, clan)
+		if err != nil {
+			return err
+		}
+		if role != models.RoleOwner {
+			return fmt.Errorf("only the owner of clan %s can change roles", clan.Tag)
+		}
+		member, err := s.userService.getUser(tx, memberName)
+		if err != nil {
+			return err
+		}
+		if member.ID == owner.ID {
+			return fmt.Errorf("cannot change your own role, make another member owner instead")
+		}
+		if _, err := s.memberRole(tx, member, clan); err != nil {
+			return err
+		}
+
+		updateQuery := `UPDATE clan_members SET role = ? WHERE user_id = ?`
+		if _, err := tx.Exec(updateQuery, string(req.Role), member.ID); err != nil {
+			return fmt.Errorf("failed to set clan role: %v", err)
+		}
+		if req.Role == models.RoleOwner {
+			if _, err := tx.Exec(updateQuery, string(models.RoleOfficer), owner.ID); err != nil {
+				return fmt.Errorf("failed to set clan role: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetClan(tag)
+}
+
+// leaveClan takes a user who is being deleted out of their clan. If they
+// owned it, the longest-serving officer, or failing that member, takes
+// over; a clan left empty is disbanded.
+func (s *ClanService) leaveClan(tx *sql.Tx, userID int) error {
+	clanID, role, err := s.membership(tx, userID)
+	if err != nil || clanID == 0 {
+		return err
+	}
+
+	if role == models.RoleOwner {
+		var successor int
+		query := `SELECT user_id FROM clan_members
+		          WHERE clan_id = ? AND user_id != ?
+		          ORDER BY role = 'officer' DESC, joined_at, user_id
+		          LIMIT 1`
+		err := tx.QueryRow(query, clanID, userID).Scan(&successor)
+		if err == sql.ErrNoRows {
+			return s.disband(tx, clanID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to find new clan owner: %v", err)
+		}
+		if _, err := tx.Exec(`UPDATE clan_members SET role = 'owner' WHERE user_id = ?`, successor); err != nil {
+			return fmt.Errorf("failed to set clan role: %v", err)
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM clan_members WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to remove clan member: %v", err)
+	}
+	return nil
+}
+
+// GetLeaderboard ranks clans by the coins their members won in a season,
+// along with their members' games, wins and current balances. A season ID
+// of 0 means the season in progress. Only games against the computer count,
+// and only those played while the player was in the clan, so that moving
+// clans does not carry a player's season over. Clans are made up of their
+// current members, for finished seasons too.
+func (s *ClanService) GetLeaderboard(seasonID, limit int) (*models.Season, []models.ClanStanding, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	var season *models.Season
+	var err error
+	if seasonID == 0 {
+		season, err = s.seasons.CurrentSeason()
+	} else {
+		season, err = s.seasons.GetSeason(seasonID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := `WITH members AS (
+	              SELECT cm.clan_id, u.total_coins,
+	                     COALESCE(SUM(g.coins_earned), 0) AS coins,
+	                     COUNT(g.id) AS played,
+	                     COALESCE(SUM(g.result = 'win'), 0) AS won
+	              FROM clan_members cm
+	              JOIN users u ON u.id = cm.user_id
+	              LEFT JOIN games g ON g.user_id = cm.user_id
+	                   AND g.opponent_user_id IS NULL
+	                   AND g.played_at >= ? AND g.played_at < ?
+	                   AND g.played_at >= cm.joined_at
+	              WHERE ` + rankedUsersFilter + `
+	              GROUP BY cm.user_id
+	          )
+	          SELECT c.name, c.tag, COUNT(*), SUM(m.total_coins), SUM(m.coins), SUM(m.played), SUM(m.won)
+	          FROM members m
+	          JOIN clans c ON c.id = m.clan_id
+	          GROUP BY c.id
+	          ORDER BY SUM(m.coins) DESC, SUM(m.won) DESC, c.tag
+	          LIMIT ?`
+	rows, err := s.db.Query(query, season.StartsAt.UTC().Format(sqliteTimeFormat), season.EndsAt.UTC().Format(sqliteTimeFormat), limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query clan leaderboard: %v", err)
+	}
+	defer rows.Close()
+
+	standings := []models.ClanStanding{}
+	for rows.Next() {
+		var st models.ClanStanding
+		if err := rows.Scan(&st.Name, &st.Tag, &st.Members, &st.TotalCoins, &st.Coins, &st.GamesPlayed, &st.GamesWon); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan clan standing: %v", err)
+		}
+		st.Rank = len(standings) + 1
+		if st.GamesPlayed > 0 {
+			st.WinRate = float64(st.GamesWon) / float64(st.GamesPlayed)
+		}
+		standings = append(standings, st)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating clan standings: %v", err)
+	}
+
+	return season, standings, nil
+}
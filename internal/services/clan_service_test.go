@@ -0,0 +1,318 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+)
+
+// setupClanPlayers creates users with the given names
+func setupClanPlayers(t *testing.T, db *database.DB, names ...string) map[string]*models.User {
+	t.Helper()
+	userService := NewUserService(db)
+	users := map[string]*models.User{}
+	for _, name := range names {
+		user, err := userService.CreateUser(name)
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		users[name] = user
+	}
+	return users
+}
+
+// insertClanTestGame records a game played at the given time, against
+// another user when opponentID is not 0
+func insertClanTestGame(t *testing.T, db *database.DB, userID, opponentID int, result models.GameResult, coins int, playedAt time.Time) {
+	t.Helper()
+	var opponent interface{}
+	if opponentID != 0 {
+		opponent = opponentID
+	}
+	query := `INSERT INTO games (user_id, player_choice, computer_choice, result, coins_earned, streak_multiplier, opponent_user_id, played_at)
+	          VALUES (?, 'rock', 'scissors', ?, ?, 1, ?, ?)`
+	if _, err := db.Exec(query, userID, string(result), coins, opponent, playedAt.UTC().Format(sqliteTimeFormat)); err != nil {
+		t.Fatalf("Failed to insert game: %v", err)
+	}
+}
+
+func TestClanService_Membership(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	clans := NewClanService(db)
+	userService := NewUserService(db)
+	setupClanPlayers(t, db, "founder", "deputy", "recruit", "drifter")
+
+	clan, err := clans.CreateClan(models.CreateClanRequest{Username: "founder", Name: "Stone Wall", Tag: "rock"})
+	if err != nil {
+		t.Fatalf("Failed to create clan: %v", err)
+	}
+	if clan.Tag != "ROCK" || len(clan.Members) != 1 || clan.Members[0].Role != models.RoleOwner {
+		t.Fatalf("Expected ROCK owned by founder, got %+v", clan)
+	}
+
+	for _, req := range []models.CreateClanRequest{
+		{Username: "deputy", Name: "stone wall", Tag: "SW"},
+		{Username: "deputy", Name: "Boulders", Tag: "Rock"},
+		{Username: "founder", Name: "Second", Tag: "TWO"},
+		{Username: "deputy", Name: "Bad tag", Tag: "R-K"},
+	} {
+		if _, err := clans.CreateClan(req); err == nil {
+			t.Errorf("Expected creating %+v to fail", req)
+		}
+	}
+
+	// the clan is invite only
+	if _, err := clans.Join("ROCK", "deputy"); err == nil || !strings.Contains(err.Error(), "invite only") {
+		t.Errorf("Expected an invite only error, got %v", err)
+	}
+	if err := clans.Invite("ROCK", models.ClanMemberRequest{Username: "founder", Member: "deputy"}); err != nil {
+		t.Fatalf("Failed to invite: %v", err)
+	}
+	invites, err := clans.GetInvites("deputy")
+	if err != nil || len(invites) != 1 || invites[0].InvitedBy != "founder" {
+		t.Fatalf("Expected an invite from founder, got %+v (%v)", invites, err)
+	}
+	if _, err := clans.Join("rock", "deputy"); err != nil {
+		t.Fatalf("Failed to join: %v", err)
+	}
+	if invites, _ := clans.GetInvites("deputy"); len(invites) != 0 {
+		t.Errorf("Expected joining to use up the invite, got %+v", invites)
+	}
+
+	// members cannot invite, officers can
+	if err := clans.Invite("ROCK", models.ClanMemberRequest{Username: "deputy", Member: "recruit"}); err == nil {
+		t.Errorf("Expected a member to be unable to invite")
+	}
+	if _, err := clans.SetRole("ROCK", "deputy", models.SetClanRoleRequest{Username: "founder", Role: models.RoleOfficer}); err != nil {
+		t.Fatalf("Failed to promote: %v", err)
+	}
+	if err := clans.Invite("ROCK", models.ClanMemberRequest{Username: "deputy", Member: "recruit"}); err != nil {
+		t.Fatalf("Failed to invite as officer: %v", err)
+	}
+	if _, err := clans.Join("ROCK", "recruit"); err != nil {
+		t.Fatalf("Failed to join: %v", err)
+	}
+
+	// officers can remove members but not the owner
+	if err := clans.Kick("ROCK", models.ClanMemberRequest{Username: "deputy", Member: "founder"}); err == nil {
+		t.Errorf("Expected an officer to be unable to remove the owner")
+	}
+	if err := clans.Kick("ROCK", models.ClanMemberRequest{Username: "deputy", Member: "recruit"}); err != nil {
+		t.Fatalf("Failed to remove member: %v", err)
+	}
+
+	user, err := userService.GetUser("deputy")
+	if err != nil || user.ClanTag != "ROCK" {
+		t.Errorf("Expected deputy to carry the ROCK tag, got %q (%v)", user.ClanTag, err)
+	}
+	leaderboard, err := userService.GetLeaderboard(10)
+	if err != nil {
+		t.Fatalf("Failed to get leaderboard: %v", err)
+	}
+	for _, entry := range leaderboard {
+		want := ""
+		if entry.Username == "founder" || entry.Username == "deputy" {
+			want = "ROCK"
+		}
+		if entry.ClanTag != want {
+			t.Errorf("Expected %s to have tag %q on the leaderboard, got %q", entry.Username, want, entry.ClanTag)
+		}
+	}
+
+	// the owner has to hand over before leaving
+	if _, err := clans.Leave("ROCK", "founder"); err == nil || !strings.Contains(err.Error(), "must hand") {
+		t.Errorf("Expected the owner to be unable to leave, got %v", err)
+	}
+	clan, err = clans.SetRole("ROCK", "deputy", models.SetClanRoleRequest{Username: "founder", Role: models.RoleOwner})
+	if err != nil {
+		t.Fatalf("Failed to hand over: %v", err)
+	}
+	if clan.Members[0].Username != "deputy" || clan.Members[1].Role != models.RoleOfficer {
+		t.Errorf("Expected deputy to own the clan with founder as officer, got %+v", clan.Members)
+	}
+	if disbanded, err := clans.Leave("ROCK", "founder"); err != nil || disbanded {
+		t.Fatalf("Expected founder to leave, got %v (disbanded %v)", err, disbanded)
+	}
+	if disbanded, err := clans.Leave("ROCK", "deputy"); err != nil || !disbanded {
+		t.Fatalf("Expected the last member leaving to disband the clan, got %v (disbanded %v)", err, disbanded)
+	}
+	if _, err := clans.GetClan("ROCK"); err == nil {
+		t.Errorf("Expected the clan to be gone")
+	}
+}
+
+func TestClanService_Leaderboard(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	clans := NewClanService(db)
+	now := time.Date(2026, 6, 10, 12, 0, 0, 0, time.UTC)
+	clans.now = func() time.Time { return now }
+	clans.seasons.now = clans.now
+	users := setupClanPlayers(t, db, "ann", "ben", "cat", "loner")
+
+	for _, req := range []models.CreateClanRequest{
+		{Username: "ann", Name: "Alpha", Tag: "AA", Open: true},
+		{Username: "cat", Name: "Bravo", Tag: "BB", Open: true},
+	} {
+		if _, err := clans.CreateClan(req); err != nil {
+			t.Fatalf("Failed to create clan: %v", err)
+		}
+	}
+	if _, err := clans.Join("AA", "ben"); err != nil {
+		t.Fatalf("Failed to join: %v", err)
+	}
+
+	insertClanTestGame(t, db, users["ann"].ID, 0, models.Win, 10, now.Add(time.Hour))
+	insertClanTestGame(t, db, users["ben"].ID, 0, models.Win, 6, now.Add(2*time.Hour))
+	insertClanTestGame(t, db, users["ben"].ID, 0, models.Lose, 0, now.Add(3*time.Hour))
+	insertClanTestGame(t, db, users["cat"].ID, 0, models.Win, 12, now.Add(time.Hour))
+	// played before joining, against another player, and by someone in no
+	// clan: none of these count
+	insertClanTestGame(t, db, users["cat"].ID, 0, models.Win, 50, now.Add(-time.Hour))
+	insertClanTestGame(t, db, users["cat"].ID, users["ann"].ID, models.Win, 0, now.Add(time.Hour))
+	insertClanTestGame(t, db, users["loner"].ID, 0, models.Win, 99, now.Add(time.Hour))
+
+	season, standings, err := clans.GetLeaderboard(0, 10)
+	if err != nil {
+		t.Fatalf("Failed to get leaderboard: %v", err)
+	}
+	if !now.After(season.StartsAt) || !now.Before(season.EndsAt) {
+		t.Errorf("Expected the current season, got %+v", season)
+	}
+	if len(standings) != 2 {
+		t.Fatalf("Expected 2 clans, got %+v", standings)
+	}
+	alpha := standings[0]
+	if alpha.Tag != "AA" || alpha.Members != 2 || alpha.Coins != 16 || alpha.GamesPlayed != 3 || alpha.GamesWon != 2 {
+		t.Errorf("Unexpected first place: %+v", alpha)
+	}
+	bravo := standings[1]
+	if bravo.Tag != "BB" || bravo.Rank != 2 || bravo.Coins != 12 || bravo.GamesPlayed != 1 {
+		t.Errorf("Unexpected second place: %+v", bravo)
+	}
+}
+
+func TestClanService_War(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	clans := NewClanService(db)
+	now := time.Date(2026, 6, 10, 12, 0, 0, 0, time.UTC)
+	clans.now = func() time.Time { return now }
+	users := setupClanPlayers(t, db, "ann", "ben", "cat", "dan")
+
+	for _, req := range []models.CreateClanRequest{
+		{Username: "ann", Name: "Alpha", Tag: "AA", Open: true},
+		{Username: "cat", Name: "Bravo", Tag: "BB", Open: true},
+	} {
+		if _, err := clans.CreateClan(req); err != nil {
+			t.Fatalf("Failed to create clan: %v", err)
+		}
+	}
+	for _, join := range [][2]string{{"AA", "ben"}, {"BB", "dan"}} {
+		if _, err := clans.Join(join[0], join[1]); err != nil {
+			t.Fatalf("Failed to join: %v", err)
+		}
+	}
+
+	if _, err := clans.DeclareWar("AA", models.DeclareClanWarRequest{Username: "ben", Opponent: "BB"}); err == nil {
+		t.Errorf("Expected a member to be unable to declare war")
+	}
+	war, err := clans.DeclareWar("AA", models.DeclareClanWarRequest{Username: "ann", Opponent: "bb", DurationHours: 2})
+	if err != nil {
+		t.Fatalf("Failed to declare war: %v", err)
+	}
+	if _, err := clans.DeclareWar("BB", models.DeclareClanWarRequest{Username: "cat", Opponent: "AA"}); err == nil {
+		t.Errorf("Expected a second war between the same clans to fail")
+	}
+	if _, err := clans.AcceptWar(war.ID, "ann"); err == nil {
+		t.Errorf("Expected the declaring clan to be unable to accept")
+	}
+	if war, err = clans.AcceptWar(war.ID, "cat"); err != nil || war.Status != models.ClanWarActive {
+		t.Fatalf("Failed to accept war: %+v (%v)", war, err)
+	}
+
+	// each PvP game is stored once from each side; only wins score
+	play := func(winner, loser string, at time.Time) {
+		insertClanTestGame(t, db, users[winner].ID, users[loser].ID, models.Win, 0, at)
+		insertClanTestGame(t, db, users[loser].ID, users[winner].ID, models.Lose, 0, at)
+	}
+	play("ann", "cat", now.Add(10*time.Minute))
+	play("ben", "dan", now.Add(20*time.Minute))
+	play("dan", "ann", now.Add(30*time.Minute))
+	play("ann", "ben", now.Add(40*time.Minute)) // same clan
+	play("cat", "dan", now.Add(3*time.Hour))    // after the war
+
+	if war, err = clans.GetWar(war.ID); err != nil || war.ClanScore != 2 || war.OpponentScore != 1 {
+		t.Fatalf("Expected a live score of 2-1, got %+v (%v)", war, err)
+	}
+
+	now = now.Add(3 * time.Hour)
+	finished, err := clans.FinishWars()
+	if err != nil || len(finished) != 1 {
+		t.Fatalf("Expected the war to finish, got %v (%v)", finished, err)
+	}
+	war, err = clans.GetWar(war.ID)
+	if err != nil {
+		t.Fatalf("Failed to get war: %v", err)
+	}
+	if war.Status != models.ClanWarCompleted || war.Winner != "AA" || war.ClanScore != 2 || war.OpponentScore != 1 {
+		t.Errorf("Expected AA to win 2-1, got %+v", war)
+	}
+
+	// the final score stays put as members leave
+	if _, err := clans.Leave("AA", "ben"); err != nil {
+		t.Fatalf("Failed to leave: %v", err)
+	}
+	wars, err := clans.ListWars("BB")
+	if err != nil || len(wars) != 1 || wars[0].ClanScore != 2 {
+		t.Errorf("Expected the stored score, got %+v (%v)", wars, err)
+	}
+}
+
+func TestClanService_DeletedOwnerHandsOver(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	clans := NewClanService(db)
+	setupClanPlayers(t, db, "owner", "elder", "newbie", "solo")
+
+	if _, err := clans.CreateClan(models.CreateClanRequest{Username: "owner", Name: "Keepers", Tag: "KEEP", Open: true}); err != nil {
+		t.Fatalf("Failed to create clan: %v", err)
+	}
+	if _, err := clans.CreateClan(models.CreateClanRequest{Username: "solo", Name: "Alone", Tag: "SOLO"}); err != nil {
+		t.Fatalf("Failed to create clan: %v", err)
+	}
+	for _, name := range []string{"newbie", "elder"} {
+		if _, err := clans.Join("KEEP", name); err != nil {
+			t.Fatalf("Failed to join: %v", err)
+		}
+	}
+	if _, err := clans.SetRole("KEEP", "elder", models.SetClanRoleRequest{Username: "owner", Role: models.RoleOfficer}); err != nil {
+		t.Fatalf("Failed to promote: %v", err)
+	}
+
+	admin := NewAdminService(db)
+	for _, name := range []string{"owner", "solo"} {
+		if err := admin.DeleteUser("admin", name, "test"); err != nil {
+			t.Fatalf("Failed to delete user: %v", err)
+		}
+	}
+
+	clan, err := clans.GetClan("KEEP")
+	if err != nil {
+		t.Fatalf("Failed to get clan: %v", err)
+	}
+	if clan.MemberCount != 2 || clan.Members[0].Username != "elder" || clan.Members[0].Role != models.RoleOwner {
+		t.Errorf("Expected the officer to take over, got %+v", clan.Members)
+	}
+	if _, err := clans.GetClan("SOLO"); err == nil {
+		t.Errorf("Expected the emptied clan to be disbanded")
+	}
+}
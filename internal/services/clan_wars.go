@@ -0,0 +1,362 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"rockpaperscissors/internal/models"
+	"time"
+)
+
+const (
+	// defaultClanWarHours is how long a war runs when no duration is given
+	defaultClanWarHours = 24
+	// maxClanWarHours is the longest a war can run
+	maxClanWarHours = 7 * 24
+)
+
+// clanWarRow is a stored clan war along with the IDs of both clans
+type clanWarRow struct {
+	war        models.ClanWar
+	clanID     int
+	opponentID int
+}
+
+// clanWarColumns are the columns scanClanWar reads, in order
+const clanWarColumns = `w.id, w.clan_id, w.opponent_clan_id, c.tag, o.tag, w.status, w.duration_hours,
+	w.clan_score, w.opponent_score, winner.tag, w.declared_by, w.created_at, w.starts_at, w.ends_at
+	FROM clan_wars w
+	JOIN clans c ON c.id = w.clan_id
+	JOIN clans o ON o.id = w.opponent_clan_id
+	LEFT JOIN clans winner ON winner.id = w.winner_clan_id`
+
+// scanClanWar reads a row of clanWarColumns
+func scanClanWar(row rowScanner) (*clanWarRow, error) {
+	var r clanWarRow
+	var status string
+	var winner sql.NullString
+	var startsAt, endsAt sql.NullTime
+	err := row.Scan(&r.war.ID, &r.clanID, &r.opponentID, &r.war.Clan, &r.war.Opponent, &status, &r.war.DurationHours,
+		&r.war.ClanScore, &r.war.OpponentScore, &winner, &r.war.DeclaredBy, &r.war.CreatedAt, &startsAt, &endsAt)
+	if err != nil {
+		return nil, err
+	}
+	r.war.Status = models.ClanWarStatus(status)
+	r.war.Winner = winner.String
+	if startsAt.Valid {
+		r.war.StartsAt = &startsAt.Time
+	}
+	if endsAt.Valid {
+		r.war.EndsAt = &endsAt.Time
+	}
+	return &r, nil
+}
+
+// getWar loads a clan war through exec
+func (s *ClanService) getWar(exec dbExecutor, id int) (*clanWarRow, error) {
+	r, err := scanClanWar(exec.QueryRow(`SELECT `+clanWarColumns+` WHERE w.id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("clan war %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clan war: %v", err)
+	}
+	return r, nil
+}
+
+// scoreWar counts the games members of each clan won against members of the
+// other between start and end. Games a player played before joining their
+// clan do not count.
+func (s *ClanService) scoreWar(exec dbExecutor, clanID, opponentID int, start, end time.Time) (int, int, error) {
+	query := `SELECT COALESCE(SUM(cm.clan_id = ?), 0), COALESCE(SUM(cm.clan_id = ?), 0)
+	          FROM games g
+	          JOIN clan_members cm ON cm.user_id = g.user_id
+	          JOIN clan_members om ON om.user_id = g.opponent_user_id
+	          WHERE g.result = 'win'
+	            AND g.played_at >= ? AND g.played_at < ?
+	            AND g.played_at >= cm.joined_at AND g.played_at >= om.joined_at
+	            AND ((cm.clan_id = ? AND om.clan_id = ?) OR (cm.clan_id = ? AND om.clan_id = ?))`
+
+	var clanScore, opponentScore int
+	err := exec.QueryRow(query, clanID, opponentID,
+		start.UTC().Format(sqliteTimeFormat), end.UTC().Format(sqliteTimeFormat),
+		clanID, opponentID, opponentID, clanID).Scan(&clanScore, &opponentScore)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to score clan war: %v", err)
+	}
+	return clanScore, opponentScore, nil
+}
+
+// liveScore fills in the running score of an active war
+func (s *ClanService) liveScore(exec dbExecutor, r *clanWarRow) error {
+	if r.war.Status != models.ClanWarActive {
+		return nil
+	}
+	var err error
+	r.war.ClanScore, r.war.OpponentScore, err = s.scoreWar(exec, r.clanID, r.opponentID, *r.war.StartsAt, *r.war.EndsAt)
+	return err
+}
+
+// DeclareWar lets an officer challenge another clan to a war of the given
+// length. The war starts once an officer of the other clan accepts.
+func (s *ClanService) DeclareWar(tag string, req models.DeclareClanWarRequest) (*models.ClanWar, error) {
+	hours := req.DurationHours
+	if hours == 0 {
+		hours = defaultClanWarHours
+	}
+	if hours < 1 || hours > maxClanWarHours {
+		return nil, fmt.Errorf("invalid duration: must be between 1 and %d hours", maxClanWarHours)
+	}
+
+	var warID int
+	err := runInTx(s.db, func(tx *sql.Tx) error {
+		clan, err := s.getClan(tx, tag)
+		if err != nil {
+			return err
+		}
+		opponent, err := s.getClan(tx, req.Opponent)
+		if err != nil {
+			return err
+		}
+		if clan.ID == opponent.ID {
+			return fmt.Errorf("invalid opponent: a clan cannot declare war on itself")
+		}
+		officer, _, err := s.requireOfficer(tx, req.Username, clan)
+		if err != nil {
+			return err
+		}
+
+		var ongoing int
+		ongoingQuery := `SELECT COUNT(*) FROM clan_wars
+		                 WHERE status IN ('pending', 'active')
+		                   AND ((clan_id = ? AND opponent_clan_id = ?) OR (clan_id = ? AND opponent_clan_id = ?))`
+		if err := tx.QueryRow(ongoingQuery, clan.ID, opponent.ID, opponent.ID, clan.ID).Scan(&ongoing); err != nil {
+			return fmt.Errorf("failed to check clan wars: %v", err)
+		}
+		if ongoing > 0 {
+			return fmt.Errorf("clans %s and %s are already at war", clan.Tag, opponent.Tag)
+		}
+
+		insertQuery := `INSERT INTO clan_wars (clan_id, opponent_clan_id, status, duration_hours, declared_by, created_at)
+		                VALUES (?, ?, ?, ?, ?, ?)`
+		res, err := tx.Exec(insertQuery, clan.ID, opponent.ID, string(models.ClanWarPending), hours,
+			officer.Username, s.now().UTC().Format(sqliteTimeFormat))
+		if err != nil {
+			return fmt.Errorf("failed to declare clan war: %v", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get clan war ID: %v", err)
+		}
+		warID = int(id)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetWar(warID)
+}
+
+// AcceptWar lets an officer of the challenged clan start a war
+func (s *ClanService) AcceptWar(id int, username string) (*models.ClanWar, error) {
+	err := runInTx(s.db, func(tx *sql.Tx) error {
+		r, err := s.getWar(tx, id)
+		if err != nil {
+			return err
+		}
+		if r.war.Status != models.ClanWarPending {
+			return fmt.Errorf("clan war %d is not pending", id)
+		}
+		opponent, err := s.getClan(tx, r.war.Opponent)
+		if err != nil {
+			return err
+		}
+		if _, _, err := s.requireOfficer(tx, username, opponent); err != nil {
+			return err
+		}
+
+		start := s.now().UTC()
+		end := start.Add(time.Duration(r.war.DurationHours) * time.Hour)
+		updateQuery := `UPDATE clan_wars SET status = ?, starts_at = ?, ends_at = ? WHERE id = ?`
+		if _, err := tx.Exec(updateQuery, string(models.ClanWarActive), start.Format(sqliteTimeFormat), end.Format(sqliteTimeFormat), id); err != nil {
+			return fmt.Errorf("failed to accept clan war: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetWar(id)
+}
+
+// DeclineWar calls off a war that has not started. Officers of the
+// challenged clan can decline it and officers of the declaring clan can
+// withdraw it.
+func (s *ClanService) DeclineWar(id int, username string) (*models.ClanWar, error) {
+	err := runInTx(s.db, func(tx *sql.Tx) error {
+		r, err := s.getWar(tx, id)
+		if err != nil {
+			return err
+		}
+		if r.war.Status != models.ClanWarPending {
+			return fmt.Errorf("clan war %d is not pending", id)
+		}
+		user, err := s.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+		clanID, role, err := s.membership(tx, user.ID)
+		if err != nil {
+			return err
+		}
+		if clanID != r.clanID && clanID != r.opponentID {
+			return fmt.Errorf("user '%s' is not a member of clan %s or %s", user.Username, r.war.Clan, r.war.Opponent)
+		}
+		if role == models.RoleMember {
+			return fmt.Errorf("only officers of the clans at war can do this")
+		}
+
+		if _, err := tx.Exec(`UPDATE clan_wars SET status = ? WHERE id = ?`, string(models.ClanWarDeclined), id); err != nil {
+			return fmt.Errorf("failed to decline clan war: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetWar(id)
+}
+
+// GetWar returns a clan war, with the running score while it is on. A war
+// whose time is up is settled first.
+func (s *ClanService) GetWar(id int) (*models.ClanWar, error) {
+	r, err := s.getWar(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	if r.war.Status == models.ClanWarActive && !s.now().Before(*r.war.EndsAt) {
+		if err := runInTx(s.db, func(tx *sql.Tx) error { return s.finishWar(tx, id) }); err != nil {
+			return nil, err
+		}
+		if r, err = s.getWar(s.db, id); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.liveScore(s.db, r); err != nil {
+		return nil, err
+	}
+	return &r.war, nil
+}
+
+// ListWars returns a clan's wars, newest first
+func (s *ClanService) ListWars(tag string) ([]models.ClanWar, error) {
+	clan, err := s.getClan(s.db, tag)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.FinishWars(); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + clanWarColumns + `
+	          WHERE w.clan_id = ? OR w.opponent_clan_id = ?
+	          ORDER BY w.created_at DESC, w.id DESC`
+	rows, err := s.db.Query(query, clan.ID, clan.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query clan wars: %v", err)
+	}
+	defer rows.Close()
+
+	var warRows []*clanWarRow
+	for rows.Next() {
+		r, err := scanClanWar(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan clan war: %v", err)
+		}
+		warRows = append(warRows, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating clan wars: %v", err)
+	}
+	rows.Close()
+
+	wars := []models.ClanWar{}
+	for _, r := range warRows {
+		if err := s.liveScore(s.db, r); err != nil {
+			return nil, err
+		}
+		wars = append(wars, r.war)
+	}
+	return wars, nil
+}
+
+// finishWar settles an active war whose time is up, storing the final score
+// and the winner. A drawn war has no winner.
+func (s *ClanService) finishWar(tx *sql.Tx, id int) error {
+	r, err := s.getWar(tx, id)
+	if err != nil {
+		return err
+	}
+	if r.war.Status != models.ClanWarActive {
+		return nil
+	}
+
+	clanScore, opponentScore, err := s.scoreWar(tx, r.clanID, r.opponentID, *r.war.StartsAt, *r.war.EndsAt)
+	if err != nil {
+		return err
+	}
+	var winner interface{}
+	switch {
+	case clanScore > opponentScore:
+		winner = r.clanID
+	case opponentScore > clanScore:
+		winner = r.opponentID
+	}
+
+	updateQuery := `UPDATE clan_wars SET status = ?, clan_score = ?, opponent_score = ?, winner_clan_id = ? WHERE id = ?`
+	if _, err := tx.Exec(updateQuery, string(models.ClanWarCompleted), clanScore, opponentScore, winner, id); err != nil {
+		return fmt.Errorf("failed to finish clan war: %v", err)
+	}
+	return nil
+}
+
+// FinishWars settles every war whose time is up and returns their IDs
+func (s *ClanService) FinishWars() ([]int, error) {
+	ids, err := queryIDs(s.db, `SELECT id FROM clan_wars WHERE status = ? AND ends_at <= ?`,
+		string(models.ClanWarActive), s.now().UTC().Format(sqliteTimeFormat))
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if err := runInTx(s.db, func(tx *sql.Tx) error { return s.finishWar(tx, id) }); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// RunWarScheduler settles finished wars every interval until stop is closed,
+// so that final scores are fixed before members come and go
+func (s *ClanService) RunWarScheduler(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ids, err := s.FinishWars()
+			if err != nil {
+				log.Printf("Clan war scheduler failed: %v", err)
+				continue
+			}
+			for _, id := range ids {
+				log.Printf("Finished clan war %d", id)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
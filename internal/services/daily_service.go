@@ -0,0 +1,420 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"time"
+)
+
+// dailyRewardCurve is the login reward for each consecutive day claimed;
+// streaks longer than the curve keep earning the last value
+var dailyRewardCurve = []int{20, 30, 40, 50, 60, 80, 100}
+
+// dailyChallengeCount is how many challenges are generated each day
+const dailyChallengeCount = 3
+
+// minDailyClaimInterval is the least real time between claims for two local
+// days. A new local day can start sooner after a timezone change, but it
+// cannot pay out again until most of a real day has passed.
+const minDailyClaimInterval = 20 * time.Hour
+
+// sqliteTimeFormat matches how CURRENT_TIMESTAMP stores UTC times
+const sqliteTimeFormat = "2006-01-02 15:04:05"
+
+// dayFormat is the layout of a calendar day key
+const dayFormat = "2006-01-02"
+
+// DailyService handles daily login rewards and daily challenges
+type DailyService struct {
+	db          *sql.DB
+	userService *UserService
+	ledger      *LedgerService
+	now         func() time.Time
+}
+
+// NewDailyService creates a new daily service
+func NewDailyService(db *database.DB) *DailyService {
+	return &DailyService{
+		db:          db.DB,
+		userService: NewUserService(db),
+		ledger:      NewLedgerService(db),
+		now:         time.Now,
+	}
+}
+
+// dailyRewardFor returns the login reward for the given consecutive day
+func dailyRewardFor(streakDay int) int {
+	if streakDay < 1 {
+		streakDay = 1
+	}
+	if streakDay > len(dailyRewardCurve) {
+		return dailyRewardCurve[len(dailyRewardCurve)-1]
+	}
+	return dailyRewardCurve[streakDay-1]
+}
+
+// userDay returns the start of the user's current local day and the start of
+// the next one
+func (d *DailyService) userDay(user *models.User) (time.Time, time.Time) {
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	now := d.now().In(loc)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// lastRewardClaim returns the latest login reward day and its streak. Days
+// are compared rather than claim order, since moving to a timezone further
+// west can make a later claim fall on an earlier local day.
+func (d *DailyService) lastRewardClaim(exec dbExecutor, userID int) (string, int, time.Time, error) {
+	var day string
+	var streakDay int
+	var claimedAt time.Time
+	query := `SELECT day, streak_day, claimed_at FROM daily_rewards WHERE user_id = ? ORDER BY day DESC, id DESC LIMIT 1`
+	err := exec.QueryRow(query, userID).Scan(&day, &streakDay, &claimedAt)
+	if err == sql.ErrNoRows {
+		return "", 0, time.Time{}, nil
+	}
+	if err != nil {
+		return "", 0, time.Time{}, fmt.Errorf("failed to get last daily reward: %v", err)
+	}
+	return day, streakDay, claimedAt, nil
+}
+
+// checkClaimInterval rejects a claim for a new day that comes less than
+// minDailyClaimInterval after lastClaim
+func (d *DailyService) checkClaimInterval(what string, lastClaim time.Time) error {
+	if next := lastClaim.Add(minDailyClaimInterval); !lastClaim.IsZero() && d.now().Before(next) {
+		return fmt.Errorf("%s already claimed recently, the next one can be claimed after %s", what, next.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// GetRewardStatus reports whether the user has claimed today's login reward
+// and what the next claim is worth
+func (d *DailyService) GetRewardStatus(username string) (*models.DailyRewardStatus, error) {
+	user, err := d.userService.GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := d.userDay(user)
+	today := start.Format(dayFormat)
+	yesterday := start.AddDate(0, 0, -1).Format(dayFormat)
+
+	lastDay, lastStreak, lastClaim, err := d.lastRewardClaim(d.db, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.DailyRewardStatus{
+		Day:         today,
+		Timezone:    user.Timezone,
+		NextResetAt: end.UTC(),
+	}
+	next := lastClaim.Add(minDailyClaimInterval)
+	if lastDay >= today && next.Before(end) {
+		next = end
+	}
+	if !lastClaim.IsZero() && next.After(d.now()) {
+		next = next.UTC()
+		status.NextClaimAt = &next
+	}
+	switch {
+	case lastDay >= today:
+		status.ClaimedToday = true
+		status.StreakDay = lastStreak
+		status.NextReward = dailyRewardFor(lastStreak + 1)
+	case lastDay == yesterday:
+		status.StreakDay = lastStreak
+		status.NextReward = dailyRewardFor(lastStreak + 1)
+	default:
+		status.NextReward = dailyRewardFor(1)
+	}
+
+	return status, nil
+}
+
+// ClaimReward pays out today's login reward. Claiming on consecutive local
+// days climbs the reward curve; missing a day starts it over.
+func (d *DailyService) ClaimReward(username string) (*models.DailyRewardClaim, error) {
+	var claim *models.DailyRewardClaim
+
+	err := runInTx(d.db, func(tx *sql.Tx) error {
+		user, err := d.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+
+		start, _ := d.userDay(user)
+		today := start.Format(dayFormat)
+		yesterday := start.AddDate(0, 0, -1).Format(dayFormat)
+
+		lastDay, lastStreak, lastClaim, err := d.lastRewardClaim(tx, user.ID)
+		if err != nil {
+			return err
+		}
+		// a day on or before the last one claimed never pays again, and a
+		// later one only once most of a real day has passed, so changing
+		// timezone cannot win a second reward in one real day
+		if lastDay >= today {
+			return fmt.Errorf("daily reward already claimed for %s", lastDay)
+		}
+		if err := d.checkClaimInterval("daily reward", lastClaim); err != nil {
+			return err
+		}
+
+		streakDay := 1
+		if lastDay == yesterday {
+			streakDay = lastStreak + 1
+		}
+		coins := dailyRewardFor(streakDay)
+
+		insertQuery := `INSERT INTO daily_rewards (user_id, day, streak_day, coins, claimed_at)
+		                VALUES (?, ?, ?, ?, ?)`
+		if _, err := tx.Exec(insertQuery, user.ID, today, streakDay, coins, d.now().UTC().Format(sqliteTimeFormat)); err != nil {
+			return fmt.Errorf("failed to record daily reward: %v", err)
+		}
+
+		entry, err := d.ledger.Post(tx, user.ID, models.TxDailyBonus, coins, "daily:"+today, fmt.Sprintf("Daily login reward, day %d", streakDay))
+		if err != nil {
+			return err
+		}
+
+		claim = &models.DailyRewardClaim{
+			Day:        today,
+			StreakDay:  streakDay,
+			Coins:      coins,
+			TotalCoins: entry.BalanceAfter,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+// GenerateDailyChallenges returns the challenges for a calendar day. The same
+// day always produces the same challenges, so nothing needs to be stored.
+func GenerateDailyChallenges(day string) []models.DailyChallenge {
+	hash := fnv.New64a()
+	hash.Write([]byte(day))
+	rng := rand.New(rand.NewSource(int64(hash.Sum64())))
+
+	kinds := []models.ChallengeKind{
+		models.ChallengeWinWithChoice,
+		models.ChallengeReachStreak,
+		models.ChallengePlayGames,
+		models.ChallengeWinGames,
+	}
+	choices := []models.Choice{models.Rock, models.Paper, models.Scissors}
+
+	challenges := make([]models.DailyChallenge, 0, dailyChallengeCount)
+	for i, idx := range rng.Perm(len(kinds))[:dailyChallengeCount] {
+		challenge := models.DailyChallenge{
+			ID:   fmt.Sprintf("%s-%d", day, i+1),
+			Day:  day,
+			Kind: kinds[idx],
+		}
+
+		switch challenge.Kind {
+		case models.ChallengeWinWithChoice:
+			challenge.Choice = choices[rng.Intn(len(choices))]
+			challenge.Target = 2 + rng.Intn(3)
+			challenge.RewardCoins = 20 * challenge.Target
+			challenge.Description = fmt.Sprintf("Win %d games with %s", challenge.Target, challenge.Choice)
+		case models.ChallengeReachStreak:
+			challenge.Target = 3 + rng.Intn(3)
+			challenge.RewardCoins = 25 * challenge.Target
+			challenge.Description = fmt.Sprintf("Reach a streak of %d", challenge.Target)
+		case models.ChallengePlayGames:
+			challenge.Target = 5 * (1 + rng.Intn(3))
+			challenge.RewardCoins = 4 * challenge.Target
+			challenge.Description = fmt.Sprintf("Play %d games", challenge.Target)
+		case models.ChallengeWinGames:
+			challenge.Target = 3 + rng.Intn(4)
+			challenge.RewardCoins = 15 * challenge.Target
+			challenge.Description = fmt.Sprintf("Win %d games", challenge.Target)
+		}
+
+		challenges = append(challenges, challenge)
+	}
+
+	return challenges
+}
+
+// challengeProgress fills in progress for challenges from the games the user
+// played between start and end
+func (d *DailyService) challengeProgress(exec dbExecutor, userID int, start, end time.Time, challenges []models.DailyChallenge) error {
+	query := `SELECT player_choice, result FROM games
+	          WHERE user_id = ? AND opponent_user_id IS NULL AND played_at >= ? AND played_at < ?
+	          ORDER BY played_at, id`
+	rows, err := exec.Query(query, userID, start.UTC().Format(sqliteTimeFormat), end.UTC().Format(sqliteTimeFormat))
+	if err != nil {
+		return fmt.Errorf("failed to query today's games: %v", err)
+	}
+	defer rows.Close()
+
+	played, won, streak, bestStreak := 0, 0, 0, 0
+	winsByChoice := make(map[models.Choice]int)
+	for rows.Next() {
+		var choice, result string
+		if err := rows.Scan(&choice, &result); err != nil {
+			return fmt.Errorf("failed to scan game row: %v", err)
+		}
+		played++
+		switch models.GameResult(result) {
+		case models.Win:
+			won++
+			winsByChoice[models.Choice(choice)]++
+			streak++
+			if streak > bestStreak {
+				bestStreak = streak
+			}
+		case models.Lose:
+			streak = 0
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating game rows: %v", err)
+	}
+
+	for i := range challenges {
+		c := &challenges[i]
+		switch c.Kind {
+		case models.ChallengeWinWithChoice:
+			c.Progress = winsByChoice[c.Choice]
+		case models.ChallengeReachStreak:
+			c.Progress = bestStreak
+		case models.ChallengePlayGames:
+			c.Progress = played
+		case models.ChallengeWinGames:
+			c.Progress = won
+		}
+		if c.Progress >= c.Target {
+			c.Progress = c.Target
+			c.Completed = true
+		}
+	}
+
+	return nil
+}
+
+// claimedChallenges marks the challenges the user has already claimed
+func (d *DailyService) claimedChallenges(exec dbExecutor, userID int, challenges []models.DailyChallenge) error {
+	for i := range challenges {
+		var count int
+		query := `SELECT COUNT(*) FROM daily_challenge_claims WHERE user_id = ? AND challenge_id = ?`
+		if err := exec.QueryRow(query, userID, challenges[i].ID).Scan(&count); err != nil {
+			return fmt.Errorf("failed to check challenge claim: %v", err)
+		}
+		challenges[i].Claimed = count > 0
+	}
+	return nil
+}
+
+// GetChallenges returns today's challenges in the user's timezone along with
+// their progress
+func (d *DailyService) GetChallenges(username string) ([]models.DailyChallenge, error) {
+	user, err := d.userService.GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := d.userDay(user)
+	challenges := GenerateDailyChallenges(start.Format(dayFormat))
+	if err := d.challengeProgress(d.db, user.ID, start, end, challenges); err != nil {
+		return nil, err
+	}
+	if err := d.claimedChallenges(d.db, user.ID, challenges); err != nil {
+		return nil, err
+	}
+
+	return challenges, nil
+}
+
+// ClaimChallenge pays out the reward for a completed challenge of today
+func (d *DailyService) ClaimChallenge(username, challengeID string) (*models.DailyChallenge, int, error) {
+	var claimed *models.DailyChallenge
+	var balance int
+
+	err := runInTx(d.db, func(tx *sql.Tx) error {
+		user, err := d.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+
+		start, end := d.userDay(user)
+		challenges := GenerateDailyChallenges(start.Format(dayFormat))
+		for i := range challenges {
+			if challenges[i].ID == challengeID {
+				claimed = &challenges[i]
+			}
+		}
+		if claimed == nil {
+			return fmt.Errorf("challenge '%s' not found for today", challengeID)
+		}
+
+		// once a day's challenges have been claimed, those of earlier days
+		// are closed, so moving to a timezone further west cannot reopen them
+		var lastDay sql.NullString
+		if err := tx.QueryRow(`SELECT MAX(day) FROM daily_challenge_claims WHERE user_id = ?`, user.ID).Scan(&lastDay); err != nil {
+			return fmt.Errorf("failed to check challenge claims: %v", err)
+		}
+		if lastDay.Valid && lastDay.String > claimed.Day {
+			return fmt.Errorf("challenges already claimed for %s, a later day than %s", lastDay.String, claimed.Day)
+		}
+		if lastDay.Valid && lastDay.String < claimed.Day {
+			// a new day's challenges open most of a real day after the
+			// first claim of the last one
+			var firstClaim time.Time
+			query := `SELECT claimed_at FROM daily_challenge_claims WHERE user_id = ? AND day = ? ORDER BY claimed_at LIMIT 1`
+			if err := tx.QueryRow(query, user.ID, lastDay.String).Scan(&firstClaim); err != nil {
+				return fmt.Errorf("failed to check challenge claims: %v", err)
+			}
+			if err := d.checkClaimInterval("challenges", firstClaim); err != nil {
+				return err
+			}
+		}
+
+		if err := d.challengeProgress(tx, user.ID, start, end, challenges); err != nil {
+			return err
+		}
+		if err := d.claimedChallenges(tx, user.ID, challenges); err != nil {
+			return err
+		}
+		if claimed.Claimed {
+			return fmt.Errorf("challenge '%s' already claimed", challengeID)
+		}
+		if !claimed.Completed {
+			return fmt.Errorf("challenge '%s' is not completed yet", challengeID)
+		}
+
+		insertQuery := `INSERT INTO daily_challenge_claims (user_id, challenge_id, day, coins, claimed_at)
+		                VALUES (?, ?, ?, ?, ?)`
+		if _, err := tx.Exec(insertQuery, user.ID, claimed.ID, claimed.Day, claimed.RewardCoins, d.now().UTC().Format(sqliteTimeFormat)); err != nil {
+			return fmt.Errorf("failed to record challenge claim: %v", err)
+		}
+
+		entry, err := d.ledger.Post(tx, user.ID, models.TxDailyChallenge, claimed.RewardCoins, "challenge:"+claimed.ID, claimed.Description)
+		if err != nil {
+			return err
+		}
+		claimed.Claimed = true
+		balance = entry.BalanceAfter
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return claimed, balance, nil
+}
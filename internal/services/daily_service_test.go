@@ -0,0 +1,244 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"rockpaperscissors/internal/models"
+)
+
+func TestDailyService_ClaimReward(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	userService := NewUserService(db)
+	daily := NewDailyService(db)
+
+	if _, err := userService.CreateUser("nyplayer"); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	if err := userService.SetTimezone("nyplayer", "America/New_York"); err != nil {
+		t.Fatalf("Failed to set timezone: %v", err)
+	}
+
+	// 03:00 UTC on March 10th is still March 9th in New York
+	daily.now = func() time.Time { return time.Date(2026, 3, 10, 3, 0, 0, 0, time.UTC) }
+
+	claim, err := daily.ClaimReward("nyplayer")
+	if err != nil {
+		t.Fatalf("Failed to claim reward: %v", err)
+	}
+	if claim.Day != "2026-03-09" || claim.StreakDay != 1 || claim.Coins != dailyRewardCurve[0] {
+		t.Errorf("Unexpected first claim: %+v", claim)
+	}
+
+	if _, err := daily.ClaimReward("nyplayer"); err == nil {
+		t.Error("Expected second claim on the same local day to fail")
+	}
+
+	// Two hours later it is already March 10th locally, but most of a real
+	// day has to pass between claims
+	daily.now = func() time.Time { return time.Date(2026, 3, 10, 5, 0, 0, 0, time.UTC) }
+	if _, err := daily.ClaimReward("nyplayer"); err == nil || !strings.Contains(err.Error(), "recently") {
+		t.Errorf("Expected a claim two hours later to fail, got %v", err)
+	}
+	status, err := daily.GetRewardStatus("nyplayer")
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+	if status.ClaimedToday || status.NextClaimAt == nil || !status.NextClaimAt.Equal(time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the next claim 20 hours after the first, got %+v", status)
+	}
+
+	// Later on March 10th the streak continues
+	daily.now = func() time.Time { return time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC) }
+	claim, err = daily.ClaimReward("nyplayer")
+	if err != nil {
+		t.Fatalf("Failed to claim reward: %v", err)
+	}
+	if claim.Day != "2026-03-10" || claim.StreakDay != 2 || claim.Coins != dailyRewardCurve[1] {
+		t.Errorf("Unexpected consecutive claim: %+v", claim)
+	}
+
+	// Skipping a day resets the curve
+	daily.now = func() time.Time { return time.Date(2026, 3, 12, 15, 0, 0, 0, time.UTC) }
+	status, err = daily.GetRewardStatus("nyplayer")
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+	if status.ClaimedToday || status.NextReward != dailyRewardCurve[0] {
+		t.Errorf("Expected reset status, got %+v", status)
+	}
+
+	user, _ := userService.GetUser("nyplayer")
+	if user.TotalCoins != dailyRewardCurve[0]+dailyRewardCurve[1] {
+		t.Errorf("Expected rewards in balance, got %d", user.TotalCoins)
+	}
+}
+
+func TestDailyService_Challenges(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	t.Run("Generation is deterministic per day", func(t *testing.T) {
+		first := GenerateDailyChallenges("2026-05-01")
+		second := GenerateDailyChallenges("2026-05-01")
+		if !reflect.DeepEqual(first, second) {
+			t.Error("Expected identical challenges for the same day")
+		}
+		if len(first) != dailyChallengeCount {
+			t.Errorf("Expected %d challenges, got %d", dailyChallengeCount, len(first))
+		}
+	})
+
+	t.Run("Progress comes from today's games", func(t *testing.T) {
+		userService := NewUserService(db)
+		daily := NewDailyService(db)
+		now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+		daily.now = func() time.Time { return now }
+
+		user, _ := userService.CreateUser("challenger")
+
+		// Yesterday's games must not count
+		for i := 0; i < 20; i++ {
+			db.Exec(`INSERT INTO games (user_id, player_choice, computer_choice, result, played_at) VALUES (?, 'rock', 'scissors', 'win', ?)`,
+				user.ID, now.AddDate(0, 0, -1).Format(sqliteTimeFormat))
+		}
+		challenges, err := daily.GetChallenges("challenger")
+		if err != nil {
+			t.Fatalf("Failed to get challenges: %v", err)
+		}
+		for _, c := range challenges {
+			if c.Progress != 0 {
+				t.Errorf("Expected no progress from yesterday, got %+v", c)
+			}
+		}
+		if _, _, err := daily.ClaimChallenge("challenger", challenges[0].ID); err == nil {
+			t.Error("Expected claim of incomplete challenge to fail")
+		}
+
+		// Enough wins today with every choice completes every challenge
+		for _, choice := range []models.Choice{models.Rock, models.Paper, models.Scissors} {
+			for i := 0; i < 6; i++ {
+				db.Exec(`INSERT INTO games (user_id, player_choice, computer_choice, result, played_at) VALUES (?, ?, 'rock', 'win', ?)`,
+					user.ID, string(choice), now.Add(-time.Hour).Format(sqliteTimeFormat))
+			}
+		}
+		challenges, _ = daily.GetChallenges("challenger")
+		for _, c := range challenges {
+			if !c.Completed {
+				t.Errorf("Expected challenge to be completed: %+v", c)
+			}
+			if _, _, err := daily.ClaimChallenge("challenger", c.ID); err != nil {
+				t.Errorf("Failed to claim challenge %s: %v", c.ID, err)
+			}
+		}
+		if _, _, err := daily.ClaimChallenge("challenger", challenges[0].ID); err == nil {
+			t.Error("Expected second claim to fail")
+		}
+		if _, _, err := daily.ClaimChallenge("challenger", "2020-01-01-1"); err == nil {
+			t.Error("Expected claim of another day's challenge to fail")
+		}
+	})
+}
+
+func TestDailyService_TimezoneHopping(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	userService := NewUserService(db)
+	daily := NewDailyService(db)
+
+	// 11:00 UTC on March 10th is already March 11th in Kiritimati (UTC+14)
+	// and still March 9th at UTC-12
+	now := time.Date(2026, 3, 10, 11, 0, 0, 0, time.UTC)
+	daily.now = func() time.Time { return now }
+
+	// hop moves a user west without waiting out the cooldown
+	hop := func(t *testing.T, username, timezone string) {
+		if _, err := db.Exec(`UPDATE users SET timezone = ? WHERE username = ?`, timezone, username); err != nil {
+			t.Fatalf("Failed to move %s to %s: %v", username, timezone, err)
+		}
+	}
+
+	t.Run("Login reward", func(t *testing.T) {
+		userService.CreateUser("rewardhopper")
+		if err := userService.SetTimezone("rewardhopper", "Pacific/Kiritimati"); err != nil {
+			t.Fatalf("Failed to set timezone: %v", err)
+		}
+		if claim, err := daily.ClaimReward("rewardhopper"); err != nil || claim.Day != "2026-03-11" {
+			t.Fatalf("Expected a claim for March 11th, got %+v (%v)", claim, err)
+		}
+
+		hop(t, "rewardhopper", "Etc/GMT+12")
+		if _, err := daily.ClaimReward("rewardhopper"); err == nil || !strings.Contains(err.Error(), "already claimed") {
+			t.Errorf("Expected a claim for March 9th to fail, got %v", err)
+		}
+		if status, _ := daily.GetRewardStatus("rewardhopper"); !status.ClaimedToday {
+			t.Errorf("Expected an earlier day to count as claimed, got %+v", status)
+		}
+
+		// the next day that has not been claimed continues the streak
+		daily.now = func() time.Time { return now.AddDate(0, 0, 2).Add(2 * time.Hour) }
+		defer func() { daily.now = func() time.Time { return now } }()
+		claim, err := daily.ClaimReward("rewardhopper")
+		if err != nil || claim.Day != "2026-03-12" || claim.StreakDay != 2 {
+			t.Errorf("Expected day 2 of the streak on March 12th, got %+v (%v)", claim, err)
+		}
+	})
+
+	t.Run("Challenges", func(t *testing.T) {
+		user, _ := userService.CreateUser("challengehopper")
+		if err := userService.SetTimezone("challengehopper", "Pacific/Kiritimati"); err != nil {
+			t.Fatalf("Failed to set timezone: %v", err)
+		}
+		for _, choice := range []models.Choice{models.Rock, models.Paper, models.Scissors} {
+			for i := 0; i < 6; i++ {
+				db.Exec(`INSERT INTO games (user_id, player_choice, computer_choice, result, played_at) VALUES (?, ?, 'rock', 'win', ?)`,
+					user.ID, string(choice), now.Add(-30*time.Minute).Format(sqliteTimeFormat))
+			}
+		}
+		challenges, _ := daily.GetChallenges("challengehopper")
+		if _, _, err := daily.ClaimChallenge("challengehopper", challenges[0].ID); err != nil {
+			t.Fatalf("Failed to claim challenge %s: %v", challenges[0].ID, err)
+		}
+
+		hop(t, "challengehopper", "Etc/GMT+12")
+		challenges, _ = daily.GetChallenges("challengehopper")
+		_, _, err := daily.ClaimChallenge("challengehopper", challenges[0].ID)
+		if err == nil || !strings.Contains(err.Error(), "already claimed") {
+			t.Errorf("Expected a challenge of March 9th to fail, got %v", err)
+		}
+	})
+	t.Run("Moving east with the first timezone change", func(t *testing.T) {
+		user, _ := userService.CreateUser("easthopper")
+		hop(t, "easthopper", "Etc/GMT+12")
+		for _, choice := range []models.Choice{models.Rock, models.Paper, models.Scissors} {
+			for i := 0; i < 6; i++ {
+				db.Exec(`INSERT INTO games (user_id, player_choice, computer_choice, result, played_at) VALUES (?, ?, 'rock', 'win', ?)`,
+					user.ID, string(choice), now.Add(-30*time.Minute).Format(sqliteTimeFormat))
+			}
+		}
+		if claim, err := daily.ClaimReward("easthopper"); err != nil || claim.Day != "2026-03-09" {
+			t.Fatalf("Expected a claim for March 9th, got %+v (%v)", claim, err)
+		}
+		challenges, _ := daily.GetChallenges("easthopper")
+		if _, _, err := daily.ClaimChallenge("easthopper", challenges[0].ID); err != nil {
+			t.Fatalf("Failed to claim challenge %s: %v", challenges[0].ID, err)
+		}
+
+		// the first change has no cooldown, and makes it March 11th
+		if err := userService.SetTimezone("easthopper", "Pacific/Kiritimati"); err != nil {
+			t.Fatalf("Failed to set timezone: %v", err)
+		}
+		if _, err := daily.ClaimReward("easthopper"); err == nil || !strings.Contains(err.Error(), "recently") {
+			t.Errorf("Expected a claim for March 11th in the same real day to fail, got %v", err)
+		}
+		challenges, _ = daily.GetChallenges("easthopper")
+		if _, _, err := daily.ClaimChallenge("easthopper", challenges[0].ID); err == nil || !strings.Contains(err.Error(), "recently") {
+			t.Errorf("Expected a challenge of March 11th in the same real day to fail, got %v", err)
+		}
+	})
+}
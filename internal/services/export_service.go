@@ -0,0 +1,205 @@
+package services
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+const (
+	// exportFlushRows is how many rows text exports buffer before flushing
+	exportFlushRows = 1000
+	// exportRowGroupRows caps a Parquet row group, which is held in memory
+	// until it is complete
+	exportRowGroupRows = 50000
+)
+
+// ExportService streams game history out in analyst-friendly formats. Rows
+// are written as they are read, so exports never hold a whole history in
+// memory.
+type ExportService struct {
+	db          *sql.DB
+	userService *UserService
+}
+
+// NewExportService creates a new export service
+func NewExportService(db *database.DB) *ExportService {
+	return &ExportService{
+		db:          db.DB,
+		userService: NewUserService(db),
+	}
+}
+
+// gameEncoder writes export rows in one format
+type gameEncoder interface {
+	Encode(row models.GameExportRow) error
+	Close() error
+}
+
+// newGameEncoder creates an encoder for format writing to w
+func newGameEncoder(format models.ExportFormat, w io.Writer) (gameEncoder, error) {
+	switch format {
+	case models.ExportCSV:
+		encoder := &csvGameEncoder{writer: csv.NewWriter(w)}
+		if err := encoder.writer.Write(models.GameExportColumns); err != nil {
+			return nil, fmt.Errorf("failed to write csv header: %v", err)
+		}
+		return encoder, nil
+	case models.ExportNDJSON:
+		buffered := bufio.NewWriter(w)
+		return &ndjsonGameEncoder{buffered: buffered, encoder: json.NewEncoder(buffered)}, nil
+	case models.ExportParquet:
+		writer := parquet.NewGenericWriter[models.GameExportRow](w, parquet.MaxRowsPerRowGroup(exportRowGroupRows))
+		return &parquetGameEncoder{writer: writer}, nil
+	default:
+		return nil, fmt.Errorf("invalid export format '%s': must be csv, ndjson or parquet", format)
+	}
+}
+
+// csvGameEncoder writes rows as CSV under a header of GameExportColumns
+type csvGameEncoder struct {
+	writer *csv.Writer
+	rows   int
+}
+
+func (e *csvGameEncoder) Encode(row models.GameExportRow) error {
+	opponentUserID := ""
+	if row.OpponentUserID != nil {
+		opponentUserID = strconv.FormatInt(*row.OpponentUserID, 10)
+	}
+	record := []string{
+		strconv.FormatInt(row.GameID, 10),
+		strconv.FormatInt(row.UserID, 10),
+		row.Username,
+		row.PlayerChoice,
+		row.OpponentChoice,
+		row.Result,
+		strconv.FormatInt(row.CoinsEarned, 10),
+		strconv.FormatInt(row.StreakMultiplier, 10),
+		row.OpponentType,
+		opponentUserID,
+		row.PlayedAt.UTC().Format(time.RFC3339),
+	}
+	if err := e.writer.Write(record); err != nil {
+		return err
+	}
+	e.rows++
+	if e.rows%exportFlushRows == 0 {
+		e.writer.Flush()
+		return e.writer.Error()
+	}
+	return nil
+}
+
+func (e *csvGameEncoder) Close() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// ndjsonGameEncoder writes one JSON object per line
+type ndjsonGameEncoder struct {
+	buffered *bufio.Writer
+	encoder  *json.Encoder
+}
+
+func (e *ndjsonGameEncoder) Encode(row models.GameExportRow) error {
+	row.PlayedAt = row.PlayedAt.UTC()
+	return e.encoder.Encode(row)
+}
+
+func (e *ndjsonGameEncoder) Close() error {
+	return e.buffered.Flush()
+}
+
+// parquetGameEncoder writes rows into Parquet row groups
+type parquetGameEncoder struct {
+	writer *parquet.GenericWriter[models.GameExportRow]
+}
+
+func (e *parquetGameEncoder) Encode(row models.GameExportRow) error {
+	_, err := e.writer.Write([]models.GameExportRow{row})
+	return err
+}
+
+func (e *parquetGameEncoder) Close() error {
+	return e.writer.Close()
+}
+
+// ExportUserGames writes every game of a user to w, oldest first. The user is
+// looked up before anything is written, so a missing user leaves w untouched.
+func (e *ExportService) ExportUserGames(username string, format models.ExportFormat, w io.Writer) (int, error) {
+	if !format.IsValid() {
+		return 0, fmt.Errorf("invalid export format '%s': must be csv, ndjson or parquet", format)
+	}
+	user, err := e.userService.GetUser(username)
+	if err != nil {
+		return 0, err
+	}
+	return e.exportGames("g.user_id = ?", []interface{}{user.ID}, format, w)
+}
+
+// ExportAllGames writes every game of every user to w, in the order they
+// were recorded
+func (e *ExportService) ExportAllGames(format models.ExportFormat, w io.Writer) (int, error) {
+	if !format.IsValid() {
+		return 0, fmt.Errorf("invalid export format '%s': must be csv, ndjson or parquet", format)
+	}
+	return e.exportGames("1 = 1", nil, format, w)
+}
+
+// exportGames streams the games matching where through an encoder and
+// returns how many rows were written
+func (e *ExportService) exportGames(where string, args []interface{}, format models.ExportFormat, w io.Writer) (int, error) {
+	query := `SELECT g.id, g.user_id, u.username, g.player_choice, g.computer_choice, g.result,
+	                 g.coins_earned, g.streak_multiplier, g.opponent_user_id, g.played_at
+	          FROM games g
+	          JOIN users u ON u.id = g.user_id
+	          WHERE ` + where + `
+	          ORDER BY g.id`
+	rows, err := e.db.Query(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query games for export: %v", err)
+	}
+	defer rows.Close()
+
+	encoder, err := newGameEncoder(format, w)
+	if err != nil {
+		return 0, err
+	}
+
+	exported := 0
+	for rows.Next() {
+		var row models.GameExportRow
+		var opponentUserID sql.NullInt64
+		if err := rows.Scan(&row.GameID, &row.UserID, &row.Username, &row.PlayerChoice, &row.OpponentChoice, &row.Result,
+			&row.CoinsEarned, &row.StreakMultiplier, &opponentUserID, &row.PlayedAt); err != nil {
+			return exported, fmt.Errorf("failed to scan game for export: %v", err)
+		}
+		row.OpponentType = string(models.OpponentComputer)
+		if opponentUserID.Valid {
+			row.OpponentType = string(models.OpponentPlayer)
+			row.OpponentUserID = &opponentUserID.Int64
+		}
+		if err := encoder.Encode(row); err != nil {
+			return exported, fmt.Errorf("failed to write exported game: %v", err)
+		}
+		exported++
+	}
+	if err := rows.Err(); err != nil {
+		return exported, fmt.Errorf("error iterating games for export: %v", err)
+	}
+
+	if err := encoder.Close(); err != nil {
+		return exported, fmt.Errorf("failed to finish export: %v", err)
+	}
+	return exported, nil
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+)
+
+// FriendService manages friend requests and friend lists
+type FriendService struct {
+	db          *sql.DB
+	userService *UserService
+}
+
+// NewFriendService creates a new friend service
+func NewFriendService(db *database.DB) *FriendService {
+	return &FriendService{
+		db:          db.DB,
+		userService: NewUserService(db),
+	}
+}
+
+// friendship is a stored friendship row
+type friendship struct {
+	id          int
+	requesterID int
+	addresseeID int
+	status      models.FriendshipStatus
+}
+
+// getFriendship returns the friendship between two users in either direction,
+// or nil if there is none
+func (f *FriendService) getFriendship(exec dbExecutor, userID, otherID int) (*friendship, error) {
+	var fs friendship
+	var status string
+	query := `SELECT id, requester_id, addressee_id, status FROM friendships
+	          WHERE (requester_id = ? AND addressee_id = ?) OR (requester_id = ? AND addressee_id = ?)`
+	err := exec.QueryRow(query, userID, otherID, otherID, userID).Scan(&fs.id, &fs.requesterID, &fs.addresseeID, &status)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get friendship: %v", err)
+	}
+	fs.status = models.FriendshipStatus(status)
+	return &fs, nil
+}
+
+// areFriends reports whether two users have an accepted friendship
+func (f *FriendService) areFriends(exec dbExecutor, userID, otherID int) (bool, error) {
+	fs, err := f.getFriendship(exec, userID, otherID)
+	if err != nil {
+		return false, err
+	}
+	return fs != nil && fs.status == models.FriendshipAccepted, nil
+}
+
+// getPair loads both users of a friend action
+func (f *FriendService) getPair(exec dbExecutor, username, friendName string) (*models.User, *models.User, error) {
+	if username == friendName {
+		return nil, nil, fmt.Errorf("cannot befriend yourself")
+	}
+	user, err := f.userService.getUser(exec, username)
+	if err != nil {
+		return nil, nil, err
+	}
+	friend, err := f.userService.getUser(exec, friendName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, friend, nil
+}
+
+// SendRequest sends a friend request. If the other user already asked to be
+// friends, the request is accepted instead.
+func (f *FriendService) SendRequest(username, friendName string) (models.FriendshipStatus, error) {
+	var status models.FriendshipStatus
+
+	err := runInTx(f.db, func(tx *sql.Tx) error {
+		user, friend, err := f.getPair(tx, username, friendName)
+		if err != nil {
+			return err
+		}
+
+		existing, err := f.getFriendship(tx, user.ID, friend.ID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case existing == nil:
+			insertQuery := `INSERT INTO friendships (requester_id, addressee_id, status, created_at)
+			                VALUES (?, ?, 'pending', CURRENT_TIMESTAMP)`
+			if _, err := tx.Exec(insertQuery, user.ID, friend.ID); err != nil {
+				return fmt.Errorf("failed to create friend request: %v", err)
+			}
+			status = models.FriendshipPending
+		case existing.status == models.FriendshipAccepted:
+			return fmt.Errorf("already friends with '%s'", friendName)
+		case existing.requesterID == user.ID:
+			return fmt.Errorf("friend request to '%s' already sent", friendName)
+		default:
+			if err := f.accept(tx, existing.id); err != nil {
+				return err
+			}
+			status = models.FriendshipAccepted
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return status, nil
+}
+
+// accept marks a pending friendship as accepted
+func (f *FriendService) accept(exec dbExecutor, friendshipID int) error {
+	updateQuery := `UPDATE friendships SET status = 'accepted', accepted_at = CURRENT_TIMESTAMP WHERE id = ?`
+	if _, err := exec.Exec(updateQuery, friendshipID); err != nil {
+		return fmt.Errorf("failed to accept friend request: %v", err)
+	}
+	return nil
+}
+
+// pendingRequestFrom returns the pending request friendName sent to username
+func (f *FriendService) pendingRequestFrom(exec dbExecutor, username, friendName string) (*friendship, error) {
+	user, friend, err := f.getPair(exec, username, friendName)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := f.getFriendship(exec, user.ID, friend.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil || existing.status != models.FriendshipPending || existing.addresseeID != user.ID {
+		return nil, fmt.Errorf("friend request from '%s' not found", friendName)
+	}
+	return existing, nil
+}
+
+// AcceptRequest accepts a pending friend request sent by friendName
+func (f *FriendService) AcceptRequest(username, friendName string) error {
+	return runInTx(f.db, func(tx *sql.Tx) error {
+		existing, err := f.pendingRequestFrom(tx, username, friendName)
+		if err != nil {
+			return err
+		}
+		return f.accept(tx, existing.id)
+	})
+}
+
+// DeclineRequest declines a pending friend request sent by friendName
+func (f *FriendService) DeclineRequest(username, friendName string) error {
+	return runInTx(f.db, func(tx *sql.Tx) error {
+		existing, err := f.pendingRequestFrom(tx, username, friendName)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM friendships WHERE id = ?`, existing.id); err != nil {
+			return fmt.Errorf("failed to decline friend request: %v", err)
+		}
+		return nil
+	})
+}
+
+// RemoveFriend removes a friend, or withdraws a pending request in either
+// direction
+func (f *FriendService) RemoveFriend(username, friendName string) error {
+	return runInTx(f.db, func(tx *sql.Tx) error {
+		user, friend, err := f.getPair(tx, username, friendName)
+		if err != nil {
+			return err
+		}
+		existing, err := f.getFriendship(tx, user.ID, friend.ID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return fmt.Errorf("friend '%s' not found", friendName)
+		}
+		if _, err := tx.Exec(`DELETE FROM friendships WHERE id = ?`, existing.id); err != nil {
+			return fmt.Errorf("failed to remove friend: %v", err)
+		}
+		return nil
+	})
+}
+
+// GetFriends returns the user's friends and pending requests
+func (f *FriendService) GetFriends(username string) (*models.FriendList, error) {
+	user, err := f.userService.GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT u.username, f.requester_id, f.status, f.created_at, f.accepted_at
+	          FROM friendships f
+	          JOIN users u ON u.id = CASE WHEN f.requester_id = ? THEN f.addressee_id ELSE f.requester_id END
+	          WHERE f.requester_id = ? OR f.addressee_id = ?
+	          ORDER BY u.username`
+	rows, err := f.db.Query(query, user.ID, user.ID, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query friends: %v", err)
+	}
+	defer rows.Close()
+
+	list := &models.FriendList{
+		Friends:  []models.Friend{},
+		Incoming: []models.Friend{},
+		Outgoing: []models.Friend{},
+	}
+	for rows.Next() {
+		var friend models.Friend
+		var requesterID int
+		var status string
+		var acceptedAt sql.NullTime
+		if err := rows.Scan(&friend.Username, &requesterID, &status, &friend.Since, &acceptedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan friend row: %v", err)
+		}
+		friend.Status = models.FriendshipStatus(status)
+		if acceptedAt.Valid {
+			friend.Since = acceptedAt.Time
+		}
+
+		switch {
+		case friend.Status == models.FriendshipAccepted:
+			list.Friends = append(list.Friends, friend)
+		case requesterID == user.ID:
+			list.Outgoing = append(list.Outgoing, friend)
+		default:
+			list.Incoming = append(list.Incoming, friend)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating friend rows: %v", err)
+	}
+
+	return list, nil
+}
+
+// GetFriendsLeaderboard ranks the user among their friends
+func (f *FriendService) GetFriendsLeaderboard(username string, limit int) ([]models.LeaderboardEntry, error) {
+	user, err := f.userService.GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+	return f.userService.GetFriendsLeaderboard(user.ID, limit)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
 	"rockpaperscissors/internal/models"
 	"time"
@@ -21,6 +22,14 @@ func NewGameLogicService() *GameLogicService {
 	}
 }
 
+// NewSeededGameLogicService creates a game logic service whose computer
+// choices are reproducible from seed, for simulations and tests
+func NewSeededGameLogicService(seed int64) *GameLogicService {
+	return &GameLogicService{
+		rng: rand.New(rand.NewSource(seed)),
+	}
+}
+
 // GenerateComputerChoice randomly selects rock, paper, or scissors
 func (g *GameLogicService) GenerateComputerChoice() models.Choice {
 	choices := []models.Choice{models.Rock, models.Paper, models.Scissors}
@@ -68,6 +77,18 @@ func (g *GameLogicService) CalculateStreakMultiplier(currentStreak int) int {
 	}
 }
 
+// OppositeResult returns the result of the same game from the other player's side
+func (g *GameLogicService) OppositeResult(result models.GameResult) models.GameResult {
+	switch result {
+	case models.Win:
+		return models.Lose
+	case models.Lose:
+		return models.Win
+	default:
+		return result
+	}
+}
+
 // calculate the new streak after a game ends
 func (g *GameLogicService) CalculateNewStreak(currentStreak int, result models.GameResult) int {
 	if result == models.Win {
@@ -95,6 +116,31 @@ func (g *GameLogicService) CalculateCoinsEarned(result models.GameResult, curren
 	return baseCoins * multiplier
 }
 
+// DefaultRating is the rating every player starts a season with, and the
+// fixed rating of the computer opponent
+const DefaultRating = 1000
+
+// ratingKFactor controls how far a single game moves a rating
+const ratingKFactor = 32
+
+// CalculateRatingChange returns the Elo rating change for a player rated
+// rating after a game against an opponent rated opponentRating
+func (g *GameLogicService) CalculateRatingChange(rating, opponentRating int, result models.GameResult) int {
+	expected := 1 / (1 + math.Pow(10, float64(opponentRating-rating)/400))
+
+	var score float64
+	switch result {
+	case models.Win:
+		score = 1
+	case models.Tie:
+		score = 0.5
+	default:
+		score = 0
+	}
+
+	return int(math.Round(ratingKFactor * (score - expected)))
+}
+
 func (g *GameLogicService) GetBeatMessage(winner, loser models.Choice) string {
 	switch {
 	case winner == models.Rock && loser == models.Scissors:
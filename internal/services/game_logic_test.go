@@ -18,6 +18,69 @@ func TestGameLogicService(t *testing.T) {
 		t.Logf("Computer chose: %s", choice)
 	})
 
+	t.Run("NewSeededGameLogicService", func(t *testing.T) {
+		first, second := NewSeededGameLogicService(42), NewSeededGameLogicService(42)
+		for i := 0; i < 20; i++ {
+			if a, b := first.GenerateComputerChoice(), second.GenerateComputerChoice(); a != b {
+				t.Fatalf("Expected the same choices from the same seed, got %s and %s on draw %d", a, b, i)
+			}
+		}
+	})
+
+	t.Run("ChooseBotMove", func(t *testing.T) {
+		memory := botMemory{
+			LastPlayer: models.Rock,
+			LastBot:    models.Scissors,
+			Counts:     map[models.Choice]int{models.Rock: 1, models.Scissors: 3},
+		}
+		tests := []struct {
+			bot  models.BotStrategy
+			want models.Choice
+		}{
+			{models.BotCycle, models.Rock},
+			{models.BotMirror, models.Rock},
+			{models.BotCounter, models.Paper},
+			{models.BotFrequency, models.Rock},
+		}
+		for _, tt := range tests {
+			if got := gameLogic.scriptedBotMove(tt.bot, memory); got != tt.want {
+				t.Errorf("Expected %s to play %s, got %s", tt.bot, tt.want, got)
+			}
+		}
+
+		// with nothing to go on every bot plays at random
+		if choice := gameLogic.ChooseBotMove(models.BotCounter, botMemory{}); !choice.IsValid() {
+			t.Errorf("Invalid choice for a new player: %v", choice)
+		}
+	})
+
+	t.Run("ChooseBotMove can not be beaten every game", func(t *testing.T) {
+		gameLogic := NewSeededGameLogicService(7)
+		for _, bot := range models.BotStrategies[1:] {
+			// the player always throws what beats the bot's strategy
+			memory := botMemory{Counts: make(map[models.Choice]int)}
+			wins, losses, games := 0, 0, 300
+			for i := 0; i < games; i++ {
+				playerChoice := models.Rock
+				if memory.LastPlayer != "" {
+					playerChoice = winningChoice(gameLogic.scriptedBotMove(bot, memory))
+				}
+				botChoice := gameLogic.ChooseBotMove(bot, memory)
+				switch gameLogic.DetermineWinner(playerChoice, botChoice) {
+				case models.Win:
+					wins++
+				case models.Lose:
+					losses++
+				}
+				memory.LastPlayer, memory.LastBot = playerChoice, botChoice
+				memory.Counts[playerChoice]++
+			}
+			if losses == 0 || wins > games*3/4 {
+				t.Errorf("Expected a scripted player to lose to %s now and then, got %d wins and %d losses in %d games", bot, wins, losses, games)
+			}
+		}
+	})
+
 	// Test to determine the winner of a game
 	t.Run("DetermineWinner", func(t *testing.T) {
 		//test if rock beats scissors
@@ -59,6 +122,26 @@ func TestGameLogicService(t *testing.T) {
 		}
 	})
 
+	// Test that ratings move towards the result of the game
+	t.Run("CalculateRatingChange", func(t *testing.T) {
+		change := gameLogic.CalculateRatingChange(1000, 1000, models.Win)
+		if change != 16 {
+			t.Errorf("Even ratings, win: expected +16, got %d.", change)
+		}
+		change = gameLogic.CalculateRatingChange(1000, 1000, models.Lose)
+		if change != -16 {
+			t.Errorf("Even ratings, loss: expected -16, got %d.", change)
+		}
+		change = gameLogic.CalculateRatingChange(1000, 1000, models.Tie)
+		if change != 0 {
+			t.Errorf("Even ratings, tie: expected 0, got %d.", change)
+		}
+		change = gameLogic.CalculateRatingChange(1400, 1000, models.Win)
+		if change >= 16 {
+			t.Errorf("Favourite winning should gain less than 16, got %d.", change)
+		}
+	})
+
 	t.Run("GameScenario", func(t *testing.T) {
 		currentStreak := 1
 		result := gameLogic.DetermineWinner(models.Rock, models.Scissors)
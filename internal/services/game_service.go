@@ -2,24 +2,38 @@ package services
 
 import (
 	"database/sql"
+	"encoding/base64"
 	"fmt"
+	"rockpaperscissors/internal/database"
 	"rockpaperscissors/internal/models"
+	"strconv"
+	"strings"
 )
 
 // GameService struct -> handles game logic and user interactions
 
 type GameService struct {
-	db          *sql.DB
-	gameLogic   *GameLogicService
-	userService *UserService
+	db           *sql.DB
+	stmts        *stmtCache
+	gameLogic    *GameLogicService
+	userService  *UserService
+	ledger       *LedgerService
+	achievements *AchievementService
+	seasons      *SeasonService
+	streaks      *StreakService
 }
 
 // creates a new game service
-func NewGameService(db *sql.DB) *GameService {
+func NewGameService(db *database.DB) *GameService {
 	return &GameService{
-		db:          db,
-		gameLogic:   NewGameLogicService(),
-		userService: NewUserService(db),
+		db:           db.DB,
+		stmts:        newStmtCache(db.DB),
+		gameLogic:    NewGameLogicService(),
+		userService:  NewUserService(db),
+		ledger:       NewLedgerService(db),
+		achievements: NewAchievementService(db),
+		seasons:      NewSeasonService(db),
+		streaks:      NewStreakService(db),
 	}
 }
 
@@ -28,89 +42,274 @@ func NewGameService(db *sql.DB) *GameService {
 // (username string, playerChoice models.Choice) -> username and player choice is passed as parameters
 // (*models.PlayGameResponse, error) -> return type and error
 func (g *GameService) PlayGame(username string, playerChoice models.Choice) (*models.PlayGameResponse, error) {
-	user, err := g.userService.GetUser(username)
-	if err != nil {
-		return nil, fmt.Errorf("user not found: %v", err)
-	}
-	// game logic
-	computerChoice := g.gameLogic.GenerateComputerChoice()
-	result := g.gameLogic.DetermineWinner(playerChoice, computerChoice)
-	coinsEarned := g.gameLogic.CalculateCoinsEarned(result, user.CurrentStreak)
-	streakMultiplier := g.gameLogic.CalculateStreakMultiplier(user.CurrentStreak)
-	newStreak := g.gameLogic.CalculateNewStreak(user.CurrentStreak, result)
-
-	// update user stats
-	newTotalCoins := user.TotalCoins + coinsEarned
-	newGamesPlayed := user.GamesPlayed + 1
-	newGamesWon := user.GamesWon
-	if result == models.Win {
-		newGamesWon++
-	}
-	// error handling
-	err = g.userService.UpdateUserStats(user.ID, newTotalCoins, newStreak, newGamesPlayed, newGamesWon)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update user stats: %v for user: %s", err, username)
+	return g.PlayGameAgainst(username, playerChoice, models.BotRandom)
+}
+
+// PlayGameAgainst plays a game against one of the computer's bot strategies
+func (g *GameService) PlayGameAgainst(username string, playerChoice models.Choice, bot models.BotStrategy) (*models.PlayGameResponse, error) {
+	if !bot.IsValid() {
+		return nil, fmt.Errorf("invalid bot '%s'", bot)
 	}
+	var response *models.PlayGameResponse
+
+	// everything a game changes is settled in one transaction so the user row,
+	// the game record and the ledger entry can never disagree
+	err := runInTx(g.db, func(tx *sql.Tx) error {
+		// every game runs the same queries, so they are prepared once
+		exec := g.stmts.on(tx)
+		user, err := g.userService.getUser(exec, username)
+		if err != nil {
+			return fmt.Errorf("user not found: %v", err)
+		}
+		if err := checkCanPlay(user); err != nil {
+			return err
+		}
+		// game logic
+		memory, err := g.botMemory(exec, user.ID, bot)
+		if err != nil {
+			return err
+		}
+		computerChoice := g.gameLogic.ChooseBotMove(bot, memory)
+		result := g.gameLogic.DetermineWinner(playerChoice, computerChoice)
+		coinsEarned := g.gameLogic.CalculateCoinsEarned(result, user.CurrentStreak)
+		streakMultiplier := g.gameLogic.CalculateStreakMultiplier(user.CurrentStreak)
+		newStreak := g.gameLogic.CalculateNewStreak(user.CurrentStreak, result)
+
+		// update user stats
+		newGamesPlayed := user.GamesPlayed + 1
+		newGamesWon := user.GamesWon
+		if result == models.Win {
+			newGamesWon++
+		}
+		// error handling
+		err = g.userService.updateUserStats(exec, user.ID, newStreak, newGamesPlayed, newGamesWon)
+		if err != nil {
+			return fmt.Errorf("failed to update user stats: %v for user: %s", err, username)
+		}
+
+		// Save game record to database
+		gameID, err := g.saveGameRecord(exec, user.ID, bot, playerChoice, computerChoice, result, coinsEarned, streakMultiplier)
+		if err != nil {
+			return fmt.Errorf("failed to save game record: %v", err)
+		}
+
+		// pay out the reward through the ledger
+		newTotalCoins := user.TotalCoins
+		entry, err := g.ledger.Post(tx, user.ID, models.TxGameReward, coinsEarned, fmt.Sprintf("game:%d", gameID), "")
+		if err != nil {
+			return fmt.Errorf("failed to pay game reward: %v", err)
+		}
+		if entry != nil {
+			newTotalCoins = entry.BalanceAfter
+		}
+
+		// unlock achievements; their rewards are posted in the same transaction
+		settled := *user
+		settled.CurrentStreak = newStreak
+		settled.GamesPlayed = newGamesPlayed
+		settled.GamesWon = newGamesWon
+		settled.TotalCoins = newTotalCoins
+		game := settledGame{
+			GameID:       gameID,
+			User:         settled,
+			Bot:          bot,
+			PlayerChoice: playerChoice,
+			Result:       result,
+		}
+		newAchievements, err := g.achievements.Evaluate(tx, game)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate achievements: %v", err)
+		}
+		for _, achievement := range newAchievements {
+			newTotalCoins += achievement.RewardCoins
+		}
+
+		// count the game towards the current season
+		if err := g.seasons.RecordGame(tx, game, coinsEarned); err != nil {
+			return fmt.Errorf("failed to record season stats: %v", err)
+		}
+
+		// extend or end the win streak and track the personal best
+		newBestStreak, err := g.streaks.RecordGame(tx, game, coinsEarned)
+		if err != nil {
+			return fmt.Errorf("failed to record streak: %v", err)
+		}
+
+		// create response
+		message := g.gameLogic.GetResultMessage(playerChoice, computerChoice, result, coinsEarned)
 
-	// Save game record to database
-	err = g.SaveGameRecord(user.ID, playerChoice, computerChoice, result, coinsEarned, streakMultiplier)
+		response = &models.PlayGameResponse{
+			PlayerChoice:     playerChoice,
+			ComputerChoice:   computerChoice,
+			Result:           result,
+			CoinsEarned:      coinsEarned,
+			StreakMultiplier: streakMultiplier,
+			NewStreak:        newStreak,
+			NewBestStreak:    newBestStreak,
+			TotalCoins:       newTotalCoins,
+			Message:          message,
+			NewAchievements:  newAchievements,
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to save game record: %v", err)
-	}
-
-	// create response
-	message := g.gameLogic.GetResultMessage(playerChoice, computerChoice, result, coinsEarned)
-
-	return &models.PlayGameResponse{
-		PlayerChoice:     playerChoice,
-		ComputerChoice:   computerChoice,
-		Result:           result,
-		CoinsEarned:      coinsEarned,
-		StreakMultiplier: streakMultiplier,
-		NewStreak:        newStreak,
-		TotalCoins:       newTotalCoins,
-		Message:          message,
-	}, nil
+		return nil, err
+	}
+
+	return response, nil
 }
 
 // SaveGameRecord saves an individual game record to the database
 func (g *GameService) SaveGameRecord(userID int, playerChoice, computerChoice models.Choice, result models.GameResult, coinsEarned, streakMultiplier int) error {
+	_, err := g.saveGameRecord(g.db, userID, models.BotRandom, playerChoice, computerChoice, result, coinsEarned, streakMultiplier)
+	return err
+}
+
+// saveGameRecord inserts a game against bot through exec and returns its ID
+func (g *GameService) saveGameRecord(exec dbExecutor, userID int, bot models.BotStrategy, playerChoice, computerChoice models.Choice, result models.GameResult, coinsEarned, streakMultiplier int) (int, error) {
 	query := `
-		INSERT INTO games (user_id, player_choice, computer_choice, result, coins_earned, streak_multiplier, played_at)
-		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		INSERT INTO games (user_id, bot, player_choice, computer_choice, result, coins_earned, streak_multiplier, played_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
 	`
 
-	_, err := g.db.Exec(query, userID, string(playerChoice), string(computerChoice), string(result), coinsEarned, streakMultiplier)
+	res, err := exec.Exec(query, userID, string(bot), string(playerChoice), string(computerChoice), string(result), coinsEarned, streakMultiplier)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert game record: %v", err)
+	}
+
+	gameID, err := res.LastInsertId()
 	if err != nil {
+		return 0, fmt.Errorf("failed to get game ID: %v", err)
+	}
+
+	return int(gameID), nil
+}
+
+// saveMatchGames records a game between two users, one row from each
+// player's side. The rows do not count towards either user's stats.
+func (g *GameService) saveMatchGames(exec dbExecutor, userID, opponentID int, playerChoice, opponentChoice models.Choice, result models.GameResult) error {
+	query := `
+		INSERT INTO games (user_id, player_choice, computer_choice, result, coins_earned, streak_multiplier, opponent_user_id, played_at)
+		VALUES (?, ?, ?, ?, 0, 1, ?, CURRENT_TIMESTAMP)
+	`
+
+	if _, err := exec.Exec(query, userID, string(playerChoice), string(opponentChoice), string(result), opponentID); err != nil {
+		return fmt.Errorf("failed to insert game record: %v", err)
+	}
+	if _, err := exec.Exec(query, opponentID, string(opponentChoice), string(playerChoice), string(g.gameLogic.OppositeResult(result)), userID); err != nil {
 		return fmt.Errorf("failed to insert game record: %v", err)
 	}
 
 	return nil
 }
 
-// GetUserGameHistory retrieves the game history for a specific user
-func (g *GameService) GetUserGameHistory(username string, limit int) ([]models.Game, error) {
-	if limit <= 0 {
-		limit = 20 // Default to last 20 games
+// encodeGameCursor turns the last game of a page into an opaque cursor
+func encodeGameCursor(gameID int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(gameID)))
+}
+
+// decodeGameCursor returns the game ID a cursor points at
+func decodeGameCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	gameID, err := strconv.Atoi(string(raw))
+	if err != nil || gameID <= 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return gameID, nil
+}
+
+// GetUserGameHistory retrieves a page of a user's games matching filter,
+// along with the cursor of the next page, which is empty on the last page.
+// Pages are keyed on (played_at, id) so they stay stable while new games are
+// played, and every query walks idx_games_user_played_at.
+func (g *GameService) GetUserGameHistory(username string, filter models.GameHistoryFilter) ([]models.Game, string, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 20 // Default to last 20 games
+	}
+	if filter.Order == "" {
+		filter.Order = models.SortDesc
+	}
+	if filter.Opponent == "" {
+		filter.Opponent = models.OpponentAll
+	}
+	if !filter.Order.IsValid() {
+		return nil, "", fmt.Errorf("invalid order '%s': must be asc or desc", filter.Order)
+	}
+	if filter.Result != "" && !filter.Result.IsValid() {
+		return nil, "", fmt.Errorf("invalid result '%s': must be win, lose or tie", filter.Result)
+	}
+	if filter.PlayerChoice != "" && !filter.PlayerChoice.IsValid() {
+		return nil, "", fmt.Errorf("invalid choice '%s': must be rock, paper or scissors", filter.PlayerChoice)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, "", fmt.Errorf("invalid date range: from must be before to")
+	}
+	opponent, err := opponentFilter(filter.Opponent)
+	if err != nil {
+		return nil, "", err
 	}
 
 	// First get the user to get their ID
 	user, err := g.userService.GetUser(username)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %v", err)
+		return nil, "", fmt.Errorf("user not found: %v", err)
+	}
+
+	conditions := []string{"user_id = ?", opponent}
+	args := []interface{}{user.ID}
+	if filter.Result != "" {
+		conditions = append(conditions, "result = ?")
+		args = append(args, string(filter.Result))
+	}
+	if filter.PlayerChoice != "" {
+		conditions = append(conditions, "player_choice = ?")
+		args = append(args, string(filter.PlayerChoice))
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "played_at >= ?")
+		args = append(args, filter.From.UTC().Format(sqliteTimeFormat))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "played_at < ?")
+		args = append(args, filter.To.UTC().Format(sqliteTimeFormat))
+	}
+
+	comparison, direction := "<", "DESC"
+	if filter.Order == models.SortAsc {
+		comparison, direction = ">", "ASC"
+	}
+	if filter.Cursor != "" {
+		gameID, err := decodeGameCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		// the cursor must point at one of the user's own games
+		var owned bool
+		if err := g.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM games WHERE id = ? AND user_id = ?)`, gameID, user.ID).Scan(&owned); err != nil {
+			return nil, "", fmt.Errorf("failed to check cursor: %v", err)
+		}
+		if !owned {
+			return nil, "", fmt.Errorf("invalid cursor")
+		}
+		conditions = append(conditions, "(played_at, id) "+comparison+" (SELECT played_at, id FROM games WHERE id = ?)")
+		args = append(args, gameID)
 	}
 
 	query := `
-		SELECT id, user_id, player_choice, computer_choice, result, coins_earned, streak_multiplier, played_at
-		FROM games 
-		WHERE user_id = ?
-		ORDER BY played_at DESC
+		SELECT id, user_id, player_choice, computer_choice, result, coins_earned, streak_multiplier, opponent_user_id, played_at
+		FROM games
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY played_at ` + direction + `, id ` + direction + `
 		LIMIT ?
 	`
 
-	rows, err := g.db.Query(query, user.ID, limit)
+	// one extra row tells whether there is another page
+	rows, err := g.db.Query(query, append(args, filter.Limit+1)...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query game history: %v", err)
+		return nil, "", fmt.Errorf("failed to query game history: %v", err)
 	}
 	defer rows.Close()
 
@@ -118,6 +317,7 @@ func (g *GameService) GetUserGameHistory(username string, limit int) ([]models.G
 	for rows.Next() {
 		var game models.Game
 		var playerChoice, computerChoice, result string
+		var opponentUserID sql.NullInt64
 
 		err := rows.Scan(
 			&game.ID,
@@ -127,10 +327,15 @@ func (g *GameService) GetUserGameHistory(username string, limit int) ([]models.G
 			&result,
 			&game.CoinsEarned,
 			&game.StreakMultiplier,
+			&opponentUserID,
 			&game.PlayedAt,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan game row: %v", err)
+			return nil, "", fmt.Errorf("failed to scan game row: %v", err)
+		}
+		if opponentUserID.Valid {
+			id := int(opponentUserID.Int64)
+			game.OpponentUserID = &id
 		}
 
 		// Convert string fields back to typed fields
@@ -142,8 +347,14 @@ func (g *GameService) GetUserGameHistory(username string, limit int) ([]models.G
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating game rows: %v", err)
+		return nil, "", fmt.Errorf("error iterating game rows: %v", err)
+	}
+
+	nextCursor := ""
+	if len(games) > filter.Limit {
+		games = games[:filter.Limit]
+		nextCursor = encodeGameCursor(games[len(games)-1].ID)
 	}
 
-	return games, nil
+	return games, nextCursor, nil
 }
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+)
+
+// headToHeadRecentGames is how many recent games a head-to-head record shows
+const headToHeadRecentGames = 10
+
+// HeadToHeadService builds records of games played between two users
+type HeadToHeadService struct {
+	db          *sql.DB
+	userService *UserService
+}
+
+// NewHeadToHeadService creates a new head-to-head service
+func NewHeadToHeadService(db *database.DB) *HeadToHeadService {
+	return &HeadToHeadService{
+		db:          db.DB,
+		userService: NewUserService(db),
+	}
+}
+
+// GetHeadToHead returns the record of every game username played against
+// opponentName, from username's point of view. Every query reads the player's
+// side of the games through idx_games_user_opponent.
+func (h *HeadToHeadService) GetHeadToHead(username, opponentName string) (*models.HeadToHead, error) {
+	if username == opponentName {
+		return nil, fmt.Errorf("invalid head-to-head: a player cannot face themselves")
+	}
+	player, err := h.userService.GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+	opponent, err := h.userService.GetUser(opponentName)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.HeadToHead{
+		Player:   player.Username,
+		Opponent: opponent.Username,
+	}
+
+	if err := h.countResults(player.ID, opponent.ID, record); err != nil {
+		return nil, err
+	}
+	record.WinRate = winRate(record.Wins, record.GamesPlayed)
+
+	pairFilter := "user_id = ? AND opponent_user_id = ?"
+	pair := []interface{}{player.ID, opponent.ID}
+	if record.LongestWinStreak, err = longestRun(h.db, pairFilter, pair, models.Win); err != nil {
+		return nil, err
+	}
+	if record.OpponentLongestStreak, err = longestRun(h.db, pairFilter, pair, models.Lose); err != nil {
+		return nil, err
+	}
+	if record.PlayerChoices, err = h.choiceCounts(player.ID, opponent.ID, "player_choice"); err != nil {
+		return nil, err
+	}
+	// the player's rows hold the opponent's throw in computer_choice
+	if record.OpponentChoices, err = h.choiceCounts(player.ID, opponent.ID, "computer_choice"); err != nil {
+		return nil, err
+	}
+	if err := h.challengeSwing(player.ID, opponent.ID, record); err != nil {
+		return nil, err
+	}
+	if record.RecentGames, err = h.recentGames(player.ID, opponent.ID, headToHeadRecentGames); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// countResults tallies wins, losses and ties
+func (h *HeadToHeadService) countResults(playerID, opponentID int, record *models.HeadToHead) error {
+	query := `SELECT result, COUNT(*) FROM games
+	          WHERE user_id = ? AND opponent_user_id = ?
+	          GROUP BY result`
+	rows, err := h.db.Query(query, playerID, opponentID)
+	if err != nil {
+		return fmt.Errorf("failed to count head-to-head results: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result string
+		var count int
+		if err := rows.Scan(&result, &count); err != nil {
+			return fmt.Errorf("failed to scan head-to-head result: %v", err)
+		}
+		switch models.GameResult(result) {
+		case models.Win:
+			record.Wins = count
+		case models.Lose:
+			record.Losses = count
+		case models.Tie:
+			record.Ties = count
+		}
+		record.GamesPlayed += count
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating head-to-head results: %v", err)
+	}
+
+	return nil
+}
+
+// choiceCounts counts each choice in column, most common first
+func (h *HeadToHeadService) choiceCounts(playerID, opponentID int, column string) ([]models.ChoiceCount, error) {
+	query := `SELECT ` + column + `, COUNT(*) AS times FROM games
+	          WHERE user_id = ? AND opponent_user_id = ?
+	          GROUP BY ` + column + `
+	          ORDER BY times DESC, ` + column
+	rows, err := h.db.Query(query, playerID, opponentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count head-to-head choices: %v", err)
+	}
+	defer rows.Close()
+
+	counts := []models.ChoiceCount{}
+	for rows.Next() {
+		var choice string
+		var count int
+		if err := rows.Scan(&choice, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan head-to-head choice: %v", err)
+		}
+		counts = append(counts, models.ChoiceCount{Choice: models.Choice(choice), Count: count})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating head-to-head choices: %v", err)
+	}
+
+	return counts, nil
+}
+
+// challengeSwing sums the stakes won and lost in completed and forfeited
+// challenges between the two players
+func (h *HeadToHeadService) challengeSwing(playerID, opponentID int, record *models.HeadToHead) error {
+	query := `SELECT COUNT(*),
+	                 COALESCE(SUM(CASE WHEN winner_id = ? THEN stake ELSE -stake END), 0)
+	          FROM challenges
+	          WHERE status IN ('completed', 'forfeited')
+	            AND ((challenger_id = ? AND opponent_id = ?) OR (challenger_id = ? AND opponent_id = ?))`
+	err := h.db.QueryRow(query, playerID, playerID, opponentID, opponentID, playerID).Scan(&record.ChallengesPlayed, &record.CoinSwing)
+	if err != nil {
+		return fmt.Errorf("failed to compute head-to-head coin swing: %v", err)
+	}
+	return nil
+}
+
+// recentGames returns the latest games between the two players
+func (h *HeadToHeadService) recentGames(playerID, opponentID, limit int) ([]models.Game, error) {
+	query := `SELECT id, user_id, player_choice, computer_choice, result, coins_earned, streak_multiplier, played_at
+	          FROM games
+	          WHERE user_id = ? AND opponent_user_id = ?
+	          ORDER BY played_at DESC, id DESC
+	          LIMIT ?`
+	rows, err := h.db.Query(query, playerID, opponentID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query head-to-head games: %v", err)
+	}
+	defer rows.Close()
+
+	games := []models.Game{}
+	for rows.Next() {
+		var game models.Game
+		var playerChoice, computerChoice, result string
+		if err := rows.Scan(&game.ID, &game.UserID, &playerChoice, &computerChoice, &result, &game.CoinsEarned, &game.StreakMultiplier, &game.PlayedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan head-to-head game: %v", err)
+		}
+		game.PlayerChoice = models.Choice(playerChoice)
+		game.ComputerChoice = models.Choice(computerChoice)
+		game.Result = models.GameResult(result)
+		opponent := opponentID
+		game.OpponentUserID = &opponent
+		games = append(games, game)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating head-to-head games: %v", err)
+	}
+
+	return games, nil
+}
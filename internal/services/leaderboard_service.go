@@ -0,0 +1,262 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+)
+
+// LeaderboardService keeps the coin leaderboard in memory, so reading it
+// does not sort the users table on every request
+type LeaderboardService struct {
+	cache *leaderboardCache
+}
+
+// NewLeaderboardService creates a new leaderboard service
+func NewLeaderboardService(db *database.DB) *LeaderboardService {
+	return &LeaderboardService{cache: leaderboardFor(db)}
+}
+
+// UseStore moves the leaderboard into store, to share it between replicas.
+// The store is filled on the next read or Warm.
+func (l *LeaderboardService) UseStore(store LeaderboardStore) {
+	l.cache.mu.Lock()
+	defer l.cache.mu.Unlock()
+	l.cache.store = store
+	l.cache.warm = false
+}
+
+// Warm loads the leaderboard from the database and returns how many users
+// are on it
+func (l *LeaderboardService) Warm() (int, error) {
+	l.cache.mu.Lock()
+	defer l.cache.mu.Unlock()
+	if err := l.cache.warmLocked(); err != nil {
+		return 0, err
+	}
+	return l.cache.store.Len()
+}
+
+// RunRefresh reloads the leaderboard every interval until stop is closed.
+// Changes the server makes itself show up at once; this picks up the ones
+// made around it, such as by rpsadmin.
+func (l *LeaderboardService) RunRefresh(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := l.Warm(); err != nil {
+				log.Printf("Leaderboard refresh failed: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// leaderboardCache keeps the users of a database who belong on the coin
+// leaderboard in a LeaderboardStore. Users are refreshed from the database
+// after every commit that changes their coins, their games won or whether
+// they are ranked at all. It starts cold and is warmed by its first read.
+type leaderboardCache struct {
+	db  *sql.DB
+	now func() time.Time
+
+	// mu serializes warming and refreshes, so a refresh that read the
+	// database before another one can never be applied after it
+	mu        sync.Mutex
+	store     LeaderboardStore
+	warm      bool
+	suspended map[int]suspendedScore
+}
+
+// suspendedScore is a suspended user's score, kept off the leaderboard until
+// their suspension ends
+type suspendedScore struct {
+	score models.LeaderboardScore
+	until time.Time
+}
+
+// leaderboardKey is what the leaderboard cache is kept under on a database
+type leaderboardKey struct{}
+
+// leaderboardFor returns the leaderboard cache of db, shared by every
+// service built on it and dropped when it is closed
+func leaderboardFor(db *database.DB) *leaderboardCache {
+	return db.Keep(leaderboardKey{}, func() interface{} {
+		return &leaderboardCache{
+			db:        db.DB,
+			now:       time.Now,
+			store:     NewMemoryLeaderboardStore(),
+			suspended: make(map[int]suspendedScore),
+		}
+	}).(*leaderboardCache)
+}
+
+// leaderboardColumns are what decides a user's place on the leaderboard
+const leaderboardColumns = `id, total_coins, games_won, status, suspended_until, deletion_scheduled_at`
+
+// leaderboardRow is a row of leaderboardColumns
+type leaderboardRow struct {
+	score               models.LeaderboardScore
+	status              string
+	suspendedUntil      sql.NullTime
+	deletionScheduledAt sql.NullTime
+}
+
+func scanLeaderboardRow(row rowScanner) (leaderboardRow, error) {
+	var r leaderboardRow
+	err := row.Scan(&r.score.UserID, &r.score.Coins, &r.score.GamesWon, &r.status, &r.suspendedUntil, &r.deletionScheduledAt)
+	return r, err
+}
+
+// rankedFrom mirrors rankedUsersFilter: it reports whether the user belongs
+// on the leaderboard at all and, for a suspended user, from when
+func (r leaderboardRow) rankedFrom() (time.Time, bool) {
+	if r.deletionScheduledAt.Valid {
+		return time.Time{}, false
+	}
+	switch models.UserStatus(r.status) {
+	case models.UserActive:
+		return time.Time{}, true
+	case models.UserSuspended:
+		return r.suspendedUntil.Time, r.suspendedUntil.Valid
+	}
+	return time.Time{}, false
+}
+
+// placeLocked puts a user where they belong: on the leaderboard, waiting out
+// a suspension or nowhere
+func (c *leaderboardCache) placeLocked(r leaderboardRow) error {
+	delete(c.suspended, r.score.UserID)
+	from, ranked := r.rankedFrom()
+	if ranked && from.After(c.now()) {
+		c.suspended[r.score.UserID] = suspendedScore{score: r.score, until: from}
+		ranked = false
+	}
+	if !ranked {
+		return c.store.Remove(r.score.UserID)
+	}
+	return c.store.Set(r.score)
+}
+
+// warmLocked loads every user into the store
+func (c *leaderboardCache) warmLocked() error {
+	c.warm = false
+	rows, err := c.db.Query(`SELECT ` + leaderboardColumns + ` FROM users`)
+	if err != nil {
+		return fmt.Errorf("failed to query leaderboard: %v", err)
+	}
+	defer rows.Close()
+
+	now := c.now()
+	scores := []models.LeaderboardScore{}
+	suspended := make(map[int]suspendedScore)
+	for rows.Next() {
+		r, err := scanLeaderboardRow(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan leaderboard row: %v", err)
+		}
+		from, ranked := r.rankedFrom()
+		switch {
+		case ranked && from.After(now):
+			suspended[r.score.UserID] = suspendedScore{score: r.score, until: from}
+		case ranked:
+			scores = append(scores, r.score)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating leaderboard rows: %v", err)
+	}
+
+	if err := c.store.Replace(scores); err != nil {
+		return fmt.Errorf("failed to load leaderboard: %v", err)
+	}
+	c.suspended = suspended
+	c.warm = true
+	return nil
+}
+
+// readyLocked warms the cache if it is cold and puts users whose suspension
+// is over back on the leaderboard
+func (c *leaderboardCache) readyLocked() error {
+	if !c.warm {
+		return c.warmLocked()
+	}
+	now := c.now()
+	for userID, s := range c.suspended {
+		if s.until.After(now) {
+			continue
+		}
+		if err := c.store.Set(s.score); err != nil {
+			c.warm = false
+			return fmt.Errorf("failed to update leaderboard: %v", err)
+		}
+		delete(c.suspended, userID)
+	}
+	return nil
+}
+
+// top returns the scores of the first limit users on the leaderboard
+func (c *leaderboardCache) top(limit int) ([]models.LeaderboardScore, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.readyLocked(); err != nil {
+		return nil, err
+	}
+	return c.store.Range(0, limit)
+}
+
+// rank returns a user's rank, or 0 if they are not on the leaderboard
+func (c *leaderboardCache) rank(userID int) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.readyLocked(); err != nil {
+		return 0, err
+	}
+	return c.store.Rank(userID)
+}
+
+// refresh reloads a user from the database. A cold cache is left alone;
+// warming it picks the change up. If the user cannot be reloaded the cache
+// goes cold rather than stay wrong.
+func (c *leaderboardCache) refresh(userID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.warm {
+		return
+	}
+
+	r, err := scanLeaderboardRow(c.db.QueryRow(`SELECT `+leaderboardColumns+` FROM users WHERE id = ?`, userID))
+	switch {
+	case err == sql.ErrNoRows:
+		delete(c.suspended, userID)
+		err = c.store.Remove(userID)
+	case err == nil:
+		err = c.placeLocked(r)
+	}
+	if err != nil {
+		log.Printf("Failed to refresh leaderboard for user %d, reloading it: %v", userID, err)
+		c.warm = false
+	}
+}
+
+// touch refreshes a user once the change exec made to them is committed.
+// Outside a transaction the change already is.
+func (c *leaderboardCache) touch(exec dbExecutor, userID int) {
+	if cached, ok := exec.(*cachedExecutor); ok {
+		exec = cached.exec
+	}
+	if tx, ok := exec.(*sql.Tx); ok {
+		afterCommit(tx, fmt.Sprintf("leaderboard:%d", userID), func() { c.refresh(userID) })
+		return
+	}
+	c.refresh(userID)
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"rockpaperscissors/internal/models"
+)
+
+func TestLeaderboardCache(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	userService := NewUserService(db)
+	ledger := NewLedgerService(db)
+	admin := NewAdminService(db)
+	ids := make(map[string]int)
+	for i, name := range []string{"alice", "bob", "carol"} {
+		user, err := userService.CreateUser(name)
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		ids[name] = user.ID
+		if _, err := ledger.Record(user.ID, models.TxAdminAdjustment, 30-10*i, "", "start"); err != nil {
+			t.Fatalf("Failed to record coins: %v", err)
+		}
+	}
+
+	cache := leaderboardFor(db)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	expectOrder := func(want ...string) {
+		t.Helper()
+		leaderboard, err := userService.GetLeaderboard(10)
+		if err != nil {
+			t.Fatalf("Failed to get leaderboard: %v", err)
+		}
+		var got []string
+		for _, entry := range leaderboard {
+			got = append(got, entry.Username)
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("Expected leaderboard %v, got %v", want, got)
+		}
+		for i, name := range want {
+			if rank, err := userService.GetRank(ids[name]); err != nil || rank != i+1 {
+				t.Errorf("Expected %s at rank %d, got %d, %v", name, i+1, rank, err)
+			}
+		}
+	}
+	// the first read warms the cache; everything after goes through it
+	expectOrder("alice", "bob", "carol")
+
+	if _, err := ledger.Record(ids["carol"], models.TxAdminAdjustment, 25, "", "bonus"); err != nil {
+		t.Fatalf("Failed to record coins: %v", err)
+	}
+	expectOrder("carol", "alice", "bob")
+
+	// a rolled back change never reaches the cache
+	err := runInTx(db.DB, func(tx *sql.Tx) error {
+		if _, err := ledger.Post(tx, ids["bob"], models.TxAdminAdjustment, 100, "", "bonus"); err != nil {
+			return err
+		}
+		return fmt.Errorf("changed my mind")
+	})
+	if err == nil {
+		t.Fatalf("Expected the transaction to fail")
+	}
+	expectOrder("carol", "alice", "bob")
+
+	// moderated and deleted players drop off, a suspension only until it ends
+	if _, err := admin.BanUser("mod", "carol", "cheating"); err != nil {
+		t.Fatalf("Failed to ban user: %v", err)
+	}
+	expectOrder("alice", "bob")
+	if rank, _ := userService.GetRank(ids["carol"]); rank != 0 {
+		t.Errorf("Expected a banned user to have no rank, got %d", rank)
+	}
+	if _, err := admin.ReinstateUser("mod", "carol", "appeal"); err != nil {
+		t.Fatalf("Failed to reinstate user: %v", err)
+	}
+	if _, err := admin.SuspendUser("mod", "alice", now.Add(time.Hour), "spam"); err != nil {
+		t.Fatalf("Failed to suspend user: %v", err)
+	}
+	expectOrder("carol", "bob")
+	// the suspension ends for the database as well as for the cache
+	now = now.Add(2 * time.Hour)
+	if _, err := db.Exec(`UPDATE users SET suspended_until = datetime('now', '-1 hour') WHERE id = ?`, ids["alice"]); err != nil {
+		t.Fatalf("Failed to end suspension: %v", err)
+	}
+	expectOrder("carol", "alice", "bob")
+
+	if err := admin.DeleteUser("mod", "bob", "requested"); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+	expectOrder("carol", "alice")
+
+	// writes made around the services show up once it is warmed again
+	if _, err := db.Exec(`UPDATE users SET total_coins = 500 WHERE id = ?`, ids["alice"]); err != nil {
+		t.Fatalf("Failed to update user: %v", err)
+	}
+	expectOrder("carol", "alice")
+	if ranked, err := NewLeaderboardService(db).Warm(); err != nil || ranked != 2 {
+		t.Fatalf("Expected 2 players loaded, got %d, %v", ranked, err)
+	}
+	expectOrder("alice", "carol")
+
+	// a ban written around the services takes the player off the page at
+	// once, even while the cache still ranks them
+	if _, err := db.Exec(`UPDATE users SET status = 'banned' WHERE id = ?`, ids["alice"]); err != nil {
+		t.Fatalf("Failed to ban user: %v", err)
+	}
+	leaderboard, err := userService.GetLeaderboard(10)
+	if err != nil {
+		t.Fatalf("Failed to get leaderboard: %v", err)
+	}
+	if len(leaderboard) != 1 || leaderboard[0].Username != "carol" {
+		t.Errorf("Expected only carol on the leaderboard, got %+v", leaderboard)
+	}
+
+	// the cache belongs to the database and goes when it is closed
+	if leaderboardFor(db) != cache {
+		t.Errorf("Expected services on one database to share its leaderboard")
+	}
+	db.Close()
+	if leaderboardFor(db) == cache {
+		t.Errorf("Expected the leaderboard to be dropped with the database")
+	}
+}
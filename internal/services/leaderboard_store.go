@@ -0,0 +1,267 @@
+package services
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"rockpaperscissors/internal/models"
+)
+
+// LeaderboardStore keeps users in leaderboard order. Ranks start at 1.
+//
+// MemoryLeaderboardStore serves a single server. The operations are those of
+// a Redis sorted set (ZADD, ZREM, ZREVRANGE, ZREVRANK, ZCARD), so when
+// several replicas serve one database a Redis-compatible store shared
+// between them can take its place, with each score packed into the sorted
+// set's score and member.
+type LeaderboardStore interface {
+	// Set adds the user or moves them to their new score
+	Set(score models.LeaderboardScore) error
+	// Remove takes the user off the leaderboard, if they are on it
+	Remove(userID int) error
+	// Range returns up to limit scores starting after the first offset
+	Range(offset, limit int) ([]models.LeaderboardScore, error)
+	// Rank returns the user's rank, or 0 if they are not on the leaderboard
+	Rank(userID int) (int, error)
+	// Len returns how many users are on the leaderboard
+	Len() (int, error)
+	// Replace swaps the whole leaderboard for scores
+	Replace(scores []models.LeaderboardScore) error
+}
+
+// MemoryLeaderboardStore is a LeaderboardStore held in memory, in a skip list
+// that knows how many entries each link skips so ranks are found in
+// O(log n), like a Redis sorted set
+type MemoryLeaderboardStore struct {
+	mu     sync.RWMutex
+	list   *skipList
+	scores map[int]models.LeaderboardScore
+}
+
+// NewMemoryLeaderboardStore creates an empty in-memory leaderboard
+func NewMemoryLeaderboardStore() *MemoryLeaderboardStore {
+	return &MemoryLeaderboardStore{
+		list:   newSkipList(),
+		scores: make(map[int]models.LeaderboardScore),
+	}
+}
+
+func (m *MemoryLeaderboardStore) Set(score models.LeaderboardScore) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if old, ok := m.scores[score.UserID]; ok {
+		if old == score {
+			return nil
+		}
+		m.list.delete(old)
+	}
+	m.list.insert(score)
+	m.scores[score.UserID] = score
+	return nil
+}
+
+func (m *MemoryLeaderboardStore) Remove(userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if old, ok := m.scores[userID]; ok {
+		m.list.delete(old)
+		delete(m.scores, userID)
+	}
+	return nil
+}
+
+func (m *MemoryLeaderboardStore) Range(offset, limit int) ([]models.LeaderboardScore, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	scores := []models.LeaderboardScore{}
+	if offset < 0 || limit <= 0 {
+		return scores, nil
+	}
+	for node := m.list.byRank(offset + 1); node != nil && len(scores) < limit; node = node.levels[0].next {
+		scores = append(scores, node.score)
+	}
+	return scores, nil
+}
+
+func (m *MemoryLeaderboardStore) Rank(userID int) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	score, ok := m.scores[userID]
+	if !ok {
+		return 0, nil
+	}
+	return m.list.rank(score), nil
+}
+
+func (m *MemoryLeaderboardStore) Len() (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.list.length, nil
+}
+
+func (m *MemoryLeaderboardStore) Replace(scores []models.LeaderboardScore) error {
+	list := newSkipList()
+	byUser := make(map[int]models.LeaderboardScore, len(scores))
+	for _, score := range scores {
+		if old, ok := byUser[score.UserID]; ok {
+			list.delete(old)
+		}
+		list.insert(score)
+		byUser[score.UserID] = score
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.list = list
+	m.scores = byUser
+	return nil
+}
+
+const (
+	skipListMaxLevel = 32
+	skipListP        = 0.25 // chance of a node reaching each next level
+)
+
+// skipList keeps scores in leaderboard order. Every link records its span,
+// how many entries it skips, so adding up the spans on the way to an entry
+// gives its rank.
+type skipList struct {
+	head   *skipListNode
+	level  int
+	length int
+	random *rand.Rand
+}
+
+type skipListNode struct {
+	score  models.LeaderboardScore
+	levels []skipListLink
+}
+
+type skipListLink struct {
+	next *skipListNode
+	span int
+}
+
+func newSkipList() *skipList {
+	return &skipList{
+		head:   &skipListNode{levels: make([]skipListLink, skipListMaxLevel)},
+		level:  1,
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (l *skipList) randomLevel() int {
+	level := 1
+	for level < skipListMaxLevel && l.random.Float64() < skipListP {
+		level++
+	}
+	return level
+}
+
+// insert adds a score, which must not be in the list yet
+func (l *skipList) insert(score models.LeaderboardScore) {
+	var update [skipListMaxLevel]*skipListNode
+	var rank [skipListMaxLevel]int
+
+	node := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		if i < l.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for node.levels[i].next != nil && node.levels[i].next.score.RanksAbove(score) {
+			rank[i] += node.levels[i].span
+			node = node.levels[i].next
+		}
+		update[i] = node
+	}
+
+	level := l.randomLevel()
+	if level > l.level {
+		for i := l.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = l.head
+			update[i].levels[i].span = l.length
+		}
+		l.level = level
+	}
+
+	inserted := &skipListNode{score: score, levels: make([]skipListLink, level)}
+	for i := 0; i < level; i++ {
+		inserted.levels[i].next = update[i].levels[i].next
+		update[i].levels[i].next = inserted
+		// rank[0] - rank[i] entries lie between update[i] and the new node
+		inserted.levels[i].span = update[i].levels[i].span - (rank[0] - rank[i])
+		update[i].levels[i].span = rank[0] - rank[i] + 1
+	}
+	for i := level; i < l.level; i++ {
+		update[i].levels[i].span++
+	}
+	l.length++
+}
+
+// delete removes a score if it is in the list
+func (l *skipList) delete(score models.LeaderboardScore) {
+	var update [skipListMaxLevel]*skipListNode
+
+	node := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for node.levels[i].next != nil && node.levels[i].next.score.RanksAbove(score) {
+			node = node.levels[i].next
+		}
+		update[i] = node
+	}
+	node = node.levels[0].next
+	if node == nil || node.score != score {
+		return
+	}
+
+	for i := 0; i < l.level; i++ {
+		if update[i].levels[i].next == node {
+			update[i].levels[i].span += node.levels[i].span - 1
+			update[i].levels[i].next = node.levels[i].next
+		} else {
+			update[i].levels[i].span--
+		}
+	}
+	for l.level > 1 && l.head.levels[l.level-1].next == nil {
+		l.level--
+	}
+	l.length--
+}
+
+// rank returns the 1-based rank of a score in the list, or 0 if it is not
+// in it
+func (l *skipList) rank(score models.LeaderboardScore) int {
+	rank := 0
+	node := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for node.levels[i].next != nil && !score.RanksAbove(node.levels[i].next.score) {
+			rank += node.levels[i].span
+			node = node.levels[i].next
+		}
+		if node != l.head && node.score == score {
+			return rank
+		}
+	}
+	return 0
+}
+
+// byRank returns the node at a 1-based rank, or nil past the end
+func (l *skipList) byRank(rank int) *skipListNode {
+	if rank < 1 || rank > l.length {
+		return nil
+	}
+	traversed := 0
+	node := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for node.levels[i].next != nil && traversed+node.levels[i].span <= rank {
+			traversed += node.levels[i].span
+			node = node.levels[i].next
+		}
+		if traversed == rank {
+			return node
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"rockpaperscissors/internal/models"
+)
+
+func TestMemoryLeaderboardStore_MatchesSorting(t *testing.T) {
+	store := NewMemoryLeaderboardStore()
+	scores := make(map[int]models.LeaderboardScore)
+	random := rand.New(rand.NewSource(1))
+
+	// few distinct coin and win counts so ties are common
+	for i := 0; i < 3000; i++ {
+		userID := random.Intn(300) + 1
+		if random.Intn(5) == 0 {
+			store.Remove(userID)
+			delete(scores, userID)
+		} else {
+			score := models.LeaderboardScore{UserID: userID, Coins: random.Intn(20), GamesWon: random.Intn(3)}
+			store.Set(score)
+			scores[userID] = score
+		}
+
+		if i%250 != 0 {
+			continue
+		}
+		want := make([]models.LeaderboardScore, 0, len(scores))
+		for _, score := range scores {
+			want = append(want, score)
+		}
+		sort.Slice(want, func(a, b int) bool { return want[a].RanksAbove(want[b]) })
+
+		if n, _ := store.Len(); n != len(want) {
+			t.Fatalf("Expected %d users, got %d", len(want), n)
+		}
+		got, _ := store.Range(0, len(want)+5)
+		if len(got) != len(want) {
+			t.Fatalf("Expected a range of %d, got %d", len(want), len(got))
+		}
+		for rank, score := range want {
+			if got[rank] != score {
+				t.Fatalf("Expected %+v at rank %d, got %+v", score, rank+1, got[rank])
+			}
+			if r, _ := store.Rank(score.UserID); r != rank+1 {
+				t.Fatalf("Expected user %d at rank %d, got %d", score.UserID, rank+1, r)
+			}
+		}
+		if len(want) > 10 {
+			page, _ := store.Range(5, 3)
+			if len(page) != 3 || page[0] != want[5] || page[2] != want[7] {
+				t.Fatalf("Expected ranks 6 to 8, got %+v", page)
+			}
+		}
+	}
+
+	if rank, _ := store.Rank(1000); rank != 0 {
+		t.Errorf("Expected an unknown user to have no rank, got %d", rank)
+	}
+	store.Replace([]models.LeaderboardScore{{UserID: 1, Coins: 5}, {UserID: 2, Coins: 9}})
+	if top, _ := store.Range(0, 10); len(top) != 2 || top[0].UserID != 2 {
+		t.Errorf("Expected the replaced leaderboard, got %+v", top)
+	}
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"sync"
+	"time"
+)
+
+// dbExecutor is satisfied by both *sql.DB and *sql.Tx so queries can run
+// either standalone or as part of a larger transaction
+type dbExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// commitHooks holds the functions to run once a transaction commits, keyed
+// so the same one is only registered once per transaction
+var commitHooks = struct {
+	sync.Mutex
+	byTx map[*sql.Tx]map[string]func()
+}{byTx: make(map[*sql.Tx]map[string]func())}
+
+// afterCommit runs fn once tx has committed, for in-memory state that must
+// not see changes that may still be rolled back. Nothing runs if tx rolls
+// back, and a second fn under the same key is ignored. tx must have been
+// started by runInTx.
+func afterCommit(tx *sql.Tx, key string, fn func()) {
+	commitHooks.Lock()
+	defer commitHooks.Unlock()
+	hooks := commitHooks.byTx[tx]
+	if hooks == nil {
+		hooks = make(map[string]func())
+		commitHooks.byTx[tx] = hooks
+	}
+	if _, ok := hooks[key]; !ok {
+		hooks[key] = fn
+	}
+}
+
+// takeCommitHooks removes and returns the hooks registered on tx
+func takeCommitHooks(tx *sql.Tx) map[string]func() {
+	commitHooks.Lock()
+	defer commitHooks.Unlock()
+	hooks := commitHooks.byTx[tx]
+	delete(commitHooks.byTx, tx)
+	return hooks
+}
+
+// commitTx runs fn inside tx and commits it. The hooks registered on tx are
+// dropped if either fails.
+func commitTx(tx *sql.Tx, fn func(tx *sql.Tx) error) error {
+	if err := fn(tx); err != nil {
+		takeCommitHooks(tx)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		takeCommitHooks(tx)
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// runCommitHooks runs the hooks registered on a committed tx
+func runCommitHooks(tx *sql.Tx) {
+	for _, hook := range takeCommitHooks(tx) {
+		hook()
+	}
+}
+
+// runInTx executes fn inside a transaction, committing on success
+func runInTx(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := commitTx(tx, fn); err != nil {
+		return err
+	}
+	runCommitHooks(tx)
+	return nil
+}
+
+// queryIDs collects the IDs a query returns up front, so each row can then
+// be worked on without holding the result set open
+func queryIDs(exec dbExecutor, query string, args ...interface{}) ([]int, error) {
+	rows, err := exec.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query IDs: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan ID: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating IDs: %v", err)
+	}
+
+	return ids, nil
+}
+
+// LedgerService records every coin balance change as an immutable entry
+type LedgerService struct {
+	db          *sql.DB
+	stmts       *stmtCache
+	leaderboard *leaderboardCache
+}
+
+// NewLedgerService creates a new ledger service
+func NewLedgerService(db *database.DB) *LedgerService {
+	return &LedgerService{db: db.DB, stmts: newStmtCache(db.DB), leaderboard: leaderboardFor(db)}
+}
+
+// Post applies amount to the user's balance and appends the matching ledger
+// entry, along with the opposite entry on the type's counter account. It
+// must run inside tx so the balance and the entries never diverge.
+// Debits that would take the balance below zero are rejected. Zero amounts
+// are not recorded and return a nil entry.
+func (l *LedgerService) Post(tx *sql.Tx, userID int, txType models.TransactionType, amount int, reference, reason string) (*models.CoinTransaction, error) {
+	if amount == 0 {
+		return nil, nil
+	}
+	exec := l.stmts.on(tx)
+
+	updateQuery := `UPDATE users
+	                SET total_coins = total_coins + ?, updated_at = CURRENT_TIMESTAMP
+	                WHERE id = ? AND total_coins + ? >= 0`
+	result, err := exec.Exec(updateQuery, amount, userID, amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update balance: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check balance update: %v", err)
+	}
+	if rowsAffected == 0 {
+		var exists int
+		if err := exec.QueryRow(`SELECT COUNT(*) FROM users WHERE id = ?`, userID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("failed to check user: %v", err)
+		}
+		if exists == 0 {
+			return nil, fmt.Errorf("user with ID %d not found", userID)
+		}
+		return nil, fmt.Errorf("insufficient coins for %s of %d", txType, -amount)
+	}
+
+	var balanceAfter int
+	if err := exec.QueryRow(`SELECT total_coins FROM users WHERE id = ?`, userID).Scan(&balanceAfter); err != nil {
+		return nil, fmt.Errorf("failed to read new balance: %v", err)
+	}
+
+	entry := &models.CoinTransaction{
+		UserID:         userID,
+		Type:           txType,
+		Amount:         amount,
+		BalanceAfter:   balanceAfter,
+		CounterAccount: txType.CounterAccount(),
+		Reference:      reference,
+		Reason:         reason,
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	insertQuery := `
+		INSERT INTO coin_transactions (user_id, type, amount, balance_after, counter_account, reference, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`
+	res, err := exec.Exec(insertQuery, userID, string(txType), amount, balanceAfter, entry.CounterAccount, reference, reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert ledger entry: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger entry ID: %v", err)
+	}
+	entry.ID = int(id)
+
+	systemQuery := `INSERT INTO system_ledger_entries (transaction_id, account, amount, created_at)
+	                VALUES (?, ?, ?, CURRENT_TIMESTAMP)`
+	if _, err := exec.Exec(systemQuery, entry.ID, entry.CounterAccount, -amount); err != nil {
+		return nil, fmt.Errorf("failed to insert system ledger entry: %v", err)
+	}
+	l.leaderboard.touch(tx, userID)
+
+	return entry, nil
+}
+
+// Record posts a single entry in its own transaction
+func (l *LedgerService) Record(userID int, txType models.TransactionType, amount int, reference, reason string) (*models.CoinTransaction, error) {
+	var entry *models.CoinTransaction
+	err := runInTx(l.db, func(tx *sql.Tx) error {
+		var err error
+		entry, err = l.Post(tx, userID, txType, amount, reference, reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// GetUserTransactions returns a page of a user's ledger entries, newest first,
+// along with the total number of entries
+func (l *LedgerService) GetUserTransactions(userID, limit, offset int) ([]models.CoinTransaction, int, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var total int
+	if err := l.db.QueryRow(`SELECT COUNT(*) FROM coin_transactions WHERE user_id = ?`, userID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count transactions: %v", err)
+	}
+
+	query := `
+		SELECT id, user_id, type, amount, balance_after, counter_account, reference, reason, created_at
+		FROM coin_transactions
+		WHERE user_id = ?
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?
+	`
+	rows, err := l.db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query transactions: %v", err)
+	}
+	defer rows.Close()
+
+	transactions := []models.CoinTransaction{}
+	for rows.Next() {
+		var t models.CoinTransaction
+		var txType string
+		if err := rows.Scan(&t.ID, &t.UserID, &txType, &t.Amount, &t.BalanceAfter, &t.CounterAccount, &t.Reference, &t.Reason, &t.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan transaction row: %v", err)
+		}
+		t.Type = models.TransactionType(txType)
+		transactions = append(transactions, t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating transaction rows: %v", err)
+	}
+
+	return transactions, total, nil
+}
+
+// Reconcile compares every user's stored total_coins with the sum of their
+// ledger entries and returns the users that have drifted
+func (l *LedgerService) Reconcile() ([]models.LedgerDrift, error) {
+	query := `
+		SELECT u.id, u.username, u.total_coins, COALESCE(SUM(t.amount), 0) AS ledger_balance
+		FROM users u
+		LEFT JOIN coin_transactions t ON t.user_id = u.id
+		GROUP BY u.id
+		HAVING u.total_coins != ledger_balance
+		ORDER BY u.id
+	`
+	rows, err := l.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile ledger: %v", err)
+	}
+	defer rows.Close()
+
+	drifts := []models.LedgerDrift{}
+	for rows.Next() {
+		var d models.LedgerDrift
+		if err := rows.Scan(&d.UserID, &d.Username, &d.StoredBalance, &d.LedgerBalance); err != nil {
+			return nil, fmt.Errorf("failed to scan reconciliation row: %v", err)
+		}
+		d.Drift = d.StoredBalance - d.LedgerBalance
+		drifts = append(drifts, d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reconciliation rows: %v", err)
+	}
+
+	return drifts, nil
+}
+
+// UnbalancedTransactions returns the ledger transactions whose user entry and
+// system entries do not sum to zero
+func (l *LedgerService) UnbalancedTransactions() ([]models.UnbalancedTransaction, error) {
+	query := `
+		SELECT t.id, t.user_id, t.type, t.amount, COALESCE(SUM(s.amount), 0) AS system_amount
+		FROM coin_transactions t
+		LEFT JOIN system_ledger_entries s ON s.transaction_id = t.id
+		GROUP BY t.id
+		HAVING t.amount + system_amount != 0
+		ORDER BY t.id
+	`
+	rows, err := l.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check ledger balance: %v", err)
+	}
+	defer rows.Close()
+
+	unbalanced := []models.UnbalancedTransaction{}
+	for rows.Next() {
+		var u models.UnbalancedTransaction
+		var txType string
+		if err := rows.Scan(&u.TransactionID, &u.UserID, &txType, &u.Amount, &u.SystemAmount); err != nil {
+			return nil, fmt.Errorf("failed to scan unbalanced transaction: %v", err)
+		}
+		u.Type = models.TransactionType(txType)
+		u.Imbalance = u.Amount + u.SystemAmount
+		unbalanced = append(unbalanced, u)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating unbalanced transactions: %v", err)
+	}
+
+	return unbalanced, nil
+}
+
+// RunReconciliation reconciles the ledger every interval and logs any drift
+// or transaction that does not sum to zero until stop is closed
+func (l *LedgerService) RunReconciliation(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			drifts, err := l.Reconcile()
+			if err != nil {
+				log.Printf("Ledger reconciliation failed: %v", err)
+				continue
+			}
+			for _, d := range drifts {
+				log.Printf("Ledger drift for user %s (ID %d): stored=%d ledger=%d drift=%d",
+					d.Username, d.UserID, d.StoredBalance, d.LedgerBalance, d.Drift)
+			}
+			unbalanced, err := l.UnbalancedTransactions()
+			if err != nil {
+				log.Printf("Ledger balance check failed: %v", err)
+				continue
+			}
+			for _, u := range unbalanced {
+				log.Printf("Unbalanced ledger transaction %d (%s for user ID %d): user=%d system=%d imbalance=%d",
+					u.TransactionID, u.Type, u.UserID, u.Amount, u.SystemAmount, u.Imbalance)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
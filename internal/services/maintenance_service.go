@@ -0,0 +1,513 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxSeedAttempts bounds the usernames Seed tries before giving up, in case
+// every name with the prefix is taken
+const maxSeedAttempts = 100000
+
+// MaintenanceService looks after the database itself rather than the game:
+// it checks and repairs the counters stored on users, prunes old games into
+// an archive, compacts the file and seeds fake data for local testing.
+type MaintenanceService struct {
+	db          *sql.DB
+	userService *UserService
+	ledger      *LedgerService
+	streaks     *StreakService
+	admin       *AdminService
+	now         func() time.Time
+}
+
+// NewMaintenanceService creates a new maintenance service
+func NewMaintenanceService(db *database.DB) *MaintenanceService {
+	return &MaintenanceService{
+		db:          db.DB,
+		userService: NewUserService(db),
+		ledger:      NewLedgerService(db),
+		streaks:     NewStreakService(db),
+		admin:       NewAdminService(db),
+		now:         time.Now,
+	}
+}
+
+// aggregateTally replays a user's games against the computer into the
+// counters stored on their row, the same way PlayGame updates them
+type aggregateTally struct {
+	models.UserAggregates
+	reset *time.Time // an admin streak reset the replay has not reached yet
+}
+
+// play adds a game to the tally
+func (t *aggregateTally) play(result models.GameResult, playedAt time.Time) {
+	t.resetBefore(playedAt)
+	t.GamesPlayed++
+	switch result {
+	case models.Win:
+		t.GamesWon++
+		t.CurrentStreak++
+	case models.Lose:
+		t.CurrentStreak = 0
+	}
+}
+
+// resetBefore applies a pending streak reset made before at
+func (t *aggregateTally) resetBefore(at time.Time) {
+	if t.reset != nil && t.reset.Before(at) {
+		t.CurrentStreak = 0
+		t.reset = nil
+	}
+}
+
+// startTallies returns every user's tally as of the games archived so far,
+// with the latest admin streak reset still to apply if it came after them
+func (m *MaintenanceService) startTallies(exec dbExecutor) (map[int]*aggregateTally, error) {
+	tallies := map[int]*aggregateTally{}
+	archivedThrough := map[int]time.Time{}
+
+	rows, err := exec.Query(`SELECT user_id, games_played, games_won, current_streak, archived_through FROM game_archive_totals`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query archive totals: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID int
+		var through time.Time
+		t := &aggregateTally{}
+		if err := rows.Scan(&userID, &t.GamesPlayed, &t.GamesWon, &t.CurrentStreak, &through); err != nil {
+			return nil, fmt.Errorf("failed to scan archive totals: %v", err)
+		}
+		tallies[userID] = t
+		archivedThrough[userID] = through
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating archive totals: %v", err)
+	}
+	rows.Close()
+
+	// a reset wipes out every streak before it, so only the latest matters
+	resetQuery := `SELECT user_id, created_at FROM admin_actions
+	               WHERE action = 'reset_streak' AND user_id IS NOT NULL
+	               ORDER BY id`
+	resetRows, err := exec.Query(resetQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query streak resets: %v", err)
+	}
+	defer resetRows.Close()
+	latestReset := map[int]time.Time{}
+	for resetRows.Next() {
+		var userID int
+		var at time.Time
+		if err := resetRows.Scan(&userID, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan streak reset: %v", err)
+		}
+		latestReset[userID] = at
+	}
+	if err := resetRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating streak resets: %v", err)
+	}
+
+	for userID, at := range latestReset {
+		if through, ok := archivedThrough[userID]; ok && at.Before(through) {
+			continue // already replayed into the archive totals
+		}
+		at := at
+		tallyFor(tallies, userID).reset = &at
+	}
+	return tallies, nil
+}
+
+// tallyFor returns a user's tally, starting one if they have none yet
+func tallyFor(tallies map[int]*aggregateTally, userID int) *aggregateTally {
+	t, ok := tallies[userID]
+	if !ok {
+		t = &aggregateTally{}
+		tallies[userID] = t
+	}
+	return t
+}
+
+// replayGames plays the games against the computer matching where into the
+// tallies, in the order they were played, and returns the users it touched
+func replayGames(exec dbExecutor, tallies map[int]*aggregateTally, where string, args ...interface{}) (map[int]bool, error) {
+	query := `SELECT user_id, result, played_at FROM games
+	          WHERE opponent_user_id IS NULL AND ` + where + `
+	          ORDER BY user_id, played_at, id`
+	rows, err := exec.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query games: %v", err)
+	}
+	defer rows.Close()
+
+	touched := map[int]bool{}
+	for rows.Next() {
+		var userID int
+		var result string
+		var playedAt time.Time
+		if err := rows.Scan(&userID, &result, &playedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan game row: %v", err)
+		}
+		tallyFor(tallies, userID).play(models.GameResult(result), playedAt)
+		touched[userID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating game rows: %v", err)
+	}
+	return touched, nil
+}
+
+// CheckAggregates recomputes every user's games_played, games_won and
+// current_streak from their games against the computer, together with the
+// archive totals of pruned ones, and total_coins from the coin ledger, and
+// returns the users whose stored counters differ. Balances are checked
+// against the ledger rather than the games because purchases, bonuses and
+// adjustments move coins too; game rewards are ledger entries like any other.
+func (m *MaintenanceService) CheckAggregates() ([]models.AggregateDrift, error) {
+	return m.findDrift(m.db)
+}
+
+// findDrift compares the stored counters with the recomputed ones
+func (m *MaintenanceService) findDrift(exec dbExecutor) ([]models.AggregateDrift, error) {
+	tallies, err := m.startTallies(exec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := replayGames(exec, tallies, "1 = 1"); err != nil {
+		return nil, err
+	}
+
+	balanceRows, err := exec.Query(`SELECT user_id, SUM(amount) FROM coin_transactions GROUP BY user_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ledger balances: %v", err)
+	}
+	defer balanceRows.Close()
+	balances := map[int]int{}
+	for balanceRows.Next() {
+		var userID, balance int
+		if err := balanceRows.Scan(&userID, &balance); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger balance: %v", err)
+		}
+		balances[userID] = balance
+	}
+	if err := balanceRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ledger balances: %v", err)
+	}
+	balanceRows.Close()
+
+	rows, err := exec.Query(`SELECT id, username, games_played, games_won, total_coins, current_streak FROM users ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %v", err)
+	}
+	defer rows.Close()
+
+	drifts := []models.AggregateDrift{}
+	for rows.Next() {
+		var d models.AggregateDrift
+		if err := rows.Scan(&d.UserID, &d.Username, &d.Stored.GamesPlayed, &d.Stored.GamesWon, &d.Stored.TotalCoins, &d.Stored.CurrentStreak); err != nil {
+			return nil, fmt.Errorf("failed to scan user row: %v", err)
+		}
+		t := tallyFor(tallies, d.UserID)
+		if t.reset != nil {
+			t.CurrentStreak = 0 // reset after their last game
+		}
+		d.Expected = t.UserAggregates
+		d.Expected.TotalCoins = balances[d.UserID]
+		if d.Stored != d.Expected {
+			drifts = append(drifts, d)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user rows: %v", err)
+	}
+
+	return drifts, nil
+}
+
+// RepairAggregates overwrites the drifted counters CheckAggregates finds with
+// the recomputed ones, recording each repair in the admin audit log, and
+// returns what was repaired
+func (m *MaintenanceService) RepairAggregates(actor string) ([]models.AggregateDrift, error) {
+	var drifts []models.AggregateDrift
+	err := runInTx(m.db, func(tx *sql.Tx) error {
+		var err error
+		if drifts, err = m.findDrift(tx); err != nil {
+			return err
+		}
+
+		// the ledger is the record of every balance change, so the balance
+		// is brought back in line with it rather than posting a correction
+		updateQuery := `UPDATE users
+		                SET games_played = ?, games_won = ?, total_coins = ?, current_streak = ?, updated_at = CURRENT_TIMESTAMP
+		                WHERE id = ?`
+		for _, d := range drifts {
+			e := d.Expected
+			if _, err := tx.Exec(updateQuery, e.GamesPlayed, e.GamesWon, e.TotalCoins, e.CurrentStreak, d.UserID); err != nil {
+				return fmt.Errorf("failed to repair user %s: %v", d.Username, err)
+			}
+			m.userService.leaderboard.touch(tx, d.UserID)
+			user := &models.User{ID: d.UserID, Username: d.Username}
+			if err := m.admin.audit(tx, actor, "repair_aggregates", user, describeDrift(d)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return drifts, nil
+}
+
+// describeDrift lists the counters that differ, as stored -> expected
+func describeDrift(d models.AggregateDrift) string {
+	var changes []string
+	for _, c := range []struct {
+		name             string
+		stored, expected int
+	}{
+		{"games_played", d.Stored.GamesPlayed, d.Expected.GamesPlayed},
+		{"games_won", d.Stored.GamesWon, d.Expected.GamesWon},
+		{"total_coins", d.Stored.TotalCoins, d.Expected.TotalCoins},
+		{"current_streak", d.Stored.CurrentStreak, d.Expected.CurrentStreak},
+	} {
+		if c.stored != c.expected {
+			changes = append(changes, fmt.Sprintf("%s %d -> %d", c.name, c.stored, c.expected))
+		}
+	}
+	return strings.Join(changes, ", ")
+}
+
+// PruneGames deletes every game against the computer played before the
+// cutoff, after writing each one to archive as a line of JSON. Games between
+// two players are kept: the archive totals only replay games against the
+// computer, and head-to-head records are read from the rest. archive is
+// closed before anything is deleted, so a failed write leaves the games in
+// place, and closed on every error too. The counters of the players whose
+// games went are folded into their archive totals so CheckAggregates still
+// adds up.
+func (m *MaintenanceService) PruneGames(before time.Time, archive io.WriteCloser) (*models.PruneResult, error) {
+	if before.After(m.now()) {
+		archive.Close()
+		return nil, fmt.Errorf("invalid cutoff: %s is in the future", before.UTC().Format(time.RFC3339))
+	}
+	cutoff := before.UTC().Format(sqliteTimeFormat)
+	result := &models.PruneResult{Before: before.UTC()}
+
+	// deleting games clears the references streaks and achievements keep
+	// to them through their foreign keys
+	closed := false
+	err := runInTx(m.db, func(tx *sql.Tx) error {
+		games, err := writeGameArchive(tx, archive, cutoff)
+		closed = true
+		if closeErr := archive.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to write archive: %v", closeErr)
+		}
+		if err != nil {
+			return err
+		}
+		result.Games = games
+
+		tallies, err := m.startTallies(tx)
+		if err != nil {
+			return err
+		}
+		touched, err := replayGames(tx, tallies, "played_at < ?", cutoff)
+		if err != nil {
+			return err
+		}
+		upsertQuery := `INSERT INTO game_archive_totals (user_id, games_played, games_won, current_streak, archived_through)
+		                VALUES (?, ?, ?, ?, ?)
+		                ON CONFLICT (user_id) DO UPDATE SET
+		                    games_played = excluded.games_played,
+		                    games_won = excluded.games_won,
+		                    current_streak = excluded.current_streak,
+		                    archived_through = excluded.archived_through`
+		for userID := range touched {
+			t := tallies[userID]
+			t.resetBefore(before)
+			if _, err := tx.Exec(upsertQuery, userID, t.GamesPlayed, t.GamesWon, t.CurrentStreak, cutoff); err != nil {
+				return fmt.Errorf("failed to update archive totals: %v", err)
+			}
+		}
+		result.Users = len(touched)
+
+		if _, err := tx.Exec(`DELETE FROM games WHERE opponent_user_id IS NULL AND played_at < ?`, cutoff); err != nil {
+			return fmt.Errorf("failed to delete games: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if !closed {
+			archive.Close()
+		}
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// writeGameArchive writes the games against the computer played before
+// cutoff to w, one JSON object per line, and returns how many there were
+func writeGameArchive(exec dbExecutor, w io.Writer, cutoff string) (int, error) {
+	query := `SELECT id, user_id, player_choice, computer_choice, result, coins_earned, streak_multiplier, opponent_user_id, played_at
+	          FROM games
+	          WHERE opponent_user_id IS NULL AND played_at < ?
+	          ORDER BY id`
+	rows, err := exec.Query(query, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query games: %v", err)
+	}
+	defer rows.Close()
+
+	encoder := json.NewEncoder(w)
+	count := 0
+	for rows.Next() {
+		var game models.Game
+		var playerChoice, computerChoice, result string
+		var opponentUserID sql.NullInt64
+		err := rows.Scan(&game.ID, &game.UserID, &playerChoice, &computerChoice, &result,
+			&game.CoinsEarned, &game.StreakMultiplier, &opponentUserID, &game.PlayedAt)
+		if err != nil {
+			return 0, fmt.Errorf("failed to scan game row: %v", err)
+		}
+		if opponentUserID.Valid {
+			id := int(opponentUserID.Int64)
+			game.OpponentUserID = &id
+		}
+		game.PlayerChoice = models.Choice(playerChoice)
+		game.ComputerChoice = models.Choice(computerChoice)
+		game.Result = models.GameResult(result)
+
+		if err := encoder.Encode(game); err != nil {
+			return 0, fmt.Errorf("failed to write archive: %v", err)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating game rows: %v", err)
+	}
+	return count, nil
+}
+
+// databaseSize returns the size of the database file in bytes
+func (m *MaintenanceService) databaseSize() (int64, error) {
+	var size int64
+	if err := m.db.QueryRow(`SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()`).Scan(&size); err != nil {
+		return 0, fmt.Errorf("failed to get database size: %v", err)
+	}
+	return size, nil
+}
+
+// Vacuum rebuilds the database file without the free pages deleted rows
+// leave behind, and returns its size before and after
+func (m *MaintenanceService) Vacuum() (*models.DatabaseSize, error) {
+	var size models.DatabaseSize
+	var err error
+	if size.Before, err = m.databaseSize(); err != nil {
+		return nil, err
+	}
+	if _, err := m.db.Exec(`VACUUM`); err != nil {
+		return nil, fmt.Errorf("failed to vacuum database: %v", err)
+	}
+	if size.After, err = m.databaseSize(); err != nil {
+		return nil, err
+	}
+	return &size, nil
+}
+
+// Analyze refreshes the statistics the query planner picks indexes by
+func (m *MaintenanceService) Analyze() error {
+	if _, err := m.db.Exec(`ANALYZE`); err != nil {
+		return fmt.Errorf("failed to analyze database: %v", err)
+	}
+	return nil
+}
+
+// Seed creates fake players with a history of games against the computer,
+// spread over the past days, for trying the game out locally. Their games
+// are scored and paid through the ledger like real ones, so CheckAggregates
+// and the ledger reconciliation find nothing wrong with them.
+func (m *MaintenanceService) Seed(opts models.SeedOptions) (*models.SeedResult, error) {
+	if opts.Users < 1 || opts.GamesPerUser < 0 || opts.Days < 1 {
+		return nil, fmt.Errorf("invalid seed options: need at least one user and one day, and no negative game count")
+	}
+
+	random := rand.New(rand.NewSource(opts.Seed))
+	logic := NewSeededGameLogicService(opts.Seed)
+	result := &models.SeedResult{Users: []string{}}
+
+	for i := 1; len(result.Users) < opts.Users; i++ {
+		if i > maxSeedAttempts {
+			return nil, fmt.Errorf("failed to find free usernames starting with '%s'", opts.Prefix)
+		}
+		user, err := m.userService.CreateUser(fmt.Sprintf("%s%d", opts.Prefix, i))
+		if err != nil {
+			if strings.Contains(err.Error(), "already exists") || strings.Contains(err.Error(), "too similar") {
+				continue
+			}
+			return nil, err
+		}
+		if err := m.seedGames(user, opts, random, logic); err != nil {
+			return nil, err
+		}
+		result.Users = append(result.Users, user.Username)
+		result.Games += opts.GamesPerUser
+	}
+
+	// fill in the streak history of the new players
+	if _, err := m.streaks.Backfill(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// seedGames plays a seeded player's games at random times over the window
+func (m *MaintenanceService) seedGames(user *models.User, opts models.SeedOptions, random *rand.Rand, logic *GameLogicService) error {
+	now := m.now().UTC()
+	window := time.Duration(opts.Days) * 24 * time.Hour
+	playedAt := make([]time.Time, opts.GamesPerUser)
+	for i := range playedAt {
+		playedAt[i] = now.Add(-time.Duration(random.Int63n(int64(window))))
+	}
+	sort.Slice(playedAt, func(i, j int) bool { return playedAt[i].Before(playedAt[j]) })
+
+	insertQuery := `INSERT INTO games (user_id, player_choice, computer_choice, result, coins_earned, streak_multiplier, played_at)
+	                VALUES (?, ?, ?, ?, ?, ?, ?)`
+	return runInTx(m.db, func(tx *sql.Tx) error {
+		streak, won := 0, 0
+		for _, at := range playedAt {
+			playerChoice := logic.GenerateComputerChoice()
+			computerChoice := logic.GenerateComputerChoice()
+			result := logic.DetermineWinner(playerChoice, computerChoice)
+			coins := logic.CalculateCoinsEarned(result, streak)
+			multiplier := logic.CalculateStreakMultiplier(streak)
+			streak = logic.CalculateNewStreak(streak, result)
+			if result == models.Win {
+				won++
+			}
+
+			res, err := tx.Exec(insertQuery, user.ID, string(playerChoice), string(computerChoice), string(result), coins, multiplier, at.Format(sqliteTimeFormat))
+			if err != nil {
+				return fmt.Errorf("failed to insert game record: %v", err)
+			}
+			gameID, err := res.LastInsertId()
+			if err != nil {
+				return fmt.Errorf("failed to get game ID: %v", err)
+			}
+			if _, err := m.ledger.Post(tx, user.ID, models.TxGameReward, coins, fmt.Sprintf("game:%d", gameID), "seeded game"); err != nil {
+				return err
+			}
+		}
+		return m.userService.updateUserStats(tx, user.ID, streak, len(playedAt), won)
+	})
+}
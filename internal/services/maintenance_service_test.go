@@ -0,0 +1,248 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"rockpaperscissors/internal/models"
+)
+
+// nopWriteCloser collects an archive in memory
+type nopWriteCloser struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (w *nopWriteCloser) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestMaintenanceService_Aggregates(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	userService := NewUserService(db)
+	games := NewGameService(db)
+	admin := NewAdminService(db)
+	maintenance := NewMaintenanceService(db)
+
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := userService.CreateUser(name); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := games.PlayGame("alice", models.Rock); err != nil {
+			t.Fatalf("Failed to play game: %v", err)
+		}
+	}
+	if _, err := admin.AdjustCoins("moderator", "bob", 75, "refund"); err != nil {
+		t.Fatalf("Failed to adjust coins: %v", err)
+	}
+	if _, err := admin.ResetStreak("moderator", "alice", "exploit"); err != nil {
+		t.Fatalf("Failed to reset streak: %v", err)
+	}
+
+	drifts, err := maintenance.CheckAggregates()
+	if err != nil {
+		t.Fatalf("Failed to check aggregates: %v", err)
+	}
+	if len(drifts) != 0 {
+		t.Fatalf("Expected no drift after normal play, got %+v", drifts)
+	}
+
+	alice, _ := userService.GetUser("alice")
+	if _, err := db.Exec(`UPDATE users SET games_played = 99, total_coins = total_coins + 5, current_streak = 4 WHERE id = ?`, alice.ID); err != nil {
+		t.Fatalf("Failed to corrupt counters: %v", err)
+	}
+
+	drifts, err = maintenance.CheckAggregates()
+	if err != nil {
+		t.Fatalf("Failed to check aggregates: %v", err)
+	}
+	if len(drifts) != 1 || drifts[0].Username != "alice" {
+		t.Fatalf("Expected alice to have drifted, got %+v", drifts)
+	}
+	want := models.UserAggregates{GamesPlayed: 10, GamesWon: alice.GamesWon, TotalCoins: alice.TotalCoins, CurrentStreak: 0}
+	if drifts[0].Expected != want {
+		t.Errorf("Expected %+v, got %+v", want, drifts[0].Expected)
+	}
+
+	repaired, err := maintenance.RepairAggregates("maintainer")
+	if err != nil {
+		t.Fatalf("Failed to repair aggregates: %v", err)
+	}
+	if len(repaired) != 1 {
+		t.Errorf("Expected 1 user repaired, got %d", len(repaired))
+	}
+	if drifts, _ := maintenance.CheckAggregates(); len(drifts) != 0 {
+		t.Errorf("Expected no drift after repair, got %+v", drifts)
+	}
+	if ledgerDrifts, _ := NewLedgerService(db).Reconcile(); len(ledgerDrifts) != 0 {
+		t.Errorf("Expected the balance to match the ledger after repair, got %+v", ledgerDrifts)
+	}
+
+	var details string
+	if err := db.QueryRow(`SELECT details FROM admin_actions WHERE action = 'repair_aggregates' AND actor = 'maintainer'`).Scan(&details); err != nil {
+		t.Fatalf("Expected the repair in the audit log: %v", err)
+	}
+	if !strings.Contains(details, "games_played 99 -> 10") {
+		t.Errorf("Expected the audit entry to describe the repair, got %q", details)
+	}
+}
+
+func TestMaintenanceService_PruneGames(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	userService := NewUserService(db)
+	maintenance := NewMaintenanceService(db)
+
+	alice, err := userService.CreateUser("alice")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	bob, err := userService.CreateUser("bob")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	// alice's streak of 3 runs across the cutoff; bob's old games against
+	// the computer are all pruned, and the match between them counts for
+	// neither and is kept
+	now := time.Now().UTC()
+	cutoff := now.Add(-5 * 24 * time.Hour)
+	day := func(n int) time.Time { return now.Add(time.Duration(-n) * 24 * time.Hour) }
+	insertClanTestGame(t, db, alice.ID, 0, models.Lose, 0, day(9))
+	insertClanTestGame(t, db, alice.ID, 0, models.Win, 10, day(8))
+	insertClanTestGame(t, db, alice.ID, 0, models.Tie, 0, day(7))
+	insertClanTestGame(t, db, alice.ID, 0, models.Win, 20, day(6))
+	insertClanTestGame(t, db, alice.ID, bob.ID, models.Lose, 0, day(6))
+	insertClanTestGame(t, db, alice.ID, 0, models.Win, 30, day(2))
+	insertClanTestGame(t, db, bob.ID, 0, models.Win, 10, day(8))
+	insertClanTestGame(t, db, bob.ID, 0, models.Win, 20, day(7))
+	insertClanTestGame(t, db, bob.ID, alice.ID, models.Win, 0, day(6))
+	if _, err := maintenance.RepairAggregates("setup"); err != nil {
+		t.Fatalf("Failed to set counters: %v", err)
+	}
+
+	archive := &nopWriteCloser{}
+	result, err := maintenance.PruneGames(cutoff, archive)
+	if err != nil {
+		t.Fatalf("Failed to prune games: %v", err)
+	}
+	if result.Games != 6 || result.Users != 2 {
+		t.Errorf("Expected 6 games of 2 users pruned, got %+v", result)
+	}
+	if !archive.closed {
+		t.Errorf("Expected the archive to be closed")
+	}
+
+	var archived []models.Game
+	decoder := json.NewDecoder(&archive.Buffer)
+	for {
+		var game models.Game
+		if err := decoder.Decode(&game); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Failed to decode archive: %v", err)
+		}
+		archived = append(archived, game)
+	}
+	if len(archived) != 6 {
+		t.Errorf("Expected the 6 pruned games in the archive, got %+v", archived)
+	}
+	for _, game := range archived {
+		if game.OpponentUserID != nil {
+			t.Errorf("Expected the match against bob to stay out of the archive, got %+v", game)
+		}
+	}
+
+	var remaining, matches int
+	db.QueryRow(`SELECT COUNT(*) FROM games`).Scan(&remaining)
+	db.QueryRow(`SELECT COUNT(*) FROM games WHERE opponent_user_id IS NOT NULL`).Scan(&matches)
+	if remaining != 3 || matches != 2 {
+		t.Errorf("Expected the match and 1 game against the computer left, got %d games with %d from the match", remaining, matches)
+	}
+
+	drifts, err := maintenance.CheckAggregates()
+	if err != nil {
+		t.Fatalf("Failed to check aggregates: %v", err)
+	}
+	if len(drifts) != 0 {
+		t.Errorf("Expected pruned games to still count, got drift %+v", drifts)
+	}
+	got, _ := userService.GetUser("alice")
+	if got.GamesPlayed != 5 || got.CurrentStreak != 3 {
+		t.Errorf("Expected alice at 5 games on a streak of 3, got %d and %d", got.GamesPlayed, got.CurrentStreak)
+	}
+
+	// a reset after the prune still ends the archived streak
+	if _, err := NewAdminService(db).ResetStreak("moderator", "bob", "exploit"); err != nil {
+		t.Fatalf("Failed to reset streak: %v", err)
+	}
+	if drifts, _ := maintenance.CheckAggregates(); len(drifts) != 0 {
+		t.Errorf("Expected no drift after a reset, got %+v", drifts)
+	}
+
+	if _, err := maintenance.PruneGames(now.Add(time.Hour), &nopWriteCloser{}); err == nil {
+		t.Error("Expected a cutoff in the future to be rejected")
+	}
+
+	// the archive is closed even when the transaction never starts
+	db.Close()
+	archive = &nopWriteCloser{}
+	if _, err := maintenance.PruneGames(cutoff, archive); err == nil {
+		t.Error("Expected pruning a closed database to fail")
+	}
+	if !archive.closed {
+		t.Errorf("Expected the archive to be closed after a failed prune")
+	}
+}
+
+func TestMaintenanceService_Seed(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	maintenance := NewMaintenanceService(db)
+	opts := models.SeedOptions{Users: 3, GamesPerUser: 25, Days: 10, Prefix: "bot", Seed: 7}
+
+	result, err := maintenance.Seed(opts)
+	if err != nil {
+		t.Fatalf("Failed to seed: %v", err)
+	}
+	if strings.Join(result.Users, ",") != "bot1,bot2,bot3" || result.Games != 75 {
+		t.Errorf("Expected bot1 to bot3 with 75 games, got %+v", result)
+	}
+
+	// names already taken are skipped
+	again, err := maintenance.Seed(models.SeedOptions{Users: 1, GamesPerUser: 0, Days: 1, Prefix: "bot", Seed: 7})
+	if err != nil {
+		t.Fatalf("Failed to seed again: %v", err)
+	}
+	if len(again.Users) != 1 || again.Users[0] != "bot4" {
+		t.Errorf("Expected bot4, got %+v", again.Users)
+	}
+
+	if drifts, _ := maintenance.CheckAggregates(); len(drifts) != 0 {
+		t.Errorf("Expected seeded counters to add up, got %+v", drifts)
+	}
+	if ledgerDrifts, _ := NewLedgerService(db).Reconcile(); len(ledgerDrifts) != 0 {
+		t.Errorf("Expected seeded balances to match the ledger, got %+v", ledgerDrifts)
+	}
+
+	var oldest time.Time
+	if err := db.QueryRow(`SELECT played_at FROM games ORDER BY played_at LIMIT 1`).Scan(&oldest); err == nil && time.Since(oldest) > 10*24*time.Hour {
+		t.Errorf("Expected games within the last 10 days, got one at %v", oldest)
+	}
+	var streaks int
+	db.QueryRow(`SELECT COUNT(*) FROM streaks`).Scan(&streaks)
+	if streaks == 0 {
+		t.Errorf("Expected the seeded players' streaks to be backfilled")
+	}
+}
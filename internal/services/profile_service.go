@@ -0,0 +1,288 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif" // register the decoders avatars are checked with
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	maxDisplayNameLength = 32
+	maxBioLength         = 160
+	maxAvatarURLLength   = 512
+
+	// MaxAvatarBytes is the largest avatar image that can be uploaded
+	MaxAvatarBytes    = 1 << 20
+	maxAvatarPixels   = 1024 // per side
+	avatarRandomBytes = 8
+
+	// AvatarURLPrefix is the path uploaded avatars are served under
+	AvatarURLPrefix  = "/avatars/"
+	defaultAvatarDir = "data/avatars"
+)
+
+// AvatarDir is where uploaded avatars are stored and served from: the
+// AVATAR_DIR environment variable, or data/avatars next to the database
+func AvatarDir() string {
+	if dir := os.Getenv("AVATAR_DIR"); dir != "" {
+		return dir
+	}
+	return defaultAvatarDir
+}
+
+// ProfileService manages the profile fields players edit themselves
+type ProfileService struct {
+	db          *sql.DB
+	userService *UserService
+	avatarDir   string
+}
+
+// NewProfileService creates a new profile service
+func NewProfileService(db *database.DB) *ProfileService {
+	return &ProfileService{
+		db:          db.DB,
+		userService: NewUserService(db),
+		avatarDir:   AvatarDir(),
+	}
+}
+
+// UpdateProfile applies the fields set in req and returns the updated user.
+// Setting an avatar URL replaces an uploaded avatar.
+func (p *ProfileService) UpdateProfile(username string, req models.UpdateProfileRequest) (*models.User, error) {
+	user, err := p.userService.GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := LoadUsernamePolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	var sets []string
+	var args []interface{}
+	set := func(column string, value interface{}) {
+		sets = append(sets, column+" = ?")
+		args = append(args, value)
+	}
+
+	if req.DisplayName != nil {
+		displayName, err := cleanProfileText("display name", *req.DisplayName, maxDisplayNameLength, false)
+		if err != nil {
+			return nil, err
+		}
+		if policy.BlocksText(displayName) {
+			return nil, fmt.Errorf("invalid display name: '%s' is reserved or not allowed", displayName)
+		}
+		set("display_name", displayName)
+	}
+	if req.AvatarURL != nil {
+		avatarURL := strings.TrimSpace(*req.AvatarURL)
+		if err := validateAvatarURL(avatarURL); err != nil {
+			return nil, err
+		}
+		set("avatar_url", avatarURL)
+	}
+	if req.Country != nil {
+		country := ""
+		if strings.TrimSpace(*req.Country) != "" {
+			if country, err = normalizeCountry(*req.Country); err != nil {
+				return nil, err
+			}
+		}
+		set("country", country)
+	}
+	if req.Bio != nil {
+		bio, err := cleanProfileText("bio", *req.Bio, maxBioLength, true)
+		if err != nil {
+			return nil, err
+		}
+		if policy.BlocksText(bio) {
+			return nil, fmt.Errorf("invalid bio: contains words that are not allowed")
+		}
+		set("bio", bio)
+	}
+	if req.Timezone != nil {
+		if err := validateTimezone(*req.Timezone); err != nil {
+			return nil, err
+		}
+		changed, err := p.userService.checkTimezoneChange(p.db, user.ID, *req.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		// the current timezone again is accepted and changes nothing
+		set("timezone", *req.Timezone)
+		if changed {
+			sets = append(sets, "timezone_changed_at = CURRENT_TIMESTAMP")
+		}
+	}
+	if len(sets) == 0 {
+		return nil, fmt.Errorf("invalid profile update: no fields to change")
+	}
+
+	updateQuery := `UPDATE users SET ` + strings.Join(sets, ", ") + `, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	if _, err := p.db.Exec(updateQuery, append(args, user.ID)...); err != nil {
+		return nil, fmt.Errorf("failed to update profile: %v", err)
+	}
+	if req.AvatarURL != nil {
+		p.removeAvatarFile(user.Profile.AvatarURL)
+	}
+
+	return p.userService.GetUser(username)
+}
+
+// SetAvatar stores an uploaded PNG, JPEG or GIF of at most MaxAvatarBytes
+// and 1024 pixels a side as the user's avatar, replacing any earlier one
+func (p *ProfileService) SetAvatar(username string, upload io.Reader) (*models.User, error) {
+	user, err := p.userService.GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(upload, MaxAvatarBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read avatar: %v", err)
+	}
+	if len(data) > MaxAvatarBytes {
+		return nil, fmt.Errorf("invalid avatar: larger than %d KB", MaxAvatarBytes/1024)
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid avatar: not a PNG, JPEG or GIF image")
+	}
+	if config.Width > maxAvatarPixels || config.Height > maxAvatarPixels {
+		return nil, fmt.Errorf("invalid avatar: larger than %dx%d pixels", maxAvatarPixels, maxAvatarPixels)
+	}
+
+	// a fresh name per upload so caches never serve the old picture
+	suffix := make([]byte, avatarRandomBytes)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, fmt.Errorf("failed to name avatar: %v", err)
+	}
+	extension := map[string]string{"png": "png", "jpeg": "jpg", "gif": "gif"}[format]
+	name := fmt.Sprintf("%d-%s.%s", user.ID, hex.EncodeToString(suffix), extension)
+	if err := p.writeAvatarFile(name, data); err != nil {
+		return nil, err
+	}
+
+	avatarURL := AvatarURLPrefix + name
+	_, err = p.db.Exec(`UPDATE users SET avatar_url = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, avatarURL, user.ID)
+	if err != nil {
+		p.removeAvatarFile(avatarURL)
+		return nil, fmt.Errorf("failed to update avatar: %v", err)
+	}
+	p.removeAvatarFile(user.Profile.AvatarURL)
+
+	return p.userService.GetUser(username)
+}
+
+// writeAvatarFile writes an avatar through a temporary file so a partly
+// written image is never served
+func (p *ProfileService) writeAvatarFile(name string, data []byte) error {
+	if err := os.MkdirAll(p.avatarDir, 0755); err != nil {
+		return fmt.Errorf("failed to create avatar directory: %v", err)
+	}
+	tmp, err := os.CreateTemp(p.avatarDir, ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to store avatar: %v", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to store avatar: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to store avatar: %v", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to store avatar: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(p.avatarDir, name)); err != nil {
+		return fmt.Errorf("failed to store avatar: %v", err)
+	}
+	return nil
+}
+
+// removeAvatarFile deletes an uploaded avatar that is no longer used.
+// External avatar URLs are left alone.
+func (p *ProfileService) removeAvatarFile(avatarURL string) {
+	if !strings.HasPrefix(avatarURL, AvatarURLPrefix) {
+		return
+	}
+	path := filepath.Join(p.avatarDir, filepath.Base(strings.TrimPrefix(avatarURL, AvatarURLPrefix)))
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove old avatar %s: %v", path, err)
+	}
+}
+
+// cleanProfileText normalizes free text and rejects control and invisible
+// formatting characters, which can hide or reorder what others see.
+// Newlines are kept when multiline is set.
+func cleanProfileText(field, text string, maxLength int, multiline bool) (string, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimSpace(norm.NFKC.String(text))
+
+	for _, r := range text {
+		if r == '\n' && multiline {
+			continue
+		}
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+			return "", fmt.Errorf("invalid %s: contains control or formatting characters", field)
+		}
+	}
+	if utf8.RuneCountInString(text) > maxLength {
+		return "", fmt.Errorf("invalid %s: must be at most %d characters", field, maxLength)
+	}
+	return text, nil
+}
+
+// validateAvatarURL accepts an empty URL, which clears the avatar, or an
+// absolute http(s) URL
+func validateAvatarURL(avatarURL string) error {
+	if avatarURL == "" {
+		return nil
+	}
+	if len(avatarURL) > maxAvatarURLLength {
+		return fmt.Errorf("invalid avatar URL: must be at most %d characters", maxAvatarURLLength)
+	}
+	parsed, err := url.Parse(avatarURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" || parsed.User != nil {
+		return fmt.Errorf("invalid avatar URL: must be an http or https URL")
+	}
+	return nil
+}
+
+// normalizeCountry returns the upper-case ISO 3166-1 alpha-2 code for a
+// country, rejecting continents, private-use codes and anything else.
+// Deprecated codes give their replacement, so UK becomes GB.
+func normalizeCountry(country string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(country))
+	invalid := fmt.Errorf("invalid country '%s': use an ISO 3166-1 alpha-2 code such as GB", country)
+	if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+		return "", invalid
+	}
+	region, err := language.ParseRegion(code)
+	if err != nil || !region.IsCountry() || region.IsPrivateUse() {
+		return "", invalid
+	}
+	return region.Canonicalize().String(), nil
+}
@@ -0,0 +1,409 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"strconv"
+	"time"
+)
+
+// SeasonCalendar defines when seasons start and end. Season 1 starts at
+// Start and every season lasts Length.
+type SeasonCalendar struct {
+	Start  time.Time
+	Length time.Duration
+}
+
+// defaultSeasonStart anchors the calendar when SEASON_START is not set
+var defaultSeasonStart = time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+
+// defaultSeasonLengthDays is used when SEASON_LENGTH_DAYS is not set
+const defaultSeasonLengthDays = 28
+
+// seasonRewards are paid to the top finishers of a season, by rank
+var seasonRewards = []struct {
+	maxRank int
+	badge   string
+	coins   int
+}{
+	{1, "gold", 500},
+	{2, "silver", 300},
+	{3, "bronze", 200},
+	{10, "top10", 100},
+}
+
+// LoadSeasonCalendar reads the season calendar from SEASON_START (YYYY-MM-DD,
+// UTC) and SEASON_LENGTH_DAYS
+func LoadSeasonCalendar() (SeasonCalendar, error) {
+	calendar := SeasonCalendar{
+		Start:  defaultSeasonStart,
+		Length: defaultSeasonLengthDays * 24 * time.Hour,
+	}
+
+	if raw := os.Getenv("SEASON_START"); raw != "" {
+		start, err := time.Parse(dayFormat, raw)
+		if err != nil {
+			return calendar, fmt.Errorf("invalid SEASON_START %q: %v", raw, err)
+		}
+		calendar.Start = start
+	}
+	if raw := os.Getenv("SEASON_LENGTH_DAYS"); raw != "" {
+		days, err := strconv.Atoi(raw)
+		if err != nil || days <= 0 {
+			return calendar, fmt.Errorf("invalid SEASON_LENGTH_DAYS %q", raw)
+		}
+		calendar.Length = time.Duration(days) * 24 * time.Hour
+	}
+
+	return calendar, nil
+}
+
+// SeasonAt returns the season that contains t. Times before the calendar
+// start belong to season 1.
+func (c SeasonCalendar) SeasonAt(t time.Time) models.Season {
+	number := 1
+	if t.After(c.Start) {
+		number = int(t.Sub(c.Start)/c.Length) + 1
+	}
+	start := c.Start.Add(time.Duration(number-1) * c.Length)
+	return models.Season{
+		ID:       number,
+		Name:     fmt.Sprintf("Season %d", number),
+		StartsAt: start,
+		EndsAt:   start.Add(c.Length),
+		Status:   models.SeasonActive,
+	}
+}
+
+// SeasonService handles season tallies, rollover and season leaderboards
+type SeasonService struct {
+	db        *sql.DB
+	stmts     *stmtCache
+	calendar  SeasonCalendar
+	gameLogic *GameLogicService
+	ledger    *LedgerService
+	now       func() time.Time
+}
+
+// NewSeasonService creates a new season service using the configured calendar.
+// An invalid calendar configuration falls back to the defaults; the server
+// validates it at startup with LoadSeasonCalendar.
+func NewSeasonService(db *database.DB) *SeasonService {
+	calendar, _ := LoadSeasonCalendar()
+	return &SeasonService{
+		db:        db.DB,
+		stmts:     newStmtCache(db.DB),
+		calendar:  calendar,
+		gameLogic: NewGameLogicService(),
+		ledger:    NewLedgerService(db),
+		now:       time.Now,
+	}
+}
+
+// ensureSeason makes sure the season row exists
+func (s *SeasonService) ensureSeason(exec dbExecutor, season models.Season) error {
+	query := `INSERT OR IGNORE INTO seasons (id, name, starts_at, ends_at, status)
+	          VALUES (?, ?, ?, ?, ?)`
+	_, err := exec.Exec(query, season.ID, season.Name,
+		season.StartsAt.UTC().Format(sqliteTimeFormat), season.EndsAt.UTC().Format(sqliteTimeFormat), string(season.Status))
+	if err != nil {
+		return fmt.Errorf("failed to create season: %v", err)
+	}
+	return nil
+}
+
+// CurrentSeason returns the season in progress
+func (s *SeasonService) CurrentSeason() (*models.Season, error) {
+	season := s.calendar.SeasonAt(s.now().UTC())
+	if err := s.ensureSeason(s.db, season); err != nil {
+		return nil, err
+	}
+	return s.GetSeason(season.ID)
+}
+
+// GetSeason looks up a season by ID
+func (s *SeasonService) GetSeason(id int) (*models.Season, error) {
+	var season models.Season
+	var status string
+	query := `SELECT id, name, starts_at, ends_at, status FROM seasons WHERE id = ?`
+	err := s.db.QueryRow(query, id).Scan(&season.ID, &season.Name, &season.StartsAt, &season.EndsAt, &status)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("season %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get season: %v", err)
+	}
+	season.Status = models.SeasonStatus(status)
+	return &season, nil
+}
+
+// ListSeasons returns every season that has started, newest first
+func (s *SeasonService) ListSeasons() ([]models.Season, error) {
+	if _, err := s.CurrentSeason(); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`SELECT id, name, starts_at, ends_at, status FROM seasons ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query seasons: %v", err)
+	}
+	defer rows.Close()
+
+	seasons := []models.Season{}
+	for rows.Next() {
+		var season models.Season
+		var status string
+		if err := rows.Scan(&season.ID, &season.Name, &season.StartsAt, &season.EndsAt, &status); err != nil {
+			return nil, fmt.Errorf("failed to scan season row: %v", err)
+		}
+		season.Status = models.SeasonStatus(status)
+		seasons = append(seasons, season)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating season rows: %v", err)
+	}
+
+	return seasons, nil
+}
+
+// RecordGame adds a settled game to the current season's tally for the
+// player. It runs inside the settlement transaction.
+func (s *SeasonService) RecordGame(tx *sql.Tx, game settledGame, coinsEarned int) error {
+	exec := s.stmts.on(tx)
+	season := s.calendar.SeasonAt(s.now().UTC())
+	if err := s.ensureSeason(exec, season); err != nil {
+		return err
+	}
+
+	rating := DefaultRating
+	err := exec.QueryRow(`SELECT rating FROM season_stats WHERE season_id = ? AND user_id = ?`, season.ID, game.User.ID).Scan(&rating)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get season rating: %v", err)
+	}
+	ratingChange := s.gameLogic.CalculateRatingChange(rating, DefaultRating, game.Result)
+
+	won := 0
+	if game.Result == models.Win {
+		won = 1
+	}
+
+	query := `
+		INSERT INTO season_stats (season_id, user_id, coins, games_played, games_won, rating, updated_at)
+		VALUES (?, ?, ?, 1, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (season_id, user_id) DO UPDATE SET
+			coins = coins + excluded.coins,
+			games_played = games_played + 1,
+			games_won = games_won + excluded.games_won,
+			rating = excluded.rating,
+			updated_at = CURRENT_TIMESTAMP
+	`
+	if _, err := exec.Exec(query, season.ID, game.User.ID, coinsEarned, won, rating+ratingChange); err != nil {
+		return fmt.Errorf("failed to update season stats: %v", err)
+	}
+	return nil
+}
+
+// GetLeaderboard returns the standings of a season: live tallies for the
+// current season and archived results for finished ones
+func (s *SeasonService) GetLeaderboard(seasonID, limit int) ([]models.SeasonStanding, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	season, err := s.GetSeason(seasonID)
+	if err != nil {
+		return nil, err
+	}
+
+	var query string
+	if season.Status == models.SeasonArchived {
+		query = `SELECT r.rank, u.username, r.coins, r.games_played, r.games_won, r.rating, r.badge, r.reward_coins
+		         FROM season_results r
+		         JOIN users u ON u.id = r.user_id
+		         WHERE r.season_id = ? AND ` + rankedUsersFilter + `
+		         ORDER BY r.rank
+		         LIMIT ?`
+	} else {
+		query = `SELECT 0, u.username, st.coins, st.games_played, st.games_won, st.rating, '', 0
+		         FROM season_stats st
+		         JOIN users u ON u.id = st.user_id
+		         WHERE st.season_id = ? AND ` + rankedUsersFilter + `
+		         ORDER BY st.coins DESC, st.games_won DESC, st.rating DESC
+		         LIMIT ?`
+	}
+
+	rows, err := s.db.Query(query, season.ID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query season leaderboard: %v", err)
+	}
+	defer rows.Close()
+
+	standings := []models.SeasonStanding{}
+	for rows.Next() {
+		var st models.SeasonStanding
+		if err := rows.Scan(&st.Rank, &st.Username, &st.Coins, &st.GamesPlayed, &st.GamesWon, &st.Rating, &st.Badge, &st.RewardCoins); err != nil {
+			return nil, fmt.Errorf("failed to scan season standing: %v", err)
+		}
+		if st.Rank == 0 {
+			st.Rank = len(standings) + 1
+		}
+		if st.GamesPlayed > 0 {
+			st.WinRate = float64(st.GamesWon) / float64(st.GamesPlayed)
+		}
+		standings = append(standings, st)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating season standings: %v", err)
+	}
+
+	return standings, nil
+}
+
+// GetUserResults returns a user's archived results across finished seasons
+func (s *SeasonService) GetUserResults(userID int) ([]models.SeasonResult, error) {
+	query := `SELECT se.id, se.name, se.starts_at, se.ends_at, se.status,
+	                 r.rank, r.coins, r.games_played, r.games_won, r.rating, r.badge, r.reward_coins
+	          FROM season_results r
+	          JOIN seasons se ON se.id = r.season_id
+	          WHERE r.user_id = ?
+	          ORDER BY se.id DESC`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query season results: %v", err)
+	}
+	defer rows.Close()
+
+	results := []models.SeasonResult{}
+	for rows.Next() {
+		var r models.SeasonResult
+		var status string
+		if err := rows.Scan(&r.ID, &r.Name, &r.StartsAt, &r.EndsAt, &status,
+			&r.Standing.Rank, &r.Standing.Coins, &r.Standing.GamesPlayed, &r.Standing.GamesWon,
+			&r.Standing.Rating, &r.Standing.Badge, &r.Standing.RewardCoins); err != nil {
+			return nil, fmt.Errorf("failed to scan season result: %v", err)
+		}
+		r.Status = models.SeasonStatus(status)
+		if r.Standing.GamesPlayed > 0 {
+			r.Standing.WinRate = float64(r.Standing.GamesWon) / float64(r.Standing.GamesPlayed)
+		}
+		results = append(results, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating season results: %v", err)
+	}
+
+	return results, nil
+}
+
+// Rollover archives every season that has ended: final standings are copied
+// to season_results and the top finishers receive their rewards. It returns
+// the IDs of the seasons archived.
+func (s *SeasonService) Rollover() ([]int, error) {
+	now := s.now().UTC()
+	if err := s.ensureSeason(s.db, s.calendar.SeasonAt(now)); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`SELECT id FROM seasons WHERE status = ? AND ends_at <= ? ORDER BY id`,
+		string(models.SeasonActive), now.Format(sqliteTimeFormat))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ended seasons: %v", err)
+	}
+	var ended []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan season row: %v", err)
+		}
+		ended = append(ended, id)
+	}
+	rows.Close()
+
+	for _, id := range ended {
+		if err := runInTx(s.db, func(tx *sql.Tx) error { return s.archiveSeason(tx, id) }); err != nil {
+			return nil, err
+		}
+	}
+
+	return ended, nil
+}
+
+// archiveSeason writes the final standings of a season and pays its rewards
+func (s *SeasonService) archiveSeason(tx *sql.Tx, seasonID int) error {
+	query := `SELECT user_id, coins, games_played, games_won, rating
+	          FROM season_stats
+	          WHERE season_id = ?
+	          ORDER BY coins DESC, games_won DESC, rating DESC, user_id`
+	rows, err := tx.Query(query, seasonID)
+	if err != nil {
+		return fmt.Errorf("failed to query season stats: %v", err)
+	}
+
+	type finalStanding struct {
+		userID, coins, played, won, rating int
+	}
+	var standings []finalStanding
+	for rows.Next() {
+		var f finalStanding
+		if err := rows.Scan(&f.userID, &f.coins, &f.played, &f.won, &f.rating); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan season stats: %v", err)
+		}
+		standings = append(standings, f)
+	}
+	rows.Close()
+
+	for i, f := range standings {
+		rank := i + 1
+		badge, rewardCoins := "", 0
+		for _, reward := range seasonRewards {
+			if rank <= reward.maxRank {
+				badge, rewardCoins = reward.badge, reward.coins
+				break
+			}
+		}
+
+		insertQuery := `INSERT INTO season_results (season_id, user_id, rank, coins, games_played, games_won, rating, badge, reward_coins, archived_at)
+		                VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
+		if _, err := tx.Exec(insertQuery, seasonID, f.userID, rank, f.coins, f.played, f.won, f.rating, badge, rewardCoins); err != nil {
+			return fmt.Errorf("failed to archive season result: %v", err)
+		}
+
+		reference := fmt.Sprintf("season:%d", seasonID)
+		if _, err := s.ledger.Post(tx, f.userID, models.TxSeasonReward, rewardCoins, reference, fmt.Sprintf("Season %d rank %d", seasonID, rank)); err != nil {
+			return fmt.Errorf("failed to pay season reward: %v", err)
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE seasons SET status = ?, archived_at = CURRENT_TIMESTAMP WHERE id = ?`, string(models.SeasonArchived), seasonID); err != nil {
+		return fmt.Errorf("failed to archive season: %v", err)
+	}
+	return nil
+}
+
+// RunRollover checks for ended seasons every interval until stop is closed
+func (s *SeasonService) RunRollover(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			archived, err := s.Rollover()
+			if err != nil {
+				log.Printf("Season rollover failed: %v", err)
+				continue
+			}
+			for _, id := range archived {
+				log.Printf("Archived season %d", id)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"rockpaperscissors/internal/models"
+)
+
+func TestSeasonCalendar_SeasonAt(t *testing.T) {
+	calendar := SeasonCalendar{Start: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Length: 7 * 24 * time.Hour}
+
+	season := calendar.SeasonAt(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	if season.ID != 1 {
+		t.Errorf("Expected season 1, got %d", season.ID)
+	}
+
+	season = calendar.SeasonAt(time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC))
+	if season.ID != 3 || !season.StartsAt.Equal(time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected season 3 starting Jan 15th, got %+v", season)
+	}
+
+	season = calendar.SeasonAt(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	if season.ID != 1 {
+		t.Errorf("Expected times before the calendar to be season 1, got %d", season.ID)
+	}
+}
+
+func TestSeasonService_Rollover(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	calendar := SeasonCalendar{Start: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Length: 7 * 24 * time.Hour}
+	duringSeason := time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	games := NewGameService(db)
+	games.seasons.calendar = calendar
+	games.seasons.now = func() time.Time { return duringSeason }
+
+	userService := NewUserService(db)
+	for _, name := range []string{"seasonal1", "seasonal2"} {
+		if _, err := userService.CreateUser(name); err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		for i := 0; i < 20; i++ {
+			if _, err := games.PlayGame(name, models.Rock); err != nil {
+				t.Fatalf("Failed to play game: %v", err)
+			}
+		}
+	}
+
+	seasons := NewSeasonService(db)
+	seasons.calendar = calendar
+	seasons.now = func() time.Time { return duringSeason }
+
+	live, err := seasons.GetLeaderboard(1, 10)
+	if err != nil {
+		t.Fatalf("Failed to get live leaderboard: %v", err)
+	}
+	if len(live) != 2 || live[0].GamesPlayed != 20 {
+		t.Fatalf("Unexpected live standings: %+v", live)
+	}
+
+	archived, err := seasons.Rollover()
+	if err != nil {
+		t.Fatalf("Failed to roll over: %v", err)
+	}
+	if len(archived) != 0 {
+		t.Errorf("Expected nothing to archive mid-season, got %v", archived)
+	}
+
+	seasons.now = func() time.Time { return duringSeason.AddDate(0, 0, 10) }
+	archived, err = seasons.Rollover()
+	if err != nil {
+		t.Fatalf("Failed to roll over: %v", err)
+	}
+	if len(archived) != 1 || archived[0] != 1 {
+		t.Fatalf("Expected season 1 to be archived, got %v", archived)
+	}
+
+	final, err := seasons.GetLeaderboard(1, 10)
+	if err != nil {
+		t.Fatalf("Failed to get archived leaderboard: %v", err)
+	}
+	if len(final) != 2 || final[0].Badge != "gold" || final[1].Badge != "silver" {
+		t.Errorf("Unexpected archived standings: %+v", final)
+	}
+	if final[0].Coins != live[0].Coins {
+		t.Errorf("Archived coins %d differ from final tally %d", final[0].Coins, live[0].Coins)
+	}
+
+	// The winner's reward is paid through the ledger
+	winner, _ := userService.GetUser(final[0].Username)
+	results, _ := seasons.GetUserResults(winner.ID)
+	if len(results) != 1 || results[0].Standing.RewardCoins != 500 {
+		t.Errorf("Unexpected season results: %+v", results)
+	}
+	drifts, _ := NewLedgerService(db).Reconcile()
+	if len(drifts) != 0 {
+		t.Errorf("Expected ledger to reconcile after rewards, got %v", drifts)
+	}
+
+	// Rolling over again is a no-op
+	archived, _ = seasons.Rollover()
+	if len(archived) != 0 {
+		t.Errorf("Expected no further seasons to archive, got %v", archived)
+	}
+}
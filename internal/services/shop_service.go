@@ -0,0 +1,276 @@
+package services
+
+import (
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"strings"
+	"sync"
+)
+
+//go:embed shop_catalog.json
+var defaultCatalogJSON []byte
+
+// ShopCatalog is the set of cosmetic items available for purchase
+type ShopCatalog struct {
+	Items []models.ShopItem `json:"items"`
+	byID  map[string]models.ShopItem
+}
+
+// Item looks up a catalog item by ID
+func (c *ShopCatalog) Item(id string) (models.ShopItem, bool) {
+	item, ok := c.byID[id]
+	return item, ok
+}
+
+// ParseShopCatalog parses and validates a catalog data file
+func ParseShopCatalog(data []byte) (*ShopCatalog, error) {
+	var catalog ShopCatalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse shop catalog: %v", err)
+	}
+
+	catalog.byID = make(map[string]models.ShopItem, len(catalog.Items))
+	for _, item := range catalog.Items {
+		if item.ID == "" {
+			return nil, fmt.Errorf("shop catalog item %q has no ID", item.Name)
+		}
+		if _, exists := catalog.byID[item.ID]; exists {
+			return nil, fmt.Errorf("duplicate shop catalog item '%s'", item.ID)
+		}
+		if !item.Slot.IsValid() {
+			return nil, fmt.Errorf("shop catalog item '%s' has invalid slot '%s'", item.ID, item.Slot)
+		}
+		if item.Price < 0 {
+			return nil, fmt.Errorf("shop catalog item '%s' has a negative price", item.ID)
+		}
+		catalog.byID[item.ID] = item
+	}
+
+	return &catalog, nil
+}
+
+var (
+	shopCatalogOnce sync.Once
+	shopCatalog     *ShopCatalog
+	shopCatalogErr  error
+)
+
+// LoadShopCatalog returns the shop catalog, read once from the file named by
+// SHOP_CATALOG_PATH or from the catalog embedded in the binary
+func LoadShopCatalog() (*ShopCatalog, error) {
+	shopCatalogOnce.Do(func() {
+		data := defaultCatalogJSON
+		if path := os.Getenv("SHOP_CATALOG_PATH"); path != "" {
+			data, shopCatalogErr = os.ReadFile(path)
+			if shopCatalogErr != nil {
+				shopCatalogErr = fmt.Errorf("failed to read shop catalog: %v", shopCatalogErr)
+				return
+			}
+		}
+		shopCatalog, shopCatalogErr = ParseShopCatalog(data)
+	})
+	return shopCatalog, shopCatalogErr
+}
+
+// ShopService handles buying and equipping cosmetic items
+type ShopService struct {
+	db          *sql.DB
+	userService *UserService
+	ledger      *LedgerService
+}
+
+// NewShopService creates a new shop service
+func NewShopService(db *database.DB) *ShopService {
+	return &ShopService{
+		db:          db.DB,
+		userService: NewUserService(db),
+		ledger:      NewLedgerService(db),
+	}
+}
+
+// GetItems returns every item in the catalog
+func (s *ShopService) GetItems() ([]models.ShopItem, error) {
+	catalog, err := LoadShopCatalog()
+	if err != nil {
+		return nil, err
+	}
+	return catalog.Items, nil
+}
+
+// Purchase debits the item price and adds the item to the user's inventory
+// in a single transaction
+func (s *ShopService) Purchase(username, itemID string) (*models.InventoryItem, int, error) {
+	catalog, err := LoadShopCatalog()
+	if err != nil {
+		return nil, 0, err
+	}
+	item, ok := catalog.Item(itemID)
+	if !ok {
+		return nil, 0, fmt.Errorf("item '%s' not found", itemID)
+	}
+
+	var balance int
+	err = runInTx(s.db, func(tx *sql.Tx) error {
+		user, err := s.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+		balance = user.TotalCoins
+
+		var owned int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM inventory WHERE user_id = ? AND item_id = ?`, user.ID, item.ID).Scan(&owned); err != nil {
+			return fmt.Errorf("failed to check inventory: %v", err)
+		}
+		if owned > 0 {
+			return fmt.Errorf("user '%s' already owns item '%s'", username, item.ID)
+		}
+
+		entry, err := s.ledger.Post(tx, user.ID, models.TxPurchase, -item.Price, "item:"+item.ID, "Purchased "+item.Name)
+		if err != nil {
+			return err
+		}
+		if entry != nil {
+			balance = entry.BalanceAfter
+		}
+
+		insertQuery := `INSERT INTO inventory (user_id, item_id, slot, equipped, purchased_at)
+		                VALUES (?, ?, ?, 0, CURRENT_TIMESTAMP)`
+		if _, err := tx.Exec(insertQuery, user.ID, item.ID, string(item.Slot)); err != nil {
+			return fmt.Errorf("failed to add item to inventory: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &models.InventoryItem{ShopItem: item, Equipped: false}, balance, nil
+}
+
+// Equip equips an owned item, replacing whatever was in its slot
+func (s *ShopService) Equip(username, itemID string) error {
+	return runInTx(s.db, func(tx *sql.Tx) error {
+		user, err := s.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+
+		var slot string
+		err = tx.QueryRow(`SELECT slot FROM inventory WHERE user_id = ? AND item_id = ?`, user.ID, itemID).Scan(&slot)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("item '%s' not found in inventory of '%s'", itemID, username)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check inventory: %v", err)
+		}
+
+		if _, err := tx.Exec(`UPDATE inventory SET equipped = 0 WHERE user_id = ? AND slot = ?`, user.ID, slot); err != nil {
+			return fmt.Errorf("failed to unequip slot: %v", err)
+		}
+		if _, err := tx.Exec(`UPDATE inventory SET equipped = 1 WHERE user_id = ? AND item_id = ?`, user.ID, itemID); err != nil {
+			return fmt.Errorf("failed to equip item: %v", err)
+		}
+		return nil
+	})
+}
+
+// Unequip clears a cosmetic slot
+func (s *ShopService) Unequip(username string, slot models.CosmeticSlot) error {
+	user, err := s.userService.GetUser(username)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`UPDATE inventory SET equipped = 0 WHERE user_id = ? AND slot = ?`, user.ID, string(slot)); err != nil {
+		return fmt.Errorf("failed to unequip slot: %v", err)
+	}
+	return nil
+}
+
+// GetInventory lists every item a user owns
+func (s *ShopService) GetInventory(username string) ([]models.InventoryItem, error) {
+	catalog, err := LoadShopCatalog()
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userService.GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT item_id, equipped, purchased_at FROM inventory WHERE user_id = ? ORDER BY purchased_at, id`
+	rows, err := s.db.Query(query, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query inventory: %v", err)
+	}
+	defer rows.Close()
+
+	inventory := []models.InventoryItem{}
+	for rows.Next() {
+		var itemID string
+		var owned models.InventoryItem
+		if err := rows.Scan(&itemID, &owned.Equipped, &owned.PurchasedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan inventory row: %v", err)
+		}
+		item, ok := catalog.Item(itemID)
+		if !ok {
+			// Items removed from the catalog stay owned but are no longer shown
+			continue
+		}
+		owned.ShopItem = item
+		inventory = append(inventory, owned)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating inventory rows: %v", err)
+	}
+
+	return inventory, nil
+}
+
+// loadEquippedCosmetics returns the equipped items for each of userIDs
+func loadEquippedCosmetics(exec dbExecutor, userIDs []int) (map[int]models.EquippedCosmetics, error) {
+	cosmetics := make(map[int]models.EquippedCosmetics, len(userIDs))
+	if len(userIDs) == 0 {
+		return cosmetics, nil
+	}
+
+	catalog, err := LoadShopCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(userIDs)), ",")
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		args[i] = id
+	}
+
+	query := `SELECT user_id, item_id FROM inventory WHERE equipped = 1 AND user_id IN (` + placeholders + `)`
+	rows, err := exec.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query equipped cosmetics: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID int
+		var itemID string
+		if err := rows.Scan(&userID, &itemID); err != nil {
+			return nil, fmt.Errorf("failed to scan equipped cosmetic: %v", err)
+		}
+		if item, ok := catalog.Item(itemID); ok {
+			equipped := cosmetics[userID]
+			equipped.Set(item)
+			cosmetics[userID] = equipped
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating equipped cosmetics: %v", err)
+	}
+
+	return cosmetics, nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"database/sql"
+	"sync"
+)
+
+// maxCachedStmts caps how many statements a database keeps prepared. Queries
+// past the cap still run, just without being prepared first.
+const maxCachedStmts = 256
+
+// stmtCache prepares each query a service runs once and reuses the
+// statement. database/sql prepares a statement lazily on every pooled
+// connection it runs on and keeps it there, so a cached statement costs a
+// parse per connection instead of one per call. Each service keeps its own
+// cache; the statements go with the service, and closing the database
+// closes them along with its connections.
+type stmtCache struct {
+	db    *sql.DB
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt // nil while being prepared, or if it failed to
+}
+
+// newStmtCache creates an empty statement cache for db
+func newStmtCache(db *sql.DB) *stmtCache {
+	return &stmtCache{db: db, stmts: make(map[string]*sql.Stmt)}
+}
+
+// lookup returns the cached statement for query, or nil if there is none
+// yet. The first lookup of a query prepares it in the background: preparing
+// takes a connection of its own, which a caller in the middle of a
+// transaction may wait on forever once the pool is used up.
+func (c *stmtCache) lookup(query string) *sql.Stmt {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stmt, seen := c.stmts[query]
+	if !seen && len(c.stmts) < maxCachedStmts {
+		c.stmts[query] = nil
+		go c.prepare(query)
+	}
+	return stmt
+}
+
+// prepare fills in the cache entry of query. A query that fails to prepare
+// keeps running unprepared, which reports its error.
+func (c *stmtCache) prepare(query string) {
+	stmt, err := c.db.Prepare(query)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	c.stmts[query] = stmt
+	c.mu.Unlock()
+}
+
+// on returns exec with its queries run as cached statements. exec must be
+// the cache's database or a transaction on it.
+func (c *stmtCache) on(exec dbExecutor) dbExecutor {
+	switch e := exec.(type) {
+	case *sql.DB:
+		return &cachedExecutor{cache: c, exec: e}
+	case *sql.Tx:
+		return &cachedExecutor{cache: c, exec: e, tx: e}
+	}
+	return exec
+}
+
+// cachedExecutor runs queries through a stmtCache. Anything that fails to
+// prepare runs unprepared instead, so errors surface from the query itself
+// just as they would without the cache.
+type cachedExecutor struct {
+	cache *stmtCache
+	exec  dbExecutor
+	tx    *sql.Tx // nil outside a transaction
+}
+
+// stmt returns the cached statement for query bound to the transaction, if
+// any, or nil to run the query unprepared. A statement bound to a
+// transaction reuses what was prepared on the transaction's connection and
+// is closed along with the transaction.
+func (e *cachedExecutor) stmt(query string) *sql.Stmt {
+	stmt := e.cache.lookup(query)
+	if stmt != nil && e.tx != nil {
+		return e.tx.Stmt(stmt)
+	}
+	return stmt
+}
+
+func (e *cachedExecutor) Exec(query string, args ...interface{}) (sql.Result, error) {
+	if stmt := e.stmt(query); stmt != nil {
+		return stmt.Exec(args...)
+	}
+	return e.exec.Exec(query, args...)
+}
+
+func (e *cachedExecutor) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	if stmt := e.stmt(query); stmt != nil {
+		return stmt.Query(args...)
+	}
+	return e.exec.Query(query, args...)
+}
+
+func (e *cachedExecutor) QueryRow(query string, args ...interface{}) *sql.Row {
+	if stmt := e.stmt(query); stmt != nil {
+		return stmt.QueryRow(args...)
+	}
+	return e.exec.QueryRow(query, args...)
+}
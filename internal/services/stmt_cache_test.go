@@ -0,0 +1,50 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestStmtCache(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	cache := newStmtCache(db.DB)
+
+	// a miss inside a transaction holding the only connection must not wait
+	// for a second one
+	query := `INSERT INTO users (username) VALUES (?)`
+	err := runInTx(db.DB, func(tx *sql.Tx) error {
+		_, err := cache.on(tx).Exec(query, "alice")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to insert through the cache: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for cache.lookup(query) == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the statement to be prepared once the connection was free")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	err = runInTx(db.DB, func(tx *sql.Tx) error {
+		_, err := cache.on(tx).Exec(query, "bob")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to insert with the cached statement: %v", err)
+	}
+
+	// queries that do not prepare still report their error
+	var count int
+	if err := cache.on(db.DB).QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count); err != nil || count != 2 {
+		t.Errorf("Expected 2 users, got %d, %v", count, err)
+	}
+	if err := cache.on(db.DB).QueryRow(`SELECT COUNT(*) FROM no_such_table`).Scan(&count); err == nil {
+		t.Errorf("Expected a query on a missing table to fail")
+	}
+}
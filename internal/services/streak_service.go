@@ -0,0 +1,253 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"time"
+)
+
+// StreakService tracks every win streak a player has had
+type StreakService struct {
+	db    *sql.DB
+	stmts *stmtCache
+}
+
+// NewStreakService creates a new streak service
+func NewStreakService(db *database.DB) *StreakService {
+	return &StreakService{db: db.DB, stmts: newStmtCache(db.DB)}
+}
+
+// RecordGame updates the user's active streak with a settled game and
+// reports whether the game set a new best streak. It runs inside the
+// settlement transaction.
+func (s *StreakService) RecordGame(tx *sql.Tx, game settledGame, coinsEarned int) (bool, error) {
+	exec := s.stmts.on(tx)
+	switch game.Result {
+	case models.Win:
+		updated := int64(0)
+		if game.User.CurrentStreak > 1 {
+			updateQuery := `UPDATE streaks SET end_game_id = ?, length = ?, coins_earned = coins_earned + ?
+			                WHERE user_id = ? AND status = 'active'`
+			result, err := exec.Exec(updateQuery, game.GameID, game.User.CurrentStreak, coinsEarned, game.User.ID)
+			if err != nil {
+				return false, fmt.Errorf("failed to extend streak: %v", err)
+			}
+			if updated, err = result.RowsAffected(); err != nil {
+				return false, fmt.Errorf("failed to check streak update: %v", err)
+			}
+		}
+		// a streak that predates streak tracking and was never backfilled
+		// starts being tracked from this game
+		if updated == 0 {
+			if err := s.endActive(exec, game.User.ID); err != nil {
+				return false, err
+			}
+			insertQuery := `INSERT INTO streaks (user_id, start_game_id, end_game_id, length, coins_earned, status, started_at)
+			                VALUES (?, ?, ?, ?, ?, 'active', CURRENT_TIMESTAMP)`
+			if _, err := exec.Exec(insertQuery, game.User.ID, game.GameID, game.GameID, game.User.CurrentStreak, coinsEarned); err != nil {
+				return false, fmt.Errorf("failed to start streak: %v", err)
+			}
+		}
+
+		if game.User.CurrentStreak <= game.User.BestStreak {
+			return false, nil
+		}
+		updateBest := `UPDATE users SET best_streak = ? WHERE id = ?`
+		if _, err := exec.Exec(updateBest, game.User.CurrentStreak, game.User.ID); err != nil {
+			return false, fmt.Errorf("failed to update best streak: %v", err)
+		}
+		return true, nil
+
+	case models.Lose:
+		if err := s.endActive(exec, game.User.ID); err != nil {
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+// endActive ends the user's active streak, if there is one
+func (s *StreakService) endActive(exec dbExecutor, userID int) error {
+	endQuery := `UPDATE streaks SET status = 'ended', ended_at = CURRENT_TIMESTAMP
+	             WHERE user_id = ? AND status = 'active'`
+	if _, err := exec.Exec(endQuery, userID); err != nil {
+		return fmt.Errorf("failed to end streak: %v", err)
+	}
+	return nil
+}
+
+// GetUserStreaks lists a user's streaks newest first, along with the total
+func (s *StreakService) GetUserStreaks(userID, limit, offset int) ([]models.Streak, int, error) {
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM streaks WHERE user_id = ?`, userID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count streaks: %v", err)
+	}
+
+	query := `SELECT id, start_game_id, end_game_id, length, coins_earned, status, started_at, ended_at
+	          FROM streaks
+	          WHERE user_id = ?
+	          ORDER BY id DESC
+	          LIMIT ? OFFSET ?`
+	rows, err := s.db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query streaks: %v", err)
+	}
+	defer rows.Close()
+
+	streaks := []models.Streak{}
+	for rows.Next() {
+		var streak models.Streak
+		var startGameID, endGameID sql.NullInt64
+		var status string
+		var endedAt sql.NullTime
+		if err := rows.Scan(&streak.ID, &startGameID, &endGameID, &streak.Length, &streak.CoinsEarned, &status, &streak.StartedAt, &endedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan streak row: %v", err)
+		}
+		if startGameID.Valid {
+			id := int(startGameID.Int64)
+			streak.StartGameID = &id
+		}
+		if endGameID.Valid {
+			id := int(endGameID.Int64)
+			streak.EndGameID = &id
+		}
+		streak.Status = models.StreakStatus(status)
+		if endedAt.Valid {
+			streak.EndedAt = &endedAt.Time
+		}
+		streaks = append(streaks, streak)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating streak rows: %v", err)
+	}
+
+	return streaks, total, nil
+}
+
+// GetStreakLeaderboard ranks users by their best streak ever
+func (s *StreakService) GetStreakLeaderboard(limit int) ([]models.StreakLeaderboardEntry, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	query := `SELECT username, best_streak, current_streak
+	          FROM users u
+	          WHERE best_streak > 0 AND ` + rankedUsersFilter + `
+	          ORDER BY best_streak DESC, current_streak DESC, id
+	          LIMIT ?`
+	rows, err := s.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query streak leaderboard: %v", err)
+	}
+	defer rows.Close()
+
+	leaderboard := []models.StreakLeaderboardEntry{}
+	for rows.Next() {
+		entry := models.StreakLeaderboardEntry{Rank: len(leaderboard) + 1}
+		if err := rows.Scan(&entry.Username, &entry.BestStreak, &entry.CurrentStreak); err != nil {
+			return nil, fmt.Errorf("failed to scan streak leaderboard row: %v", err)
+		}
+		leaderboard = append(leaderboard, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating streak leaderboard rows: %v", err)
+	}
+
+	return leaderboard, nil
+}
+
+// Backfill rebuilds the streak history of every user who has won games but
+// has no streaks recorded, by replaying their games against the computer. It
+// is safe to run repeatedly and returns how many users were backfilled.
+func (s *StreakService) Backfill() (int, error) {
+	query := `SELECT u.id FROM users u
+	          WHERE NOT EXISTS (SELECT 1 FROM streaks s WHERE s.user_id = u.id)
+	            AND EXISTS (SELECT 1 FROM games g WHERE g.user_id = u.id AND g.opponent_user_id IS NULL AND g.result = 'win')`
+	userIDs, err := queryIDs(s.db, query)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, userID := range userIDs {
+		if err := runInTx(s.db, func(tx *sql.Tx) error {
+			return s.backfillUser(tx, userID)
+		}); err != nil {
+			return 0, fmt.Errorf("failed to backfill streaks of user %d: %v", userID, err)
+		}
+	}
+
+	return len(userIDs), nil
+}
+
+// backfillUser replays one user's games into streak rows
+func (s *StreakService) backfillUser(tx *sql.Tx, userID int) error {
+	query := `SELECT id, result, coins_earned, played_at FROM games
+	          WHERE user_id = ? AND opponent_user_id IS NULL
+	          ORDER BY played_at, id`
+	rows, err := tx.Query(query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to query games: %v", err)
+	}
+	defer rows.Close()
+
+	var streaks []models.Streak
+	current := -1 // index of the streak still going, if any
+	for rows.Next() {
+		var gameID, coins int
+		var result string
+		var playedAt time.Time
+		if err := rows.Scan(&gameID, &result, &coins, &playedAt); err != nil {
+			return fmt.Errorf("failed to scan game row: %v", err)
+		}
+
+		switch models.GameResult(result) {
+		case models.Win:
+			if current < 0 {
+				startID := gameID
+				streaks = append(streaks, models.Streak{StartGameID: &startID, Status: models.StreakActive, StartedAt: playedAt})
+				current = len(streaks) - 1
+			}
+			endID := gameID
+			streaks[current].EndGameID = &endID
+			streaks[current].Length++
+			streaks[current].CoinsEarned += coins
+		case models.Lose:
+			if current >= 0 {
+				endedAt := playedAt
+				streaks[current].Status = models.StreakEnded
+				streaks[current].EndedAt = &endedAt
+				current = -1
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating game rows: %v", err)
+	}
+	rows.Close()
+
+	best := 0
+	insertQuery := `INSERT INTO streaks (user_id, start_game_id, end_game_id, length, coins_earned, status, started_at, ended_at)
+	                VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	for _, streak := range streaks {
+		var endedAt interface{}
+		if streak.EndedAt != nil {
+			endedAt = streak.EndedAt.UTC().Format(sqliteTimeFormat)
+		}
+		_, err := tx.Exec(insertQuery, userID, *streak.StartGameID, *streak.EndGameID, streak.Length, streak.CoinsEarned,
+			string(streak.Status), streak.StartedAt.UTC().Format(sqliteTimeFormat), endedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert streak: %v", err)
+		}
+		if streak.Length > best {
+			best = streak.Length
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET best_streak = MAX(best_streak, ?) WHERE id = ?`, best, userID); err != nil {
+		return fmt.Errorf("failed to update best streak: %v", err)
+	}
+	return nil
+}
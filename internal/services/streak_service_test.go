@@ -0,0 +1,147 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+
+	"rockpaperscissors/internal/models"
+)
+
+func TestStreakService_RecordGame(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	userService := NewUserService(db)
+	games := NewGameService(db)
+	streaks := NewStreakService(db)
+
+	user, err := userService.CreateUser("streaker")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	// win, win, tie, win, lose, win
+	results := []models.GameResult{models.Win, models.Win, models.Tie, models.Win, models.Lose, models.Win}
+	logic := NewGameLogicService()
+	state := *user
+	var newBests []bool
+	for _, result := range results {
+		coins := logic.CalculateCoinsEarned(result, state.CurrentStreak)
+		state.CurrentStreak = logic.CalculateNewStreak(state.CurrentStreak, result)
+
+		err := runInTx(db.DB, func(tx *sql.Tx) error {
+			gameID, err := games.saveGameRecord(tx, user.ID, models.BotRandom, models.Rock, models.Scissors, result, coins, 1)
+			if err != nil {
+				return err
+			}
+			newBest, err := streaks.RecordGame(tx, settledGame{GameID: gameID, User: state, Result: result}, coins)
+			if newBest {
+				state.BestStreak = state.CurrentStreak
+			}
+			newBests = append(newBests, newBest)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Failed to record game: %v", err)
+		}
+	}
+
+	history, total, err := streaks.GetUserStreaks(user.ID, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get streaks: %v", err)
+	}
+	if total != 2 {
+		t.Fatalf("Expected 2 streaks, got %d", total)
+	}
+
+	latest, first := history[0], history[1]
+	if first.Length != 3 || first.Status != models.StreakEnded || first.EndedAt == nil {
+		t.Errorf("Expected an ended streak of 3, got %+v", first)
+	}
+	if first.CoinsEarned != 10+20+30 {
+		t.Errorf("Expected the first streak to have earned 60 coins, got %d", first.CoinsEarned)
+	}
+	if latest.Length != 1 || latest.Status != models.StreakActive {
+		t.Errorf("Expected an active streak of 1, got %+v", latest)
+	}
+
+	got, err := userService.GetUser("streaker")
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if got.BestStreak != 3 {
+		t.Errorf("Expected best streak 3, got %d", got.BestStreak)
+	}
+	if !newBests[3] || newBests[5] {
+		t.Errorf("Expected the 4th game to set a new best and the 6th not to, got %v", newBests)
+	}
+}
+
+func TestStreakService_Backfill(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	userService := NewUserService(db)
+	games := NewGameService(db)
+	streaks := NewStreakService(db)
+
+	user, err := userService.CreateUser("veteran")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if _, err := userService.CreateUser("newcomer"); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	// games from before streaks were tracked: a streak of 4 across a tie,
+	// then a streak of 2 that is still going
+	results := []models.GameResult{models.Lose, models.Win, models.Win, models.Tie, models.Win, models.Win, models.Lose, models.Win, models.Win}
+	for _, result := range results {
+		if _, err := games.saveGameRecord(db, user.ID, models.BotRandom, models.Paper, models.Rock, result, 10, 1); err != nil {
+			t.Fatalf("Failed to save game: %v", err)
+		}
+	}
+
+	backfilled, err := streaks.Backfill()
+	if err != nil {
+		t.Fatalf("Failed to backfill: %v", err)
+	}
+	if backfilled != 1 {
+		t.Errorf("Expected 1 user backfilled, got %d", backfilled)
+	}
+
+	history, total, err := streaks.GetUserStreaks(user.ID, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get streaks: %v", err)
+	}
+	if total != 2 || history[1].Length != 4 || history[1].Status != models.StreakEnded {
+		t.Fatalf("Expected an ended streak of 4 and an active one, got %+v", history)
+	}
+	if history[0].Length != 2 || history[0].Status != models.StreakActive || history[0].CoinsEarned != 20 {
+		t.Errorf("Expected an active streak of 2 worth 20 coins, got %+v", history[0])
+	}
+
+	got, err := userService.GetUser("veteran")
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if got.BestStreak != 4 {
+		t.Errorf("Expected best streak 4, got %d", got.BestStreak)
+	}
+
+	backfilled, err = streaks.Backfill()
+	if err != nil {
+		t.Fatalf("Failed to backfill again: %v", err)
+	}
+	if backfilled != 0 {
+		t.Errorf("Expected a second backfill to do nothing, got %d users", backfilled)
+	}
+
+	leaderboard, err := streaks.GetStreakLeaderboard(10)
+	if err != nil {
+		t.Fatalf("Failed to get streak leaderboard: %v", err)
+	}
+	if len(leaderboard) != 1 || leaderboard[0].Username != "veteran" || leaderboard[0].BestStreak != 4 {
+		t.Errorf("Expected only veteran on the streak leaderboard, got %+v", leaderboard)
+	}
+}
@@ -0,0 +1,341 @@
+package services
+
+import (
+	"rockpaperscissors/internal/models"
+	"sort"
+)
+
+// The functions in this file plan tournaments without touching the
+// database. Players are referred to by seed, 1 being the top seed and 0
+// meaning nobody.
+
+// bracketLink sends the winner or loser of a match to a slot (0 or 1) of a
+// later match
+type bracketLink struct {
+	match int // index into the planned matches
+	slot  int
+}
+
+// plannedMatch is a match in a generated bracket. Slots are either seeded
+// when the bracket is built, possibly with nobody, or filled later through
+// the links of earlier matches.
+type plannedMatch struct {
+	bracket  models.TournamentBracket
+	round    int
+	position int
+	seeds    [2]int
+	seeded   [2]bool
+	winnerTo *bracketLink
+	loserTo  *bracketLink
+	// eliminates is the elimination rank its loser gets, 0 if losing does
+	// not knock them out. Players knocked out later rank higher.
+	eliminates int
+}
+
+// bracketSize is the smallest power of two that fits n players
+func bracketSize(n int) int {
+	size := 2
+	for size < n {
+		size *= 2
+	}
+	return size
+}
+
+// seedOrder lists the seeds of a bracket of the given size in slot order, so
+// the top seeds can only meet in the latest rounds: 1, 8, 4, 5, 2, 7, 3, 6
+// for eight players
+func seedOrder(size int) []int {
+	order := []int{1}
+	for len(order) < size {
+		next := make([]int, 0, 2*len(order))
+		total := 2*len(order) + 1
+		for _, seed := range order {
+			next = append(next, seed, total-seed)
+		}
+		order = next
+	}
+	return order
+}
+
+// planWinnersBracket adds a single-elimination bracket for n players to
+// matches and returns the index of its final. Seeds past n are byes.
+func planWinnersBracket(matches []plannedMatch, n int, bracket models.TournamentBracket) ([]plannedMatch, int, [][]int) {
+	size := bracketSize(n)
+	order := seedOrder(size)
+
+	var rounds [][]int
+	var previous []int
+	for round, count := 1, size/2; count >= 1; round, count = round+1, count/2 {
+		var current []int
+		for position := 0; position < count; position++ {
+			m := plannedMatch{bracket: bracket, round: round, position: position + 1}
+			if round == 1 {
+				for slot := 0; slot < 2; slot++ {
+					seed := order[2*position+slot]
+					if seed > n {
+						seed = 0
+					}
+					m.seeds[slot] = seed
+					m.seeded[slot] = true
+				}
+			}
+			current = append(current, len(matches))
+			matches = append(matches, m)
+		}
+		for i, index := range previous {
+			matches[index].winnerTo = &bracketLink{match: current[i/2], slot: i % 2}
+		}
+		rounds = append(rounds, current)
+		previous = current
+	}
+	return matches, previous[0], rounds
+}
+
+// planSingleElimination plans a knockout bracket for n players
+func planSingleElimination(n int) []plannedMatch {
+	matches, _, rounds := planWinnersBracket(nil, n, models.BracketWinners)
+	for round, indexes := range rounds {
+		for _, index := range indexes {
+			matches[index].eliminates = round + 1
+		}
+	}
+	return matches
+}
+
+// planDoubleElimination plans a winners bracket, a losers bracket that
+// players drop into after their first loss, and a single grand final
+// between the two bracket winners
+func planDoubleElimination(n int) []plannedMatch {
+	matches, winnersFinal, winners := planWinnersBracket(nil, n, models.BracketWinners)
+
+	// losers bracket rounds alternate between winners of the previous losers
+	// round playing each other and playing players dropping down from the
+	// winners bracket
+	var previous []int
+	for round := 1; round <= 2*(len(winners)-1); round++ {
+		var current []int
+		count := len(winners[0]) / (1 << uint((round+1)/2))
+		for position := 0; position < count; position++ {
+			current = append(current, len(matches))
+			matches = append(matches, plannedMatch{
+				bracket:    models.BracketLosers,
+				round:      round,
+				position:   position + 1,
+				eliminates: round,
+			})
+		}
+
+		switch {
+		case round == 1:
+			for i, index := range winners[0] {
+				matches[index].loserTo = &bracketLink{match: current[i/2], slot: i % 2}
+			}
+		case round%2 == 0:
+			// drop-ins go in reverse order every other round so players
+			// do not meet again straight away
+			dropping := winners[round/2]
+			for i, index := range previous {
+				matches[index].winnerTo = &bracketLink{match: current[i], slot: 0}
+			}
+			for i, index := range dropping {
+				target := i
+				if (round/2)%2 == 1 {
+					target = len(dropping) - 1 - i
+				}
+				matches[index].loserTo = &bracketLink{match: current[target], slot: 1}
+			}
+		default:
+			for i, index := range previous {
+				matches[index].winnerTo = &bracketLink{match: current[i/2], slot: i % 2}
+			}
+		}
+		previous = current
+	}
+
+	grandFinal := len(matches)
+	matches = append(matches, plannedMatch{
+		bracket:    models.BracketGrandFinal,
+		round:      1,
+		position:   1,
+		eliminates: 2*(len(winners)-1) + 1,
+	})
+	matches[winnersFinal].winnerTo = &bracketLink{match: grandFinal, slot: 0}
+	if len(previous) == 1 {
+		matches[previous[0]].winnerTo = &bracketLink{match: grandFinal, slot: 1}
+	} else {
+		// two players: the loser of the only winners match gets a rematch
+		matches[winnersFinal].loserTo = &bracketLink{match: grandFinal, slot: 1}
+	}
+	return matches
+}
+
+// planRoundRobin pairs every player with every other once using the circle
+// method. With an odd number of players one sits out each round.
+func planRoundRobin(n int) []plannedMatch {
+	seeds := make([]int, 0, n+1)
+	for seed := 1; seed <= n; seed++ {
+		seeds = append(seeds, seed)
+	}
+	if len(seeds)%2 == 1 {
+		seeds = append(seeds, 0)
+	}
+
+	var matches []plannedMatch
+	half := len(seeds) / 2
+	for round := 1; round < len(seeds); round++ {
+		position := 0
+		for i := 0; i < half; i++ {
+			a, b := seeds[i], seeds[len(seeds)-1-i]
+			if a == 0 || b == 0 {
+				continue
+			}
+			position++
+			matches = append(matches, plannedMatch{
+				bracket:  models.BracketMain,
+				round:    round,
+				position: position,
+				seeds:    [2]int{a, b},
+				seeded:   [2]bool{true, true},
+			})
+		}
+		// keep the first seat fixed and rotate everyone else by one
+		rotated := append([]int{seeds[0], seeds[len(seeds)-1]}, seeds[1:len(seeds)-1]...)
+		seeds = rotated
+	}
+	return matches
+}
+
+// swissStanding is a player's record going into a Swiss round
+type swissStanding struct {
+	seed    int
+	points  int
+	hadBye  bool
+	opposed map[int]bool
+}
+
+// swissPairings pairs the next Swiss round. The first round sets the top
+// half of the seeds against the bottom half; later rounds pair players on
+// the same points, avoiding rematches where possible. With an odd number of
+// players the lowest-ranked player without a bye so far gets one. It
+// returns pairs of seeds and the seed getting the bye, or 0.
+func swissPairings(standings []swissStanding, round int) ([][2]int, int) {
+	ranked := append([]swissStanding(nil), standings...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].points != ranked[j].points {
+			return ranked[i].points > ranked[j].points
+		}
+		return ranked[i].seed < ranked[j].seed
+	})
+
+	bye := 0
+	if len(ranked)%2 == 1 {
+		at := len(ranked) - 1
+		for i := len(ranked) - 1; i >= 0; i-- {
+			if !ranked[i].hadBye {
+				at = i
+				break
+			}
+		}
+		bye = ranked[at].seed
+		ranked = append(ranked[:at], ranked[at+1:]...)
+	}
+
+	if round == 1 {
+		half := len(ranked) / 2
+		pairs := make([][2]int, 0, half)
+		for i := 0; i < half; i++ {
+			pairs = append(pairs, [2]int{ranked[i].seed, ranked[i+half].seed})
+		}
+		return pairs, bye
+	}
+
+	if pairs, ok := pairWithoutRematches(ranked, make([]bool, len(ranked))); ok {
+		return pairs, bye
+	}
+	// everyone has played everyone they could; allow rematches
+	pairs := make([][2]int, 0, len(ranked)/2)
+	for i := 0; i+1 < len(ranked); i += 2 {
+		pairs = append(pairs, [2]int{ranked[i].seed, ranked[i+1].seed})
+	}
+	return pairs, bye
+}
+
+// pairWithoutRematches pairs the highest-ranked unpaired player with the
+// next highest they have not played, backtracking when that leaves someone
+// without an opponent
+func pairWithoutRematches(ranked []swissStanding, paired []bool) ([][2]int, bool) {
+	first := -1
+	for i := range ranked {
+		if !paired[i] {
+			first = i
+			break
+		}
+	}
+	if first == -1 {
+		return nil, true
+	}
+
+	paired[first] = true
+	for j := first + 1; j < len(ranked); j++ {
+		if paired[j] || ranked[first].opposed[ranked[j].seed] {
+			continue
+		}
+		paired[j] = true
+		if rest, ok := pairWithoutRematches(ranked, paired); ok {
+			return append([][2]int{{ranked[first].seed, ranked[j].seed}}, rest...), true
+		}
+		paired[j] = false
+	}
+	paired[first] = false
+	return nil, false
+}
+
+// swissRoundsFor is how many Swiss rounds it takes to find a clear winner
+// among n players
+func swissRoundsFor(n int) int {
+	rounds := 1
+	for 1<<uint(rounds) < n {
+		rounds++
+	}
+	return rounds
+}
+
+// splitPrizePool divides pool between finishing places. split gives the
+// percentage for 1st, 2nd and so on; players sharing a place share the
+// percentages of the places they cover. Whatever is left over, from
+// rounding or from places nobody finished in, goes to the winner. places
+// holds each player's place and the result each player's prize.
+func splitPrizePool(pool int, split []int, places []int) []int {
+	prizes := make([]int, len(places))
+	if pool <= 0 || len(places) == 0 {
+		return prizes
+	}
+
+	byPlace := map[int][]int{}
+	for i, place := range places {
+		byPlace[place] = append(byPlace[place], i)
+	}
+
+	paid := 0
+	winner := -1
+	for place, players := range byPlace {
+		if place == 1 && winner == -1 {
+			winner = players[0]
+		}
+		percent := 0
+		for p := place; p < place+len(players); p++ {
+			if p-1 < len(split) {
+				percent += split[p-1]
+			}
+		}
+		share := pool * percent / 100 / len(players)
+		for _, i := range players {
+			prizes[i] = share
+			paid += share
+		}
+	}
+	if winner >= 0 {
+		prizes[winner] += pool - paid
+	}
+	return prizes
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// simulateBracket plays a planned bracket through in order, letting pick
+// choose the winner of every match with two players. It fails the test if a
+// match is played before both its slots are filled, and returns the seed
+// who won the last match along with every player's elimination rank and
+// number of losses.
+func simulateBracket(t *testing.T, plan []plannedMatch, pick func(a, b int) int) (int, map[int]int, map[int]int) {
+	t.Helper()
+	slots := make([][2]int, len(plan))
+	filled := make([][2]bool, len(plan))
+	for i, m := range plan {
+		for slot := 0; slot < 2; slot++ {
+			if m.seeded[slot] {
+				slots[i][slot], filled[i][slot] = m.seeds[slot], true
+			}
+		}
+	}
+
+	eliminated := map[int]int{}
+	losses := map[int]int{}
+	champion := 0
+	for i, m := range plan {
+		if !filled[i][0] || !filled[i][1] {
+			t.Fatalf("Match %d (%s round %d) played before both players were known", i, m.bracket, m.round)
+		}
+		a, b := slots[i][0], slots[i][1]
+		for _, seed := range []int{a, b} {
+			if _, out := eliminated[seed]; out && seed != 0 {
+				t.Fatalf("Seed %d played match %d after being eliminated", seed, i)
+			}
+		}
+
+		winner, loser := a+b, 0
+		if a != 0 && b != 0 {
+			winner = pick(a, b)
+			loser = a + b - winner
+			losses[loser]++
+			if m.eliminates > 0 {
+				eliminated[loser] = m.eliminates
+			}
+		}
+
+		if m.winnerTo != nil {
+			slots[m.winnerTo.match][m.winnerTo.slot] = winner
+			filled[m.winnerTo.match][m.winnerTo.slot] = true
+		} else {
+			champion = winner
+		}
+		if m.loserTo != nil {
+			slots[m.loserTo.match][m.loserTo.slot] = loser
+			filled[m.loserTo.match][m.loserTo.slot] = true
+		}
+	}
+	return champion, eliminated, losses
+}
+
+func TestSeedOrder(t *testing.T) {
+	if got := seedOrder(8); !reflect.DeepEqual(got, []int{1, 8, 4, 5, 2, 7, 3, 6}) {
+		t.Errorf("Unexpected seed order for 8: %v", got)
+	}
+	if got := bracketSize(5); got != 8 {
+		t.Errorf("Expected a bracket of 8 for 5 players, got %d", got)
+	}
+}
+
+func TestPlanSingleElimination(t *testing.T) {
+	plan := planSingleElimination(5)
+	if len(plan) != 7 {
+		t.Fatalf("Expected 7 matches for 5 players, got %d", len(plan))
+	}
+	var firstRound [][2]int
+	for _, m := range plan[:4] {
+		firstRound = append(firstRound, m.seeds)
+	}
+	// the top three seeds get byes
+	if !reflect.DeepEqual(firstRound, [][2]int{{1, 0}, {4, 5}, {2, 0}, {3, 0}}) {
+		t.Errorf("Unexpected first round: %v", firstRound)
+	}
+
+	for n := 2; n <= 20; n++ {
+		champion, eliminated, _ := simulateBracket(t, planSingleElimination(n), func(a, b int) int {
+			if a < b {
+				return a
+			}
+			return b
+		})
+		if champion != 1 {
+			t.Errorf("Expected the top seed to win with %d players, got %d", n, champion)
+		}
+		if len(eliminated) != n-1 {
+			t.Errorf("Expected %d players eliminated with %d players, got %d", n-1, n, len(eliminated))
+		}
+	}
+}
+
+func TestPlanDoubleElimination(t *testing.T) {
+	random := rand.New(rand.NewSource(41))
+	for n := 2; n <= 20; n++ {
+		for run := 0; run < 10; run++ {
+			champion, eliminated, losses := simulateBracket(t, planDoubleElimination(n), func(a, b int) int {
+				if random.Intn(2) == 0 {
+					return a
+				}
+				return b
+			})
+			if champion == 0 {
+				t.Fatalf("Expected a champion with %d players", n)
+			}
+			if _, out := eliminated[champion]; out {
+				t.Errorf("Champion %d was eliminated with %d players", champion, n)
+			}
+			if len(eliminated) != n-1 {
+				t.Errorf("Expected %d players eliminated with %d players, got %d", n-1, n, len(eliminated))
+			}
+			for seed, lost := range losses {
+				if lost > 2 {
+					t.Errorf("Seed %d lost %d matches with %d players", seed, lost, n)
+				}
+			}
+		}
+	}
+}
+
+func TestPlanRoundRobin(t *testing.T) {
+	for _, n := range []int{2, 5, 8} {
+		plan := planRoundRobin(n)
+		met := map[[2]int]int{}
+		perRound := map[[2]int]int{}
+		for _, m := range plan {
+			a, b := m.seeds[0], m.seeds[1]
+			if a > b {
+				a, b = b, a
+			}
+			met[[2]int{a, b}]++
+			perRound[[2]int{m.round, m.seeds[0]}]++
+			perRound[[2]int{m.round, m.seeds[1]}]++
+		}
+		if len(met) != n*(n-1)/2 || len(plan) != n*(n-1)/2 {
+			t.Errorf("Expected every pair of %d players to meet once, got %d matches for %d pairs", n, len(plan), len(met))
+		}
+		for key, count := range perRound {
+			if count > 1 {
+				t.Errorf("Seed %d plays %d times in round %d", key[1], count, key[0])
+			}
+		}
+	}
+}
+
+func TestSwissPairings(t *testing.T) {
+	standings := []swissStanding{
+		{seed: 1, points: 1, opposed: map[int]bool{4: true}},
+		{seed: 2, points: 1, hadBye: true, opposed: map[int]bool{}},
+		{seed: 3, points: 0, opposed: map[int]bool{5: true}},
+		{seed: 4, points: 0, opposed: map[int]bool{1: true}},
+		{seed: 5, points: 1, opposed: map[int]bool{3: true}},
+	}
+
+	var fresh []swissStanding
+	for seed := 1; seed <= 5; seed++ {
+		fresh = append(fresh, swissStanding{seed: seed, opposed: map[int]bool{}})
+	}
+	pairs, bye := swissPairings(fresh, 1)
+	if bye != 5 || !reflect.DeepEqual(pairs, [][2]int{{1, 3}, {2, 4}}) {
+		t.Errorf("Unexpected first round: %v, bye %d", pairs, bye)
+	}
+
+	// 5 and 3 have met, so the top three cannot simply pair down
+	pairs, bye = swissPairings(standings, 2)
+	if bye != 4 {
+		t.Errorf("Expected the lowest player without a bye to get it, got %d", bye)
+	}
+	for _, pair := range pairs {
+		for _, s := range standings {
+			if s.seed == pair[0] && s.opposed[pair[1]] {
+				t.Errorf("Rematch paired: %v", pair)
+			}
+		}
+	}
+	if !reflect.DeepEqual(pairs, [][2]int{{1, 5}, {2, 3}}) {
+		t.Errorf("Unexpected second round: %v", pairs)
+	}
+
+	if got := swissRoundsFor(9); got != 4 {
+		t.Errorf("Expected 4 rounds for 9 players, got %d", got)
+	}
+}
+
+func TestSplitPrizePool(t *testing.T) {
+	tests := []struct {
+		name   string
+		pool   int
+		split  []int
+		places []int
+		want   []int
+	}{
+		{"Top three", 100, []int{50, 30, 20}, []int{1, 2, 3, 4}, []int{50, 30, 20, 0}},
+		{"Shared third place", 100, []int{50, 30, 20}, []int{1, 2, 3, 3}, []int{50, 30, 10, 10}},
+		{"Rounding goes to the winner", 10, []int{50, 30, 20}, []int{3, 1, 2, 3}, []int{1, 5, 3, 1}},
+		{"Unfilled places go to the winner", 100, []int{50, 30, 20}, []int{2, 1}, []int{30, 70}},
+		{"Empty pool", 0, []int{100}, []int{1, 2}, []int{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitPrizePool(tt.pool, tt.split, tt.places); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
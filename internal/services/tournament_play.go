@@ -0,0 +1,726 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"rockpaperscissors/internal/models"
+	"time"
+)
+
+// tournamentMatchRow is a match along with the IDs and links the bracket
+// view leaves out. A player ID of 0 with the slot ready means nobody comes
+// through to that slot.
+type tournamentMatchRow struct {
+	models.TournamentMatch
+	tournamentID int
+	playerIDs    [2]int
+	ready        [2]bool
+	winnerID     int
+	winnerTo     *tournamentMatchLink
+	loserTo      *tournamentMatchLink
+	eliminates   int
+}
+
+// tournamentMatchLink is the slot of a later match a player moves on to
+type tournamentMatchLink struct {
+	matchID int
+	slot    int
+}
+
+// getMatches loads the matches matching where, in bracket order
+func (t *TournamentService) getMatches(exec dbExecutor, where string, args ...interface{}) ([]*tournamentMatchRow, error) {
+	query := `SELECT m.id, m.tournament_id, m.bracket, m.round, m.position, m.player1_id, p1.username, m.player2_id, p2.username,
+	                 m.player1_ready, m.player2_ready, m.player1_wins, m.player2_wins, m.winner_id, wu.username, m.status,
+	                 m.winner_next_match_id, m.winner_next_slot, m.loser_next_match_id, m.loser_next_slot, m.eliminates,
+	                 m.deadline, m.completed_at
+	          FROM tournament_matches m
+	          LEFT JOIN users p1 ON p1.id = m.player1_id
+	          LEFT JOIN users p2 ON p2.id = m.player2_id
+	          LEFT JOIN users wu ON wu.id = m.winner_id
+	          WHERE ` + where + `
+	          ORDER BY CASE m.bracket WHEN 'losers' THEN 1 WHEN 'grand_final' THEN 2 ELSE 0 END, m.round, m.position`
+	rows, err := exec.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tournament matches: %v", err)
+	}
+	defer rows.Close()
+
+	var matches []*tournamentMatchRow
+	for rows.Next() {
+		var m tournamentMatchRow
+		var bracket, status string
+		var player1ID, player2ID, winnerID, winnerNext, winnerSlot, loserNext, loserSlot sql.NullInt64
+		var player1, player2, winner sql.NullString
+		var deadline, completedAt sql.NullTime
+		err := rows.Scan(&m.ID, &m.tournamentID, &bracket, &m.Round, &m.Position, &player1ID, &player1, &player2ID, &player2,
+			&m.ready[0], &m.ready[1], &m.Player1Wins, &m.Player2Wins, &winnerID, &winner, &status,
+			&winnerNext, &winnerSlot, &loserNext, &loserSlot, &m.eliminates,
+			&deadline, &completedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tournament match: %v", err)
+		}
+		m.Bracket = models.TournamentBracket(bracket)
+		m.Status = models.TournamentMatchStatus(status)
+		m.playerIDs = [2]int{int(player1ID.Int64), int(player2ID.Int64)}
+		m.Player1 = player1.String
+		m.Player2 = player2.String
+		m.winnerID = int(winnerID.Int64)
+		m.Winner = winner.String
+		if winnerNext.Valid {
+			m.winnerTo = &tournamentMatchLink{matchID: int(winnerNext.Int64), slot: int(winnerSlot.Int64)}
+		}
+		if loserNext.Valid {
+			m.loserTo = &tournamentMatchLink{matchID: int(loserNext.Int64), slot: int(loserSlot.Int64)}
+		}
+		if deadline.Valid {
+			m.Deadline = &deadline.Time
+		}
+		if completedAt.Valid {
+			m.CompletedAt = &completedAt.Time
+		}
+		m.Games = []models.TournamentGame{}
+		matches = append(matches, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tournament matches: %v", err)
+	}
+	return matches, nil
+}
+
+// getMatch loads a single match
+func (t *TournamentService) getMatch(exec dbExecutor, matchID int) (*tournamentMatchRow, error) {
+	matches, err := t.getMatches(exec, `m.id = ?`, matchID)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("tournament match %d not found", matchID)
+	}
+	return matches[0], nil
+}
+
+// attachGames loads the games of a tournament's matches. Choices stay secret
+// until both players have moved.
+func (t *TournamentService) attachGames(exec dbExecutor, tournamentID int, matches []*tournamentMatchRow) error {
+	byID := make(map[int]*tournamentMatchRow, len(matches))
+	for _, m := range matches {
+		byID[m.ID] = m
+	}
+
+	query := `SELECT g.match_id, g.game, g.player1_choice, g.player2_choice, g.winner
+	          FROM tournament_games g
+	          JOIN tournament_matches m ON m.id = g.match_id
+	          WHERE m.tournament_id = ?
+	          ORDER BY g.match_id, g.game`
+	rows, err := exec.Query(query, tournamentID)
+	if err != nil {
+		return fmt.Errorf("failed to query tournament games: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var matchID int
+		var game models.TournamentGame
+		var player1Choice, player2Choice, winner sql.NullString
+		if err := rows.Scan(&matchID, &game.Number, &player1Choice, &player2Choice, &winner); err != nil {
+			return fmt.Errorf("failed to scan tournament game: %v", err)
+		}
+		game.Player1Moved = player1Choice.Valid
+		game.Player2Moved = player2Choice.Valid
+		game.Winner = winner.String
+		if winner.Valid {
+			game.Player1Choice = models.Choice(player1Choice.String)
+			game.Player2Choice = models.Choice(player2Choice.String)
+		}
+		if m, ok := byID[matchID]; ok {
+			m.Games = append(m.Games, game)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating tournament games: %v", err)
+	}
+	return nil
+}
+
+// fillSlot puts a player, or nobody when userID is 0, into a slot of a
+// match and starts the match once both slots are filled
+func (t *TournamentService) fillSlot(tx *sql.Tx, tournament *tournamentRow, matchID, slot, userID int) error {
+	var player interface{}
+	if userID != 0 {
+		player = userID
+	}
+	column := fmt.Sprintf("player%d", slot+1)
+	updateQuery := `UPDATE tournament_matches SET ` + column + `_id = ?, ` + column + `_ready = 1 WHERE id = ?`
+	if _, err := tx.Exec(updateQuery, player, matchID); err != nil {
+		return fmt.Errorf("failed to fill tournament match: %v", err)
+	}
+	return t.startMatch(tx, tournament, matchID)
+}
+
+// startMatch starts a waiting match whose slots are both filled. A match
+// with at most one player is a bye, and a player who has withdrawn forfeits
+// straight away.
+func (t *TournamentService) startMatch(tx *sql.Tx, tournament *tournamentRow, matchID int) error {
+	m, err := t.getMatch(tx, matchID)
+	if err != nil {
+		return err
+	}
+	if m.Status != models.MatchWaiting || !m.ready[0] || !m.ready[1] {
+		return nil
+	}
+
+	p1, p2 := m.playerIDs[0], m.playerIDs[1]
+	switch {
+	case p1 == 0 || p2 == 0:
+		return t.completeMatch(tx, tournament, m, p1+p2, models.MatchBye)
+	default:
+		out1, err := t.isOut(tx, tournament.ID, p1)
+		if err != nil {
+			return err
+		}
+		out2, err := t.isOut(tx, tournament.ID, p2)
+		if err != nil {
+			return err
+		}
+		switch {
+		case out1 && !out2:
+			return t.completeMatch(tx, tournament, m, p2, models.MatchForfeit)
+		case out1 || out2:
+			return t.completeMatch(tx, tournament, m, p1, models.MatchForfeit)
+		}
+	}
+
+	deadline := t.now().UTC().Add(time.Duration(tournament.MoveTimeoutMinutes) * time.Minute).Format(sqliteTimeFormat)
+	updateQuery := `UPDATE tournament_matches SET status = 'active', deadline = ? WHERE id = ?`
+	if _, err := tx.Exec(updateQuery, deadline, m.ID); err != nil {
+		return fmt.Errorf("failed to start tournament match: %v", err)
+	}
+	return nil
+}
+
+// isOut reports whether a player has withdrawn from a tournament or is no
+// longer registered for it
+func (t *TournamentService) isOut(exec dbExecutor, tournamentID, userID int) (bool, error) {
+	var withdrawn bool
+	query := `SELECT withdrawn FROM tournament_players WHERE tournament_id = ? AND user_id = ?`
+	err := exec.QueryRow(query, tournamentID, userID).Scan(&withdrawn)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check tournament player: %v", err)
+	}
+	return withdrawn, nil
+}
+
+// SubmitMove records a player's choice in their current match of a
+// tournament. Once both players have moved the game is resolved, and the
+// match once either player has won enough games. Tied games are replayed.
+func (t *TournamentService) SubmitMove(tournamentID int, req models.TournamentMoveRequest) (*models.TournamentMatch, error) {
+	if !req.PlayerChoice.IsValid() {
+		return nil, fmt.Errorf("invalid choice: %s", req.PlayerChoice)
+	}
+	if err := t.Advance(); err != nil {
+		return nil, err
+	}
+
+	var matchID int
+	err := runInTx(t.db, func(tx *sql.Tx) error {
+		user, err := t.userService.getUser(tx, req.Username)
+		if err != nil {
+			return err
+		}
+		if err := checkCanPlay(user); err != nil {
+			return err
+		}
+		tournament, err := t.getTournament(tx, tournamentID)
+		if err != nil {
+			return err
+		}
+		if tournament.Status != models.TournamentInProgress {
+			return fmt.Errorf("tournament %d is not in play: it is %s", tournamentID, tournament.Status)
+		}
+
+		matches, err := t.getMatches(tx, `m.tournament_id = ? AND m.status = 'active' AND (m.player1_id = ? OR m.player2_id = ?)`,
+			tournamentID, user.ID, user.ID)
+		if err != nil {
+			return err
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("'%s' has no match to play in tournament %d", req.Username, tournamentID)
+		}
+		m := matches[0]
+		matchID = m.ID
+
+		column := "player1_choice"
+		if m.playerIDs[1] == user.ID {
+			column = "player2_choice"
+		}
+
+		// the current game is the last one, unless it has been resolved
+		var game int
+		var player1Choice, player2Choice, winner sql.NullString
+		query := `SELECT game, player1_choice, player2_choice, winner FROM tournament_games
+		          WHERE match_id = ? ORDER BY game DESC LIMIT 1`
+		err = tx.QueryRow(query, m.ID).Scan(&game, &player1Choice, &player2Choice, &winner)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to get tournament game: %v", err)
+		}
+		if err == nil && !winner.Valid {
+			if (column == "player1_choice" && player1Choice.Valid) || (column == "player2_choice" && player2Choice.Valid) {
+				return fmt.Errorf("already moved in game %d of tournament match %d", game, m.ID)
+			}
+		} else {
+			game++
+			insertQuery := `INSERT INTO tournament_games (match_id, game) VALUES (?, ?)`
+			if _, err := tx.Exec(insertQuery, m.ID, game); err != nil {
+				return fmt.Errorf("failed to start tournament game: %v", err)
+			}
+		}
+
+		updateQuery := `UPDATE tournament_games SET ` + column + ` = ? WHERE match_id = ? AND game = ?`
+		if _, err := tx.Exec(updateQuery, string(req.PlayerChoice), m.ID, game); err != nil {
+			return fmt.Errorf("failed to record move: %v", err)
+		}
+
+		return t.resolveGame(tx, tournament, m, game)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := t.getMatches(t.db, `m.id = ?`, matchID)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.attachGames(t.db, tournamentID, matches); err != nil {
+		return nil, err
+	}
+	return &matches[0].TournamentMatch, nil
+}
+
+// resolveGame settles a game once both players have moved, and the match
+// once a player has won enough games. Each resolved game gives the players
+// a fresh deadline for the next one.
+func (t *TournamentService) resolveGame(tx *sql.Tx, tournament *tournamentRow, m *tournamentMatchRow, game int) error {
+	var player1Choice, player2Choice sql.NullString
+	query := `SELECT player1_choice, player2_choice FROM tournament_games WHERE match_id = ? AND game = ?`
+	if err := tx.QueryRow(query, m.ID, game).Scan(&player1Choice, &player2Choice); err != nil {
+		return fmt.Errorf("failed to get tournament game: %v", err)
+	}
+	if !player1Choice.Valid || !player2Choice.Valid {
+		return nil
+	}
+
+	choice1, choice2 := models.Choice(player1Choice.String), models.Choice(player2Choice.String)
+	result := t.gameLogic.DetermineWinner(choice1, choice2)
+	if err := t.gameService.saveMatchGames(tx, m.playerIDs[0], m.playerIDs[1], choice1, choice2, result); err != nil {
+		return err
+	}
+
+	winner := "tie"
+	switch result {
+	case models.Win:
+		winner = "player1"
+		m.Player1Wins++
+	case models.Lose:
+		winner = "player2"
+		m.Player2Wins++
+	}
+
+	updateGame := `UPDATE tournament_games SET winner = ?, resolved_at = CURRENT_TIMESTAMP WHERE match_id = ? AND game = ?`
+	if _, err := tx.Exec(updateGame, winner, m.ID, game); err != nil {
+		return fmt.Errorf("failed to resolve tournament game: %v", err)
+	}
+	deadline := t.now().UTC().Add(time.Duration(tournament.MoveTimeoutMinutes) * time.Minute).Format(sqliteTimeFormat)
+	updateScore := `UPDATE tournament_matches SET player1_wins = ?, player2_wins = ?, deadline = ? WHERE id = ?`
+	if _, err := tx.Exec(updateScore, m.Player1Wins, m.Player2Wins, deadline, m.ID); err != nil {
+		return fmt.Errorf("failed to update tournament match score: %v", err)
+	}
+
+	switch {
+	case m.Player1Wins >= winsNeeded(tournament.BestOf):
+		return t.completeMatch(tx, tournament, m, m.playerIDs[0], models.MatchCompleted)
+	case m.Player2Wins >= winsNeeded(tournament.BestOf):
+		return t.completeMatch(tx, tournament, m, m.playerIDs[1], models.MatchCompleted)
+	}
+	return nil
+}
+
+// completeMatch records the result of a match and moves its players on: to
+// their next matches in a bracket, or to the next round of a round robin or
+// Swiss tournament. The tournament finishes with its last match.
+func (t *TournamentService) completeMatch(tx *sql.Tx, tournament *tournamentRow, m *tournamentMatchRow, winnerID int, status models.TournamentMatchStatus) error {
+	loserID := m.playerIDs[0]
+	if loserID == winnerID {
+		loserID = m.playerIDs[1]
+	}
+
+	var winner interface{}
+	if winnerID != 0 {
+		winner = winnerID
+	}
+	updateQuery := `UPDATE tournament_matches
+	                SET status = ?, winner_id = ?, deadline = NULL, completed_at = CURRENT_TIMESTAMP
+	                WHERE id = ?`
+	if _, err := tx.Exec(updateQuery, string(status), winner, m.ID); err != nil {
+		return fmt.Errorf("failed to complete tournament match: %v", err)
+	}
+
+	if status != models.MatchBye {
+		winnerGames, loserGames := m.Player1Wins, m.Player2Wins
+		if winnerID == m.playerIDs[1] {
+			winnerGames, loserGames = loserGames, winnerGames
+		}
+		recordQuery := `UPDATE tournament_players
+		                SET points = points + ?, match_wins = match_wins + ?, match_losses = match_losses + ?,
+		                    game_wins = game_wins + ?, game_losses = game_losses + ?
+		                WHERE tournament_id = ? AND user_id = ?`
+		if _, err := tx.Exec(recordQuery, 1, 1, 0, winnerGames, loserGames, tournament.ID, winnerID); err != nil {
+			return fmt.Errorf("failed to update tournament record: %v", err)
+		}
+		if _, err := tx.Exec(recordQuery, 0, 0, 1, loserGames, winnerGames, tournament.ID, loserID); err != nil {
+			return fmt.Errorf("failed to update tournament record: %v", err)
+		}
+	}
+	if m.eliminates > 0 && loserID != 0 {
+		eliminateQuery := `UPDATE tournament_players SET eliminated = 1, elimination_rank = ? WHERE tournament_id = ? AND user_id = ?`
+		if _, err := tx.Exec(eliminateQuery, m.eliminates, tournament.ID, loserID); err != nil {
+			return fmt.Errorf("failed to eliminate tournament player: %v", err)
+		}
+	}
+
+	if m.winnerTo != nil {
+		if err := t.fillSlot(tx, tournament, m.winnerTo.matchID, m.winnerTo.slot, winnerID); err != nil {
+			return err
+		}
+	}
+	if m.loserTo != nil {
+		if err := t.fillSlot(tx, tournament, m.loserTo.matchID, m.loserTo.slot, loserID); err != nil {
+			return err
+		}
+	}
+
+	if tournament.Format.IsElimination() {
+		if m.winnerTo == nil {
+			return t.finish(tx, tournament, winnerID)
+		}
+		return nil
+	}
+	return t.advanceRound(tx, tournament)
+}
+
+// advanceRound opens the next round of a round robin or Swiss tournament
+// once every match of the current one is over, and finishes the tournament
+// after the last round
+func (t *TournamentService) advanceRound(tx *sql.Tx, tournament *tournamentRow) error {
+	for tournament.Status == models.TournamentInProgress {
+		var unfinished int
+		query := `SELECT COUNT(*) FROM tournament_matches WHERE tournament_id = ? AND round = ? AND status IN ('waiting', 'active')`
+		if err := tx.QueryRow(query, tournament.ID, tournament.CurrentRound).Scan(&unfinished); err != nil {
+			return fmt.Errorf("failed to check tournament round: %v", err)
+		}
+		if unfinished > 0 {
+			return nil
+		}
+
+		rounds := tournament.SwissRounds
+		if tournament.Format == models.FormatRoundRobin {
+			if err := tx.QueryRow(`SELECT MAX(round) FROM tournament_matches WHERE tournament_id = ?`, tournament.ID).Scan(&rounds); err != nil {
+				return fmt.Errorf("failed to count tournament rounds: %v", err)
+			}
+		}
+		if tournament.CurrentRound >= rounds {
+			return t.finish(tx, tournament, 0)
+		}
+
+		tournament.CurrentRound++
+		if _, err := tx.Exec(`UPDATE tournaments SET current_round = ? WHERE id = ?`, tournament.CurrentRound, tournament.ID); err != nil {
+			return fmt.Errorf("failed to advance tournament round: %v", err)
+		}
+		if tournament.Format == models.FormatSwiss {
+			if err := t.pairSwissRound(tx, tournament); err != nil {
+				return err
+			}
+		}
+
+		ids, err := queryIDs(tx, `SELECT id FROM tournament_matches WHERE tournament_id = ? AND round = ? ORDER BY position`,
+			tournament.ID, tournament.CurrentRound)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := t.startMatch(tx, tournament, id); err != nil {
+				return err
+			}
+		}
+		if tournament.Status != models.TournamentInProgress {
+			return nil
+		}
+	}
+	return nil
+}
+
+// pairSwissRound pairs the current Swiss round among the players still in
+// the tournament. A bye is recorded as a match and counts as a win.
+func (t *TournamentService) pairSwissRound(tx *sql.Tx, tournament *tournamentRow) error {
+	players, err := t.getPlayers(tx, tournament.ID)
+	if err != nil {
+		return err
+	}
+
+	opposed := map[int]map[int]bool{}
+	query := `SELECT player1_id, player2_id FROM tournament_matches
+	          WHERE tournament_id = ? AND player1_id IS NOT NULL AND player2_id IS NOT NULL`
+	rows, err := tx.Query(query, tournament.ID)
+	if err != nil {
+		return fmt.Errorf("failed to query tournament opponents: %v", err)
+	}
+	for rows.Next() {
+		var a, b int
+		if err := rows.Scan(&a, &b); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan tournament opponents: %v", err)
+		}
+		for _, pair := range [][2]int{{a, b}, {b, a}} {
+			if opposed[pair[0]] == nil {
+				opposed[pair[0]] = map[int]bool{}
+			}
+			opposed[pair[0]][pair[1]] = true
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating tournament opponents: %v", err)
+	}
+
+	bySeed := map[int]*tournamentPlayerRow{}
+	var standings []swissStanding
+	for _, p := range players {
+		if p.Withdrawn {
+			continue
+		}
+		bySeed[p.Seed] = p
+		standing := swissStanding{seed: p.Seed, points: p.Points, hadBye: p.hadBye, opposed: map[int]bool{}}
+		for _, other := range players {
+			if opposed[p.userID][other.userID] {
+				standing.opposed[other.Seed] = true
+			}
+		}
+		standings = append(standings, standing)
+	}
+
+	pairs, bye := swissPairings(standings, tournament.CurrentRound)
+	insertQuery := `INSERT INTO tournament_matches (tournament_id, bracket, round, position, player1_id, player2_id, player1_ready, player2_ready)
+	                VALUES (?, 'main', ?, ?, ?, ?, 1, 1)`
+	for i, pair := range pairs {
+		if _, err := tx.Exec(insertQuery, tournament.ID, tournament.CurrentRound, i+1, bySeed[pair[0]].userID, bySeed[pair[1]].userID); err != nil {
+			return fmt.Errorf("failed to create tournament match: %v", err)
+		}
+	}
+	if bye == 0 {
+		return nil
+	}
+
+	byeQuery := `INSERT INTO tournament_matches (tournament_id, bracket, round, position, player1_id, player1_ready, player2_ready,
+	                                             winner_id, status, completed_at)
+	             VALUES (?, 'main', ?, ?, ?, 1, 1, ?, 'bye', CURRENT_TIMESTAMP)`
+	byeID := bySeed[bye].userID
+	if _, err := tx.Exec(byeQuery, tournament.ID, tournament.CurrentRound, len(pairs)+1, byeID, byeID); err != nil {
+		return fmt.Errorf("failed to record tournament bye: %v", err)
+	}
+	recordQuery := `UPDATE tournament_players SET points = points + 1, had_bye = 1 WHERE tournament_id = ? AND user_id = ?`
+	if _, err := tx.Exec(recordQuery, tournament.ID, byeID); err != nil {
+		return fmt.Errorf("failed to record tournament bye: %v", err)
+	}
+	return nil
+}
+
+// withdrawPlayer marks a player as withdrawn and forfeits every match of
+// theirs whose opponent is known, including round robin matches of later
+// rounds
+func (t *TournamentService) withdrawPlayer(tx *sql.Tx, tournament *tournamentRow, userID int) error {
+	updateQuery := `UPDATE tournament_players SET withdrawn = 1 WHERE tournament_id = ? AND user_id = ?`
+	if _, err := tx.Exec(updateQuery, tournament.ID, userID); err != nil {
+		return fmt.Errorf("failed to withdraw from tournament: %v", err)
+	}
+
+	query := `SELECT id FROM tournament_matches
+	          WHERE tournament_id = ? AND status IN ('waiting', 'active') AND player1_ready = 1 AND player2_ready = 1
+	            AND (player1_id = ? OR player2_id = ?)
+	          ORDER BY round, position`
+	ids, err := queryIDs(tx, query, tournament.ID, userID, userID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if tournament.Status != models.TournamentInProgress {
+			return nil
+		}
+		m, err := t.getMatch(tx, id)
+		if err != nil {
+			return err
+		}
+		if m.Status != models.MatchWaiting && m.Status != models.MatchActive {
+			continue
+		}
+		opponent := m.playerIDs[0]
+		if opponent == userID {
+			opponent = m.playerIDs[1]
+		}
+		if err := t.completeMatch(tx, tournament, m, opponent, models.MatchForfeit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ForfeitOverdueMatches decides every match whose players ran out of time
+// on their current game. A player who moved beats one who did not;
+// otherwise the player ahead on games wins, and the higher seed on a level
+// score. It returns how many matches were forfeited.
+func (t *TournamentService) ForfeitOverdueMatches() (int, error) {
+	forfeited := 0
+
+	err := runInTx(t.db, func(tx *sql.Tx) error {
+		now := t.now().UTC().Format(sqliteTimeFormat)
+		query := `SELECT m.id FROM tournament_matches m
+		          JOIN tournaments t ON t.id = m.tournament_id
+		          WHERE t.status = 'in_progress' AND m.status = 'active' AND m.deadline <= ?
+		          ORDER BY m.deadline, m.id`
+		ids, err := queryIDs(tx, query, now)
+		if err != nil {
+			return err
+		}
+
+		tournaments := map[int]*tournamentRow{}
+		for _, id := range ids {
+			m, err := t.getMatch(tx, id)
+			if err != nil {
+				return err
+			}
+			tournament, ok := tournaments[m.tournamentID]
+			if !ok {
+				if tournament, err = t.getTournament(tx, m.tournamentID); err != nil {
+					return err
+				}
+				tournaments[m.tournamentID] = tournament
+			}
+			// an earlier forfeit may have finished the tournament
+			if m.Status != models.MatchActive || tournament.Status != models.TournamentInProgress {
+				continue
+			}
+
+			winnerID, err := t.timeoutWinner(tx, tournament, m)
+			if err != nil {
+				return err
+			}
+			if err := t.completeMatch(tx, tournament, m, winnerID, models.MatchForfeit); err != nil {
+				return err
+			}
+			forfeited++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return forfeited, nil
+}
+
+// timeoutWinner picks the winner of a match whose deadline has passed
+func (t *TournamentService) timeoutWinner(tx *sql.Tx, tournament *tournamentRow, m *tournamentMatchRow) (int, error) {
+	var player1Choice, player2Choice, winner sql.NullString
+	query := `SELECT player1_choice, player2_choice, winner FROM tournament_games
+	          WHERE match_id = ? ORDER BY game DESC LIMIT 1`
+	err := tx.QueryRow(query, m.ID).Scan(&player1Choice, &player2Choice, &winner)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to get tournament game: %v", err)
+	}
+	if err == nil && !winner.Valid && player1Choice.Valid != player2Choice.Valid {
+		if player1Choice.Valid {
+			return m.playerIDs[0], nil
+		}
+		return m.playerIDs[1], nil
+	}
+
+	switch {
+	case m.Player1Wins > m.Player2Wins:
+		return m.playerIDs[0], nil
+	case m.Player2Wins > m.Player1Wins:
+		return m.playerIDs[1], nil
+	}
+
+	var seed1, seed2 int
+	seedQuery := `SELECT COALESCE(MAX(CASE WHEN user_id = ? THEN seed END), 0), COALESCE(MAX(CASE WHEN user_id = ? THEN seed END), 0)
+	              FROM tournament_players WHERE tournament_id = ?`
+	if err := tx.QueryRow(seedQuery, m.playerIDs[0], m.playerIDs[1], tournament.ID).Scan(&seed1, &seed2); err != nil {
+		return 0, fmt.Errorf("failed to get tournament seeds: %v", err)
+	}
+	if seed2 != 0 && (seed1 == 0 || seed2 < seed1) {
+		return m.playerIDs[1], nil
+	}
+	return m.playerIDs[0], nil
+}
+
+// finish places every player, pays out the prize pool and closes the
+// tournament. championID is the winner of a knockout's final and 0 for
+// round robin and Swiss, where the standings decide.
+func (t *TournamentService) finish(tx *sql.Tx, tournament *tournamentRow, championID int) error {
+	players, err := t.rankPlayers(tx, tournament)
+	if err != nil {
+		return err
+	}
+	if len(players) == 0 {
+		return t.cancel(tx, tournament, fmt.Sprintf("Tournament #%d cancelled: no players left", tournament.ID))
+	}
+
+	places := make([]int, len(players))
+	if tournament.Format.IsElimination() {
+		// the champion outlasted everyone; everyone else shares a place
+		// with those knocked out in the same round
+		rank := func(p *tournamentPlayerRow) int {
+			if p.userID == championID {
+				return 1 << 30
+			}
+			return p.eliminationRank
+		}
+		for i, p := range players {
+			places[i] = 1
+			for _, other := range players {
+				if rank(other) > rank(p) {
+					places[i]++
+				}
+			}
+		}
+	} else {
+		for i := range players {
+			places[i] = i + 1
+		}
+		championID = players[0].userID
+	}
+
+	prizes := splitPrizePool(tournament.PrizePool, tournament.PrizeSplit, places)
+	for i, p := range players {
+		updateQuery := `UPDATE tournament_players SET place = ?, prize = ? WHERE tournament_id = ? AND user_id = ?`
+		if _, err := tx.Exec(updateQuery, places[i], prizes[i], tournament.ID, p.userID); err != nil {
+			return fmt.Errorf("failed to place tournament player: %v", err)
+		}
+		reason := fmt.Sprintf("Tournament #%d place %d", tournament.ID, places[i])
+		if _, err := t.ledger.Post(tx, p.userID, models.TxTournament, prizes[i], tournamentReference(tournament.ID), reason); err != nil {
+			return err
+		}
+	}
+
+	var winner interface{}
+	if championID != 0 {
+		winner = championID
+	}
+	completeQuery := `UPDATE tournaments SET status = 'completed', winner_id = ?, completed_at = CURRENT_TIMESTAMP WHERE id = ?`
+	if _, err := tx.Exec(completeQuery, winner, tournament.ID); err != nil {
+		return fmt.Errorf("failed to complete tournament: %v", err)
+	}
+	tournament.Status = models.TournamentCompleted
+	return nil
+}
@@ -0,0 +1,813 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// maxTournamentBestOf caps the length of a tournament match
+	maxTournamentBestOf = 9
+
+	// defaultTournamentPlayers is how many players a tournament takes when
+	// the organizer does not say
+	defaultTournamentPlayers = 16
+
+	// maxTournamentPlayers caps the size of any tournament
+	maxTournamentPlayers = 128
+
+	// maxRoundRobinPlayers caps round robins, where everyone plays everyone
+	maxRoundRobinPlayers = 32
+
+	// defaultMoveTimeout is how long a player has to make each throw before
+	// forfeiting the match
+	defaultMoveTimeout = time.Hour
+
+	// maxMoveTimeout caps the time allowed for each throw
+	maxMoveTimeout = 7 * 24 * time.Hour
+
+	// ratingSeedOrder ranks players by win rate, pulled towards the one in
+	// three a random player wins by as if they had played ten games more, so
+	// a lucky first game does not make a top seed
+	ratingSeedOrder = `(u.games_won + 10.0 / 3) / (u.games_played + 10.0)`
+)
+
+// defaultPrizeSplit is the share of the prize pool for 1st, 2nd and 3rd place
+var defaultPrizeSplit = []int{50, 30, 20}
+
+// TournamentService runs tournaments: registration, brackets, matches and
+// prizes. Entry fees are held in the prize pool until it is paid out or the
+// tournament is cancelled.
+type TournamentService struct {
+	db          *sql.DB
+	gameLogic   *GameLogicService
+	gameService *GameService
+	userService *UserService
+	ledger      *LedgerService
+	now         func() time.Time
+}
+
+// NewTournamentService creates a new tournament service
+func NewTournamentService(db *database.DB) *TournamentService {
+	return &TournamentService{
+		db:          db.DB,
+		gameLogic:   NewGameLogicService(),
+		gameService: NewGameService(db),
+		userService: NewUserService(db),
+		ledger:      NewLedgerService(db),
+		now:         time.Now,
+	}
+}
+
+// tournamentRow is a tournament along with its winner's ID
+type tournamentRow struct {
+	models.Tournament
+	winnerID int
+}
+
+// tournamentPlayerRow is a registered player along with the fields that are
+// not shown in standings
+type tournamentPlayerRow struct {
+	models.TournamentPlayer
+	userID          int
+	hadBye          bool
+	eliminationRank int
+}
+
+// tournamentReference is the ledger reference of every entry for a tournament
+func tournamentReference(tournamentID int) string {
+	return fmt.Sprintf("tournament:%d", tournamentID)
+}
+
+// CreateTournament sets up a tournament that is open for registration until
+// req.RegistrationClosesAt, when it starts on its own
+func (t *TournamentService) CreateTournament(actor string, req models.CreateTournamentRequest) (*models.Tournament, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("invalid name: must not be blank")
+	}
+	if !req.Format.IsValid() {
+		return nil, fmt.Errorf("invalid format: %s", req.Format)
+	}
+	seeding := req.Seeding
+	if seeding == "" {
+		seeding = models.SeedByCoins
+	}
+	if !seeding.IsValid() {
+		return nil, fmt.Errorf("invalid seeding: %s", req.Seeding)
+	}
+
+	bestOf := req.BestOf
+	if bestOf == 0 {
+		bestOf = 1
+	}
+	if bestOf < 1 || bestOf > maxTournamentBestOf || bestOf%2 == 0 {
+		return nil, fmt.Errorf("invalid best_of %d: must be an odd number between 1 and %d", req.BestOf, maxTournamentBestOf)
+	}
+
+	maxPlayers := req.MaxPlayers
+	if maxPlayers == 0 {
+		maxPlayers = defaultTournamentPlayers
+	}
+	limit := maxTournamentPlayers
+	if req.Format == models.FormatRoundRobin {
+		limit = maxRoundRobinPlayers
+	}
+	if maxPlayers < 2 || maxPlayers > limit {
+		return nil, fmt.Errorf("invalid max_players %d: must be between 2 and %d", req.MaxPlayers, limit)
+	}
+
+	if req.EntryFee < 0 || req.GuaranteedPrize < 0 {
+		return nil, fmt.Errorf("invalid prize: entry_fee and guaranteed_prize must not be negative")
+	}
+	split := req.PrizeSplit
+	if len(split) == 0 {
+		split = defaultPrizeSplit
+	}
+	total := 0
+	for _, percent := range split {
+		if percent < 0 {
+			return nil, fmt.Errorf("invalid prize_split: percentages must not be negative")
+		}
+		total += percent
+	}
+	if total != 100 || len(split) > maxPlayers {
+		return nil, fmt.Errorf("invalid prize_split: must add up to 100 over at most max_players places")
+	}
+
+	if req.SwissRounds != 0 && req.Format != models.FormatSwiss {
+		return nil, fmt.Errorf("invalid swiss_rounds: only Swiss tournaments have a set number of rounds")
+	}
+	if req.SwissRounds < 0 || req.SwissRounds > maxPlayers-1 {
+		return nil, fmt.Errorf("invalid swiss_rounds %d: must be at most %d", req.SwissRounds, maxPlayers-1)
+	}
+
+	timeout := defaultMoveTimeout
+	if req.MoveTimeoutMinutes > 0 {
+		timeout = time.Duration(req.MoveTimeoutMinutes) * time.Minute
+	}
+	if timeout > maxMoveTimeout {
+		return nil, fmt.Errorf("invalid move timeout: players may have at most %s per throw", maxMoveTimeout)
+	}
+	if !req.RegistrationClosesAt.After(t.now()) {
+		return nil, fmt.Errorf("invalid registration_closes_at: must be in the future")
+	}
+
+	splitJSON, err := json.Marshal(split)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode prize split: %v", err)
+	}
+
+	insertQuery := `INSERT INTO tournaments (name, format, seeding, best_of, max_players, entry_fee, guaranteed_prize, prize_pool,
+	                                         prize_split, swiss_rounds, move_timeout_minutes, registration_closes_at, created_by, created_at)
+	                VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
+	result, err := t.db.Exec(insertQuery, name, string(req.Format), string(seeding), bestOf, maxPlayers, req.EntryFee,
+		req.GuaranteedPrize, req.GuaranteedPrize, string(splitJSON), req.SwissRounds, int(timeout/time.Minute),
+		req.RegistrationClosesAt.UTC().Format(sqliteTimeFormat), actor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tournament: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tournament ID: %v", err)
+	}
+
+	return t.GetTournament(int(id))
+}
+
+// getTournament loads a tournament through exec
+func (t *TournamentService) getTournament(exec dbExecutor, tournamentID int) (*tournamentRow, error) {
+	var row tournamentRow
+	var format, status, seeding, split string
+	var winnerID sql.NullInt64
+	var winner sql.NullString
+	var startedAt, completedAt sql.NullTime
+
+	query := `SELECT t.id, t.name, t.format, t.status, t.seeding, t.best_of, t.max_players, t.entry_fee, t.guaranteed_prize,
+	                 t.prize_pool, t.prize_split, t.swiss_rounds, t.move_timeout_minutes, t.current_round, t.winner_id, wu.username,
+	                 t.registration_closes_at, t.created_by, t.created_at, t.started_at, t.completed_at,
+	                 (SELECT COUNT(*) FROM tournament_players tp WHERE tp.tournament_id = t.id)
+	          FROM tournaments t
+	          LEFT JOIN users wu ON wu.id = t.winner_id
+	          WHERE t.id = ?`
+	err := exec.QueryRow(query, tournamentID).Scan(
+		&row.ID, &row.Name, &format, &status, &seeding, &row.BestOf, &row.MaxPlayers, &row.EntryFee, &row.GuaranteedPrize,
+		&row.PrizePool, &split, &row.SwissRounds, &row.MoveTimeoutMinutes, &row.CurrentRound, &winnerID, &winner,
+		&row.RegistrationClosesAt, &row.CreatedBy, &row.CreatedAt, &startedAt, &completedAt,
+		&row.Players,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("tournament %d not found", tournamentID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tournament: %v", err)
+	}
+
+	row.Format = models.TournamentFormat(format)
+	row.Status = models.TournamentStatus(status)
+	row.Seeding = models.TournamentSeeding(seeding)
+	if err := json.Unmarshal([]byte(split), &row.PrizeSplit); err != nil {
+		return nil, fmt.Errorf("failed to decode prize split: %v", err)
+	}
+	row.winnerID = int(winnerID.Int64)
+	row.Winner = winner.String
+	if startedAt.Valid {
+		row.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		row.CompletedAt = &completedAt.Time
+	}
+	return &row, nil
+}
+
+// GetTournament returns a tournament
+func (t *TournamentService) GetTournament(tournamentID int) (*models.Tournament, error) {
+	if err := t.Advance(); err != nil {
+		return nil, err
+	}
+	row, err := t.getTournament(t.db, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	return &row.Tournament, nil
+}
+
+// ListTournaments lists tournaments, newest first, optionally filtered by
+// status
+func (t *TournamentService) ListTournaments(status models.TournamentStatus) ([]models.Tournament, error) {
+	if err := t.Advance(); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id FROM tournaments WHERE (? = '' OR status = ?) ORDER BY id DESC`
+	ids, err := queryIDs(t.db, query, string(status), string(status))
+	if err != nil {
+		return nil, err
+	}
+
+	tournaments := make([]models.Tournament, 0, len(ids))
+	for _, id := range ids {
+		row, err := t.getTournament(t.db, id)
+		if err != nil {
+			return nil, err
+		}
+		tournaments = append(tournaments, row.Tournament)
+	}
+	return tournaments, nil
+}
+
+// getPlayers loads everyone registered for a tournament, by seed once it has
+// started and by registration before that
+func (t *TournamentService) getPlayers(exec dbExecutor, tournamentID int) ([]*tournamentPlayerRow, error) {
+	query := `SELECT tp.user_id, u.username, tp.seed, tp.points, tp.match_wins, tp.match_losses, tp.game_wins, tp.game_losses,
+	                 tp.had_bye, tp.eliminated, tp.elimination_rank, tp.withdrawn, tp.place, tp.prize
+	          FROM tournament_players tp
+	          JOIN users u ON u.id = tp.user_id
+	          WHERE tp.tournament_id = ?
+	          ORDER BY tp.seed = 0, tp.seed, tp.registered_at, tp.user_id`
+	rows, err := exec.Query(query, tournamentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tournament players: %v", err)
+	}
+	defer rows.Close()
+
+	var players []*tournamentPlayerRow
+	for rows.Next() {
+		var p tournamentPlayerRow
+		err := rows.Scan(&p.userID, &p.Username, &p.Seed, &p.Points, &p.MatchWins, &p.MatchLosses, &p.GameWins, &p.GameLosses,
+			&p.hadBye, &p.Eliminated, &p.eliminationRank, &p.Withdrawn, &p.Place, &p.Prize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tournament player: %v", err)
+		}
+		players = append(players, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tournament players: %v", err)
+	}
+	return players, nil
+}
+
+// Register enters a player into a tournament that is open for registration.
+// The entry fee goes into the prize pool.
+func (t *TournamentService) Register(tournamentID int, username string) (*models.Tournament, error) {
+	if err := t.Advance(); err != nil {
+		return nil, err
+	}
+
+	err := runInTx(t.db, func(tx *sql.Tx) error {
+		user, err := t.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+		if err := checkCanPlay(user); err != nil {
+			return err
+		}
+		tournament, err := t.getTournament(tx, tournamentID)
+		if err != nil {
+			return err
+		}
+		if tournament.Status != models.TournamentRegistration || !t.now().Before(tournament.RegistrationClosesAt) {
+			return fmt.Errorf("registration for tournament %d is closed", tournamentID)
+		}
+
+		var registered int
+		query := `SELECT COUNT(*) FROM tournament_players WHERE tournament_id = ? AND user_id = ?`
+		if err := tx.QueryRow(query, tournamentID, user.ID).Scan(&registered); err != nil {
+			return fmt.Errorf("failed to check registration: %v", err)
+		}
+		if registered > 0 {
+			return fmt.Errorf("'%s' is already registered for tournament %d", username, tournamentID)
+		}
+		if tournament.Players >= tournament.MaxPlayers {
+			return fmt.Errorf("tournament %d is full", tournamentID)
+		}
+
+		insertQuery := `INSERT INTO tournament_players (tournament_id, user_id, registered_at) VALUES (?, ?, CURRENT_TIMESTAMP)`
+		if _, err := tx.Exec(insertQuery, tournamentID, user.ID); err != nil {
+			return fmt.Errorf("failed to register for tournament: %v", err)
+		}
+		return t.collectFee(tx, tournament, user.ID, -tournament.EntryFee, fmt.Sprintf("Entry fee for tournament #%d", tournamentID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t.GetTournament(tournamentID)
+}
+
+// collectFee posts an entry fee, or its refund when amount is positive, and
+// moves it in or out of the prize pool
+func (t *TournamentService) collectFee(tx *sql.Tx, tournament *tournamentRow, userID, amount int, reason string) error {
+	if _, err := t.ledger.Post(tx, userID, models.TxTournament, amount, tournamentReference(tournament.ID), reason); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE tournaments SET prize_pool = prize_pool - ? WHERE id = ?`, amount, tournament.ID); err != nil {
+		return fmt.Errorf("failed to update prize pool: %v", err)
+	}
+	tournament.PrizePool -= amount
+	return nil
+}
+
+// Withdraw takes a player out of a tournament. Before it starts the entry
+// fee is refunded; once it is under way the player forfeits whatever matches
+// they have left and the fee stays in the prize pool.
+func (t *TournamentService) Withdraw(tournamentID int, username string) (*models.Tournament, error) {
+	if err := t.Advance(); err != nil {
+		return nil, err
+	}
+
+	err := runInTx(t.db, func(tx *sql.Tx) error {
+		user, err := t.userService.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+		tournament, err := t.getTournament(tx, tournamentID)
+		if err != nil {
+			return err
+		}
+
+		var withdrawn, eliminated bool
+		query := `SELECT withdrawn, eliminated FROM tournament_players WHERE tournament_id = ? AND user_id = ?`
+		err = tx.QueryRow(query, tournamentID, user.ID).Scan(&withdrawn, &eliminated)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("'%s' is not registered for tournament %d", username, tournamentID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check registration: %v", err)
+		}
+
+		switch tournament.Status {
+		case models.TournamentRegistration:
+			deleteQuery := `DELETE FROM tournament_players WHERE tournament_id = ? AND user_id = ?`
+			if _, err := tx.Exec(deleteQuery, tournamentID, user.ID); err != nil {
+				return fmt.Errorf("failed to withdraw from tournament: %v", err)
+			}
+			return t.collectFee(tx, tournament, user.ID, tournament.EntryFee, fmt.Sprintf("Withdrew from tournament #%d", tournamentID))
+		case models.TournamentInProgress:
+			if withdrawn || eliminated {
+				return fmt.Errorf("'%s' is already out of tournament %d", username, tournamentID)
+			}
+			return t.withdrawPlayer(tx, tournament, user.ID)
+		default:
+			return fmt.Errorf("tournament %d is not in play: it is %s", tournamentID, tournament.Status)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t.GetTournament(tournamentID)
+}
+
+// Start closes registration, seeds the players and sets up the first
+// matches. It fails with fewer than two players.
+func (t *TournamentService) Start(tournamentID int) (*models.Tournament, error) {
+	err := runInTx(t.db, func(tx *sql.Tx) error {
+		tournament, err := t.getTournament(tx, tournamentID)
+		if err != nil {
+			return err
+		}
+		if tournament.Status != models.TournamentRegistration {
+			return fmt.Errorf("tournament %d is not open: it is %s", tournamentID, tournament.Status)
+		}
+		if tournament.Players < 2 {
+			return fmt.Errorf("tournament %d needs at least 2 players to start", tournamentID)
+		}
+		return t.start(tx, tournament)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t.GetTournament(tournamentID)
+}
+
+// start seeds a tournament with at least two players and plans its matches
+func (t *TournamentService) start(tx *sql.Tx, tournament *tournamentRow) error {
+	order := `u.total_coins DESC`
+	if tournament.Seeding == models.SeedByRating {
+		order = ratingSeedOrder + ` DESC`
+	}
+	query := `SELECT tp.user_id FROM tournament_players tp
+	          JOIN users u ON u.id = tp.user_id
+	          WHERE tp.tournament_id = ?
+	          ORDER BY ` + order + `, tp.registered_at, tp.user_id`
+	seeded, err := queryIDs(tx, query, tournament.ID)
+	if err != nil {
+		return err
+	}
+	for i, userID := range seeded {
+		seedQuery := `UPDATE tournament_players SET seed = ? WHERE tournament_id = ? AND user_id = ?`
+		if _, err := tx.Exec(seedQuery, i+1, tournament.ID, userID); err != nil {
+			return fmt.Errorf("failed to seed player: %v", err)
+		}
+	}
+
+	if tournament.Format == models.FormatSwiss && tournament.SwissRounds == 0 {
+		tournament.SwissRounds = swissRoundsFor(len(seeded))
+	}
+	startQuery := `UPDATE tournaments SET status = 'in_progress', swiss_rounds = ?, started_at = CURRENT_TIMESTAMP WHERE id = ?`
+	if _, err := tx.Exec(startQuery, tournament.SwissRounds, tournament.ID); err != nil {
+		return fmt.Errorf("failed to start tournament: %v", err)
+	}
+	tournament.Status = models.TournamentInProgress
+
+	// userOf turns a seed into a player, 0 being nobody
+	userOf := func(seed int) int {
+		if seed == 0 {
+			return 0
+		}
+		return seeded[seed-1]
+	}
+
+	var plan []plannedMatch
+	switch tournament.Format {
+	case models.FormatSingleElimination:
+		plan = planSingleElimination(len(seeded))
+	case models.FormatDoubleElimination:
+		plan = planDoubleElimination(len(seeded))
+	case models.FormatRoundRobin:
+		plan = planRoundRobin(len(seeded))
+	case models.FormatSwiss:
+		// Swiss rounds are paired one at a time as the previous one ends
+		return t.advanceRound(tx, tournament)
+	}
+
+	matchIDs := make([]int, len(plan))
+	for i, m := range plan {
+		// elimination brackets fill their seeded slots once every match is
+		// linked, so byes move players on like any other result
+		var player1, player2 interface{}
+		ready := tournament.Format == models.FormatRoundRobin
+		if ready {
+			player1, player2 = userOf(m.seeds[0]), userOf(m.seeds[1])
+		}
+		insertQuery := `INSERT INTO tournament_matches (tournament_id, bracket, round, position, player1_id, player2_id,
+		                                                player1_ready, player2_ready, eliminates)
+		                VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		result, err := tx.Exec(insertQuery, tournament.ID, string(m.bracket), m.round, m.position, player1, player2, ready, ready, m.eliminates)
+		if err != nil {
+			return fmt.Errorf("failed to create tournament match: %v", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get tournament match ID: %v", err)
+		}
+		matchIDs[i] = int(id)
+	}
+
+	for i, m := range plan {
+		if m.winnerTo == nil && m.loserTo == nil {
+			continue
+		}
+		var winnerMatch, winnerSlot, loserMatch, loserSlot interface{}
+		if m.winnerTo != nil {
+			winnerMatch, winnerSlot = matchIDs[m.winnerTo.match], m.winnerTo.slot
+		}
+		if m.loserTo != nil {
+			loserMatch, loserSlot = matchIDs[m.loserTo.match], m.loserTo.slot
+		}
+		linkQuery := `UPDATE tournament_matches
+		              SET winner_next_match_id = ?, winner_next_slot = ?, loser_next_match_id = ?, loser_next_slot = ?
+		              WHERE id = ?`
+		if _, err := tx.Exec(linkQuery, winnerMatch, winnerSlot, loserMatch, loserSlot, matchIDs[i]); err != nil {
+			return fmt.Errorf("failed to link tournament matches: %v", err)
+		}
+	}
+
+	if tournament.Format == models.FormatRoundRobin {
+		return t.advanceRound(tx, tournament)
+	}
+	for i, m := range plan {
+		for slot := 0; slot < 2; slot++ {
+			if !m.seeded[slot] {
+				continue
+			}
+			if err := t.fillSlot(tx, tournament, matchIDs[i], slot, userOf(m.seeds[slot])); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Cancel calls a tournament off and refunds every entry fee
+func (t *TournamentService) Cancel(tournamentID int) (*models.Tournament, error) {
+	err := runInTx(t.db, func(tx *sql.Tx) error {
+		tournament, err := t.getTournament(tx, tournamentID)
+		if err != nil {
+			return err
+		}
+		if tournament.Status != models.TournamentRegistration && tournament.Status != models.TournamentInProgress {
+			return fmt.Errorf("tournament %d is not open: it is %s", tournamentID, tournament.Status)
+		}
+		return t.cancel(tx, tournament, fmt.Sprintf("Tournament #%d cancelled", tournamentID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t.GetTournament(tournamentID)
+}
+
+// cancel refunds every entry fee and marks the tournament cancelled
+func (t *TournamentService) cancel(tx *sql.Tx, tournament *tournamentRow, reason string) error {
+	players, err := t.getPlayers(tx, tournament.ID)
+	if err != nil {
+		return err
+	}
+	for _, p := range players {
+		if err := t.collectFee(tx, tournament, p.userID, tournament.EntryFee, reason); err != nil {
+			return err
+		}
+	}
+	updateQuery := `UPDATE tournaments SET status = 'cancelled', completed_at = CURRENT_TIMESTAMP WHERE id = ?`
+	if _, err := tx.Exec(updateQuery, tournament.ID); err != nil {
+		return fmt.Errorf("failed to cancel tournament: %v", err)
+	}
+	tournament.Status = models.TournamentCancelled
+	return nil
+}
+
+// GetBracket returns every match of a tournament grouped into rounds:
+// winners, losers and grand final brackets in that order for eliminations,
+// and the main bracket for round robin and Swiss
+func (t *TournamentService) GetBracket(tournamentID int) ([]models.TournamentRound, error) {
+	if err := t.Advance(); err != nil {
+		return nil, err
+	}
+	if _, err := t.getTournament(t.db, tournamentID); err != nil {
+		return nil, err
+	}
+
+	matches, err := t.getMatches(t.db, `m.tournament_id = ?`, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.attachGames(t.db, tournamentID, matches); err != nil {
+		return nil, err
+	}
+
+	rounds := []models.TournamentRound{}
+	for _, m := range matches {
+		last := len(rounds) - 1
+		if last < 0 || rounds[last].Bracket != m.Bracket || rounds[last].Round != m.Round {
+			rounds = append(rounds, models.TournamentRound{Bracket: m.Bracket, Round: m.Round})
+			last++
+		}
+		rounds[last].Matches = append(rounds[last].Matches, m.TournamentMatch)
+	}
+	return rounds, nil
+}
+
+// GetStandings returns a tournament's players from first to last: by place
+// once it is over, and by how far they have got while it is being played
+func (t *TournamentService) GetStandings(tournamentID int) ([]models.TournamentPlayer, error) {
+	if err := t.Advance(); err != nil {
+		return nil, err
+	}
+	tournament, err := t.getTournament(t.db, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	players, err := t.rankPlayers(t.db, tournament)
+	if err != nil {
+		return nil, err
+	}
+
+	standings := make([]models.TournamentPlayer, 0, len(players))
+	for _, p := range players {
+		standings = append(standings, p.TournamentPlayer)
+	}
+	return standings, nil
+}
+
+// rankPlayers loads a tournament's players and sorts them from first to
+// last. Knockout players rank by how long they lasted, sharing ranks with
+// those who went out in the same round. Round robin and Swiss players rank
+// by points, then for Swiss by the points of everyone they played, then by
+// games won less games lost. Players who withdrew rank last; seed breaks
+// any remaining tie.
+func (t *TournamentService) rankPlayers(exec dbExecutor, tournament *tournamentRow) ([]*tournamentPlayerRow, error) {
+	players, err := t.getPlayers(exec, tournament.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if tournament.Format == models.FormatSwiss {
+		points := map[int]int{}
+		for _, p := range players {
+			points[p.userID] = p.Points
+		}
+		query := `SELECT player1_id, player2_id FROM tournament_matches
+		          WHERE tournament_id = ? AND status IN ('completed', 'forfeit')
+		            AND player1_id IS NOT NULL AND player2_id IS NOT NULL`
+		rows, err := exec.Query(query, tournament.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query tournament opponents: %v", err)
+		}
+		opponents := map[int][]int{}
+		for rows.Next() {
+			var a, b int
+			if err := rows.Scan(&a, &b); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan tournament opponents: %v", err)
+			}
+			opponents[a] = append(opponents[a], b)
+			opponents[b] = append(opponents[b], a)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("error iterating tournament opponents: %v", err)
+		}
+		for _, p := range players {
+			p.Buchholz = 0
+			for _, opponent := range opponents[p.userID] {
+				p.Buchholz += points[opponent]
+			}
+		}
+	}
+
+	sort.SliceStable(players, func(i, j int) bool {
+		a, b := players[i], players[j]
+		if a.Place != b.Place && a.Place > 0 && b.Place > 0 {
+			return a.Place < b.Place
+		}
+		if tournament.Format.IsElimination() {
+			if a.Eliminated != b.Eliminated {
+				return !a.Eliminated
+			}
+			if a.eliminationRank != b.eliminationRank {
+				return a.eliminationRank > b.eliminationRank
+			}
+		} else {
+			if a.Withdrawn != b.Withdrawn {
+				return !a.Withdrawn
+			}
+			if a.Points != b.Points {
+				return a.Points > b.Points
+			}
+			if a.Buchholz != b.Buchholz {
+				return a.Buchholz > b.Buchholz
+			}
+			if a.GameWins-a.GameLosses != b.GameWins-b.GameLosses {
+				return a.GameWins-a.GameLosses > b.GameWins-b.GameLosses
+			}
+		}
+		return a.Seed < b.Seed
+	})
+	return players, nil
+}
+
+// forfeitTournaments takes a user whose account is being deleted out of
+// every tournament they are playing in. Their entry fees stay in the prize
+// pools; registrations for tournaments that have not started go with the
+// account.
+func (t *TournamentService) forfeitTournaments(tx *sql.Tx, userID int) error {
+	query := `SELECT t.id FROM tournaments t
+	          JOIN tournament_players tp ON tp.tournament_id = t.id
+	          WHERE t.status = 'in_progress' AND tp.user_id = ? AND tp.withdrawn = 0 AND tp.eliminated = 0`
+	ids, err := queryIDs(tx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		tournament, err := t.getTournament(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := t.withdrawPlayer(tx, tournament, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Advance starts tournaments whose registration has closed and forfeits
+// matches whose players ran out of time. Reads call it so nobody sees a
+// tournament that should have moved on.
+func (t *TournamentService) Advance() error {
+	if _, err := t.StartDueTournaments(); err != nil {
+		return err
+	}
+	_, err := t.ForfeitOverdueMatches()
+	return err
+}
+
+// StartDueTournaments starts every tournament whose registration has closed,
+// cancelling and refunding those with fewer than two players. It returns how
+// many tournaments started.
+func (t *TournamentService) StartDueTournaments() (int, error) {
+	started := 0
+
+	err := runInTx(t.db, func(tx *sql.Tx) error {
+		now := t.now().UTC().Format(sqliteTimeFormat)
+		query := `SELECT id FROM tournaments WHERE status = 'registration' AND registration_closes_at <= ? ORDER BY id`
+		ids, err := queryIDs(tx, query, now)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			tournament, err := t.getTournament(tx, id)
+			if err != nil {
+				return err
+			}
+			if tournament.Players < 2 {
+				if err := t.cancel(tx, tournament, fmt.Sprintf("Tournament #%d cancelled: not enough players", id)); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := t.start(tx, tournament); err != nil {
+				return err
+			}
+			started++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return started, nil
+}
+
+// RunScheduler starts due tournaments and forfeits overdue matches every
+// interval until stop is closed
+func (t *TournamentService) RunScheduler(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			started, err := t.StartDueTournaments()
+			if err != nil {
+				log.Printf("Starting tournaments failed: %v", err)
+				continue
+			}
+			if started > 0 {
+				log.Printf("Started %d tournaments", started)
+			}
+			forfeited, err := t.ForfeitOverdueMatches()
+			if err != nil {
+				log.Printf("Tournament match forfeits failed: %v", err)
+				continue
+			}
+			if forfeited > 0 {
+				log.Printf("Forfeited %d tournament matches on time", forfeited)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,431 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"rockpaperscissors/internal/database"
+	"rockpaperscissors/internal/models"
+)
+
+// setupTournamentPlayers creates users with the given coin balances
+func setupTournamentPlayers(t *testing.T, db *database.DB, coins map[string]int) {
+	t.Helper()
+	userService := NewUserService(db)
+	ledger := NewLedgerService(db)
+	for name, balance := range coins {
+		user, err := userService.CreateUser(name)
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if _, err := ledger.Record(user.ID, models.TxAdminAdjustment, balance, "", "test balance"); err != nil {
+			t.Fatalf("Failed to credit user: %v", err)
+		}
+	}
+}
+
+// playTournament plays every active match until none are left, the first
+// player throwing rock and the second scissors so the first always wins
+func playTournament(t *testing.T, tournaments *TournamentService, tournamentID int) {
+	t.Helper()
+	for {
+		rounds, err := tournaments.GetBracket(tournamentID)
+		if err != nil {
+			t.Fatalf("Failed to get bracket: %v", err)
+		}
+		played := false
+		for _, round := range rounds {
+			for _, m := range round.Matches {
+				if m.Status != models.MatchActive {
+					continue
+				}
+				for _, move := range []models.TournamentMoveRequest{
+					{Username: m.Player1, PlayerChoice: models.Rock},
+					{Username: m.Player2, PlayerChoice: models.Scissors},
+				} {
+					if _, err := tournaments.SubmitMove(tournamentID, move); err != nil {
+						t.Fatalf("Failed to submit move for %s: %v", move.Username, err)
+					}
+				}
+				played = true
+			}
+		}
+		if !played {
+			return
+		}
+	}
+}
+
+func TestTournamentService_SingleElimination(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	tournaments := NewTournamentService(db)
+	userService := NewUserService(db)
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	tournaments.now = func() time.Time { return now }
+
+	setupTournamentPlayers(t, db, map[string]int{"ace": 500, "bea": 400, "cal": 300, "dee": 200, "eve": 100, "broke": 5})
+
+	tournament, err := tournaments.CreateTournament("organizer", models.CreateTournamentRequest{
+		Name:                 "Office cup",
+		Format:               models.FormatSingleElimination,
+		EntryFee:             10,
+		GuaranteedPrize:      20,
+		RegistrationClosesAt: now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create tournament: %v", err)
+	}
+
+	// registration order does not matter; seeding is by coins
+	for _, name := range []string{"eve", "dee", "cal", "bea", "ace"} {
+		if _, err := tournaments.Register(tournament.ID, name); err != nil {
+			t.Fatalf("Failed to register %s: %v", name, err)
+		}
+	}
+	if _, err := tournaments.Register(tournament.ID, "ace"); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf("Expected already registered error, got %v", err)
+	}
+	if _, err := tournaments.Register(tournament.ID, "broke"); err == nil || !strings.Contains(err.Error(), "insufficient coins") {
+		t.Errorf("Expected insufficient coins error, got %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := tournaments.Register(tournament.ID, "broke"); err == nil || !strings.Contains(err.Error(), "is closed") {
+		t.Errorf("Expected registration closed error, got %v", err)
+	}
+	got, err := tournaments.GetTournament(tournament.ID)
+	if err != nil {
+		t.Fatalf("Failed to get tournament: %v", err)
+	}
+	if got.Status != models.TournamentInProgress || got.PrizePool != 70 {
+		t.Fatalf("Expected the tournament to start with a pool of 70, got %s with %d", got.Status, got.PrizePool)
+	}
+
+	// the top three seeds get byes; 4th and 5th play first
+	rounds, err := tournaments.GetBracket(tournament.ID)
+	if err != nil {
+		t.Fatalf("Failed to get bracket: %v", err)
+	}
+	var active []models.TournamentMatch
+	for _, m := range rounds[0].Matches {
+		if m.Status == models.MatchActive {
+			active = append(active, m)
+		}
+	}
+	if len(active) != 1 || active[0].Player1 != "dee" || active[0].Player2 != "eve" {
+		t.Fatalf("Expected dee against eve to be the only first round match, got %+v", active)
+	}
+
+	// eve throws and dee never does, so eve goes through on time; bea and
+	// cal, who got byes, never throw either and bea goes through on seed
+	if _, err := tournaments.SubmitMove(tournament.ID, models.TournamentMoveRequest{Username: "eve", PlayerChoice: models.Paper}); err != nil {
+		t.Fatalf("Failed to submit move: %v", err)
+	}
+	if _, err := tournaments.SubmitMove(tournament.ID, models.TournamentMoveRequest{Username: "eve", PlayerChoice: models.Rock}); err == nil || !strings.Contains(err.Error(), "already moved") {
+		t.Errorf("Expected already moved error, got %v", err)
+	}
+	now = now.Add(defaultMoveTimeout)
+	if forfeited, err := tournaments.ForfeitOverdueMatches(); err != nil || forfeited != 2 {
+		t.Fatalf("Expected two forfeits, got %d, %v", forfeited, err)
+	}
+
+	playTournament(t, tournaments, tournament.ID)
+
+	got, err = tournaments.GetTournament(tournament.ID)
+	if err != nil {
+		t.Fatalf("Failed to get tournament: %v", err)
+	}
+	if got.Status != models.TournamentCompleted || got.Winner != "ace" {
+		t.Fatalf("Expected ace to win, got %s won by %q", got.Status, got.Winner)
+	}
+
+	standings, err := tournaments.GetStandings(tournament.ID)
+	if err != nil {
+		t.Fatalf("Failed to get standings: %v", err)
+	}
+	want := []struct {
+		name  string
+		place int
+		prize int
+	}{{"ace", 1, 35}, {"bea", 2, 21}, {"cal", 3, 7}, {"eve", 3, 7}, {"dee", 5, 0}}
+	for i, w := range want {
+		s := standings[i]
+		if s.Username != w.name || s.Place != w.place || s.Prize != w.prize {
+			t.Errorf("Expected %s in place %d with %d, got %+v", w.name, w.place, w.prize, s)
+		}
+	}
+
+	ace, _ := userService.GetUser("ace")
+	if ace.TotalCoins != 500-10+35 {
+		t.Errorf("Expected ace to have %d coins, got %d", 500-10+35, ace.TotalCoins)
+	}
+	if drift, err := NewLedgerService(db).Reconcile(); err != nil || len(drift) != 0 {
+		t.Errorf("Expected balances to match the ledger, got %v, %v", drift, err)
+	}
+}
+
+func TestTournamentService_Swiss(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	tournaments := NewTournamentService(db)
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	tournaments.now = func() time.Time { return now }
+
+	coins := map[string]int{}
+	for i := 1; i <= 5; i++ {
+		coins[fmt.Sprintf("swiss%d", i)] = 100 * i
+	}
+	setupTournamentPlayers(t, db, coins)
+
+	tournament, err := tournaments.CreateTournament("organizer", models.CreateTournamentRequest{
+		Name:                 "Swiss open",
+		Format:               models.FormatSwiss,
+		BestOf:               3,
+		RegistrationClosesAt: now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create tournament: %v", err)
+	}
+	for name := range coins {
+		if _, err := tournaments.Register(tournament.ID, name); err != nil {
+			t.Fatalf("Failed to register %s: %v", name, err)
+		}
+	}
+	if _, err := tournaments.Start(tournament.ID); err != nil {
+		t.Fatalf("Failed to start tournament: %v", err)
+	}
+
+	playTournament(t, tournaments, tournament.ID)
+
+	got, err := tournaments.GetTournament(tournament.ID)
+	if err != nil {
+		t.Fatalf("Failed to get tournament: %v", err)
+	}
+	if got.Status != models.TournamentCompleted || got.SwissRounds != 3 {
+		t.Fatalf("Expected a completed 3 round tournament, got %s after %d rounds", got.Status, got.SwissRounds)
+	}
+
+	rounds, err := tournaments.GetBracket(tournament.ID)
+	if err != nil {
+		t.Fatalf("Failed to get bracket: %v", err)
+	}
+	if len(rounds) != 3 {
+		t.Fatalf("Expected 3 rounds, got %d", len(rounds))
+	}
+	met := map[string]bool{}
+	byes := map[string]bool{}
+	for _, round := range rounds {
+		for _, m := range round.Matches {
+			if m.Status == models.MatchBye {
+				if byes[m.Player1] {
+					t.Errorf("%s got a second bye", m.Player1)
+				}
+				byes[m.Player1] = true
+				continue
+			}
+			if m.Player1Wins != 2 || m.Player2Wins != 0 || len(m.Games) != 2 {
+				t.Errorf("Expected a 2-0 best of 3, got %+v", m)
+			}
+			if met[m.Player1+m.Player2] || met[m.Player2+m.Player1] {
+				t.Errorf("Rematch between %s and %s", m.Player1, m.Player2)
+			}
+			met[m.Player1+m.Player2] = true
+		}
+	}
+	if len(byes) != 3 {
+		t.Errorf("Expected a bye in each round, got %v", byes)
+	}
+
+	standings, err := tournaments.GetStandings(tournament.ID)
+	if err != nil {
+		t.Fatalf("Failed to get standings: %v", err)
+	}
+	for i, s := range standings {
+		if s.Place != i+1 {
+			t.Errorf("Expected %s in place %d, got %d", s.Username, i+1, s.Place)
+		}
+		if i > 0 && s.Points > standings[i-1].Points {
+			t.Errorf("Standings out of order: %+v", standings)
+		}
+	}
+}
+
+func TestTournamentService_WithdrawAndCancel(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	tournaments := NewTournamentService(db)
+	userService := NewUserService(db)
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	tournaments.now = func() time.Time { return now }
+
+	setupTournamentPlayers(t, db, map[string]int{"rr1": 100, "rr2": 90, "rr3": 80, "lonely": 50})
+
+	// a round robin where one player walks out after the first round
+	roundRobin, err := tournaments.CreateTournament("organizer", models.CreateTournamentRequest{
+		Name:                 "Round robin",
+		Format:               models.FormatRoundRobin,
+		EntryFee:             10,
+		RegistrationClosesAt: now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create tournament: %v", err)
+	}
+	for _, name := range []string{"rr1", "rr2", "rr3"} {
+		if _, err := tournaments.Register(roundRobin.ID, name); err != nil {
+			t.Fatalf("Failed to register %s: %v", name, err)
+		}
+	}
+	if _, err := tournaments.Start(roundRobin.ID); err != nil {
+		t.Fatalf("Failed to start tournament: %v", err)
+	}
+	if _, err := tournaments.Withdraw(roundRobin.ID, "rr1"); err != nil {
+		t.Fatalf("Failed to withdraw: %v", err)
+	}
+	if _, err := tournaments.Withdraw(roundRobin.ID, "rr1"); err == nil || !strings.Contains(err.Error(), "already out") {
+		t.Errorf("Expected already out error, got %v", err)
+	}
+	playTournament(t, tournaments, roundRobin.ID)
+
+	standings, err := tournaments.GetStandings(roundRobin.ID)
+	if err != nil {
+		t.Fatalf("Failed to get standings: %v", err)
+	}
+	if standings[0].Username != "rr2" || standings[0].Points != 2 || standings[2].Username != "rr1" || !standings[2].Withdrawn {
+		t.Errorf("Expected rr2 to win with rr1 last, got %+v", standings)
+	}
+
+	// a tournament nobody else joins is cancelled and refunded
+	empty, err := tournaments.CreateTournament("organizer", models.CreateTournamentRequest{
+		Name:                 "Quiet night",
+		Format:               models.FormatDoubleElimination,
+		EntryFee:             20,
+		RegistrationClosesAt: now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create tournament: %v", err)
+	}
+	if _, err := tournaments.Register(empty.ID, "lonely"); err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	if _, err := tournaments.Start(empty.ID); err == nil || !strings.Contains(err.Error(), "at least 2 players") {
+		t.Errorf("Expected not enough players error, got %v", err)
+	}
+	now = now.Add(time.Hour)
+	got, err := tournaments.GetTournament(empty.ID)
+	if err != nil {
+		t.Fatalf("Failed to get tournament: %v", err)
+	}
+	if got.Status != models.TournamentCancelled || got.PrizePool != 0 {
+		t.Errorf("Expected the tournament to be cancelled with an empty pool, got %s with %d", got.Status, got.PrizePool)
+	}
+	lonely, _ := userService.GetUser("lonely")
+	if lonely.TotalCoins != 50 {
+		t.Errorf("Expected the entry fee back, got %d coins", lonely.TotalCoins)
+	}
+}
+
+func TestTournamentService_DeletedPlayerForfeits(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	tournaments := NewTournamentService(db)
+	now := time.Now()
+	tournaments.now = func() time.Time { return now }
+
+	setupTournamentPlayers(t, db, map[string]int{"stays": 100, "leaves": 50})
+	tournament, err := tournaments.CreateTournament("organizer", models.CreateTournamentRequest{
+		Name:                 "Final",
+		Format:               models.FormatSingleElimination,
+		RegistrationClosesAt: now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create tournament: %v", err)
+	}
+	for _, name := range []string{"stays", "leaves"} {
+		if _, err := tournaments.Register(tournament.ID, name); err != nil {
+			t.Fatalf("Failed to register %s: %v", name, err)
+		}
+	}
+	if _, err := tournaments.Start(tournament.ID); err != nil {
+		t.Fatalf("Failed to start tournament: %v", err)
+	}
+
+	if err := NewAdminService(db).DeleteUser("admin", "leaves", "test"); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	got, err := tournaments.GetTournament(tournament.ID)
+	if err != nil {
+		t.Fatalf("Failed to get tournament: %v", err)
+	}
+	if got.Status != models.TournamentCompleted || got.Winner != "stays" {
+		t.Errorf("Expected stays to win by forfeit, got %s won by %q", got.Status, got.Winner)
+	}
+}
+
+func TestTournamentService_DoubleElimination(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	tournaments := NewTournamentService(db)
+	now := time.Now()
+	tournaments.now = func() time.Time { return now }
+
+	coins := map[string]int{}
+	for i := 1; i <= 6; i++ {
+		coins[fmt.Sprintf("double%d", i)] = 100 * i
+	}
+	setupTournamentPlayers(t, db, coins)
+
+	tournament, err := tournaments.CreateTournament("organizer", models.CreateTournamentRequest{
+		Name:                 "Second chance",
+		Format:               models.FormatDoubleElimination,
+		GuaranteedPrize:      100,
+		RegistrationClosesAt: now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create tournament: %v", err)
+	}
+	for name := range coins {
+		if _, err := tournaments.Register(tournament.ID, name); err != nil {
+			t.Fatalf("Failed to register %s: %v", name, err)
+		}
+	}
+	if _, err := tournaments.Start(tournament.ID); err != nil {
+		t.Fatalf("Failed to start tournament: %v", err)
+	}
+
+	playTournament(t, tournaments, tournament.ID)
+
+	got, err := tournaments.GetTournament(tournament.ID)
+	if err != nil {
+		t.Fatalf("Failed to get tournament: %v", err)
+	}
+	if got.Status != models.TournamentCompleted || got.Winner == "" {
+		t.Fatalf("Expected the tournament to finish with a winner, got %s won by %q", got.Status, got.Winner)
+	}
+
+	standings, err := tournaments.GetStandings(tournament.ID)
+	if err != nil {
+		t.Fatalf("Failed to get standings: %v", err)
+	}
+	paid := 0
+	for _, s := range standings {
+		if s.MatchLosses > 2 {
+			t.Errorf("%s lost %d matches", s.Username, s.MatchLosses)
+		}
+		paid += s.Prize
+	}
+	if standings[0].Username != got.Winner || standings[0].Place != 1 || standings[1].Place != 2 {
+		t.Errorf("Expected the winner first and the runner-up second, got %+v", standings)
+	}
+	if paid != 100 {
+		t.Errorf("Expected the whole pool of 100 paid out, got %d", paid)
+	}
+}
@@ -1,42 +1,62 @@
 package services
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"rockpaperscissors/internal/database"
 	"rockpaperscissors/internal/models"
+	"strings"
 	"time"
 )
 
 // UserService handles user-related operations
 type UserService struct {
-	db *sql.DB
+	db          *sql.DB
+	leaderboard *leaderboardCache
 }
 
 // NewUserService creates a new user service
-func NewUserService(db *sql.DB) *UserService {
-	return &UserService{db: db}
+func NewUserService(db *database.DB) *UserService {
+	return &UserService{db: db.DB, leaderboard: leaderboardFor(db)}
 }
 
+// CreateUser registers a new user. The username must pass the username
+// policy and may not match an existing one ignoring case, or look like one.
+// The returned user carries the new account token.
 func (u *UserService) CreateUser(username string) (*models.User, error) {
-	checkQuery := `SELECT COUNT(*) FROM users WHERE username = ?`
-
-	var count int
-	err := u.db.QueryRow(checkQuery, username).Scan(&count) // check if user exists
+	policy, err := LoadUsernamePolicy()
+	if err != nil {
+		return nil, err
+	}
+	username, err = policy.Check(username)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check if user exists: %v", err)
+		return nil, err
+	}
+	if err := u.checkUsernameAvailable(u.db, username, 0); err != nil {
+		return nil, err
 	}
-	if count > 0 {
-		return nil, fmt.Errorf("user '%s' already exists", username)
+	token, tokenHash, err := newAccountToken()
+	if err != nil {
+		return nil, err
 	}
 
 	insertQuery := `
-	INSERT INTO users (username, total_coins, current_streak, games_played, games_won, created_at, updated_at)
-	VALUES (?,0,0,0,0,CURRENT_TIMESTAMP,CURRENT_TIMESTAMP)
+	INSERT INTO users (username, username_normalized, username_skeleton, account_token_hash, total_coins, current_streak, games_played, games_won, created_at, updated_at)
+	VALUES (?,?,?,?,0,0,0,0,CURRENT_TIMESTAMP,CURRENT_TIMESTAMP)
 	`
 
 	// create the user in database
-	result, err := u.db.Exec(insertQuery, username)
+	result, err := u.db.Exec(insertQuery, username, normalizeUsername(username), usernameSkeleton(username), tokenHash)
 	if err != nil {
+		// someone else took the name since it was checked
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("user '%s' already exists", username)
+		}
 		return nil, fmt.Errorf("failed to create user: %v", err)
 	}
 
@@ -44,6 +64,7 @@ func (u *UserService) CreateUser(username string) (*models.User, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user ID: %v", err)
 	}
+	u.leaderboard.touch(u.db, int(userID))
 
 	return &models.User{
 		ID:            int(userID),
@@ -52,44 +73,210 @@ func (u *UserService) CreateUser(username string) (*models.User, error) {
 		CurrentStreak: 0,
 		GamesPlayed:   0,
 		GamesWon:      0,
+		Timezone:      "UTC",
+		Status:        models.UserActive,
+		AccountToken:  token,
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 	}, nil
 }
 
+// checkUsernameAvailable rejects a username that matches another user's
+// ignoring case or shares its skeleton. userID is the user being renamed, or
+// 0 for a new user.
+func (u *UserService) checkUsernameAvailable(exec dbExecutor, username string, userID int) error {
+	var taken int
+	checkQuery := `SELECT COUNT(*) FROM users WHERE (username = ? OR username_normalized = ?) AND id != ?`
+	if err := exec.QueryRow(checkQuery, username, normalizeUsername(username), userID).Scan(&taken); err != nil {
+		return fmt.Errorf("failed to check if user exists: %v", err)
+	}
+	if taken > 0 {
+		return fmt.Errorf("user '%s' already exists", username)
+	}
+
+	var lookalike string
+	err := exec.QueryRow(`SELECT username FROM users WHERE username_skeleton = ? AND id != ?`, usernameSkeleton(username), userID).Scan(&lookalike)
+	if err == nil {
+		return fmt.Errorf("username '%s' is too similar to existing user '%s'", username, lookalike)
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("failed to check similar usernames: %v", err)
+	}
+	return nil
+}
+
+// BackfillUsernameKeys fills in the case-insensitive and skeleton forms of
+// usernames registered before they were stored, and brings up to date keys
+// stored under an older rule. Where two existing users clash, the older one
+// keeps the key and the newer one is returned so an admin can rename it. It
+// is safe to run repeatedly and returns how many users were updated.
+func (u *UserService) BackfillUsernameKeys() (int, []string, error) {
+	rows, err := u.db.Query(`SELECT id, username, username_normalized, username_skeleton FROM users ORDER BY id`)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to query usernames: %v", err)
+	}
+	type pending struct {
+		id       int
+		username string
+	}
+	var users []pending
+	for rows.Next() {
+		var p pending
+		var normalized, skeleton sql.NullString
+		if err := rows.Scan(&p.id, &p.username, &normalized, &skeleton); err != nil {
+			rows.Close()
+			return 0, nil, fmt.Errorf("failed to scan username: %v", err)
+		}
+		if normalized.Valid && normalized.String == normalizeUsername(p.username) &&
+			skeleton.Valid && skeleton.String == usernameSkeleton(p.username) {
+			continue
+		}
+		users = append(users, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, nil, fmt.Errorf("error iterating usernames: %v", err)
+	}
+
+	updated := 0
+	var clashes []string
+	for _, p := range users {
+		clashed := false
+		for _, key := range []struct{ column, value string }{
+			{"username_normalized", normalizeUsername(p.username)},
+			{"username_skeleton", usernameSkeleton(p.username)},
+		} {
+			var taken int
+			if err := u.db.QueryRow(`SELECT COUNT(*) FROM users WHERE `+key.column+` = ? AND id != ?`, key.value, p.id).Scan(&taken); err != nil {
+				return updated, clashes, fmt.Errorf("failed to check %s: %v", key.column, err)
+			}
+			if taken > 0 {
+				clashed = true
+				continue
+			}
+			if _, err := u.db.Exec(`UPDATE users SET `+key.column+` = ? WHERE id = ?`, key.value, p.id); err != nil {
+				return updated, clashes, fmt.Errorf("failed to set %s: %v", key.column, err)
+			}
+		}
+		if clashed {
+			clashes = append(clashes, p.username)
+		} else {
+			updated++
+		}
+	}
+
+	return updated, clashes, nil
+}
+
 func (u *UserService) GetUser(username string) (*models.User, error) {
-	query := `SELECT id, username, total_coins, current_streak, games_played, games_won, created_at, updated_at
+	return u.getUser(u.db, username)
+}
+
+// userColumns are the columns scanUser reads, in order. They expect the
+// users table unaliased.
+var userColumns = `id, username, total_coins, current_streak, best_streak, games_played, games_won, timezone,
+	display_name, avatar_url, country, bio, status, suspended_until, deletion_scheduled_at, created_at, updated_at,
+	` + clanTagColumn("users")
+
+// clanTagColumn selects the tag of the clan the user in table is in, or NULL
+func clanTagColumn(table string) string {
+	return `(SELECT c.tag FROM clan_members cm JOIN clans c ON c.id = cm.clan_id WHERE cm.user_id = ` + table + `.id)`
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// getUser loads a user through exec so it can take part in a transaction
+func (u *UserService) getUser(exec dbExecutor, username string) (*models.User, error) {
+	query := `SELECT ` + userColumns + `
 	          FROM users
 			  WHERE username = ?`
 
-	// TODO: Execute query and scan results into user struct
+	user, err := scanUser(exec.QueryRow(query, username))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user '%s' not found", username)
+		}
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+	return user, nil
+}
+
+// scanUser reads a row of userColumns into a user
+func scanUser(row rowScanner) (*models.User, error) {
 	var user models.User
+	var status string
+	var suspendedUntil, deletionScheduledAt sql.NullTime
+	var clanTag sql.NullString
 
-	err := u.db.QueryRow(query, username).Scan(
+	err := row.Scan(
 		&user.ID,
 		&user.Username,
 		&user.TotalCoins,
 		&user.CurrentStreak,
+		&user.BestStreak,
 		&user.GamesPlayed,
 		&user.GamesWon,
+		&user.Timezone,
+		&user.Profile.DisplayName,
+		&user.Profile.AvatarURL,
+		&user.Profile.Country,
+		&user.Profile.Bio,
+		&status,
+		&suspendedUntil,
+		&deletionScheduledAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&clanTag,
 	)
-
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user '%s' not found", username)
+		return nil, err
+	}
+	user.ClanTag = clanTag.String
+
+	// a suspension that has run out lifts itself
+	user.Status = models.UserStatus(status)
+	if user.Status == models.UserSuspended {
+		if suspendedUntil.Valid && suspendedUntil.Time.After(time.Now()) {
+			user.SuspendedUntil = &suspendedUntil.Time
+		} else {
+			user.Status = models.UserActive
 		}
-		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+	if deletionScheduledAt.Valid {
+		user.DeletionScheduledAt = &deletionScheduledAt.Time
 	}
 	return &user, nil
 }
 
-func (u *UserService) UpdateUserStats(userID int, totalCoins int, currentStreak int, gamesPlayed int, gamesWon int) error {
+// checkCanPlay rejects banned users, users whose suspension is running and
+// users waiting to be deleted
+func checkCanPlay(user *models.User) error {
+	if user.DeletionScheduledAt != nil {
+		return fmt.Errorf("user '%s' is scheduled for deletion", user.Username)
+	}
+	switch user.Status {
+	case models.UserBanned:
+		return fmt.Errorf("user '%s' is banned", user.Username)
+	case models.UserSuspended:
+		return fmt.Errorf("user '%s' is suspended until %s", user.Username, user.SuspendedUntil.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// UpdateUserStats updates the game counters for a user. Coin balances are
+// never written here; they only change through LedgerService.Post.
+func (u *UserService) UpdateUserStats(userID int, currentStreak int, gamesPlayed int, gamesWon int) error {
+	return u.updateUserStats(u.db, userID, currentStreak, gamesPlayed, gamesWon)
+}
+
+func (u *UserService) updateUserStats(exec dbExecutor, userID int, currentStreak int, gamesPlayed int, gamesWon int) error {
 	query := `UPDATE users
-	          SET total_coins = ?, current_streak = ?, games_played = ?, games_won = ?, updated_at = CURRENT_TIMESTAMP
+	          SET current_streak = ?, games_played = ?, games_won = ?, updated_at = CURRENT_TIMESTAMP
 			  WHERE id = ?`
-	result, err := u.db.Exec(query, totalCoins, currentStreak, gamesPlayed, gamesWon, userID)
+	result, err := exec.Exec(query, currentStreak, gamesPlayed, gamesWon, userID)
 	if err != nil {
 		return fmt.Errorf("failed to update user stats: %v", err)
 	}
@@ -101,40 +288,240 @@ func (u *UserService) UpdateUserStats(userID int, totalCoins int, currentStreak
 	if rowsAffected == 0 {
 		return fmt.Errorf("user with ID %d not found", userID)
 	}
+	u.leaderboard.touch(exec, userID)
+
+	return nil
+}
+
+// TimezoneChangeCooldown is how long a player waits between timezone
+// changes. A change moves the start of the player's day, so changing at
+// will would let them claim daily rewards more than once a day.
+const TimezoneChangeCooldown = 30 * 24 * time.Hour
+
+// SetTimezone changes the IANA timezone used for a user's day boundaries
+func (u *UserService) SetTimezone(username, timezone string) error {
+	if err := validateTimezone(timezone); err != nil {
+		return err
+	}
+
+	return runInTx(u.db, func(tx *sql.Tx) error {
+		user, err := u.getUser(tx, username)
+		if err != nil {
+			return err
+		}
+		changed, err := u.checkTimezoneChange(tx, user.ID, timezone)
+		if err != nil || !changed {
+			return err
+		}
+
+		updateQuery := `UPDATE users SET timezone = ?, timezone_changed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+		if _, err := tx.Exec(updateQuery, timezone, user.ID); err != nil {
+			return fmt.Errorf("failed to update timezone: %v", err)
+		}
+		return nil
+	})
+}
+
+// checkTimezoneChange reports whether moving a user to timezone is a
+// change, and fails if their last change is within TimezoneChangeCooldown
+func (u *UserService) checkTimezoneChange(exec dbExecutor, userID int, timezone string) (bool, error) {
+	var current string
+	var changedAt sql.NullTime
+	query := `SELECT timezone, timezone_changed_at FROM users WHERE id = ?`
+	if err := exec.QueryRow(query, userID).Scan(&current, &changedAt); err != nil {
+		return false, fmt.Errorf("failed to get timezone: %v", err)
+	}
+	if current == timezone {
+		return false, nil
+	}
+	if changedAt.Valid {
+		if next := changedAt.Time.Add(TimezoneChangeCooldown); time.Now().Before(next) {
+			return false, fmt.Errorf("timezone changed too recently, it can be changed again after %s", next.UTC().Format(time.RFC3339))
+		}
+	}
+	return true, nil
+}
+
+// validateTimezone accepts IANA timezone names only
+func validateTimezone(timezone string) error {
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "" || timezone == "Local" {
+		return fmt.Errorf("invalid timezone '%s'", timezone)
+	}
+	return nil
+}
+
+// newAccountToken returns a random account token and the hash it is
+// stored as. Only the hash is kept, so a lost token has to be reissued.
+func newAccountToken() (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("failed to generate account token: %v", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	return token, hashAccountToken(token), nil
+}
+
+func hashAccountToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
 
+// Authenticate checks that token is the account token of username. Users
+// created before account tokens existed have none until an admin issues one.
+func (u *UserService) Authenticate(username, token string) error {
+	var storedHash sql.NullString
+	err := u.db.QueryRow(`SELECT account_token_hash FROM users WHERE username = ?`, username).Scan(&storedHash)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to check account token: %v", err)
+	}
+	if err == sql.ErrNoRows || !storedHash.Valid ||
+		subtle.ConstantTimeCompare([]byte(hashAccountToken(token)), []byte(storedHash.String)) != 1 {
+		return fmt.Errorf("invalid account token")
+	}
 	return nil
 }
 
-// GetLeaderboard retrieves top users ordered by total coins
+// Identify returns the username an account token belongs to
+func (u *UserService) Identify(token string) (string, error) {
+	var username string
+	err := u.db.QueryRow(`SELECT username FROM users WHERE account_token_hash = ?`, hashAccountToken(token)).Scan(&username)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("invalid account token")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to check account token: %v", err)
+	}
+	return username, nil
+}
+
+// issueAccountToken replaces a user's account token and returns the new one
+func (u *UserService) issueAccountToken(exec dbExecutor, userID int) (string, error) {
+	token, tokenHash, err := newAccountToken()
+	if err != nil {
+		return "", err
+	}
+	if _, err := exec.Exec(`UPDATE users SET account_token_hash = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, tokenHash, userID); err != nil {
+		return "", fmt.Errorf("failed to store account token: %v", err)
+	}
+	return token, nil
+}
+
+// GetEquippedCosmetics returns the cosmetic items a user has equipped
+func (u *UserService) GetEquippedCosmetics(userID int) (models.EquippedCosmetics, error) {
+	cosmetics, err := loadEquippedCosmetics(u.db, []int{userID})
+	if err != nil {
+		return models.EquippedCosmetics{}, err
+	}
+	return cosmetics[userID], nil
+}
+
+// rankedUsersFilter keeps banned and suspended users, and users waiting to be
+// deleted, off leaderboards. It expects the users table under the alias u.
+const rankedUsersFilter = `((u.status = 'active' OR (u.status = 'suspended' AND u.suspended_until <= CURRENT_TIMESTAMP))
+	AND u.deletion_scheduled_at IS NULL)`
+
+// GetLeaderboard retrieves top users ordered by total coins. Who is on it
+// and in what order comes from the leaderboard cache; only the users on the
+// page are read, and rankedUsersFilter is applied to them again so a player
+// banned around the server drops off before the next refresh.
 func (u *UserService) GetLeaderboard(limit int) ([]models.LeaderboardEntry, error) {
 	if limit <= 0 {
 		limit = 10 // Default to top 10
 	}
+	scores, err := u.leaderboard.top(limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(scores) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(scores))
+	ids := make([]interface{}, len(scores))
+	order := "CASE u.id"
+	var orderArgs []interface{}
+	for i, score := range scores {
+		placeholders[i] = "?"
+		ids[i] = score.UserID
+		order += " WHEN ? THEN ?"
+		orderArgs = append(orderArgs, score.UserID, i)
+	}
+	order += " END"
+	args := append(ids, orderArgs...)
+	return u.queryLeaderboard("u.id IN ("+strings.Join(placeholders, ", ")+") AND "+rankedUsersFilter, order, args, limit)
+}
+
+// GetRank returns a user's place on the leaderboard, or 0 if they are not on
+// it
+func (u *UserService) GetRank(userID int) (int, error) {
+	return u.leaderboard.rank(userID)
+}
 
-	query := `SELECT username, total_coins, current_streak, games_played, games_won, created_at, updated_at
-	          FROM users 
-			  ORDER BY total_coins DESC, games_won DESC 
+// GetCountryLeaderboard ranks the users of one country by total coins. The
+// country is an ISO 3166-1 alpha-2 code in either case.
+func (u *UserService) GetCountryLeaderboard(country string, limit int) ([]models.LeaderboardEntry, error) {
+	country, err := normalizeCountry(country)
+	if err != nil {
+		return nil, err
+	}
+	return u.queryLeaderboard("u.country = ? AND "+rankedUsersFilter, leaderboardOrder, []interface{}{country}, limit)
+}
+
+// GetFriendsLeaderboard ranks a user and their accepted friends by total coins
+func (u *UserService) GetFriendsLeaderboard(userID int, limit int) ([]models.LeaderboardEntry, error) {
+	filter := `(id = ? OR id IN (
+	               SELECT addressee_id FROM friendships WHERE requester_id = ? AND status = 'accepted'
+	               UNION
+	               SELECT requester_id FROM friendships WHERE addressee_id = ? AND status = 'accepted'
+	           )) AND ` + rankedUsersFilter
+	return u.queryLeaderboard(filter, leaderboardOrder, []interface{}{userID, userID, userID}, limit)
+}
+
+// leaderboardOrder ranks users by total coins, the same way
+// models.LeaderboardScore.RanksAbove does
+const leaderboardOrder = `total_coins DESC, games_won DESC, id`
+
+// queryLeaderboard ranks the users matching filter in the given order.
+// Callers leave out banned and suspended users with rankedUsersFilter.
+func (u *UserService) queryLeaderboard(filter, order string, args []interface{}, limit int) ([]models.LeaderboardEntry, error) {
+	if limit <= 0 {
+		limit = 10 // Default to top 10
+	}
+
+	query := `SELECT id, username, total_coins, current_streak, games_played, games_won, display_name, avatar_url, country, bio, created_at, updated_at,
+	          ` + clanTagColumn("u") + `
+	          FROM users u
+	          WHERE ` + filter + `
+			  ORDER BY ` + order + `
 			  LIMIT ?`
 
-	rows, err := u.db.Query(query, limit)
+	rows, err := u.db.Query(query, append(args, limit)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query leaderboard: %v", err)
 	}
 	defer rows.Close()
 
 	var leaderboard []models.LeaderboardEntry
+	var userIDs []int
 	rank := 1
 
 	for rows.Next() {
 		var user models.User
+		var clanTag sql.NullString
 		err := rows.Scan(
+			&user.ID,
 			&user.Username,
 			&user.TotalCoins,
 			&user.CurrentStreak,
 			&user.GamesPlayed,
 			&user.GamesWon,
+			&user.Profile.DisplayName,
+			&user.Profile.AvatarURL,
+			&user.Profile.Country,
+			&user.Profile.Bio,
 			&user.CreatedAt,
 			&user.UpdatedAt,
+			&clanTag,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan leaderboard row: %v", err)
@@ -149,12 +536,15 @@ func (u *UserService) GetLeaderboard(limit int) ([]models.LeaderboardEntry, erro
 		leaderboard = append(leaderboard, models.LeaderboardEntry{
 			Rank:          rank,
 			Username:      user.Username,
+			ClanTag:       clanTag.String,
 			TotalCoins:    user.TotalCoins,
 			GamesPlayed:   user.GamesPlayed,
 			GamesWon:      user.GamesWon,
 			WinRate:       winRate,
 			CurrentStreak: user.CurrentStreak,
+			Profile:       user.Profile,
 		})
+		userIDs = append(userIDs, user.ID)
 		rank++
 	}
 
@@ -162,5 +552,14 @@ func (u *UserService) GetLeaderboard(limit int) ([]models.LeaderboardEntry, erro
 		return nil, fmt.Errorf("error iterating leaderboard rows: %v", err)
 	}
 
+	// Attach equipped cosmetics so the UI can render them next to each name
+	cosmetics, err := loadEquippedCosmetics(u.db, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i, userID := range userIDs {
+		leaderboard[i].Cosmetics = cosmetics[userID]
+	}
+
 	return leaderboard, nil
 }
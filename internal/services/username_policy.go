@@ -0,0 +1,300 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+//go:embed username_blocklist.txt
+var defaultUsernameBlocklist []byte
+
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 20
+)
+
+// reservedUsernames cannot be registered by players: system accounts and
+// names that could pass for the game itself or one of its bots. They are
+// compared by skeleton, so "Adm1n" is reserved too.
+var reservedUsernames = []string{
+	"admin", "administrator", "root", "system", "moderator", "mod", "support", "staff",
+	"official", "rockpaperscissors", "rps", "computer", "cpu", "bot", "rpsbot", "ai",
+	"opponent", "player", "guest", "anonymous", "null", "undefined", "deleted",
+}
+
+// usernameScripts are the writing systems a username may use. All letters of
+// a name must come from one of them, which rules out names that mix
+// look-alike letters from different scripts.
+var usernameScripts = []struct {
+	name   string
+	tables []*unicode.RangeTable
+}{
+	{"Latin", []*unicode.RangeTable{unicode.Latin}},
+	{"Cyrillic", []*unicode.RangeTable{unicode.Cyrillic}},
+	{"Greek", []*unicode.RangeTable{unicode.Greek}},
+	{"Arabic", []*unicode.RangeTable{unicode.Arabic}},
+	{"Hebrew", []*unicode.RangeTable{unicode.Hebrew}},
+	{"Devanagari", []*unicode.RangeTable{unicode.Devanagari}},
+	{"Thai", []*unicode.RangeTable{unicode.Thai}},
+	{"Hangul", []*unicode.RangeTable{unicode.Hangul}},
+	// Japanese mixes kanji with both kana; Chinese names are kanji only
+	{"CJK", []*unicode.RangeTable{unicode.Han, unicode.Hiragana, unicode.Katakana}},
+}
+
+// usernameConfusables maps letters of other scripts to the Latin letter they
+// are most easily mistaken for, following the Unicode confusables data
+// (UTS #39) for the scripts usernames may use
+var usernameConfusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'һ': 'h', 'і': 'i', 'ї': 'i', 'ј': 'j', 'к': 'k',
+	'ӏ': 'l', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x',
+	'ѕ': 's', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w', 'ь': 'b',
+	'А': 'a', 'В': 'b', 'Е': 'e', 'Ё': 'e', 'І': 'i', 'Ї': 'i', 'Ј': 'j', 'К': 'k', 'М': 'm',
+	'Н': 'h', 'О': 'o', 'Р': 'p', 'С': 'c', 'Т': 't', 'У': 'y', 'Х': 'x', 'Ѕ': 's', 'Ӏ': 'l',
+	// Greek
+	'α': 'a', 'β': 'b', 'γ': 'y', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p',
+	'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w',
+	'Α': 'a', 'Β': 'b', 'Ε': 'e', 'Ζ': 'z', 'Η': 'h', 'Ι': 'i', 'Κ': 'k', 'Μ': 'm', 'Ν': 'n',
+	'Ο': 'o', 'Ρ': 'p', 'Τ': 't', 'Υ': 'y', 'Χ': 'x',
+}
+
+// blocklistConfusables are characters of one script that pass for others:
+// i, l and 1 are all one stroke in many fonts. They are too common in real
+// names to keep two users apart, so only the blocklist folds them.
+var blocklistConfusables = map[rune]rune{'0': 'o', '1': 'l', 'i': 'l', '|': 'l'}
+
+// blocklistConfusableSequences are letter pairs that read as a single letter
+var blocklistConfusableSequences = strings.NewReplacer("rn", "m", "vv", "w")
+
+// usernameSeparators may appear inside a username, one at a time
+const usernameSeparators = "_-."
+
+// normalizeUsername returns the case-insensitive form of a username that
+// must be unique across users. A Caser keeps state, so each call gets its own.
+func normalizeUsername(username string) string {
+	return cases.Fold().String(norm.NFKC.String(username))
+}
+
+// usernameSkeleton returns the form of a username that look-alike names
+// share, such as "Alice", "ALICE" and "аlice" with a Cyrillic а. No two
+// users may have the same skeleton. Only case and letters of other scripts
+// are folded, so ordinary names like "mike" and "mlke" stay apart.
+func usernameSkeleton(username string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKC.String(username) {
+		// uppercase letters can resemble something other than their
+		// lowercase form, like Greek Ν and ν
+		mapped, ok := usernameConfusables[r]
+		if !ok {
+			r = unicode.ToLower(r)
+			if mapped, ok = usernameConfusables[r]; !ok {
+				mapped = r
+			}
+		}
+		b.WriteRune(mapped)
+	}
+	return b.String()
+}
+
+// UsernamePolicy decides which usernames players may register
+type UsernamePolicy struct {
+	contains []string        // skeletons no username may contain
+	exact    map[string]bool // skeletons no username or part of one may be
+	allowed  []string        // skeletons of innocent words a contains entry turns up in
+	reserved map[string]bool // skeletons of reservedUsernames
+}
+
+// ParseUsernameBlocklist builds a policy from a blocklist file: one word per
+// line, # for comments, a leading = for words that are only rejected as a
+// whole word, meaning the whole name or a part of it between separators, and
+// a leading ! for innocent words that are allowed even though a blocked word
+// turns up inside them. Reserved names are always added.
+func ParseUsernameBlocklist(data []byte) (*UsernamePolicy, error) {
+	policy := &UsernamePolicy{exact: make(map[string]bool), reserved: make(map[string]bool)}
+	for _, name := range reservedUsernames {
+		policy.reserved[textSkeleton(name)] = true
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		marker := word[0]
+		word = textSkeleton(strings.TrimLeft(word, "=!"))
+		if word == "" {
+			return nil, fmt.Errorf("username blocklist line %d has no word", line)
+		}
+		switch marker {
+		case '=':
+			policy.exact[word] = true
+		case '!':
+			policy.allowed = append(policy.allowed, word)
+		default:
+			policy.contains = append(policy.contains, word)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read username blocklist: %v", err)
+	}
+
+	return policy, nil
+}
+
+var (
+	usernamePolicyOnce sync.Once
+	usernamePolicy     *UsernamePolicy
+	usernamePolicyErr  error
+)
+
+// LoadUsernamePolicy returns the username policy, read once from the
+// blocklist file named by USERNAME_BLOCKLIST_PATH or from the blocklist
+// embedded in the binary
+func LoadUsernamePolicy() (*UsernamePolicy, error) {
+	usernamePolicyOnce.Do(func() {
+		data := defaultUsernameBlocklist
+		if path := os.Getenv("USERNAME_BLOCKLIST_PATH"); path != "" {
+			data, usernamePolicyErr = os.ReadFile(path)
+			if usernamePolicyErr != nil {
+				usernamePolicyErr = fmt.Errorf("failed to read username blocklist: %v", usernamePolicyErr)
+				return
+			}
+		}
+		usernamePolicy, usernamePolicyErr = ParseUsernameBlocklist(data)
+	})
+	return usernamePolicy, usernamePolicyErr
+}
+
+// Check validates a username and returns the form it is stored in, which is
+// its NFKC normalization. Names are 3 to 20 letters, digits and single
+// separators (_ - .) between them, in one script, and neither reserved nor
+// blocked.
+func (p *UsernamePolicy) Check(username string) (string, error) {
+	username = norm.NFKC.String(username)
+
+	length := utf8.RuneCountInString(username)
+	if length < minUsernameLength || length > maxUsernameLength {
+		return "", fmt.Errorf("invalid username: must be %d to %d characters", minUsernameLength, maxUsernameLength)
+	}
+
+	script := ""
+	previousSeparator := true // a name cannot start with a separator
+	for _, r := range username {
+		switch {
+		case strings.ContainsRune(usernameSeparators, r):
+			if previousSeparator {
+				return "", fmt.Errorf("invalid username: separators (_ - .) must sit between letters or digits")
+			}
+			previousSeparator = true
+			continue
+		case r >= '0' && r <= '9':
+		case unicode.IsLetter(r) || unicode.Is(unicode.M, r):
+			letterScript := runeScript(r)
+			if letterScript == "" {
+				return "", fmt.Errorf("invalid username: %q is not allowed", r)
+			}
+			if script != "" && letterScript != script {
+				return "", fmt.Errorf("invalid username: letters must all come from one script")
+			}
+			script = letterScript
+		default:
+			return "", fmt.Errorf("invalid username: %q is not allowed; use letters, digits, _ - or .", r)
+		}
+		previousSeparator = false
+	}
+	if previousSeparator {
+		return "", fmt.Errorf("invalid username: separators (_ - .) must sit between letters or digits")
+	}
+
+	if p.reserved[textSkeleton(username)] || p.blocks(username) {
+		return "", fmt.Errorf("invalid username: '%s' is reserved or not allowed", username)
+	}
+
+	return username, nil
+}
+
+// textSkeleton is the looser skeleton the blocklist matches against: the
+// username skeleton with look-alike digits and letters folded too, and
+// everything but letters and digits dropped, so "s_h_1_t" and "S H I T" both
+// become "shlt"
+func textSkeleton(text string) string {
+	skeleton := strings.Map(func(r rune) rune {
+		if mapped, ok := blocklistConfusables[r]; ok {
+			return mapped
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, usernameSkeleton(text))
+	return blocklistConfusableSequences.Replace(skeleton)
+}
+
+// blocks reports whether a single word is or contains a blocked word,
+// ignoring case, look-alikes and anything between the letters. Whole-word
+// entries also match one part of the word between separators, with any
+// digits at its ends left off, so "nazi_88" is caught but "Ignazio" is not.
+func (p *UsernamePolicy) blocks(word string) bool {
+	skeleton := textSkeleton(word)
+	if p.exact[skeleton] {
+		return true
+	}
+	parts := strings.FieldsFunc(word, func(r rune) bool { return strings.ContainsRune(usernameSeparators, r) })
+	for _, part := range parts {
+		if p.exact[textSkeleton(part)] || p.exact[textSkeleton(strings.Trim(part, "0123456789"))] {
+			return true
+		}
+	}
+	// allowed words are blanked out first, so that "Scunthorpe" passes but
+	// a blocked word next to one does not
+	for _, allowed := range p.allowed {
+		skeleton = strings.ReplaceAll(skeleton, allowed, " ")
+	}
+	for _, blocked := range p.contains {
+		if strings.Contains(skeleton, blocked) {
+			return true
+		}
+	}
+	return false
+}
+
+// BlocksText reports whether free text, such as a display name or bio, is a
+// reserved name as a whole or has a blocked word in it. Words are checked
+// one at a time so that innocent neighbours like "push it" do not run
+// together.
+func (p *UsernamePolicy) BlocksText(text string) bool {
+	if p.reserved[textSkeleton(text)] {
+		return true
+	}
+	for _, word := range strings.Fields(text) {
+		if p.blocks(word) {
+			return true
+		}
+	}
+	return false
+}
+
+// runeScript returns which of the username scripts a letter or mark belongs
+// to. Marks shared by every script, such as a combining accent left over
+// after normalization, belong to none.
+func runeScript(r rune) string {
+	for _, script := range usernameScripts {
+		for _, table := range script.tables {
+			if unicode.Is(table, r) {
+				return script.name
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUsernamePolicy_Check(t *testing.T) {
+	policy, err := ParseUsernameBlocklist(defaultUsernameBlocklist)
+	if err != nil {
+		t.Fatalf("Failed to parse blocklist: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		want     string // stored form; empty when the name is rejected
+	}{
+		{"Success - plain", "gamer123", "gamer123"},
+		{"Success - separators", "troll_1.x-y", "troll_1.x-y"},
+		{"Success - Cyrillic", "Борис", "Борис"},
+		{"Success - Japanese", "さくら桜", "さくら桜"},
+		{"Success - normalized fullwidth", "ｇａｍｅｒ", "gamer"},
+		{"Success - blocked word inside an unrelated one", "torpedo", "torpedo"},
+		{"Success - allowed name with a blocked word inside", "Ignazio", "Ignazio"},
+		{"Success - allowed place name with a blocked word inside", "Scunthorpe", "Scunthorpe"},
+		{"Success - allowed word with a blocked word inside", "therapist", "therapist"},
+		{"Error - too short", "ab", ""},
+		{"Error - too long", strings.Repeat("a", 21), ""},
+		{"Error - space", "bad name", ""},
+		{"Error - emoji", "rock🪨", ""},
+		{"Error - leading separator", "_lead", ""},
+		{"Error - doubled separator", "a__b", ""},
+		{"Error - mixed scripts", "pаypal", ""}, // Cyrillic а
+		{"Error - reserved", "Admin", ""},
+		{"Error - reserved look-alike", "adm1n", ""},
+		{"Error - blocked word", "xXshitXx", ""},
+		{"Error - blocked word split by separators", "s_h_i_t", ""},
+		{"Error - exact blocked word", "kkk", ""},
+		{"Error - blocked word inside a name", "cuntface", ""},
+		{"Error - blocked word next to an allowed one", "IgnazioNazi", ""},
+		{"Error - look-alike blocked word", "Naz1lover", ""},
+		{"Error - whole-word entry between separators", "the_kkk.lol", ""},
+		{"Error - whole-word entry with digits run on", "kkk88", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := policy.Check(tt.username)
+			if tt.want == "" {
+				if err == nil || !strings.Contains(err.Error(), "invalid username") {
+					t.Errorf("Expected invalid username error for %q, got %q, %v", tt.username, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected %q to be allowed, got %v", tt.username, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected stored form %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUsernameSkeleton(t *testing.T) {
+	for _, lookalike := range []string{"аlice", "ALICE", "АLIСЕ", "ａｌｉｃｅ"} {
+		if usernameSkeleton(lookalike) != usernameSkeleton("alice") {
+			t.Errorf("Expected %q to share a skeleton with alice, got %q", lookalike, usernameSkeleton(lookalike))
+		}
+	}
+	// names that only differ in letters of one script are different names
+	for _, pair := range [][2]string{{"mike", "mlke"}, {"kim", "klm"}, {"burn", "bum"}, {"vvv", "wv"}, {"bob", "bib"}} {
+		if usernameSkeleton(pair[0]) == usernameSkeleton(pair[1]) {
+			t.Errorf("Expected %q and %q to have distinct skeletons", pair[0], pair[1])
+		}
+	}
+	// the blocklist still reads them alike
+	if textSkeleton("rnoney") != textSkeleton("money") || textSkeleton("AIice") != textSkeleton("alice") {
+		t.Errorf("Expected the blocklist skeleton to fold rn and I")
+	}
+}
+
+func TestUserService_CreateUser_UsernameUniqueness(t *testing.T) {
+	db := setupServiceTestDB(t)
+	defer db.Close()
+
+	userService := NewUserService(db)
+	if _, err := userService.CreateUser("Alice"); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	t.Run("Error - same name in another case", func(t *testing.T) {
+		_, err := userService.CreateUser("aLiCe")
+		if err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("Expected already exists error, got %v", err)
+		}
+	})
+
+	t.Run("Error - look-alike name", func(t *testing.T) {
+		_, err := userService.CreateUser("аӏісе") // all Cyrillic
+		if err == nil || !strings.Contains(err.Error(), "too similar") {
+			t.Errorf("Expected too similar error, got %v", err)
+		}
+	})
+
+	t.Run("Success - same-script names that look alike are different users", func(t *testing.T) {
+		if _, err := userService.CreateUser("AIice"); err != nil {
+			t.Errorf("Expected AIice to be allowed next to Alice, got %v", err)
+		}
+	})
+
+	t.Run("Success - backfill keys legacy users and reports clashes", func(t *testing.T) {
+		// written directly, as before usernames had keys
+		if _, err := db.Exec(`INSERT INTO users (username) VALUES ('bob'), ('BOB'), ('carol')`); err != nil {
+			t.Fatalf("Failed to insert legacy users: %v", err)
+		}
+
+		updated, clashes, err := userService.BackfillUsernameKeys()
+		if err != nil {
+			t.Fatalf("Failed to backfill: %v", err)
+		}
+		if updated != 2 {
+			t.Errorf("Expected bob and carol to be keyed, got %d", updated)
+		}
+		if len(clashes) != 1 || clashes[0] != "BOB" {
+			t.Errorf("Expected BOB to clash, got %v", clashes)
+		}
+
+		if _, err := userService.CreateUser("Carol"); err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("Expected backfilled carol to block Carol, got %v", err)
+		}
+	})
+	t.Run("Success - backfill replaces skeletons stored under the old rule", func(t *testing.T) {
+		mike, err := userService.CreateUser("mike")
+		if err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		// skeletons used to fold i into l
+		if _, err := db.Exec(`UPDATE users SET username_skeleton = 'mlke' WHERE id = ?`, mike.ID); err != nil {
+			t.Fatalf("Failed to store old skeleton: %v", err)
+		}
+
+		if updated, _, err := userService.BackfillUsernameKeys(); err != nil || updated != 1 {
+			t.Fatalf("Expected mike to be rekeyed, got %d, %v", updated, err)
+		}
+		if _, err := userService.CreateUser("mlke"); err != nil {
+			t.Errorf("Expected mlke to be allowed next to mike, got %v", err)
+		}
+	})
+}
+
+func TestUsernamePolicy_BlocksText(t *testing.T) {
+	policy, err := ParseUsernameBlocklist(defaultUsernameBlocklist)
+	if err != nil {
+		t.Fatalf("Failed to parse blocklist: %v", err)
+	}
+
+	for text, want := range map[string]bool{
+		"Push it to the limit":    false,
+		"Player One":              false,
+		"what the fuck":           true,
+		"S H I T":                 false, // single letters are separate words
+		"s_h_i_t happens":         true,
+		"Administrator":           true,
+		"Official Admin Team":     false,
+		"Ignazio from Scunthorpe": false,
+		"Therapist by day":        false,
+		"nazi scum":               true,
+	} {
+		if got := policy.BlocksText(text); got != want {
+			t.Errorf("BlocksText(%q) = %v, want %v", text, got, want)
+		}
+	}
+}